@@ -0,0 +1,185 @@
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// Workload represents a container about to be created, described independently of the compose file,
+// the Docker API request or the Kubernetes manifest defining it
+type Workload struct {
+	// Name of the compose service, of the container or of the Kubernetes resource and container
+	Name        string
+	Image       string
+	Labels      map[string]string
+	CPULimit    bool
+	MemoryLimit bool
+	HostNetwork bool
+}
+
+// Violation represents a rule of a policy which is not respected by a workload
+type Violation struct {
+	Policy   string `json:"Policy"`
+	Workload string `json:"Workload"`
+	Message  string `json:"Message"`
+}
+
+// ViolationError is returned when the workloads do not respect the policies of the environment(endpoint)
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (err *ViolationError) Error() string {
+	messages := make([]string, 0, len(err.Violations))
+	for _, v := range err.Violations {
+		messages = append(messages, fmt.Sprintf("%s: %s (policy %q)", v.Workload, v.Message, v.Policy))
+	}
+
+	return "denied by the admission policies: " + strings.Join(messages, "; ")
+}
+
+// EndpointPolicies returns the enabled policies attached to the environment(endpoint) or to its group
+func EndpointPolicies(dataStore dataservices.DataStore, endpoint *portainer.Endpoint) ([]portainer.AdmissionPolicy, error) {
+	policies, err := dataStore.AdmissionPolicy().AdmissionPolicies()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the admission policies")
+	}
+
+	attached := []portainer.AdmissionPolicy{}
+
+	for _, policy := range policies {
+		if policy.Enabled && isAttached(policy, endpoint) {
+			attached = append(attached, policy)
+		}
+	}
+
+	return attached, nil
+}
+
+func isAttached(policy portainer.AdmissionPolicy, endpoint *portainer.Endpoint) bool {
+	for _, id := range policy.EndpointIDs {
+		if id == endpoint.ID {
+			return true
+		}
+	}
+
+	for _, id := range policy.EndpointGroupIDs {
+		if id == endpoint.GroupID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Admit evaluates the workloads against the policies of the environment(endpoint),
+// it returns a *ViolationError when at least one rule is not respected
+func Admit(dataStore dataservices.DataStore, endpoint *portainer.Endpoint, workloads []Workload) error {
+	policies, err := EndpointPolicies(dataStore, endpoint)
+	if err != nil {
+		return err
+	}
+
+	return evaluate(policies, workloads)
+}
+
+func evaluate(policies []portainer.AdmissionPolicy, workloads []Workload) error {
+	violations := Evaluate(policies, workloads)
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// Evaluate returns the violations of the rules of the policies by the workloads
+func Evaluate(policies []portainer.AdmissionPolicy, workloads []Workload) []Violation {
+	violations := []Violation{}
+
+	for _, policy := range policies {
+		for _, workload := range workloads {
+			for _, message := range evaluateRules(policy.Rules, workload) {
+				violations = append(violations, Violation{
+					Policy:   policy.Name,
+					Workload: workload.Name,
+					Message:  message,
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+func evaluateRules(rules portainer.AdmissionPolicyRules, workload Workload) []string {
+	messages := []string{}
+
+	if len(rules.AllowedRegistries) > 0 {
+		allowed := strings.Join(rules.AllowedRegistries, ", ")
+
+		if workload.Image == "" {
+			messages = append(messages, fmt.Sprintf("the image must be pulled from an allowed registry (%s)", allowed))
+		} else if !IsRegistryAllowed(workload.Image, rules.AllowedRegistries) {
+			messages = append(messages, fmt.Sprintf("image %q is not pulled from an allowed registry (%s)", workload.Image, allowed))
+		}
+	}
+
+	missingLabels := []string{}
+	for _, label := range rules.RequiredLabels {
+		if _, ok := workload.Labels[label]; !ok {
+			missingLabels = append(missingLabels, label)
+		}
+	}
+
+	if len(missingLabels) > 0 {
+		sort.Strings(missingLabels)
+		messages = append(messages, fmt.Sprintf("missing required labels: %s", strings.Join(missingLabels, ", ")))
+	}
+
+	if rules.RequireResourceLimits {
+		if !workload.CPULimit {
+			messages = append(messages, "a CPU limit is required")
+		}
+
+		if !workload.MemoryLimit {
+			messages = append(messages, "a memory limit is required")
+		}
+	}
+
+	if rules.DenyHostNetwork && workload.HostNetwork {
+		messages = append(messages, "the host network is not allowed")
+	}
+
+	return messages
+}
+
+// IsRegistryAllowed returns true when the image is pulled from one of the registries. A registry matches
+// the fully qualified name of the image or one of its path prefixes, e.g. docker.io or registry.example.com/team.
+func IsRegistryAllowed(image string, registries []string) bool {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return false
+	}
+
+	name := named.Name()
+
+	for _, registry := range registries {
+		registry = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(registry)), "/")
+		if registry == "" {
+			continue
+		}
+
+		if name == registry || strings.HasPrefix(name, registry+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,216 @@
+package admission
+
+import (
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var strictRules = portainer.AdmissionPolicyRules{
+	AllowedRegistries:     []string{"docker.io/library", "registry.example.com"},
+	RequiredLabels:        []string{"team"},
+	RequireResourceLimits: true,
+	DenyHostNetwork:       true,
+}
+
+func Test_ComposeWorkloads(t *testing.T) {
+	is := assert.New(t)
+
+	content := []byte(`
+version: "3.8"
+services:
+  web:
+    image: nginx:${NGINX_TAG:-1.23}
+    labels:
+      team: web
+    deploy:
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 128M
+  proxy:
+    image: ${PROXY_IMAGE}
+    network_mode: host
+    cpus: 1
+    mem_limit: 64m
+`)
+
+	workloads, err := ComposeWorkloads(content, []portainer.Pair{{Name: "PROXY_IMAGE", Value: "ghcr.io/acme/proxy:1"}})
+	is.NoError(err)
+	is.Len(workloads, 2)
+
+	byName := map[string]Workload{}
+	for _, workload := range workloads {
+		byName[workload.Name] = workload
+	}
+
+	is.Equal(Workload{Name: "service web", Image: "nginx:1.23", Labels: map[string]string{"team": "web"}, CPULimit: true, MemoryLimit: true}, byName["service web"])
+	is.Equal(Workload{Name: "service proxy", Image: "ghcr.io/acme/proxy:1", Labels: map[string]string{}, CPULimit: true, MemoryLimit: true, HostNetwork: true}, byName["service proxy"])
+
+	violations := Evaluate([]portainer.AdmissionPolicy{{Name: "production", Rules: strictRules}}, workloads)
+	is.ElementsMatch([]Violation{
+		{Policy: "production", Workload: "service proxy", Message: `image "ghcr.io/acme/proxy:1" is not pulled from an allowed registry (docker.io/library, registry.example.com)`},
+		{Policy: "production", Workload: "service proxy", Message: "missing required labels: team"},
+		{Policy: "production", Workload: "service proxy", Message: "the host network is not allowed"},
+	}, violations)
+}
+
+func Test_ContainerWorkload(t *testing.T) {
+	is := assert.New(t)
+
+	workload, err := ContainerWorkload("web", []byte(`{"Image":"registry.example.com/web","Labels":{"team":"web"},"HostConfig":{"NetworkMode":"host","Memory":134217728}}`))
+	is.NoError(err)
+	is.Equal(Workload{Name: "container web", Image: "registry.example.com/web", Labels: map[string]string{"team": "web"}, MemoryLimit: true, HostNetwork: true}, workload)
+
+	violations := Evaluate([]portainer.AdmissionPolicy{{Name: "production", Rules: strictRules}}, []Workload{workload})
+	is.Equal([]Violation{
+		{Policy: "production", Workload: "container web", Message: "a CPU limit is required"},
+		{Policy: "production", Workload: "container web", Message: "the host network is not allowed"},
+	}, violations)
+
+	_, err = ContainerWorkload("", []byte("{"))
+	is.Error(err)
+}
+
+func Test_ServiceWorkload(t *testing.T) {
+	is := assert.New(t)
+
+	workload, err := ServiceWorkload([]byte(`{
+		"Name": "web",
+		"Labels": {"team": "web"},
+		"TaskTemplate": {
+			"ContainerSpec": {"Image": "nginx:1.23", "Labels": {"tier": "front"}},
+			"Resources": {"Limits": {"NanoCPUs": 500000000}},
+			"Networks": [{"Target": "host"}]
+		}
+	}`))
+	is.NoError(err)
+	is.Equal(Workload{Name: "service web", Image: "nginx:1.23", Labels: map[string]string{"team": "web", "tier": "front"}, CPULimit: true, HostNetwork: true}, workload)
+
+	violations := Evaluate([]portainer.AdmissionPolicy{{Name: "production", Rules: strictRules}}, []Workload{workload})
+	is.Equal([]Violation{
+		{Policy: "production", Workload: "service web", Message: "a memory limit is required"},
+		{Policy: "production", Workload: "service web", Message: "the host network is not allowed"},
+	}, violations)
+
+	_, err = ServiceWorkload([]byte("{"))
+	is.Error(err)
+}
+
+func Test_KubernetesWorkloads(t *testing.T) {
+	is := assert.New(t)
+
+	content := []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    team: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      hostNetwork: true
+      containers:
+        - name: nginx
+          image: nginx:1.23
+          resources:
+            limits:
+              cpu: 500m
+              memory: 128Mi
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          initContainers:
+            - name: init
+              image: busybox
+          containers:
+            - name: cleanup
+              image: registry.example.com/tools/cleanup
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+    - name: shell
+      image: alpine
+      resources:
+        limits:
+          memory: 64Mi
+`)
+
+	workloads, err := KubernetesWorkloads(content)
+	is.NoError(err)
+	is.Equal([]Workload{
+		{Name: "Deployment/web container nginx", Image: "nginx:1.23", Labels: map[string]string{"team": "web", "app": "web"}, CPULimit: true, MemoryLimit: true, HostNetwork: true},
+		{Name: "CronJob/cleanup container init", Image: "busybox", Labels: map[string]string{}},
+		{Name: "CronJob/cleanup container cleanup", Image: "registry.example.com/tools/cleanup", Labels: map[string]string{}},
+		{Name: "Pod/debug container shell", Image: "alpine", Labels: map[string]string{}, MemoryLimit: true},
+	}, workloads)
+}
+
+func Test_IsRegistryAllowed(t *testing.T) {
+	is := assert.New(t)
+
+	registries := []string{"docker.io/library", "registry.example.com/team/", "GHCR.IO"}
+
+	is.True(IsRegistryAllowed("nginx", registries))
+	is.True(IsRegistryAllowed("docker.io/library/nginx:1.23", registries))
+	is.False(IsRegistryAllowed("bitnami/nginx", registries))
+	is.True(IsRegistryAllowed("registry.example.com/team/app@sha256:2cf2c3bb4ba5e4a5c56b5b4e4d3fc5fb5b0b1c2b3e4d5f6a7b8c9d0e1f2a3b4c", registries))
+	is.False(IsRegistryAllowed("registry.example.com/team-b/app", registries))
+	is.True(IsRegistryAllowed("ghcr.io/acme/app", registries))
+	is.False(IsRegistryAllowed("not a reference", registries))
+}
+
+func Test_Admit(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	for _, policy := range []portainer.AdmissionPolicy{
+		{Name: "endpoint", Enabled: true, EndpointIDs: []portainer.EndpointID{1}, Rules: portainer.AdmissionPolicyRules{DenyHostNetwork: true}},
+		{Name: "group", Enabled: true, EndpointGroupIDs: []portainer.EndpointGroupID{2}, Rules: portainer.AdmissionPolicyRules{RequiredLabels: []string{"team"}}},
+		{Name: "disabled", EndpointIDs: []portainer.EndpointID{1}, Rules: portainer.AdmissionPolicyRules{RequireResourceLimits: true}},
+	} {
+		policy := policy
+		is.NoError(store.AdmissionPolicy().Create(&policy))
+	}
+
+	workloads := []Workload{{Name: "container web", Image: "nginx", HostNetwork: true}}
+
+	err := Admit(store, &portainer.Endpoint{ID: 1, GroupID: 1}, workloads)
+
+	var violationErr *ViolationError
+	is.True(errors.As(err, &violationErr))
+	is.Equal([]Violation{{Policy: "endpoint", Workload: "container web", Message: "the host network is not allowed"}}, violationErr.Violations)
+	is.Equal(`denied by the admission policies: container web: the host network is not allowed (policy "endpoint")`, err.Error())
+
+	err = Admit(store, &portainer.Endpoint{ID: 3, GroupID: 2}, workloads)
+	is.True(errors.As(err, &violationErr))
+	is.Equal("group", violationErr.Violations[0].Policy)
+
+	is.NoError(Admit(store, &portainer.Endpoint{ID: 4, GroupID: 1}, workloads))
+}
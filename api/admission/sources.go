@@ -0,0 +1,297 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeWorkloads returns the workloads of the services of a compose file, the variables are interpolated
+// with the environment variables of the stack. Both the version 2 and the version 3 of the compose file
+// format are supported.
+func ComposeWorkloads(content []byte, env []portainer.Pair) ([]Workload, error) {
+	services, err := stackutils.ParseComposeServices(content, env)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	workloads := make([]Workload, 0, len(services))
+
+	for _, name := range names {
+		service := services[name]
+		deploy, _ := service["deploy"].(map[string]interface{})
+		resources, _ := deploy["resources"].(map[string]interface{})
+		limits, _ := resources["limits"].(map[string]interface{})
+
+		workload := Workload{
+			Name:        fmt.Sprintf("service %s", name),
+			Labels:      map[string]string{},
+			CPULimit:    limits["cpus"] != nil || service["cpus"] != nil,
+			MemoryLimit: limits["memory"] != nil || service["mem_limit"] != nil,
+			HostNetwork: service["network_mode"] == "host",
+		}
+
+		workload.Image, _ = service["image"].(string)
+
+		for _, labels := range []interface{}{deploy["labels"], service["labels"]} {
+			for label, value := range stackutils.ComposeMapping(labels) {
+				workload.Labels[label] = value
+			}
+		}
+
+		if _, ok := stackutils.ComposeMapping(service["networks"])["host"]; ok {
+			workload.HostNetwork = true
+		}
+
+		workloads = append(workloads, workload)
+	}
+
+	return workloads, nil
+}
+
+// ContainerWorkload returns the workload of the body of a Docker container creation request
+func ContainerWorkload(name string, body []byte) (Workload, error) {
+	var container struct {
+		Image      string            `json:"Image"`
+		Labels     map[string]string `json:"Labels"`
+		HostConfig struct {
+			NetworkMode string `json:"NetworkMode"`
+			Memory      int64  `json:"Memory"`
+			NanoCPUs    int64  `json:"NanoCpus"`
+			CPUQuota    int64  `json:"CpuQuota"`
+		} `json:"HostConfig"`
+	}
+
+	err := json.Unmarshal(body, &container)
+	if err != nil {
+		return Workload{}, errors.Wrap(err, "failed to parse the container creation request")
+	}
+
+	workload := Workload{
+		Name:        "container",
+		Image:       container.Image,
+		Labels:      container.Labels,
+		CPULimit:    container.HostConfig.NanoCPUs > 0 || container.HostConfig.CPUQuota > 0,
+		MemoryLimit: container.HostConfig.Memory > 0,
+		HostNetwork: container.HostConfig.NetworkMode == "host",
+	}
+
+	if name != "" {
+		workload.Name = fmt.Sprintf("container %s", name)
+	}
+
+	if workload.Labels == nil {
+		workload.Labels = map[string]string{}
+	}
+
+	return workload, nil
+}
+
+// ServiceWorkload returns the workload of the body of a Docker Swarm service creation or update request
+func ServiceWorkload(body []byte) (Workload, error) {
+	var service struct {
+		Name         string            `json:"Name"`
+		Labels       map[string]string `json:"Labels"`
+		TaskTemplate struct {
+			ContainerSpec struct {
+				Image  string            `json:"Image"`
+				Labels map[string]string `json:"Labels"`
+			} `json:"ContainerSpec"`
+			Resources struct {
+				Limits struct {
+					NanoCPUs    int64 `json:"NanoCPUs"`
+					MemoryBytes int64 `json:"MemoryBytes"`
+				} `json:"Limits"`
+			} `json:"Resources"`
+			Networks []struct {
+				Target string `json:"Target"`
+			} `json:"Networks"`
+		} `json:"TaskTemplate"`
+		// deprecated location of the networks of the service
+		Networks []struct {
+			Target string `json:"Target"`
+		} `json:"Networks"`
+	}
+
+	err := json.Unmarshal(body, &service)
+	if err != nil {
+		return Workload{}, errors.Wrap(err, "failed to parse the service request")
+	}
+
+	workload := Workload{
+		Name:        "service",
+		Image:       service.TaskTemplate.ContainerSpec.Image,
+		Labels:      map[string]string{},
+		CPULimit:    service.TaskTemplate.Resources.Limits.NanoCPUs > 0,
+		MemoryLimit: service.TaskTemplate.Resources.Limits.MemoryBytes > 0,
+	}
+
+	if service.Name != "" {
+		workload.Name = fmt.Sprintf("service %s", service.Name)
+	}
+
+	for _, labels := range []map[string]string{service.Labels, service.TaskTemplate.ContainerSpec.Labels} {
+		for label, value := range labels {
+			workload.Labels[label] = value
+		}
+	}
+
+	for _, network := range append(service.TaskTemplate.Networks, service.Networks...) {
+		if network.Target == "host" {
+			workload.HostNetwork = true
+		}
+	}
+
+	return workload, nil
+}
+
+type kubernetesMetadata struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+type kubernetesContainer struct {
+	Name      string `yaml:"name"`
+	Image     string `yaml:"image"`
+	Resources struct {
+		Limits map[string]interface{} `yaml:"limits"`
+	} `yaml:"resources"`
+}
+
+type kubernetesPodSpec struct {
+	HostNetwork    bool                  `yaml:"hostNetwork"`
+	Containers     []kubernetesContainer `yaml:"containers"`
+	InitContainers []kubernetesContainer `yaml:"initContainers"`
+}
+
+type kubernetesPodTemplate struct {
+	Metadata kubernetesMetadata `yaml:"metadata"`
+	Spec     kubernetesPodSpec  `yaml:"spec"`
+}
+
+type kubernetesObject struct {
+	Kind     string             `yaml:"kind"`
+	Metadata kubernetesMetadata `yaml:"metadata"`
+	Spec     struct {
+		kubernetesPodSpec `yaml:",inline"`
+		// Deployment, StatefulSet, DaemonSet, ReplicaSet and Job
+		Template kubernetesPodTemplate `yaml:"template"`
+		// CronJob
+		JobTemplate struct {
+			Spec struct {
+				Template kubernetesPodTemplate `yaml:"template"`
+			} `yaml:"spec"`
+		} `yaml:"jobTemplate"`
+	} `yaml:"spec"`
+}
+
+// KubernetesWorkloads returns the workloads of the containers of the pods and of the pod templates of the manifests
+func KubernetesWorkloads(content []byte) ([]Workload, error) {
+	workloads := []Workload{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	for {
+		var object kubernetesObject
+
+		err := decoder.Decode(&object)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the manifest")
+		}
+
+		template := kubernetesPodTemplate{Spec: object.Spec.kubernetesPodSpec}
+		switch object.Kind {
+		case "Pod":
+		case "CronJob":
+			template = object.Spec.JobTemplate.Spec.Template
+		default:
+			template = object.Spec.Template
+		}
+
+		labels := map[string]string{}
+		for name, value := range object.Metadata.Labels {
+			labels[name] = value
+		}
+
+		for name, value := range template.Metadata.Labels {
+			labels[name] = value
+		}
+
+		for _, container := range append(template.Spec.InitContainers, template.Spec.Containers...) {
+			workloads = append(workloads, Workload{
+				Name:        fmt.Sprintf("%s/%s container %s", object.Kind, object.Metadata.Name, container.Name),
+				Image:       container.Image,
+				Labels:      labels,
+				CPULimit:    container.Resources.Limits["cpu"] != nil,
+				MemoryLimit: container.Resources.Limits["memory"] != nil,
+				HostNetwork: template.Spec.HostNetwork,
+			})
+		}
+	}
+
+	return workloads, nil
+}
+
+// AdmitStack evaluates the compose files of a stack against the policies of the environment(endpoint)
+func AdmitStack(dataStore dataservices.DataStore, fileService portainer.FileService, stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+	policies, err := EndpointPolicies(dataStore, endpoint)
+	if err != nil || len(policies) == 0 {
+		return err
+	}
+
+	workloads := []Workload{}
+
+	for _, file := range stackutils.GetStackFilePaths(stack, false) {
+		content, err := fileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return errors.Wrap(err, "failed to get stack file content")
+		}
+
+		fileWorkloads, err := ComposeWorkloads(content, stack.Env)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the stack file %s", file)
+		}
+
+		workloads = append(workloads, fileWorkloads...)
+	}
+
+	return evaluate(policies, workloads)
+}
+
+// AdmitKubernetesManifests evaluates the manifest files against the policies of the environment(endpoint)
+func AdmitKubernetesManifests(dataStore dataservices.DataStore, endpoint *portainer.Endpoint, manifests [][]byte) error {
+	policies, err := EndpointPolicies(dataStore, endpoint)
+	if err != nil || len(policies) == 0 {
+		return err
+	}
+
+	workloads := []Workload{}
+
+	for _, manifest := range manifests {
+		manifestWorkloads, err := KubernetesWorkloads(manifest)
+		if err != nil {
+			return err
+		}
+
+		workloads = append(workloads, manifestWorkloads...)
+	}
+
+	return evaluate(policies, workloads)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"sync"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
@@ -14,12 +15,19 @@ import (
 
 const portainerAPIKeyPrefix = "ptr_"
 
-var ErrInvalidAPIKey = errors.New("Invalid API key")
+var (
+	ErrInvalidAPIKey = errors.New("Invalid API key")
+	ErrExpiredAPIKey = errors.New("API key has expired")
+)
 
 type apiKeyService struct {
 	apiKeyRepository dataservices.APIKeyRepository
 	userRepository   dataservices.UserService
 	cache            *apiKeyCache
+
+	// mu serializes the writes of the database and the cache so that a revoked key
+	// cannot be put back in the cache by a concurrent lookup or update
+	mu sync.Mutex
 }
 
 func NewAPIKeyService(apiKeyRepository dataservices.APIKeyRepository, userRepository dataservices.UserService) *apiKeyService {
@@ -39,6 +47,12 @@ func (a *apiKeyService) HashRaw(rawKey string) []byte {
 // GenerateApiKey generates a raw API key for a user (for one-time display).
 // The generated API key is stored in the cache and database.
 func (a *apiKeyService) GenerateApiKey(user portainer.User, description string) (string, *portainer.APIKey, error) {
+	return a.GenerateScopedApiKey(user, description, portainer.APIKeyScope{})
+}
+
+// GenerateScopedApiKey generates a raw API key restricted by a scope for a user (for one-time display).
+// The generated API key is stored in the cache and database.
+func (a *apiKeyService) GenerateScopedApiKey(user portainer.User, description string, scope portainer.APIKeyScope) (string, *portainer.APIKey, error) {
 	randKey := generateRandomKey(32)
 	encodedRawAPIKey := base64.StdEncoding.EncodeToString(randKey)
 	prefixedAPIKey := portainerAPIKeyPrefix + encodedRawAPIKey
@@ -51,8 +65,12 @@ func (a *apiKeyService) GenerateApiKey(user portainer.User, description string)
 		Prefix:      prefixedAPIKey[:7],
 		DateCreated: time.Now().Unix(),
 		Digest:      hashDigest,
+		Scope:       scope,
 	}
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	err := a.apiKeyRepository.CreateAPIKey(apiKey)
 	if err != nil {
 		return "", nil, errors.Wrap(err, "Unable to create API key")
@@ -76,13 +94,30 @@ func (a *apiKeyService) GetAPIKeys(userID portainer.UserID) ([]portainer.APIKey,
 
 // GetDigestUserAndKey returns the user and api-key associated to a specified hash digest.
 // A cache lookup is performed first; if the user/api-key is not found in the cache, respective database lookups are performed.
+// ErrExpiredAPIKey is returned when the api-key has expired.
 func (a *apiKeyService) GetDigestUserAndKey(digest []byte) (portainer.User, portainer.APIKey, error) {
+	user, apiKey, err := a.getDigestUserAndKey(digest)
+	if err != nil {
+		return portainer.User{}, portainer.APIKey{}, err
+	}
+
+	if apiKey.Scope.ExpiresAt > 0 && time.Now().Unix() >= apiKey.Scope.ExpiresAt {
+		return portainer.User{}, portainer.APIKey{}, ErrExpiredAPIKey
+	}
+
+	return user, apiKey, nil
+}
+
+func (a *apiKeyService) getDigestUserAndKey(digest []byte) (portainer.User, portainer.APIKey, error) {
 	// get api key from cache if possible
 	cachedUser, cachedKey, ok := a.cache.Get(digest)
 	if ok {
 		return cachedUser, cachedKey, nil
 	}
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	apiKey, err := a.apiKeyRepository.GetAPIKeyByDigest(digest)
 	if err != nil {
 		return portainer.User{}, portainer.APIKey{}, errors.Wrap(err, "Unable to retrieve API key")
@@ -100,13 +135,25 @@ func (a *apiKeyService) GetDigestUserAndKey(digest []byte) (portainer.User, port
 }
 
 // UpdateAPIKey updates an API key and in cache and database.
+// The cache is only refreshed once the database update succeeded, a revoked key is never restored.
 func (a *apiKeyService) UpdateAPIKey(apiKey *portainer.APIKey) error {
-	user, _, err := a.GetDigestUserAndKey(apiKey.Digest)
+	user, _, err := a.getDigestUserAndKey(apiKey.Digest)
 	if err != nil {
 		return errors.Wrap(err, "Unable to retrieve API key")
 	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err = a.apiKeyRepository.UpdateAPIKey(apiKey)
+	if err != nil {
+		a.cache.Delete(apiKey.Digest)
+		return err
+	}
+
 	a.cache.Set(apiKey.Digest, user, *apiKey)
-	return a.apiKeyRepository.UpdateAPIKey(apiKey)
+
+	return nil
 }
 
 // DeleteAPIKey deletes an API key and removes the digest/api-key entry from the cache.
@@ -117,6 +164,9 @@ func (a *apiKeyService) DeleteAPIKey(apiKeyID portainer.APIKeyID) error {
 		return errors.Wrap(err, fmt.Sprintf("Unable to retrieve API key: %d", apiKeyID))
 	}
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	// delete the user/api-key from cache
 	a.cache.Delete(apiKey.Digest)
 	return a.apiKeyRepository.DeleteAPIKey(apiKeyID)
@@ -148,6 +148,15 @@ func Test_GetDigestUserAndKey(t *testing.T) {
 		is.Equal(userGot, userFromCache)
 		is.Equal(apiKeyGot, apiKeyFromCache)
 	})
+
+	t.Run("Fails to return an expired api key", func(t *testing.T) {
+		user := portainer.User{ID: 1}
+		_, apiKey, err := service.GenerateScopedApiKey(user, "test-expired", portainer.APIKeyScope{ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+		is.NoError(err)
+
+		_, _, err = service.GetDigestUserAndKey(apiKey.Digest)
+		is.ErrorIs(err, ErrExpiredAPIKey)
+	})
 }
 
 func Test_UpdateAPIKey(t *testing.T) {
@@ -235,6 +244,24 @@ func Test_DeleteAPIKey(t *testing.T) {
 		_, _, ok = service.cache.Get(apiKey.Digest)
 		is.False(ok)
 	})
+
+	t.Run("Revoked api-key is not restored by a later update", func(t *testing.T) {
+		user := portainer.User{ID: 1}
+		_, apiKey, err := service.GenerateApiKey(user, "test-1")
+		is.NoError(err)
+
+		err = service.DeleteAPIKey(apiKey.ID)
+		is.NoError(err)
+
+		apiKey.LastUsed = time.Now().UTC().Unix()
+		is.Error(service.UpdateAPIKey(apiKey))
+
+		_, _, ok := service.cache.Get(apiKey.Digest)
+		is.False(ok)
+
+		_, err = store.APIKeyRepository().GetAPIKey(apiKey.ID)
+		is.True(store.IsErrObjectNotFound(err))
+	})
 }
 
 func Test_InvalidateUserKeyCache(t *testing.T) {
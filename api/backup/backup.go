@@ -7,8 +7,10 @@ import (
 	"path/filepath"
 	"time"
 
+	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/archive"
 	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/database/sqlite"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/http/offlinegate"
@@ -81,7 +83,7 @@ func CreateBackupArchive(password string, gate *offlinegate.OfflineGate, datasto
 }
 
 func backupDb(backupDirPath string, datastore dataservices.DataStore) error {
-	backupWriter, err := os.Create(filepath.Join(backupDirPath, "portainer.db"))
+	backupWriter, err := os.Create(filepath.Join(backupDirPath, backupDbFileName(datastore)))
 	if err != nil {
 		return err
 	}
@@ -91,6 +93,18 @@ func backupDb(backupDirPath string, datastore dataservices.DataStore) error {
 	return backupWriter.Close()
 }
 
+// backupDbFileName returns the name of the database file inside the archive,
+// the SQLite databases keep their own file name so that they are restored with the right engine
+func backupDbFileName(datastore dataservices.DataStore) string {
+	if store, ok := datastore.(interface{ Connection() portainer.Connection }); ok {
+		if connection, ok := store.Connection().(*sqlite.DbConnection); ok {
+			return connection.GetDatabaseFileName()
+		}
+	}
+
+	return "portainer.db"
+}
+
 func encrypt(path string, passphrase string) (string, error) {
 	in, err := os.Open(path)
 	if err != nil {
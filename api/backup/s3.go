@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	s3DefaultRegion  = "us-east-1"
+	s3RequestTimeout = 10 * time.Minute
+)
+
+// s3Client is a minimal client of the S3 API, it supports the operations required by the backup targets
+// against AWS S3 and S3-compatible servers such as MinIO. The bucket is addressed with path-style URLs.
+type s3Client struct {
+	settings    portainer.S3BackupSettings
+	credentials aws.Credentials
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func newS3Client(settings portainer.S3BackupSettings) *s3Client {
+	if settings.Region == "" {
+		settings.Region = s3DefaultRegion
+	}
+
+	if settings.Endpoint == "" {
+		settings.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", settings.Region)
+	}
+
+	return &s3Client{
+		settings: settings,
+		credentials: aws.Credentials{
+			AccessKeyID:     settings.AccessKeyID,
+			SecretAccessKey: settings.SecretAccessKey,
+		},
+		signer:     v4.NewSigner(),
+		httpClient: &http.Client{Timeout: s3RequestTimeout},
+	}
+}
+
+// PutObject uploads the content of a file to the bucket
+func (client *s3Client) PutObject(ctx context.Context, key string, file *os.File) error {
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	req, err := client.newRequest(ctx, http.MethodPut, key, nil, file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	_, err = client.do(req, hex.EncodeToString(hash.Sum(nil)))
+
+	return err
+}
+
+// ListObjects returns the keys of the objects starting with the prefix
+func (client *s3Client) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	continuationToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		req, err := client.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := client.do(req, emptyPayloadHash())
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListBucketResult
+		err = xml.Unmarshal(body, &result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the list of objects: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// DeleteObject removes an object from the bucket
+func (client *s3Client) DeleteObject(ctx context.Context, key string) error {
+	req, err := client.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.do(req, emptyPayloadHash())
+
+	return err
+}
+
+func (client *s3Client) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(client.settings.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	endpoint.Path += "/" + client.settings.BucketName
+	if key != "" {
+		endpoint.Path += "/" + key
+	}
+	endpoint.RawQuery = query.Encode()
+
+	return http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+}
+
+// do signs and sends the request, it returns the body of the response
+func (client *s3Client) do(req *http.Request, payloadHash string) ([]byte, error) {
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	err := client.signer.SignHTTP(req.Context(), client.credentials, req, payloadHash, "s3", client.settings.Region, time.Now().UTC(), func(options *v4.SignerOptions) {
+		options.DisableURIPathEscaping = true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the S3 request: %w", err)
+	}
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var s3Err s3Error
+		if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("S3 request failed with status %d: %s: %s", resp.StatusCode, s3Err.Code, s3Err.Message)
+		}
+
+		return nil, fmt.Errorf("S3 request failed with status %d", resp.StatusCode)
+	}
+
+	return body, nil
+}
+
+func emptyPayloadHash() string {
+	hash := sha256.Sum256(nil)
+	return hex.EncodeToString(hash[:])
+}
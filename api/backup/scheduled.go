@@ -0,0 +1,345 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/offlinegate"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// ArchivePrefix is the prefix of the name of the backup archives
+const ArchivePrefix = "portainer-backup_"
+
+var errNoBackupTarget = errors.New("At least one backup target is required")
+
+// ScheduledService runs the automatic backups and copies the archives to the backup targets
+type ScheduledService struct {
+	dataStore           dataservices.DataStore
+	gate                *offlinegate.OfflineGate
+	filestorePath       string
+	scheduler           *scheduler.Scheduler
+	notificationService portainer.NotificationService
+	shutdownCtx         context.Context
+
+	mu    sync.Mutex
+	jobID string
+	runMu sync.Mutex
+}
+
+// NewScheduledService returns a new instance of ScheduledService
+func NewScheduledService(dataStore dataservices.DataStore, gate *offlinegate.OfflineGate, filestorePath string, scheduler *scheduler.Scheduler, notificationService portainer.NotificationService, shutdownCtx context.Context) *ScheduledService {
+	return &ScheduledService{
+		dataStore:           dataStore,
+		gate:                gate,
+		filestorePath:       filestorePath,
+		scheduler:           scheduler,
+		notificationService: notificationService,
+		shutdownCtx:         shutdownCtx,
+	}
+}
+
+// ValidateScheduledBackupSettings verifies that the automatic backups can be scheduled with the settings
+func ValidateScheduledBackupSettings(settings portainer.ScheduledBackupSettings) error {
+	if settings.RetentionCount < 0 {
+		return errors.New("Invalid retention count, the value must be positive")
+	}
+
+	if settings.S3.Enabled {
+		if settings.S3.BucketName == "" {
+			return errors.New("Invalid S3 bucket name")
+		}
+
+		if settings.S3.AccessKeyID == "" || settings.S3.SecretAccessKey == "" {
+			return errors.New("S3 credentials are required")
+		}
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	_, err := cron.ParseStandard(settings.CronRule)
+	if err != nil {
+		return errors.Wrap(err, "Invalid cron rule")
+	}
+
+	if settings.LocalDirectory == "" && !settings.S3.Enabled {
+		return errNoBackupTarget
+	}
+
+	return nil
+}
+
+// Start schedules the automatic backups with the saved settings
+func (service *ScheduledService) Start() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return service.SetSchedule(settings.ScheduledBackup)
+}
+
+// SetSchedule replaces the schedule of the automatic backups
+func (service *ScheduledService) SetSchedule(settings portainer.ScheduledBackupSettings) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.jobID != "" {
+		err := service.scheduler.StopJob(service.jobID)
+		if err != nil {
+			return err
+		}
+
+		service.jobID = ""
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	jobID, err := service.scheduler.StartJobWithCron(settings.CronRule, func() error {
+		service.RunBackup()
+
+		// a failed backup is reported in the status, the next runs are kept
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	service.jobID = jobID
+
+	return nil
+}
+
+// RunBackup creates a backup archive, copies it to the targets and saves the status of the run
+func (service *ScheduledService) RunBackup() portainer.ScheduledBackupStatus {
+	service.runMu.Lock()
+	defer service.runMu.Unlock()
+
+	status := portainer.ScheduledBackupStatus{
+		LastRun: time.Now().UTC().Unix(),
+	}
+
+	archiveName, err := service.runBackup()
+	if err != nil {
+		log.Error().Err(err).Msg("scheduled backup failed")
+
+		status.Error = err.Error()
+	} else {
+		log.Info().Str("archive", archiveName).Msg("scheduled backup created")
+
+		status.Success = true
+		status.LastSuccess = status.LastRun
+		status.ArchiveName = archiveName
+	}
+
+	status = service.saveStatus(status)
+	service.notify(status)
+
+	return status
+}
+
+func (service *ScheduledService) runBackup() (string, error) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve the settings")
+	}
+
+	backupSettings := settings.ScheduledBackup
+	if backupSettings.LocalDirectory == "" && !backupSettings.S3.Enabled {
+		return "", errNoBackupTarget
+	}
+
+	archivePath, err := CreateBackupArchive(backupSettings.Password, service.gate, service.dataStore, service.filestorePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the backup archive")
+	}
+	defer os.RemoveAll(filepath.Dir(archivePath))
+
+	archiveName := ArchivePrefix + filepath.Base(archivePath)
+
+	var targetErrs []string
+
+	if backupSettings.LocalDirectory != "" {
+		err := copyToDirectory(archivePath, backupSettings.LocalDirectory, archiveName, backupSettings.RetentionCount)
+		if err != nil {
+			targetErrs = append(targetErrs, fmt.Sprintf("local directory: %s", err))
+		}
+	}
+
+	if backupSettings.S3.Enabled {
+		err := service.uploadToS3(archivePath, backupSettings.S3, archiveName, backupSettings.RetentionCount)
+		if err != nil {
+			targetErrs = append(targetErrs, fmt.Sprintf("S3 bucket: %s", err))
+		}
+	}
+
+	if len(targetErrs) > 0 {
+		return "", fmt.Errorf("failed to copy the backup archive to the targets: %s", strings.Join(targetErrs, "; "))
+	}
+
+	return archiveName, nil
+}
+
+// copyToDirectory copies the archive to a directory and removes the oldest archives beyond the retention count
+func copyToDirectory(archivePath, directory, archiveName string, retentionCount int) error {
+	err := os.MkdirAll(directory, 0700)
+	if err != nil {
+		return err
+	}
+
+	err = copyArchive(archivePath, filepath.Join(directory, archiveName))
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return err
+	}
+
+	var archives []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), ArchivePrefix) {
+			archives = append(archives, entry.Name())
+		}
+	}
+
+	for _, name := range expiredArchives(archives, retentionCount) {
+		err := os.Remove(filepath.Join(directory, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyArchive(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// uploadToS3 uploads the archive to the bucket and removes the oldest archives beyond the retention count
+func (service *ScheduledService) uploadToS3(archivePath string, settings portainer.S3BackupSettings, archiveName string, retentionCount int) error {
+	ctx := context.Background()
+	if service.shutdownCtx != nil {
+		ctx = service.shutdownCtx
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	client := newS3Client(settings)
+
+	err = client.PutObject(ctx, settings.Prefix+archiveName, file)
+	if err != nil {
+		return err
+	}
+
+	keys, err := client.ListObjects(ctx, settings.Prefix+ArchivePrefix)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range expiredArchives(keys, retentionCount) {
+		err := client.DeleteObject(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expiredArchives returns the archives beyond the retention count, the names of the archives
+// contain their creation date so the oldest archives come first in lexical order
+func expiredArchives(archives []string, retentionCount int) []string {
+	if retentionCount <= 0 || len(archives) <= retentionCount {
+		return nil
+	}
+
+	sort.Strings(archives)
+
+	return archives[:len(archives)-retentionCount]
+}
+
+// saveStatus saves the status of the run in the settings, the last success is kept when the run failed
+func (service *ScheduledService) saveStatus(status portainer.ScheduledBackupStatus) portainer.ScheduledBackupStatus {
+	err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		settings, err := tx.Settings().Settings()
+		if err != nil {
+			return err
+		}
+
+		if !status.Success {
+			status.LastSuccess = settings.ScheduledBackup.Status.LastSuccess
+			status.ArchiveName = settings.ScheduledBackup.Status.ArchiveName
+		}
+
+		settings.ScheduledBackup.Status = status
+
+		return tx.Settings().UpdateSettings(settings)
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to save the status of the scheduled backup")
+	}
+
+	return status
+}
+
+func (service *ScheduledService) notify(status portainer.ScheduledBackupStatus) {
+	if service.notificationService == nil {
+		return
+	}
+
+	event := portainer.NotificationEvent{
+		Type:         portainer.NotificationEventBackupSuccess,
+		Timestamp:    status.LastRun,
+		ResourceName: status.ArchiveName,
+		Message:      "Scheduled backup created",
+	}
+
+	if !status.Success {
+		event.Type = portainer.NotificationEventBackupFailure
+		event.ResourceName = ""
+		event.Message = status.Error
+	}
+
+	service.notificationService.Notify(event)
+}
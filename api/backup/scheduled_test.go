@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/offlinegate"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3Server is a stand-in for an S3-compatible server storing the objects of a single bucket in memory
+type fakeS3Server struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+func (s *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access-key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/"+s.bucket)
+	key = strings.TrimPrefix(key, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.objects[key] = body
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		var result s3ListBucketResult
+		for k := range s.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				result.Contents = append(result.Contents, struct {
+					Key string `xml:"Key"`
+				}{Key: k})
+			}
+		}
+
+		xml.NewEncoder(w).Encode(result)
+	}
+}
+
+func (s *fakeS3Server) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func Test_expiredArchives(t *testing.T) {
+	is := assert.New(t)
+
+	archives := []string{
+		"portainer-backup_2023-01-03_00-00-00.tar.gz",
+		"portainer-backup_2023-01-01_00-00-00.tar.gz",
+		"portainer-backup_2023-01-02_00-00-00.tar.gz",
+	}
+
+	is.Equal([]string{"portainer-backup_2023-01-01_00-00-00.tar.gz"}, expiredArchives(archives, 2))
+	is.Empty(expiredArchives(archives, 3))
+	is.Empty(expiredArchives(archives, 0), "all the archives should be kept without retention")
+}
+
+func Test_ValidateScheduledBackupSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateScheduledBackupSettings(portainer.ScheduledBackupSettings{}))
+	is.Error(ValidateScheduledBackupSettings(portainer.ScheduledBackupSettings{Enabled: true, CronRule: "invalid", LocalDirectory: "/backups"}))
+	is.Error(ValidateScheduledBackupSettings(portainer.ScheduledBackupSettings{Enabled: true, CronRule: "@daily"}))
+	is.Error(ValidateScheduledBackupSettings(portainer.ScheduledBackupSettings{Enabled: true, CronRule: "@daily", S3: portainer.S3BackupSettings{Enabled: true, BucketName: "backups"}}))
+	is.NoError(ValidateScheduledBackupSettings(portainer.ScheduledBackupSettings{Enabled: true, CronRule: "0 2 * * *", LocalDirectory: "/backups"}))
+}
+
+func Test_RunBackup(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	defer teardown()
+
+	s3Server := &fakeS3Server{bucket: "backups", objects: map[string][]byte{
+		"portainer/" + ArchivePrefix + "2000-01-01_00-00-00.tar.gz": []byte("expired"),
+		"portainer/other-file": []byte("kept"),
+	}}
+	server := httptest.NewServer(s3Server)
+	defer server.Close()
+
+	localDirectory := t.TempDir()
+	is.NoError(os.WriteFile(filepath.Join(localDirectory, ArchivePrefix+"2000-01-01_00-00-00.tar.gz"), []byte("expired"), 0600))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+
+	settings.ScheduledBackup = portainer.ScheduledBackupSettings{
+		Enabled:        true,
+		CronRule:       "@daily",
+		RetentionCount: 1,
+		LocalDirectory: localDirectory,
+		S3: portainer.S3BackupSettings{
+			Enabled:         true,
+			Endpoint:        server.URL,
+			BucketName:      "backups",
+			Prefix:          "portainer/",
+			AccessKeyID:     "access-key",
+			SecretAccessKey: "secret-key",
+		},
+	}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	service := NewScheduledService(store, offlinegate.NewOfflineGate(), t.TempDir(), nil, nil, nil)
+
+	status := service.RunBackup()
+	is.True(status.Success, status.Error)
+	is.True(strings.HasPrefix(status.ArchiveName, ArchivePrefix))
+
+	entries, err := os.ReadDir(localDirectory)
+	is.NoError(err)
+	is.Len(entries, 1, "the expired archive should be removed from the local directory")
+	is.Equal(status.ArchiveName, entries[0].Name())
+
+	is.Equal([]string{"portainer/other-file", "portainer/" + status.ArchiveName}, s3Server.keys())
+
+	settings, err = store.Settings().Settings()
+	is.NoError(err)
+	is.Equal(status, settings.ScheduledBackup.Status)
+
+	// the status of a failed run keeps the last success
+	settings.ScheduledBackup.S3.SecretAccessKey = ""
+	settings.ScheduledBackup.S3.AccessKeyID = "invalid"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	failedStatus := service.RunBackup()
+	is.False(failedStatus.Success)
+	is.Contains(failedStatus.Error, "S3 bucket")
+	is.Equal(status.LastSuccess, failedStatus.LastSuccess)
+	is.Equal(status.ArchiveName, failedStatus.ArchiveName)
+}
@@ -26,23 +26,25 @@ const (
 // It is used to start a reverse tunnel server and to manage the connection status of each tunnel
 // connected to the tunnel server.
 type Service struct {
-	serverFingerprint string
-	serverPort        string
-	tunnelDetailsMap  map[portainer.EndpointID]*portainer.TunnelDetails
-	dataStore         dataservices.DataStore
-	snapshotService   portainer.SnapshotService
-	chiselServer      *chserver.Server
-	shutdownCtx       context.Context
-	ProxyManager      *proxy.Manager
-	mu                sync.Mutex
+	serverFingerprint   string
+	serverPort          string
+	tunnelDetailsMap    map[portainer.EndpointID]*portainer.TunnelDetails
+	dataStore           dataservices.DataStore
+	snapshotService     portainer.SnapshotService
+	notificationService portainer.NotificationService
+	chiselServer        *chserver.Server
+	shutdownCtx         context.Context
+	ProxyManager        *proxy.Manager
+	mu                  sync.Mutex
 }
 
 // NewService returns a pointer to a new instance of Service
-func NewService(dataStore dataservices.DataStore, shutdownCtx context.Context) *Service {
+func NewService(dataStore dataservices.DataStore, notificationService portainer.NotificationService, shutdownCtx context.Context) *Service {
 	return &Service{
-		tunnelDetailsMap: make(map[portainer.EndpointID]*portainer.TunnelDetails),
-		dataStore:        dataStore,
-		shutdownCtx:      shutdownCtx,
+		tunnelDetailsMap:    make(map[portainer.EndpointID]*portainer.TunnelDetails),
+		dataStore:           dataStore,
+		notificationService: notificationService,
+		shutdownCtx:         shutdownCtx,
 	}
 }
 
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/portainer/libcrypto"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/internal/edge/cache"
+	"github.com/portainer/portainer/api/metrics"
 
 	"github.com/dchest/uniuri"
 )
@@ -104,12 +106,18 @@ func (service *Service) GetActiveTunnel(endpoint *portainer.Endpoint) (portainer
 func (service *Service) SetTunnelStatusToActive(endpointID portainer.EndpointID) {
 	service.mu.Lock()
 	tunnel := service.getTunnelDetails(endpointID)
+	previousStatus := tunnel.Status
 	tunnel.Status = portainer.EdgeAgentActive
 	tunnel.Credentials = ""
 	tunnel.LastActivity = time.Now()
 	service.mu.Unlock()
 
 	cache.Del(endpointID)
+
+	if previousStatus != portainer.EdgeAgentActive {
+		metrics.IncActiveTunnels()
+		service.notifyTunnelStatus(endpointID, portainer.NotificationEventTunnelConnected)
+	}
 }
 
 // SetTunnelStatusToIdle update the status of the tunnel associated to the specified environment(endpoint).
@@ -119,6 +127,7 @@ func (service *Service) SetTunnelStatusToIdle(endpointID portainer.EndpointID) {
 	service.mu.Lock()
 
 	tunnel := service.getTunnelDetails(endpointID)
+	previousStatus := tunnel.Status
 	tunnel.Status = portainer.EdgeAgentIdle
 	tunnel.Port = 0
 	tunnel.LastActivity = time.Now()
@@ -134,6 +143,11 @@ func (service *Service) SetTunnelStatusToIdle(endpointID portainer.EndpointID) {
 	service.mu.Unlock()
 
 	cache.Del(endpointID)
+
+	if previousStatus == portainer.EdgeAgentActive {
+		metrics.DecActiveTunnels()
+		service.notifyTunnelStatus(endpointID, portainer.NotificationEventTunnelDisconnected)
+	}
 }
 
 // SetTunnelStatusToRequired update the status of the tunnel associated to the specified environment(endpoint).
@@ -176,6 +190,26 @@ func (service *Service) SetTunnelStatusToRequired(endpointID portainer.EndpointI
 	return nil
 }
 
+// notifyTunnelStatus sends a tunnel connection event to the notification channels
+func (service *Service) notifyTunnelStatus(endpointID portainer.EndpointID, eventType portainer.NotificationEventType) {
+	if service.notificationService == nil {
+		return
+	}
+
+	event := portainer.NotificationEvent{
+		Type:       eventType,
+		EndpointID: endpointID,
+		ResourceID: strconv.Itoa(int(endpointID)),
+	}
+
+	endpoint, err := service.dataStore.Endpoint().Endpoint(endpointID)
+	if err == nil {
+		event.ResourceName = endpoint.Name
+	}
+
+	service.notificationService.Notify(event)
+}
+
 func generateRandomCredentials() (string, string) {
 	username := uniuri.NewLen(8)
 	password := uniuri.NewLen(8)
@@ -21,6 +21,7 @@ var (
 	errSocketOrNamedPipeNotFound     = errors.New("Unable to locate Unix socket or named pipe")
 	errInvalidSnapshotInterval       = errors.New("Invalid snapshot interval")
 	errAdminPassExcludeAdminPassFile = errors.New("Cannot use --admin-password with --admin-password-file")
+	errMigrateBoltDBRequiresSQLite   = errors.New("Cannot use --migrate-boltdb without --database-engine=sqlite")
 )
 
 // ParseFlags parse the CLI flags and return a portainer.Flags struct
@@ -50,6 +51,8 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 		SSLCert:                   kingpin.Flag("sslcert", "Path to the SSL certificate used to secure the Portainer instance").String(),
 		SSLKey:                    kingpin.Flag("sslkey", "Path to the SSL key used to secure the Portainer instance").String(),
 		Rollback:                  kingpin.Flag("rollback", "Rollback the database store to the previous version").Bool(),
+		DatabaseEngine:            kingpin.Flag("database-engine", "Database engine used to store the data").Default("boltdb").Enum("boltdb", "sqlite"),
+		MigrateBoltDB:             kingpin.Flag("migrate-boltdb", "Copy the BoltDB database of the data folder into an empty SQLite database and exit").Bool(),
 		SnapshotInterval:          kingpin.Flag("snapshot-interval", "Duration between each environment snapshot job").String(),
 		AdminPassword:             kingpin.Flag("admin-password", "Set admin password with provided hash").String(),
 		AdminPasswordFile:         kingpin.Flag("admin-password-file", "Path to the file containing the password for the admin user").String(),
@@ -63,6 +66,8 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 		SecretKeyName:             kingpin.Flag("secret-key-name", "Secret key name for encryption and will be used as /run/secrets/<secret-key-name>.").Default(defaultSecretKeyName).String(),
 		LogLevel:                  kingpin.Flag("log-level", "Set the minimum logging level to show").Default("INFO").Enum("DEBUG", "INFO", "WARN", "ERROR"),
 		LogMode:                   kingpin.Flag("log-mode", "Set the logging output mode").Default("PRETTY").Enum("PRETTY", "JSON"),
+		MetricsToken:              kingpin.Flag("metrics-token", "Bearer token allowing the Prometheus metrics to be scraped without authenticating").String(),
+		VulnerabilityScanner:      kingpin.Flag("vulnerability-scanner", "Path of the Trivy compatible CLI used to scan the images for vulnerabilities").Default("trivy").String(),
 	}
 
 	kingpin.Parse()
@@ -97,6 +102,10 @@ func (*Service) ValidateFlags(flags *portainer.CLIFlags) error {
 		return errAdminPassExcludeAdminPassFile
 	}
 
+	if *flags.MigrateBoltDB && *flags.DatabaseEngine != "sqlite" {
+		return errMigrateBoltDBRequiresSQLite
+	}
+
 	return nil
 }
 
@@ -20,6 +20,7 @@ import (
 	"github.com/portainer/portainer/api/database"
 	"github.com/portainer/portainer/api/database/boltdb"
 	"github.com/portainer/portainer/api/database/models"
+	"github.com/portainer/portainer/api/database/sqlite"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/datastore"
 	"github.com/portainer/portainer/api/demo"
@@ -42,9 +43,12 @@ import (
 	"github.com/portainer/portainer/api/kubernetes"
 	kubecli "github.com/portainer/portainer/api/kubernetes/cli"
 	"github.com/portainer/portainer/api/ldap"
+	"github.com/portainer/portainer/api/notifications"
 	"github.com/portainer/portainer/api/oauth"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/drift"
+	"github.com/portainer/portainer/api/vulnerability"
 	"github.com/portainer/portainer/pkg/featureflags"
 	"github.com/portainer/portainer/pkg/libhelm"
 
@@ -77,17 +81,22 @@ func initFileService(dataStorePath string) portainer.FileService {
 }
 
 func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService portainer.FileService, shutdownCtx context.Context) dataservices.DataStore {
-	connection, err := database.NewDatabase("boltdb", *flags.Data, secretKey)
+	connection, err := database.NewDatabase(*flags.DatabaseEngine, *flags.Data, secretKey)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed creating database connection")
 	}
 
-	if bconn, ok := connection.(*boltdb.DbConnection); ok {
-		bconn.MaxBatchSize = *flags.MaxBatchSize
-		bconn.MaxBatchDelay = *flags.MaxBatchDelay
-		bconn.InitialMmapSize = *flags.InitialMmapSize
-	} else {
-		log.Fatal().Msg("failed creating database connection: expecting a boltdb database type but a different one was received")
+	switch conn := connection.(type) {
+	case *boltdb.DbConnection:
+		conn.MaxBatchSize = *flags.MaxBatchSize
+		conn.MaxBatchDelay = *flags.MaxBatchDelay
+		conn.InitialMmapSize = *flags.InitialMmapSize
+	case *sqlite.DbConnection:
+		if *flags.MigrateBoltDB {
+			migrateBoltDBToSQLite(flags, secretKey, conn)
+		}
+	default:
+		log.Fatal().Msg("failed creating database connection: expecting a boltdb or sqlite database type but a different one was received")
 	}
 
 	store := datastore.NewStore(*flags.Data, fileService, connection)
@@ -151,6 +160,44 @@ func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService port
 	return store
 }
 
+// migrateBoltDBToSQLite copies the BoltDB database of the data folder into the SQLite database and exits
+func migrateBoltDBToSQLite(flags *portainer.CLIFlags, secretKey []byte, conn *sqlite.DbConnection) {
+	source := &boltdb.DbConnection{
+		Path:            *flags.Data,
+		EncryptionKey:   secretKey,
+		InitialMmapSize: *flags.InitialMmapSize,
+	}
+
+	if _, err := source.NeedsEncryptionMigration(); err != nil {
+		log.Fatal().Err(err).Msg("failed opening the BoltDB database")
+	}
+
+	if _, err := conn.NeedsEncryptionMigration(); err != nil {
+		log.Fatal().Err(err).Msg("failed opening the SQLite database")
+	}
+
+	if err := source.Open(); err != nil {
+		log.Fatal().Err(err).Msg("failed opening the BoltDB database")
+	}
+
+	if err := conn.Open(); err != nil {
+		log.Fatal().Err(err).Msg("failed opening the SQLite database")
+	}
+
+	if err := conn.MigrateFromBoltDB(source); err != nil {
+		log.Fatal().Err(err).Msg("failed migrating the BoltDB database to SQLite")
+	}
+
+	log.Info().
+		Str("source", source.GetDatabaseFilePath()).
+		Str("destination", conn.GetDatabaseFilePath()).
+		Msg("exiting BoltDB migration")
+
+	source.Close()
+	conn.Close()
+	os.Exit(0)
+}
+
 func initComposeStackManager(composeDeployer libstack.Deployer, reverseTunnelService portainer.ReverseTunnelService, proxyManager *proxy.Manager) portainer.ComposeStackManager {
 	composeWrapper, err := exec.NewComposeStackManager(composeDeployer, proxyManager)
 	if err != nil {
@@ -246,12 +293,13 @@ func initSnapshotService(
 	dataStore dataservices.DataStore,
 	dockerClientFactory *docker.ClientFactory,
 	kubernetesClientFactory *kubecli.ClientFactory,
+	notificationService portainer.NotificationService,
 	shutdownCtx context.Context,
 ) (portainer.SnapshotService, error) {
 	dockerSnapshotter := docker.NewSnapshotter(dockerClientFactory)
 	kubernetesSnapshotter := kubernetes.NewSnapshotter(kubernetesClientFactory)
 
-	snapshotService, err := snapshot.NewService(snapshotIntervalFromFlag, dataStore, dockerSnapshotter, kubernetesSnapshotter, shutdownCtx)
+	snapshotService, err := snapshot.NewService(snapshotIntervalFromFlag, dataStore, dockerSnapshotter, kubernetesSnapshotter, notificationService, shutdownCtx)
 	if err != nil {
 		return nil, err
 	}
@@ -428,12 +476,14 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		log.Fatal().Err(err).Msg("failed initializing key pair")
 	}
 
-	reverseTunnelService := chisel.NewService(dataStore, shutdownCtx)
+	notificationService := notifications.NewService(dataStore, shutdownCtx)
+
+	reverseTunnelService := chisel.NewService(dataStore, notificationService, shutdownCtx)
 
 	dockerClientFactory := initDockerClientFactory(digitalSignatureService, reverseTunnelService)
 	kubernetesClientFactory, err := initKubernetesClientFactory(digitalSignatureService, reverseTunnelService, dataStore, instanceID, *flags.AddrHTTPS, settings.UserSessionTimeout)
 
-	snapshotService, err := initSnapshotService(*flags.SnapshotInterval, dataStore, dockerClientFactory, kubernetesClientFactory, shutdownCtx)
+	snapshotService, err := initSnapshotService(*flags.SnapshotInterval, dataStore, dockerClientFactory, kubernetesClientFactory, notificationService, shutdownCtx)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed initializing snapshot service")
 	}
@@ -538,8 +588,22 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	}
 
 	scheduler := scheduler.NewScheduler(shutdownCtx)
-	stackDeployer := deployments.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer)
+
+	imageScanService := vulnerability.NewService(dataStore, scheduler, kubernetesClientFactory, *flags.VulnerabilityScanner)
+	err = imageScanService.Start()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to schedule the image vulnerability scans")
+	}
+
+	stackDeployer := deployments.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer, notificationService, dataStore, fileService, imageScanService)
 	deployments.StartStackSchedules(scheduler, stackDeployer, dataStore, gitService)
+	deployments.StartPendingDeploymentSweep(scheduler, dataStore)
+
+	stackDriftService := drift.NewService(dataStore, fileService, scheduler, kubernetesClientFactory)
+	err = stackDriftService.Start()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to schedule the stack drift detection")
+	}
 
 	sslDBSettings, err := dataStore.SSLSettings().Settings()
 	if err != nil {
@@ -567,6 +631,7 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	return &http.Server{
 		AuthorizationService:        authorizationService,
 		ReverseTunnelService:        reverseTunnelService,
+		NotificationService:         notificationService,
 		Status:                      applicationStatus,
 		BindAddress:                 *flags.Addr,
 		BindAddressHTTPS:            *flags.AddrHTTPS,
@@ -597,7 +662,10 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		Scheduler:                   scheduler,
 		ShutdownCtx:                 shutdownCtx,
 		ShutdownTrigger:             shutdownTrigger,
+		MetricsToken:                *flags.MetricsToken,
 		StackDeployer:               stackDeployer,
+		ImageScanService:            imageScanService,
+		StackDriftService:           stackDriftService,
 		DemoService:                 demoService,
 		UpgradeService:              upgradeService,
 		AdminCreationDone:           adminCreationDone,
@@ -12,6 +12,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+	"github.com/portainer/portainer/api/metrics"
 
 	"github.com/rs/zerolog/log"
 	bolt "go.etcd.io/bbolt"
@@ -159,6 +160,8 @@ func (connection *DbConnection) txFn(fn func(portainer.Transaction) error) func(
 
 // UpdateTx executes the given function inside a read-write transaction
 func (connection *DbConnection) UpdateTx(fn func(portainer.Transaction) error) error {
+	defer metrics.ObserveDBTransaction("update", time.Now())
+
 	if connection.MaxBatchDelay > 0 && connection.MaxBatchSize > 1 {
 		return connection.Batch(connection.txFn(fn))
 	}
@@ -168,6 +171,8 @@ func (connection *DbConnection) UpdateTx(fn func(portainer.Transaction) error) e
 
 // ViewTx executes the given function inside a read-only transaction
 func (connection *DbConnection) ViewTx(fn func(portainer.Transaction) error) error {
+	defer metrics.ObserveDBTransaction("view", time.Now())
+
 	return connection.View(connection.txFn(fn))
 }
 
@@ -0,0 +1,376 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/codec"
+	"github.com/portainer/portainer/api/metrics"
+
+	"github.com/rs/zerolog/log"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DatabaseFileName          = "portainer.sqlite"
+	EncryptedDatabaseFileName = "portainer.esqlite"
+
+	// driverName is the name under which the pure-Go SQLite driver registers itself
+	driverName = "sqlite"
+	// busyTimeout is the time a connection waits for a lock held by another connection
+	busyTimeout = 5 * time.Second
+)
+
+var (
+	ErrHaveEncryptedAndUnencrypted = errors.New("Portainer has detected both an encrypted and un-encrypted database and cannot start.  Only one database should exist")
+	ErrHaveEncryptedWithNoKey      = errors.New("The portainer database is encrypted, but no secret was loaded")
+)
+
+// The buckets of the BoltDB store are mapped to the rows of the buckets table, which also holds
+// the sequence of each bucket. The objects are stored as key/value pairs of their bucket,
+// the values use the same encoding (and encryption) as the BoltDB store.
+var schema = []string{
+	// the readers don't block the writer and the writer doesn't block the readers
+	`PRAGMA journal_mode = WAL`,
+	`CREATE TABLE IF NOT EXISTS buckets (
+		name TEXT PRIMARY KEY,
+		sequence INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS objects (
+		bucket TEXT NOT NULL REFERENCES buckets(name) ON DELETE CASCADE,
+		key BLOB NOT NULL,
+		value BLOB NOT NULL,
+		PRIMARY KEY (bucket, key)
+	) WITHOUT ROWID`,
+}
+
+type DbConnection struct {
+	Path          string
+	EncryptionKey []byte
+	isEncrypted   bool
+
+	// SQLite allows a single writer at a time, the write transactions are serialized
+	// instead of failing when the database is locked
+	writeLock sync.Mutex
+
+	*sql.DB
+}
+
+// GetDatabaseFileName get the database filename
+func (connection *DbConnection) GetDatabaseFileName() string {
+	if connection.IsEncryptedStore() {
+		return EncryptedDatabaseFileName
+	}
+
+	return DatabaseFileName
+}
+
+// GetDatabaseFilePath get the path + filename for the database file
+func (connection *DbConnection) GetDatabaseFilePath() string {
+	return path.Join(connection.Path, connection.GetDatabaseFileName())
+}
+
+// GetStorePath get the filename and path for the database file
+func (connection *DbConnection) GetStorePath() string {
+	return connection.Path
+}
+
+func (connection *DbConnection) SetEncrypted(flag bool) {
+	connection.isEncrypted = flag
+}
+
+// Return true if the database is encrypted
+func (connection *DbConnection) IsEncryptedStore() bool {
+	return connection.getEncryptionKey() != nil
+}
+
+// NeedsEncryptionMigration returns true if database encryption is enabled and
+// we have an un-encrypted DB that requires migration to an encrypted DB.
+// It follows the same cases as the BoltDB store.
+func (connection *DbConnection) NeedsEncryptionMigration() (bool, error) {
+	if connection.EncryptionKey != nil {
+		connection.SetEncrypted(true)
+	}
+
+	_, err := os.Stat(path.Join(connection.Path, DatabaseFileName))
+	haveDbFile := err == nil
+
+	_, err = os.Stat(path.Join(connection.Path, EncryptedDatabaseFileName))
+	haveEdbFile := err == nil
+
+	if haveDbFile && haveEdbFile {
+		return false, ErrHaveEncryptedAndUnencrypted
+	}
+
+	if haveDbFile && connection.EncryptionKey != nil {
+		return true, nil
+	}
+
+	if haveEdbFile && connection.EncryptionKey == nil {
+		return false, ErrHaveEncryptedWithNoKey
+	}
+
+	return false, nil
+}
+
+// Open opens and initializes the SQLite database.
+func (connection *DbConnection) Open() error {
+	log.Info().Str("filename", connection.GetDatabaseFileName()).Msg("loading PortainerDB")
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_pragma=foreign_keys(1)",
+		connection.GetDatabaseFilePath(), busyTimeout.Milliseconds())
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range schema {
+		_, err := db.Exec(statement)
+		if err != nil {
+			db.Close()
+			return fmt.Errorf("failed to initialize the database schema: %w", err)
+		}
+	}
+
+	connection.DB = db
+
+	return nil
+}
+
+// Close closes the SQLite database.
+// Safe to being called multiple times.
+func (connection *DbConnection) Close() error {
+	if connection.DB == nil {
+		return nil
+	}
+
+	err := connection.DB.Close()
+	connection.DB = nil
+
+	return err
+}
+
+// UpdateTx executes the given function inside a read-write transaction
+func (connection *DbConnection) UpdateTx(fn func(portainer.Transaction) error) error {
+	defer metrics.ObserveDBTransaction("update", time.Now())
+
+	connection.writeLock.Lock()
+	defer connection.writeLock.Unlock()
+
+	return connection.runTx(&sql.TxOptions{}, fn)
+}
+
+// ViewTx executes the given function inside a read-only transaction
+func (connection *DbConnection) ViewTx(fn func(portainer.Transaction) error) error {
+	defer metrics.ObserveDBTransaction("view", time.Now())
+
+	return connection.runTx(&sql.TxOptions{ReadOnly: true}, fn)
+}
+
+func (connection *DbConnection) runTx(opts *sql.TxOptions, fn func(portainer.Transaction) error) error {
+	tx, err := connection.BeginTx(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	err = fn(&DbTransaction{conn: connection, tx: tx})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// BackupTo backs up db to a provided writer.
+// The database is copied to a temporary file with VACUUM INTO, which doesn't block the readers.
+func (connection *DbConnection) BackupTo(w io.Writer) error {
+	backupFile, err := os.CreateTemp("", "portainer-sqlite-backup")
+	if err != nil {
+		return err
+	}
+	backupPath := backupFile.Name()
+	backupFile.Close()
+	os.Remove(backupPath)
+	defer os.Remove(backupPath)
+
+	_, err = connection.Exec("VACUUM INTO ?", backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to copy the database: %w", err)
+	}
+
+	backupFile, err = os.Open(backupPath)
+	if err != nil {
+		return err
+	}
+	defer backupFile.Close()
+
+	_, err = io.Copy(w, backupFile)
+
+	return err
+}
+
+func (connection *DbConnection) ExportRaw(filename string) error {
+	b, err := connection.ExportJSON(true)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, b, 0600)
+}
+
+// ConvertToKey returns an 8-byte big endian representation of v.
+// The keys are ordered like in the BoltDB store.
+func (connection *DbConnection) ConvertToKey(v int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b
+}
+
+// keyToString Converts a key to a string value suitable for logging
+func keyToString(b []byte) string {
+	if len(b) != 8 {
+		return string(b)
+	}
+
+	v := binary.BigEndian.Uint64(b)
+	if v <= math.MaxInt32 {
+		return fmt.Sprintf("%d", v)
+	}
+
+	return string(b)
+}
+
+func (connection *DbConnection) getEncryptionKey() []byte {
+	if !connection.isEncrypted {
+		return nil
+	}
+
+	return connection.EncryptionKey
+}
+
+// MarshalObject encodes an object to binary format
+func (connection *DbConnection) MarshalObject(object interface{}) ([]byte, error) {
+	return codec.MarshalObject(object, connection.getEncryptionKey())
+}
+
+// UnmarshalObject decodes an object from binary data
+func (connection *DbConnection) UnmarshalObject(data []byte, object interface{}) error {
+	return codec.UnmarshalObject(data, object, connection.getEncryptionKey())
+}
+
+// UnmarshalObjectWithJsoniter decodes an object from binary data using the jsoniter library
+func (connection *DbConnection) UnmarshalObjectWithJsoniter(data []byte, object interface{}) error {
+	return codec.UnmarshalObjectWithJsoniter(data, object, connection.getEncryptionKey())
+}
+
+// SetServiceName creates the bucket of a service if it doesn't exist.
+func (connection *DbConnection) SetServiceName(bucketName string) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		return tx.SetServiceName(bucketName)
+	})
+}
+
+// GetObject is a generic function used to retrieve an unmarshalled object from a database.
+func (connection *DbConnection) GetObject(bucketName string, key []byte, object interface{}) error {
+	return connection.ViewTx(func(tx portainer.Transaction) error {
+		return tx.GetObject(bucketName, key, object)
+	})
+}
+
+// UpdateObject is a generic function used to update an object inside a database.
+func (connection *DbConnection) UpdateObject(bucketName string, key []byte, object interface{}) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		return tx.UpdateObject(bucketName, key, object)
+	})
+}
+
+// UpdateObjectFunc is a generic function used to update an object safely without race conditions.
+func (connection *DbConnection) UpdateObjectFunc(bucketName string, key []byte, object any, updateFn func()) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.GetObject(bucketName, key, object)
+		if err != nil {
+			return err
+		}
+
+		updateFn()
+
+		return tx.UpdateObject(bucketName, key, object)
+	})
+}
+
+// DeleteObject is a generic function used to delete an object inside a database.
+func (connection *DbConnection) DeleteObject(bucketName string, key []byte) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		return tx.DeleteObject(bucketName, key)
+	})
+}
+
+// DeleteAllObjects delete all objects where matching() returns (id, ok).
+func (connection *DbConnection) DeleteAllObjects(bucketName string, obj interface{}, matching func(o interface{}) (id int, ok bool)) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		return tx.DeleteAllObjects(bucketName, obj, matching)
+	})
+}
+
+// GetNextIdentifier is a generic function that returns the specified bucket identifier incremented by 1.
+func (connection *DbConnection) GetNextIdentifier(bucketName string) int {
+	var identifier int
+
+	_ = connection.UpdateTx(func(tx portainer.Transaction) error {
+		identifier = tx.GetNextIdentifier(bucketName)
+		return nil
+	})
+
+	return identifier
+}
+
+// CreateObject creates a new object in the bucket, using the next bucket sequence id
+func (connection *DbConnection) CreateObject(bucketName string, fn func(uint64) (int, interface{})) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		return tx.CreateObject(bucketName, fn)
+	})
+}
+
+// CreateObjectWithId creates a new object in the bucket, using the specified id
+func (connection *DbConnection) CreateObjectWithId(bucketName string, id int, obj interface{}) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		return tx.CreateObjectWithId(bucketName, id, obj)
+	})
+}
+
+// CreateObjectWithStringId creates a new object in the bucket, using the specified id
+func (connection *DbConnection) CreateObjectWithStringId(bucketName string, id []byte, obj interface{}) error {
+	return connection.UpdateTx(func(tx portainer.Transaction) error {
+		return tx.CreateObjectWithStringId(bucketName, id, obj)
+	})
+}
+
+func (connection *DbConnection) GetAll(bucketName string, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return connection.ViewTx(func(tx portainer.Transaction) error {
+		return tx.GetAll(bucketName, obj, append)
+	})
+}
+
+func (connection *DbConnection) GetAllWithJsoniter(bucketName string, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return connection.ViewTx(func(tx portainer.Transaction) error {
+		return tx.GetAllWithJsoniter(bucketName, obj, append)
+	})
+}
+
+func (connection *DbConnection) GetAllWithKeyPrefix(bucketName string, keyPrefix []byte, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return connection.ViewTx(func(tx portainer.Transaction) error {
+		return tx.GetAllWithKeyPrefix(bucketName, keyPrefix, obj, append)
+	})
+}
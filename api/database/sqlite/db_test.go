@@ -0,0 +1,196 @@
+package sqlite
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/boltdb"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testBucketName = "test-bucket"
+
+type testStruct struct {
+	Key   string
+	Value string
+}
+
+func newTestConnection(t *testing.T, encryptionKey []byte) *DbConnection {
+	conn := &DbConnection{
+		Path:          t.TempDir(),
+		EncryptionKey: encryptionKey,
+	}
+
+	_, err := conn.NeedsEncryptionMigration()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = conn.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestTxs(t *testing.T) {
+	is := assert.New(t)
+	conn := newTestConnection(t, nil)
+
+	// Error propagation and rollback
+	err := conn.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.SetServiceName(testBucketName)
+		if err != nil {
+			return err
+		}
+
+		err = tx.CreateObjectWithId(testBucketName, 1, testStruct{Key: "rolled-back"})
+		if err != nil {
+			return err
+		}
+
+		return errors.New("this is an error")
+	})
+	is.Error(err)
+
+	err = conn.GetObject(testBucketName, conn.ConvertToKey(1), &testStruct{})
+	is.True(dataservices.IsErrObjectNotFound(err), "the transaction should be rolled back")
+
+	is.NoError(conn.SetServiceName(testBucketName))
+
+	// Create objects using the bucket sequence
+	for i := 0; i < 3; i++ {
+		err = conn.CreateObject(testBucketName, func(id uint64) (int, interface{}) {
+			return int(id), testStruct{Key: "key", Value: string(rune('a' + id))}
+		})
+		is.NoError(err)
+	}
+
+	is.Equal(4, conn.GetNextIdentifier(testBucketName))
+
+	obj := testStruct{}
+	is.NoError(conn.GetObject(testBucketName, conn.ConvertToKey(2), &obj))
+	is.Equal("c", obj.Value)
+
+	// Update an object
+	is.NoError(conn.UpdateObjectFunc(testBucketName, conn.ConvertToKey(2), &obj, func() {
+		obj.Value = "updated"
+	}))
+
+	obj = testStruct{}
+	is.NoError(conn.GetObject(testBucketName, conn.ConvertToKey(2), &obj))
+	is.Equal("updated", obj.Value)
+
+	// List the objects ordered by key
+	var values []string
+	err = conn.GetAll(testBucketName, &testStruct{}, func(o interface{}) (interface{}, error) {
+		values = append(values, o.(*testStruct).Value)
+		return &testStruct{}, nil
+	})
+	is.NoError(err)
+	is.Equal([]string{"b", "updated", "d"}, values)
+
+	// Delete the objects
+	is.NoError(conn.DeleteObject(testBucketName, conn.ConvertToKey(1)))
+	is.NoError(conn.DeleteAllObjects(testBucketName, &testStruct{}, func(o interface{}) (int, bool) {
+		return 3, o.(*testStruct).Value == "d"
+	}))
+
+	values = nil
+	err = conn.GetAll(testBucketName, &testStruct{}, func(o interface{}) (interface{}, error) {
+		values = append(values, o.(*testStruct).Value)
+		return &testStruct{}, nil
+	})
+	is.NoError(err)
+	is.Equal([]string{"updated"}, values)
+}
+
+func TestKeyPrefix(t *testing.T) {
+	is := assert.New(t)
+	conn := newTestConnection(t, nil)
+
+	is.NoError(conn.SetServiceName(testBucketName))
+
+	for _, key := range [][]byte{{1, 0xff}, {1, 0xff, 2}, {1, 0xff, 0xff}, {2}, {1, 0xfe}} {
+		is.NoError(conn.CreateObjectWithStringId(testBucketName, key, testStruct{Value: hex.EncodeToString(key)}))
+	}
+
+	var values []string
+	err := conn.GetAllWithKeyPrefix(testBucketName, []byte{1, 0xff}, &testStruct{}, func(o interface{}) (interface{}, error) {
+		values = append(values, o.(*testStruct).Value)
+		return &testStruct{}, nil
+	})
+	is.NoError(err)
+	is.Equal([]string{"01ff", "01ff02", "01ffff"}, values)
+
+	is.Equal([]byte{2}, prefixEnd([]byte{1, 0xff}))
+	is.Nil(prefixEnd([]byte{0xff}))
+}
+
+func TestEncryptedStore(t *testing.T) {
+	is := assert.New(t)
+	conn := newTestConnection(t, []byte("apassphrasewhichneedstobe32bytes"))
+
+	is.Equal(EncryptedDatabaseFileName, conn.GetDatabaseFileName())
+	is.NoError(conn.SetServiceName(testBucketName))
+	is.NoError(conn.CreateObjectWithStringId(testBucketName, []byte("key"), testStruct{Key: "key", Value: "secret"}))
+
+	var raw []byte
+	is.NoError(conn.QueryRow("SELECT value FROM objects WHERE bucket = ?", testBucketName).Scan(&raw))
+	is.False(bytes.Contains(raw, []byte("secret")), "the value should be encrypted")
+
+	obj := testStruct{}
+	is.NoError(conn.GetObject(testBucketName, []byte("key"), &obj))
+	is.Equal("secret", obj.Value)
+}
+
+func TestBackupAndMetadata(t *testing.T) {
+	is := assert.New(t)
+	conn := newTestConnection(t, nil)
+
+	is.NoError(conn.SetServiceName(testBucketName))
+	is.NoError(conn.CreateObjectWithId(testBucketName, 1, testStruct{Key: "key", Value: "value"}))
+	is.NoError(conn.RestoreMetadata(map[string]interface{}{testBucketName: float64(10)}))
+
+	metadata, err := conn.BackupMetadata()
+	is.NoError(err)
+	is.Equal(10, metadata[testBucketName])
+
+	var backup bytes.Buffer
+	is.NoError(conn.BackupTo(&backup))
+	is.True(bytes.HasPrefix(backup.Bytes(), []byte("SQLite format 3")))
+
+	export, err := conn.ExportJSON(true)
+	is.NoError(err)
+	is.Contains(string(export), `"Value": "value"`)
+}
+
+func TestMigrateFromBoltDB(t *testing.T) {
+	is := assert.New(t)
+
+	source := &boltdb.DbConnection{Path: t.TempDir()}
+	is.NoError(source.Open())
+	defer source.Close()
+
+	is.NoError(source.SetServiceName(testBucketName))
+	is.NoError(source.CreateObject(testBucketName, func(id uint64) (int, interface{}) {
+		return int(id), testStruct{Key: "key", Value: "value"}
+	}))
+
+	conn := newTestConnection(t, nil)
+	is.NoError(conn.MigrateFromBoltDB(source))
+
+	obj := testStruct{}
+	is.NoError(conn.GetObject(testBucketName, conn.ConvertToKey(1), &obj))
+	is.Equal("value", obj.Value)
+	is.Equal(2, conn.GetNextIdentifier(testBucketName))
+
+	is.ErrorIs(conn.MigrateFromBoltDB(source), ErrDatabaseNotEmpty)
+}
@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ExportJSON creates a JSON representation of the database, with the same layout as the
+// export of the BoltDB store. You can include the database's metadata or ignore it.
+func (connection *DbConnection) ExportJSON(metadata bool) ([]byte, error) {
+	log.Debug().Str("databasePath", connection.GetDatabaseFilePath()).Msg("exportJson")
+
+	backup := make(map[string]interface{})
+	if metadata {
+		meta, err := connection.BackupMetadata()
+		if err != nil {
+			log.Error().Err(err).Msg("failed exporting metadata")
+		}
+
+		backup["__metadata"] = meta
+	}
+
+	buckets := make(map[string][]interface{})
+	versions := make(map[string]string)
+
+	rows, err := connection.Query("SELECT bucket, key, value FROM objects ORDER BY bucket, key")
+	if err != nil {
+		return []byte("{}"), err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucketName string
+		var k, v []byte
+		if err := rows.Scan(&bucketName, &k, &v); err != nil {
+			return []byte("{}"), err
+		}
+
+		if bucketName == "version" {
+			versions[string(k)] = string(v)
+			continue
+		}
+
+		var obj interface{}
+		err := connection.UnmarshalObject(v, &obj)
+		if err != nil {
+			log.Error().
+				Str("bucket", bucketName).
+				Str("object", string(v)).
+				Err(err).
+				Msg("failed to unmarshal")
+
+			obj = v
+		}
+
+		buckets[bucketName] = append(buckets[bucketName], obj)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []byte("{}"), err
+	}
+
+	if len(versions) > 0 {
+		backup["version"] = versions
+	}
+
+	for bucketName, list := range buckets {
+		if bucketName == "ssl" ||
+			bucketName == "settings" ||
+			bucketName == "tunnel_server" {
+			backup[bucketName] = list[0]
+			continue
+		}
+
+		backup[bucketName] = list
+	}
+
+	return json.MarshalIndent(backup, "", "  ")
+}
+
+// BackupMetadata will return a copy of the sequence numbers for all buckets.
+func (connection *DbConnection) BackupMetadata() (map[string]interface{}, error) {
+	buckets := map[string]interface{}{}
+
+	rows, err := connection.Query("SELECT name, sequence FROM buckets")
+	if err != nil {
+		return buckets, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucketName string
+		var seqId int
+		if err := rows.Scan(&bucketName, &seqId); err != nil {
+			return buckets, err
+		}
+
+		buckets[bucketName] = seqId
+	}
+
+	return buckets, rows.Err()
+}
+
+// RestoreMetadata will restore the sequence numbers for all buckets.
+func (connection *DbConnection) RestoreMetadata(s map[string]interface{}) error {
+	connection.writeLock.Lock()
+	defer connection.writeLock.Unlock()
+
+	var err error
+
+	for bucketName, v := range s {
+		id, ok := v.(float64) // JSON ints are unmarshalled to interface as float64. See: https://pkg.go.dev/encoding/json#Decoder.Decode
+		if !ok {
+			log.Error().Str("bucket", bucketName).Msg("failed to restore metadata to bucket, skipped")
+			continue
+		}
+
+		err = setSequence(connection.DB, bucketName, int(id))
+	}
+
+	return err
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// setSequence creates a bucket if it doesn't exist and sets its sequence
+func setSequence(db execer, bucketName string, sequence int) error {
+	_, err := db.Exec("INSERT INTO buckets (name, sequence) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET sequence = excluded.sequence", bucketName, sequence)
+	return err
+}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/portainer/portainer/api/database/boltdb"
+
+	"github.com/rs/zerolog/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+var ErrDatabaseNotEmpty = errors.New("The SQLite database already contains data, the migration from BoltDB can only run on an empty database")
+
+// MigrateFromBoltDB copies the buckets of an opened BoltDB store, with their sequences, into an
+// empty SQLite database. The values are copied as-is, so both databases must use the same
+// encryption key.
+func (connection *DbConnection) MigrateFromBoltDB(source *boltdb.DbConnection) error {
+	if source.IsEncryptedStore() != connection.IsEncryptedStore() {
+		return errors.New("the BoltDB and SQLite databases must either both be encrypted or both be unencrypted")
+	}
+
+	connection.writeLock.Lock()
+	defer connection.writeLock.Unlock()
+
+	var count int
+	err := connection.QueryRow("SELECT COUNT(*) FROM objects").Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrDatabaseNotEmpty
+	}
+
+	tx, err := connection.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	err = source.View(func(boltTx *bolt.Tx) error {
+		return boltTx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			bucketName := string(name)
+
+			err := setSequence(tx, bucketName, int(bucket.Sequence()))
+			if err != nil {
+				return fmt.Errorf("failed to create the bucket %s: %w", bucketName, err)
+			}
+
+			objects := 0
+			err = bucket.ForEach(func(k, v []byte) error {
+				objects++
+
+				t := &DbTransaction{conn: connection, tx: tx}
+				return t.put(bucketName, k, v)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to copy the bucket %s: %w", bucketName, err)
+			}
+
+			log.Debug().Str("bucket", bucketName).Int("objects", objects).Msg("bucket migrated to SQLite")
+
+			return nil
+		})
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+type DbTransaction struct {
+	conn *DbConnection
+	tx   *sql.Tx
+}
+
+func (tx *DbTransaction) SetServiceName(bucketName string) error {
+	_, err := tx.tx.Exec("INSERT INTO buckets (name) VALUES (?) ON CONFLICT (name) DO NOTHING", bucketName)
+	return err
+}
+
+func (tx *DbTransaction) GetObject(bucketName string, key []byte, object interface{}) error {
+	var data []byte
+
+	err := tx.tx.QueryRow("SELECT value FROM objects WHERE bucket = ? AND key = ?", bucketName, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w (bucket=%s, key=%s)", dserrors.ErrObjectNotFound, bucketName, keyToString(key))
+	} else if err != nil {
+		return err
+	}
+
+	return tx.conn.UnmarshalObjectWithJsoniter(data, object)
+}
+
+func (tx *DbTransaction) UpdateObject(bucketName string, key []byte, object interface{}) error {
+	data, err := tx.conn.MarshalObject(object)
+	if err != nil {
+		return err
+	}
+
+	return tx.put(bucketName, key, data)
+}
+
+func (tx *DbTransaction) DeleteObject(bucketName string, key []byte) error {
+	_, err := tx.tx.Exec("DELETE FROM objects WHERE bucket = ? AND key = ?", bucketName, key)
+	return err
+}
+
+func (tx *DbTransaction) DeleteAllObjects(bucketName string, obj interface{}, matching func(o interface{}) (id int, ok bool)) error {
+	var ids []int
+
+	err := tx.forEach(bucketName, nil, func(data []byte) error {
+		err := tx.conn.UnmarshalObject(data, &obj)
+		if err != nil {
+			return err
+		}
+
+		if id, ok := matching(obj); ok {
+			ids = append(ids, id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := tx.DeleteObject(bucketName, tx.conn.ConvertToKey(id))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tx *DbTransaction) GetNextIdentifier(bucketName string) int {
+	id, err := tx.nextSequence(bucketName)
+	if err != nil {
+		log.Error().Err(err).Str("bucket", bucketName).Msg("failed to get the next identifer")
+		return 0
+	}
+
+	return id
+}
+
+func (tx *DbTransaction) CreateObject(bucketName string, fn func(uint64) (int, interface{})) error {
+	seqId, _ := tx.nextSequence(bucketName)
+	id, obj := fn(uint64(seqId))
+
+	data, err := tx.conn.MarshalObject(obj)
+	if err != nil {
+		return err
+	}
+
+	return tx.put(bucketName, tx.conn.ConvertToKey(id), data)
+}
+
+func (tx *DbTransaction) CreateObjectWithId(bucketName string, id int, obj interface{}) error {
+	data, err := tx.conn.MarshalObject(obj)
+	if err != nil {
+		return err
+	}
+
+	return tx.put(bucketName, tx.conn.ConvertToKey(id), data)
+}
+
+func (tx *DbTransaction) CreateObjectWithStringId(bucketName string, id []byte, obj interface{}) error {
+	data, err := tx.conn.MarshalObject(obj)
+	if err != nil {
+		return err
+	}
+
+	return tx.put(bucketName, id, data)
+}
+
+func (tx *DbTransaction) GetAll(bucketName string, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return tx.forEach(bucketName, nil, func(data []byte) error {
+		err := tx.conn.UnmarshalObject(data, obj)
+		if err != nil {
+			return err
+		}
+
+		obj, err = append(obj)
+		return err
+	})
+}
+
+func (tx *DbTransaction) GetAllWithJsoniter(bucketName string, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return tx.forEach(bucketName, nil, func(data []byte) error {
+		err := tx.conn.UnmarshalObjectWithJsoniter(data, obj)
+		if err != nil {
+			return err
+		}
+
+		obj, err = append(obj)
+		return err
+	})
+}
+
+func (tx *DbTransaction) GetAllWithKeyPrefix(bucketName string, keyPrefix []byte, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return tx.forEach(bucketName, keyPrefix, func(data []byte) error {
+		err := tx.conn.UnmarshalObjectWithJsoniter(data, obj)
+		if err != nil {
+			return err
+		}
+
+		obj, err = append(obj)
+		return err
+	})
+}
+
+// put inserts or replaces the value of a key
+func (tx *DbTransaction) put(bucketName string, key, data []byte) error {
+	_, err := tx.tx.Exec("INSERT INTO objects (bucket, key, value) VALUES (?, ?, ?) ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value", bucketName, key, data)
+	return err
+}
+
+// nextSequence increments and returns the sequence of a bucket
+func (tx *DbTransaction) nextSequence(bucketName string) (int, error) {
+	var sequence int
+
+	err := tx.tx.QueryRow("UPDATE buckets SET sequence = sequence + 1 WHERE name = ? RETURNING sequence", bucketName).Scan(&sequence)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("bucket %s not found", bucketName)
+	}
+
+	return sequence, err
+}
+
+// forEach calls fn with the values of a bucket ordered by key. The values are read before calling fn,
+// so that fn can run other queries in the same transaction. When keyPrefix is set, only the keys
+// starting with it are visited.
+func (tx *DbTransaction) forEach(bucketName string, keyPrefix []byte, fn func(data []byte) error) error {
+	query := "SELECT key, value FROM objects WHERE bucket = ?"
+	args := []any{bucketName}
+
+	// the keys are compared as blobs, the prefixed keys are read as a range of the index
+	if keyPrefix != nil {
+		query += " AND key >= ?"
+		args = append(args, keyPrefix)
+
+		if end := prefixEnd(keyPrefix); end != nil {
+			query += " AND key < ?"
+			args = append(args, end)
+		}
+	}
+
+	rows, err := tx.tx.Query(query+" ORDER BY key", args...)
+	if err != nil {
+		return err
+	}
+
+	var values [][]byte
+	for rows.Next() {
+		var key, value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if keyPrefix != nil && !bytes.HasPrefix(key, keyPrefix) {
+			continue
+		}
+
+		values = append(values, value)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		if err := fn(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prefixEnd returns the first key greater than all the keys starting with the prefix, or nil when there is none
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
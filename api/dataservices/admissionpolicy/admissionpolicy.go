@@ -0,0 +1,93 @@
+package admissionpolicy
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "admission_policies"
+)
+
+// Service represents a service for managing admission policy data.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// AdmissionPolicies returns an array containing all the admission policies.
+func (service *Service) AdmissionPolicies() ([]portainer.AdmissionPolicy, error) {
+	var policies = make([]portainer.AdmissionPolicy, 0)
+
+	err := service.connection.GetAll(
+		BucketName,
+		&portainer.AdmissionPolicy{},
+		func(obj interface{}) (interface{}, error) {
+			policy, ok := obj.(*portainer.AdmissionPolicy)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to AdmissionPolicy object")
+				return nil, fmt.Errorf("failed to convert to AdmissionPolicy object: %s", obj)
+			}
+
+			policies = append(policies, *policy)
+
+			return &portainer.AdmissionPolicy{}, nil
+		})
+
+	return policies, err
+}
+
+// AdmissionPolicy returns an admission policy by ID.
+func (service *Service) AdmissionPolicy(ID portainer.AdmissionPolicyID) (*portainer.AdmissionPolicy, error) {
+	var policy portainer.AdmissionPolicy
+	identifier := service.connection.ConvertToKey(int(ID))
+
+	err := service.connection.GetObject(BucketName, identifier, &policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// Create assigns an ID to a new admission policy and saves it.
+func (service *Service) Create(policy *portainer.AdmissionPolicy) error {
+	return service.connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			policy.ID = portainer.AdmissionPolicyID(id)
+			return int(policy.ID), policy
+		},
+	)
+}
+
+// UpdateAdmissionPolicy updates an admission policy.
+func (service *Service) UpdateAdmissionPolicy(ID portainer.AdmissionPolicyID, policy *portainer.AdmissionPolicy) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.UpdateObject(BucketName, identifier, policy)
+}
+
+// DeleteAdmissionPolicy deletes an admission policy.
+func (service *Service) DeleteAdmissionPolicy(ID portainer.AdmissionPolicyID) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
@@ -115,9 +115,14 @@ func (service *Service) GetAPIKey(keyID portainer.APIKeyID) (*portainer.APIKey,
 	return &key, nil
 }
 
+// UpdateAPIKey updates an existing APIKey object, a deleted key is not re-created.
 func (service *Service) UpdateAPIKey(key *portainer.APIKey) error {
 	identifier := service.connection.ConvertToKey(int(key.ID))
-	return service.connection.UpdateObject(BucketName, identifier, key)
+
+	existing := &portainer.APIKey{}
+	return service.connection.UpdateObjectFunc(BucketName, identifier, existing, func() {
+		*existing = *key
+	})
 }
 
 func (service *Service) DeleteAPIKey(ID portainer.APIKeyID) error {
@@ -0,0 +1,207 @@
+package auditlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "audit_logs"
+
+	// MaxAuditLogs is the number of audit logs kept, the oldest records are deleted first
+	MaxAuditLogs = 100000
+)
+
+var errChainBroken = errors.New("the audit log chain is broken")
+
+// Service represents a service for managing audit log data.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// AuditLogs returns an array containing all the audit logs, ordered by identifier.
+func (service *Service) AuditLogs() ([]portainer.AuditLog, error) {
+	var auditLogs = make([]portainer.AuditLog, 0)
+
+	err := service.ForEachAuditLog(func(auditLog *portainer.AuditLog) error {
+		auditLogs = append(auditLogs, *auditLog)
+		return nil
+	})
+
+	return auditLogs, err
+}
+
+// ForEachAuditLog calls fn with each audit log, ordered by identifier, without keeping them in memory. The iteration
+// stops at the first error returned by fn.
+func (service *Service) ForEachAuditLog(fn func(auditLog *portainer.AuditLog) error) error {
+	return service.connection.GetAllWithJsoniter(
+		BucketName,
+		&portainer.AuditLog{},
+		func(obj interface{}) (interface{}, error) {
+			auditLog, ok := obj.(*portainer.AuditLog)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to AuditLog object")
+				return nil, fmt.Errorf("failed to convert to AuditLog object: %s", obj)
+			}
+
+			err := fn(auditLog)
+			if err != nil {
+				return nil, err
+			}
+
+			return &portainer.AuditLog{}, nil
+		})
+}
+
+// VerifyAuditLogs checks that the audit logs were not altered since they were recorded. It returns the number of
+// verified records and the identifier of the first altered one, 0 when the chain is intact.
+func (service *Service) VerifyAuditLogs() (int, portainer.AuditLogID, error) {
+	var previous *portainer.AuditLog
+	count := 0
+	tamperedID := portainer.AuditLogID(0)
+
+	err := service.ForEachAuditLog(func(auditLog *portainer.AuditLog) error {
+		if !verifyRecord(previous, auditLog) {
+			tamperedID = auditLog.ID
+			return errChainBroken
+		}
+
+		count++
+		previous = auditLog
+
+		return nil
+	})
+	if errors.Is(err, errChainBroken) {
+		return count, tamperedID, nil
+	}
+
+	return count, 0, err
+}
+
+// AuditLog returns an audit log by ID.
+func (service *Service) AuditLog(ID portainer.AuditLogID) (*portainer.AuditLog, error) {
+	var auditLog portainer.AuditLog
+	identifier := service.connection.ConvertToKey(int(ID))
+
+	err := service.connection.GetObject(BucketName, identifier, &auditLog)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auditLog, nil
+}
+
+// Create assigns an ID to a new audit log, chains its hash to the previous record and saves it.
+func (service *Service) Create(auditLog *portainer.AuditLog) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		ID := tx.GetNextIdentifier(BucketName)
+
+		previousHash := ""
+		if ID > 1 {
+			var previous portainer.AuditLog
+			err := tx.GetObject(BucketName, service.connection.ConvertToKey(ID-1), &previous)
+			if err != nil && !errors.Is(err, dserrors.ErrObjectNotFound) {
+				return err
+			}
+
+			previousHash = previous.Hash
+		}
+
+		auditLog.ID = portainer.AuditLogID(ID)
+		auditLog.PreviousHash = previousHash
+
+		hash, err := ComputeHash(previousHash, auditLog)
+		if err != nil {
+			return err
+		}
+		auditLog.Hash = hash
+
+		err = tx.CreateObjectWithId(BucketName, ID, auditLog)
+		if err != nil {
+			return err
+		}
+
+		// the hash of the deleted records is kept in the following one, the chain is still verified from it
+		if ID > MaxAuditLogs {
+			return tx.DeleteObject(BucketName, service.connection.ConvertToKey(ID-MaxAuditLogs))
+		}
+
+		return nil
+	})
+}
+
+// ComputeHash returns the hash of an audit log chained with the hash of the record preceding it.
+// The Hash field of the audit log is ignored during the computation.
+func ComputeHash(previousHash string, auditLog *portainer.AuditLog) (string, error) {
+	record := *auditLog
+	record.Hash = ""
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal audit log: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(previousHash))
+	hash.Write(data)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// VerifyChain checks that a contiguous list of audit logs, ordered by identifier, has not been altered.
+// It returns the identifier of the first record that does not match its hash.
+func VerifyChain(auditLogs []portainer.AuditLog) (portainer.AuditLogID, bool) {
+	for i := range auditLogs {
+		var previous *portainer.AuditLog
+		if i > 0 {
+			previous = &auditLogs[i-1]
+		}
+
+		if !verifyRecord(previous, &auditLogs[i]) {
+			return auditLogs[i].ID, false
+		}
+	}
+
+	return 0, true
+}
+
+// verifyRecord checks that an audit log matches its hash and follows the previous record, nil for the first record
+// kept. Only the first record ever recorded is known not to follow any other.
+func verifyRecord(previous *portainer.AuditLog, auditLog *portainer.AuditLog) bool {
+	if previous == nil {
+		if auditLog.ID == 1 && auditLog.PreviousHash != "" {
+			return false
+		}
+	} else if auditLog.ID != previous.ID+1 || auditLog.PreviousHash != previous.Hash {
+		return false
+	}
+
+	hash, err := ComputeHash(auditLog.PreviousHash, auditLog)
+
+	return err == nil && hash == auditLog.Hash
+}
@@ -0,0 +1,150 @@
+package edgejobrun
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "edge_job_runs"
+)
+
+// Service represents a service for managing the execution history of the Edge jobs.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		service: service,
+		tx:      tx,
+	}
+}
+
+// EdgeJobRuns returns the runs of an Edge job, ordered by identifier. Only the runs of an environment(endpoint) are
+// returned when its identifier is not 0.
+func (service *Service) EdgeJobRuns(edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID) ([]portainer.EdgeJobRun, error) {
+	return edgeJobRuns(service.connection, edgeJobID, endpointID)
+}
+
+// Create assigns an ID to a new run and saves it.
+func (service *Service) Create(run *portainer.EdgeJobRun) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		return create(tx, run)
+	})
+}
+
+// DeleteEdgeJobRuns deletes all the runs of an Edge job.
+func (service *Service) DeleteEdgeJobRuns(edgeJobID portainer.EdgeJobID) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		_, err := deleteEndpointRuns(tx, edgeJobID, 0, 0)
+		return err
+	})
+}
+
+// DeleteEndpointRuns deletes the runs of an environment(endpoint) for an Edge job, except for its keep most recent
+// runs, and returns the deleted runs.
+func (service *Service) DeleteEndpointRuns(edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID, keep int) ([]portainer.EdgeJobRun, error) {
+	var deleted []portainer.EdgeJobRun
+
+	err := service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		var err error
+		deleted, err = deleteEndpointRuns(tx, edgeJobID, endpointID, keep)
+		return err
+	})
+
+	return deleted, err
+}
+
+// runKeyPrefix returns the prefix of the keys of the runs of an Edge job, or of one of its environments(endpoints)
+// when its identifier is not 0. The runs are stored under the key of their Edge job, environment and identifier,
+// so that they are read without scanning the whole bucket.
+func runKeyPrefix(edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID) []byte {
+	prefix := binary.BigEndian.AppendUint64(nil, uint64(edgeJobID))
+	if endpointID == 0 {
+		return prefix
+	}
+
+	return binary.BigEndian.AppendUint64(prefix, uint64(endpointID))
+}
+
+func runKey(run *portainer.EdgeJobRun) []byte {
+	return binary.BigEndian.AppendUint64(runKeyPrefix(run.EdgeJobID, run.EndpointID), uint64(run.ID))
+}
+
+func edgeJobRuns(tx portainer.ReadTransaction, edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID) ([]portainer.EdgeJobRun, error) {
+	var runs = make([]portainer.EdgeJobRun, 0)
+
+	err := tx.GetAllWithKeyPrefix(
+		BucketName,
+		runKeyPrefix(edgeJobID, endpointID),
+		&portainer.EdgeJobRun{},
+		func(obj interface{}) (interface{}, error) {
+			run, ok := obj.(*portainer.EdgeJobRun)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to EdgeJobRun object")
+				return nil, fmt.Errorf("failed to convert to EdgeJobRun object: %s", obj)
+			}
+
+			if run.EdgeJobID == edgeJobID && (endpointID == 0 || run.EndpointID == endpointID) {
+				runs = append(runs, *run)
+			}
+
+			return &portainer.EdgeJobRun{}, nil
+		})
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].ID < runs[j].ID
+	})
+
+	return runs, err
+}
+
+func create(tx portainer.Transaction, run *portainer.EdgeJobRun) error {
+	run.ID = portainer.EdgeJobRunID(tx.GetNextIdentifier(BucketName))
+
+	return tx.CreateObjectWithStringId(BucketName, runKey(run), run)
+}
+
+func deleteEndpointRuns(tx portainer.Transaction, edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID, keep int) ([]portainer.EdgeJobRun, error) {
+	runs, err := edgeJobRuns(tx, edgeJobID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(runs) <= keep {
+		return nil, nil
+	}
+
+	deleted := runs[:len(runs)-keep]
+	for i := range deleted {
+		err = tx.DeleteObject(BucketName, runKey(&deleted[i]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return deleted, nil
+}
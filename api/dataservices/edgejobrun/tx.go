@@ -0,0 +1,37 @@
+package edgejobrun
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+type ServiceTx struct {
+	service *Service
+	tx      portainer.Transaction
+}
+
+func (service ServiceTx) BucketName() string {
+	return BucketName
+}
+
+// EdgeJobRuns returns the runs of an Edge job, ordered by identifier. Only the runs of an environment(endpoint) are
+// returned when its identifier is not 0.
+func (service ServiceTx) EdgeJobRuns(edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID) ([]portainer.EdgeJobRun, error) {
+	return edgeJobRuns(service.tx, edgeJobID, endpointID)
+}
+
+// Create assigns an ID to a new run and saves it.
+func (service ServiceTx) Create(run *portainer.EdgeJobRun) error {
+	return create(service.tx, run)
+}
+
+// DeleteEdgeJobRuns deletes all the runs of an Edge job.
+func (service ServiceTx) DeleteEdgeJobRuns(edgeJobID portainer.EdgeJobID) error {
+	_, err := deleteEndpointRuns(service.tx, edgeJobID, 0, 0)
+	return err
+}
+
+// DeleteEndpointRuns deletes the runs of an environment(endpoint) for an Edge job, except for its keep most recent
+// runs, and returns the deleted runs.
+func (service ServiceTx) DeleteEndpointRuns(edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID, keep int) ([]portainer.EdgeJobRun, error) {
+	return deleteEndpointRuns(service.tx, edgeJobID, endpointID, keep)
+}
@@ -16,6 +16,7 @@ const BucketName = "edge_stack"
 type Service struct {
 	connection          portainer.Connection
 	idxVersion          map[portainer.EdgeStackID]int
+	idxRollout          map[portainer.EdgeStackID]*rolloutIndex
 	mu                  sync.RWMutex
 	cacheInvalidationFn func(portainer.EdgeStackID)
 }
@@ -34,6 +35,7 @@ func NewService(connection portainer.Connection, cacheInvalidationFn func(portai
 	s := &Service{
 		connection:          connection,
 		idxVersion:          make(map[portainer.EdgeStackID]int),
+		idxRollout:          make(map[portainer.EdgeStackID]*rolloutIndex),
 		cacheInvalidationFn: cacheInvalidationFn,
 	}
 
@@ -46,8 +48,8 @@ func NewService(connection portainer.Connection, cacheInvalidationFn func(portai
 		return nil, err
 	}
 
-	for _, e := range es {
-		s.idxVersion[e.ID] = e.Version
+	for i := range es {
+		s.index(es[i].ID, &es[i])
 	}
 
 	return s, nil
@@ -104,6 +106,58 @@ func (service *Service) EdgeStackVersion(ID portainer.EdgeStackID) (int, bool) {
 	return v, ok
 }
 
+// EdgeStackEndpointVersion returns the version of the given edge stack ID to deploy on an environment(endpoint), which
+// is the previous version while the environment waits for its batch of a rollout
+func (service *Service) EdgeStackEndpointVersion(ID portainer.EdgeStackID, endpointID portainer.EndpointID) (int, bool) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	return service.endpointVersion(ID, endpointID)
+}
+
+func (service *Service) endpointVersion(ID portainer.EdgeStackID, endpointID portainer.EndpointID) (int, bool) {
+	v, ok := service.idxVersion[ID]
+	if !ok {
+		return 0, false
+	}
+
+	if rollout, ok := service.idxRollout[ID]; ok && !rollout.released[endpointID] {
+		return rollout.previousVersion, true
+	}
+
+	return v, true
+}
+
+// rolloutIndex holds the environments(endpoints) released by an active rollout
+type rolloutIndex struct {
+	previousVersion int
+	released        map[portainer.EndpointID]bool
+}
+
+// index updates the in-memory indexes, it must be called with the lock held
+func (service *Service) index(ID portainer.EdgeStackID, edgeStack *portainer.EdgeStack) {
+	service.idxVersion[ID] = edgeStack.Version
+
+	rollout := edgeStack.Rollout
+	if rollout == nil || (rollout.Status != portainer.EdgeStackRolloutInProgress && rollout.Status != portainer.EdgeStackRolloutPaused) {
+		delete(service.idxRollout, ID)
+		return
+	}
+
+	idx := &rolloutIndex{
+		previousVersion: rollout.PreviousVersion,
+		released:        make(map[portainer.EndpointID]bool),
+	}
+
+	for i := 0; i < rollout.ReleasedBatches && i < len(rollout.Batches); i++ {
+		for _, endpointID := range rollout.Batches[i] {
+			idx.released[endpointID] = true
+		}
+	}
+
+	service.idxRollout[ID] = idx
+}
+
 // CreateEdgeStack saves an Edge stack object to db.
 func (service *Service) Create(id portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error {
 	edgeStack.ID = id
@@ -118,7 +172,7 @@ func (service *Service) Create(id portainer.EdgeStackID, edgeStack *portainer.Ed
 	}
 
 	service.mu.Lock()
-	service.idxVersion[id] = edgeStack.Version
+	service.index(id, edgeStack)
 	service.cacheInvalidationFn(id)
 	service.mu.Unlock()
 
@@ -137,7 +191,7 @@ func (service *Service) UpdateEdgeStack(ID portainer.EdgeStackID, edgeStack *por
 		return err
 	}
 
-	service.idxVersion[ID] = edgeStack.Version
+	service.index(ID, edgeStack)
 	service.cacheInvalidationFn(ID)
 
 	return nil
@@ -154,7 +208,7 @@ func (service *Service) UpdateEdgeStackFunc(ID portainer.EdgeStackID, updateFunc
 	return service.connection.UpdateObjectFunc(BucketName, id, edgeStack, func() {
 		updateFunc(edgeStack)
 
-		service.idxVersion[ID] = edgeStack.Version
+		service.index(ID, edgeStack)
 		service.cacheInvalidationFn(ID)
 	})
 }
@@ -177,6 +231,7 @@ func (service *Service) DeleteEdgeStack(ID portainer.EdgeStackID) error {
 	}
 
 	delete(service.idxVersion, ID)
+	delete(service.idxRollout, ID)
 
 	service.cacheInvalidationFn(ID)
 
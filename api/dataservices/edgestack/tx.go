@@ -61,6 +61,15 @@ func (service ServiceTx) EdgeStackVersion(ID portainer.EdgeStackID) (int, bool)
 	return v, ok
 }
 
+// EdgeStackEndpointVersion returns the version of the given edge stack ID to deploy on an environment(endpoint), which
+// is the previous version while the environment waits for its batch of a rollout
+func (service ServiceTx) EdgeStackEndpointVersion(ID portainer.EdgeStackID, endpointID portainer.EndpointID) (int, bool) {
+	service.service.mu.RLock()
+	defer service.service.mu.RUnlock()
+
+	return service.service.endpointVersion(ID, endpointID)
+}
+
 // CreateEdgeStack saves an Edge stack object to db.
 func (service ServiceTx) Create(id portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error {
 	edgeStack.ID = id
@@ -75,7 +84,7 @@ func (service ServiceTx) Create(id portainer.EdgeStackID, edgeStack *portainer.E
 	}
 
 	service.service.mu.Lock()
-	service.service.idxVersion[id] = edgeStack.Version
+	service.service.index(id, edgeStack)
 	service.service.cacheInvalidationFn(id)
 	service.service.mu.Unlock()
 
@@ -94,7 +103,7 @@ func (service ServiceTx) UpdateEdgeStack(ID portainer.EdgeStackID, edgeStack *po
 		return err
 	}
 
-	service.service.idxVersion[ID] = edgeStack.Version
+	service.service.index(ID, edgeStack)
 	service.service.cacheInvalidationFn(ID)
 
 	return nil
@@ -125,6 +134,7 @@ func (service ServiceTx) DeleteEdgeStack(ID portainer.EdgeStackID) error {
 	}
 
 	delete(service.service.idxVersion, ID)
+	delete(service.service.idxRollout, ID)
 
 	service.service.cacheInvalidationFn(ID)
 
@@ -0,0 +1,159 @@
+package edgestackstatusevent
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "edge_stack_status_events"
+
+	// MaxEndpointEvents is the number of events kept for each environment(endpoint) of an edge stack, the oldest
+	// events are deleted first
+	MaxEndpointEvents = 100
+)
+
+// Service represents a service for managing the status timeline of the edge stacks.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		service: service,
+		tx:      tx,
+	}
+}
+
+// EdgeStackStatusEvents returns the status events of an edge stack, ordered by identifier. Only the events of an
+// environment(endpoint) are returned when its identifier is not 0.
+func (service *Service) EdgeStackStatusEvents(edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) ([]portainer.EdgeStackStatusEvent, error) {
+	return edgeStackStatusEvents(service.connection, edgeStackID, endpointID)
+}
+
+// Create assigns an ID to a new status event and saves it, the oldest events of the environment(endpoint) are
+// deleted past MaxEndpointEvents.
+func (service *Service) Create(event *portainer.EdgeStackStatusEvent) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		return create(tx, event)
+	})
+}
+
+// DeleteEdgeStackStatusEvents deletes all the status events of an edge stack.
+func (service *Service) DeleteEdgeStackStatusEvents(edgeStackID portainer.EdgeStackID) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		return deleteStatusEvents(tx, edgeStackID, 0)
+	})
+}
+
+// DeleteEndpointStatusEvents deletes the status events of an environment(endpoint) for an edge stack.
+func (service *Service) DeleteEndpointStatusEvents(edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		return deleteStatusEvents(tx, edgeStackID, endpointID)
+	})
+}
+
+// eventKeyPrefix returns the prefix of the keys of the events of an edge stack, or of one of its environments
+// (endpoints) when its identifier is not 0. The events are stored under the key of their edge stack, environment
+// and identifier, so that they are read without scanning the whole bucket.
+func eventKeyPrefix(edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) []byte {
+	prefix := binary.BigEndian.AppendUint64(nil, uint64(edgeStackID))
+	if endpointID == 0 {
+		return prefix
+	}
+
+	return binary.BigEndian.AppendUint64(prefix, uint64(endpointID))
+}
+
+func eventKey(event *portainer.EdgeStackStatusEvent) []byte {
+	return binary.BigEndian.AppendUint64(eventKeyPrefix(event.EdgeStackID, event.EndpointID), uint64(event.ID))
+}
+
+func edgeStackStatusEvents(tx portainer.ReadTransaction, edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) ([]portainer.EdgeStackStatusEvent, error) {
+	var events = make([]portainer.EdgeStackStatusEvent, 0)
+
+	err := tx.GetAllWithKeyPrefix(
+		BucketName,
+		eventKeyPrefix(edgeStackID, endpointID),
+		&portainer.EdgeStackStatusEvent{},
+		func(obj interface{}) (interface{}, error) {
+			event, ok := obj.(*portainer.EdgeStackStatusEvent)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to EdgeStackStatusEvent object")
+				return nil, fmt.Errorf("failed to convert to EdgeStackStatusEvent object: %s", obj)
+			}
+
+			if event.EdgeStackID == edgeStackID && (endpointID == 0 || event.EndpointID == endpointID) {
+				events = append(events, *event)
+			}
+
+			return &portainer.EdgeStackStatusEvent{}, nil
+		})
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events, err
+}
+
+func create(tx portainer.Transaction, event *portainer.EdgeStackStatusEvent) error {
+	event.ID = portainer.EdgeStackStatusEventID(tx.GetNextIdentifier(BucketName))
+
+	err := tx.CreateObjectWithStringId(BucketName, eventKey(event), event)
+	if err != nil {
+		return err
+	}
+
+	events, err := edgeStackStatusEvents(tx, event.EdgeStackID, event.EndpointID)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(events)-MaxEndpointEvents; i++ {
+		err = tx.DeleteObject(BucketName, eventKey(&events[i]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteStatusEvents(tx portainer.Transaction, edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) error {
+	events, err := edgeStackStatusEvents(tx, edgeStackID, endpointID)
+	if err != nil {
+		return err
+	}
+
+	for i := range events {
+		err = tx.DeleteObject(BucketName, eventKey(&events[i]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package edgestackstatusevent
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+type ServiceTx struct {
+	service *Service
+	tx      portainer.Transaction
+}
+
+func (service ServiceTx) BucketName() string {
+	return BucketName
+}
+
+// EdgeStackStatusEvents returns the status events of an edge stack, ordered by identifier. Only the events of an
+// environment(endpoint) are returned when its identifier is not 0.
+func (service ServiceTx) EdgeStackStatusEvents(edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) ([]portainer.EdgeStackStatusEvent, error) {
+	return edgeStackStatusEvents(service.tx, edgeStackID, endpointID)
+}
+
+// Create assigns an ID to a new status event and saves it, the oldest events of the environment(endpoint) are
+// deleted past MaxEndpointEvents.
+func (service ServiceTx) Create(event *portainer.EdgeStackStatusEvent) error {
+	return create(service.tx, event)
+}
+
+// DeleteEdgeStackStatusEvents deletes all the status events of an edge stack.
+func (service ServiceTx) DeleteEdgeStackStatusEvents(edgeStackID portainer.EdgeStackID) error {
+	return deleteStatusEvents(service.tx, edgeStackID, 0)
+}
+
+// DeleteEndpointStatusEvents deletes the status events of an environment(endpoint) for an edge stack.
+func (service ServiceTx) DeleteEndpointStatusEvents(edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) error {
+	return deleteStatusEvents(service.tx, edgeStackID, endpointID)
+}
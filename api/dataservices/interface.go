@@ -13,23 +13,34 @@ import (
 type (
 	DataStoreTx interface {
 		IsErrObjectNotFound(err error) bool
+		AdmissionPolicy() AdmissionPolicyService
+		AuditLog() AuditLogService
 		CustomTemplate() CustomTemplateService
 		EdgeGroup() EdgeGroupService
 		EdgeJob() EdgeJobService
+		EdgeJobRun() EdgeJobRunService
 		EdgeStack() EdgeStackService
+		EdgeStackStatusEvent() EdgeStackStatusEventService
 		Endpoint() EndpointService
 		EndpointGroup() EndpointGroupService
 		EndpointRelation() EndpointRelationService
 		FDOProfile() FDOProfileService
 		HelmUserRepository() HelmUserRepositoryService
+		ImageScan() ImageScanService
+		NotificationChannel() NotificationChannelService
+		NotificationDelivery() NotificationDeliveryService
+		PendingDeployment() PendingDeploymentService
 		Registry() RegistryService
 		ResourceControl() ResourceControlService
 		Role() RoleService
 		APIKeyRepository() APIKeyRepository
 		Settings() SettingsService
 		Snapshot() SnapshotService
+		SnapshotHistory() SnapshotHistoryService
 		SSLSettings() SSLSettingsService
 		Stack() StackService
+		StackDrift() StackDriftService
+		StackVersion() StackVersionService
 		Tag() TagService
 		TeamMembership() TeamMembershipService
 		Team() TeamService
@@ -55,6 +66,26 @@ type (
 		DataStoreTx
 	}
 
+	// AdmissionPolicyService represents a service for managing admission policy data
+	AdmissionPolicyService interface {
+		AdmissionPolicy(ID portainer.AdmissionPolicyID) (*portainer.AdmissionPolicy, error)
+		AdmissionPolicies() ([]portainer.AdmissionPolicy, error)
+		Create(policy *portainer.AdmissionPolicy) error
+		UpdateAdmissionPolicy(ID portainer.AdmissionPolicyID, policy *portainer.AdmissionPolicy) error
+		DeleteAdmissionPolicy(ID portainer.AdmissionPolicyID) error
+		BucketName() string
+	}
+
+	// AuditLogService represents a service to manage audit logs
+	AuditLogService interface {
+		AuditLogs() ([]portainer.AuditLog, error)
+		ForEachAuditLog(fn func(auditLog *portainer.AuditLog) error) error
+		AuditLog(ID portainer.AuditLogID) (*portainer.AuditLog, error)
+		Create(auditLog *portainer.AuditLog) error
+		VerifyAuditLogs() (int, portainer.AuditLogID, error)
+		BucketName() string
+	}
+
 	// CustomTemplateService represents a service to manage custom templates
 	CustomTemplateService interface {
 		GetNextIdentifier() int
@@ -89,11 +120,21 @@ type (
 		BucketName() string
 	}
 
+	// EdgeJobRunService represents a service to manage the execution history of the Edge jobs
+	EdgeJobRunService interface {
+		EdgeJobRuns(edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID) ([]portainer.EdgeJobRun, error)
+		Create(run *portainer.EdgeJobRun) error
+		DeleteEdgeJobRuns(edgeJobID portainer.EdgeJobID) error
+		DeleteEndpointRuns(edgeJobID portainer.EdgeJobID, endpointID portainer.EndpointID, keep int) ([]portainer.EdgeJobRun, error)
+		BucketName() string
+	}
+
 	// EdgeStackService represents a service to manage Edge stacks
 	EdgeStackService interface {
 		EdgeStacks() ([]portainer.EdgeStack, error)
 		EdgeStack(ID portainer.EdgeStackID) (*portainer.EdgeStack, error)
 		EdgeStackVersion(ID portainer.EdgeStackID) (int, bool)
+		EdgeStackEndpointVersion(ID portainer.EdgeStackID, endpointID portainer.EndpointID) (int, bool)
 		Create(id portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error
 		UpdateEdgeStack(ID portainer.EdgeStackID, edgeStack *portainer.EdgeStack) error
 		UpdateEdgeStackFunc(ID portainer.EdgeStackID, updateFunc func(edgeStack *portainer.EdgeStack)) error
@@ -102,6 +143,15 @@ type (
 		BucketName() string
 	}
 
+	// EdgeStackStatusEventService represents a service to manage the status timeline of the edge stacks
+	EdgeStackStatusEventService interface {
+		EdgeStackStatusEvents(edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) ([]portainer.EdgeStackStatusEvent, error)
+		Create(event *portainer.EdgeStackStatusEvent) error
+		DeleteEdgeStackStatusEvents(edgeStackID portainer.EdgeStackID) error
+		DeleteEndpointStatusEvents(edgeStackID portainer.EdgeStackID, endpointID portainer.EndpointID) error
+		BucketName() string
+	}
+
 	// EndpointService represents a service for managing environment(endpoint) data
 	EndpointService interface {
 		Endpoint(ID portainer.EndpointID) (*portainer.Endpoint, error)
@@ -157,6 +207,15 @@ type (
 		BucketName() string
 	}
 
+	// ImageScanService represents a service for managing the vulnerability scans of the images
+	ImageScanService interface {
+		ImageScan(digest string) (*portainer.ImageScan, error)
+		ImageScans() ([]portainer.ImageScan, error)
+		UpdateImageScan(scan *portainer.ImageScan) error
+		DeleteImageScan(digest string) error
+		BucketName() string
+	}
+
 	// JWTService represents a service for managing JWT tokens
 	JWTService interface {
 		GenerateToken(data *portainer.TokenData) (string, error)
@@ -166,6 +225,35 @@ type (
 		SetUserSessionDuration(userSessionDuration time.Duration)
 	}
 
+	// NotificationChannelService represents a service for managing notification channel data
+	NotificationChannelService interface {
+		NotificationChannel(ID portainer.NotificationChannelID) (*portainer.NotificationChannel, error)
+		NotificationChannels() ([]portainer.NotificationChannel, error)
+		Create(channel *portainer.NotificationChannel) error
+		UpdateNotificationChannel(ID portainer.NotificationChannelID, channel *portainer.NotificationChannel) error
+		DeleteNotificationChannel(ID portainer.NotificationChannelID) error
+		BucketName() string
+	}
+
+	// NotificationDeliveryService represents a service for managing the delivery history of the notification channels
+	NotificationDeliveryService interface {
+		NotificationDeliveries() ([]portainer.NotificationDelivery, error)
+		NotificationDeliveriesByChannelID(channelID portainer.NotificationChannelID) ([]portainer.NotificationDelivery, error)
+		Create(delivery *portainer.NotificationDelivery) error
+		DeleteNotificationDelivery(ID portainer.NotificationDeliveryID) error
+		BucketName() string
+	}
+
+	// PendingDeploymentService represents a service for managing the stack deployments waiting for an approval
+	PendingDeploymentService interface {
+		PendingDeployment(ID portainer.PendingDeploymentID) (*portainer.PendingDeployment, error)
+		PendingDeployments() ([]portainer.PendingDeployment, error)
+		Create(deployment *portainer.PendingDeployment) error
+		UpdatePendingDeployment(ID portainer.PendingDeploymentID, deployment *portainer.PendingDeployment) error
+		DeletePendingDeployment(ID portainer.PendingDeploymentID) error
+		BucketName() string
+	}
+
 	// RegistryService represents a service for managing registry data
 	RegistryService interface {
 		Registry(ID portainer.RegistryID) (*portainer.Registry, error)
@@ -222,6 +310,14 @@ type (
 		BucketName() string
 	}
 
+	// SnapshotHistoryService represents a service for managing the snapshot history of the environments(endpoints)
+	SnapshotHistoryService interface {
+		SnapshotHistory(endpointID portainer.EndpointID) (*portainer.SnapshotHistory, error)
+		UpdateSnapshotHistory(history *portainer.SnapshotHistory) error
+		DeleteSnapshotHistory(endpointID portainer.EndpointID) error
+		BucketName() string
+	}
+
 	// SSLSettingsService represents a service for managing application settings
 	SSLSettingsService interface {
 		Settings() (*portainer.SSLSettings, error)
@@ -244,6 +340,24 @@ type (
 		BucketName() string
 	}
 
+	// StackDriftService represents a service for managing the drift reports of the stacks
+	StackDriftService interface {
+		StackDriftReport(stackID portainer.StackID) (*portainer.StackDriftReport, error)
+		StackDriftReports() ([]portainer.StackDriftReport, error)
+		UpdateStackDriftReport(report *portainer.StackDriftReport) error
+		DeleteStackDriftReport(stackID portainer.StackID) error
+		BucketName() string
+	}
+
+	// StackVersionService represents a service for managing the deployment history of the stacks
+	StackVersionService interface {
+		StackVersion(ID portainer.StackVersionID) (*portainer.StackVersion, error)
+		StackVersionsByStackID(stackID portainer.StackID) ([]portainer.StackVersion, error)
+		Create(version *portainer.StackVersion) error
+		DeleteStackVersion(ID portainer.StackVersionID) error
+		BucketName() string
+	}
+
 	// TagService represents a service for managing tag data
 	TagService interface {
 		Tags() ([]portainer.Tag, error)
@@ -0,0 +1,115 @@
+package notificationdelivery
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "notification_deliveries"
+
+	// MaxChannelDeliveries is the number of deliveries kept for each notification channel, the oldest deliveries
+	// are deleted first
+	MaxChannelDeliveries = 100
+)
+
+// Service represents a service for managing the delivery history of the notification channels.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// NotificationDeliveries returns an array containing all the notification deliveries, ordered by identifier.
+func (service *Service) NotificationDeliveries() ([]portainer.NotificationDelivery, error) {
+	return notificationDeliveries(service.connection, func(delivery *portainer.NotificationDelivery) bool {
+		return true
+	})
+}
+
+// NotificationDeliveriesByChannelID returns the deliveries made to a specific notification channel.
+func (service *Service) NotificationDeliveriesByChannelID(channelID portainer.NotificationChannelID) ([]portainer.NotificationDelivery, error) {
+	return notificationDeliveries(service.connection, func(delivery *portainer.NotificationDelivery) bool {
+		return delivery.ChannelID == channelID
+	})
+}
+
+func notificationDeliveries(tx portainer.ReadTransaction, predicate func(delivery *portainer.NotificationDelivery) bool) ([]portainer.NotificationDelivery, error) {
+	var deliveries = make([]portainer.NotificationDelivery, 0)
+
+	err := tx.GetAllWithJsoniter(
+		BucketName,
+		&portainer.NotificationDelivery{},
+		func(obj interface{}) (interface{}, error) {
+			delivery, ok := obj.(*portainer.NotificationDelivery)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to NotificationDelivery object")
+				return nil, fmt.Errorf("failed to convert to NotificationDelivery object: %s", obj)
+			}
+
+			if predicate(delivery) {
+				deliveries = append(deliveries, *delivery)
+			}
+
+			return &portainer.NotificationDelivery{}, nil
+		})
+
+	return deliveries, err
+}
+
+// Create assigns an ID to a new notification delivery and saves it, the oldest deliveries of the notification
+// channel are deleted past MaxChannelDeliveries.
+func (service *Service) Create(delivery *portainer.NotificationDelivery) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.CreateObject(
+			BucketName,
+			func(id uint64) (int, interface{}) {
+				delivery.ID = portainer.NotificationDeliveryID(id)
+				return int(delivery.ID), delivery
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		deliveries, err := notificationDeliveries(tx, func(channelDelivery *portainer.NotificationDelivery) bool {
+			return channelDelivery.ChannelID == delivery.ChannelID
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < len(deliveries)-MaxChannelDeliveries; i++ {
+			err = tx.DeleteObject(BucketName, service.connection.ConvertToKey(int(deliveries[i].ID)))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteNotificationDelivery deletes a notification delivery.
+func (service *Service) DeleteNotificationDelivery(ID portainer.NotificationDeliveryID) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
@@ -0,0 +1,100 @@
+package pendingdeployment
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "pending_deployments"
+)
+
+// Service represents a service for managing the stack deployments waiting for an approval.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+func (service *Service) Tx(tx portainer.Transaction) ServiceTx {
+	return ServiceTx{
+		service: service,
+		tx:      tx,
+	}
+}
+
+// PendingDeployments returns an array containing all the pending deployments.
+func (service *Service) PendingDeployments() ([]portainer.PendingDeployment, error) {
+	var deployments = make([]portainer.PendingDeployment, 0)
+
+	err := service.connection.GetAll(
+		BucketName,
+		&portainer.PendingDeployment{},
+		func(obj interface{}) (interface{}, error) {
+			deployment, ok := obj.(*portainer.PendingDeployment)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to PendingDeployment object")
+				return nil, fmt.Errorf("failed to convert to PendingDeployment object: %s", obj)
+			}
+
+			deployments = append(deployments, *deployment)
+
+			return &portainer.PendingDeployment{}, nil
+		})
+
+	return deployments, err
+}
+
+// PendingDeployment returns a pending deployment by ID.
+func (service *Service) PendingDeployment(ID portainer.PendingDeploymentID) (*portainer.PendingDeployment, error) {
+	var deployment portainer.PendingDeployment
+	identifier := service.connection.ConvertToKey(int(ID))
+
+	err := service.connection.GetObject(BucketName, identifier, &deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// Create assigns an ID to a new pending deployment and saves it.
+func (service *Service) Create(deployment *portainer.PendingDeployment) error {
+	return service.connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			deployment.ID = portainer.PendingDeploymentID(id)
+			return int(deployment.ID), deployment
+		},
+	)
+}
+
+// UpdatePendingDeployment updates a pending deployment.
+func (service *Service) UpdatePendingDeployment(ID portainer.PendingDeploymentID, deployment *portainer.PendingDeployment) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.UpdateObject(BucketName, identifier, deployment)
+}
+
+// DeletePendingDeployment deletes a pending deployment.
+func (service *Service) DeletePendingDeployment(ID portainer.PendingDeploymentID) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
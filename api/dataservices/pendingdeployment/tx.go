@@ -0,0 +1,76 @@
+package pendingdeployment
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+type ServiceTx struct {
+	service *Service
+	tx      portainer.Transaction
+}
+
+func (service ServiceTx) BucketName() string {
+	return BucketName
+}
+
+// PendingDeployments returns an array containing all the pending deployments.
+func (service ServiceTx) PendingDeployments() ([]portainer.PendingDeployment, error) {
+	var deployments = make([]portainer.PendingDeployment, 0)
+
+	err := service.tx.GetAll(
+		BucketName,
+		&portainer.PendingDeployment{},
+		func(obj interface{}) (interface{}, error) {
+			deployment, ok := obj.(*portainer.PendingDeployment)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to PendingDeployment object")
+				return nil, fmt.Errorf("failed to convert to PendingDeployment object: %s", obj)
+			}
+
+			deployments = append(deployments, *deployment)
+
+			return &portainer.PendingDeployment{}, nil
+		})
+
+	return deployments, err
+}
+
+// PendingDeployment returns a pending deployment by ID.
+func (service ServiceTx) PendingDeployment(ID portainer.PendingDeploymentID) (*portainer.PendingDeployment, error) {
+	var deployment portainer.PendingDeployment
+	identifier := service.service.connection.ConvertToKey(int(ID))
+
+	err := service.tx.GetObject(BucketName, identifier, &deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// Create assigns an ID to a new pending deployment and saves it.
+func (service ServiceTx) Create(deployment *portainer.PendingDeployment) error {
+	return service.tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			deployment.ID = portainer.PendingDeploymentID(id)
+			return int(deployment.ID), deployment
+		},
+	)
+}
+
+// UpdatePendingDeployment updates a pending deployment.
+func (service ServiceTx) UpdatePendingDeployment(ID portainer.PendingDeploymentID, deployment *portainer.PendingDeployment) error {
+	identifier := service.service.connection.ConvertToKey(int(ID))
+	return service.tx.UpdateObject(BucketName, identifier, deployment)
+}
+
+// DeletePendingDeployment deletes a pending deployment.
+func (service ServiceTx) DeletePendingDeployment(ID portainer.PendingDeploymentID) error {
+	identifier := service.service.connection.ConvertToKey(int(ID))
+	return service.tx.DeleteObject(BucketName, identifier)
+}
@@ -0,0 +1,56 @@
+package snapshothistory
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "snapshot_history"
+)
+
+// Service represents a service for managing the snapshot history of the environments(endpoints).
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// SnapshotHistory returns the snapshot history of an environment(endpoint).
+func (service *Service) SnapshotHistory(endpointID portainer.EndpointID) (*portainer.SnapshotHistory, error) {
+	var history portainer.SnapshotHistory
+	identifier := service.connection.ConvertToKey(int(endpointID))
+
+	err := service.connection.GetObject(BucketName, identifier, &history)
+	if err != nil {
+		return nil, err
+	}
+
+	return &history, nil
+}
+
+// UpdateSnapshotHistory saves the snapshot history of an environment(endpoint).
+func (service *Service) UpdateSnapshotHistory(history *portainer.SnapshotHistory) error {
+	identifier := service.connection.ConvertToKey(int(history.EndpointID))
+	return service.connection.UpdateObject(BucketName, identifier, history)
+}
+
+// DeleteSnapshotHistory deletes the snapshot history of an environment(endpoint).
+func (service *Service) DeleteSnapshotHistory(endpointID portainer.EndpointID) error {
+	identifier := service.connection.ConvertToKey(int(endpointID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
@@ -0,0 +1,82 @@
+package stackdrift
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "stack_drift_reports"
+)
+
+// Service represents a service for managing the drift reports of the stacks.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// StackDriftReport returns the last drift report of a stack.
+func (service *Service) StackDriftReport(stackID portainer.StackID) (*portainer.StackDriftReport, error) {
+	var report portainer.StackDriftReport
+	identifier := service.connection.ConvertToKey(int(stackID))
+
+	err := service.connection.GetObject(BucketName, identifier, &report)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// StackDriftReports returns the last drift report of every stack.
+func (service *Service) StackDriftReports() ([]portainer.StackDriftReport, error) {
+	var reports = make([]portainer.StackDriftReport, 0)
+
+	err := service.connection.GetAllWithJsoniter(
+		BucketName,
+		&portainer.StackDriftReport{},
+		func(obj interface{}) (interface{}, error) {
+			report, ok := obj.(*portainer.StackDriftReport)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to StackDriftReport object")
+				return nil, fmt.Errorf("failed to convert to StackDriftReport object: %s", obj)
+			}
+
+			reports = append(reports, *report)
+
+			return &portainer.StackDriftReport{}, nil
+		})
+
+	return reports, err
+}
+
+// UpdateStackDriftReport saves the drift report of a stack, replacing the previous one.
+func (service *Service) UpdateStackDriftReport(report *portainer.StackDriftReport) error {
+	identifier := service.connection.ConvertToKey(int(report.StackID))
+	return service.connection.UpdateObject(BucketName, identifier, report)
+}
+
+// DeleteStackDriftReport deletes the drift report of a stack.
+func (service *Service) DeleteStackDriftReport(stackID portainer.StackID) error {
+	identifier := service.connection.ConvertToKey(int(stackID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
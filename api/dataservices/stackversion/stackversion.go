@@ -0,0 +1,94 @@
+package stackversion
+
+import (
+	"fmt"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "stack_versions"
+)
+
+// Service represents a service for managing the deployment history of the stacks.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// StackVersion returns a stack version by ID.
+func (service *Service) StackVersion(ID portainer.StackVersionID) (*portainer.StackVersion, error) {
+	var version portainer.StackVersion
+	identifier := service.connection.ConvertToKey(int(ID))
+
+	err := service.connection.GetObject(BucketName, identifier, &version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// StackVersionsByStackID returns the versions recorded for a stack, ordered by version number.
+func (service *Service) StackVersionsByStackID(stackID portainer.StackID) ([]portainer.StackVersion, error) {
+	var versions = make([]portainer.StackVersion, 0)
+
+	err := service.connection.GetAllWithJsoniter(
+		BucketName,
+		&portainer.StackVersion{},
+		func(obj interface{}) (interface{}, error) {
+			version, ok := obj.(*portainer.StackVersion)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to StackVersion object")
+				return nil, fmt.Errorf("failed to convert to StackVersion object: %s", obj)
+			}
+
+			if version.StackID == stackID {
+				versions = append(versions, *version)
+			}
+
+			return &portainer.StackVersion{}, nil
+		})
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, err
+}
+
+// Create assigns an ID to a new stack version and saves it.
+func (service *Service) Create(version *portainer.StackVersion) error {
+	return service.connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			version.ID = portainer.StackVersionID(id)
+			return int(version.ID), version
+		},
+	)
+}
+
+// DeleteStackVersion deletes a stack version.
+func (service *Service) DeleteStackVersion(ID portainer.StackVersionID) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
@@ -0,0 +1,142 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+type ServiceTx struct {
+	service *Service
+	tx      portainer.Transaction
+}
+
+func (service ServiceTx) BucketName() string {
+	return BucketName
+}
+
+// User returns a user by ID
+func (service ServiceTx) User(ID portainer.UserID) (*portainer.User, error) {
+	var user portainer.User
+	identifier := service.service.connection.ConvertToKey(int(ID))
+
+	err := service.tx.GetObject(BucketName, identifier, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UserByUsername returns a user by username.
+func (service ServiceTx) UserByUsername(username string) (*portainer.User, error) {
+	var u *portainer.User
+	stop := fmt.Errorf("ok")
+	err := service.tx.GetAll(
+		BucketName,
+		&portainer.User{},
+		func(obj interface{}) (interface{}, error) {
+			user, ok := obj.(*portainer.User)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to User object")
+
+				return nil, fmt.Errorf("Failed to convert to User object: %s", obj)
+			}
+
+			if strings.EqualFold(user.Username, username) {
+				u = user
+				return nil, stop
+			}
+
+			return &portainer.User{}, nil
+		})
+
+	if errors.Is(err, stop) {
+		return u, nil
+	}
+
+	if err == nil {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return nil, err
+}
+
+// Users return an array containing all the users.
+func (service ServiceTx) Users() ([]portainer.User, error) {
+	var users = make([]portainer.User, 0)
+
+	err := service.tx.GetAll(
+		BucketName,
+		&portainer.User{},
+		func(obj interface{}) (interface{}, error) {
+			user, ok := obj.(*portainer.User)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to User object")
+
+				return nil, fmt.Errorf("Failed to convert to User object: %s", obj)
+			}
+
+			users = append(users, *user)
+
+			return &portainer.User{}, nil
+		})
+
+	return users, err
+}
+
+// UsersByRole return an array containing all the users with the specified role.
+func (service ServiceTx) UsersByRole(role portainer.UserRole) ([]portainer.User, error) {
+	var users = make([]portainer.User, 0)
+
+	err := service.tx.GetAll(
+		BucketName,
+		&portainer.User{},
+		func(obj interface{}) (interface{}, error) {
+			user, ok := obj.(*portainer.User)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to User object")
+
+				return nil, fmt.Errorf("Failed to convert to User object: %s", obj)
+			}
+
+			if user.Role == role {
+				users = append(users, *user)
+			}
+
+			return &portainer.User{}, nil
+		})
+
+	return users, err
+}
+
+// UpdateUser saves a user.
+func (service ServiceTx) UpdateUser(ID portainer.UserID, user *portainer.User) error {
+	identifier := service.service.connection.ConvertToKey(int(ID))
+	user.Username = strings.ToLower(user.Username)
+	return service.tx.UpdateObject(BucketName, identifier, user)
+}
+
+// CreateUser creates a new user.
+func (service ServiceTx) Create(user *portainer.User) error {
+	return service.tx.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			user.ID = portainer.UserID(id)
+			user.Username = strings.ToLower(user.Username)
+
+			return int(user.ID), user
+		},
+	)
+}
+
+// DeleteUser deletes a user.
+func (service ServiceTx) DeleteUser(ID portainer.UserID) error {
+	identifier := service.service.connection.ConvertToKey(int(ID))
+	return service.tx.DeleteObject(BucketName, identifier)
+}
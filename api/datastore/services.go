@@ -8,26 +8,37 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/database/models"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/dataservices/admissionpolicy"
 	"github.com/portainer/portainer/api/dataservices/apikeyrepository"
+	"github.com/portainer/portainer/api/dataservices/auditlog"
 	"github.com/portainer/portainer/api/dataservices/customtemplate"
 	"github.com/portainer/portainer/api/dataservices/dockerhub"
 	"github.com/portainer/portainer/api/dataservices/edgegroup"
 	"github.com/portainer/portainer/api/dataservices/edgejob"
+	"github.com/portainer/portainer/api/dataservices/edgejobrun"
 	"github.com/portainer/portainer/api/dataservices/edgestack"
+	"github.com/portainer/portainer/api/dataservices/edgestackstatusevent"
 	"github.com/portainer/portainer/api/dataservices/endpoint"
 	"github.com/portainer/portainer/api/dataservices/endpointgroup"
 	"github.com/portainer/portainer/api/dataservices/endpointrelation"
 	"github.com/portainer/portainer/api/dataservices/extension"
 	"github.com/portainer/portainer/api/dataservices/fdoprofile"
 	"github.com/portainer/portainer/api/dataservices/helmuserrepository"
+	"github.com/portainer/portainer/api/dataservices/imagescan"
+	"github.com/portainer/portainer/api/dataservices/notificationchannel"
+	"github.com/portainer/portainer/api/dataservices/notificationdelivery"
+	"github.com/portainer/portainer/api/dataservices/pendingdeployment"
 	"github.com/portainer/portainer/api/dataservices/registry"
 	"github.com/portainer/portainer/api/dataservices/resourcecontrol"
 	"github.com/portainer/portainer/api/dataservices/role"
 	"github.com/portainer/portainer/api/dataservices/schedule"
 	"github.com/portainer/portainer/api/dataservices/settings"
 	"github.com/portainer/portainer/api/dataservices/snapshot"
+	"github.com/portainer/portainer/api/dataservices/snapshothistory"
 	"github.com/portainer/portainer/api/dataservices/ssl"
 	"github.com/portainer/portainer/api/dataservices/stack"
+	"github.com/portainer/portainer/api/dataservices/stackdrift"
+	"github.com/portainer/portainer/api/dataservices/stackversion"
 	"github.com/portainer/portainer/api/dataservices/tag"
 	"github.com/portainer/portainer/api/dataservices/team"
 	"github.com/portainer/portainer/api/dataservices/teammembership"
@@ -44,34 +55,45 @@ import (
 type Store struct {
 	connection portainer.Connection
 
-	fileService               portainer.FileService
-	CustomTemplateService     *customtemplate.Service
-	DockerHubService          *dockerhub.Service
-	EdgeGroupService          *edgegroup.Service
-	EdgeJobService            *edgejob.Service
-	EdgeStackService          *edgestack.Service
-	EndpointGroupService      *endpointgroup.Service
-	EndpointService           *endpoint.Service
-	EndpointRelationService   *endpointrelation.Service
-	ExtensionService          *extension.Service
-	FDOProfilesService        *fdoprofile.Service
-	HelmUserRepositoryService *helmuserrepository.Service
-	RegistryService           *registry.Service
-	ResourceControlService    *resourcecontrol.Service
-	RoleService               *role.Service
-	APIKeyRepositoryService   *apikeyrepository.Service
-	ScheduleService           *schedule.Service
-	SettingsService           *settings.Service
-	SnapshotService           *snapshot.Service
-	SSLSettingsService        *ssl.Service
-	StackService              *stack.Service
-	TagService                *tag.Service
-	TeamMembershipService     *teammembership.Service
-	TeamService               *team.Service
-	TunnelServerService       *tunnelserver.Service
-	UserService               *user.Service
-	VersionService            *version.Service
-	WebhookService            *webhook.Service
+	fileService                 portainer.FileService
+	AdmissionPolicyService      *admissionpolicy.Service
+	AuditLogService             *auditlog.Service
+	CustomTemplateService       *customtemplate.Service
+	DockerHubService            *dockerhub.Service
+	EdgeGroupService            *edgegroup.Service
+	EdgeJobService              *edgejob.Service
+	EdgeJobRunService           *edgejobrun.Service
+	EdgeStackService            *edgestack.Service
+	EdgeStackStatusEventService *edgestackstatusevent.Service
+	EndpointGroupService        *endpointgroup.Service
+	EndpointService             *endpoint.Service
+	EndpointRelationService     *endpointrelation.Service
+	ExtensionService            *extension.Service
+	FDOProfilesService          *fdoprofile.Service
+	HelmUserRepositoryService   *helmuserrepository.Service
+	ImageScanService            *imagescan.Service
+	NotificationChannelService  *notificationchannel.Service
+	NotificationDeliveryService *notificationdelivery.Service
+	PendingDeploymentService    *pendingdeployment.Service
+	RegistryService             *registry.Service
+	ResourceControlService      *resourcecontrol.Service
+	RoleService                 *role.Service
+	APIKeyRepositoryService     *apikeyrepository.Service
+	ScheduleService             *schedule.Service
+	SettingsService             *settings.Service
+	SnapshotService             *snapshot.Service
+	SnapshotHistoryService      *snapshothistory.Service
+	SSLSettingsService          *ssl.Service
+	StackService                *stack.Service
+	StackDriftService           *stackdrift.Service
+	StackVersionService         *stackversion.Service
+	TagService                  *tag.Service
+	TeamMembershipService       *teammembership.Service
+	TeamService                 *team.Service
+	TunnelServerService         *tunnelserver.Service
+	UserService                 *user.Service
+	VersionService              *version.Service
+	WebhookService              *webhook.Service
 }
 
 func (store *Store) initServices() error {
@@ -81,6 +103,18 @@ func (store *Store) initServices() error {
 	}
 	store.RoleService = authorizationsetService
 
+	admissionPolicyService, err := admissionpolicy.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.AdmissionPolicyService = admissionPolicyService
+
+	auditLogService, err := auditlog.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.AuditLogService = auditLogService
+
 	customTemplateService, err := customtemplate.NewService(store.connection)
 	if err != nil {
 		return err
@@ -106,6 +140,12 @@ func (store *Store) initServices() error {
 	store.EdgeStackService = edgeStackService
 	endpointRelationService.RegisterUpdateStackFunction(edgeStackService.UpdateEdgeStackFunc, edgeStackService.UpdateEdgeStackFuncTx)
 
+	edgeStackStatusEventService, err := edgestackstatusevent.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.EdgeStackStatusEventService = edgeStackStatusEventService
+
 	edgeGroupService, err := edgegroup.NewService(store.connection)
 	if err != nil {
 		return err
@@ -118,6 +158,12 @@ func (store *Store) initServices() error {
 	}
 	store.EdgeJobService = edgeJobService
 
+	edgeJobRunService, err := edgejobrun.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.EdgeJobRunService = edgeJobRunService
+
 	endpointgroupService, err := endpointgroup.NewService(store.connection)
 	if err != nil {
 		return err
@@ -148,6 +194,30 @@ func (store *Store) initServices() error {
 	}
 	store.HelmUserRepositoryService = helmUserRepositoryService
 
+	imageScanService, err := imagescan.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.ImageScanService = imageScanService
+
+	notificationChannelService, err := notificationchannel.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.NotificationChannelService = notificationChannelService
+
+	notificationDeliveryService, err := notificationdelivery.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.NotificationDeliveryService = notificationDeliveryService
+
+	pendingDeploymentService, err := pendingdeployment.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.PendingDeploymentService = pendingDeploymentService
+
 	registryService, err := registry.NewService(store.connection)
 	if err != nil {
 		return err
@@ -172,6 +242,12 @@ func (store *Store) initServices() error {
 	}
 	store.SnapshotService = snapshotService
 
+	snapshotHistoryService, err := snapshothistory.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.SnapshotHistoryService = snapshotHistoryService
+
 	sslSettingsService, err := ssl.NewService(store.connection)
 	if err != nil {
 		return err
@@ -184,6 +260,18 @@ func (store *Store) initServices() error {
 	}
 	store.StackService = stackService
 
+	stackDriftService, err := stackdrift.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.StackDriftService = stackDriftService
+
+	stackVersionService, err := stackversion.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.StackVersionService = stackVersionService
+
 	tagService, err := tag.NewService(store.connection)
 	if err != nil {
 		return err
@@ -241,6 +329,16 @@ func (store *Store) initServices() error {
 	return nil
 }
 
+// AdmissionPolicy gives access to the AdmissionPolicy data management layer
+func (store *Store) AdmissionPolicy() dataservices.AdmissionPolicyService {
+	return store.AdmissionPolicyService
+}
+
+// AuditLog gives access to the AuditLog data management layer
+func (store *Store) AuditLog() dataservices.AuditLogService {
+	return store.AuditLogService
+}
+
 // CustomTemplate gives access to the CustomTemplate data management layer
 func (store *Store) CustomTemplate() dataservices.CustomTemplateService {
 	return store.CustomTemplateService
@@ -256,11 +354,21 @@ func (store *Store) EdgeJob() dataservices.EdgeJobService {
 	return store.EdgeJobService
 }
 
+// EdgeJobRun gives access to the EdgeJobRun data management layer
+func (store *Store) EdgeJobRun() dataservices.EdgeJobRunService {
+	return store.EdgeJobRunService
+}
+
 // EdgeStack gives access to the EdgeStack data management layer
 func (store *Store) EdgeStack() dataservices.EdgeStackService {
 	return store.EdgeStackService
 }
 
+// EdgeStackStatusEvent gives access to the EdgeStackStatusEvent data management layer
+func (store *Store) EdgeStackStatusEvent() dataservices.EdgeStackStatusEventService {
+	return store.EdgeStackStatusEventService
+}
+
 // Environment(Endpoint) gives access to the Environment(Endpoint) data management layer
 func (store *Store) Endpoint() dataservices.EndpointService {
 	return store.EndpointService
@@ -286,6 +394,26 @@ func (store *Store) HelmUserRepository() dataservices.HelmUserRepositoryService
 	return store.HelmUserRepositoryService
 }
 
+// ImageScan gives access to the ImageScan data management layer
+func (store *Store) ImageScan() dataservices.ImageScanService {
+	return store.ImageScanService
+}
+
+// NotificationChannel gives access to the NotificationChannel data management layer
+func (store *Store) NotificationChannel() dataservices.NotificationChannelService {
+	return store.NotificationChannelService
+}
+
+// NotificationDelivery gives access to the NotificationDelivery data management layer
+func (store *Store) NotificationDelivery() dataservices.NotificationDeliveryService {
+	return store.NotificationDeliveryService
+}
+
+// PendingDeployment gives access to the PendingDeployment data management layer
+func (store *Store) PendingDeployment() dataservices.PendingDeploymentService {
+	return store.PendingDeploymentService
+}
+
 // Registry gives access to the Registry data management layer
 func (store *Store) Registry() dataservices.RegistryService {
 	return store.RegistryService
@@ -315,6 +443,11 @@ func (store *Store) Snapshot() dataservices.SnapshotService {
 	return store.SnapshotService
 }
 
+// SnapshotHistory gives access to the SnapshotHistory data management layer
+func (store *Store) SnapshotHistory() dataservices.SnapshotHistoryService {
+	return store.SnapshotHistoryService
+}
+
 // SSLSettings gives access to the SSL Settings data management layer
 func (store *Store) SSLSettings() dataservices.SSLSettingsService {
 	return store.SSLSettingsService
@@ -325,6 +458,16 @@ func (store *Store) Stack() dataservices.StackService {
 	return store.StackService
 }
 
+// StackDrift gives access to the StackDrift data management layer
+func (store *Store) StackDrift() dataservices.StackDriftService {
+	return store.StackDriftService
+}
+
+// StackVersion gives access to the StackVersion data management layer
+func (store *Store) StackVersion() dataservices.StackVersionService {
+	return store.StackVersionService
+}
+
 // Tag gives access to the Tag data management layer
 func (store *Store) Tag() dataservices.TagService {
 	return store.TagService
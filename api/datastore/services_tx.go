@@ -14,6 +14,14 @@ func (tx *StoreTx) IsErrObjectNotFound(err error) bool {
 	return tx.store.IsErrObjectNotFound(err)
 }
 
+func (tx *StoreTx) AdmissionPolicy() dataservices.AdmissionPolicyService {
+	return nil
+}
+
+func (tx *StoreTx) AuditLog() dataservices.AuditLogService {
+	return nil
+}
+
 func (tx *StoreTx) CustomTemplate() dataservices.CustomTemplateService { return nil }
 
 func (tx *StoreTx) EdgeGroup() dataservices.EdgeGroupService {
@@ -24,10 +32,18 @@ func (tx *StoreTx) EdgeJob() dataservices.EdgeJobService {
 	return tx.store.EdgeJobService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) EdgeJobRun() dataservices.EdgeJobRunService {
+	return tx.store.EdgeJobRunService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) EdgeStack() dataservices.EdgeStackService {
 	return tx.store.EdgeStackService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) EdgeStackStatusEvent() dataservices.EdgeStackStatusEventService {
+	return tx.store.EdgeStackStatusEventService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) Endpoint() dataservices.EndpointService {
 	return tx.store.EndpointService.Tx(tx.tx)
 }
@@ -43,6 +59,22 @@ func (tx *StoreTx) EndpointRelation() dataservices.EndpointRelationService {
 func (tx *StoreTx) FDOProfile() dataservices.FDOProfileService                 { return nil }
 func (tx *StoreTx) HelmUserRepository() dataservices.HelmUserRepositoryService { return nil }
 
+func (tx *StoreTx) ImageScan() dataservices.ImageScanService {
+	return nil
+}
+
+func (tx *StoreTx) NotificationChannel() dataservices.NotificationChannelService {
+	return nil
+}
+
+func (tx *StoreTx) NotificationDelivery() dataservices.NotificationDeliveryService {
+	return nil
+}
+
+func (tx *StoreTx) PendingDeployment() dataservices.PendingDeploymentService {
+	return tx.store.PendingDeploymentService.Tx(tx.tx)
+}
+
 func (tx *StoreTx) Registry() dataservices.RegistryService {
 	return nil
 }
@@ -56,15 +88,30 @@ func (tx *StoreTx) Role() dataservices.RoleService {
 }
 
 func (tx *StoreTx) APIKeyRepository() dataservices.APIKeyRepository { return nil }
-func (tx *StoreTx) Settings() dataservices.SettingsService          { return nil }
+
+func (tx *StoreTx) Settings() dataservices.SettingsService {
+	return tx.store.SettingsService.Tx(tx.tx)
+}
 
 func (tx *StoreTx) Snapshot() dataservices.SnapshotService {
 	return tx.store.SnapshotService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) SnapshotHistory() dataservices.SnapshotHistoryService {
+	return nil
+}
+
 func (tx *StoreTx) SSLSettings() dataservices.SSLSettingsService { return nil }
 func (tx *StoreTx) Stack() dataservices.StackService             { return nil }
 
+func (tx *StoreTx) StackDrift() dataservices.StackDriftService {
+	return nil
+}
+
+func (tx *StoreTx) StackVersion() dataservices.StackVersionService {
+	return nil
+}
+
 func (tx *StoreTx) Tag() dataservices.TagService {
 	return tx.store.TagService.Tx(tx.tx)
 }
@@ -75,6 +122,10 @@ func (tx *StoreTx) TeamMembership() dataservices.TeamMembershipService {
 
 func (tx *StoreTx) Team() dataservices.TeamService                 { return nil }
 func (tx *StoreTx) TunnelServer() dataservices.TunnelServerService { return nil }
-func (tx *StoreTx) User() dataservices.UserService                 { return nil }
-func (tx *StoreTx) Version() dataservices.VersionService           { return nil }
-func (tx *StoreTx) Webhook() dataservices.WebhookService           { return nil }
+
+func (tx *StoreTx) User() dataservices.UserService {
+	return tx.store.UserService.Tx(tx.tx)
+}
+
+func (tx *StoreTx) Version() dataservices.VersionService { return nil }
+func (tx *StoreTx) Webhook() dataservices.WebhookService { return nil }
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// envHashPrefix prefixes the hashed values of the environment variables
+const envHashPrefix = "sha256:"
+
+// HashEnvValue returns the hash of the value of an environment variable, which can be stored
+// and compared without disclosing the value
+func HashEnvValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return envHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// HashEnv replaces the values of the KEY=VALUE environment variables by their hash, the variables
+// without a value are kept as is
+func HashEnv(env []string) []string {
+	if env == nil {
+		return nil
+	}
+
+	hashed := make([]string, 0, len(env))
+
+	for _, variable := range env {
+		key, value, ok := strings.Cut(variable, "=")
+		if !ok {
+			hashed = append(hashed, variable)
+			continue
+		}
+
+		hashed = append(hashed, key+"="+HashEnvValue(value))
+	}
+
+	return hashed
+}
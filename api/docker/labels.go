@@ -2,5 +2,6 @@ package docker
 
 const (
 	ComposeStackNameLabel = "com.docker.compose.project"
+	ComposeServiceLabel   = "com.docker.compose.service"
 	SwarmStackNameLabel   = "com.docker.stack.namespace"
 )
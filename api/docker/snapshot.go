@@ -10,6 +10,7 @@ import (
 	"github.com/docker/docker/api/types"
 	_container "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
 	"github.com/rs/zerolog/log"
 )
@@ -132,19 +133,37 @@ func snapshotSwarmServices(snapshot *portainer.DockerSnapshot, cli *client.Clien
 		return err
 	}
 
+	// only the services of the stacks are kept for the drift detection, without the values of their variables
+	stackServices := []swarm.Service{}
+
 	for _, service := range services {
-		for k, v := range service.Spec.Labels {
-			if k == "com.docker.stack.namespace" {
-				stacks[v] = struct{}{}
-			}
+		stackName, ok := service.Spec.Labels[SwarmStackNameLabel]
+		if !ok {
+			continue
+		}
+
+		stacks[stackName] = struct{}{}
+
+		hashServiceEnv(&service.Spec)
+		if service.PreviousSpec != nil {
+			hashServiceEnv(service.PreviousSpec)
 		}
+
+		stackServices = append(stackServices, service)
 	}
 
 	snapshot.ServiceCount = len(services)
 	snapshot.StackCount += len(stacks)
+	snapshot.SnapshotRaw.Services = stackServices
 	return nil
 }
 
+func hashServiceEnv(spec *swarm.ServiceSpec) {
+	if spec.TaskTemplate.ContainerSpec != nil {
+		spec.TaskTemplate.ContainerSpec.Env = HashEnv(spec.TaskTemplate.ContainerSpec.Env)
+	}
+}
+
 func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client) error {
 	containers, err := cli.ContainerList(context.Background(), types.ContainerListOptions{All: true})
 	if err != nil {
@@ -156,6 +175,7 @@ func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client)
 	healthyContainers := 0
 	unhealthyContainers := 0
 	stacks := make(map[string]struct{})
+	containerEnv := make(map[string][]string)
 	gpuUseSet := make(map[string]struct{})
 	gpuUseAll := false
 	for _, container := range containers {
@@ -176,6 +196,11 @@ func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client)
 					log.Info().Str("container", container.ID).Err(err).Msg("unable to inspect container in other Swarm nodes")
 				}
 			} else {
+				// the variables of the compose containers are kept for the drift detection, only their hash is stored
+				if _, ok := container.Labels[ComposeStackNameLabel]; ok && response.Config != nil {
+					containerEnv[container.ID] = HashEnv(response.Config.Env)
+				}
+
 				var gpuOptions *_container.DeviceRequest = nil
 				for _, deviceRequest := range response.HostConfig.Resources.DeviceRequests {
 					deviceRequest := deviceRequest
@@ -222,7 +247,7 @@ func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client)
 	snapshot.UnhealthyContainerCount = unhealthyContainers
 	snapshot.StackCount += len(stacks)
 	for _, container := range containers {
-		snapshot.SnapshotRaw.Containers = append(snapshot.SnapshotRaw.Containers, portainer.DockerContainerSnapshot{Container: container})
+		snapshot.SnapshotRaw.Containers = append(snapshot.SnapshotRaw.Containers, portainer.DockerContainerSnapshot{Container: container, Env: containerEnv[container.ID]})
 	}
 	return nil
 }
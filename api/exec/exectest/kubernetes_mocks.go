@@ -22,3 +22,11 @@ func (deployer *kubernetesMockDeployer) Remove(userID portainer.UserID, endpoint
 func (deployer *kubernetesMockDeployer) ConvertCompose(data []byte) ([]byte, error) {
 	return nil, nil
 }
+
+func (deployer *kubernetesMockDeployer) Render(projectPath, entryPoint string, build portainer.KubernetesBuildConfig, namespace string) ([]byte, error) {
+	return nil, nil
+}
+
+func (deployer *kubernetesMockDeployer) DryRun(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
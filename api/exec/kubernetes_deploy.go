@@ -10,7 +10,9 @@ import (
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/admission"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/proxy/factory"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
@@ -77,8 +79,24 @@ func (deployer *KubernetesDeployer) getToken(userID portainer.UserID, endpoint *
 	return token, nil
 }
 
-// Deploy upserts Kubernetes resources defined in manifest(s)
+// Deploy upserts Kubernetes resources defined in manifest(s), once they are admitted by the admission policies
+// of the environment(endpoint)
 func (deployer *KubernetesDeployer) Deploy(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	manifests := make([][]byte, 0, len(manifestFiles))
+	for _, manifestFile := range manifestFiles {
+		manifest, err := os.ReadFile(strings.TrimSpace(manifestFile))
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read the manifest")
+		}
+
+		manifests = append(manifests, manifest)
+	}
+
+	err := admission.AdmitKubernetesManifests(deployer.dataStore, endpoint, manifests)
+	if err != nil {
+		return "", err
+	}
+
 	return deployer.command("apply", userID, endpoint, manifestFiles, namespace)
 }
 
@@ -87,7 +105,23 @@ func (deployer *KubernetesDeployer) Remove(userID portainer.UserID, endpoint *po
 	return deployer.command("delete", userID, endpoint, manifestFiles, namespace)
 }
 
-func (deployer *KubernetesDeployer) command(operation string, userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+// DryRun returns the deployed objects of the manifest(s) and the objects as they would be applied by a server-side
+// dry-run, both in JSON. The objects which are not deployed yet are missing from the deployed objects.
+func (deployer *KubernetesDeployer) DryRun(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) ([]byte, []byte, error) {
+	live, err := deployer.command("get", userID, endpoint, manifestFiles, namespace, "--ignore-not-found=true", "--output=json")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applied, err := deployer.command("apply", userID, endpoint, manifestFiles, namespace, "--dry-run=server", "--output=json")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []byte(live), []byte(applied), nil
+}
+
+func (deployer *KubernetesDeployer) command(operation string, userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string, flags ...string) (string, error) {
 	token, err := deployer.getToken(userID, endpoint, endpoint.Type == portainer.KubernetesLocalEnvironment)
 	if err != nil {
 		return "", errors.Wrap(err, "failed generating a user token")
@@ -119,6 +153,7 @@ func (deployer *KubernetesDeployer) command(operation string, userID portainer.U
 	}
 
 	args = append(args, operation)
+	args = append(args, flags...)
 	for _, path := range manifestFiles {
 		args = append(args, "-f", strings.TrimSpace(path))
 	}
@@ -160,6 +195,53 @@ func (deployer *KubernetesDeployer) ConvertCompose(data []byte) ([]byte, error)
 	return output, nil
 }
 
+// Render renders the manifests of a Kubernetes git stack from the directory of its entry point, a kustomization
+// directory is rendered with kubectl kustomize and a Helm chart with helm template
+func (deployer *KubernetesDeployer) Render(projectPath, entryPoint string, build portainer.KubernetesBuildConfig, namespace string) ([]byte, error) {
+	directory := filesystem.JoinPaths(projectPath, path.Dir(entryPoint))
+
+	var command string
+	var args []string
+
+	switch build.Mode {
+	case portainer.KubernetesBuildKustomize:
+		command = deployer.binary("kubectl")
+		args = []string{"kustomize", directory}
+	case portainer.KubernetesBuildHelm:
+		command = deployer.binary("helm")
+		// the CRDs are only installed by helm install unless they are included
+		args = []string{"template", build.ReleaseName, directory, "--include-crds"}
+		if namespace != "" {
+			args = append(args, "--namespace", namespace)
+		}
+
+		for _, valuesFile := range build.ValuesFiles {
+			args = append(args, "--values", filesystem.JoinPaths(projectPath, valuesFile))
+		}
+	default:
+		return nil, errors.Errorf("unsupported build mode %q", build.Mode)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render the manifests: %q", stderr.String())
+	}
+
+	return output, nil
+}
+
+func (deployer *KubernetesDeployer) binary(name string) string {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return path.Join(deployer.binaryPath, name)
+}
+
 func (deployer *KubernetesDeployer) getAgentURL(endpoint *portainer.Endpoint) (string, *factory.ProxyServer, error) {
 	proxy, err := deployer.proxyManager.CreateAgentProxyServer(endpoint)
 	if err != nil {
@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+const renderedManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: rendered
+`
+
+// writeStubBinary writes a script which records its arguments, one per line, in the args file and prints the
+// rendered manifest, or exits with an error when the args file can't be written
+func writeStubBinary(t *testing.T, binaryPath, name string) string {
+	t.Helper()
+
+	argsFile := filepath.Join(t.TempDir(), name+".args")
+
+	script := "#!/bin/sh\n" +
+		"printf '%s\\n' \"$@\" > " + argsFile + " || exit 1\n" +
+		"cat <<'EOF'\n" + renderedManifest + "EOF\n"
+
+	err := os.WriteFile(filepath.Join(binaryPath, name), []byte(script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return argsFile
+}
+
+func readArgs(t *testing.T, argsFile string) []string {
+	t.Helper()
+
+	content, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func Test_KubernetesDeployer_Render(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub binaries are shell scripts")
+	}
+
+	binaryPath := t.TempDir()
+	kubectlArgs := writeStubBinary(t, binaryPath, "kubectl")
+	helmArgs := writeStubBinary(t, binaryPath, "helm")
+
+	deployer := NewKubernetesDeployer(nil, nil, nil, nil, nil, nil, binaryPath)
+	projectPath := "/data/compose/1"
+
+	t.Run("kustomize renders the directory of the entry point", func(t *testing.T) {
+		is := assert.New(t)
+
+		output, err := deployer.Render(projectPath, "overlays/production/kustomization.yaml", portainer.KubernetesBuildConfig{
+			Mode: portainer.KubernetesBuildKustomize,
+		}, "production")
+		is.NoError(err)
+		is.Equal(renderedManifest, string(output))
+		is.Equal([]string{"kustomize", "/data/compose/1/overlays/production"}, readArgs(t, kubectlArgs))
+	})
+
+	t.Run("helm renders the chart with the namespace and the values files", func(t *testing.T) {
+		is := assert.New(t)
+
+		output, err := deployer.Render(projectPath, "charts/app/Chart.yaml", portainer.KubernetesBuildConfig{
+			Mode:        portainer.KubernetesBuildHelm,
+			ReleaseName: "my-app",
+			ValuesFiles: []string{"charts/app/values.yaml", "charts/app/values-production.yaml"},
+		}, "production")
+		is.NoError(err)
+		is.Equal(renderedManifest, string(output))
+		is.Equal([]string{
+			"template", "my-app", "/data/compose/1/charts/app", "--include-crds",
+			"--namespace", "production",
+			"--values", "/data/compose/1/charts/app/values.yaml",
+			"--values", "/data/compose/1/charts/app/values-production.yaml",
+		}, readArgs(t, helmArgs))
+	})
+
+	t.Run("helm renders the chart without namespace", func(t *testing.T) {
+		is := assert.New(t)
+
+		_, err := deployer.Render(projectPath, "Chart.yaml", portainer.KubernetesBuildConfig{
+			Mode:        portainer.KubernetesBuildHelm,
+			ReleaseName: "my-app",
+		}, "")
+		is.NoError(err)
+		is.Equal([]string{"template", "my-app", "/data/compose/1", "--include-crds"}, readArgs(t, helmArgs))
+	})
+
+	t.Run("unsupported build mode", func(t *testing.T) {
+		_, err := deployer.Render(projectPath, "Chart.yaml", portainer.KubernetesBuildConfig{Mode: "jsonnet"}, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("the errors of the binary are returned", func(t *testing.T) {
+		is := assert.New(t)
+
+		failing := "#!/bin/sh\necho 'Error: Chart.yaml file is missing' >&2\nexit 1\n"
+		err := os.WriteFile(filepath.Join(binaryPath, "helm"), []byte(failing), 0755)
+		is.NoError(err)
+
+		_, err = deployer.Render(projectPath, "Chart.yaml", portainer.KubernetesBuildConfig{Mode: portainer.KubernetesBuildHelm}, "")
+		is.ErrorContains(err, "Chart.yaml file is missing")
+	})
+}
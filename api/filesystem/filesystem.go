@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gofrs/uuid"
@@ -35,6 +36,9 @@ const (
 	ManifestFileDefaultName = "k8s-deployment.yml"
 	// EdgeStackStorePath represents the subfolder where edge stack files are stored in the file store folder.
 	EdgeStackStorePath = "edge_stacks"
+	// EdgeStackVersionStorePath represents the subfolder where the files of the previous versions of the edge stacks
+	// are stored in the file store folder, outside of the edge stack folders which are replaced by the git redeployments.
+	EdgeStackVersionStorePath = "edge_stack_versions"
 	// FDOProfileStorePath represents the subfolder where FDO profiles files are stored in the file store folder.
 	FDOProfileStorePath = "fdo_profiles"
 	// PrivateKeyFile represents the name on disk of the file containing the private key.
@@ -316,6 +320,29 @@ func (service *Service) StoreEdgeStackFileFromBytes(edgeStackIdentifier, fileNam
 	return service.wrapFileStore(stackStorePath), nil
 }
 
+// GetEdgeStackVersionPath returns the absolute path on the FS for the files of a previous version of an edge stack
+// based on its identifier.
+func (service *Service) GetEdgeStackVersionPath(edgeStackIdentifier string, version int) string {
+	return JoinPaths(service.wrapFileStore(EdgeStackVersionStorePath), edgeStackIdentifier, strconv.Itoa(version))
+}
+
+// StoreEdgeStackVersionFileFromBytes creates a subfolder for the version in the EdgeStackVersionStorePath and stores a
+// new file from bytes. It returns the path to the folder where the file is stored.
+func (service *Service) StoreEdgeStackVersionFileFromBytes(edgeStackIdentifier string, version int, fileName string, data []byte) (string, error) {
+	versionStorePath := JoinPaths(EdgeStackVersionStorePath, edgeStackIdentifier, strconv.Itoa(version))
+	err := service.createDirectoryInStore(versionStorePath)
+	if err != nil {
+		return "", err
+	}
+
+	err = service.createFileInStore(JoinPaths(versionStorePath, fileName), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	return service.wrapFileStore(versionStorePath), nil
+}
+
 // StoreRegistryManagementFileFromBytes creates a subfolder in the
 // ExtensionRegistryManagementStorePath and stores a new file from bytes.
 // It returns the path to the folder where the file is stored.
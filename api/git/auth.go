@@ -0,0 +1,270 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	// the installation tokens are valid for an hour, they are renewed a bit before they expire
+	githubAppTokenRefreshMargin = 5 * time.Minute
+	// the username expected by GitHub with an installation token
+	githubAppTokenUsername = "x-access-token"
+)
+
+func newBaseOption(repositoryURL string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) baseOption {
+	options := baseOption{
+		repositoryUrl: repositoryURL,
+		tlsSkipVerify: tlsSkipVerify,
+	}
+
+	if auth == nil {
+		return options
+	}
+
+	switch auth.AuthorizationType {
+	case gittypes.GitCredentialAuthTypeSSH:
+		options.username = auth.Username
+		options.ssh = auth.SSH
+	case gittypes.GitCredentialAuthTypeGitHubApp:
+		options.githubApp = auth.GitHubApp
+	default:
+		options.username = auth.Username
+		options.password = auth.Password
+	}
+
+	return options
+}
+
+// credentialsKey identifies the credentials of the options in the cache keys without exposing their secrets
+func (opt baseOption) credentialsKey() string {
+	if opt.ssh == nil && opt.githubApp == nil {
+		return generateCacheKey(opt.username, opt.password)
+	}
+
+	credentials, _ := json.Marshal(struct {
+		Username  string
+		SSH       *gittypes.SSHCredentials
+		GitHubApp *gittypes.GitHubAppCredentials
+	}{opt.username, opt.ssh, opt.githubApp})
+
+	hash := sha256.Sum256(credentials)
+	return hex.EncodeToString(hash[:])
+}
+
+// getAuth returns the authentication of the options: basic authentication, SSH deploy key or GitHub App
+// installation token. It returns nil when the repository is accessed anonymously.
+func (c *gitClient) getAuth(ctx context.Context, opt baseOption) (transport.AuthMethod, error) {
+	switch {
+	case opt.ssh != nil:
+		return newSSHAuth(opt.username, opt.ssh)
+	case opt.githubApp != nil:
+		token, err := c.githubAppTokens.token(ctx, opt.githubApp)
+		if err != nil {
+			return nil, err
+		}
+
+		return &githttp.BasicAuth{Username: githubAppTokenUsername, Password: token}, nil
+	case opt.password != "":
+		username := opt.username
+		if username == "" {
+			username = "token"
+		}
+
+		return &githttp.BasicAuth{
+			Username: username,
+			Password: opt.password,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func newSSHAuth(username string, credentials *gittypes.SSHCredentials) (transport.AuthMethod, error) {
+	if username == "" {
+		username = gitssh.DefaultUsername
+	}
+
+	auth, err := gitssh.NewPublicKeys(username, []byte(credentials.PrivateKey), credentials.Passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SSH private key")
+	}
+
+	auth.HostKeyCallback, err = knownHostsCallback(credentials.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth, nil
+}
+
+// knownHostsCallback only accepts the host keys pinned by the known hosts, the servers are not trusted on first use
+func knownHostsCallback(knownHosts string) (ssh.HostKeyCallback, error) {
+	if strings.TrimSpace(knownHosts) == "" {
+		return nil, errors.New("the known hosts of the SSH server are required")
+	}
+
+	// knownhosts only reads files
+	file, err := os.CreateTemp("", "known_hosts")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the known hosts file")
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(knownHosts + "\n")
+	file.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write the known hosts file")
+	}
+
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid known hosts")
+	}
+
+	return callback, nil
+}
+
+// ValidateSSHCredentials verifies that the deploy key and the known hosts can be parsed
+func ValidateSSHCredentials(credentials *gittypes.SSHCredentials) error {
+	if credentials == nil || credentials.PrivateKey == "" {
+		return errors.New("the SSH private key is required")
+	}
+
+	_, err := newSSHAuth("", credentials)
+	return err
+}
+
+// ValidateGitHubAppCredentials verifies that the installation is identified and that its private key can be parsed
+func ValidateGitHubAppCredentials(credentials *gittypes.GitHubAppCredentials) error {
+	if credentials == nil || credentials.AppID == 0 || credentials.InstallationID == 0 {
+		return errors.New("the GitHub App and installation identifiers are required")
+	}
+
+	_, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(credentials.PrivateKey))
+	if err != nil {
+		return errors.Wrap(err, "invalid GitHub App private key")
+	}
+
+	return nil
+}
+
+type githubAppToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// githubAppTokens exchanges the JWT of the GitHub Apps for installation tokens and keeps them until they expire
+type githubAppTokens struct {
+	mu     sync.Mutex
+	tokens map[string]githubAppToken
+	client *http.Client
+	now    func() time.Time
+}
+
+func newGitHubAppTokens() *githubAppTokens {
+	return &githubAppTokens{
+		tokens: make(map[string]githubAppToken),
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}
+}
+
+func (tokens *githubAppTokens) token(ctx context.Context, app *gittypes.GitHubAppCredentials) (string, error) {
+	apiURL := strings.TrimSuffix(app.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	// the token is only shared with the callers who hold the private key that minted it
+	keyHash := sha256.Sum256([]byte(app.PrivateKey))
+	key := fmt.Sprintf("%s/%d/%d/%s", apiURL, app.AppID, app.InstallationID, hex.EncodeToString(keyHash[:]))
+
+	tokens.mu.Lock()
+	defer tokens.mu.Unlock()
+
+	now := tokens.now()
+
+	if cached, ok := tokens.tokens[key]; ok && now.Add(githubAppTokenRefreshMargin).Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	appJWT, err := newGitHubAppJWT(app, now)
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", apiURL, app.InstallationID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := tokens.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to request a GitHub App installation token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+			return "", gittypes.ErrAuthenticationFailure
+		}
+
+		return "", errors.Errorf("failed to request a GitHub App installation token, status code %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode the GitHub App installation token")
+	}
+
+	tokens.tokens[key] = githubAppToken{token: result.Token, expiresAt: result.ExpiresAt}
+
+	return result.Token, nil
+}
+
+// newGitHubAppJWT returns the JWT authenticating a GitHub App, GitHub rejects the JWT which expire after more than 10 minutes
+func newGitHubAppJWT(app *gittypes.GitHubAppCredentials, now time.Time) (string, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(app.PrivateKey))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid GitHub App private key")
+	}
+
+	claims := jwt.RegisteredClaims{
+		Issuer: strconv.FormatInt(app.AppID, 10),
+		// allows a clock drift with GitHub
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(9 * time.Minute)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+}
@@ -0,0 +1,165 @@
+package git
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/golang-jwt/jwt/v4"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func generatePrivateKey(t *testing.T) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return key, string(encoded)
+}
+
+func publicKey(t *testing.T, key *rsa.PrivateKey) ssh.PublicKey {
+	public, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return public
+}
+
+func Test_getAuth_SSH(t *testing.T) {
+	is := assert.New(t)
+
+	_, deployKey := generatePrivateKey(t)
+	hostKey, _ := generatePrivateKey(t)
+	otherHostKey, _ := generatePrivateKey(t)
+
+	credentials := &gittypes.SSHCredentials{
+		PrivateKey: deployKey,
+		KnownHosts: knownhosts.Line([]string{"git.example.com"}, publicKey(t, hostKey)),
+	}
+
+	client := NewGitClient(false)
+	opt := newBaseOption("git@git.example.com:portainer/stacks.git", &gittypes.GitAuthentication{
+		AuthorizationType: gittypes.GitCredentialAuthTypeSSH,
+		SSH:               credentials,
+	}, false)
+
+	auth, err := client.getAuth(context.Background(), opt)
+	is.NoError(err)
+
+	publicKeys, ok := auth.(*gitssh.PublicKeys)
+	is.True(ok)
+	is.Equal(gitssh.DefaultUsername, publicKeys.User)
+
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+	is.NoError(publicKeys.HostKeyCallback("git.example.com:22", remote, publicKey(t, hostKey)))
+	is.Error(publicKeys.HostKeyCallback("git.example.com:22", remote, publicKey(t, otherHostKey)), "the host keys which are not pinned should be rejected")
+	is.Error(publicKeys.HostKeyCallback("other.example.com:22", remote, publicKey(t, hostKey)), "the unknown hosts should be rejected")
+
+	credentials.KnownHosts = ""
+	_, err = client.getAuth(context.Background(), opt)
+	is.Error(err, "the known hosts should be required")
+
+	is.Error(ValidateSSHCredentials(&gittypes.SSHCredentials{PrivateKey: "invalid", KnownHosts: "git.example.com ssh-rsa AAAA"}))
+}
+
+func Test_getAuth_GitHubApp(t *testing.T) {
+	is := assert.New(t)
+
+	key, privateKey := generatePrivateKey(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		claims := &jwt.RegisteredClaims{}
+		_, err := jwt.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), claims, func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithoutClaimsValidation())
+		if err != nil || claims.Issuer != "7" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("token-%d", requests),
+			"expires_at": time.Date(2023, 1, 1, 13, 0, 0, 0, time.UTC),
+		})
+	}))
+	defer server.Close()
+
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	client := NewGitClient(false)
+	client.githubAppTokens.now = func() time.Time { return now }
+
+	app := &gittypes.GitHubAppCredentials{AppID: 7, InstallationID: 42, PrivateKey: privateKey, APIURL: server.URL + "/"}
+	opt := newBaseOption("https://github.com/portainer/stacks.git", &gittypes.GitAuthentication{
+		AuthorizationType: gittypes.GitCredentialAuthTypeGitHubApp,
+		GitHubApp:         app,
+	}, false)
+
+	auth, err := client.getAuth(context.Background(), opt)
+	is.NoError(err)
+	is.Equal(&githttp.BasicAuth{Username: githubAppTokenUsername, Password: "token-1"}, auth)
+
+	_, err = client.getAuth(context.Background(), opt)
+	is.NoError(err)
+	is.Equal(1, requests, "the installation token should be cached")
+
+	// the token is renewed before it expires
+	now = now.Add(56 * time.Minute)
+	auth, err = client.getAuth(context.Background(), opt)
+	is.NoError(err)
+	is.Equal(2, requests)
+	is.Equal("token-2", auth.(*githttp.BasicAuth).Password)
+
+	// the cached token is not returned to a caller holding another private key
+	_, otherPrivateKey := generatePrivateKey(t)
+	other := *app
+	other.PrivateKey = otherPrivateKey
+	_, err = client.githubAppTokens.token(context.Background(), &other)
+	is.ErrorIs(err, gittypes.ErrAuthenticationFailure)
+	is.Equal(3, requests, "the token should not be shared between private keys")
+
+	unknown := *app
+	unknown.InstallationID = 43
+	_, err = client.githubAppTokens.token(context.Background(), &unknown)
+	is.ErrorIs(err, gittypes.ErrAuthenticationFailure)
+
+	is.NoError(ValidateGitHubAppCredentials(app))
+	is.Error(ValidateGitHubAppCredentials(&gittypes.GitHubAppCredentials{AppID: 7, PrivateKey: privateKey}))
+}
+
+func Test_IsValidRepositoryURL(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(IsValidRepositoryURL("https://github.com/portainer/portainer.git"))
+	is.True(IsValidRepositoryURL("ssh://git@github.com:22/portainer/portainer.git"))
+	is.True(IsValidRepositoryURL("git@github.com:portainer/portainer.git"))
+	is.False(IsValidRepositoryURL("not a url"))
+}
@@ -77,6 +77,11 @@ func newHttpClientForAzure(insecureSkipVerify bool) *http.Client {
 }
 
 func (a *azureClient) download(ctx context.Context, destination string, opt cloneOption) error {
+	// the signatures are not supported, so there is no verified commit to check out
+	if opt.commitHash != "" {
+		return errors.New("cloning a specific commit is not supported by Azure DevOps")
+	}
+
 	zipFilepath, err := a.downloadZipFromAzureDevOps(ctx, opt)
 	if err != nil {
 		return errors.Wrap(err, "failed to download a zip file from Azure DevOps")
@@ -151,6 +156,11 @@ func (a *azureClient) latestCommitID(ctx context.Context, opt fetchOption) (stri
 	return rootItem.CommitId, nil
 }
 
+// verifySignature is not supported as the Azure DevOps API does not expose the signatures of the commits
+func (a *azureClient) verifySignature(ctx context.Context, opt fetchOption, verification *gittypes.SignatureVerification) (string, error) {
+	return "", errors.New("the signatures can't be verified for Azure DevOps repositories")
+}
+
 func (a *azureClient) getRootItem(ctx context.Context, opt fetchOption) (*azureItem, error) {
 	config, err := parseUrl(opt.repositoryUrl)
 	if err != nil {
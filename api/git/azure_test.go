@@ -382,6 +382,10 @@ func (t *testRepoManager) listRefs(_ context.Context, _ baseOption) ([]string, e
 func (t *testRepoManager) listFiles(_ context.Context, _ fetchOption) ([]string, error) {
 	return nil, nil
 }
+
+func (t *testRepoManager) verifySignature(_ context.Context, _ fetchOption, _ *gittypes.SignatureVerification) (string, error) {
+	return "", nil
+}
 func Test_cloneRepository_azure(t *testing.T) {
 	tests := []struct {
 		name   string
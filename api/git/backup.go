@@ -18,10 +18,13 @@ type CloneOptions struct {
 	ProjectPath   string
 	URL           string
 	ReferenceName string
-	Username      string
-	Password      string
+	// Authentication is nil for the public repositories
+	Authentication *gittypes.GitAuthentication
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
+	// CommitHash is the hash the cloned reference must have, e.g. the hash whose signature was verified.
+	// Not checked when empty
+	CommitHash string
 }
 
 func CloneWithBackup(gitService portainer.GitService, fileService portainer.FileService, options CloneOptions) (clean func(), err error) {
@@ -45,9 +48,16 @@ func CloneWithBackup(gitService portainer.GitService, fileService portainer.File
 
 	cleanUp = true
 
-	err = gitService.CloneRepository(options.ProjectPath, options.URL, options.ReferenceName, options.Username, options.Password, options.TLSSkipVerify)
+	err = gitService.CloneCommit(options.ProjectPath, options.URL, options.ReferenceName, options.CommitHash, options.Authentication, options.TLSSkipVerify)
 	if err != nil {
 		cleanUp = false
+
+		// removes what was cloned before the failure to restore the backup in place
+		removeError := fileService.RemoveDirectory(options.ProjectPath)
+		if removeError != nil {
+			log.Warn().Err(removeError).Msg("failed removing the cloned git repository directory")
+		}
+
 		restoreError := filesystem.MoveDirectory(backupProjectPath, options.ProjectPath)
 		if restoreError != nil {
 			log.Warn().Err(restoreError).Msg("failed restoring backup folder")
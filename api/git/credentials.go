@@ -4,10 +4,75 @@ import (
 	gittypes "github.com/portainer/portainer/api/git/types"
 )
 
-func GetCredentials(auth *gittypes.GitAuthentication) (string, string, error) {
-	if auth == nil {
-		return "", "", nil
+func GetCredentials(auth *gittypes.GitAuthentication) (*gittypes.GitAuthentication, error) {
+	return auth, nil
+}
+
+// KeepSavedSecrets fills the password and the private keys left empty in the authentication, as they are not
+// sent back by the API, with the saved ones of the same authentication type. The private key of a GitHub App is
+// only reused for the same installation of the same App on the same API, so that it can't be used to sign tokens
+// for another server
+func KeepSavedSecrets(auth, saved *gittypes.GitAuthentication) {
+	if auth == nil || saved == nil {
+		return
+	}
+
+	switch auth.AuthorizationType {
+	case gittypes.GitCredentialAuthTypeBasic:
+		if auth.Password == "" {
+			auth.Password = saved.Password
+		}
+	case gittypes.GitCredentialAuthTypeSSH:
+		if auth.SSH != nil && auth.SSH.PrivateKey == "" && saved.SSH != nil {
+			auth.SSH.PrivateKey = saved.SSH.PrivateKey
+			auth.SSH.Passphrase = saved.SSH.Passphrase
+		}
+	case gittypes.GitCredentialAuthTypeGitHubApp:
+		if auth.GitHubApp != nil && auth.GitHubApp.PrivateKey == "" && sameGitHubApp(auth.GitHubApp, saved.GitHubApp) {
+			auth.GitHubApp.PrivateKey = saved.GitHubApp.PrivateKey
+		}
+	}
+}
+
+func sameGitHubApp(app, saved *gittypes.GitHubAppCredentials) bool {
+	return saved != nil &&
+		app.APIURL == saved.APIURL &&
+		app.AppID == saved.AppID &&
+		app.InstallationID == saved.InstallationID
+}
+
+// CredentialsPayload is embedded by the payloads of the git repositories to support the SSH and the GitHub App
+// authentications, the basic authentication keeps using the username and the password of the payloads
+type CredentialsPayload struct {
+	// Type of the authentication used when the authentication is enabled
+	// Valid values are: 0 - 'basic', 1 - 'ssh', 2 - 'github app'
+	RepositoryAuthorizationType gittypes.GitCredentialAuthType `example:"0" enums:"0,1,2"`
+	// Deploy key of the SSH authentication
+	RepositorySSH *gittypes.SSHCredentials
+	// Installation of the GitHub App authentication
+	RepositoryGitHubApp *gittypes.GitHubAppCredentials
+}
+
+// Authentication returns the authentication described by a payload, nil when the authentication is disabled
+func (payload CredentialsPayload) Authentication(enabled bool, username, password string) *gittypes.GitAuthentication {
+	if !enabled {
+		return nil
+	}
+
+	auth := &gittypes.GitAuthentication{
+		Username:          username,
+		AuthorizationType: payload.RepositoryAuthorizationType,
+	}
+
+	switch payload.RepositoryAuthorizationType {
+	case gittypes.GitCredentialAuthTypeSSH:
+		auth.SSH = payload.RepositorySSH
+	case gittypes.GitCredentialAuthTypeGitHubApp:
+		auth.Username = ""
+		auth.GitHubApp = payload.RepositoryGitHubApp
+	default:
+		auth.Password = password
 	}
 
-	return auth.Username, auth.Password, nil
+	return auth
 }
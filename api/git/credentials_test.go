@@ -0,0 +1,46 @@
+package git
+
+import (
+	"testing"
+
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeepSavedSecrets(t *testing.T) {
+	is := assert.New(t)
+
+	saved := &gittypes.GitAuthentication{
+		AuthorizationType: gittypes.GitCredentialAuthTypeGitHubApp,
+		GitHubApp: &gittypes.GitHubAppCredentials{
+			AppID:          1,
+			InstallationID: 2,
+			PrivateKey:     "private-key",
+			APIURL:         "https://github.example.com/api/v3",
+		},
+	}
+
+	githubApp := func(apiURL string, appID, installationID int64) *gittypes.GitAuthentication {
+		return &gittypes.GitAuthentication{
+			AuthorizationType: gittypes.GitCredentialAuthTypeGitHubApp,
+			GitHubApp:         &gittypes.GitHubAppCredentials{AppID: appID, InstallationID: installationID, APIURL: apiURL},
+		}
+	}
+
+	auth := githubApp("https://github.example.com/api/v3", 1, 2)
+	KeepSavedSecrets(auth, saved)
+	is.Equal("private-key", auth.GitHubApp.PrivateKey, "the saved key should be kept for the same installation")
+
+	for _, auth := range []*gittypes.GitAuthentication{
+		githubApp("https://attacker.example.com", 1, 2),
+		githubApp("https://github.example.com/api/v3", 3, 2),
+		githubApp("https://github.example.com/api/v3", 1, 3),
+	} {
+		KeepSavedSecrets(auth, saved)
+		is.Empty(auth.GitHubApp.PrivateKey, "the saved key should not be reused for another installation")
+	}
+
+	auth = &gittypes.GitAuthentication{AuthorizationType: gittypes.GitCredentialAuthTypeBasic, Username: "user"}
+	KeepSavedSecrets(auth, &gittypes.GitAuthentication{Username: "user", Password: "password"})
+	is.Equal("password", auth.Password)
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/pkg/errors"
 	gittypes "github.com/portainer/portainer/api/git/types"
@@ -18,27 +17,34 @@ import (
 
 type gitClient struct {
 	preserveGitDirectory bool
+	githubAppTokens      *githubAppTokens
 }
 
 func NewGitClient(preserveGitDir bool) *gitClient {
 	return &gitClient{
 		preserveGitDirectory: preserveGitDir,
+		githubAppTokens:      newGitHubAppTokens(),
 	}
 }
 
 func (c *gitClient) download(ctx context.Context, dst string, opt cloneOption) error {
+	auth, err := c.getAuth(ctx, opt.baseOption)
+	if err != nil {
+		return err
+	}
+
 	gitOptions := git.CloneOptions{
 		URL:             opt.repositoryUrl,
 		Depth:           opt.depth,
 		InsecureSkipTLS: opt.tlsSkipVerify,
-		Auth:            getAuth(opt.username, opt.password),
+		Auth:            auth,
 	}
 
 	if opt.referenceName != "" {
 		gitOptions.ReferenceName = plumbing.ReferenceName(opt.referenceName)
 	}
 
-	_, err := git.PlainCloneContext(ctx, dst, false, &gitOptions)
+	repo, err := git.PlainCloneContext(ctx, dst, false, &gitOptions)
 
 	if err != nil {
 		if err.Error() == "authentication required" {
@@ -47,6 +53,13 @@ func (c *gitClient) download(ctx context.Context, dst string, opt cloneOption) e
 		return errors.Wrap(err, "failed to clone git repository")
 	}
 
+	if opt.commitHash != "" {
+		if err := checkClonedCommit(repo, opt); err != nil {
+			os.RemoveAll(dst)
+			return err
+		}
+	}
+
 	if !c.preserveGitDirectory {
 		os.RemoveAll(filepath.Join(dst, ".git"))
 	}
@@ -54,14 +67,37 @@ func (c *gitClient) download(ctx context.Context, dst string, opt cloneOption) e
 	return nil
 }
 
+// checkClonedCommit verifies that the cloned reference is at the expected commit, the reference is resolved like
+// in verifySignature so that an annotated tag is compared with the hash of the tag object
+func checkClonedCommit(repo *git.Repository, opt cloneOption) error {
+	ref, err := repo.Reference(plumbing.ReferenceName(opt.referenceName), true)
+	if err != nil {
+		ref, err = repo.Head()
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve the cloned reference")
+		}
+	}
+
+	if !strings.EqualFold(ref.Hash().String(), opt.commitHash) {
+		return errors.WithMessagef(gittypes.ErrUnexpectedCommit, "the cloned commit %s is not the commit %s", ref.Hash(), opt.commitHash)
+	}
+
+	return nil
+}
+
 func (c *gitClient) latestCommitID(ctx context.Context, opt fetchOption) (string, error) {
+	auth, err := c.getAuth(ctx, opt.baseOption)
+	if err != nil {
+		return "", err
+	}
+
 	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
 		Name: "origin",
 		URLs: []string{opt.repositoryUrl},
 	})
 
 	listOptions := &git.ListOptions{
-		Auth:            getAuth(opt.username, opt.password),
+		Auth:            auth,
 		InsecureSkipTLS: opt.tlsSkipVerify,
 	}
 
@@ -91,28 +127,19 @@ func (c *gitClient) latestCommitID(ctx context.Context, opt fetchOption) (string
 	return "", errors.Errorf("could not find ref %q in the repository", opt.referenceName)
 }
 
-func getAuth(username, password string) *githttp.BasicAuth {
-	if password != "" {
-		if username == "" {
-			username = "token"
-		}
-
-		return &githttp.BasicAuth{
-			Username: username,
-			Password: password,
-		}
+func (c *gitClient) listRefs(ctx context.Context, opt baseOption) ([]string, error) {
+	auth, err := c.getAuth(ctx, opt)
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
 
-func (c *gitClient) listRefs(ctx context.Context, opt baseOption) ([]string, error) {
 	rem := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
 		Name: "origin",
 		URLs: []string{opt.repositoryUrl},
 	})
 
 	listOptions := &git.ListOptions{
-		Auth:            getAuth(opt.username, opt.password),
+		Auth:            auth,
 		InsecureSkipTLS: opt.tlsSkipVerify,
 	}
 
@@ -134,13 +161,18 @@ func (c *gitClient) listRefs(ctx context.Context, opt baseOption) ([]string, err
 
 // listFiles list all filenames under the specific repository
 func (c *gitClient) listFiles(ctx context.Context, opt fetchOption) ([]string, error) {
+	auth, err := c.getAuth(ctx, opt.baseOption)
+	if err != nil {
+		return nil, err
+	}
+
 	cloneOption := &git.CloneOptions{
 		URL:             opt.repositoryUrl,
 		NoCheckout:      true,
 		Depth:           1,
 		SingleBranch:    true,
 		ReferenceName:   plumbing.ReferenceName(opt.referenceName),
-		Auth:            getAuth(opt.username, opt.password),
+		Auth:            auth,
 		InsecureSkipTLS: opt.tlsSkipVerify,
 	}
 
@@ -38,7 +38,7 @@ func Test_ClonePublicRepository_Shallow(t *testing.T) {
 
 	dir := t.TempDir()
 	t.Logf("Cloning into %s", dir)
-	err := service.CloneRepository(dir, repositoryURL, referenceName, "", "", false)
+	err := service.CloneRepository(dir, repositoryURL, referenceName, nil, false)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, getCommitHistoryLength(t, err, dir), "cloned repo has incorrect depth")
 }
@@ -50,7 +50,7 @@ func Test_ClonePublicRepository_NoGitDirectory(t *testing.T) {
 
 	dir := t.TempDir()
 	t.Logf("Cloning into %s", dir)
-	err := service.CloneRepository(dir, repositoryURL, referenceName, "", "", false)
+	err := service.CloneRepository(dir, repositoryURL, referenceName, nil, false)
 	assert.NoError(t, err)
 	assert.NoDirExists(t, filepath.Join(dir, ".git"))
 }
@@ -84,12 +84,29 @@ func Test_latestCommitID(t *testing.T) {
 	repositoryURL := setup(t)
 	referenceName := "refs/heads/main"
 
-	id, err := service.LatestCommitID(repositoryURL, referenceName, "", "", false)
+	id, err := service.LatestCommitID(repositoryURL, referenceName, nil, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "68dcaa7bd452494043c64252ab90db0f98ecf8d2", id)
 }
 
+func Test_CloneCommit(t *testing.T) {
+	service := Service{git: NewGitClient(false)} // no need for http client since the test access the repo via file system.
+
+	repositoryURL := setup(t)
+	referenceName := "refs/heads/main"
+
+	dir := t.TempDir()
+	err := service.CloneCommit(dir, repositoryURL, referenceName, "68dcaa7bd452494043c64252ab90db0f98ecf8d2", nil, false)
+	assert.NoError(t, err)
+	assert.DirExists(t, dir)
+
+	dir = filepath.Join(t.TempDir(), "project")
+	err = service.CloneCommit(dir, repositoryURL, referenceName, "0000000000000000000000000000000000000000", nil, false)
+	assert.ErrorIs(t, err, gittypes.ErrUnexpectedCommit)
+	assert.NoDirExists(t, dir, "the files of another commit should not be kept")
+}
+
 func getCommitHistoryLength(t *testing.T, err error, dir string) int {
 	repo, err := git.PlainOpen(dir)
 	if err != nil {
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	gittypes "github.com/portainer/portainer/api/git/types"
+)
+
+// PinningPayload is embedded by the payloads of the git stacks to follow the releases of the repository
+// instead of the head of a branch
+type PinningPayload struct {
+	// Semver constraint of the tags to follow instead of the reference name, e.g. ~1.4
+	RepositoryTagConstraint string `example:"~1.4"`
+	// Trusted keys of the signatures required on the deployed commits or tags
+	RepositorySignatureVerification *gittypes.SignatureVerification
+}
+
+// Validate verifies the tag constraint and the trusted keys of the payload
+func (payload PinningPayload) Validate() error {
+	if payload.RepositoryTagConstraint != "" {
+		if _, err := semver.NewConstraint(payload.RepositoryTagConstraint); err != nil {
+			return errors.Wrap(err, "invalid tag constraint")
+		}
+	}
+
+	return ValidateSignatureVerification(payload.RepositorySignatureVerification)
+}
+
+// Apply sets the tag constraint and the trusted keys of the payload on a git config
+func (payload PinningPayload) Apply(config *gittypes.RepoConfig) {
+	config.TagConstraint = payload.RepositoryTagConstraint
+	config.SignatureVerification = payload.RepositorySignatureVerification
+}
+
+// LatestTag returns the full name of the highest tag matching the semver constraint, the tags which
+// are not semantic versions are ignored
+func LatestTag(refs []string, constraint string) (string, error) {
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid tag constraint")
+	}
+
+	var latest *semver.Version
+	latestRef := ""
+
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref, tagPrefix) {
+			continue
+		}
+
+		version, err := semver.NewVersion(strings.TrimPrefix(ref, tagPrefix))
+		if err != nil || !constraints.Check(version) {
+			continue
+		}
+
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+			latestRef = ref
+		}
+	}
+
+	if latest == nil {
+		return "", errors.Errorf("no tag matches the constraint %q", constraint)
+	}
+
+	return latestRef, nil
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LatestTag(t *testing.T) {
+	refs := []string{
+		"refs/heads/main",
+		"refs/heads/v1.9.0",
+		"refs/tags/v1.3.9",
+		"refs/tags/v1.4.0",
+		"refs/tags/1.4.2",
+		"refs/tags/v1.4.10",
+		"refs/tags/v1.4.11-rc.1",
+		"refs/tags/v1.5.0",
+		"refs/tags/latest",
+	}
+
+	tests := []struct {
+		constraint string
+		expected   string
+	}{
+		{constraint: "~1.4", expected: "refs/tags/v1.4.10"},
+		{constraint: "^1", expected: "refs/tags/v1.5.0"},
+		{constraint: "< 1.4", expected: "refs/tags/v1.3.9"},
+		{constraint: "1.4.2", expected: "refs/tags/1.4.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			tag, err := LatestTag(refs, tt.constraint)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, tag)
+		})
+	}
+
+	_, err := LatestTag(refs, "~2")
+	assert.Error(t, err, "no tag should match the constraint")
+
+	_, err = LatestTag(refs, "not a constraint")
+	assert.Error(t, err)
+}
+
+func Test_PinningPayload_Validate(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(PinningPayload{}.Validate())
+	is.NoError(PinningPayload{RepositoryTagConstraint: ">= 1.2, < 2"}.Validate())
+	is.Error(PinningPayload{RepositoryTagConstraint: "one"}.Validate())
+}
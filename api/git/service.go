@@ -8,6 +8,8 @@ import (
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,6 +24,10 @@ type baseOption struct {
 	username      string
 	password      string
 	tlsSkipVerify bool
+	// ssh is set by the SSH authentication
+	ssh *gittypes.SSHCredentials
+	// githubApp is set by the GitHub App authentication
+	githubApp *gittypes.GitHubAppCredentials
 }
 
 // fetchOption allows to specify the reference name of the target repository
@@ -34,6 +40,8 @@ type fetchOption struct {
 type cloneOption struct {
 	fetchOption
 	depth int
+	// Hash the cloned reference must have, e.g. the hash whose signature was verified. Not checked when empty
+	commitHash string
 }
 
 type repoManager interface {
@@ -41,6 +49,7 @@ type repoManager interface {
 	latestCommitID(ctx context.Context, opt fetchOption) (string, error)
 	listRefs(ctx context.Context, opt baseOption) ([]string, error)
 	listFiles(ctx context.Context, opt fetchOption) ([]string, error)
+	verifySignature(ctx context.Context, opt fetchOption, verification *gittypes.SignatureVerification) (string, error)
 }
 
 // Service represents a service for managing Git.
@@ -119,17 +128,26 @@ func (service *Service) timerHasStopped() bool {
 	return ret
 }
 
+// repoManager returns the manager of the repository of the options, only the basic authentication
+// is supported by Azure DevOps
+func (service *Service) repoManager(options baseOption) (repoManager, error) {
+	if !isAzureUrl(options.repositoryUrl) {
+		return service.git, nil
+	}
+
+	if options.ssh != nil || options.githubApp != nil {
+		return nil, errors.New("only the basic authentication is supported by Azure DevOps repositories")
+	}
+
+	return service.azure, nil
+}
+
 // CloneRepository clones a git repository using the specified URL in the specified
 // destination folder.
-func (service *Service) CloneRepository(destination, repositoryURL, referenceName, username, password string, tlsSkipVerify bool) error {
+func (service *Service) CloneRepository(destination, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) error {
 	options := cloneOption{
 		fetchOption: fetchOption{
-			baseOption: baseOption{
-				repositoryUrl: repositoryURL,
-				username:      username,
-				password:      password,
-				tlsSkipVerify: tlsSkipVerify,
-			},
+			baseOption:    newBaseOption(repositoryURL, auth, tlsSkipVerify),
 			referenceName: referenceName,
 		},
 		depth: 1,
@@ -138,36 +156,67 @@ func (service *Service) CloneRepository(destination, repositoryURL, referenceNam
 	return service.cloneRepository(destination, options)
 }
 
+// CloneCommit clones a git repository like CloneRepository, and fails with ErrUnexpectedCommit when the reference
+// is not at the specified commit anymore, e.g. when it was updated after the verification of its signature. The
+// commit is not checked when its hash is empty
+func (service *Service) CloneCommit(destination, repositoryURL, referenceName, commitHash string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) error {
+	options := cloneOption{
+		fetchOption: fetchOption{
+			baseOption:    newBaseOption(repositoryURL, auth, tlsSkipVerify),
+			referenceName: referenceName,
+		},
+		depth:      1,
+		commitHash: commitHash,
+	}
+
+	return service.cloneRepository(destination, options)
+}
+
 func (service *Service) cloneRepository(destination string, options cloneOption) error {
-	if isAzureUrl(options.repositoryUrl) {
-		return service.azure.download(context.TODO(), destination, options)
+	manager, err := service.repoManager(options.baseOption)
+	if err != nil {
+		return err
 	}
 
-	return service.git.download(context.TODO(), destination, options)
+	return manager.download(context.TODO(), destination, options)
 }
 
 // LatestCommitID returns SHA1 of the latest commit of the specified reference
-func (service *Service) LatestCommitID(repositoryURL, referenceName, username, password string, tlsSkipVerify bool) (string, error) {
+func (service *Service) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) (string, error) {
 	options := fetchOption{
-		baseOption: baseOption{
-			repositoryUrl: repositoryURL,
-			username:      username,
-			password:      password,
-			tlsSkipVerify: tlsSkipVerify,
-		},
+		baseOption:    newBaseOption(repositoryURL, auth, tlsSkipVerify),
+		referenceName: referenceName,
+	}
+
+	manager, err := service.repoManager(options.baseOption)
+	if err != nil {
+		return "", err
+	}
+
+	return manager.latestCommitID(context.TODO(), options)
+}
+
+// VerifySignature verifies that the commit or the tag of the reference is signed by one of the trusted keys,
+// it returns the hash of the reference
+func (service *Service) VerifySignature(repositoryURL, referenceName string, auth *gittypes.GitAuthentication, tlsSkipVerify bool, verification *gittypes.SignatureVerification) (string, error) {
+	options := fetchOption{
+		baseOption:    newBaseOption(repositoryURL, auth, tlsSkipVerify),
 		referenceName: referenceName,
 	}
 
-	if isAzureUrl(options.repositoryUrl) {
-		return service.azure.latestCommitID(context.TODO(), options)
+	manager, err := service.repoManager(options.baseOption)
+	if err != nil {
+		return "", err
 	}
 
-	return service.git.latestCommitID(context.TODO(), options)
+	return manager.verifySignature(context.TODO(), options, verification)
 }
 
 // ListRefs will list target repository's references without cloning the repository
-func (service *Service) ListRefs(repositoryURL, username, password string, hardRefresh bool, tlsSkipVerify bool) ([]string, error) {
-	refCacheKey := generateCacheKey(repositoryURL, username, password, strconv.FormatBool(tlsSkipVerify))
+func (service *Service) ListRefs(repositoryURL string, auth *gittypes.GitAuthentication, hardRefresh bool, tlsSkipVerify bool) ([]string, error) {
+	options := newBaseOption(repositoryURL, auth, tlsSkipVerify)
+
+	refCacheKey := generateCacheKey(repositoryURL, options.credentialsKey(), strconv.FormatBool(tlsSkipVerify))
 	if service.cacheEnabled && hardRefresh {
 		// Should remove the cache explicitly, so that the following normal list can show the correct result
 		service.repoRefCache.Remove(refCacheKey)
@@ -193,27 +242,14 @@ func (service *Service) ListRefs(repositoryURL, username, password string, hardR
 		}
 	}
 
-	options := baseOption{
-		repositoryUrl: repositoryURL,
-		username:      username,
-		password:      password,
-		tlsSkipVerify: tlsSkipVerify,
+	manager, err := service.repoManager(options)
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		refs []string
-		err  error
-	)
-	if isAzureUrl(options.repositoryUrl) {
-		refs, err = service.azure.listRefs(context.TODO(), options)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		refs, err = service.git.listRefs(context.TODO(), options)
-		if err != nil {
-			return nil, err
-		}
+	refs, err := manager.listRefs(context.TODO(), options)
+	if err != nil {
+		return nil, err
 	}
 
 	if service.cacheEnabled && service.repoRefCache != nil {
@@ -224,8 +260,13 @@ func (service *Service) ListRefs(repositoryURL, username, password string, hardR
 
 // ListFiles will list all the files of the target repository with specific extensions.
 // If extension is not provided, it will list all the files under the target repository
-func (service *Service) ListFiles(repositoryURL, referenceName, username, password string, hardRefresh bool, includedExts []string, tlsSkipVerify bool) ([]string, error) {
-	repoKey := generateCacheKey(repositoryURL, referenceName, username, password, strconv.FormatBool(tlsSkipVerify))
+func (service *Service) ListFiles(repositoryURL, referenceName string, auth *gittypes.GitAuthentication, hardRefresh bool, includedExts []string, tlsSkipVerify bool) ([]string, error) {
+	options := fetchOption{
+		baseOption:    newBaseOption(repositoryURL, auth, tlsSkipVerify),
+		referenceName: referenceName,
+	}
+
+	repoKey := generateCacheKey(repositoryURL, referenceName, options.credentialsKey(), strconv.FormatBool(tlsSkipVerify))
 
 	if service.cacheEnabled && hardRefresh {
 		// Should remove the cache explicitly, so that the following normal list can show the correct result
@@ -246,30 +287,14 @@ func (service *Service) ListFiles(repositoryURL, referenceName, username, passwo
 		}
 	}
 
-	options := fetchOption{
-		baseOption: baseOption{
-			repositoryUrl: repositoryURL,
-			username:      username,
-			password:      password,
-			tlsSkipVerify: tlsSkipVerify,
-		},
-		referenceName: referenceName,
+	manager, err := service.repoManager(options.baseOption)
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		files []string
-		err   error
-	)
-	if isAzureUrl(options.repositoryUrl) {
-		files, err = service.azure.listFiles(context.TODO(), options)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		files, err = service.git.listFiles(context.TODO(), options)
-		if err != nil {
-			return nil, err
-		}
+	files, err := manager.listFiles(context.TODO(), options)
+	if err != nil {
+		return nil, err
 	}
 
 	includedFiles := filterFiles(files, includedExts)
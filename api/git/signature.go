@@ -0,0 +1,275 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/pkg/errors"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshSignatureMagic = "SSHSIG"
+	// namespace of the signatures made by git with SSH keys
+	sshSignatureNamespace = "git"
+	sshSignatureBegin     = "-----BEGIN SSH SIGNATURE-----"
+)
+
+var (
+	ErrUnsignedReference  = errors.New("the commit or the tag is not signed")
+	ErrUntrustedSignature = errors.New("the commit or the tag is not signed by a trusted key")
+)
+
+// ValidateSignatureVerification verifies that the trusted keys can be parsed
+func ValidateSignatureVerification(verification *gittypes.SignatureVerification) error {
+	if verification == nil {
+		return nil
+	}
+
+	if strings.TrimSpace(verification.GPGKeyRing) == "" && strings.TrimSpace(verification.SSHPublicKeys) == "" {
+		return errors.New("at least one trusted key is required to verify the signatures")
+	}
+
+	if strings.TrimSpace(verification.GPGKeyRing) != "" {
+		if _, err := openpgp.ReadArmoredKeyRing(strings.NewReader(verification.GPGKeyRing)); err != nil {
+			return errors.Wrap(err, "invalid GPG key ring")
+		}
+	}
+
+	_, err := parseSSHPublicKeys(verification.SSHPublicKeys)
+	return err
+}
+
+func parseSSHPublicKeys(keys string) ([]ssh.PublicKey, error) {
+	var publicKeys []ssh.PublicKey
+
+	for _, line := range strings.Split(keys, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid SSH public key")
+		}
+
+		publicKeys = append(publicKeys, key)
+	}
+
+	return publicKeys, nil
+}
+
+// verifySignature clones the reference in memory and verifies its signature. An annotated tag is trusted
+// when either the tag or its commit is signed by a trusted key.
+func (c *gitClient) verifySignature(ctx context.Context, opt fetchOption, verification *gittypes.SignatureVerification) (string, error) {
+	auth, err := c.getAuth(ctx, opt.baseOption)
+	if err != nil {
+		return "", err
+	}
+
+	cloneOption := &git.CloneOptions{
+		URL:             opt.repositoryUrl,
+		NoCheckout:      true,
+		Depth:           1,
+		SingleBranch:    true,
+		ReferenceName:   plumbing.ReferenceName(opt.referenceName),
+		Auth:            auth,
+		InsecureSkipTLS: opt.tlsSkipVerify,
+	}
+
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, cloneOption)
+	if err != nil {
+		return "", checkGitError(err)
+	}
+
+	ref, err := repo.Reference(plumbing.ReferenceName(opt.referenceName), true)
+	if err != nil {
+		ref, err = repo.Head()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if tag, err := repo.TagObject(ref.Hash()); err == nil {
+		if verifyTag(tag, verification) == nil {
+			return ref.Hash().String(), nil
+		}
+
+		commit, err := tag.Commit()
+		if err != nil {
+			return "", err
+		}
+
+		if err := verifyCommit(commit, verification); err != nil {
+			return "", err
+		}
+
+		return ref.Hash().String(), nil
+	}
+
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return "", err
+	}
+
+	if err := verifyCommit(commit, verification); err != nil {
+		return "", err
+	}
+
+	return ref.Hash().String(), nil
+}
+
+func verifyCommit(commit *object.Commit, verification *gittypes.SignatureVerification) error {
+	encoded := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		return err
+	}
+
+	return verifyObject(encoded, commit.PGPSignature, verification)
+}
+
+func verifyTag(tag *object.Tag, verification *gittypes.SignatureVerification) error {
+	signature := tag.PGPSignature
+	unsigned := *tag
+
+	// only the PGP signatures of the tags are parsed, the SSH signatures are kept at the end of their message
+	if signature == "" {
+		if i := strings.Index(tag.Message, sshSignatureBegin); i != -1 {
+			signature = tag.Message[i:]
+			unsigned.Message = tag.Message[:i]
+		}
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := unsigned.EncodeWithoutSignature(encoded); err != nil {
+		return err
+	}
+
+	return verifyObject(encoded, signature, verification)
+}
+
+func verifyObject(encoded plumbing.EncodedObject, signature string, verification *gittypes.SignatureVerification) error {
+	if signature == "" {
+		return ErrUnsignedReference
+	}
+
+	reader, err := encoded.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(signature, sshSignatureBegin) {
+		return verifySSHSignature(verification.SSHPublicKeys, signature, payload)
+	}
+
+	if strings.TrimSpace(verification.GPGKeyRing) == "" {
+		return ErrUntrustedSignature
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(verification.GPGKeyRing))
+	if err != nil {
+		return errors.Wrap(err, "invalid GPG key ring")
+	}
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(payload), strings.NewReader(signature)); err != nil {
+		return errors.WithMessage(ErrUntrustedSignature, err.Error())
+	}
+
+	return nil
+}
+
+// verifySSHSignature verifies a signature made by git with an SSH key, following the format of the signatures of ssh-keygen -Y sign
+func verifySSHSignature(publicKeys string, armoredSignature string, message []byte) error {
+	block, _ := pem.Decode([]byte(armoredSignature))
+	if block == nil || block.Type != "SSH SIGNATURE" || !bytes.HasPrefix(block.Bytes, []byte(sshSignatureMagic)) {
+		return errors.New("invalid SSH signature")
+	}
+
+	var signature struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+
+	if err := ssh.Unmarshal(block.Bytes[len(sshSignatureMagic):], &signature); err != nil {
+		return errors.Wrap(err, "invalid SSH signature")
+	}
+
+	if signature.Namespace != sshSignatureNamespace {
+		return errors.Errorf("invalid SSH signature namespace %q", signature.Namespace)
+	}
+
+	signer, err := ssh.ParsePublicKey(signature.PublicKey)
+	if err != nil {
+		return errors.Wrap(err, "invalid SSH signature key")
+	}
+
+	trustedKeys, err := parseSSHPublicKeys(publicKeys)
+	if err != nil {
+		return err
+	}
+
+	trusted := false
+	for _, key := range trustedKeys {
+		if bytes.Equal(key.Marshal(), signer.Marshal()) {
+			trusted = true
+			break
+		}
+	}
+
+	if !trusted {
+		return ErrUntrustedSignature
+	}
+
+	var h hash.Hash
+	switch signature.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return errors.Errorf("unsupported SSH signature hash algorithm %q", signature.HashAlgorithm)
+	}
+
+	h.Write(message)
+
+	signedData := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{signature.Namespace, signature.Reserved, signature.HashAlgorithm, h.Sum(nil)})...)
+
+	var sshSignature ssh.Signature
+	if err := ssh.Unmarshal(signature.Signature, &sshSignature); err != nil {
+		return errors.Wrap(err, "invalid SSH signature")
+	}
+
+	if err := signer.Verify(signedData, &sshSignature); err != nil {
+		return errors.WithMessage(ErrUntrustedSignature, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package git
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/ssh"
+)
+
+func newGPGKey(t *testing.T) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity("portainer", "", "release@example.com", &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keyRing bytes.Buffer
+	w, err := armor.Encode(&keyRing, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return entity, keyRing.String()
+}
+
+// newRepository creates a repository with a commit tagged v1.0.0, both signed with the key when it is set
+func newRepository(t *testing.T, signKey *openpgp.Entity) (string, *git.Repository, plumbing.Hash) {
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := worktree.Add("docker-compose.yml"); err != nil {
+		t.Fatal(err)
+	}
+
+	signature := &object.Signature{Name: "portainer", Email: "release@example.com", When: time.Now()}
+
+	hash, err := worktree.Commit("initial commit", &git.CommitOptions{Author: signature, SignKey: signKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.CreateTag("v1.0.0", hash, &git.CreateTagOptions{Tagger: signature, Message: "v1.0.0", SignKey: signKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, repo, hash
+}
+
+func Test_VerifySignature_GPG(t *testing.T) {
+	is := assert.New(t)
+
+	signKey, keyRing := newGPGKey(t)
+	_, otherKeyRing := newGPGKey(t)
+
+	repositoryURL, _, commitHash := newRepository(t, signKey)
+	service := Service{git: NewGitClient(false)}
+
+	hash, err := service.VerifySignature(repositoryURL, "refs/heads/master", nil, false, &gittypes.SignatureVerification{GPGKeyRing: keyRing})
+	is.NoError(err)
+	is.Equal(commitHash.String(), hash)
+
+	// the hash of an annotated tag is the hash of the tag object, as listed by the remote
+	hash, err = service.VerifySignature(repositoryURL, "refs/tags/v1.0.0", nil, false, &gittypes.SignatureVerification{GPGKeyRing: keyRing})
+	is.NoError(err)
+	tagHash, err := service.LatestCommitID(repositoryURL, "refs/tags/v1.0.0", nil, false)
+	is.NoError(err)
+	is.Equal(tagHash, hash)
+
+	_, err = service.VerifySignature(repositoryURL, "refs/heads/master", nil, false, &gittypes.SignatureVerification{GPGKeyRing: otherKeyRing})
+	is.ErrorIs(err, ErrUntrustedSignature)
+
+	unsignedURL, _, _ := newRepository(t, nil)
+	_, err = service.VerifySignature(unsignedURL, "refs/heads/master", nil, false, &gittypes.SignatureVerification{GPGKeyRing: keyRing})
+	is.ErrorIs(err, ErrUnsignedReference)
+}
+
+// sshSign signs the message like ssh-keygen -Y sign -n git
+func sshSign(t *testing.T, signer ssh.Signer, message []byte) string {
+	hash := sha512.Sum512(message)
+
+	signedData := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sshSignatureNamespace, "", "sha512", hash[:]})...)
+
+	signature, err := signer.Sign(rand.Reader, signedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{1, signer.PublicKey().Marshal(), sshSignatureNamespace, "", "sha512", ssh.Marshal(signature)})...)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob}))
+}
+
+func newSSHSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+func Test_VerifySignature_SSH(t *testing.T) {
+	is := assert.New(t)
+
+	signer := newSSHSigner(t)
+	otherSigner := newSSHSigner(t)
+
+	_, repo, commitHash := newRepository(t, nil)
+
+	commit, err := repo.CommitObject(commitHash)
+	is.NoError(err)
+
+	encoded := &plumbing.MemoryObject{}
+	is.NoError(commit.EncodeWithoutSignature(encoded))
+	payload, err := encoded.Reader()
+	is.NoError(err)
+
+	var message bytes.Buffer
+	_, err = message.ReadFrom(payload)
+	is.NoError(err)
+
+	commit.PGPSignature = sshSign(t, signer, message.Bytes())
+
+	verification := &gittypes.SignatureVerification{
+		SSHPublicKeys: "# release keys\n" + string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey())) + string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+	}
+	is.NoError(ValidateSignatureVerification(verification))
+	is.NoError(verifyCommit(commit, verification))
+
+	verification.SSHPublicKeys = string(ssh.MarshalAuthorizedKey(otherSigner.PublicKey()))
+	is.ErrorIs(verifyCommit(commit, verification), ErrUntrustedSignature)
+
+	// the signature does not match a modified commit
+	verification.SSHPublicKeys = string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	commit.Message = "modified commit"
+	is.ErrorIs(verifyCommit(commit, verification), ErrUntrustedSignature)
+
+	is.Error(ValidateSignatureVerification(&gittypes.SignatureVerification{}))
+	is.Error(ValidateSignatureVerification(&gittypes.SignatureVerification{SSHPublicKeys: "ssh-ed25519 invalid"}))
+}
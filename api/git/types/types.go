@@ -5,6 +5,7 @@ import "errors"
 var (
 	ErrIncorrectRepositoryURL = errors.New("git repository could not be found, please ensure that the URL is correct")
 	ErrAuthenticationFailure  = errors.New("authentication failed, please ensure that the git credentials are correct")
+	ErrUnexpectedCommit       = errors.New("the reference of the git repository was updated after the verification of its signature")
 )
 
 // RepoConfig represents a configuration for a repo
@@ -21,6 +22,19 @@ type RepoConfig struct {
 	ConfigHash string `example:"bc4c183d756879ea4d173315338110b31004b8e0"`
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
+	// Semver constraint of the tags to follow, e.g. ~1.4. When it is set, the reference name is
+	// the highest tag of the repository matching the constraint
+	TagConstraint string `json:",omitempty" example:"~1.4"`
+	// Trusted keys of the signatures required on the deployed commits or tags
+	SignatureVerification *SignatureVerification `json:",omitempty"`
+}
+
+// SignatureVerification represents the keys trusted to sign the commits or the tags of a repository
+type SignatureVerification struct {
+	// Armored OpenPGP public keys
+	GPGKeyRing string
+	// SSH public keys in the authorized_keys format
+	SSHPublicKeys string `example:"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl release@example.com"`
 }
 
 type GitAuthentication struct {
@@ -30,4 +44,57 @@ type GitAuthentication struct {
 	// When the value is 0, Username and Password are set without using saved credential
 	// This is introduced since 2.15.0
 	GitCredentialID int `example:"0"`
+	// Type of the authentication, Username and Password are used by the basic authentication
+	AuthorizationType GitCredentialAuthType `json:",omitempty" example:"0"`
+	// Deploy key used by the SSH authentication
+	SSH *SSHCredentials `json:",omitempty"`
+	// Installation used by the GitHub App authentication
+	GitHubApp *GitHubAppCredentials `json:",omitempty"`
+}
+
+// GitCredentialAuthType represents the type of the authentication to a git repository
+type GitCredentialAuthType int
+
+const (
+	// GitCredentialAuthTypeBasic authenticates with a username and a password or an access token
+	GitCredentialAuthTypeBasic GitCredentialAuthType = iota
+	// GitCredentialAuthTypeSSH authenticates with an SSH deploy key
+	GitCredentialAuthTypeSSH
+	// GitCredentialAuthTypeGitHubApp authenticates with the installation tokens of a GitHub App
+	GitCredentialAuthTypeGitHubApp
+)
+
+// SSHCredentials represents an SSH deploy key
+type SSHCredentials struct {
+	// Private key in PEM or OpenSSH format
+	PrivateKey string
+	// Passphrase of the private key, if it is encrypted
+	Passphrase string
+	// Entries in the known_hosts format pinning the host keys of the git server
+	KnownHosts string `example:"github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"`
+}
+
+// GitHubAppCredentials represents the installation of a GitHub App
+type GitHubAppCredentials struct {
+	AppID          int64 `example:"123456"`
+	InstallationID int64 `example:"7891011"`
+	// Private key of the GitHub App in PEM format
+	PrivateKey string
+	// URL of the GitHub API, e.g. https://github.example.com/api/v3 for GitHub Enterprise Server.
+	// Defaults to https://api.github.com
+	APIURL string `example:"https://api.github.com"`
+}
+
+// HideSecrets removes the password and the private keys, e.g. from the http responses
+func (auth *GitAuthentication) HideSecrets() {
+	auth.Password = ""
+
+	if auth.SSH != nil {
+		auth.SSH.PrivateKey = ""
+		auth.SSH.Passphrase = ""
+	}
+
+	if auth.GitHubApp != nil {
+		auth.GitHubApp.PrivateKey = ""
+	}
 }
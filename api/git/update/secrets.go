@@ -0,0 +1,22 @@
+package update
+
+import portainer "github.com/portainer/portainer/api"
+
+// HideWebhookSecret removes the secret of the webhook from the responses
+func HideWebhookSecret(autoUpdate *portainer.AutoUpdateSettings) {
+	if autoUpdate != nil {
+		autoUpdate.WebhookSecret = ""
+	}
+}
+
+// KeepSavedWebhookSecret fills the secret left empty, as it is not sent back by the API, with the saved one when the
+// webhook is unchanged
+func KeepSavedWebhookSecret(autoUpdate, saved *portainer.AutoUpdateSettings) {
+	if autoUpdate == nil || saved == nil {
+		return
+	}
+
+	if autoUpdate.WebhookSecret == "" && autoUpdate.Webhook != "" && autoUpdate.Webhook == saved.Webhook {
+		autoUpdate.WebhookSecret = saved.WebhookSecret
+	}
+}
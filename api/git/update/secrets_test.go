@@ -0,0 +1,30 @@
+package update
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeepSavedWebhookSecret(t *testing.T) {
+	is := assert.New(t)
+
+	saved := &portainer.AutoUpdateSettings{Webhook: "webhook", WebhookSecret: "secret"}
+
+	autoUpdate := &portainer.AutoUpdateSettings{Webhook: "webhook"}
+	KeepSavedWebhookSecret(autoUpdate, saved)
+	is.Equal("secret", autoUpdate.WebhookSecret, "the saved secret should be kept")
+
+	autoUpdate = &portainer.AutoUpdateSettings{Webhook: "webhook", WebhookSecret: "new-secret"}
+	KeepSavedWebhookSecret(autoUpdate, saved)
+	is.Equal("new-secret", autoUpdate.WebhookSecret, "the new secret should be used")
+
+	autoUpdate = &portainer.AutoUpdateSettings{Webhook: "other-webhook"}
+	KeepSavedWebhookSecret(autoUpdate, saved)
+	is.Empty(autoUpdate.WebhookSecret, "the secret of another webhook should not be reused")
+
+	HideWebhookSecret(saved)
+	is.Empty(saved.WebhookSecret)
+	is.Equal("webhook", saved.Webhook)
+}
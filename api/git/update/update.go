@@ -1,11 +1,14 @@
 package update
 
 import (
+	"fmt"
+	"os"
 	"strings"
 
 	"github.com/pkg/errors"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/rs/zerolog/log"
@@ -23,12 +26,16 @@ func UpdateGitObject(gitService portainer.GitService, objId string, gitConfig *g
 		Str("object", objId).
 		Msg("the object has a git config, try to poll from git repository")
 
-	username, password, err := git.GetCredentials(gitConfig.Authentication)
+	auth, err := git.GetCredentials(gitConfig.Authentication)
 	if err != nil {
 		return false, "", errors.WithMessagef(err, "failed to get credentials for %v", objId)
 	}
 
-	newHash, err := gitService.LatestCommitID(gitConfig.URL, gitConfig.ReferenceName, username, password, gitConfig.TLSSkipVerify)
+	if err := ResolveTagConstraint(gitService, gitConfig); err != nil {
+		return false, "", errors.WithMessagef(err, "failed to resolve the tag of %v", objId)
+	}
+
+	newHash, err := gitService.LatestCommitID(gitConfig.URL, gitConfig.ReferenceName, auth, gitConfig.TLSSkipVerify)
 	if err != nil {
 		return false, "", errors.WithMessagef(err, "failed to fetch latest commit id of %v", objId)
 	}
@@ -46,18 +53,24 @@ func UpdateGitObject(gitService portainer.GitService, objId string, gitConfig *g
 		return false, newHash, nil
 	}
 
+	verifiedHash, err := VerifySignature(gitService, gitConfig)
+	if err != nil {
+		return false, "", errors.WithMessagef(err, "failed to verify the signature of %v", objId)
+	}
+
+	if verifiedHash != "" && !strings.EqualFold(verifiedHash, newHash) {
+		return false, "", errors.Errorf("the reference of %v was updated during the verification of its signature", objId)
+	}
+
 	cloneParams := &cloneRepositoryParameters{
-		url:           gitConfig.URL,
-		ref:           gitConfig.ReferenceName,
-		toDir:         projectPath,
+		url:   gitConfig.URL,
+		ref:   gitConfig.ReferenceName,
+		toDir: projectPath,
+		auth:  auth,
+		// the signature is only valid for the cloned files when the reference was not updated in the meantime
+		commitHash:    verifiedHash,
 		tlsSkipVerify: gitConfig.TLSSkipVerify,
 	}
-	if gitConfig.Authentication != nil {
-		cloneParams.auth = &gitAuth{
-			username: username,
-			password: password,
-		}
-	}
 
 	if err := cloneGitRepository(gitService, cloneParams); err != nil {
 		return false, "", errors.WithMessagef(err, "failed to do a fresh clone of %v", objId)
@@ -73,24 +86,92 @@ func UpdateGitObject(gitService portainer.GitService, objId string, gitConfig *g
 	return true, newHash, nil
 }
 
+// ResolveTagConstraint sets the reference name of a git config to the highest tag of the repository
+// matching its tag constraint, it does nothing when the git config has no tag constraint
+func ResolveTagConstraint(gitService portainer.GitService, gitConfig *gittypes.RepoConfig) error {
+	if gitConfig.TagConstraint == "" {
+		return nil
+	}
+
+	// the refs are refreshed to find the tags pushed since the last update
+	refs, err := gitService.ListRefs(gitConfig.URL, gitConfig.Authentication, true, gitConfig.TLSSkipVerify)
+	if err != nil {
+		return errors.WithMessage(err, "failed to list the references of the repository")
+	}
+
+	tag, err := git.LatestTag(refs, gitConfig.TagConstraint)
+	if err != nil {
+		return err
+	}
+
+	if tag != gitConfig.ReferenceName {
+		log.Debug().
+			Str("url", gitConfig.URL).
+			Str("constraint", gitConfig.TagConstraint).
+			Str("previous_ref", gitConfig.ReferenceName).
+			Str("ref", tag).
+			Msg("a new tag matches the tag constraint")
+	}
+
+	gitConfig.ReferenceName = tag
+
+	return nil
+}
+
+// VerifySignature verifies the signature of the reference of a git config when it requires signatures,
+// it returns the hash of the verified reference, or an empty hash when the signatures are not required
+func VerifySignature(gitService portainer.GitService, gitConfig *gittypes.RepoConfig) (string, error) {
+	if gitConfig.SignatureVerification == nil {
+		return "", nil
+	}
+
+	return gitService.VerifySignature(gitConfig.URL, gitConfig.ReferenceName, gitConfig.Authentication, gitConfig.TLSSkipVerify, gitConfig.SignatureVerification)
+}
+
 type cloneRepositoryParameters struct {
 	url   string
 	ref   string
 	toDir string
-	auth  *gitAuth
+	auth  *gittypes.GitAuthentication
+	// commitHash is the hash the cloned reference must have, not checked when empty
+	commitHash string
 	// tlsSkipVerify skips SSL verification when cloning the Git repository
 	tlsSkipVerify bool `example:"false"`
 }
 
-type gitAuth struct {
-	username string
-	password string
-}
-
+// cloneGitRepository clones the repository in place of the previous files of the object, which are restored when
+// the clone fails, e.g. when the reference is not at the expected commit anymore
 func cloneGitRepository(gitService portainer.GitService, cloneParams *cloneRepositoryParameters) error {
-	if cloneParams.auth != nil {
-		return gitService.CloneRepository(cloneParams.toDir, cloneParams.url, cloneParams.ref, cloneParams.auth.username, cloneParams.auth.password, cloneParams.tlsSkipVerify)
+	exists, err := filesystem.FileExists(cloneParams.toDir)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return gitService.CloneCommit(cloneParams.toDir, cloneParams.url, cloneParams.ref, cloneParams.commitHash, cloneParams.auth, cloneParams.tlsSkipVerify)
+	}
+
+	backupPath := fmt.Sprintf("%s-old", cloneParams.toDir)
+
+	err = filesystem.MoveDirectory(cloneParams.toDir, backupPath)
+	if err != nil {
+		return errors.WithMessage(err, "unable to move the git repository directory")
+	}
+
+	err = gitService.CloneCommit(cloneParams.toDir, cloneParams.url, cloneParams.ref, cloneParams.commitHash, cloneParams.auth, cloneParams.tlsSkipVerify)
+	if err != nil {
+		os.RemoveAll(cloneParams.toDir)
+
+		if restoreErr := filesystem.MoveDirectory(backupPath, cloneParams.toDir); restoreErr != nil {
+			log.Warn().Err(restoreErr).Msg("failed restoring backup folder")
+		}
+
+		return err
+	}
+
+	if err := os.RemoveAll(backupPath); err != nil {
+		log.Warn().Err(err).Msg("unable to remove git repository directory")
 	}
 
-	return gitService.CloneRepository(cloneParams.toDir, cloneParams.url, cloneParams.ref, "", "", cloneParams.tlsSkipVerify)
+	return nil
 }
@@ -0,0 +1,60 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type unexpectedCommitGitService struct {
+	portainer.GitService
+}
+
+func (g *unexpectedCommitGitService) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) (string, error) {
+	return "verified", nil
+}
+
+func (g *unexpectedCommitGitService) VerifySignature(repositoryURL, referenceName string, auth *gittypes.GitAuthentication, tlsSkipVerify bool, verification *gittypes.SignatureVerification) (string, error) {
+	return "verified", nil
+}
+
+// CloneCommit clones files of another commit, as when the reference is updated after the verification
+func (g *unexpectedCommitGitService) CloneCommit(destination, repositoryURL, referenceName, commitHash string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) error {
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(destination, "docker-compose.yml"), []byte("unverified"), 0644); err != nil {
+		return err
+	}
+
+	return gittypes.ErrUnexpectedCommit
+}
+
+func Test_UpdateGitObject_RestoresTheFilesOfAnUnexpectedCommit(t *testing.T) {
+	is := assert.New(t)
+
+	projectPath := filepath.Join(t.TempDir(), "stack")
+	is.NoError(os.MkdirAll(projectPath, 0755))
+	is.NoError(os.WriteFile(filepath.Join(projectPath, "docker-compose.yml"), []byte("deployed"), 0644))
+
+	gitConfig := &gittypes.RepoConfig{
+		URL:                   "https://github.com/portainer/portainer.git",
+		ReferenceName:         "refs/heads/main",
+		ConfigHash:            "deployed",
+		SignatureVerification: &gittypes.SignatureVerification{},
+	}
+
+	updated, _, err := UpdateGitObject(&unexpectedCommitGitService{}, "stack:1", gitConfig, false, projectPath)
+	is.ErrorIs(err, gittypes.ErrUnexpectedCommit)
+	is.False(updated)
+
+	content, err := os.ReadFile(filepath.Join(projectPath, "docker-compose.yml"))
+	is.NoError(err)
+	is.Equal("deployed", string(content), "the deployed files should be restored")
+	is.NoDirExists(projectPath + "-old")
+}
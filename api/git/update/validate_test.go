@@ -23,6 +23,11 @@ func Test_ValidateAutoUpdate(t *testing.T) {
 			value:   &portainer.AutoUpdateSettings{Interval: "1dd2hh3mm"},
 			wantErr: true,
 		},
+		{
+			name:    "webhook secret without webhook",
+			value:   &portainer.AutoUpdateSettings{Interval: "5m", WebhookSecret: "secret"},
+			wantErr: true,
+		},
 		{
 			name: "valid auto update",
 			value: &portainer.AutoUpdateSettings{
@@ -1,14 +1,20 @@
 package git
 
 import (
+	"regexp"
+	"strings"
+
 	"github.com/asaskevich/govalidator"
 
 	gittypes "github.com/portainer/portainer/api/git/types"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 )
 
+// scpLikeURL matches the SSH URLs in the scp-like syntax, e.g. git@github.com:portainer/portainer.git
+var scpLikeURL = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[\w.~/-]+$`)
+
 func ValidateRepoConfig(repoConfig *gittypes.RepoConfig) error {
-	if govalidator.IsNull(repoConfig.URL) || !govalidator.IsURL(repoConfig.URL) {
+	if govalidator.IsNull(repoConfig.URL) || !IsValidRepositoryURL(repoConfig.URL) {
 		return httperrors.NewInvalidPayloadError("Invalid repository URL. Must correspond to a valid URL format")
 	}
 
@@ -16,9 +22,35 @@ func ValidateRepoConfig(repoConfig *gittypes.RepoConfig) error {
 
 }
 
+// IsValidRepositoryURL returns true for the HTTP URLs and the SSH URLs of the git repositories
+func IsValidRepositoryURL(url string) bool {
+	if strings.HasPrefix(url, "ssh://") {
+		return govalidator.IsURL("https://" + strings.TrimPrefix(url, "ssh://"))
+	}
+
+	return scpLikeURL.MatchString(url) || govalidator.IsURL(url)
+}
+
 func ValidateRepoAuthentication(auth *gittypes.GitAuthentication) error {
-	if auth != nil && govalidator.IsNull(auth.Password) {
-		return httperrors.NewInvalidPayloadError("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.AuthorizationType {
+	case gittypes.GitCredentialAuthTypeBasic:
+		if govalidator.IsNull(auth.Password) {
+			return httperrors.NewInvalidPayloadError("Invalid repository credentials. Password must be specified when authentication is enabled")
+		}
+	case gittypes.GitCredentialAuthTypeSSH:
+		if err := ValidateSSHCredentials(auth.SSH); err != nil {
+			return httperrors.NewInvalidPayloadError("Invalid repository credentials. " + err.Error())
+		}
+	case gittypes.GitCredentialAuthTypeGitHubApp:
+		if err := ValidateGitHubAppCredentials(auth.GitHubApp); err != nil {
+			return httperrors.NewInvalidPayloadError("Invalid repository credentials. " + err.Error())
+		}
+	default:
+		return httperrors.NewInvalidPayloadError("Invalid repository authorization type")
 	}
 
 	return nil
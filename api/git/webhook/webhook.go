@@ -0,0 +1,470 @@
+package gitwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
+
+	"github.com/pkg/errors"
+)
+
+// Provider is a git provider sending push webhooks
+type Provider string
+
+const (
+	ProviderGitHub    Provider = "github"
+	ProviderGitLab    Provider = "gitlab"
+	ProviderGitea     Provider = "gitea"
+	ProviderBitbucket Provider = "bitbucket"
+)
+
+var (
+	ErrMissingSignature = errors.New("the webhook call is not signed")
+	ErrInvalidSignature = errors.New("the signature of the webhook call is invalid")
+	ErrNotPush          = errors.New("the webhook call is not a push event")
+)
+
+// maxBodySize limits the size of the webhook payloads which are read
+const maxBodySize = 25 << 20
+
+const zeroCommit = "0000000000000000000000000000000000000000"
+
+// PushEvent is a push to a git repository, as described by the webhook of its provider
+type PushEvent struct {
+	Provider Provider
+	// Full name of the pushed reference, e.g. refs/heads/main
+	Ref    string
+	Commit string
+	// Default branch of the repository, empty when the provider does not send it
+	DefaultBranch  string
+	RepositoryURLs []string
+	// Paths changed by the pushed commits, only meaningful when FilesKnown is true
+	ChangedFiles []string
+	// FilesKnown is false when the provider does not list the changed files, or lists them partially
+	FilesKnown bool
+	// Deleted is true when the reference was removed by the push
+	Deleted bool
+}
+
+// DetectProvider returns the provider of a webhook call from its headers.
+// Gitea also sends the GitHub headers, so it is detected first.
+func DetectProvider(header http.Header) (Provider, bool) {
+	switch {
+	case header.Get("X-Gitea-Event") != "":
+		return ProviderGitea, true
+	case header.Get("X-Gitlab-Event") != "":
+		return ProviderGitLab, true
+	case header.Get("X-GitHub-Event") != "":
+		return ProviderGitHub, true
+	case header.Get("X-Event-Key") != "":
+		return ProviderBitbucket, true
+	}
+
+	return "", false
+}
+
+// IsPushEvent returns true when the webhook call describes a push, the other events such as the ping
+// sent when a webhook is created are ignored
+func IsPushEvent(provider Provider, header http.Header) bool {
+	switch provider {
+	case ProviderGitHub:
+		return header.Get("X-GitHub-Event") == "push"
+	case ProviderGitea:
+		return header.Get("X-Gitea-Event") == "push"
+	case ProviderGitLab:
+		event := header.Get("X-Gitlab-Event")
+		return event == "Push Hook" || event == "Tag Push Hook"
+	case ProviderBitbucket:
+		// repo:push is sent by Bitbucket Cloud and repo:refs_changed by Bitbucket Server
+		event := header.Get("X-Event-Key")
+		return event == "repo:push" || event == "repo:refs_changed"
+	}
+
+	return false
+}
+
+// VerifySignature validates the HMAC signature of the body, or the token for GitLab, with the secret of the webhook
+func VerifySignature(provider Provider, header http.Header, body []byte, secret string) error {
+	var signature string
+
+	switch provider {
+	case ProviderGitLab:
+		token := header.Get("X-Gitlab-Token")
+		if token == "" {
+			return ErrMissingSignature
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	case ProviderGitHub:
+		signature = header.Get("X-Hub-Signature-256")
+	case ProviderGitea:
+		signature = header.Get("X-Gitea-Signature")
+		if signature == "" {
+			signature = header.Get("X-Hub-Signature-256")
+		}
+	case ProviderBitbucket:
+		signature = header.Get("X-Hub-Signature")
+	default:
+		return errors.Errorf("unsupported git provider %q", provider)
+	}
+
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// IsSignatureError returns true when a webhook call was rejected because of its signature
+func IsSignatureError(err error) bool {
+	return errors.Is(err, ErrMissingSignature) || errors.Is(err, ErrInvalidSignature)
+}
+
+// ReadPush validates and parses the webhook call of a git provider. The secret is required to be validated when
+// it is not empty. The push is nil when the call was not sent by a supported provider, such calls are only
+// accepted without secret. ErrNotPush is returned for the events other than the pushes.
+func ReadPush(r *http.Request, secret string) (*PushEvent, error) {
+	provider, ok := DetectProvider(r.Header)
+	if !ok {
+		if secret != "" {
+			return nil, ErrMissingSignature
+		}
+
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the webhook payload")
+	}
+
+	if secret != "" {
+		if err := VerifySignature(provider, r.Header, body, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if !IsPushEvent(provider, r.Header) {
+		return nil, ErrNotPush
+	}
+
+	return ParsePushEvent(provider, body)
+}
+
+type commitFiles struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// githubPush is the push payload of GitHub, also sent by Gitea
+type githubPush struct {
+	Ref        string        `json:"ref"`
+	After      string        `json:"after"`
+	Deleted    bool          `json:"deleted"`
+	Commits    []commitFiles `json:"commits"`
+	Repository struct {
+		CloneURL      string `json:"clone_url"`
+		SSHURL        string `json:"ssh_url"`
+		HTMLURL       string `json:"html_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+}
+
+type gitlabPush struct {
+	Ref               string        `json:"ref"`
+	After             string        `json:"after"`
+	CheckoutSHA       string        `json:"checkout_sha"`
+	TotalCommitsCount int           `json:"total_commits_count"`
+	Commits           []commitFiles `json:"commits"`
+	Project           struct {
+		GitHTTPURL    string `json:"git_http_url"`
+		GitSSHURL     string `json:"git_ssh_url"`
+		WebURL        string `json:"web_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"project"`
+}
+
+type bitbucketLink struct {
+	Href string `json:"href"`
+}
+
+// bitbucketPush covers both the Bitbucket Cloud and the Bitbucket Server payloads
+type bitbucketPush struct {
+	// Bitbucket Cloud
+	Push struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+	// Bitbucket Server
+	Changes []struct {
+		Ref struct {
+			ID string `json:"id"`
+		} `json:"ref"`
+		ToHash string `json:"toHash"`
+		Type   string `json:"type"`
+	} `json:"changes"`
+	Repository struct {
+		// the links are objects in Bitbucket Cloud and arrays in Bitbucket Server
+		Links struct {
+			HTML  json.RawMessage `json:"html"`
+			Clone json.RawMessage `json:"clone"`
+			Self  json.RawMessage `json:"self"`
+		} `json:"links"`
+	} `json:"repository"`
+}
+
+// ParsePushEvent parses the push payload of a provider. Bitbucket does not list the changed files.
+func ParsePushEvent(provider Provider, body []byte) (*PushEvent, error) {
+	event := &PushEvent{Provider: provider}
+
+	switch provider {
+	case ProviderGitHub, ProviderGitea:
+		var payload githubPush
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, errors.Wrap(err, "invalid push payload")
+		}
+
+		event.Ref = payload.Ref
+		event.Commit = payload.After
+		event.Deleted = payload.Deleted
+		event.DefaultBranch = payload.Repository.DefaultBranch
+		event.RepositoryURLs = []string{payload.Repository.CloneURL, payload.Repository.SSHURL, payload.Repository.HTMLURL}
+		event.ChangedFiles = changedFiles(payload.Commits)
+		// the branch creations and the force pushes can come without commits
+		event.FilesKnown = len(payload.Commits) > 0
+
+	case ProviderGitLab:
+		var payload gitlabPush
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, errors.Wrap(err, "invalid push payload")
+		}
+
+		event.Ref = payload.Ref
+		event.Commit = payload.After
+		if payload.CheckoutSHA != "" {
+			event.Commit = payload.CheckoutSHA
+		}
+		event.DefaultBranch = payload.Project.DefaultBranch
+		event.RepositoryURLs = []string{payload.Project.GitHTTPURL, payload.Project.GitSSHURL, payload.Project.WebURL}
+		event.ChangedFiles = changedFiles(payload.Commits)
+		// GitLab only sends the details of the last 20 commits
+		event.FilesKnown = len(payload.Commits) > 0 && payload.TotalCommitsCount <= len(payload.Commits)
+
+	case ProviderBitbucket:
+		var payload bitbucketPush
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, errors.Wrap(err, "invalid push payload")
+		}
+
+		switch {
+		case len(payload.Push.Changes) > 0:
+			change := payload.Push.Changes[len(payload.Push.Changes)-1]
+			if change.New == nil {
+				event.Deleted = true
+				break
+			}
+
+			prefix := "refs/heads/"
+			if change.New.Type == "tag" {
+				prefix = "refs/tags/"
+			}
+
+			event.Ref = prefix + change.New.Name
+			event.Commit = change.New.Target.Hash
+		case len(payload.Changes) > 0:
+			change := payload.Changes[len(payload.Changes)-1]
+			event.Ref = change.Ref.ID
+			event.Commit = change.ToHash
+			event.Deleted = change.Type == "DELETE"
+		default:
+			return nil, errors.New("invalid push payload, no change found")
+		}
+
+		for _, links := range []json.RawMessage{payload.Repository.Links.HTML, payload.Repository.Links.Clone, payload.Repository.Links.Self} {
+			event.RepositoryURLs = append(event.RepositoryURLs, bitbucketHrefs(links)...)
+		}
+
+	default:
+		return nil, errors.Errorf("unsupported git provider %q", provider)
+	}
+
+	if event.Commit == zeroCommit {
+		event.Deleted = true
+	}
+
+	return event, nil
+}
+
+func bitbucketHrefs(links json.RawMessage) []string {
+	var list []bitbucketLink
+	if json.Unmarshal(links, &list) != nil {
+		var link bitbucketLink
+		if json.Unmarshal(links, &link) != nil {
+			return nil
+		}
+
+		list = []bitbucketLink{link}
+	}
+
+	hrefs := []string{}
+	for _, link := range list {
+		hrefs = append(hrefs, link.Href)
+	}
+
+	return hrefs
+}
+
+func changedFiles(commits []commitFiles) []string {
+	seen := map[string]bool{}
+	files := []string{}
+
+	for _, commit := range commits {
+		for _, list := range [][]string{commit.Added, commit.Removed, commit.Modified} {
+			for _, file := range list {
+				if !seen[file] {
+					seen[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+	}
+
+	return files
+}
+
+// Record returns the push to save on the object it redeployed
+func (event *PushEvent) Record(receivedAt time.Time) *portainer.GitWebhookPush {
+	return &portainer.GitWebhookPush{
+		Provider:   string(event.Provider),
+		Ref:        event.Ref,
+		Commit:     event.Commit,
+		ReceivedAt: receivedAt.Unix(),
+	}
+}
+
+// Affects returns true when the push can change the files of a git config: the push updates the
+// repository and the reference of the config and changes the files
+func (event *PushEvent) Affects(config *gittypes.RepoConfig, files []string) bool {
+	return config != nil && !event.Deleted && event.MatchesRepository(config.URL) && event.MatchesRef(config.ReferenceName) && event.AffectsFiles(files)
+}
+
+// MatchesRef returns true when the pushed reference is the reference name of a git config.
+// An empty reference name stands for the default branch of the repository.
+func (event *PushEvent) MatchesRef(referenceName string) bool {
+	if referenceName == "" {
+		// without the default branch, the push can't be excluded
+		return event.DefaultBranch == "" || event.Ref == "refs/heads/"+event.DefaultBranch
+	}
+
+	if !strings.HasPrefix(referenceName, "refs/") {
+		return event.Ref == "refs/heads/"+referenceName || event.Ref == "refs/tags/"+referenceName
+	}
+
+	return event.Ref == referenceName
+}
+
+// MatchesRepository returns true when the repository of the push is the repository of the URL,
+// or when the provider does not describe the repository
+func (event *PushEvent) MatchesRepository(url string) bool {
+	known := false
+
+	for _, repositoryURL := range event.RepositoryURLs {
+		if repositoryURL == "" {
+			continue
+		}
+
+		known = true
+		if normalizeURL(repositoryURL) == normalizeURL(url) {
+			return true
+		}
+	}
+
+	return !known
+}
+
+// AffectsFiles returns true when the push changed one of the files, or a file of the directory of one
+// of the files when it is not the root of the repository. A push is assumed to affect the files when
+// its changed files are not known.
+func (event *PushEvent) AffectsFiles(files []string) bool {
+	if !event.FilesKnown {
+		return true
+	}
+
+	for _, changed := range event.ChangedFiles {
+		changed = path.Clean(changed)
+
+		for _, file := range files {
+			file = path.Clean(strings.TrimPrefix(file, "/"))
+			if changed == file {
+				return true
+			}
+
+			if dir := path.Dir(file); dir != "." && strings.HasPrefix(changed, dir+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// normalizeURL reduces the HTTP and the SSH URLs of a repository to host/path
+func normalizeURL(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+
+	if scheme := strings.Index(url, "://"); scheme != -1 {
+		url = url[scheme+3:]
+	} else if host, repositoryPath, ok := strings.Cut(url, ":"); ok {
+		// scp-like syntax, e.g. git@github.com:portainer/portainer.git
+		url = host + "/" + repositoryPath
+	}
+
+	if at := strings.LastIndex(url, "@"); at != -1 {
+		url = url[at+1:]
+	}
+
+	// the ports differ between the HTTP and the SSH URLs
+	if host, repositoryPath, ok := strings.Cut(url, "/"); ok {
+		host, _, _ = strings.Cut(host, ":")
+		url = host + "/" + repositoryPath
+	}
+
+	// the clone URLs of Bitbucket Server are prefixed with /scm
+	url = strings.Replace(url, "/scm/", "/", 1)
+
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
@@ -0,0 +1,153 @@
+package gitwebhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gittypes "github.com/portainer/portainer/api/git/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const githubPayload = `{
+	"ref": "refs/heads/main",
+	"after": "bc4c183d756879ea4d173315338110b31004b8e0",
+	"repository": {
+		"clone_url": "https://github.com/portainer/stacks.git",
+		"ssh_url": "git@github.com:portainer/stacks.git",
+		"html_url": "https://github.com/portainer/stacks",
+		"default_branch": "main"
+	},
+	"commits": [
+		{"added": ["web/nginx.conf"], "removed": [], "modified": []},
+		{"added": [], "removed": ["README.md"], "modified": ["web/nginx.conf"]}
+	]
+}`
+
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newRequest(body string, headers map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(body))
+	for key, value := range headers {
+		r.Header.Set(key, value)
+	}
+
+	return r
+}
+
+func Test_ReadPush(t *testing.T) {
+	is := assert.New(t)
+
+	body := []byte(githubPayload)
+
+	push, err := ReadPush(newRequest(githubPayload, map[string]string{
+		"X-GitHub-Event":      "push",
+		"X-Hub-Signature-256": "sha256=" + sign(body, "secret"),
+	}), "secret")
+	is.NoError(err)
+	is.Equal(ProviderGitHub, push.Provider)
+	is.Equal("refs/heads/main", push.Ref)
+	is.Equal("bc4c183d756879ea4d173315338110b31004b8e0", push.Commit)
+	is.Equal([]string{"web/nginx.conf", "README.md"}, push.ChangedFiles)
+	is.True(push.FilesKnown)
+
+	_, err = ReadPush(newRequest(githubPayload, map[string]string{
+		"X-GitHub-Event":      "push",
+		"X-Hub-Signature-256": "sha256=" + sign(body, "other"),
+	}), "secret")
+	is.ErrorIs(err, ErrInvalidSignature)
+
+	_, err = ReadPush(newRequest(githubPayload, map[string]string{"X-GitHub-Event": "push"}), "secret")
+	is.ErrorIs(err, ErrMissingSignature)
+
+	_, err = ReadPush(newRequest("{}", map[string]string{"X-GitHub-Event": "ping"}), "")
+	is.ErrorIs(err, ErrNotPush)
+
+	// the calls which are not sent by a provider are only accepted without secret
+	push, err = ReadPush(newRequest("", nil), "")
+	is.NoError(err)
+	is.Nil(push)
+
+	_, err = ReadPush(newRequest("", nil), "secret")
+	is.ErrorIs(err, ErrMissingSignature)
+
+	push, err = ReadPush(newRequest(githubPayload, map[string]string{
+		"X-Gitea-Event":     "push",
+		"X-GitHub-Event":    "push",
+		"X-Gitea-Signature": sign(body, "secret"),
+	}), "secret")
+	is.NoError(err)
+	is.Equal(ProviderGitea, push.Provider)
+
+	push, err = ReadPush(newRequest(`{"ref": "refs/tags/v1", "checkout_sha": "abc", "total_commits_count": 30, "commits": [{"modified": ["a.yml"]}]}`, map[string]string{
+		"X-Gitlab-Event": "Tag Push Hook",
+		"X-Gitlab-Token": "secret",
+	}), "secret")
+	is.NoError(err)
+	is.Equal("refs/tags/v1", push.Ref)
+	is.Equal("abc", push.Commit)
+	is.False(push.FilesKnown)
+
+	_, err = ReadPush(newRequest(`{}`, map[string]string{
+		"X-Gitlab-Event": "Push Hook",
+		"X-Gitlab-Token": "wrong",
+	}), "secret")
+	is.ErrorIs(err, ErrInvalidSignature)
+}
+
+func Test_ParsePushEvent_Bitbucket(t *testing.T) {
+	is := assert.New(t)
+
+	push, err := ParsePushEvent(ProviderBitbucket, []byte(`{
+		"push": {"changes": [{"new": {"type": "branch", "name": "main", "target": {"hash": "abc"}}}]},
+		"repository": {"links": {"html": {"href": "https://bitbucket.org/portainer/stacks"}, "self": {"href": "https://api.bitbucket.org/2.0/repositories/portainer/stacks"}}}
+	}`))
+	is.NoError(err)
+	is.Equal("refs/heads/main", push.Ref)
+	is.Equal("abc", push.Commit)
+	is.False(push.FilesKnown)
+	is.True(push.MatchesRepository("https://bitbucket.org/portainer/stacks.git"))
+
+	push, err = ParsePushEvent(ProviderBitbucket, []byte(`{
+		"changes": [{"ref": {"id": "refs/heads/dev"}, "toHash": "0000000000000000000000000000000000000000", "type": "DELETE"}],
+		"repository": {"links": {"clone": [{"href": "ssh://git@bitbucket.example.com:7999/prj/stacks.git"}]}}
+	}`))
+	is.NoError(err)
+	is.Equal("refs/heads/dev", push.Ref)
+	is.True(push.Deleted)
+	is.True(push.MatchesRepository("https://bitbucket.example.com/scm/prj/stacks.git"))
+}
+
+func Test_Affects(t *testing.T) {
+	is := assert.New(t)
+
+	push, err := ParsePushEvent(ProviderGitHub, []byte(githubPayload))
+	is.NoError(err)
+
+	config := &gittypes.RepoConfig{URL: "https://github.com/Portainer/stacks", ConfigFilePath: "web/docker-compose.yml"}
+
+	is.True(push.Affects(config, []string{"web/docker-compose.yml"}))
+	is.True(push.Affects(config, []string{"README.md"}))
+	is.False(push.Affects(config, []string{"docker-compose.yml"}))
+	is.False(push.Affects(config, []string{"api/docker-compose.yml"}))
+	is.False(push.Affects(nil, []string{"web/docker-compose.yml"}))
+
+	config.ReferenceName = "refs/heads/dev"
+	is.False(push.Affects(config, []string{"web/docker-compose.yml"}))
+
+	config.ReferenceName = "main"
+	is.True(push.Affects(config, []string{"web/docker-compose.yml"}))
+
+	config.URL = "https://github.com/portainer/other.git"
+	is.False(push.Affects(config, []string{"web/docker-compose.yml"}))
+}
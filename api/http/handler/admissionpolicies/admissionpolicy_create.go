@@ -0,0 +1,73 @@
+package admissionpolicies
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type admissionPolicyCreatePayload struct {
+	// Name of the admission policy
+	Name string `validate:"required" example:"production"`
+	// Whether the policy is evaluated
+	Enabled bool `example:"true"`
+	// Environments(endpoints) the policy is attached to
+	EndpointIDs []portainer.EndpointID
+	// Environment(endpoint) groups the policy is attached to
+	EndpointGroupIDs []portainer.EndpointGroupID
+	// Rules evaluated against every container
+	Rules portainer.AdmissionPolicyRules
+}
+
+func (payload *admissionPolicyCreatePayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Name) {
+		return errors.New("invalid admission policy name")
+	}
+
+	return validateRules(payload.Rules)
+}
+
+// @id AdmissionPolicyCreate
+// @summary Create an admission policy
+// @description Create a policy evaluated before the creation of the containers and the deployment of the stacks
+// @description of the environments(endpoints) and environment(endpoint) groups it is attached to.
+// @description **Access policy**: administrator
+// @tags admission_policies
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body admissionPolicyCreatePayload true "Admission policy details"
+// @success 200 {object} portainer.AdmissionPolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /admission_policies [post]
+func (handler *Handler) admissionPolicyCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload admissionPolicyCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	policy := &portainer.AdmissionPolicy{
+		Name:             payload.Name,
+		Enabled:          payload.Enabled,
+		EndpointIDs:      payload.EndpointIDs,
+		EndpointGroupIDs: payload.EndpointGroupIDs,
+		Rules:            payload.Rules,
+	}
+	sanitizePolicy(policy)
+
+	err = handler.DataStore.AdmissionPolicy().Create(policy)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the admission policy inside the database", err)
+	}
+
+	return response.JSON(w, policy)
+}
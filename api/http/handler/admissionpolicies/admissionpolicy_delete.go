@@ -0,0 +1,44 @@
+package admissionpolicies
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id AdmissionPolicyDelete
+// @summary Remove an admission policy
+// @description **Access policy**: administrator
+// @tags admission_policies
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Admission policy identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Admission policy not found"
+// @failure 500 "Server error"
+// @router /admission_policies/{id} [delete]
+func (handler *Handler) admissionPolicyDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid admission policy identifier route variable", err)
+	}
+	policyID := portainer.AdmissionPolicyID(id)
+
+	_, err = handler.DataStore.AdmissionPolicy().AdmissionPolicy(policyID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an admission policy with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an admission policy with the specified identifier inside the database", err)
+	}
+
+	err = handler.DataStore.AdmissionPolicy().DeleteAdmissionPolicy(policyID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the admission policy from the database", err)
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,39 @@
+package admissionpolicies
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id AdmissionPolicyInspect
+// @summary Inspect an admission policy
+// @description **Access policy**: administrator
+// @tags admission_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Admission policy identifier"
+// @success 200 {object} portainer.AdmissionPolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Admission policy not found"
+// @failure 500 "Server error"
+// @router /admission_policies/{id} [get]
+func (handler *Handler) admissionPolicyInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid admission policy identifier route variable", err)
+	}
+
+	policy, err := handler.DataStore.AdmissionPolicy().AdmissionPolicy(portainer.AdmissionPolicyID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an admission policy with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an admission policy with the specified identifier inside the database", err)
+	}
+
+	return response.JSON(w, policy)
+}
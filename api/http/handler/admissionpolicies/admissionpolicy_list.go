@@ -0,0 +1,27 @@
+package admissionpolicies
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+)
+
+// @id AdmissionPolicyList
+// @summary List admission policies
+// @description **Access policy**: administrator
+// @tags admission_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.AdmissionPolicy "Success"
+// @failure 500 "Server error"
+// @router /admission_policies [get]
+func (handler *Handler) admissionPolicyList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policies, err := handler.DataStore.AdmissionPolicy().AdmissionPolicies()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve admission policies from the database", err)
+	}
+
+	return response.JSON(w, policies)
+}
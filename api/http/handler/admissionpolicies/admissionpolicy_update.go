@@ -0,0 +1,101 @@
+package admissionpolicies
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type admissionPolicyUpdatePayload struct {
+	// Name of the admission policy
+	Name *string `example:"production"`
+	// Whether the policy is evaluated
+	Enabled *bool `example:"true"`
+	// Environments(endpoints) the policy is attached to
+	EndpointIDs []portainer.EndpointID
+	// Environment(endpoint) groups the policy is attached to
+	EndpointGroupIDs []portainer.EndpointGroupID
+	// Rules evaluated against every container
+	Rules *portainer.AdmissionPolicyRules
+}
+
+func (payload *admissionPolicyUpdatePayload) Validate(r *http.Request) error {
+	if payload.Name != nil && govalidator.IsNull(*payload.Name) {
+		return errors.New("invalid admission policy name")
+	}
+
+	if payload.Rules != nil {
+		return validateRules(*payload.Rules)
+	}
+
+	return nil
+}
+
+// @id AdmissionPolicyUpdate
+// @summary Update an admission policy
+// @description **Access policy**: administrator
+// @tags admission_policies
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Admission policy identifier"
+// @param body body admissionPolicyUpdatePayload true "Admission policy details"
+// @success 200 {object} portainer.AdmissionPolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Admission policy not found"
+// @failure 500 "Server error"
+// @router /admission_policies/{id} [put]
+func (handler *Handler) admissionPolicyUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid admission policy identifier route variable", err)
+	}
+
+	var payload admissionPolicyUpdatePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	policy, err := handler.DataStore.AdmissionPolicy().AdmissionPolicy(portainer.AdmissionPolicyID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an admission policy with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an admission policy with the specified identifier inside the database", err)
+	}
+
+	if payload.Name != nil {
+		policy.Name = *payload.Name
+	}
+
+	if payload.Enabled != nil {
+		policy.Enabled = *payload.Enabled
+	}
+
+	if payload.EndpointIDs != nil {
+		policy.EndpointIDs = payload.EndpointIDs
+	}
+
+	if payload.EndpointGroupIDs != nil {
+		policy.EndpointGroupIDs = payload.EndpointGroupIDs
+	}
+
+	if payload.Rules != nil {
+		policy.Rules = *payload.Rules
+	}
+	sanitizePolicy(policy)
+
+	err = handler.DataStore.AdmissionPolicy().UpdateAdmissionPolicy(policy.ID, policy)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the admission policy changes inside the database", err)
+	}
+
+	return response.JSON(w, policy)
+}
@@ -0,0 +1,85 @@
+package admissionpolicies
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	httperror "github.com/portainer/libhttp/error"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/docker/distribution/reference"
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle admission policy operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage admission policy operations.
+func NewHandler(bouncer *security.RequestBouncer) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+	h.Handle("/admission_policies",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.admissionPolicyCreate))).Methods(http.MethodPost)
+	h.Handle("/admission_policies",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.admissionPolicyList))).Methods(http.MethodGet)
+	h.Handle("/admission_policies/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.admissionPolicyInspect))).Methods(http.MethodGet)
+	h.Handle("/admission_policies/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.admissionPolicyUpdate))).Methods(http.MethodPut)
+	h.Handle("/admission_policies/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.admissionPolicyDelete))).Methods(http.MethodDelete)
+
+	return h
+}
+
+func validateRules(rules portainer.AdmissionPolicyRules) error {
+	for _, registry := range rules.AllowedRegistries {
+		registry = strings.TrimSuffix(strings.TrimSpace(registry), "/")
+		if registry == "" {
+			return errors.New("invalid allowed registry")
+		}
+
+		// a registry is either a host or a repository prefix
+		if !strings.Contains(registry, "/") {
+			registry += "/image"
+		}
+
+		_, err := reference.ParseNormalizedNamed(registry)
+		if err != nil {
+			return errors.New("invalid allowed registry: " + registry)
+		}
+	}
+
+	for _, label := range rules.RequiredLabels {
+		if strings.TrimSpace(label) == "" {
+			return errors.New("invalid required label")
+		}
+	}
+
+	return nil
+}
+
+func sanitizePolicy(policy *portainer.AdmissionPolicy) {
+	if policy.EndpointIDs == nil {
+		policy.EndpointIDs = []portainer.EndpointID{}
+	}
+
+	if policy.EndpointGroupIDs == nil {
+		policy.EndpointGroupIDs = []portainer.EndpointGroupID{}
+	}
+
+	if policy.Rules.AllowedRegistries == nil {
+		policy.Rules.AllowedRegistries = []string{}
+	}
+
+	if policy.Rules.RequiredLabels == nil {
+		policy.Rules.RequiredLabels = []string{}
+	}
+}
@@ -0,0 +1,60 @@
+package auditlogs
+
+import (
+	"encoding/json"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+// @id AuditLogExport
+// @summary Export audit logs
+// @description Export the audit logs as a JSON Lines file, one record per line.
+// @description The same filters as the audit log list are supported, the audit logs are streamed without pagination.
+// @description **Access policy**: administrator
+// @tags audit
+// @security ApiKeyAuth
+// @security jwt
+// @produce application/x-ndjson
+// @param userId query int false "Only export the operations performed by this user"
+// @param endpointId query int false "Only export the operations targeting this environment(endpoint)"
+// @param resourceType query string false "Only export the operations targeting this type of resource"
+// @param resourceId query string false "Only export the operations targeting this resource"
+// @param from query int false "Only export the operations performed after this unix timestamp"
+// @param to query int false "Only export the operations performed before this unix timestamp"
+// @success 200 "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /audit/export [get]
+func (handler *Handler) auditLogExport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	filters, err := parseFilters(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter", err)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", "attachment; filename=portainer-audit.jsonl")
+
+	encoder := json.NewEncoder(w)
+	written := false
+
+	err = handler.DataStore.AuditLog().ForEachAuditLog(func(auditLog *portainer.AuditLog) error {
+		if !filters.match(auditLog) {
+			return nil
+		}
+
+		written = true
+
+		return encoder.Encode(auditLog)
+	})
+	if err != nil && !written {
+		return httperror.InternalServerError("Unable to retrieve audit logs from the database", err)
+	} else if err != nil {
+		log.Warn().Err(err).Msg("unable to write the audit logs to the export")
+	}
+
+	return nil
+}
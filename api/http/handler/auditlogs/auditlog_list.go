@@ -0,0 +1,157 @@
+package auditlogs
+
+import (
+	"net/http"
+	"strconv"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	auditLogListDefaultLimit = 100
+	auditLogListMaxLimit     = 1000
+)
+
+type auditLogListFilters struct {
+	UserID       portainer.UserID
+	EndpointID   portainer.EndpointID
+	ResourceType string
+	ResourceID   string
+	From         int64
+	To           int64
+}
+
+// @id AuditLogList
+// @summary List audit logs
+// @description List the audit logs of the mutating operations performed through the API, from the oldest one.
+// @description Only the last 100000 audit logs are kept. The total number of matching audit logs is returned in the X-Total-Count header.
+// @description **Access policy**: administrator
+// @tags audit
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param userId query int false "Only return the operations performed by this user"
+// @param endpointId query int false "Only return the operations targeting this environment(endpoint)"
+// @param resourceType query string false "Only return the operations targeting this type of resource"
+// @param resourceId query string false "Only return the operations targeting this resource"
+// @param from query int false "Only return the operations performed after this unix timestamp"
+// @param to query int false "Only return the operations performed before this unix timestamp"
+// @param start query int false "Start from this audit log, starting at 1"
+// @param limit query int false "Limit the number of audit logs to this value, defaults to 100 and can't exceed 1000"
+// @success 200 {array} portainer.AuditLog "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /audit [get]
+func (handler *Handler) auditLogList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	filters, err := parseFilters(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter", err)
+	}
+
+	start, err := request.RetrieveNumericQueryParameter(r, "start", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: start", err)
+	}
+	if start != 0 {
+		start--
+	}
+
+	limit, err := request.RetrieveNumericQueryParameter(r, "limit", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: limit", err)
+	}
+	if limit <= 0 {
+		limit = auditLogListDefaultLimit
+	}
+	if limit > auditLogListMaxLimit {
+		limit = auditLogListMaxLimit
+	}
+
+	auditLogs := make([]portainer.AuditLog, 0, limit)
+	count := 0
+
+	err = handler.DataStore.AuditLog().ForEachAuditLog(func(auditLog *portainer.AuditLog) error {
+		if !filters.match(auditLog) {
+			return nil
+		}
+
+		if count >= start && len(auditLogs) < limit {
+			auditLogs = append(auditLogs, *auditLog)
+		}
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve audit logs from the database", err)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(count))
+
+	return response.JSON(w, auditLogs)
+}
+
+func parseFilters(r *http.Request) (*auditLogListFilters, error) {
+	userID, err := request.RetrieveNumericQueryParameter(r, "userId", true)
+	if err != nil {
+		return nil, err
+	}
+
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := request.RetrieveNumericQueryParameter(r, "from", true)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := request.RetrieveNumericQueryParameter(r, "to", true)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceType, _ := request.RetrieveQueryParameter(r, "resourceType", true)
+	resourceID, _ := request.RetrieveQueryParameter(r, "resourceId", true)
+
+	return &auditLogListFilters{
+		UserID:       portainer.UserID(userID),
+		EndpointID:   portainer.EndpointID(endpointID),
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		From:         int64(from),
+		To:           int64(to),
+	}, nil
+}
+
+func (filters *auditLogListFilters) match(auditLog *portainer.AuditLog) bool {
+	if filters.UserID != 0 && auditLog.UserID != filters.UserID {
+		return false
+	}
+
+	if filters.EndpointID != 0 && auditLog.EndpointID != filters.EndpointID {
+		return false
+	}
+
+	if filters.ResourceType != "" && auditLog.ResourceType != filters.ResourceType {
+		return false
+	}
+
+	if filters.ResourceID != "" && auditLog.ResourceID != filters.ResourceID {
+		return false
+	}
+
+	if filters.From != 0 && auditLog.Timestamp < filters.From {
+		return false
+	}
+
+	if filters.To != 0 && auditLog.Timestamp > filters.To {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,43 @@
+package auditlogs
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+type auditLogVerifyResponse struct {
+	// Whether none of the audit logs was altered since it was recorded
+	Valid bool `json:"Valid" example:"true"`
+	// Number of audit logs verified before the first altered one
+	Verified int `json:"Verified" example:"1024"`
+	// Identifier of the first altered audit log, 0 when the audit logs are valid
+	TamperedID portainer.AuditLogID `json:"TamperedId" example:"0"`
+}
+
+// @id AuditLogVerify
+// @summary Verify audit logs
+// @description Verify that the audit logs were not altered or deleted since they were recorded, by checking the hash
+// @description chaining each audit log to the previous one. The audit logs deleted by the retention are not reported.
+// @description **Access policy**: administrator
+// @tags audit
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} auditLogVerifyResponse "Success"
+// @failure 500 "Server error"
+// @router /audit/verify [get]
+func (handler *Handler) auditLogVerify(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	verified, tamperedID, err := handler.DataStore.AuditLog().VerifyAuditLogs()
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify the audit logs", err)
+	}
+
+	return response.JSON(w, auditLogVerifyResponse{
+		Valid:      tamperedID == 0,
+		Verified:   verified,
+		TamperedID: tamperedID,
+	})
+}
@@ -0,0 +1,32 @@
+package auditlogs
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle audit log operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage audit log operations.
+func NewHandler(bouncer *security.RequestBouncer) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+	h.Handle("/audit",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.auditLogList))).Methods(http.MethodGet)
+	h.Handle("/audit/export",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.auditLogExport))).Methods(http.MethodGet)
+	h.Handle("/audit/verify",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.auditLogVerify))).Methods(http.MethodGet)
+
+	return h
+}
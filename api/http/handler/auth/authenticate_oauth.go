@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"net/http"
+	"regexp"
 
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
@@ -16,6 +17,10 @@ import (
 type oauthPayload struct {
 	// OAuth code returned from OAuth Provided
 	Code string
+	// PKCE code verifier matching the code challenge of the authorization request, required when PKCE is enabled
+	CodeVerifier string
+	// Nonce of the authorization request, required when OpenID Connect is enabled
+	Nonce string
 }
 
 func (payload *oauthPayload) Validate(r *http.Request) error {
@@ -26,21 +31,16 @@ func (payload *oauthPayload) Validate(r *http.Request) error {
 	return nil
 }
 
-func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuthSettings) (string, error) {
+func (handler *Handler) authenticateOAuth(code, codeVerifier, nonce string, settings *portainer.OAuthSettings) (*portainer.OAuthInfo, error) {
 	if code == "" {
-		return "", errors.New("Invalid OAuth authorization code")
+		return nil, errors.New("Invalid OAuth authorization code")
 	}
 
 	if settings == nil {
-		return "", errors.New("Invalid OAuth configuration")
+		return nil, errors.New("Invalid OAuth configuration")
 	}
 
-	username, err := handler.OAuthService.Authenticate(code, settings)
-	if err != nil {
-		return "", err
-	}
-
-	return username, nil
+	return handler.OAuthService.Authenticate(code, codeVerifier, nonce, settings)
 }
 
 // @id ValidateOAuth
@@ -71,18 +71,24 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 		return httperror.Forbidden("OAuth authentication is not enabled", errors.New("OAuth authentication is not enabled"))
 	}
 
-	username, err := handler.authenticateOAuth(payload.Code, &settings.OAuthSettings)
+	oauthInfo, err := handler.authenticateOAuth(payload.Code, payload.CodeVerifier, payload.Nonce, &settings.OAuthSettings)
 	if err != nil {
 		log.Debug().Err(err).Msg("OAuth authentication error")
 
 		return httperror.InternalServerError("Unable to authenticate through OAuth", httperrors.ErrUnauthorized)
 	}
 
+	username := oauthInfo.Username
+
 	user, err := handler.DataStore.User().UserByUsername(username)
 	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
 		return httperror.InternalServerError("Unable to retrieve a user with the specified username from the database", err)
 	}
 
+	if user != nil && user.Disabled {
+		return httperror.Forbidden("User is disabled", httperrors.ErrUnauthorized)
+	}
+
 	if user == nil && !settings.OAuthSettings.OAuthAutoCreateUsers {
 		return httperror.Forbidden("Account not created beforehand in Portainer and automatic user provisioning not enabled", httperrors.ErrUnauthorized)
 	}
@@ -98,7 +104,7 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 			return httperror.InternalServerError("Unable to persist user inside the database", err)
 		}
 
-		if settings.OAuthSettings.DefaultTeamID != 0 {
+		if settings.OAuthSettings.DefaultTeamID != 0 && len(oauthInfo.Groups) == 0 {
 			membership := &portainer.TeamMembership{
 				UserID: user.ID,
 				TeamID: settings.OAuthSettings.DefaultTeamID,
@@ -113,5 +119,123 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 
 	}
 
+	err = handler.syncOAuthTeamMemberships(user, oauthInfo.Groups, &settings.OAuthSettings.TeamMemberships)
+	if err != nil {
+		return httperror.InternalServerError("Unable to update the team memberships of the user", err)
+	}
+
 	return handler.writeToken(w, user, false)
 }
+
+// syncOAuthTeamMemberships adds the user into the teams matching its groups, either through the claim mappings
+// or by team name, and removes it from the mapped teams whose groups it left. The user is promoted to administrator
+// when one of its groups matches an admin group, and demoted when it leaves them if it was promoted this way.
+// Nothing is synchronized when the groups claim is not configured
+func (handler *Handler) syncOAuthTeamMemberships(user *portainer.User, groups []string, settings *portainer.OAuthTeamMemberships) error {
+	if settings.OAuthClaimName == "" {
+		return nil
+	}
+
+	adminGroupMember := settings.AdminAutoPopulate && matchAnyGroup(settings.AdminGroupClaimsRegexList, groups)
+
+	promote := adminGroupMember && user.Role != portainer.AdministratorRole
+	demote := !adminGroupMember && user.OAuthAdminGranted
+
+	if promote || demote {
+		user.Role = portainer.StandardUserRole
+		if promote {
+			user.Role = portainer.AdministratorRole
+		}
+		user.OAuthAdminGranted = promote
+
+		err := handler.DataStore.User().UpdateUser(user.ID, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	teams, err := handler.DataStore.Team().Teams()
+	if err != nil {
+		return err
+	}
+
+	userMemberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	mappedTeams := map[portainer.TeamID]bool{}
+	for _, mapping := range settings.OAuthClaimMappings {
+		mappedTeams[mapping.Team] = true
+	}
+
+	for _, team := range teams {
+		matched := teamExists(team.Name, groups) || teamMapped(team.ID, groups, settings.OAuthClaimMappings)
+
+		if !matched {
+			if !mappedTeams[team.ID] {
+				continue
+			}
+
+			// the user left the groups of a mapped team
+			for _, membership := range userMemberships {
+				if membership.TeamID != team.ID {
+					continue
+				}
+
+				err := handler.DataStore.TeamMembership().DeleteTeamMembership(membership.ID)
+				if err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if teamMembershipExists(team.ID, userMemberships) {
+			continue
+		}
+
+		membership := &portainer.TeamMembership{
+			UserID: user.ID,
+			TeamID: team.ID,
+			Role:   portainer.TeamMember,
+		}
+
+		err := handler.DataStore.TeamMembership().Create(membership)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func teamMapped(teamID portainer.TeamID, groups []string, mappings []portainer.OAuthClaimMapping) bool {
+	for _, mapping := range mappings {
+		if mapping.Team == teamID && matchAnyGroup([]string{mapping.ClaimValRegex}, groups) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchAnyGroup(patterns []string, groups []string) bool {
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Warn().Err(err).Str("regex", pattern).Msg("invalid OAuth group regex")
+
+			continue
+		}
+
+		for _, group := range groups {
+			if re.MatchString(group) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
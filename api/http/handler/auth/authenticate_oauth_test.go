@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_syncOAuthTeamMemberships(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	user := &portainer.User{Username: "oauth-user", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	developers := &portainer.Team{Name: "Developers"}
+	is.NoError(store.Team().Create(developers))
+	operators := &portainer.Team{Name: "operators"}
+	is.NoError(store.Team().Create(operators))
+	other := &portainer.Team{Name: "other"}
+	is.NoError(store.Team().Create(other))
+
+	handler := &Handler{DataStore: store}
+
+	settings := &portainer.OAuthTeamMemberships{
+		OAuthClaimName: "groups",
+		OAuthClaimMappings: []portainer.OAuthClaimMapping{
+			{ClaimValRegex: "^ops-.*", Team: operators.ID},
+		},
+		AdminAutoPopulate:         true,
+		AdminGroupClaimsRegexList: []string{"^portainer-admins$"},
+	}
+
+	is.NoError(handler.syncOAuthTeamMemberships(user, []string{"developers", "ops-eu"}, settings))
+
+	memberships, err := store.TeamMembership().TeamMembershipsByUserID(user.ID)
+	is.NoError(err)
+	is.Len(memberships, 2)
+	is.True(teamMembershipExists(developers.ID, memberships), "the team should be matched by name")
+	is.True(teamMembershipExists(operators.ID, memberships), "the team should be matched by the claim mapping")
+	is.Equal(portainer.StandardUserRole, user.Role)
+
+	// memberships are not duplicated and admin groups promote the user
+	is.NoError(handler.syncOAuthTeamMemberships(user, []string{"developers", "ops-eu", "portainer-admins"}, settings))
+
+	memberships, err = store.TeamMembership().TeamMembershipsByUserID(user.ID)
+	is.NoError(err)
+	is.Len(memberships, 2)
+
+	storedUser, err := store.User().User(user.ID)
+	is.NoError(err)
+	is.Equal(portainer.AdministratorRole, storedUser.Role)
+	is.True(storedUser.OAuthAdminGranted)
+
+	// leaving the groups removes the memberships of the mapped teams and demotes the user
+	is.NoError(handler.syncOAuthTeamMemberships(user, []string{"developers"}, settings))
+
+	memberships, err = store.TeamMembership().TeamMembershipsByUserID(user.ID)
+	is.NoError(err)
+	is.Len(memberships, 1)
+	is.True(teamMembershipExists(developers.ID, memberships), "the memberships of the teams matched by name should be kept")
+
+	storedUser, err = store.User().User(user.ID)
+	is.NoError(err)
+	is.Equal(portainer.StandardUserRole, storedUser.Role)
+	is.False(storedUser.OAuthAdminGranted)
+
+	// administrators not promoted through the admin groups are not demoted
+	admin := &portainer.User{Username: "oauth-admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+
+	is.NoError(handler.syncOAuthTeamMemberships(admin, []string{}, settings))
+
+	storedUser, err = store.User().User(admin.ID)
+	is.NoError(err)
+	is.Equal(portainer.AdministratorRole, storedUser.Role)
+}
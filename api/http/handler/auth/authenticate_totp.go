@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/totp"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+)
+
+var errInvalidTOTPCode = errors.New("Invalid TOTP code")
+
+type totpPayload struct {
+	// Token returned by the first authentication step
+	Token string `validate:"required"`
+	// TOTP code or recovery code
+	Code string `example:"123456" validate:"required"`
+}
+
+func (payload *totpPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Token) {
+		return errors.New("Invalid TOTP token")
+	}
+
+	if govalidator.IsNull(payload.Code) {
+		return errors.New("Invalid TOTP code")
+	}
+
+	return nil
+}
+
+type totpEnrolPayload struct {
+	// Token returned by the first authentication step
+	Token string `validate:"required"`
+}
+
+func (payload *totpEnrolPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Token) {
+		return errors.New("Invalid TOTP token")
+	}
+
+	return nil
+}
+
+// @id AuthenticateTOTP
+// @summary Complete the authentication with a TOTP code
+// @description **Access policy**: public
+// @description Use this environment(endpoint) to complete the authentication of a user whose password was verified with a TOTP code or a recovery code.
+// @description The TOTP of a user enrolled with /auth/totp/enrol is enabled by the first valid code.
+// @tags auth
+// @accept json
+// @produce json
+// @param body body totpPayload true "Token of the first authentication step and code"
+// @success 200 {object} authenticateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 422 "Invalid Credentials"
+// @failure 500 "Server error"
+// @router /auth/totp [post]
+func (handler *Handler) authenticateTOTP(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload totpPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, challenge, httpErr := handler.totpChallengeUser(payload.Token)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	// the code is verified against the last used counter and the recovery codes stored in the database, and
+	// consumed in the same transaction so that it can't be replayed by concurrent requests
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		user, err = tx.User().User(user.ID)
+		if err != nil {
+			return err
+		}
+
+		if user.TOTP.Enabled {
+			if !totp.Verify(user, payload.Code, handler.CryptoService, time.Now()) {
+				return errInvalidTOTPCode
+			}
+		} else if err := totp.Activate(user, payload.Code, time.Now()); err != nil {
+			return errors.WithMessage(errInvalidTOTPCode, err.Error())
+		}
+
+		return tx.User().UpdateUser(user.ID, user)
+	})
+	if errors.Is(err, errInvalidTOTPCode) {
+		handler.totpChallenges.Fail(payload.Token)
+		return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid TOTP code", Err: err}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	if !handler.totpChallenges.Remove(payload.Token) {
+		return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid TOTP token", Err: httperrors.ErrUnauthorized}
+	}
+
+	return handler.writeToken(w, user, challenge.ForceChangePassword)
+}
+
+// @id AuthenticateTOTPEnrol
+// @summary Enrol in TOTP during the authentication
+// @description **Access policy**: public
+// @description Use this environment(endpoint) to enrol a user without TOTP when the TOTP is enforced for the internal users.
+// @description The TOTP is enabled once a code generated with the secret is sent to /auth/totp. The recovery codes are only returned once.
+// @tags auth
+// @accept json
+// @produce json
+// @param body body totpEnrolPayload true "Token of the first authentication step"
+// @success 200 {object} totp.Enrolment "Success"
+// @failure 400 "Invalid request"
+// @failure 409 "TOTP already enabled"
+// @failure 422 "Invalid Credentials"
+// @failure 500 "Server error"
+// @router /auth/totp/enrol [post]
+func (handler *Handler) authenticateTOTPEnrol(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload totpEnrolPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, _, httpErr := handler.totpChallengeUser(payload.Token)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	enrolment, err := totp.Enrol(user, handler.CryptoService)
+	if errors.Is(err, totp.ErrTOTPAlreadyEnabled) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "TOTP is already enabled for this user", Err: err}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to enrol the user", err)
+	}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return response.JSON(w, enrolment)
+}
+
+// totpChallengeUser returns the user of a pending second authentication step
+func (handler *Handler) totpChallengeUser(token string) (*portainer.User, *totp.Challenge, *httperror.HandlerError) {
+	challenge, ok := handler.totpChallenges.Get(token)
+	if !ok {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid TOTP token", Err: httperrors.ErrUnauthorized}
+	}
+
+	user, err := handler.DataStore.User().User(challenge.UserID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		handler.totpChallenges.Remove(token)
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid TOTP token", Err: httperrors.ErrUnauthorized}
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve user details from the database", err)
+	}
+
+	if user.Disabled {
+		handler.totpChallenges.Remove(token)
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "User is disabled", Err: httperrors.ErrUnauthorized}
+	}
+
+	return user, &challenge, nil
+}
+
+// writeTOTPChallenge starts the second authentication step of a user whose password was verified
+func (handler *Handler) writeTOTPChallenge(w http.ResponseWriter, user *portainer.User, forceChangePassword bool) *httperror.HandlerError {
+	token, err := handler.totpChallenges.Create(user.ID, forceChangePassword)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create the TOTP challenge", err)
+	}
+
+	return response.JSON(w, &authenticateResponse{
+		TOTPRequired:          user.TOTP.Enabled,
+		TOTPEnrolmentRequired: !user.TOTP.Enabled,
+		TOTPToken:             token,
+	})
+}
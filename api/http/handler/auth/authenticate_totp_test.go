@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_authenticateTOTP(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	cryptoService := &crypto.Service{}
+
+	password, err := cryptoService.Hash("a-strong-password")
+	is.NoError(err)
+
+	user := &portainer.User{Username: "john", Role: portainer.StandardUserRole, Password: password}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings.EnforceTOTP = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	bouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(100, time.Second, time.Hour)
+
+	h := NewHandler(bouncer, rateLimiter, security.NewPasswordStrengthChecker(store.SettingsService))
+	h.DataStore = store
+	h.CryptoService = cryptoService
+	h.JWTService = jwtService
+
+	post := func(path string, payload interface{}, target interface{}) int {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+
+		if target != nil && rr.Code == http.StatusOK {
+			is.NoError(json.NewDecoder(rr.Body).Decode(target))
+		}
+
+		return rr.Code
+	}
+
+	login := func() authenticateResponse {
+		var resp authenticateResponse
+		is.Equal(http.StatusOK, post("/auth", authenticatePayload{Username: "john", Password: "a-strong-password"}, &resp))
+		is.Empty(resp.JWT, "the JWT should only be issued after the second step")
+		is.NotEmpty(resp.TOTPToken)
+
+		return resp
+	}
+
+	// the enrolment is required by the settings
+	resp := login()
+	is.True(resp.TOTPEnrolmentRequired)
+
+	var enrolment totp.Enrolment
+	is.Equal(http.StatusOK, post("/auth/totp/enrol", totpEnrolPayload{Token: resp.TOTPToken}, &enrolment))
+	is.NotEmpty(enrolment.Secret)
+
+	is.Equal(http.StatusUnprocessableEntity, post("/auth/totp", totpPayload{Token: resp.TOTPToken, Code: "abcdef"}, nil))
+
+	code, err := totp.GenerateCode(enrolment.Secret, time.Now())
+	is.NoError(err)
+
+	var tokenResp authenticateResponse
+	is.Equal(http.StatusOK, post("/auth/totp", totpPayload{Token: resp.TOTPToken, Code: code}, &tokenResp))
+	is.NotEmpty(tokenResp.JWT)
+
+	is.Equal(http.StatusUnprocessableEntity, post("/auth/totp", totpPayload{Token: resp.TOTPToken, Code: code}, nil), "the challenge is single use")
+
+	// a code can't be replayed with another challenge, even by concurrent requests
+	is.Equal(http.StatusUnprocessableEntity, post("/auth/totp", totpPayload{Token: login().TOTPToken, Code: code}, nil), "the code is single use")
+
+	code, err = totp.GenerateCode(enrolment.Secret, time.Now().Add(30*time.Second))
+	is.NoError(err)
+
+	tokens := []string{login().TOTPToken, login().TOTPToken, login().TOTPToken}
+	statusCodes := make(chan int, len(tokens))
+
+	var wg sync.WaitGroup
+	for _, token := range tokens {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			statusCodes <- post("/auth/totp", totpPayload{Token: token, Code: code}, nil)
+		}(token)
+	}
+	wg.Wait()
+	close(statusCodes)
+
+	accepted := 0
+	for statusCode := range statusCodes {
+		if statusCode == http.StatusOK {
+			accepted++
+		}
+	}
+	is.Equal(1, accepted, "the code should only be accepted once")
+
+	// the TOTP is now enabled and a recovery code can be used
+	resp = login()
+	is.True(resp.TOTPRequired)
+	is.Equal(http.StatusConflict, post("/auth/totp/enrol", totpEnrolPayload{Token: resp.TOTPToken}, nil))
+
+	tokenResp = authenticateResponse{}
+	is.Equal(http.StatusOK, post("/auth/totp", totpPayload{Token: resp.TOTPToken, Code: enrolment.RecoveryCodes[0]}, &tokenResp))
+	is.NotEmpty(tokenResp.JWT)
+
+	// the challenge is removed after too many failed attempts
+	resp = login()
+	for i := 0; i < 5; i++ {
+		is.Equal(http.StatusUnprocessableEntity, post("/auth/totp", totpPayload{Token: resp.TOTPToken, Code: "abcdef"}, nil))
+	}
+
+	is.Equal(http.StatusUnprocessableEntity, post("/auth/totp", totpPayload{Token: resp.TOTPToken, Code: enrolment.RecoveryCodes[1]}, nil))
+}
@@ -0,0 +1,26 @@
+package backup
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+)
+
+// @id BackupScheduledRun
+// @summary Run the automatic backup now
+// @description Creates a backup archive with the settings of the automatic backups and copies it to the configured targets.
+// @description The status of the run is saved in the settings and returned.
+// @description **Access policy**: admin
+// @tags backup
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} portainer.ScheduledBackupStatus "Success"
+// @failure 500 "Server error"
+// @router /backup/scheduled [post]
+func (h *Handler) backupScheduledRun(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	status := h.ScheduledBackupService.RunBackup()
+
+	return response.JSON(w, status)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	httperror "github.com/portainer/libhttp/error"
+	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/adminmonitor"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
@@ -23,6 +24,8 @@ type Handler struct {
 	filestorePath   string
 	shutdownTrigger context.CancelFunc
 	adminMonitor    *adminmonitor.Monitor
+
+	ScheduledBackupService portainer.ScheduledBackupService
 }
 
 // NewHandler creates an new instance of backup handler
@@ -51,6 +54,7 @@ func NewHandler(
 	demoRestrictedRouter.Use(middlewares.RestrictDemoEnv(demoService.IsDemo))
 
 	demoRestrictedRouter.Handle("/backup", bouncer.RestrictedAccess(adminAccess(httperror.LoggerHandler(h.backup)))).Methods(http.MethodPost)
+	demoRestrictedRouter.Handle("/backup/scheduled", bouncer.RestrictedAccess(adminAccess(httperror.LoggerHandler(h.backupScheduledRun)))).Methods(http.MethodPost)
 	demoRestrictedRouter.Handle("/restore", bouncer.PublicAccess(httperror.LoggerHandler(h.restore))).Methods(http.MethodPost)
 
 	return h
@@ -30,12 +30,16 @@ type TestGitService struct {
 	targetFilePath string
 }
 
-func (g *TestGitService) CloneRepository(destination string, repositoryURL, referenceName string, username, password string, tlsSkipVerify bool) error {
+func (g *TestGitService) CloneRepository(destination string, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) error {
 	time.Sleep(100 * time.Millisecond)
 	return createTestFile(g.targetFilePath)
 }
 
-func (g *TestGitService) LatestCommitID(repositoryURL, referenceName, username, password string, tlsSkipVerify bool) (string, error) {
+func (g *TestGitService) CloneCommit(destination, repositoryURL, referenceName, commitHash string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) error {
+	return g.CloneRepository(destination, repositoryURL, referenceName, auth, tlsSkipVerify)
+}
+
+func (g *TestGitService) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication, tlsSkipVerify bool) (string, error) {
 	return "", nil
 }
 
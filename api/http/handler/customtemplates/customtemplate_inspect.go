@@ -58,5 +58,7 @@ func (handler *Handler) customTemplateInspect(w http.ResponseWriter, r *http.Req
 		customTemplate.ResourceControl = resourceControl
 	}
 
+	hideGitSecrets(customTemplate)
+
 	return response.JSON(w, customTemplate)
 }
@@ -0,0 +1,82 @@
+package customtemplates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_customTemplateInspectAndList_HideGitSecrets(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	user := &portainer.User{ID: 1, Username: "user-1", Role: portainer.StandardUserRole, PortainerAuthorizations: authorization.DefaultPortainerAuthorizations()}
+	err := store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	template := &portainer.CustomTemplate{
+		ID:              1,
+		Title:           "custom-template-1",
+		CreatedByUserID: user.ID,
+		GitConfig: &gittypes.RepoConfig{
+			URL: "git@github.com:portainer/portainer.git",
+			Authentication: &gittypes.GitAuthentication{
+				AuthorizationType: gittypes.GitCredentialAuthTypeSSH,
+				SSH:               &gittypes.SSHCredentials{PrivateKey: "private-key", Passphrase: "passphrase", KnownHosts: "known-hosts"},
+			},
+		},
+	}
+	err = store.CustomTemplateService.Create(template)
+	is.NoError(err, "error creating custom template")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	requestBouncer := security.NewRequestBouncer(store, jwtService, nil)
+
+	h := NewHandler(requestBouncer, store, &TestFileService{}, &TestGitService{})
+
+	token, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	request := func(url string, response interface{}) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		is.Equal(http.StatusOK, rr.Code)
+		is.NoError(json.NewDecoder(rr.Body).Decode(response))
+	}
+
+	assertHidden := func(customTemplate portainer.CustomTemplate) {
+		ssh := customTemplate.GitConfig.Authentication.SSH
+		is.Empty(ssh.PrivateKey, "the private key should not be returned")
+		is.Empty(ssh.Passphrase, "the passphrase should not be returned")
+		is.Equal("known-hosts", ssh.KnownHosts)
+	}
+
+	var customTemplate portainer.CustomTemplate
+	request("/custom_templates/1", &customTemplate)
+	assertHidden(customTemplate)
+
+	var customTemplates []portainer.CustomTemplate
+	request("/custom_templates", &customTemplates)
+	is.Len(customTemplates, 1)
+	assertHidden(customTemplates[0])
+
+	saved, err := store.CustomTemplate().CustomTemplate(template.ID)
+	is.NoError(err)
+	is.Equal("private-key", saved.GitConfig.Authentication.SSH.PrivateKey, "the saved private key should be kept")
+}
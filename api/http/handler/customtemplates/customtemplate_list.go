@@ -63,6 +63,10 @@ func (handler *Handler) customTemplateList(w http.ResponseWriter, r *http.Reques
 
 	customTemplates = filterByType(customTemplates, templateTypes)
 
+	for i := range customTemplates {
+		hideGitSecrets(&customTemplates[i])
+	}
+
 	return response.JSON(w, customTemplates)
 }
 
@@ -11,6 +11,7 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
@@ -47,6 +48,8 @@ type customTemplateUpdatePayload struct {
 	// GitCredentialID used to identify the bound git credential. Required when RepositoryAuthentication
 	// is true and RepositoryUsername/RepositoryPassword are not provided
 	RepositoryGitCredentialID int `example:"0"`
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
 	// Path to the Stack file inside the Git repository
 	ComposeFilePathInRepository string `example:"docker-compose.yml" default:"docker-compose.yml"`
 	// Content of stack file
@@ -77,9 +80,12 @@ func (payload *customTemplateUpdatePayload) Validate(r *http.Request) error {
 		return errors.New("Invalid note. <img> tag is not supported")
 	}
 
-	if payload.RepositoryAuthentication && (govalidator.IsNull(payload.RepositoryUsername) || govalidator.IsNull(payload.RepositoryPassword)) {
+	if payload.RepositoryAuthentication && payload.RepositoryAuthorizationType == gittypes.GitCredentialAuthTypeBasic && (govalidator.IsNull(payload.RepositoryUsername) || govalidator.IsNull(payload.RepositoryPassword)) {
 		return errors.New("Invalid repository credentials. Username and password must be specified when authentication is enabled")
 	}
+	if err := git.ValidateRepoAuthentication(payload.Authentication(payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)); err != nil {
+		return err
+	}
 	if govalidator.IsNull(payload.ComposeFilePathInRepository) {
 		payload.ComposeFilePathInRepository = filesystem.ComposeFileDefaultName
 	}
@@ -159,7 +165,7 @@ func (handler *Handler) customTemplateUpdate(w http.ResponseWriter, r *http.Requ
 	customTemplate.IsComposeFormat = payload.IsComposeFormat
 
 	if payload.RepositoryURL != "" {
-		if !govalidator.IsURL(payload.RepositoryURL) {
+		if !git.IsValidRepositoryURL(payload.RepositoryURL) {
 			return httperror.BadRequest("Invalid repository URL. Must correspond to a valid URL format", err)
 		}
 
@@ -167,13 +173,12 @@ func (handler *Handler) customTemplateUpdate(w http.ResponseWriter, r *http.Requ
 			URL:            payload.RepositoryURL,
 			ReferenceName:  payload.RepositoryReferenceName,
 			ConfigFilePath: payload.ComposeFilePathInRepository,
+			Authentication: payload.CredentialsPayload.Authentication(payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword),
 		}
 
-		if payload.RepositoryAuthentication {
-			gitConfig.Authentication = &gittypes.GitAuthentication{
-				Username: payload.RepositoryUsername,
-				Password: payload.RepositoryPassword,
-			}
+		// the secrets are not sent back by the API, the saved ones are kept for the same repository
+		if customTemplate.GitConfig != nil && customTemplate.GitConfig.URL == gitConfig.URL {
+			git.KeepSavedSecrets(gitConfig.Authentication, customTemplate.GitConfig.Authentication)
 		}
 
 		commitHash, err := stackutils.DownloadGitRepository(*gitConfig, handler.GitService, func() string {
@@ -198,5 +203,7 @@ func (handler *Handler) customTemplateUpdate(w http.ResponseWriter, r *http.Requ
 		return httperror.InternalServerError("Unable to persist custom template changes inside the database", err)
 	}
 
+	hideGitSecrets(customTemplate)
+
 	return response.JSON(w, customTemplate)
 }
@@ -47,6 +47,14 @@ func NewHandler(bouncer *security.RequestBouncer, dataStore dataservices.DataSto
 	return h
 }
 
+// hideGitSecrets removes the password and the private keys of the git repository of a custom template from the
+// responses
+func hideGitSecrets(customTemplate *portainer.CustomTemplate) {
+	if customTemplate.GitConfig != nil && customTemplate.GitConfig.Authentication != nil {
+		customTemplate.GitConfig.Authentication.HideSecrets()
+	}
+}
+
 func userCanEditTemplate(customTemplate *portainer.CustomTemplate, securityContext *security.RestrictedRequestContext) bool {
 	return securityContext.IsAdmin || customTemplate.CreatedByUserID == securityContext.UserID
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/edge"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 
 	"github.com/asaskevich/govalidator"
@@ -19,6 +20,8 @@ type edgeGroupCreatePayload struct {
 	TagIDs       []portainer.TagID
 	Endpoints    []portainer.EndpointID
 	PartialMatch bool
+	// The new versions of the edge stacks are only deployed to the environments of the group inside this window
+	DeploymentWindow *portainer.EdgeDeploymentWindow
 }
 
 func (payload *edgeGroupCreatePayload) Validate(r *http.Request) error {
@@ -34,7 +37,7 @@ func (payload *edgeGroupCreatePayload) Validate(r *http.Request) error {
 		return errors.New("environment is mandatory for a static Edge group")
 	}
 
-	return nil
+	return edge.ValidateDeploymentWindow(payload.DeploymentWindow)
 }
 
 // @id EdgeGroupCreate
@@ -71,11 +74,12 @@ func (handler *Handler) edgeGroupCreate(w http.ResponseWriter, r *http.Request)
 		}
 
 		edgeGroup = &portainer.EdgeGroup{
-			Name:         payload.Name,
-			Dynamic:      payload.Dynamic,
-			TagIDs:       []portainer.TagID{},
-			Endpoints:    []portainer.EndpointID{},
-			PartialMatch: payload.PartialMatch,
+			Name:             payload.Name,
+			Dynamic:          payload.Dynamic,
+			TagIDs:           []portainer.TagID{},
+			Endpoints:        []portainer.EndpointID{},
+			PartialMatch:     payload.PartialMatch,
+			DeploymentWindow: payload.DeploymentWindow,
 		}
 
 		if edgeGroup.Dynamic {
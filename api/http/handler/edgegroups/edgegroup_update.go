@@ -9,6 +9,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/internal/slices"
 
@@ -21,6 +22,9 @@ type edgeGroupUpdatePayload struct {
 	TagIDs       []portainer.TagID
 	Endpoints    []portainer.EndpointID
 	PartialMatch *bool
+	// The new versions of the edge stacks are only deployed to the environments of the group inside this window,
+	// the window is removed when it is not set
+	DeploymentWindow *portainer.EdgeDeploymentWindow
 }
 
 func (payload *edgeGroupUpdatePayload) Validate(r *http.Request) error {
@@ -36,7 +40,7 @@ func (payload *edgeGroupUpdatePayload) Validate(r *http.Request) error {
 		return errors.New("environments is mandatory for a static Edge group")
 	}
 
-	return nil
+	return edge.ValidateDeploymentWindow(payload.DeploymentWindow)
 }
 
 // @id EgeGroupUpdate
@@ -123,6 +127,8 @@ func (handler *Handler) edgeGroupUpdate(w http.ResponseWriter, r *http.Request)
 			edgeGroup.PartialMatch = *payload.PartialMatch
 		}
 
+		edgeGroup.DeploymentWindow = payload.DeploymentWindow
+
 		err = tx.EdgeGroup().UpdateEdgeGroup(edgeGroup.ID, edgeGroup)
 		if err != nil {
 			return httperror.InternalServerError("Unable to persist Edge group changes inside the database", err)
@@ -131,6 +137,18 @@ func (handler *Handler) edgeGroupUpdate(w http.ResponseWriter, r *http.Request)
 		newRelatedEndpoints := edge.EdgeGroupRelatedEndpoints(edgeGroup, endpoints, endpointGroups)
 		endpointsToUpdate := append(newRelatedEndpoints, oldRelatedEndpoints...)
 
+		// the environments joining the group must be able to render its templated edge stacks
+		for i := range endpoints {
+			if !slices.Contains(newRelatedEndpoints, endpoints[i].ID) || slices.Contains(oldRelatedEndpoints, endpoints[i].ID) {
+				continue
+			}
+
+			err = edgestacks.ValidateEndpointTemplates(tx, handler.FileService, &endpoints[i], nil)
+			if err != nil {
+				return httperror.BadRequest("The edge stack templates can't be rendered with the values of the environments joining the group", err)
+			}
+		}
+
 		edgeJobs, err := tx.EdgeJob().EdgeJobs()
 		if err != nil {
 			return httperror.InternalServerError("Unable to fetch Edge jobs", err)
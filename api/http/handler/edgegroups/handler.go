@@ -17,6 +17,7 @@ import (
 type Handler struct {
 	*mux.Router
 	DataStore            dataservices.DataStore
+	FileService          portainer.FileService
 	ReverseTunnelService portainer.ReverseTunnelService
 }
 
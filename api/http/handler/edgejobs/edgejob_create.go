@@ -25,6 +25,12 @@ type edgeJobBasePayload struct {
 	Recurring      bool
 	Endpoints      []portainer.EndpointID
 	EdgeGroups     []portainer.EdgeGroupID
+	// Number of times a failed run is retried on each environment
+	MaxRetries int
+	// Seconds to wait before retrying a failed run
+	RetryInterval int
+	// Seconds after which a run is stopped and considered failed, the runs are not limited when it is not set
+	Timeout int
 }
 
 func (handler *Handler) edgeJobCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -69,7 +75,7 @@ func (payload *edgeJobCreateFromFileContentPayload) Validate(r *http.Request) er
 		return errors.New("invalid script file content")
 	}
 
-	return nil
+	return edge.ValidateEdgeJobRetryPolicy(payload.MaxRetries, payload.RetryInterval, payload.Timeout)
 }
 
 // @id EdgeJobCreateString
@@ -172,7 +178,16 @@ func (payload *edgeJobCreateFromFilePayload) Validate(r *http.Request) error {
 	}
 	payload.File = file
 
-	return nil
+	maxRetries, _ := request.RetrieveNumericMultiPartFormValue(r, "MaxRetries", true)
+	payload.MaxRetries = maxRetries
+
+	retryInterval, _ := request.RetrieveNumericMultiPartFormValue(r, "RetryInterval", true)
+	payload.RetryInterval = retryInterval
+
+	timeout, _ := request.RetrieveNumericMultiPartFormValue(r, "Timeout", true)
+	payload.Timeout = timeout
+
+	return edge.ValidateEdgeJobRetryPolicy(payload.MaxRetries, payload.RetryInterval, payload.Timeout)
 }
 
 // @id EdgeJobCreateFile
@@ -189,6 +204,9 @@ func (payload *edgeJobCreateFromFilePayload) Validate(r *http.Request) error {
 // @param EdgeGroups formData string true "JSON stringified array of Edge Groups ids"
 // @param Endpoints formData string true "JSON stringified array of Environment ids"
 // @param Recurring formData bool false "If recurring"
+// @param MaxRetries formData int false "Number of times a failed run is retried on each environment"
+// @param RetryInterval formData int false "Seconds to wait before retrying a failed run"
+// @param Timeout formData int false "Seconds after which a run is stopped and considered failed"
 // @success 200 {object} portainer.EdgeGroup
 // @failure 503 "Edge compute features are disabled"
 // @failure 500
@@ -224,6 +242,9 @@ func (handler *Handler) createEdgeJobObjectFromPayload(tx dataservices.DataStore
 		Endpoints:           convertEndpointsToMetaObject(payload.Endpoints),
 		EdgeGroups:          payload.EdgeGroups,
 		Version:             1,
+		MaxRetries:          payload.MaxRetries,
+		RetryInterval:       payload.RetryInterval,
+		Timeout:             payload.Timeout,
 		GroupLogsCollection: map[portainer.EndpointID]portainer.EdgeJobEndpointMeta{},
 	}
 }
@@ -92,5 +92,10 @@ func (handler *Handler) deleteEdgeJob(tx dataservices.DataStoreTx, edgeJobID por
 		return httperror.InternalServerError("Unable to remove the Edge job from the database", err)
 	}
 
+	err = tx.EdgeJobRun().DeleteEdgeJobRuns(edgeJob.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the runs of the Edge job from the database", err)
+	}
+
 	return nil
 }
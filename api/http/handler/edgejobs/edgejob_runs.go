@@ -0,0 +1,175 @@
+package edgejobs
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/edge"
+)
+
+// @id EdgeJobRunList
+// @summary List the runs of an EdgeJob
+// @description List the runs reported by the environments(endpoints) for an EdgeJob, from the most recent one. Only
+// @description the last 50 runs of each environment are kept.
+// @description The total number of runs is returned in the X-Total-Count header.
+// @description **Access policy**: administrator
+// @tags edge_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeJob Id"
+// @param endpointId query int false "Only return the runs of this environment(endpoint)"
+// @param start query int false "Start from this run, starting at 1"
+// @param limit query int false "Limit the number of runs to this value"
+// @success 200 {array} portainer.EdgeJobRun
+// @failure 500
+// @failure 400
+// @failure 404
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_jobs/{id}/runs [get]
+func (handler *Handler) edgeJobRunList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeJob, httpErr := handler.retrieveEdgeJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	start, err := request.RetrieveNumericQueryParameter(r, "start", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: start", err)
+	}
+	if start != 0 {
+		start--
+	}
+
+	limit, err := request.RetrieveNumericQueryParameter(r, "limit", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: limit", err)
+	}
+
+	runs, err := handler.DataStore.EdgeJobRun().EdgeJobRuns(edgeJob.ID, portainer.EndpointID(endpointID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the runs of the Edge job from the database", err)
+	}
+
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(runs)))
+
+	runs = paginateRuns(runs, start, limit)
+
+	for i := range runs {
+		output, err := handler.FileService.GetEdgeJobTaskLogFileContent(strconv.Itoa(int(edgeJob.ID)), edge.EdgeJobRunOutputTaskID(runs[i].ID))
+		if err != nil && !os.IsNotExist(err) {
+			return httperror.InternalServerError("Unable to retrieve the output of the Edge job run", err)
+		}
+
+		runs[i].Output = output
+	}
+
+	return response.JSON(w, runs)
+}
+
+// @id EdgeJobRunSummary
+// @summary Aggregate the runs of an EdgeJob
+// @description Give the status of the current version of an EdgeJob on each of its environments(endpoints), from
+// @description their last run, and count them in total and per edge group.
+// @description **Access policy**: administrator
+// @tags edge_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeJob Id"
+// @success 200 {object} edge.EdgeJobRunSummary
+// @failure 500
+// @failure 400
+// @failure 404
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_jobs/{id}/runs/summary [get]
+func (handler *Handler) edgeJobRunSummary(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	edgeJob, httpErr := handler.retrieveEdgeJob(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	runs, err := handler.DataStore.EdgeJobRun().EdgeJobRuns(edgeJob.ID, 0)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the runs of the Edge job from the database", err)
+	}
+
+	edgeGroupEndpoints := map[portainer.EdgeGroupID][]portainer.EndpointID{}
+	if len(edgeJob.EdgeGroups) > 0 {
+		endpoints, err := handler.DataStore.Endpoint().Endpoints()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve environments from the database", err)
+		}
+
+		endpointGroups, err := handler.DataStore.EndpointGroup().EndpointGroups()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve environment groups from the database", err)
+		}
+
+		for _, edgeGroupID := range edgeJob.EdgeGroups {
+			edgeGroup, err := handler.DataStore.EdgeGroup().EdgeGroup(edgeGroupID)
+			if handler.DataStore.IsErrObjectNotFound(err) {
+				continue
+			} else if err != nil {
+				return httperror.InternalServerError("Unable to retrieve the Edge group from the database", err)
+			}
+
+			edgeGroupEndpoints[edgeGroupID] = edge.EdgeGroupRelatedEndpoints(edgeGroup, endpoints, endpointGroups)
+		}
+	}
+
+	return response.JSON(w, edge.SummarizeEdgeJobRuns(edgeJob, runs, edgeGroupEndpoints))
+}
+
+func (handler *Handler) retrieveEdgeJob(r *http.Request) (*portainer.EdgeJob, *httperror.HandlerError) {
+	edgeJobID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid Edge job identifier route variable", err)
+	}
+
+	edgeJob, err := handler.DataStore.EdgeJob().EdgeJob(portainer.EdgeJobID(edgeJobID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an Edge job with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an Edge job with the specified identifier inside the database", err)
+	}
+
+	return edgeJob, nil
+}
+
+func paginateRuns(runs []portainer.EdgeJobRun, start, limit int) []portainer.EdgeJobRun {
+	if limit == 0 {
+		return runs
+	}
+
+	runCount := len(runs)
+
+	if start < 0 {
+		start = 0
+	}
+
+	if start > runCount {
+		start = runCount
+	}
+
+	end := start + limit
+	if end > runCount {
+		end = runCount
+	}
+
+	return runs[start:end]
+}
@@ -25,6 +25,12 @@ type edgeJobUpdatePayload struct {
 	Endpoints      []portainer.EndpointID
 	EdgeGroups     []portainer.EdgeGroupID
 	FileContent    *string
+	// Number of times a failed run is retried on each environment
+	MaxRetries *int
+	// Seconds to wait before retrying a failed run
+	RetryInterval *int
+	// Seconds after which a run is stopped and considered failed, the runs are not limited when it is 0
+	Timeout *int
 }
 
 func (payload *edgeJobUpdatePayload) Validate(r *http.Request) error {
@@ -32,7 +38,20 @@ func (payload *edgeJobUpdatePayload) Validate(r *http.Request) error {
 		return errors.New("invalid Edge job name format. Allowed characters are: [a-zA-Z0-9_.-]")
 	}
 
-	return nil
+	var maxRetries, retryInterval, timeout int
+	if payload.MaxRetries != nil {
+		maxRetries = *payload.MaxRetries
+	}
+
+	if payload.RetryInterval != nil {
+		retryInterval = *payload.RetryInterval
+	}
+
+	if payload.Timeout != nil {
+		timeout = *payload.Timeout
+	}
+
+	return edge.ValidateEdgeJobRetryPolicy(maxRetries, retryInterval, timeout)
 }
 
 // @id EdgeJobUpdate
@@ -221,6 +240,21 @@ func (handler *Handler) updateEdgeSchedule(tx dataservices.DataStoreTx, edgeJob
 		updateVersion = true
 	}
 
+	if payload.MaxRetries != nil && *payload.MaxRetries != edgeJob.MaxRetries {
+		edgeJob.MaxRetries = *payload.MaxRetries
+		updateVersion = true
+	}
+
+	if payload.RetryInterval != nil && *payload.RetryInterval != edgeJob.RetryInterval {
+		edgeJob.RetryInterval = *payload.RetryInterval
+		updateVersion = true
+	}
+
+	if payload.Timeout != nil && *payload.Timeout != edgeJob.Timeout {
+		edgeJob.Timeout = *payload.Timeout
+		updateVersion = true
+	}
+
 	if updateVersion {
 		edgeJob.Version++
 	}
@@ -43,6 +43,8 @@ func (handler *Handler) edgeStackCreate(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	hideGitSecrets(edgeStack)
+
 	return response.JSON(w, edgeStack)
 }
 
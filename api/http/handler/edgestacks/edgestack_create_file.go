@@ -7,6 +7,8 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/internal/edge"
+	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
 
 	"github.com/pkg/errors"
 )
@@ -23,6 +25,12 @@ type edgeStackFromFileUploadPayload struct {
 	Registries     []portainer.RegistryID
 	// Uses the manifest's namespaces instead of the default one
 	UseManifestNamespaces bool
+	// Renders the stack file as a template of the values of each environment, e.g. {{ .EndpointName }} or {{ .Variables.region }}
+	UseTemplating bool
+	// Deploys the new versions to the environments in batches, all the environments are updated at once when it is not set
+	RolloutPolicy *portainer.EdgeStackRolloutPolicy
+	// The new versions are only deployed inside this window, instead of the windows of the edge groups
+	DeploymentWindow *portainer.EdgeDeploymentWindow
 }
 
 func (payload *edgeStackFromFileUploadPayload) Validate(r *http.Request) error {
@@ -64,6 +72,31 @@ func (payload *edgeStackFromFileUploadPayload) Validate(r *http.Request) error {
 	useManifestNamespaces, _ := request.RetrieveBooleanMultiPartFormValue(r, "UseManifestNamespaces", true)
 	payload.UseManifestNamespaces = useManifestNamespaces
 
+	useTemplating, _ := request.RetrieveBooleanMultiPartFormValue(r, "UseTemplating", true)
+	payload.UseTemplating = useTemplating
+
+	var rolloutPolicy *portainer.EdgeStackRolloutPolicy
+	err = request.RetrieveMultiPartFormJSONValue(r, "RolloutPolicy", &rolloutPolicy, true)
+	if err != nil {
+		return httperrors.NewInvalidPayloadError("Invalid rollout policy")
+	}
+	payload.RolloutPolicy = rolloutPolicy
+
+	if err = edgestackservice.ValidateRolloutPolicy(payload.RolloutPolicy); err != nil {
+		return httperrors.NewInvalidPayloadError(err.Error())
+	}
+
+	var deploymentWindow *portainer.EdgeDeploymentWindow
+	err = request.RetrieveMultiPartFormJSONValue(r, "DeploymentWindow", &deploymentWindow, true)
+	if err != nil {
+		return httperrors.NewInvalidPayloadError("Invalid deployment window")
+	}
+	payload.DeploymentWindow = deploymentWindow
+
+	if err = edge.ValidateDeploymentWindow(payload.DeploymentWindow); err != nil {
+		return httperrors.NewInvalidPayloadError(err.Error())
+	}
+
 	return nil
 }
 
@@ -81,6 +114,9 @@ func (payload *edgeStackFromFileUploadPayload) Validate(r *http.Request) error {
 // @param DeploymentType formData int true "deploy type 0 - 'compose', 1 - 'kubernetes', 2 - 'nomad'"
 // @param Registries formData string false "JSON stringified array of Registry ids to use for this stack"
 // @param UseManifestNamespaces formData bool false "Uses the manifest's namespaces instead of the default one, relevant only for kube environments"
+// @param UseTemplating formData bool false "Renders the stack file as a template of the values of each environment"
+// @param RolloutPolicy formData string false "JSON stringified rollout policy deploying the new versions to the environments in batches"
+// @param DeploymentWindow formData string false "JSON stringified window outside of which the new versions are not deployed"
 // @param PrePullImage formData bool false "Pre Pull image"
 // @param RetryDeploy formData bool false "Retry deploy"
 // @param dryrun query string false "if true, will not create an edge stack, but just will check the settings and return a non-persisted edge stack object"
@@ -101,11 +137,20 @@ func (handler *Handler) createEdgeStackFromFileUpload(r *http.Request, tx datase
 		return nil, errors.Wrap(err, "failed to create edge stack object")
 	}
 
+	stack.RolloutPolicy = payload.RolloutPolicy
+	stack.DeploymentWindow = payload.DeploymentWindow
+	stack.UseTemplating = payload.UseTemplating
+
 	if dryrun {
 		return stack, nil
 	}
 
 	return handler.edgeStacksService.PersistEdgeStack(tx, stack, func(stackFolder string, relatedEndpointIds []portainer.EndpointID) (composePath string, manifestPath string, projectPath string, err error) {
+		err = handler.validateTemplate(tx, stack, payload.StackFileContent, relatedEndpointIds)
+		if err != nil {
+			return "", "", "", err
+		}
+
 		return handler.storeFileContent(tx, stackFolder, payload.DeploymentType, relatedEndpointIds, payload.StackFileContent)
 	})
 }
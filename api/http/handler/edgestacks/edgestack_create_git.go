@@ -8,11 +8,17 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/git/update"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/internal/edge"
+	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 type edgeStackFromGitRepositoryPayload struct {
@@ -28,6 +34,8 @@ type edgeStackFromGitRepositoryPayload struct {
 	RepositoryUsername string `example:"myGitUsername"`
 	// Password used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryPassword string `example:"myGitPassword"`
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
 	// Path to the Stack file inside the Git repository
 	FilePathInRepository string `example:"docker-compose.yml" default:"docker-compose.yml"`
 	// List of identifiers of EdgeGroups
@@ -41,8 +49,16 @@ type edgeStackFromGitRepositoryPayload struct {
 	Registries []portainer.RegistryID
 	// Uses the manifest's namespaces instead of the default one
 	UseManifestNamespaces bool
+	// Renders the stack file as a template of the values of each environment, e.g. {{ .EndpointName }} or {{ .Variables.region }}
+	UseTemplating bool
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
+	// Optional webhook redeploying the edge stack when the Git repository is updated, the interval is not supported
+	AutoUpdate *portainer.AutoUpdateSettings
+	// Deploys the new versions to the environments in batches, all the environments are updated at once when it is not set
+	RolloutPolicy *portainer.EdgeStackRolloutPolicy
+	// The new versions are only deployed inside this window, instead of the windows of the edge groups
+	DeploymentWindow *portainer.EdgeDeploymentWindow
 }
 
 func (payload *edgeStackFromGitRepositoryPayload) Validate(r *http.Request) error {
@@ -50,12 +66,12 @@ func (payload *edgeStackFromGitRepositoryPayload) Validate(r *http.Request) erro
 		return httperrors.NewInvalidPayloadError("Invalid stack name")
 	}
 
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return httperrors.NewInvalidPayloadError("Invalid repository URL. Must correspond to a valid URL format")
 	}
 
-	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
-		return httperrors.NewInvalidPayloadError("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if err := git.ValidateRepoAuthentication(payload.Authentication(payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)); err != nil {
+		return err
 	}
 
 	if payload.DeploymentType != portainer.EdgeStackDeploymentCompose && payload.DeploymentType != portainer.EdgeStackDeploymentKubernetes {
@@ -75,7 +91,19 @@ func (payload *edgeStackFromGitRepositoryPayload) Validate(r *http.Request) erro
 		return httperrors.NewInvalidPayloadError("Invalid edge groups. At least one edge group must be specified")
 	}
 
-	return nil
+	if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" {
+		return httperrors.NewInvalidPayloadError("Invalid auto update settings. Only the webhook is supported for the edge stacks")
+	}
+
+	if err := edgestackservice.ValidateRolloutPolicy(payload.RolloutPolicy); err != nil {
+		return httperrors.NewInvalidPayloadError(err.Error())
+	}
+
+	if err := edge.ValidateDeploymentWindow(payload.DeploymentWindow); err != nil {
+		return httperrors.NewInvalidPayloadError(err.Error())
+	}
+
+	return update.ValidateAutoUpdateSettings(payload.AutoUpdate)
 }
 
 // @id EdgeStackCreateRepository
@@ -105,6 +133,10 @@ func (handler *Handler) createEdgeStackFromGitRepository(r *http.Request, tx dat
 		return nil, errors.Wrap(err, "failed to create edge stack object")
 	}
 
+	stack.RolloutPolicy = payload.RolloutPolicy
+	stack.DeploymentWindow = payload.DeploymentWindow
+	stack.UseTemplating = payload.UseTemplating
+
 	if dryrun {
 		return stack, nil
 	}
@@ -114,21 +146,45 @@ func (handler *Handler) createEdgeStackFromGitRepository(r *http.Request, tx dat
 		ReferenceName:  payload.RepositoryReferenceName,
 		ConfigFilePath: payload.FilePathInRepository,
 		TLSSkipVerify:  payload.TLSSkipVerify,
+		Authentication: payload.CredentialsPayload.Authentication(payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword),
 	}
 
-	if payload.RepositoryAuthentication {
-		repoConfig.Authentication = &gittypes.GitAuthentication{
-			Username: payload.RepositoryUsername,
-			Password: payload.RepositoryPassword,
+	if payload.AutoUpdate != nil {
+		err = validateUniqueWebhook(tx, payload.AutoUpdate.Webhook)
+		if err != nil {
+			return nil, err
 		}
+
+		payload.AutoUpdate.LastWebhookPush = nil
 	}
 
+	stack.GitConfig = &repoConfig
+	stack.AutoUpdate = payload.AutoUpdate
+
 	return handler.edgeStacksService.PersistEdgeStack(tx, stack, func(stackFolder string, relatedEndpointIds []portainer.EndpointID) (composePath string, manifestPath string, projectPath string, err error) {
-		return handler.storeManifestFromGitRepository(tx, stackFolder, relatedEndpointIds, payload.DeploymentType, userID, repoConfig)
+		composePath, manifestPath, projectPath, err = handler.storeManifestFromGitRepository(tx, stackFolder, relatedEndpointIds, payload.DeploymentType, userID, stack.GitConfig)
+		if err != nil || !stack.UseTemplating {
+			return composePath, manifestPath, projectPath, err
+		}
+
+		content, err := handler.FileService.GetFileContent(projectPath, stack.GitConfig.ConfigFilePath)
+		if err == nil {
+			err = handler.validateTemplate(tx, stack, content, relatedEndpointIds)
+		}
+
+		if err != nil {
+			if cleanErr := handler.FileService.RemoveDirectory(projectPath); cleanErr != nil {
+				log.Warn().Err(cleanErr).Msg("Unable to clear the cloned repository")
+			}
+
+			return "", "", "", err
+		}
+
+		return composePath, manifestPath, projectPath, nil
 	})
 }
 
-func (handler *Handler) storeManifestFromGitRepository(tx dataservices.DataStoreTx, stackFolder string, relatedEndpointIds []portainer.EndpointID, deploymentType portainer.EdgeStackDeploymentType, currentUserID portainer.UserID, repositoryConfig gittypes.RepoConfig) (composePath, manifestPath, projectPath string, err error) {
+func (handler *Handler) storeManifestFromGitRepository(tx dataservices.DataStoreTx, stackFolder string, relatedEndpointIds []portainer.EndpointID, deploymentType portainer.EdgeStackDeploymentType, currentUserID portainer.UserID, repositoryConfig *gittypes.RepoConfig) (composePath, manifestPath, projectPath string, err error) {
 	hasWrongType, err := hasWrongEnvironmentType(tx.Endpoint(), relatedEndpointIds, deploymentType)
 	if err != nil {
 		return "", "", "", fmt.Errorf("unable to check for existence of non fitting environments: %w", err)
@@ -138,18 +194,15 @@ func (handler *Handler) storeManifestFromGitRepository(tx dataservices.DataStore
 	}
 
 	projectPath = handler.FileService.GetEdgeStackProjectPath(stackFolder)
-	repositoryUsername := ""
-	repositoryPassword := ""
-	if repositoryConfig.Authentication != nil && repositoryConfig.Authentication.Password != "" {
-		repositoryUsername = repositoryConfig.Authentication.Username
-		repositoryPassword = repositoryConfig.Authentication.Password
-	}
 
-	err = handler.GitService.CloneRepository(projectPath, repositoryConfig.URL, repositoryConfig.ReferenceName, repositoryUsername, repositoryPassword, repositoryConfig.TLSSkipVerify)
+	// the commit is kept to only redeploy the edge stack when the repository is updated
+	commitID, err := stackutils.DownloadGitRepository(*repositoryConfig, handler.GitService, func() string { return projectPath })
 	if err != nil {
 		return "", "", "", err
 	}
 
+	repositoryConfig.ConfigHash = commitID
+
 	if deploymentType == portainer.EdgeStackDeploymentCompose {
 		return repositoryConfig.ConfigFilePath, "", projectPath, nil
 	}
@@ -161,3 +214,23 @@ func (handler *Handler) storeManifestFromGitRepository(tx dataservices.DataStore
 	errMessage := fmt.Sprintf("unknown deployment type: %d", deploymentType)
 	return "", "", "", httperrors.NewInvalidPayloadError(errMessage)
 }
+
+// validateUniqueWebhook verifies that the webhook is not used by another edge stack
+func validateUniqueWebhook(tx dataservices.DataStoreTx, webhook string) error {
+	if webhook == "" {
+		return nil
+	}
+
+	edgeStacks, err := tx.EdgeStack().EdgeStacks()
+	if err != nil {
+		return err
+	}
+
+	for _, edgeStack := range edgeStacks {
+		if edgeStack.AutoUpdate != nil && edgeStack.AutoUpdate.Webhook == webhook {
+			return httperrors.NewInvalidPayloadError("A webhook ID already exists")
+		}
+	}
+
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/internal/edge"
+	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/pkg/errors"
@@ -30,6 +32,12 @@ type edgeStackFromStringPayload struct {
 	Registries []portainer.RegistryID
 	// Uses the manifest's namespaces instead of the default one
 	UseManifestNamespaces bool
+	// Renders the stack file as a template of the values of each environment, e.g. {{ .EndpointName }} or {{ .Variables.region }}
+	UseTemplating bool
+	// Deploys the new versions to the environments in batches, all the environments are updated at once when it is not set
+	RolloutPolicy *portainer.EdgeStackRolloutPolicy
+	// The new versions are only deployed inside this window, instead of the windows of the edge groups
+	DeploymentWindow *portainer.EdgeDeploymentWindow
 }
 
 func (payload *edgeStackFromStringPayload) Validate(r *http.Request) error {
@@ -49,6 +57,14 @@ func (payload *edgeStackFromStringPayload) Validate(r *http.Request) error {
 		return httperrors.NewInvalidPayloadError("Invalid deployment type")
 	}
 
+	if err := edgestackservice.ValidateRolloutPolicy(payload.RolloutPolicy); err != nil {
+		return httperrors.NewInvalidPayloadError(err.Error())
+	}
+
+	if err := edge.ValidateDeploymentWindow(payload.DeploymentWindow); err != nil {
+		return httperrors.NewInvalidPayloadError(err.Error())
+	}
+
 	return nil
 }
 
@@ -78,11 +94,20 @@ func (handler *Handler) createEdgeStackFromFileContent(r *http.Request, tx datas
 		return nil, errors.Wrap(err, "failed to create Edge stack object")
 	}
 
+	stack.RolloutPolicy = payload.RolloutPolicy
+	stack.DeploymentWindow = payload.DeploymentWindow
+	stack.UseTemplating = payload.UseTemplating
+
 	if dryrun {
 		return stack, nil
 	}
 
 	return handler.edgeStacksService.PersistEdgeStack(tx, stack, func(stackFolder string, relatedEndpointIds []portainer.EndpointID) (composePath string, manifestPath string, projectPath string, err error) {
+		err = handler.validateTemplate(tx, stack, []byte(payload.StackFileContent), relatedEndpointIds)
+		if err != nil {
+			return "", "", "", err
+		}
+
 		return handler.storeFileContent(tx, stackFolder, payload.DeploymentType, relatedEndpointIds, []byte(payload.StackFileContent))
 	})
 }
@@ -33,5 +33,7 @@ func (handler *Handler) edgeStackInspect(w http.ResponseWriter, r *http.Request)
 		return handler.handlerDBErr(err, "Unable to find an edge stack with the specified identifier inside the database")
 	}
 
+	hideGitSecrets(edgeStack)
+
 	return response.JSON(w, edgeStack)
 }
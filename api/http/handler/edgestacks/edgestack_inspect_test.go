@@ -1,9 +1,14 @@
 package edgestacks
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
 )
 
 // Inspect
@@ -37,3 +42,52 @@ func TestInspectInvalidEdgeID(t *testing.T) {
 		})
 	}
 }
+
+func TestInspectHidesSecrets(t *testing.T) {
+	handler, rawAPIKey, teardown := setupHandler(t)
+	defer teardown()
+
+	endpoint := createEndpoint(t, handler.DataStore)
+	edgeStack := createEdgeStack(t, handler.DataStore, endpoint.ID)
+
+	edgeStack.GitConfig = &gittypes.RepoConfig{
+		URL:            "https://github.com/portainer/portainer.git",
+		Authentication: &gittypes.GitAuthentication{Username: "user", Password: "password"},
+	}
+	edgeStack.AutoUpdate = &portainer.AutoUpdateSettings{
+		Webhook:       "05de31a2-79fa-4644-9c12-faa67e5c49f0",
+		WebhookSecret: "webhook-secret",
+	}
+
+	err := handler.DataStore.EdgeStack().UpdateEdgeStack(edgeStack.ID, &edgeStack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/edge_stacks/%d", edgeStack.ID), nil)
+	if err != nil {
+		t.Fatal("request error:", err)
+	}
+
+	req.Header.Add("x-api-key", rawAPIKey)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a %d response, found: %d", http.StatusOK, rec.Code)
+	}
+
+	var response portainer.EdgeStack
+	err = json.NewDecoder(rec.Body).Decode(&response)
+	if err != nil {
+		t.Fatal("error decoding response:", err)
+	}
+
+	if response.GitConfig.Authentication.Password != "" {
+		t.Fatal("the password of the git repository should not be returned")
+	}
+
+	if response.AutoUpdate.Webhook != edgeStack.AutoUpdate.Webhook || response.AutoUpdate.WebhookSecret != "" {
+		t.Fatal("the secret of the webhook should not be returned")
+	}
+}
@@ -0,0 +1,185 @@
+package edgestacks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
+	"github.com/portainer/portainer/pkg/featureflags"
+
+	"github.com/rs/zerolog/log"
+)
+
+// @id EdgeStackRolloutPromote
+// @summary Promote the rollout of an EdgeStack
+// @description Release the next batch of environments without waiting for the deployments of the released batches.
+// @description A paused rollout is resumed, its current failures are accepted.
+// @description **Access policy**: administrator
+// @tags edge_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeStack Id"
+// @success 200 {object} portainer.EdgeStack
+// @failure 400
+// @failure 404
+// @failure 409 "The edge stack has no active rollout"
+// @failure 500
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_stacks/{id}/rollout/promote [post]
+func (handler *Handler) edgeStackRolloutPromote(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.updateRollout(w, r, func(stack *portainer.EdgeStack) error {
+		return edgestackservice.PromoteRollout(stack)
+	})
+}
+
+// @id EdgeStackRolloutAbort
+// @summary Abort the rollout of an EdgeStack
+// @description Stop the rollout and deploy the previous version of the edge stack to every environment.
+// @description **Access policy**: administrator
+// @tags edge_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeStack Id"
+// @success 200 {object} portainer.EdgeStack
+// @failure 400
+// @failure 404
+// @failure 409 "The edge stack has no active rollout"
+// @failure 500
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_stacks/{id}/rollout/abort [post]
+func (handler *Handler) edgeStackRolloutAbort(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.updateRollout(w, r, func(stack *portainer.EdgeStack) error {
+		if !edgestackservice.IsRolloutActive(stack.Rollout) {
+			return errors.New("the edge stack has no active rollout")
+		}
+
+		edgestackservice.RollbackRollout(stack, "aborted")
+
+		return handler.restoreRolloutFiles(stack)
+	})
+}
+
+func (handler *Handler) updateRollout(w http.ResponseWriter, r *http.Request, updateFunc func(stack *portainer.EdgeStack) error) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	var stack *portainer.EdgeStack
+	update := func(tx dataservices.DataStoreTx) error {
+		stack, err = tx.EdgeStack().EdgeStack(portainer.EdgeStackID(stackID))
+		if err != nil {
+			return handler.handlerDBErr(err, "Unable to find a stack with the specified identifier inside the database")
+		}
+
+		if !edgestackservice.IsRolloutActive(stack.Rollout) {
+			return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The edge stack has no active rollout", Err: errors.New("no active rollout")}
+		}
+
+		err = updateFunc(stack)
+		if err != nil {
+			return httperror.InternalServerError("Unable to update the rollout", err)
+		}
+
+		err = tx.EdgeStack().UpdateEdgeStack(stack.ID, stack)
+		if err != nil {
+			return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+		}
+
+		return nil
+	}
+
+	if featureflags.IsEnabled(portainer.FeatureNoTx) {
+		err = update(handler.DataStore)
+	} else {
+		err = handler.DataStore.UpdateTx(update)
+	}
+
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	hideGitSecrets(stack)
+
+	return response.JSON(w, stack)
+}
+
+// readEdgeStackFiles returns the content of the compose file and of the manifest of the edge stack, by file name
+func (handler *Handler) readEdgeStackFiles(stack *portainer.EdgeStack) (map[string]string, error) {
+	return handler.readEdgeStackFilesFrom(stack, stack.ProjectPath)
+}
+
+// readEdgeStackFilesFrom returns the content of the compose file and of the manifest of the edge stack stored in a
+// folder, by file name
+func (handler *Handler) readEdgeStackFilesFrom(stack *portainer.EdgeStack, folder string) (map[string]string, error) {
+	files := map[string]string{}
+
+	for _, fileName := range []string{stack.EntryPoint, stack.ManifestPath} {
+		if fileName == "" {
+			continue
+		}
+
+		content, err := handler.FileService.GetFileContent(folder, fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		files[fileName] = string(content)
+	}
+
+	return files, nil
+}
+
+// restoreRolloutFiles writes the stored files of the previous version of a rolled back edge stack
+func (handler *Handler) restoreRolloutFiles(stack *portainer.EdgeStack) error {
+	versionPath := handler.FileService.GetEdgeStackVersionPath(strconv.Itoa(int(stack.ID)), stack.Rollout.PreviousVersion)
+
+	files, err := handler.readEdgeStackFilesFrom(stack, versionPath)
+	if err != nil {
+		return err
+	}
+
+	return handler.writeEdgeStackFiles(stack, files)
+}
+
+// writeEdgeStackFiles writes the files of an edge stack, by file name
+func (handler *Handler) writeEdgeStackFiles(stack *portainer.EdgeStack, files map[string]string) error {
+	stackFolder := strconv.Itoa(int(stack.ID))
+
+	for fileName, content := range files {
+		_, err := handler.FileService.StoreEdgeStackFileFromBytes(stackFolder, fileName, []byte(content))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evaluateRollout updates the rollout of the edge stack with the reported statuses, and restores the files of the
+// previous version when it is rolled back
+func (handler *Handler) evaluateRollout(stack *portainer.EdgeStack) {
+	if !edgestackservice.EvaluateRollout(stack) {
+		return
+	}
+
+	log.Warn().Int("edge_stack_id", int(stack.ID)).Str("reason", stack.Rollout.Reason).Msg("the rollout failed, rolling back the edge stack")
+
+	err := handler.restoreRolloutFiles(stack)
+	if err != nil {
+		log.Error().Err(err).Int("edge_stack_id", int(stack.ID)).Msg("unable to restore the files of the previous version")
+	}
+}
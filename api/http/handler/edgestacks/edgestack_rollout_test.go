@@ -0,0 +1,98 @@
+package edgestacks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func reportEdgeStackStatus(t *testing.T, handler *Handler, stackID portainer.EdgeStackID, endpoint portainer.Endpoint, status portainer.EdgeStackStatusType) {
+	t.Helper()
+
+	payload := updateStatusPayload{Status: &status, EndpointID: endpoint.ID}
+	if status == portainer.EdgeStackStatusError {
+		payload.Error = "deployment failed"
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/edge_stacks/%d/status", stackID), bytes.NewReader(jsonPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set(portainer.PortainerAgentEdgeIDHeader, endpoint.EdgeID)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a %d response, found: %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+}
+
+func TestRolloutReleaseAndRollback(t *testing.T) {
+	is := assert.New(t)
+
+	handler, _, teardown := setupHandler(t)
+	defer teardown()
+
+	endpoints := []portainer.Endpoint{}
+	for _, endpointID := range []portainer.EndpointID{1, 2, 3} {
+		endpoints = append(endpoints, createEndpointWithId(t, handler.DataStore, endpointID))
+	}
+
+	projectPath, err := handler.FileService.StoreEdgeStackFileFromBytes("1", "docker-compose.yml", []byte("version: '3.8'"))
+	is.NoError(err)
+
+	_, err = handler.FileService.StoreEdgeStackVersionFileFromBytes("1", 1, "docker-compose.yml", []byte("version: '3'"))
+	is.NoError(err)
+
+	stack := &portainer.EdgeStack{
+		ID:          1,
+		Name:        "rollout",
+		Version:     2,
+		ProjectPath: projectPath,
+		EntryPoint:  "docker-compose.yml",
+		Status: map[portainer.EndpointID]portainer.EdgeStackStatus{
+			// reported for the previous version
+			2: {EndpointID: 2, Details: portainer.EdgeStackStatusDetails{Ok: true}},
+		},
+		RolloutPolicy: &portainer.EdgeStackRolloutPolicy{BatchSize: 1, FailureAction: portainer.EdgeStackRolloutRollback},
+		Rollout: &portainer.EdgeStackRollout{
+			Status:          portainer.EdgeStackRolloutInProgress,
+			Version:         2,
+			PreviousVersion: 1,
+			Batches:         [][]portainer.EndpointID{{1}, {2}, {3}},
+			ReleasedBatches: 1,
+		},
+	}
+	is.NoError(handler.DataStore.EdgeStack().Create(stack.ID, stack))
+
+	reportEdgeStackStatus(t, handler, stack.ID, endpoints[0], portainer.EdgeStackStatusOk)
+
+	stack, err = handler.DataStore.EdgeStack().EdgeStack(stack.ID)
+	is.NoError(err)
+	is.Equal(2, stack.Rollout.ReleasedBatches, "the next batch should be released once the first one is healthy")
+	is.NotContains(stack.Status, portainer.EndpointID(2), "the status of the previous version should be cleared")
+
+	reportEdgeStackStatus(t, handler, stack.ID, endpoints[1], portainer.EdgeStackStatusError)
+
+	stack, err = handler.DataStore.EdgeStack().EdgeStack(stack.ID)
+	is.NoError(err)
+	is.Equal(portainer.EdgeStackRolloutRolledBack, stack.Rollout.Status)
+	is.Equal(3, stack.Version, "the previous version should be deployed under a new version")
+
+	content, err := handler.FileService.GetFileContent(stack.ProjectPath, "docker-compose.yml")
+	is.NoError(err)
+	is.Equal("version: '3'", string(content), "the files of the previous version should be restored")
+}
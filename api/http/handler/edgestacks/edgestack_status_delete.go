@@ -60,6 +60,8 @@ func (handler *Handler) edgeStackStatusDelete(w http.ResponseWriter, r *http.Req
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
+	hideGitSecrets(stack)
+
 	return response.JSON(w, stack)
 }
 
@@ -0,0 +1,98 @@
+package edgestacks
+
+import (
+	"net/http"
+	"strconv"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id EdgeStackStatusEventList
+// @summary List the status events of an EdgeStack
+// @description List the statuses reported by the environments(endpoints) for an EdgeStack and the versions advertised
+// @description to them, from the most recent one. The total number of events is returned in the X-Total-Count header.
+// @description Only the last 100 events of each environment are kept.
+// @description **Access policy**: administrator
+// @tags edge_stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "EdgeStack Id"
+// @param endpointId query int false "Only return the events of this environment(endpoint)"
+// @param start query int false "Start from this event, starting at 1"
+// @param limit query int false "Limit the number of events to this value"
+// @success 200 {array} portainer.EdgeStackStatusEvent
+// @failure 500
+// @failure 400
+// @failure 404
+// @failure 503 "Edge compute features are disabled"
+// @router /edge_stacks/{id}/status_events [get]
+func (handler *Handler) edgeStackStatusEventList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid edge stack identifier route variable", err)
+	}
+
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	start, err := request.RetrieveNumericQueryParameter(r, "start", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: start", err)
+	}
+	if start != 0 {
+		start--
+	}
+
+	limit, err := request.RetrieveNumericQueryParameter(r, "limit", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: limit", err)
+	}
+
+	_, err = handler.DataStore.EdgeStack().EdgeStack(portainer.EdgeStackID(stackID))
+	if err != nil {
+		return handler.handlerDBErr(err, "Unable to find an edge stack with the specified identifier inside the database")
+	}
+
+	events, err := handler.DataStore.EdgeStackStatusEvent().EdgeStackStatusEvents(portainer.EdgeStackID(stackID), portainer.EndpointID(endpointID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the status events of the edge stack from the database", err)
+	}
+
+	recentEvents := make([]portainer.EdgeStackStatusEvent, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		recentEvents = append(recentEvents, events[i])
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(recentEvents)))
+
+	return response.JSON(w, paginateStatusEvents(recentEvents, start, limit))
+}
+
+func paginateStatusEvents(events []portainer.EdgeStackStatusEvent, start, limit int) []portainer.EdgeStackStatusEvent {
+	if limit == 0 {
+		return events
+	}
+
+	eventCount := len(events)
+
+	if start < 0 {
+		start = 0
+	}
+
+	if start > eventCount {
+		start = eventCount
+	}
+
+	end := start + limit
+	if end > eventCount {
+		end = eventCount
+	}
+
+	return events[start:end]
+}
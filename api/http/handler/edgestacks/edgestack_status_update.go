@@ -3,6 +3,8 @@ package edgestacks
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
@@ -80,9 +82,54 @@ func (handler *Handler) edgeStackStatusUpdate(w http.ResponseWriter, r *http.Req
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
+	handler.notifyStatusUpdate(stack, payload)
+
+	hideGitSecrets(stack)
+
 	return response.JSON(w, stack)
 }
 
+// notifyStatusUpdate sends the status reported by an environment(endpoint) to the notification channels
+func (handler *Handler) notifyStatusUpdate(stack *portainer.EdgeStack, payload updateStatusPayload) {
+	if handler.NotificationService == nil || stack == nil {
+		return
+	}
+
+	message := edgeStackStatusName(*payload.Status)
+	if payload.Error != "" {
+		message += ": " + payload.Error
+	}
+
+	handler.NotificationService.Notify(portainer.NotificationEvent{
+		Type:         portainer.NotificationEventEdgeStackStatus,
+		EndpointID:   payload.EndpointID,
+		ResourceID:   strconv.Itoa(int(stack.ID)),
+		ResourceName: stack.Name,
+		Message:      message,
+	})
+}
+
+func edgeStackStatusName(status portainer.EdgeStackStatusType) string {
+	switch status {
+	case portainer.EdgeStackStatusPending:
+		return "pending"
+	case portainer.EdgeStackStatusOk:
+		return "ok"
+	case portainer.EdgeStackStatusError:
+		return "error"
+	case portainer.EdgeStackStatusAcknowledged:
+		return "acknowledged"
+	case portainer.EdgeStackStatusRemove:
+		return "removed"
+	case portainer.EdgeStackStatusRemoteUpdateSuccess:
+		return "remote update success"
+	case portainer.EdgeStackStatusImagesPulled:
+		return "images pulled"
+	}
+
+	return "unknown"
+}
+
 func (handler *Handler) updateEdgeStackStatus(tx dataservices.DataStoreTx, r *http.Request, stackID portainer.EdgeStackID, payload updateStatusPayload) (*portainer.EdgeStack, error) {
 	endpoint, err := tx.Endpoint().Endpoint(payload.EndpointID)
 	if err != nil {
@@ -120,6 +167,8 @@ func (handler *Handler) updateEdgeStackStatus(tx dataservices.DataStoreTx, r *ht
 				EndpointID: payload.EndpointID,
 			}
 
+			handler.evaluateRollout(edgeStack)
+
 			stack = edgeStack
 		})
 	} else {
@@ -150,11 +199,42 @@ func (handler *Handler) updateEdgeStackStatus(tx dataservices.DataStoreTx, r *ht
 			EndpointID: payload.EndpointID,
 		}
 
+		handler.evaluateRollout(stack)
+
 		err = tx.EdgeStack().UpdateEdgeStack(stackID, stack)
 	}
 	if err != nil {
 		return nil, handler.handlerDBErr(err, "Unable to persist the stack changes inside the database")
 	}
 
+	err = recordStatusEvent(tx, stack, payload)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to persist the status event inside the database", err)
+	}
+
 	return stack, nil
 }
+
+// recordStatusEvent adds the reported status to the timeline of the environment(endpoint), along with the version
+// of the edge stack last advertised to its agent
+func recordStatusEvent(tx dataservices.DataStoreTx, stack *portainer.EdgeStack, payload updateStatusPayload) error {
+	version := stack.Version
+
+	relation, err := tx.EndpointRelation().EndpointRelation(payload.EndpointID)
+	if err != nil && !tx.IsErrObjectNotFound(err) {
+		return err
+	} else if err == nil {
+		if advertisedVersion, ok := relation.EdgeStackVersions[stack.ID]; ok {
+			version = advertisedVersion
+		}
+	}
+
+	return tx.EdgeStackStatusEvent().Create(&portainer.EdgeStackStatusEvent{
+		EdgeStackID: stack.ID,
+		EndpointID:  payload.EndpointID,
+		Version:     version,
+		Type:        *payload.Status,
+		Error:       payload.Error,
+		Time:        time.Now().Unix(),
+	})
+}
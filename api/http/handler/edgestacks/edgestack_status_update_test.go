@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices/edgestackstatusevent"
 )
 
 // Update Status
@@ -149,3 +150,124 @@ func TestUpdateStatusWithInvalidPayload(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateStatusRecordsTimeline(t *testing.T) {
+	handler, rawAPIKey, teardown := setupHandler(t)
+	defer teardown()
+
+	endpoint := createEndpoint(t, handler.DataStore)
+	edgeStack := createEdgeStack(t, handler.DataStore, endpoint.ID)
+
+	acknowledged := portainer.EdgeStackStatusAcknowledged
+	failed := portainer.EdgeStackStatusError
+
+	for _, payload := range []updateStatusPayload{
+		{Status: &acknowledged, EndpointID: endpoint.ID},
+		{Status: &failed, Error: "pull access denied", EndpointID: endpoint.ID},
+	} {
+		jsonPayload, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal("request error:", err)
+		}
+
+		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/edge_stacks/%d/status", edgeStack.ID), bytes.NewBuffer(jsonPayload))
+		if err != nil {
+			t.Fatal("request error:", err)
+		}
+
+		req.Header.Set(portainer.PortainerAgentEdgeIDHeader, endpoint.EdgeID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected a %d response, found: %d", http.StatusOK, rec.Code)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/edge_stacks/%d/status_events?endpointId=%d&limit=1", edgeStack.ID, endpoint.ID), nil)
+	if err != nil {
+		t.Fatal("request error:", err)
+	}
+
+	req.Header.Add("x-api-key", rawAPIKey)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a %d response, found: %d", http.StatusOK, rec.Code)
+	}
+
+	if rec.Header().Get("X-Total-Count") != "2" {
+		t.Fatalf("expected 2 events, found %s", rec.Header().Get("X-Total-Count"))
+	}
+
+	events := []portainer.EdgeStackStatusEvent{}
+	err = json.NewDecoder(rec.Body).Decode(&events)
+	if err != nil {
+		t.Fatal("error decoding response:", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected a single event in the page, found %d", len(events))
+	}
+
+	if events[0].Type != portainer.EdgeStackStatusError || events[0].Error != "pull access denied" || events[0].Version != edgeStack.Version {
+		t.Fatalf("expected the most recent event to be the error of version %d, found %+v", edgeStack.Version, events[0])
+	}
+}
+
+func TestStatusEventsRetention(t *testing.T) {
+	handler, _, teardown := setupHandler(t)
+	defer teardown()
+
+	events := handler.DataStore.EdgeStackStatusEvent()
+
+	for i := 0; i < edgestackstatusevent.MaxEndpointEvents+5; i++ {
+		err := events.Create(&portainer.EdgeStackStatusEvent{EdgeStackID: 1, EndpointID: 5, Version: i, Type: portainer.EdgeStackStatusOk})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, event := range []portainer.EdgeStackStatusEvent{
+		{EdgeStackID: 1, EndpointID: 6, Type: portainer.EdgeStackStatusOk},
+		{EdgeStackID: 2, EndpointID: 5, Type: portainer.EdgeStackStatusOk},
+	} {
+		err := events.Create(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	endpointEvents, err := events.EdgeStackStatusEvents(1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(endpointEvents) != edgestackstatusevent.MaxEndpointEvents || endpointEvents[0].Version != 5 {
+		t.Fatalf("expected the %d most recent events to be kept, found %d from version %d", edgestackstatusevent.MaxEndpointEvents, len(endpointEvents), endpointEvents[0].Version)
+	}
+
+	err = events.DeleteEndpointStatusEvents(1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stackEvents, err := events.EdgeStackStatusEvents(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stackEvents) != 1 || stackEvents[0].EndpointID != 6 {
+		t.Fatalf("expected only the events of the other environment to be kept, found %+v", stackEvents)
+	}
+
+	otherEvents, err := events.EdgeStackStatusEvents(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(otherEvents) != 1 {
+		t.Fatalf("expected the events of the other edge stack to be kept, found %d", len(otherEvents))
+	}
+}
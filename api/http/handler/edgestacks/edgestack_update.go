@@ -12,6 +12,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/internal/edge"
+	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/pkg/featureflags"
 
@@ -19,24 +20,32 @@ import (
 )
 
 type updateEdgeStackPayload struct {
+	// Content of the stack file, the git stacks keep the file of their repository when it is empty
 	StackFileContent string
 	Version          *int
 	EdgeGroups       []portainer.EdgeGroupID
 	DeploymentType   portainer.EdgeStackDeploymentType
 	// Uses the manifest's namespaces instead of the default one
 	UseManifestNamespaces bool
+	// Renders the stack file as a template of the values of each environment, e.g. {{ .EndpointName }} or {{ .Variables.region }}
+	UseTemplating bool
+	// Deploys the new versions to the environments in batches, all the environments are updated at once when it is not set
+	RolloutPolicy *portainer.EdgeStackRolloutPolicy
+	// The new versions are only deployed inside this window, instead of the windows of the edge groups
+	DeploymentWindow *portainer.EdgeDeploymentWindow
 }
 
 func (payload *updateEdgeStackPayload) Validate(r *http.Request) error {
-	if payload.StackFileContent == "" {
-		return errors.New("invalid stack file content")
-	}
-
 	if len(payload.EdgeGroups) == 0 {
 		return errors.New("edge Groups are mandatory for an Edge stack")
 	}
 
-	return nil
+	err := edgestackservice.ValidateRolloutPolicy(payload.RolloutPolicy)
+	if err != nil {
+		return err
+	}
+
+	return edge.ValidateDeploymentWindow(payload.DeploymentWindow)
 }
 
 // @id EdgeStackUpdate
@@ -85,6 +94,8 @@ func (handler *Handler) edgeStackUpdate(w http.ResponseWriter, r *http.Request)
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
+	hideGitSecrets(stack)
+
 	return response.JSON(w, stack)
 }
 
@@ -94,6 +105,12 @@ func (handler *Handler) updateEdgeStack(tx dataservices.DataStoreTx, stackID por
 		return nil, handler.handlerDBErr(err, "Unable to find a stack with the specified identifier inside the database")
 	}
 
+	// the git stacks keep the file of their repository, unless the deployment type changes
+	keepFiles := payload.StackFileContent == ""
+	if keepFiles && (stack.GitConfig == nil || stack.DeploymentType != payload.DeploymentType) {
+		return nil, httperror.BadRequest("Invalid request payload", errors.New("invalid stack file content"))
+	}
+
 	relationConfig, err := edge.FetchEndpointRelationsConfig(tx)
 	if err != nil {
 		return nil, httperror.InternalServerError("Unable to retrieve environments relations config from database", err)
@@ -104,6 +121,20 @@ func (handler *Handler) updateEdgeStack(tx dataservices.DataStoreTx, stackID por
 		return nil, httperror.InternalServerError("Unable to retrieve edge stack related environments from database", err)
 	}
 
+	versionUpdated := payload.Version != nil && *payload.Version != stack.Version
+
+	// the environments waiting for their batch or held back by the deployment windows keep the files of the previous version
+	var previousFiles map[string]string
+	if versionUpdated {
+		previousFiles, err = handler.readEdgeStackFiles(stack)
+		if err != nil && payload.RolloutPolicy != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the files of the edge stack from disk", err)
+		} else if err != nil {
+			// the held back environments are then deployed with the new version
+			log.Warn().Err(err).Int("edge_stack_id", int(stack.ID)).Msg("Unable to retrieve the files of the previous version of the edge stack")
+		}
+	}
+
 	endpointsToAdd := map[portainer.EndpointID]bool{}
 
 	if payload.EdgeGroups != nil {
@@ -182,7 +213,19 @@ func (handler *Handler) updateEdgeStack(tx dataservices.DataStoreTx, stackID por
 		return nil, httperror.BadRequest("edge stack with config do not match the environment type", nil)
 	}
 
-	if payload.DeploymentType == portainer.EdgeStackDeploymentCompose {
+	stack.UseTemplating = payload.UseTemplating
+
+	var content []byte
+	if !keepFiles {
+		content = []byte(payload.StackFileContent)
+	}
+
+	err = handler.validateTemplate(tx, stack, content, relatedEndpointIds)
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid stack template", err)
+	}
+
+	if !keepFiles && payload.DeploymentType == portainer.EdgeStackDeploymentCompose {
 		if stack.EntryPoint == "" {
 			stack.EntryPoint = filesystem.ComposeFileDefaultName
 		}
@@ -201,22 +244,48 @@ func (handler *Handler) updateEdgeStack(tx dataservices.DataStoreTx, stackID por
 	}
 
 	if payload.DeploymentType == portainer.EdgeStackDeploymentKubernetes {
+		stack.UseManifestNamespaces = payload.UseManifestNamespaces
+	}
+
+	if !keepFiles && payload.DeploymentType == portainer.EdgeStackDeploymentKubernetes {
 		if stack.ManifestPath == "" {
 			stack.ManifestPath = filesystem.ManifestFileDefaultName
 		}
 
-		stack.UseManifestNamespaces = payload.UseManifestNamespaces
-
 		_, err = handler.FileService.StoreEdgeStackFileFromBytes(stackFolder, stack.ManifestPath, []byte(payload.StackFileContent))
 		if err != nil {
 			return nil, httperror.InternalServerError("Unable to persist updated Kubernetes manifest file on disk", err)
 		}
 	}
 
-	versionUpdated := payload.Version != nil && *payload.Version != stack.Version
+	stack.RolloutPolicy = payload.RolloutPolicy
+	stack.DeploymentWindow = payload.DeploymentWindow
+
 	if versionUpdated {
+		previousVersion := stack.Version
 		stack.Version = *payload.Version
 		stack.Status = map[portainer.EndpointID]portainer.EdgeStackStatus{}
+
+		if previousFiles != nil {
+			err = edgestackservice.StoreVersionFiles(handler.FileService, stack, previousVersion, previousFiles)
+			if err != nil {
+				return nil, httperror.InternalServerError("Unable to persist the files of the previous version of the edge stack on disk", err)
+			}
+		}
+
+		storedVersions := append(edgestackservice.StoredVersions(stack), previousVersion)
+
+		edgestackservice.StartRollout(stack, previousVersion, "", relatedEndpointIds)
+
+		advertisedVersions, err := edgestackservice.AdvertisedVersions(tx, stack.ID)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the versions of the edge stack advertised to the environments", err)
+		}
+
+		edgestackservice.KeepHeldVersions(stack, advertisedVersions, previousVersion, previousFiles != nil)
+		edgestackservice.RemoveVersionFiles(handler.FileService, stack, storedVersions)
+	} else if stack.RolloutPolicy == nil {
+		stack.Rollout = nil
 	}
 
 	stack.NumDeployments = len(relatedEndpointIds)
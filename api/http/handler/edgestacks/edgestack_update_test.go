@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
 )
 
 // Update
@@ -254,3 +255,66 @@ func TestUpdateWithInvalidPayload(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateGitStackTemplate(t *testing.T) {
+	handler, rawAPIKey, teardown := setupHandler(t)
+	defer teardown()
+
+	endpoint := createEndpoint(t, handler.DataStore)
+	edgeStack := createEdgeStack(t, handler.DataStore, endpoint.ID)
+
+	projectPath, err := handler.FileService.StoreEdgeStackFileFromBytes("14", "docker-compose.yml", []byte("image: app:{{ .Variables.region }}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edgeStack.DeploymentType = portainer.EdgeStackDeploymentCompose
+	edgeStack.ProjectPath = projectPath
+	edgeStack.EntryPoint = "docker-compose.yml"
+	edgeStack.ManifestPath = ""
+	edgeStack.GitConfig = &gittypes.RepoConfig{URL: "https://github.com/portainer/portainer.git", ConfigFilePath: "docker-compose.yml"}
+
+	err = handler.DataStore.EdgeStack().UpdateEdgeStack(edgeStack.ID, &edgeStack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(useTemplating bool) int {
+		jsonPayload, err := json.Marshal(updateEdgeStackPayload{
+			EdgeGroups:     edgeStack.EdgeGroups,
+			DeploymentType: edgeStack.DeploymentType,
+			UseTemplating:  useTemplating,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/edge_stacks/%d", edgeStack.ID), bytes.NewReader(jsonPayload))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Add("x-api-key", rawAPIKey)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	if code := update(true); code != http.StatusBadRequest {
+		t.Fatalf("the file of the repository should be validated, expected a %d response, found: %d", http.StatusBadRequest, code)
+	}
+
+	if code := update(false); code != http.StatusOK {
+		t.Fatalf("the git stack should keep the file of its repository, expected a %d response, found: %d", http.StatusOK, code)
+	}
+
+	content, err := handler.FileService.GetFileContent(projectPath, "docker-compose.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "image: app:{{ .Variables.region }}" {
+		t.Fatalf("the file of the repository should not be replaced, found: %s", content)
+	}
+}
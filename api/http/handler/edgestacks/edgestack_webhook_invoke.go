@@ -0,0 +1,163 @@
+package edgestacks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git/update"
+	gitwebhook "github.com/portainer/portainer/api/git/webhook"
+	"github.com/portainer/portainer/api/internal/edge"
+	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/rs/zerolog/log"
+)
+
+// @id EdgeStackWebhookInvoke
+// @summary Webhook for triggering edge stack updates from git
+// @description Pulls the Git repository of the edge stack and redeploys it on its environments when the repository was updated.
+// @description The push webhooks of GitHub, GitLab, Gitea and Bitbucket only redeploy the edge stack when the pushed reference
+// @description and the changed files match its git configuration. The calls must be signed with the webhook secret of the edge stack when it is set.
+// @description **Access policy**: public
+// @tags edge_stacks
+// @param webhookID path string true "Edge stack webhook identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request, or the new version of a templated edge stack can't be rendered"
+// @failure 401 "Invalid webhook signature"
+// @failure 404 "Edge stack not found"
+// @failure 500 "Server error"
+// @router /edge_stacks/webhooks/{webhookID} [post]
+func (handler *Handler) edgeStackWebhookInvoke(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhookID, err := request.RetrieveRouteVariableValue(r, "webhookID")
+	if err != nil || !govalidator.IsUUID(webhookID) {
+		return httperror.BadRequest("Invalid webhook identifier route variable", err)
+	}
+
+	edgeStack, err := handler.edgeStackByWebhook(webhookID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve edge stacks from the database", err)
+	} else if edgeStack == nil {
+		return httperror.NotFound("Unable to find the edge stack by webhook ID", errors.New("edge stack not found"))
+	}
+
+	push, err := gitwebhook.ReadPush(r, edgeStack.AutoUpdate.WebhookSecret)
+	if errors.Is(err, gitwebhook.ErrNotPush) {
+		return response.Empty(w)
+	} else if gitwebhook.IsSignatureError(err) {
+		return &httperror.HandlerError{StatusCode: http.StatusUnauthorized, Message: "Invalid webhook signature", Err: err}
+	} else if err != nil {
+		return httperror.BadRequest("Invalid webhook payload", err)
+	}
+
+	if push != nil && !push.Affects(edgeStack.GitConfig, []string{edgeStack.GitConfig.ConfigFilePath}) {
+		log.Debug().Int("edge_stack_id", int(edgeStack.ID)).Str("ref", push.Ref).Str("commit", push.Commit).Msg("the push does not affect the edge stack, skipping the redeployment")
+
+		return response.Empty(w)
+	}
+
+	// the environments waiting for their batch or held back by the deployment windows keep the files of the previous
+	// version, which are also restored when the new version of a templated edge stack can't be rendered
+	previousFiles, err := handler.readEdgeStackFiles(edgeStack)
+	if err != nil && (edgeStack.RolloutPolicy != nil || edgeStack.UseTemplating) {
+		return httperror.InternalServerError("Unable to retrieve the files of the edge stack from disk", err)
+	} else if err != nil {
+		// the held back environments are then deployed with the new version
+		log.Warn().Err(err).Int("edge_stack_id", int(edgeStack.ID)).Msg("Unable to retrieve the files of the previous version of the edge stack")
+	}
+
+	advertisedVersions, err := edgestackservice.AdvertisedVersions(handler.DataStore, edgeStack.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the versions of the edge stack advertised to the environments", err)
+	}
+
+	var relatedEndpointIds []portainer.EndpointID
+	if edgeStack.RolloutPolicy != nil || edgeStack.UseTemplating {
+		relationConfig, err := edge.FetchEndpointRelationsConfig(handler.DataStore)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve environments relations config from database", err)
+		}
+
+		relatedEndpointIds, err = edge.EdgeStackRelatedEndpoints(edgeStack.EdgeGroups, relationConfig.Endpoints, relationConfig.EndpointGroups, relationConfig.EdgeGroups)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve edge stack related environments from database", err)
+		}
+	}
+
+	updated, newHash, err := update.UpdateGitObject(handler.GitService, fmt.Sprintf("edge_stack:%d", edgeStack.ID), edgeStack.GitConfig, false, edgeStack.ProjectPath)
+	if err != nil {
+		return httperror.InternalServerError("Unable to update the edge stack from its git repository", err)
+	}
+
+	if !updated {
+		return response.Empty(w)
+	}
+
+	err = handler.validateTemplate(handler.DataStore, edgeStack, nil, relatedEndpointIds)
+	if err != nil {
+		if restoreErr := handler.writeEdgeStackFiles(edgeStack, previousFiles); restoreErr != nil {
+			log.Warn().Err(restoreErr).Msg("Unable to restore the files of the edge stack")
+		}
+
+		return httperror.BadRequest("Invalid stack template", err)
+	}
+
+	if previousFiles != nil {
+		err = edgestackservice.StoreVersionFiles(handler.FileService, edgeStack, edgeStack.Version, previousFiles)
+		if err != nil {
+			return httperror.InternalServerError("Unable to persist the files of the previous version of the edge stack on disk", err)
+		}
+	}
+
+	// a new version makes the agents redeploy the edge stack
+	var updatedStack *portainer.EdgeStack
+	var storedVersions []int
+	err = handler.DataStore.EdgeStack().UpdateEdgeStackFunc(edgeStack.ID, func(stack *portainer.EdgeStack) {
+		previousVersion, previousHash := stack.Version, stack.GitConfig.ConfigHash
+		storedVersions = append(edgestackservice.StoredVersions(stack), previousVersion)
+		updatedStack = stack
+
+		stack.GitConfig.ConfigHash = newHash
+		stack.Version++
+		stack.Status = make(map[portainer.EndpointID]portainer.EdgeStackStatus)
+
+		if stack.RolloutPolicy != nil {
+			edgestackservice.StartRollout(stack, previousVersion, previousHash, relatedEndpointIds)
+		}
+
+		edgestackservice.KeepHeldVersions(stack, advertisedVersions, previousVersion, previousFiles != nil)
+
+		if push != nil {
+			stack.AutoUpdate.LastWebhookPush = push.Record(time.Now())
+		}
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the edge stack changes inside the database", err)
+	}
+
+	edgestackservice.RemoveVersionFiles(handler.FileService, updatedStack, storedVersions)
+
+	return response.Empty(w)
+}
+
+// edgeStackByWebhook returns the git-based edge stack of a webhook, or nil when no edge stack uses it
+func (handler *Handler) edgeStackByWebhook(webhookID string) (*portainer.EdgeStack, error) {
+	edgeStacks, err := handler.DataStore.EdgeStack().EdgeStacks()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range edgeStacks {
+		edgeStack := &edgeStacks[i]
+		if edgeStack.GitConfig != nil && edgeStack.AutoUpdate != nil && edgeStack.AutoUpdate.Webhook == webhookID {
+			return edgeStack, nil
+		}
+	}
+
+	return nil, nil
+}
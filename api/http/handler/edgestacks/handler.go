@@ -9,6 +9,8 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git/update"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
@@ -17,12 +19,13 @@ import (
 // Handler is the HTTP handler used to handle environment(endpoint) group operations.
 type Handler struct {
 	*mux.Router
-	requestBouncer     *security.RequestBouncer
-	DataStore          dataservices.DataStore
-	FileService        portainer.FileService
-	GitService         portainer.GitService
-	edgeStacksService  *edgestackservice.Service
-	KubernetesDeployer portainer.KubernetesDeployer
+	requestBouncer      *security.RequestBouncer
+	DataStore           dataservices.DataStore
+	FileService         portainer.FileService
+	GitService          portainer.GitService
+	edgeStacksService   *edgestackservice.Service
+	KubernetesDeployer  portainer.KubernetesDeployer
+	NotificationService portainer.NotificationService
 }
 
 // NewHandler creates a handler to manage environment(endpoint) group operations.
@@ -46,6 +49,14 @@ func NewHandler(bouncer *security.RequestBouncer, dataStore dataservices.DataSto
 		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeStackDelete)))).Methods(http.MethodDelete)
 	h.Handle("/edge_stacks/{id}/file",
 		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeStackFile)))).Methods(http.MethodGet)
+	h.Handle("/edge_stacks/{id}/status_events",
+		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeStackStatusEventList)))).Methods(http.MethodGet)
+	h.Handle("/edge_stacks/{id}/rollout/promote",
+		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeStackRolloutPromote)))).Methods(http.MethodPost)
+	h.Handle("/edge_stacks/{id}/rollout/abort",
+		bouncer.AdminAccess(bouncer.EdgeComputeOperation(httperror.LoggerHandler(h.edgeStackRolloutAbort)))).Methods(http.MethodPost)
+	h.Handle("/edge_stacks/webhooks/{webhookID}",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.edgeStackWebhookInvoke))).Methods(http.MethodPost)
 	h.Handle("/edge_stacks/{id}/status",
 		bouncer.PublicAccess(httperror.LoggerHandler(h.edgeStackStatusUpdate))).Methods(http.MethodPut)
 
@@ -86,6 +97,52 @@ func (handler *Handler) convertAndStoreKubeManifestIfNeeded(stackFolder string,
 	return komposeFileName, nil
 }
 
+// hideGitSecrets removes the password and the private keys of the git repository, and the secret of the webhook, of
+// an edge stack from the responses
+func hideGitSecrets(stack *portainer.EdgeStack) {
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		stack.GitConfig.Authentication.HideSecrets()
+	}
+
+	update.HideWebhookSecret(stack.AutoUpdate)
+}
+
+// validateTemplate verifies that the file of a templated edge stack can be rendered for each of its environments(endpoints),
+// the file stored on disk is used when the content is nil, e.g. for the git stacks
+func (handler *Handler) validateTemplate(tx dataservices.DataStoreTx, stack *portainer.EdgeStack, content []byte, relatedEndpointIds []portainer.EndpointID) error {
+	if !stack.UseTemplating {
+		return nil
+	}
+
+	if content == nil {
+		var err error
+		content, err = handler.FileService.GetFileContent(stack.ProjectPath, templateFileName(stack))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := edgestackservice.ValidateTemplate(tx, string(content), relatedEndpointIds)
+	if err != nil {
+		return httperrors.NewInvalidPayloadError(err.Error())
+	}
+
+	return nil
+}
+
+// templateFileName returns the file of an edge stack that is rendered as a template
+func templateFileName(stack *portainer.EdgeStack) string {
+	if stack.GitConfig != nil {
+		return stack.GitConfig.ConfigFilePath
+	}
+
+	if stack.DeploymentType == portainer.EdgeStackDeploymentKubernetes {
+		return stack.ManifestPath
+	}
+
+	return stack.EntryPoint
+}
+
 func (handler *Handler) handlerDBErr(err error, msg string) *httperror.HandlerError {
 	httpErr := httperror.InternalServerError(msg, err)
 
@@ -0,0 +1,165 @@
+package endpointedge
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+type edgeJobRunPayload struct {
+	// Version of the Edge job that was run
+	Version int
+	// Starts at 1, the retries of a failed run have the following attempts
+	Attempt int
+	// Unix timestamps of the execution
+	StartedAt int64
+	EndedAt   int64
+	ExitCode  int
+	// Whether the run was stopped by the timeout of the Edge job
+	TimedOut bool
+	Output   string
+}
+
+func (payload *edgeJobRunPayload) Validate(r *http.Request) error {
+	if payload.Attempt < 0 {
+		return errors.New("invalid attempt")
+	}
+
+	if payload.StartedAt <= 0 || payload.EndedAt < payload.StartedAt {
+		return errors.New("invalid start or end time")
+	}
+
+	return nil
+}
+
+// endpointEdgeJobRunCreate
+// @summary Record a run of an EdgeJob
+// @description Record a run of an EdgeJob, only the end of its output and the last 50 runs of each environment(endpoint)
+// @description are kept. The payload is limited to 1MB.
+// @description **Access policy**: public
+// @tags edge, endpoints
+// @accept json
+// @produce json
+// @param id path int true "environment(endpoint) Id"
+// @param jobID path int true "Job Id"
+// @param body body edgeJobRunPayload true "Run details"
+// @success 200 {object} portainer.EdgeJobRun
+// @failure 500
+// @failure 400
+// @failure 403
+// @failure 404
+// @failure 413 "Payload too large"
+// @router /endpoints/{id}/edge/jobs/{jobID}/runs [post]
+func (handler *Handler) endpointEdgeJobRunCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.BadRequest("Unable to find an environment on request context", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEdgeEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	edgeJobID, err := request.RetrieveNumericRouteVariableValue(r, "jobID")
+	if err != nil {
+		return httperror.BadRequest("Invalid edge job identifier route variable", err)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, edge.EdgeJobRunPayloadMaxSize)
+
+	var payload edgeJobRunPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return httperror.NewError(http.StatusRequestEntityTooLarge, "The edge job run is too large", err)
+	} else if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	edgeJob, err := handler.DataStore.EdgeJob().EdgeJob(portainer.EdgeJobID(edgeJobID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an edge job with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an edge job with the specified identifier inside the database", err)
+	}
+
+	if _, ok := edgeJob.Endpoints[endpoint.ID]; !ok {
+		endpointIDs, err := edge.GetEndpointsFromEdgeGroups(edgeJob.EdgeGroups, handler.DataStore)
+		if err != nil {
+			return httperror.InternalServerError("Unable to get Endpoints from EdgeGroups", err)
+		}
+
+		if !slices.Contains(endpointIDs, endpoint.ID) {
+			return httperror.Forbidden("Permission denied to access environment", errors.New("the edge job doesn't target the environment"))
+		}
+	}
+
+	run := &portainer.EdgeJobRun{
+		EdgeJobID:  edgeJob.ID,
+		EndpointID: endpoint.ID,
+		Version:    payload.Version,
+		Attempt:    payload.Attempt,
+		StartedAt:  payload.StartedAt,
+		EndedAt:    payload.EndedAt,
+		ExitCode:   payload.ExitCode,
+		TimedOut:   payload.TimedOut,
+	}
+
+	if run.Version == 0 {
+		run.Version = edgeJob.Version
+	}
+
+	if run.Attempt == 0 {
+		run.Attempt = 1
+	}
+
+	output, outputTruncated := edge.TruncateEdgeJobRunOutput(payload.Output)
+	run.OutputTruncated = outputTruncated
+
+	var deletedRuns []portainer.EdgeJobRun
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		err := tx.EdgeJobRun().Create(run)
+		if err != nil {
+			return err
+		}
+
+		deletedRuns, err = tx.EdgeJobRun().DeleteEndpointRuns(edgeJob.ID, endpoint.ID, edge.EdgeJobMaxEndpointRuns)
+		return err
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the edge job run inside the database", err)
+	}
+
+	edgeJobIdentifier := strconv.Itoa(int(edgeJob.ID))
+
+	if output != "" {
+		err = handler.FileService.StoreEdgeJobTaskLogFileFromBytes(edgeJobIdentifier, edge.EdgeJobRunOutputTaskID(run.ID), []byte(output))
+		if err != nil {
+			return httperror.InternalServerError("Unable to save the output of the edge job run", err)
+		}
+	}
+
+	for _, deletedRun := range deletedRuns {
+		err = handler.FileService.ClearEdgeJobTaskLogs(edgeJobIdentifier, edge.EdgeJobRunOutputTaskID(deletedRun.ID))
+		if err != nil && !os.IsNotExist(err) {
+			log.Warn().Err(err).Int("edge_job_id", int(edgeJob.ID)).Int("run_id", int(deletedRun.ID)).Msg("unable to remove the output of a deleted edge job run")
+		}
+	}
+
+	run.Output = output
+
+	return response.JSON(w, run)
+}
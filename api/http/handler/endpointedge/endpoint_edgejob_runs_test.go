@@ -0,0 +1,109 @@
+package endpointedge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/edge"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func reportEdgeJobRun(t *testing.T, handler *Handler, endpoint portainer.Endpoint, edgeJobID portainer.EdgeJobID, payload edgeJobRunPayload) *httptest.ResponseRecorder {
+	t.Helper()
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/endpoints/%d/edge/jobs/%d/runs", endpoint.ID, edgeJobID), bytes.NewReader(jsonPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set(portainer.PortainerAgentEdgeIDHeader, endpoint.EdgeID)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestEdgeJobRunRetention(t *testing.T) {
+	is := assert.New(t)
+
+	handler, teardown, err := setupHandler(t)
+	defer teardown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints := []portainer.Endpoint{}
+	for _, endpointID := range []portainer.EndpointID{61, 62} {
+		endpoint := portainer.Endpoint{
+			ID:              endpointID,
+			Name:            fmt.Sprintf("endpoint-id-%d", endpointID),
+			Type:            portainer.EdgeAgentOnDockerEnvironment,
+			URL:             "https://portainer.io:9443",
+			EdgeID:          fmt.Sprintf("edge-id-%d", endpointID),
+			LastCheckInDate: time.Now().Unix(),
+		}
+
+		err = createEndpoint(handler, endpoint, portainer.EndpointRelation{EndpointID: endpoint.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		endpoints = append(endpoints, endpoint)
+	}
+
+	edgeJob := &portainer.EdgeJob{
+		ID:        36,
+		Name:      "test-edge-job",
+		Version:   1,
+		Endpoints: map[portainer.EndpointID]portainer.EdgeJobEndpointMeta{61: {}, 62: {}},
+	}
+	is.NoError(handler.DataStore.EdgeJob().Create(edgeJob.ID, edgeJob))
+
+	run := edgeJobRunPayload{StartedAt: 1689033600, EndedAt: 1689033612}
+
+	for i := 0; i < edge.EdgeJobMaxEndpointRuns+2; i++ {
+		run.Output = fmt.Sprintf("run %d", i)
+
+		rec := reportEdgeJobRun(t, handler, endpoints[0], edgeJob.ID, run)
+		is.Equal(http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	rec := reportEdgeJobRun(t, handler, endpoints[1], edgeJob.ID, run)
+	is.Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	runs, err := handler.DataStore.EdgeJobRun().EdgeJobRuns(edgeJob.ID, endpoints[0].ID)
+	is.NoError(err)
+	is.Len(runs, edge.EdgeJobMaxEndpointRuns, "the oldest runs of the environment should be deleted")
+	is.Empty(runs[0].Output, "the output should not be stored in the database")
+
+	runs, err = handler.DataStore.EdgeJobRun().EdgeJobRuns(edgeJob.ID, 0)
+	is.NoError(err)
+	is.Len(runs, edge.EdgeJobMaxEndpointRuns+1, "the runs of the other environments should be kept")
+
+	edgeJobIdentifier := strconv.Itoa(int(edgeJob.ID))
+
+	output, err := handler.FileService.GetEdgeJobTaskLogFileContent(edgeJobIdentifier, edge.EdgeJobRunOutputTaskID(runs[0].ID))
+	is.NoError(err)
+	is.Equal("run 2", output)
+
+	_, err = handler.FileService.GetEdgeJobTaskLogFileContent(edgeJobIdentifier, edge.EdgeJobRunOutputTaskID(1))
+	is.Error(err, "the output of the deleted runs should be removed")
+
+	run.Output = strings.Repeat("a", edge.EdgeJobRunPayloadMaxSize)
+	rec = reportEdgeJobRun(t, handler, endpoints[1], edgeJob.ID, run)
+	is.Equal(http.StatusRequestEntityTooLarge, rec.Code)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/kubernetes"
 )
@@ -29,7 +30,7 @@ type configResponse struct {
 // @param stackId path int true "EdgeStack Id"
 // @success 200 {object} configResponse
 // @failure 500
-// @failure 400
+// @failure 400 "The edge stack template can't be rendered with the values of the environment"
 // @failure 404
 // @router /endpoints/{id}/edge/stacks/{stackId} [get]
 func (handler *Handler) endpointEdgeStackInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -76,13 +77,37 @@ func (handler *Handler) endpointEdgeStackInspect(w http.ResponseWriter, r *http.
 
 	}
 
-	stackFileContent, err := handler.FileService.GetFileContent(edgeStack.ProjectPath, fileName)
+	// the agent deploys the version advertised by the status of the environment
+	relation, err := handler.DataStore.EndpointRelation().EndpointRelation(endpoint.ID)
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve relation object from the database", err)
+	}
+
+	version := 0
+	if relation != nil {
+		version = relation.EdgeStackVersions[edgeStack.ID]
+	}
+
+	stackFileContent, err := edgestacks.EndpointFile(handler.FileService, edgeStack, endpoint, fileName, version)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve Compose file from disk", err)
 	}
 
+	if edgeStack.UseTemplating {
+		data, err := edgestacks.FetchTemplateData(handler.DataStore, endpoint)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the template values of the environment", err)
+		}
+
+		stackFileContent, err = edgestacks.RenderTemplate(stackFileContent, data)
+		if err != nil {
+			// a value referenced by the template is missing from the environment
+			return httperror.BadRequest("Unable to render the edge stack template for the environment", err)
+		}
+	}
+
 	return response.JSON(w, configResponse{
-		StackFileContent: string(stackFileContent),
+		StackFileContent: stackFileContent,
 		Name:             edgeStack.Name,
 		Namespace:        namespace,
 	})
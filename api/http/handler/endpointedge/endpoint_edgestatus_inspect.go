@@ -17,7 +17,10 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/edge"
 	"github.com/portainer/portainer/api/internal/edge/cache"
+	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 )
 
 type stackStatusResponse struct {
@@ -38,6 +41,12 @@ type edgeJobResponse struct {
 	Script string `json:"Script" example:"echo hello"`
 	// Version of this EdgeJob
 	Version int `json:"Version" example:"2"`
+	// Number of times a failed run is retried
+	MaxRetries int `json:"MaxRetries" example:"3"`
+	// Seconds to wait before retrying a failed run
+	RetryInterval int `json:"RetryInterval" example:"60"`
+	// Seconds after which a run is stopped, the runs are not limited when it is 0
+	Timeout int `json:"Timeout" example:"300"`
 }
 
 type endpointEdgeStatusInspectResponse struct {
@@ -150,13 +159,14 @@ func (handler *Handler) endpointEdgeStatusInspect(w http.ResponseWriter, r *http
 		handler.ReverseTunnelService.SetTunnelStatusToActive(endpoint.ID)
 	}
 
-	edgeStacksStatus, handlerErr := handler.buildEdgeStacks(endpoint.ID)
+	edgeStacksStatus, held, handlerErr := handler.buildEdgeStacks(endpoint)
 	if handlerErr != nil {
 		return handlerErr
 	}
 	statusResponse.Stacks = edgeStacksStatus
 
-	return cacheResponse(w, endpoint.ID, statusResponse)
+	// the held back versions must be advertised as soon as a deployment window opens, without any other change
+	return cacheResponse(w, endpoint.ID, statusResponse, !held)
 }
 
 func parseAgentPlatform(r *http.Request) (portainer.EndpointType, error) {
@@ -197,6 +207,9 @@ func (handler *Handler) buildSchedules(endpointID portainer.EndpointID, tunnel p
 			CronExpression: job.CronExpression,
 			CollectLogs:    collectLogs,
 			Version:        job.Version,
+			MaxRetries:     job.MaxRetries,
+			RetryInterval:  job.RetryInterval,
+			Timeout:        job.Timeout,
 		}
 
 		file, err := handler.FileService.GetFileContent(job.ScriptPath, "")
@@ -210,19 +223,46 @@ func (handler *Handler) buildSchedules(endpointID portainer.EndpointID, tunnel p
 	return schedules, nil
 }
 
-func (handler *Handler) buildEdgeStacks(endpointID portainer.EndpointID) ([]stackStatusResponse, *httperror.HandlerError) {
-	relation, err := handler.DataStore.EndpointRelation().EndpointRelation(endpointID)
+// buildEdgeStacks returns the versions of the edge stacks to deploy on the environment(endpoint). The new versions are
+// held back outside of the deployment windows, the second value is true when at least one of them is
+func (handler *Handler) buildEdgeStacks(endpoint *portainer.Endpoint) ([]stackStatusResponse, bool, *httperror.HandlerError) {
+	relation, err := handler.DataStore.EndpointRelation().EndpointRelation(endpoint.ID)
 	if err != nil {
-		return nil, httperror.InternalServerError("Unable to retrieve relation object from the database", err)
+		return nil, false, httperror.InternalServerError("Unable to retrieve relation object from the database", err)
 	}
 
+	now := time.Now()
+	held := false
+	advertised := map[portainer.EdgeStackID]int{}
+
 	edgeStacksStatus := []stackStatusResponse{}
 	for stackID := range relation.EdgeStacks {
-		version, ok := handler.DataStore.EdgeStack().EdgeStackVersion(stackID)
+		version, ok := handler.DataStore.EdgeStack().EdgeStackEndpointVersion(stackID, endpoint.ID)
 		if !ok {
-			return nil, httperror.InternalServerError("Unable to retrieve edge stack from the database", err)
+			return nil, false, httperror.InternalServerError("Unable to retrieve edge stack from the database", err)
+		}
+
+		// the first deployment of a stack is not held back
+		if previousVersion, ok := relation.EdgeStackVersions[stackID]; ok && previousVersion != version {
+			stack, err := handler.DataStore.EdgeStack().EdgeStack(stackID)
+			if err != nil {
+				return nil, false, httperror.InternalServerError("Unable to retrieve edge stack from the database", err)
+			}
+
+			canDeploy, err := edge.CanDeployEdgeStack(handler.DataStore, stack, endpoint, now)
+			if err != nil {
+				return nil, false, httperror.InternalServerError("Unable to evaluate the deployment windows of the edge stack", err)
+			}
+
+			// the previous version can only be kept while its files are available
+			if !canDeploy && edgestacks.HasVersionFiles(handler.FileService, stack, previousVersion) {
+				version = previousVersion
+				held = true
+			}
 		}
 
+		advertised[stackID] = version
+
 		stackStatus := stackStatusResponse{
 			ID:      stackID,
 			Version: version,
@@ -231,10 +271,76 @@ func (handler *Handler) buildEdgeStacks(endpointID portainer.EndpointID) ([]stac
 		edgeStacksStatus = append(edgeStacksStatus, stackStatus)
 	}
 
-	return edgeStacksStatus, nil
+	changed := len(relation.EdgeStackVersions) != len(advertised)
+	for stackID, version := range advertised {
+		if previousVersion, ok := relation.EdgeStackVersions[stackID]; !ok || previousVersion != version {
+			changed = true
+		}
+	}
+
+	if changed {
+		err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			relation, err := tx.EndpointRelation().EndpointRelation(endpoint.ID)
+			if err != nil {
+				return err
+			}
+
+			// the agent deploys the versions it didn't get before
+			for stackID, version := range advertised {
+				if previousVersion, ok := relation.EdgeStackVersions[stackID]; ok && previousVersion == version {
+					continue
+				}
+
+				err = tx.EdgeStackStatusEvent().Create(&portainer.EdgeStackStatusEvent{
+					EdgeStackID: stackID,
+					EndpointID:  endpoint.ID,
+					Version:     version,
+					Type:        portainer.EdgeStackStatusPending,
+					Time:        now.Unix(),
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			// the agent removes the edge stacks the environment left
+			for stackID, version := range relation.EdgeStackVersions {
+				if _, ok := advertised[stackID]; ok {
+					continue
+				}
+
+				_, err := tx.EdgeStack().EdgeStack(stackID)
+				if tx.IsErrObjectNotFound(err) {
+					continue
+				} else if err != nil {
+					return err
+				}
+
+				err = tx.EdgeStackStatusEvent().Create(&portainer.EdgeStackStatusEvent{
+					EdgeStackID: stackID,
+					EndpointID:  endpoint.ID,
+					Version:     version,
+					Type:        portainer.EdgeStackStatusRemove,
+					Time:        now.Unix(),
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			relation.EdgeStackVersions = advertised
+
+			return tx.EndpointRelation().UpdateEndpointRelation(endpoint.ID, relation)
+		})
+		if err != nil {
+			return nil, false, httperror.InternalServerError("Unable to persist the versions of the edge stacks inside the database", err)
+		}
+	}
+
+	return edgeStacksStatus, held, nil
 }
 
-func cacheResponse(w http.ResponseWriter, endpointID portainer.EndpointID, statusResponse endpointEdgeStatusInspectResponse) *httperror.HandlerError {
+func cacheResponse(w http.ResponseWriter, endpointID portainer.EndpointID, statusResponse endpointEdgeStatusInspectResponse, cacheable bool) *httperror.HandlerError {
 	rr := httptest.NewRecorder()
 
 	httpErr := response.JSON(rr, statusResponse)
@@ -246,7 +352,11 @@ func cacheResponse(w http.ResponseWriter, endpointID portainer.EndpointID, statu
 	h.Write(rr.Body.Bytes())
 	etag := strconv.FormatUint(uint64(h.Sum32()), 16)
 
-	cache.Set(endpointID, []byte(etag))
+	if cacheable {
+		cache.Set(endpointID, []byte(etag))
+	} else {
+		cache.Del(endpointID)
+	}
 
 	resp := rr.Result()
 
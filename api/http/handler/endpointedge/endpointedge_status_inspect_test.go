@@ -15,6 +15,7 @@ import (
 	"github.com/portainer/portainer/api/datastore"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/edge/cache"
 	"github.com/portainer/portainer/api/jwt"
 
 	"github.com/stretchr/testify/assert"
@@ -115,10 +116,10 @@ func setupHandler(t *testing.T) (*Handler, func(), error) {
 		security.NewRequestBouncer(store, jwtService, apiKeyService),
 		store,
 		fs,
-		chisel.NewService(store, shutdownCtx),
+		chisel.NewService(store, nil, shutdownCtx),
 	)
 
-	handler.ReverseTunnelService = chisel.NewService(store, shutdownCtx)
+	handler.ReverseTunnelService = chisel.NewService(store, nil, shutdownCtx)
 
 	return handler, teardown, nil
 }
@@ -373,6 +374,129 @@ func TestEdgeStackStatus(t *testing.T) {
 	assert.Equal(t, edgeStack.Version, data.Stacks[0].Version)
 }
 
+func TestEdgeStackStatusOutsideDeploymentWindow(t *testing.T) {
+	handler, teardown, err := setupHandler(t)
+	defer teardown()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint := portainer.Endpoint{
+		ID:              8,
+		Name:            "test-endpoint-8",
+		Type:            portainer.EdgeAgentOnDockerEnvironment,
+		URL:             "https://portainer.io:9443",
+		EdgeID:          "edge-id",
+		LastCheckInDate: time.Now().Unix(),
+	}
+
+	// the window opens in the next hours, it is closed during the test
+	closedWindow := &portainer.EdgeDeploymentWindow{Schedule: fmt.Sprintf("0 %d * * *", (time.Now().UTC().Hour()+2)%24), Duration: "1h"}
+
+	projectPath, err := handler.FileService.StoreEdgeStackFileFromBytes("18", "docker-compose.yml", []byte("version: '3.8'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = handler.FileService.StoreEdgeStackVersionFileFromBytes("18", 3, "docker-compose.yml", []byte("version: '3'"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edgeStacks := []portainer.EdgeStack{
+		{ID: 18, Name: "deployed", Version: 4, DeploymentWindow: closedWindow, ProjectPath: projectPath, EntryPoint: "docker-compose.yml", HeldVersions: []int{3}},
+		{ID: 19, Name: "new", Version: 2, DeploymentWindow: closedWindow},
+		{ID: 20, Name: "unscheduled", Version: 6},
+		// the files of the deployed version are not available on disk
+		{ID: 21, Name: "lost", Version: 3, DeploymentWindow: closedWindow, HeldVersions: []int{2}},
+		// the environment left the edge groups of the stack
+		{ID: 22, Name: "left", Version: 1},
+	}
+	for i := range edgeStacks {
+		err = handler.DataStore.EdgeStack().Create(edgeStacks[i].ID, &edgeStacks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	endpointRelation := portainer.EndpointRelation{
+		EndpointID:        endpoint.ID,
+		EdgeStacks:        map[portainer.EdgeStackID]bool{18: true, 19: true, 20: true, 21: true},
+		EdgeStackVersions: map[portainer.EdgeStackID]int{18: 3, 20: 5, 21: 2, 22: 1},
+	}
+
+	err = createEndpoint(handler, endpoint, endpointRelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/endpoints/%d/edge/status", endpoint.ID), nil)
+	if err != nil {
+		t.Fatal("request error:", err)
+	}
+	req.Header.Set(portainer.PortainerAgentEdgeIDHeader, "edge-id")
+	req.Header.Set(portainer.HTTPResponseAgentPlatform, "1")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf(fmt.Sprintf("expected a %d response, found: %d", http.StatusOK, rec.Code))
+	}
+
+	var data endpointEdgeStatusInspectResponse
+	err = json.NewDecoder(rec.Body).Decode(&data)
+	if err != nil {
+		t.Fatal("error decoding response:", err)
+	}
+
+	versions := map[portainer.EdgeStackID]int{}
+	for _, stack := range data.Stacks {
+		versions[stack.ID] = stack.Version
+	}
+
+	assert.Equal(t, map[portainer.EdgeStackID]int{18: 3, 19: 2, 20: 6, 21: 3}, versions, "only the new version of a deployed stack should be held back")
+
+	relation, err := handler.DataStore.EndpointRelation().EndpointRelation(endpoint.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, versions, relation.EdgeStackVersions)
+
+	events, err := handler.DataStore.EdgeStackStatusEvent().EdgeStackStatusEvents(22, endpoint.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, portainer.EdgeStackStatusRemove, events[0].Type, "the removal of the edge stack should be recorded")
+	}
+
+	_, cached := cache.Get(endpoint.ID)
+	assert.False(t, cached, "the response should not be cached while a version is held back")
+
+	req, err = http.NewRequest(http.MethodGet, fmt.Sprintf("/api/endpoints/%d/edge/stacks/18", endpoint.ID), nil)
+	if err != nil {
+		t.Fatal("request error:", err)
+	}
+	req.Header.Set(portainer.PortainerAgentEdgeIDHeader, "edge-id")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf(fmt.Sprintf("expected a %d response, found: %d", http.StatusOK, rec.Code))
+	}
+
+	var config configResponse
+	err = json.NewDecoder(rec.Body).Decode(&config)
+	if err != nil {
+		t.Fatal("error decoding response:", err)
+	}
+
+	assert.Equal(t, "version: '3'", config.StackFileContent, "the files of the held version should be served")
+}
+
 func TestEdgeJobsResponse(t *testing.T) {
 	handler, teardown, err := setupHandler(t)
 	defer teardown()
@@ -42,5 +42,8 @@ func NewHandler(bouncer *security.RequestBouncer, dataStore dataservices.DataSto
 	endpointRouter.PathPrefix("/edge/jobs/{jobID}/logs").Handler(
 		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointEdgeJobsLogs))).Methods(http.MethodPost)
 
+	endpointRouter.PathPrefix("/edge/jobs/{jobID}/runs").Handler(
+		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointEdgeJobRunCreate))).Methods(http.MethodPost)
+
 	return h
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 )
 
 // @id EndpointGroupAddEndpoint
@@ -50,6 +51,11 @@ func (handler *Handler) endpointGroupAddEndpoint(w http.ResponseWriter, r *http.
 
 	endpoint.GroupID = endpointGroup.ID
 
+	err = edgestacks.ValidateEndpointTemplates(handler.DataStore, handler.FileService, endpoint, endpointGroup)
+	if err != nil {
+		return httperror.BadRequest("The edge stack templates can't be rendered with the values of the environment", err)
+	}
+
 	err = handler.DataStore.Endpoint().UpdateEndpoint(endpoint.ID, endpoint)
 	if err != nil {
 		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
@@ -9,6 +9,7 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/internal/tag"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 )
 
 type endpointGroupUpdatePayload struct {
@@ -20,10 +21,12 @@ type endpointGroupUpdatePayload struct {
 	TagIDs             []portainer.TagID `example:"3,4"`
 	UserAccessPolicies portainer.UserAccessPolicies
 	TeamAccessPolicies portainer.TeamAccessPolicies
+	// Approval policy of the stack deployments of the non-administrators in the environments of the group
+	DeploymentApproval *portainer.DeploymentApprovalPolicy
 }
 
 func (payload *endpointGroupUpdatePayload) Validate(r *http.Request) error {
-	return nil
+	return stackutils.ValidateDeploymentApprovalPolicy(payload.DeploymentApproval)
 }
 
 // @id EndpointGroupUpdate
@@ -78,6 +81,11 @@ func (handler *Handler) endpointGroupUpdate(w http.ResponseWriter, r *http.Reque
 		tagsChanged = len(union) > len(intersection)
 
 		if tagsChanged {
+			httpErr := handler.validateGroupTemplates(endpointGroup, payload.TagIDs)
+			if httpErr != nil {
+				return httpErr
+			}
+
 			removeTags := tag.Difference(endpointGroupTagSet, payloadTagSet)
 
 			for tagID := range removeTags {
@@ -107,6 +115,19 @@ func (handler *Handler) endpointGroupUpdate(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	if payload.DeploymentApproval != nil {
+		if payload.DeploymentApproval.Enabled {
+			_, err = handler.DataStore.Role().Role(payload.DeploymentApproval.ApproverRoleID)
+			if handler.DataStore.IsErrObjectNotFound(err) {
+				return httperror.BadRequest("Unable to find the approver role inside the database", err)
+			} else if err != nil {
+				return httperror.InternalServerError("Unable to find the approver role inside the database", err)
+			}
+		}
+
+		endpointGroup.DeploymentApproval = payload.DeploymentApproval
+	}
+
 	updateAuthorizations := false
 	if payload.UserAccessPolicies != nil && !reflect.DeepEqual(payload.UserAccessPolicies, endpointGroup.UserAccessPolicies) {
 		endpointGroup.UserAccessPolicies = payload.UserAccessPolicies
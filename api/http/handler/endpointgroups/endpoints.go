@@ -1,8 +1,10 @@
 package endpointgroups
 
 import (
+	httperror "github.com/portainer/libhttp/error"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 )
 
 func (handler *Handler) updateEndpointRelations(endpoint *portainer.Endpoint, endpointGroup *portainer.EndpointGroup) error {
@@ -43,3 +45,28 @@ func (handler *Handler) updateEndpointRelations(endpoint *portainer.Endpoint, en
 
 	return handler.DataStore.EndpointRelation().UpdateEndpointRelation(endpoint.ID, endpointRelation)
 }
+
+// validateGroupTemplates verifies that the templated edge stacks of the environments(endpoints) of a group can still be
+// rendered once the group has the new tags
+func (handler *Handler) validateGroupTemplates(endpointGroup *portainer.EndpointGroup, tagIDs []portainer.TagID) *httperror.HandlerError {
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve environments from the database", err)
+	}
+
+	updatedGroup := *endpointGroup
+	updatedGroup.TagIDs = tagIDs
+
+	for i := range endpoints {
+		if endpoints[i].GroupID != endpointGroup.ID {
+			continue
+		}
+
+		err = edgestacks.ValidateEndpointTemplates(handler.DataStore, handler.FileService, &endpoints[i], &updatedGroup)
+		if err != nil {
+			return httperror.BadRequest("The edge stack templates can't be rendered with the values of the environments of the group", err)
+		}
+	}
+
+	return nil
+}
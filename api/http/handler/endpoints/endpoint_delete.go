@@ -2,6 +2,7 @@ package endpoints
 
 import (
 	"net/http"
+	"os"
 	"strconv"
 
 	httperror "github.com/portainer/libhttp/error"
@@ -9,7 +10,10 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/internal/edge"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+
+	"github.com/rs/zerolog/log"
 )
 
 // @id EndpointDelete
@@ -55,6 +59,11 @@ func (handler *Handler) endpointDelete(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to remove the snapshot from the database", err)
 	}
 
+	err = handler.DataStore.SnapshotHistory().DeleteSnapshotHistory(portainer.EndpointID(endpointID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the snapshot history from the database", err)
+	}
+
 	err = handler.DataStore.Endpoint().DeleteEndpoint(portainer.EndpointID(endpointID))
 	if err != nil {
 		return httperror.InternalServerError("Unable to remove environment from the database", err)
@@ -100,6 +109,12 @@ func (handler *Handler) endpointDelete(w http.ResponseWriter, r *http.Request) *
 
 	for idx := range edgeStacks {
 		edgeStack := &edgeStacks[idx]
+
+		err = handler.DataStore.EdgeStackStatusEvent().DeleteEndpointStatusEvents(edgeStack.ID, endpoint.ID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to delete the status events of the environment", err)
+		}
+
 		if _, ok := edgeStack.Status[endpoint.ID]; ok {
 			delete(edgeStack.Status, endpoint.ID)
 			err = handler.DataStore.EdgeStack().UpdateEdgeStack(edgeStack.ID, edgeStack)
@@ -136,6 +151,19 @@ func (handler *Handler) endpointDelete(w http.ResponseWriter, r *http.Request) *
 
 	for idx := range edgeJobs {
 		edgeJob := &edgeJobs[idx]
+
+		deletedRuns, err := handler.DataStore.EdgeJobRun().DeleteEndpointRuns(edgeJob.ID, endpoint.ID, 0)
+		if err != nil {
+			return httperror.InternalServerError("Unable to remove the runs of the edge job from the database", err)
+		}
+
+		for _, run := range deletedRuns {
+			err = handler.FileService.ClearEdgeJobTaskLogs(strconv.Itoa(int(edgeJob.ID)), edge.EdgeJobRunOutputTaskID(run.ID))
+			if err != nil && !os.IsNotExist(err) {
+				log.Warn().Err(err).Int("edge_job_id", int(edgeJob.ID)).Int("run_id", int(run.ID)).Msg("unable to remove the output of an edge job run")
+			}
+		}
+
 		if _, ok := edgeJob.Endpoints[endpoint.ID]; ok {
 			err = handler.DataStore.EdgeJob().UpdateEdgeJobFunc(edgeJob.ID, func(j *portainer.EdgeJob) {
 				delete(j.Endpoints, endpoint.ID)
@@ -107,3 +107,66 @@ func TestEndpointDeleteEdgeGroupsConcurrently(t *testing.T) {
 		t.Fatal("the edge group is not consistent")
 	}
 }
+
+// setupAPIKeyScopeHandler creates two environments and returns the API key of an administrator which is limited
+// to the first one
+func setupAPIKeyScopeHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	t.Cleanup(teardown)
+
+	user := &portainer.User{ID: 2, Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(user)
+	if err != nil {
+		t.Fatal("could not create admin user:", err)
+	}
+
+	jwtService, err := jwt.NewService("1h", store)
+	if err != nil {
+		t.Fatal("could not initialize the JWT service:", err)
+	}
+
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	rawAPIKey, _, err := apiKeyService.GenerateScopedApiKey(*user, "test", portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{1}})
+	if err != nil {
+		t.Fatal("could not generate API key:", err)
+	}
+
+	handler := NewHandler(security.NewRequestBouncer(store, jwtService, apiKeyService), demo.NewService())
+	handler.DataStore = store
+	handler.ProxyManager = proxy.NewManager(nil, nil, nil, nil, nil, nil, nil)
+
+	for _, endpointID := range []portainer.EndpointID{1, 2} {
+		err = store.Endpoint().Create(&portainer.Endpoint{
+			ID:      endpointID,
+			Name:    "env-" + strconv.Itoa(int(endpointID)),
+			Type:    portainer.DockerEnvironment,
+			GroupID: 1,
+		})
+		if err != nil {
+			t.Fatal("could not create endpoint:", err)
+		}
+	}
+
+	return handler, rawAPIKey
+}
+
+func TestEndpointDeleteOutsideAPIKeyScope(t *testing.T) {
+	handler, rawAPIKey := setupAPIKeyScopeHandler(t)
+
+	req := httptest.NewRequest(http.MethodDelete, "/endpoints/2", nil)
+	req.Header.Add("X-Api-Key", rawAPIKey)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a %d response, found: %d", http.StatusForbidden, rec.Code)
+	}
+
+	_, err := handler.DataStore.Endpoint().Endpoint(2)
+	if err != nil {
+		t.Fatal("the environment outside of the scope of the API key should not be deleted:", err)
+	}
+}
@@ -0,0 +1,128 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/snapshot"
+)
+
+const (
+	snapshotHistoryResolutionRaw    = "raw"
+	snapshotHistoryResolutionHourly = "hourly"
+	snapshotHistoryResolutionDaily  = "daily"
+)
+
+type snapshotHistoryResponse struct {
+	EndpointID portainer.EndpointID `json:"EndpointId" example:"1"`
+	From       int64                `json:"From" example:"1663770738"`
+	To         int64                `json:"To" example:"1663857138"`
+	Resolution string               `json:"Resolution" example:"raw"`
+	Points     []portainer.SnapshotHistoryPoint
+}
+
+// @id EndpointSnapshotHistory
+// @summary Retrieve the snapshot history of an environment(endpoint)
+// @description Retrieve the container, image, volume and health counts along with the CPU and memory totals of the snapshots of an environment(endpoint).
+// @description The snapshots of the last 24 hours are kept as is, the older ones are averaged by hour and kept for 30 days.
+// @description **Access policy**: restricted
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param from query int false "Unix timestamp of the start of the period, defaults to 24 hours ago"
+// @param to query int false "Unix timestamp of the end of the period, defaults to now"
+// @param resolution query string false "Resolution of the points, defaults to raw for periods up to 24 hours and hourly otherwise" Enums(raw, hourly, daily)
+// @success 200 {object} snapshotHistoryResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/snapshots/history [get]
+func (handler *Handler) endpointSnapshotHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	now := time.Now()
+
+	from, err := retrieveTimeQueryParameter(r, "from", now.Add(-snapshot.RawHistoryRetention))
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: from", err)
+	}
+
+	to, err := retrieveTimeQueryParameter(r, "to", now)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: to", err)
+	}
+
+	if to.Before(from) {
+		return httperror.BadRequest("Invalid period", errors.New("the end of the period must be after its start"))
+	}
+
+	resolutionName, _ := request.RetrieveQueryParameter(r, "resolution", true)
+	if resolutionName == "" {
+		resolutionName = snapshotHistoryResolutionRaw
+		if to.Sub(from) > snapshot.RawHistoryRetention {
+			resolutionName = snapshotHistoryResolutionHourly
+		}
+	}
+
+	var resolution time.Duration
+	switch resolutionName {
+	case snapshotHistoryResolutionRaw:
+	case snapshotHistoryResolutionHourly:
+		resolution = time.Hour
+	case snapshotHistoryResolutionDaily:
+		resolution = 24 * time.Hour
+	default:
+		return httperror.BadRequest("Invalid query parameter: resolution", errors.New("the resolution must be one of raw, hourly or daily"))
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	history, err := handler.DataStore.SnapshotHistory().SnapshotHistory(endpoint.ID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		history = &portainer.SnapshotHistory{EndpointID: endpoint.ID}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the snapshot history from the database", err)
+	}
+
+	return response.JSON(w, snapshotHistoryResponse{
+		EndpointID: endpoint.ID,
+		From:       from.Unix(),
+		To:         to.Unix(),
+		Resolution: resolutionName,
+		Points:     snapshot.HistoryPoints(history, from, to, resolution),
+	})
+}
+
+func retrieveTimeQueryParameter(r *http.Request, name string, defaultValue time.Time) (time.Time, error) {
+	value, err := request.RetrieveNumericQueryParameter(r, name, true)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if value == 0 {
+		return defaultValue, nil
+	}
+
+	return time.Unix(int64(value), 0), nil
+}
@@ -11,6 +11,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/client"
 	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 	"github.com/portainer/portainer/api/internal/tag"
 )
 
@@ -48,10 +49,12 @@ type endpointUpdatePayload struct {
 	EdgeCheckinInterval *int `example:"5"`
 	// Associated Kubernetes data
 	Kubernetes *portainer.KubernetesData
+	// Custom values available to the templates of the edge stacks deployed on this environment(endpoint)
+	EdgeVariables map[string]string `example:"region:eu-west"`
 }
 
 func (payload *endpointUpdatePayload) Validate(r *http.Request) error {
-	return nil
+	return edgestacks.ValidateEdgeVariables(payload.EdgeVariables)
 }
 
 // @id EndpointUpdate
@@ -120,6 +123,12 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		endpoint.EdgeCheckinInterval = *payload.EdgeCheckinInterval
 	}
 
+	variablesChanged := false
+	if payload.EdgeVariables != nil {
+		variablesChanged = !reflect.DeepEqual(payload.EdgeVariables, endpoint.EdgeVariables)
+		endpoint.EdgeVariables = payload.EdgeVariables
+	}
+
 	groupIDChanged := false
 	if payload.GroupID != nil {
 		groupID := portainer.EndpointGroupID(*payload.GroupID)
@@ -127,6 +136,20 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		endpoint.GroupID = groupID
 	}
 
+	// the templated edge stacks of the environment, including the ones it joins, must still be rendered
+	templateTagsChanged := payload.TagIDs != nil && !reflect.DeepEqual(tag.Set(payload.TagIDs), tag.Set(endpoint.TagIDs))
+	if variablesChanged || groupIDChanged || templateTagsChanged {
+		updatedEndpoint := *endpoint
+		if templateTagsChanged {
+			updatedEndpoint.TagIDs = payload.TagIDs
+		}
+
+		err = edgestacks.ValidateEndpointTemplates(handler.DataStore, handler.FileService, &updatedEndpoint, nil)
+		if err != nil {
+			return httperror.BadRequest("The edge stack templates can't be rendered with the values of the environment", err)
+		}
+	}
+
 	tagsChanged := false
 	if payload.TagIDs != nil {
 		payloadTagSet := tag.Set(payload.TagIDs)
@@ -0,0 +1,31 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEndpointUpdateOutsideAPIKeyScope(t *testing.T) {
+	handler, rawAPIKey := setupAPIKeyScopeHandler(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/endpoints/2", strings.NewReader(`{"Name":"renamed"}`))
+	req.Header.Add("X-Api-Key", rawAPIKey)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a %d response, found: %d", http.StatusForbidden, rec.Code)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if endpoint.Name != "env-2" {
+		t.Fatal("the environment outside of the scope of the API key should not be updated")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 )
 
@@ -21,6 +22,8 @@ type repositoryFilePreviewPayload struct {
 	Reference  string `json:"reference" example:"refs/heads/master"`
 	Username   string `json:"username" example:"myGitUsername"`
 	Password   string `json:"password" example:"myGitPassword"`
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
 	// Path to file whose content will be read
 	TargetFile string `json:"targetFile" example:"docker-compose.yml"`
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
@@ -28,7 +31,7 @@ type repositoryFilePreviewPayload struct {
 }
 
 func (payload *repositoryFilePreviewPayload) Validate(r *http.Request) error {
-	if govalidator.IsNull(payload.Repository) || !govalidator.IsURL(payload.Repository) {
+	if govalidator.IsNull(payload.Repository) || !git.IsValidRepositoryURL(payload.Repository) {
 		return errors.New("invalid repository URL. Must correspond to a valid URL format")
 	}
 
@@ -40,7 +43,14 @@ func (payload *repositoryFilePreviewPayload) Validate(r *http.Request) error {
 		return errors.New("invalid target filename")
 	}
 
-	return nil
+	return git.ValidateRepoAuthentication(payload.authentication())
+}
+
+// authentication returns the credentials of the payload, the basic authentication is used when a password is set
+func (payload *repositoryFilePreviewPayload) authentication() *gittypes.GitAuthentication {
+	enabled := payload.Password != "" || payload.RepositoryAuthorizationType != gittypes.GitCredentialAuthTypeBasic
+
+	return payload.CredentialsPayload.Authentication(enabled, payload.Username, payload.Password)
 }
 
 // @id GitOperationRepoFilePreview
@@ -68,7 +78,7 @@ func (handler *Handler) gitOperationRepoFilePreview(w http.ResponseWriter, r *ht
 		return httperror.InternalServerError("Unable to create temporary folder", err)
 	}
 
-	err = handler.gitService.CloneRepository(projectPath, payload.Repository, payload.Reference, payload.Username, payload.Password, payload.TLSSkipVerify)
+	err = handler.gitService.CloneRepository(projectPath, payload.Repository, payload.Reference, payload.authentication(), payload.TLSSkipVerify)
 	if err != nil {
 		if errors.Is(err, gittypes.ErrAuthenticationFailure) {
 			return httperror.BadRequest("Invalid git credential", err)
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/portainer/portainer/api/http/handler/admissionpolicies"
+	"github.com/portainer/portainer/api/http/handler/auditlogs"
 	"github.com/portainer/portainer/api/http/handler/auth"
 	"github.com/portainer/portainer/api/http/handler/backup"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
@@ -23,7 +25,9 @@ import (
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
 	"github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
+	"github.com/portainer/portainer/api/http/handler/metrics"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/notificationchannels"
 	"github.com/portainer/portainer/api/http/handler/registries"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
@@ -44,43 +48,47 @@ import (
 
 // Handler is a collection of all the service handlers.
 type Handler struct {
-	AuthHandler            *auth.Handler
-	BackupHandler          *backup.Handler
-	CustomTemplatesHandler *customtemplates.Handler
-	DockerHandler          *docker.Handler
-	EdgeGroupsHandler      *edgegroups.Handler
-	EdgeJobsHandler        *edgejobs.Handler
-	EdgeStacksHandler      *edgestacks.Handler
-	EdgeTemplatesHandler   *edgetemplates.Handler
-	EndpointEdgeHandler    *endpointedge.Handler
-	EndpointGroupHandler   *endpointgroups.Handler
-	EndpointHandler        *endpoints.Handler
-	EndpointHelmHandler    *helm.Handler
-	EndpointProxyHandler   *endpointproxy.Handler
-	GitOperationHandler    *gitops.Handler
-	HelmTemplatesHandler   *helm.Handler
-	KubernetesHandler      *kubernetes.Handler
-	FileHandler            *file.Handler
-	LDAPHandler            *ldap.Handler
-	MOTDHandler            *motd.Handler
-	RegistryHandler        *registries.Handler
-	ResourceControlHandler *resourcecontrols.Handler
-	RoleHandler            *roles.Handler
-	SettingsHandler        *settings.Handler
-	SSLHandler             *ssl.Handler
-	OpenAMTHandler         *openamt.Handler
-	FDOHandler             *fdo.Handler
-	StackHandler           *stacks.Handler
-	StorybookHandler       *storybook.Handler
-	SystemHandler          *system.Handler
-	TagHandler             *tags.Handler
-	TeamMembershipHandler  *teammemberships.Handler
-	TeamHandler            *teams.Handler
-	TemplatesHandler       *templates.Handler
-	UploadHandler          *upload.Handler
-	UserHandler            *users.Handler
-	WebSocketHandler       *websocket.Handler
-	WebhookHandler         *webhooks.Handler
+	AdmissionPolicyHandler     *admissionpolicies.Handler
+	AuditLogHandler            *auditlogs.Handler
+	AuthHandler                *auth.Handler
+	BackupHandler              *backup.Handler
+	CustomTemplatesHandler     *customtemplates.Handler
+	DockerHandler              *docker.Handler
+	EdgeGroupsHandler          *edgegroups.Handler
+	EdgeJobsHandler            *edgejobs.Handler
+	EdgeStacksHandler          *edgestacks.Handler
+	EdgeTemplatesHandler       *edgetemplates.Handler
+	EndpointEdgeHandler        *endpointedge.Handler
+	EndpointGroupHandler       *endpointgroups.Handler
+	EndpointHandler            *endpoints.Handler
+	EndpointHelmHandler        *helm.Handler
+	EndpointProxyHandler       *endpointproxy.Handler
+	GitOperationHandler        *gitops.Handler
+	HelmTemplatesHandler       *helm.Handler
+	KubernetesHandler          *kubernetes.Handler
+	FileHandler                *file.Handler
+	LDAPHandler                *ldap.Handler
+	MetricsHandler             *metrics.Handler
+	MOTDHandler                *motd.Handler
+	NotificationChannelHandler *notificationchannels.Handler
+	RegistryHandler            *registries.Handler
+	ResourceControlHandler     *resourcecontrols.Handler
+	RoleHandler                *roles.Handler
+	SettingsHandler            *settings.Handler
+	SSLHandler                 *ssl.Handler
+	OpenAMTHandler             *openamt.Handler
+	FDOHandler                 *fdo.Handler
+	StackHandler               *stacks.Handler
+	StorybookHandler           *storybook.Handler
+	SystemHandler              *system.Handler
+	TagHandler                 *tags.Handler
+	TeamMembershipHandler      *teammemberships.Handler
+	TeamHandler                *teams.Handler
+	TemplatesHandler           *templates.Handler
+	UploadHandler              *upload.Handler
+	UserHandler                *users.Handler
+	WebSocketHandler           *websocket.Handler
+	WebhookHandler             *webhooks.Handler
 }
 
 // @title PortainerCE API
@@ -105,6 +113,10 @@ type Handler struct {
 // @in header
 // @name Authorization
 
+// @tag.name admission_policies
+// @tag.description Manage the admission policies of the containers and stacks
+// @tag.name audit
+// @tag.description Query the audit logs of the Portainer API
 // @tag.name auth
 // @tag.description Authenticate against Portainer HTTP API
 // @tag.name custom_templates
@@ -127,8 +139,12 @@ type Handler struct {
 // @tag.description Operate git repository
 // @tag.name kubernetes
 // @tag.description Manage Kubernetes cluster
+// @tag.name metrics
+// @tag.description Expose the Prometheus metrics of the Portainer server
 // @tag.name motd
 // @tag.description Fetch the message of the day
+// @tag.name notification_channels
+// @tag.description Manage outbound event notifications
 // @tag.name registries
 // @tag.description Manage Docker registries
 // @tag.name resource_controls
@@ -167,6 +183,10 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case strings.HasPrefix(r.URL.Path, "/api/endpoints") && strings.Contains(r.URL.Path, "/edge/"):
 		h.EndpointEdgeHandler.ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/admission_policies"):
+		http.StripPrefix("/api", h.AdmissionPolicyHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/audit"):
+		http.StripPrefix("/api", h.AuditLogHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/auth"):
 		http.StripPrefix("/api", h.AuthHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/backup"):
@@ -211,8 +231,12 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/api", h.GitOperationHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/ldap"):
 		http.StripPrefix("/api", h.LDAPHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/metrics"):
+		http.StripPrefix("/api", h.MetricsHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/motd"):
 		http.StripPrefix("/api", h.MOTDHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/notification_channels"):
+		http.StripPrefix("/api", h.NotificationChannelHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/registries"):
 		http.StripPrefix("/api", h.RegistryHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/resource_controls"):
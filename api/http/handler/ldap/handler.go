@@ -14,9 +14,10 @@ import (
 // Handler is the HTTP handler used to handle LDAP search Operations
 type Handler struct {
 	*mux.Router
-	DataStore   dataservices.DataStore
-	FileService portainer.FileService
-	LDAPService portainer.LDAPService
+	DataStore       dataservices.DataStore
+	FileService     portainer.FileService
+	LDAPService     portainer.LDAPService
+	LDAPSyncService portainer.LDAPSyncService
 }
 
 // NewHandler returns a new Handler
@@ -27,6 +28,10 @@ func NewHandler(bouncer *security.RequestBouncer) *Handler {
 
 	h.Handle("/ldap/check",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.ldapCheck))).Methods(http.MethodPost)
+	h.Handle("/ldap/sync",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.ldapSync))).Methods(http.MethodPost)
+	h.Handle("/ldap/sync/preview",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.ldapSyncPreview))).Methods(http.MethodGet)
 
 	return h
 }
@@ -0,0 +1,48 @@
+package ldap
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+)
+
+// @id LDAPSync
+// @summary Synchronise the LDAP groups
+// @description Synchronise the teams and their memberships with the LDAP groups and disable the users missing from the directory when enabled.
+// @description **Access policy**: administrator
+// @tags ldap
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} portainer.LDAPSyncReport "Success"
+// @failure 500 "Server error"
+// @router /ldap/sync [post]
+func (handler *Handler) ldapSync(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	report, err := handler.LDAPSyncService.Sync(false)
+	if err != nil {
+		return httperror.InternalServerError("Unable to synchronise the LDAP groups", err)
+	}
+
+	return response.JSON(w, report)
+}
+
+// @id LDAPSyncPreview
+// @summary Preview the synchronisation of the LDAP groups
+// @description List the changes the synchronisation of the LDAP groups would apply, without applying them.
+// @description **Access policy**: administrator
+// @tags ldap
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} portainer.LDAPSyncReport "Success"
+// @failure 500 "Server error"
+// @router /ldap/sync/preview [get]
+func (handler *Handler) ldapSyncPreview(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	report, err := handler.LDAPSyncService.Sync(true)
+	if err != nil {
+		return httperror.InternalServerError("Unable to preview the synchronisation of the LDAP groups", err)
+	}
+
+	return response.JSON(w, report)
+}
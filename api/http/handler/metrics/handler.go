@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler is the HTTP handler used to expose the Prometheus metrics.
+type Handler struct {
+	*mux.Router
+	metricsHandler http.Handler
+	adminHandler   http.Handler
+	token          string
+}
+
+// NewHandler creates a handler to expose the Prometheus metrics.
+// The metrics can be scraped by an administrator or by providing the token as a bearer token
+// when one is configured.
+func NewHandler(bouncer *security.RequestBouncer, token string) *Handler {
+	metricsHandler := promhttp.Handler()
+
+	h := &Handler{
+		Router:         mux.NewRouter(),
+		metricsHandler: metricsHandler,
+		adminHandler:   bouncer.AdminAccess(metricsHandler),
+		token:          token,
+	}
+	h.Handle("/metrics", http.HandlerFunc(h.metrics)).Methods(http.MethodGet)
+
+	return h
+}
+
+// @id Metrics
+// @summary Fetch the Prometheus metrics
+// @description Fetch the internal metrics of the Portainer server in the Prometheus text format.
+// @description **Access policy**: administrator, or bearer token configured with --metrics-token
+// @tags metrics
+// @security ApiKeyAuth
+// @security jwt
+// @produce plain
+// @success 200 "Success"
+// @failure 401 "Unauthorized"
+// @failure 403 "Permission denied"
+// @router /metrics [get]
+func (handler *Handler) metrics(w http.ResponseWriter, r *http.Request) {
+	if handler.isTokenValid(r) {
+		handler.metricsHandler.ServeHTTP(w, r)
+		return
+	}
+
+	handler.adminHandler.ServeHTTP(w, r)
+}
+
+func (handler *Handler) isTokenValid(r *http.Request) bool {
+	if handler.token == "" {
+		return false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(handler.token)) == 1
+}
@@ -0,0 +1,64 @@
+package notificationchannels
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle notification channel operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage notification channel operations.
+func NewHandler(bouncer *security.RequestBouncer) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+	h.Handle("/notification_channels",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.notificationChannelCreate))).Methods(http.MethodPost)
+	h.Handle("/notification_channels",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.notificationChannelList))).Methods(http.MethodGet)
+	h.Handle("/notification_channels/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.notificationChannelInspect))).Methods(http.MethodGet)
+	h.Handle("/notification_channels/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.notificationChannelUpdate))).Methods(http.MethodPut)
+	h.Handle("/notification_channels/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.notificationChannelDelete))).Methods(http.MethodDelete)
+	h.Handle("/notification_channels/{id}/deliveries",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.notificationDeliveryList))).Methods(http.MethodGet)
+
+	return h
+}
+
+// hideFields removes the secret from a notification channel before it is sent to the client
+func hideFields(channel *portainer.NotificationChannel) {
+	channel.Secret = ""
+}
+
+func validateEvents(events []portainer.NotificationEventType) bool {
+	for _, event := range events {
+		switch event {
+		case portainer.NotificationEventStackDeploySuccess,
+			portainer.NotificationEventStackDeployFailure,
+			portainer.NotificationEventEdgeStackStatus,
+			portainer.NotificationEventEnvironmentUp,
+			portainer.NotificationEventEnvironmentDown,
+			portainer.NotificationEventTunnelConnected,
+			portainer.NotificationEventTunnelDisconnected,
+			portainer.NotificationEventBackupSuccess,
+			portainer.NotificationEventBackupFailure:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
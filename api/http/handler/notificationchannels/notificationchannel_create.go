@@ -0,0 +1,84 @@
+package notificationchannels
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type notificationChannelCreatePayload struct {
+	// Name of the notification channel
+	Name string `validate:"required" example:"ops-alerts"`
+	// URL receiving the event payloads
+	URL string `validate:"required" example:"https://hooks.example.com/portainer"`
+	// Secret used to sign the event payloads
+	Secret string `example:"s3cr3t"`
+	// Events the channel is subscribed to, all the events are sent when empty
+	Events []portainer.NotificationEventType
+	// Whether the channel is enabled
+	Enabled bool `example:"true"`
+}
+
+func (payload *notificationChannelCreatePayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Name) {
+		return errors.New("invalid notification channel name")
+	}
+
+	if !govalidator.IsURL(payload.URL) {
+		return errors.New("invalid notification channel URL")
+	}
+
+	if !validateEvents(payload.Events) {
+		return errors.New("invalid notification channel events")
+	}
+
+	return nil
+}
+
+// @id NotificationChannelCreate
+// @summary Create a notification channel
+// @description Create a notification channel receiving the lifecycle events as signed HTTP callbacks.
+// @description **Access policy**: administrator
+// @tags notification_channels
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body notificationChannelCreatePayload true "Notification channel details"
+// @success 200 {object} portainer.NotificationChannel "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /notification_channels [post]
+func (handler *Handler) notificationChannelCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload notificationChannelCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	channel := &portainer.NotificationChannel{
+		Name:    payload.Name,
+		URL:     payload.URL,
+		Secret:  payload.Secret,
+		Events:  payload.Events,
+		Enabled: payload.Enabled,
+	}
+
+	if channel.Events == nil {
+		channel.Events = []portainer.NotificationEventType{}
+	}
+
+	err = handler.DataStore.NotificationChannel().Create(channel)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the notification channel inside the database", err)
+	}
+
+	hideFields(channel)
+	return response.JSON(w, channel)
+}
@@ -0,0 +1,57 @@
+package notificationchannels
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id NotificationChannelDelete
+// @summary Remove a notification channel
+// @description Remove a notification channel and its delivery history.
+// @description **Access policy**: administrator
+// @tags notification_channels
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Notification channel identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Notification channel not found"
+// @failure 500 "Server error"
+// @router /notification_channels/{id} [delete]
+func (handler *Handler) notificationChannelDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid notification channel identifier route variable", err)
+	}
+	channelID := portainer.NotificationChannelID(id)
+
+	_, err = handler.DataStore.NotificationChannel().NotificationChannel(channelID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a notification channel with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a notification channel with the specified identifier inside the database", err)
+	}
+
+	err = handler.DataStore.NotificationChannel().DeleteNotificationChannel(channelID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the notification channel from the database", err)
+	}
+
+	deliveries, err := handler.DataStore.NotificationDelivery().NotificationDeliveriesByChannelID(channelID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notification deliveries from the database", err)
+	}
+
+	for _, delivery := range deliveries {
+		err = handler.DataStore.NotificationDelivery().DeleteNotificationDelivery(delivery.ID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to remove the notification delivery from the database", err)
+		}
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,105 @@
+package notificationchannels
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type notificationChannelUpdatePayload struct {
+	// Name of the notification channel
+	Name *string `example:"ops-alerts"`
+	// URL receiving the event payloads
+	URL *string `example:"https://hooks.example.com/portainer"`
+	// Secret used to sign the event payloads
+	Secret *string `example:"s3cr3t"`
+	// Events the channel is subscribed to, all the events are sent when empty
+	Events []portainer.NotificationEventType
+	// Whether the channel is enabled
+	Enabled *bool `example:"true"`
+}
+
+func (payload *notificationChannelUpdatePayload) Validate(r *http.Request) error {
+	if payload.Name != nil && govalidator.IsNull(*payload.Name) {
+		return errors.New("invalid notification channel name")
+	}
+
+	if payload.URL != nil && !govalidator.IsURL(*payload.URL) {
+		return errors.New("invalid notification channel URL")
+	}
+
+	if !validateEvents(payload.Events) {
+		return errors.New("invalid notification channel events")
+	}
+
+	return nil
+}
+
+// @id NotificationChannelUpdate
+// @summary Update a notification channel
+// @description **Access policy**: administrator
+// @tags notification_channels
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Notification channel identifier"
+// @param body body notificationChannelUpdatePayload true "Notification channel details"
+// @success 200 {object} portainer.NotificationChannel "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Notification channel not found"
+// @failure 500 "Server error"
+// @router /notification_channels/{id} [put]
+func (handler *Handler) notificationChannelUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid notification channel identifier route variable", err)
+	}
+
+	var payload notificationChannelUpdatePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	channel, err := handler.DataStore.NotificationChannel().NotificationChannel(portainer.NotificationChannelID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a notification channel with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a notification channel with the specified identifier inside the database", err)
+	}
+
+	if payload.Name != nil {
+		channel.Name = *payload.Name
+	}
+
+	if payload.URL != nil {
+		channel.URL = *payload.URL
+	}
+
+	if payload.Secret != nil {
+		channel.Secret = *payload.Secret
+	}
+
+	if payload.Events != nil {
+		channel.Events = payload.Events
+	}
+
+	if payload.Enabled != nil {
+		channel.Enabled = *payload.Enabled
+	}
+
+	err = handler.DataStore.NotificationChannel().UpdateNotificationChannel(channel.ID, channel)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the notification channel changes inside the database", err)
+	}
+
+	hideFields(channel)
+	return response.JSON(w, channel)
+}
@@ -0,0 +1,58 @@
+package notificationchannels
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id NotificationDeliveryList
+// @summary List the deliveries of a notification channel
+// @description List the delivery history of a notification channel, most recent first.
+// @description **Access policy**: administrator
+// @tags notification_channels
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Notification channel identifier"
+// @param success query bool false "Only return the successful or the failed deliveries"
+// @success 200 {array} portainer.NotificationDelivery "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Notification channel not found"
+// @failure 500 "Server error"
+// @router /notification_channels/{id}/deliveries [get]
+func (handler *Handler) notificationDeliveryList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid notification channel identifier route variable", err)
+	}
+	channelID := portainer.NotificationChannelID(id)
+
+	_, err = handler.DataStore.NotificationChannel().NotificationChannel(channelID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a notification channel with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a notification channel with the specified identifier inside the database", err)
+	}
+
+	deliveries, err := handler.DataStore.NotificationDelivery().NotificationDeliveriesByChannelID(channelID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notification deliveries from the database", err)
+	}
+
+	successFilter, _ := request.RetrieveQueryParameter(r, "success", true)
+
+	filtered := make([]portainer.NotificationDelivery, 0, len(deliveries))
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		if successFilter != "" && (successFilter == "true") != deliveries[i].Success {
+			continue
+		}
+
+		filtered = append(filtered, deliveries[i])
+	}
+
+	return response.JSON(w, filtered)
+}
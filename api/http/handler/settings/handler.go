@@ -15,17 +15,24 @@ func hideFields(settings *portainer.Settings) {
 	settings.LDAPSettings.Password = ""
 	settings.OAuthSettings.ClientSecret = ""
 	settings.OAuthSettings.KubeSecretKey = nil
+	settings.ScheduledBackup.Password = ""
+	settings.ScheduledBackup.S3.SecretAccessKey = ""
 }
 
 // Handler is the HTTP handler used to handle settings operations.
 type Handler struct {
 	*mux.Router
-	DataStore       dataservices.DataStore
-	FileService     portainer.FileService
-	JWTService      dataservices.JWTService
-	LDAPService     portainer.LDAPService
-	SnapshotService portainer.SnapshotService
-	demoService     *demo.Service
+	DataStore              dataservices.DataStore
+	FileService            portainer.FileService
+	ImageScanService       portainer.ImageScanService
+	JWTService             dataservices.JWTService
+	LDAPService            portainer.LDAPService
+	LDAPSyncService        portainer.LDAPSyncService
+	OAuthService           portainer.OAuthService
+	SnapshotService        portainer.SnapshotService
+	ScheduledBackupService portainer.ScheduledBackupService
+	StackDriftService      portainer.StackDriftService
+	demoService            *demo.Service
 }
 
 // NewHandler creates a handler to manage settings operations.
@@ -2,6 +2,7 @@ package settings
 
 import (
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
@@ -11,8 +12,12 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/backup"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/ldap"
+	"github.com/portainer/portainer/api/stacks/drift"
+	"github.com/portainer/portainer/api/vulnerability"
 	"github.com/portainer/portainer/pkg/libhelm"
 )
 
@@ -52,6 +57,12 @@ type settingsUpdatePayload struct {
 	EnforceEdgeID *bool `example:"false"`
 	// EdgePortainerURL is the URL that is exposed to edge agents
 	EdgePortainerURL *string `json:"EdgePortainerURL"`
+	// Configuration of the automatic backups, the status is ignored
+	ScheduledBackup *portainer.ScheduledBackupSettings
+	// Configuration of the scanning of the images for vulnerabilities
+	VulnerabilityScan *portainer.VulnerabilityScanSettings
+	// Interval of the detection of the drift between the stacks and the running services, empty to disable
+	DriftDetectionInterval *string `example:"1h"`
 }
 
 func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
@@ -87,6 +98,66 @@ func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
 		}
 	}
 
+	if payload.OAuthSettings != nil {
+		err := validateOAuthSettings(payload.OAuthSettings)
+		if err != nil {
+			return err
+		}
+	}
+
+	if payload.LDAPSettings != nil {
+		err := ldap.ValidateGroupSyncSettings(payload.LDAPSettings.GroupSync)
+		if err != nil {
+			return err
+		}
+	}
+
+	if payload.ScheduledBackup != nil {
+		if payload.ScheduledBackup.S3.Enabled && payload.ScheduledBackup.S3.Endpoint != "" && !govalidator.IsURL(payload.ScheduledBackup.S3.Endpoint) {
+			return errors.New("Invalid S3 endpoint URL. Must correspond to a valid URL format")
+		}
+	}
+
+	if payload.VulnerabilityScan != nil {
+		err := vulnerability.ValidateSettings(*payload.VulnerabilityScan)
+		if err != nil {
+			return err
+		}
+	}
+
+	if payload.DriftDetectionInterval != nil {
+		err := drift.ValidateInterval(*payload.DriftDetectionInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateOAuthSettings(settings *portainer.OAuthSettings) error {
+	if settings.OpenIDConnect.Enabled && !govalidator.IsURL(settings.OpenIDConnect.IssuerURL) {
+		return errors.New("Invalid OpenID Connect issuer URL. Must correspond to a valid URL format")
+	}
+
+	for _, mapping := range settings.TeamMemberships.OAuthClaimMappings {
+		_, err := regexp.Compile(mapping.ClaimValRegex)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid claim value regex %q", mapping.ClaimValRegex)
+		}
+
+		if mapping.Team == 0 {
+			return errors.New("Invalid team identifier in the claim mappings")
+		}
+	}
+
+	for _, adminGroup := range settings.TeamMemberships.AdminGroupClaimsRegexList {
+		_, err := regexp.Compile(adminGroup)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid admin group regex %q", adminGroup)
+		}
+	}
+
 	return nil
 }
 
@@ -161,6 +232,7 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 
 	if payload.InternalAuthSettings != nil {
 		settings.InternalAuthSettings.RequiredPasswordLength = payload.InternalAuthSettings.RequiredPasswordLength
+		settings.InternalAuthSettings.EnforceTOTP = payload.InternalAuthSettings.EnforceTOTP
 	}
 
 	if payload.LDAPSettings != nil {
@@ -192,6 +264,13 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 		settings.OAuthSettings = *payload.OAuthSettings
 		settings.OAuthSettings.ClientSecret = clientSecret
 		settings.OAuthSettings.KubeSecretKey = kubeSecret
+
+		if settings.OAuthSettings.OpenIDConnect.Enabled {
+			err := handler.OAuthService.DiscoverOpenIDConfiguration(&settings.OAuthSettings)
+			if err != nil {
+				return httperror.BadRequest("Unable to retrieve the OpenID Connect configuration of the provider", err)
+			}
+		}
 	}
 
 	if payload.EnableEdgeComputeFeatures != nil {
@@ -237,6 +316,38 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 		settings.EnableTelemetry = *payload.EnableTelemetry
 	}
 
+	if payload.ScheduledBackup != nil {
+		err := updateScheduledBackup(settings, *payload.ScheduledBackup)
+		if err != nil {
+			return httperror.BadRequest("Unable to update the automatic backups", err)
+		}
+	}
+
+	if payload.LDAPSettings != nil {
+		err := handler.LDAPSyncService.SetSchedule(settings.LDAPSettings.GroupSync)
+		if err != nil {
+			return httperror.InternalServerError("Unable to update the LDAP group synchronisation", err)
+		}
+	}
+
+	if payload.VulnerabilityScan != nil {
+		settings.VulnerabilityScan = *payload.VulnerabilityScan
+
+		err := handler.ImageScanService.SetSchedule(settings.VulnerabilityScan)
+		if err != nil {
+			return httperror.InternalServerError("Unable to update the image vulnerability scans", err)
+		}
+	}
+
+	if payload.DriftDetectionInterval != nil {
+		settings.DriftDetectionInterval = *payload.DriftDetectionInterval
+
+		err := handler.StackDriftService.SetSchedule(settings.DriftDetectionInterval)
+		if err != nil {
+			return httperror.InternalServerError("Unable to update the stack drift detection", err)
+		}
+	}
+
 	tlsError := handler.updateTLS(settings)
 	if tlsError != nil {
 		return tlsError
@@ -251,6 +362,14 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist settings changes inside the database", err)
 	}
 
+	if payload.ScheduledBackup != nil {
+		err = handler.ScheduledBackupService.SetSchedule(settings.ScheduledBackup)
+		if err != nil {
+			return httperror.InternalServerError("Unable to reschedule the automatic backups", err)
+		}
+	}
+
+	hideFields(settings)
 	return response.JSON(w, settings)
 }
 
@@ -260,6 +379,29 @@ func (handler *Handler) updateSnapshotInterval(settings *portainer.Settings, sna
 	return handler.SnapshotService.SetSnapshotInterval(snapshotInterval)
 }
 
+// updateScheduledBackup validates the settings of the automatic backups, the secrets are kept when they are not provided.
+// The backups are rescheduled once the settings are persisted
+func updateScheduledBackup(settings *portainer.Settings, scheduledBackup portainer.ScheduledBackupSettings) error {
+	if scheduledBackup.Password == "" {
+		scheduledBackup.Password = settings.ScheduledBackup.Password
+	}
+
+	if scheduledBackup.S3.SecretAccessKey == "" {
+		scheduledBackup.S3.SecretAccessKey = settings.ScheduledBackup.S3.SecretAccessKey
+	}
+
+	scheduledBackup.Status = settings.ScheduledBackup.Status
+
+	err := backup.ValidateScheduledBackupSettings(scheduledBackup)
+	if err != nil {
+		return err
+	}
+
+	settings.ScheduledBackup = scheduledBackup
+
+	return nil
+}
+
 func (handler *Handler) updateTLS(settings *portainer.Settings) *httperror.HandlerError {
 	if (settings.LDAPSettings.TLSConfig.TLS || settings.LDAPSettings.StartTLS) && !settings.LDAPSettings.TLSConfig.TLSSkipVerify {
 		caCertPath, _ := handler.FileService.GetPathForTLSFile(filesystem.LDAPStorePath, portainer.TLSFileCA)
@@ -8,6 +8,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/deployments"
@@ -167,6 +168,10 @@ type composeStackFromGitRepositoryPayload struct {
 	RepositoryUsername string `example:"myGitUsername"`
 	// Password used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryPassword string `example:"myGitPassword"`
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+	// Tag constraint and trusted keys of the signatures
+	git.PinningPayload
 	// Path to the Stack file inside the Git repository
 	ComposeFile string `example:"docker-compose.yml" default:"docker-compose.yml"`
 	// Applicable when deploying with multiple stack files
@@ -181,16 +186,18 @@ type composeStackFromGitRepositoryPayload struct {
 	TLSSkipVerify bool `example:"false"`
 }
 
-func createStackPayloadFromComposeGitPayload(name, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication bool, composeFile string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, env []portainer.Pair, fromAppTemplate bool, repoSkipSSLVerify bool) stackbuilders.StackPayload {
+func createStackPayloadFromComposeGitPayload(name, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication bool, repoCredentials git.CredentialsPayload, repoPinning git.PinningPayload, composeFile string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, env []portainer.Pair, fromAppTemplate bool, repoSkipSSLVerify bool) stackbuilders.StackPayload {
 	return stackbuilders.StackPayload{
 		Name: name,
 		RepositoryConfigPayload: stackbuilders.RepositoryConfigPayload{
-			URL:            repoUrl,
-			ReferenceName:  repoReference,
-			Authentication: repoAuthentication,
-			Username:       repoUsername,
-			Password:       repoPassword,
-			TLSSkipVerify:  repoSkipSSLVerify,
+			URL:                repoUrl,
+			ReferenceName:      repoReference,
+			Authentication:     repoAuthentication,
+			Username:           repoUsername,
+			Password:           repoPassword,
+			TLSSkipVerify:      repoSkipSSLVerify,
+			CredentialsPayload: repoCredentials,
+			PinningPayload:     repoPinning,
 		},
 		ComposeFile:     composeFile,
 		AdditionalFiles: additionalFiles,
@@ -204,11 +211,16 @@ func (payload *composeStackFromGitRepositoryPayload) Validate(r *http.Request) e
 	if govalidator.IsNull(payload.Name) {
 		return errors.New("Invalid stack name")
 	}
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
-		return errors.New("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if payload.RepositoryAuthentication {
+		if err := git.ValidateRepoAuthentication(payload.Authentication(true, payload.RepositoryUsername, payload.RepositoryPassword)); err != nil {
+			return err
+		}
+	}
+	if err := payload.PinningPayload.Validate(); err != nil {
+		return err
 	}
 	if err := update.ValidateAutoUpdateSettings(payload.AutoUpdate); err != nil {
 		return err
@@ -287,6 +299,8 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		payload.RepositoryUsername,
 		payload.RepositoryPassword,
 		payload.RepositoryAuthentication,
+		payload.CredentialsPayload,
+		payload.PinningPayload,
 		payload.ComposeFile,
 		payload.AdditionalFiles,
 		payload.AutoUpdate,
@@ -11,6 +11,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	k "github.com/portainer/portainer/api/kubernetes"
@@ -47,23 +48,31 @@ type kubernetesGitDeploymentPayload struct {
 	RepositoryAuthentication bool
 	RepositoryUsername       string
 	RepositoryPassword       string
-	ManifestFile             string
-	AdditionalFiles          []string
-	AutoUpdate               *portainer.AutoUpdateSettings
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+	// Tag constraint and trusted keys of the signatures
+	git.PinningPayload
+	ManifestFile    string
+	AdditionalFiles []string
+	AutoUpdate      *portainer.AutoUpdateSettings
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
+	// Renders the directory of the manifest file with kustomize or Helm before applying it
+	KubernetesBuild *portainer.KubernetesBuildConfig
 }
 
-func createStackPayloadFromK8sGitPayload(name, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication, composeFormat bool, namespace, manifest string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, repoSkipSSLVerify bool) stackbuilders.StackPayload {
+func createStackPayloadFromK8sGitPayload(name, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication bool, repoCredentials git.CredentialsPayload, repoPinning git.PinningPayload, composeFormat bool, namespace, manifest string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, repoSkipSSLVerify bool) stackbuilders.StackPayload {
 	return stackbuilders.StackPayload{
 		StackName: name,
 		RepositoryConfigPayload: stackbuilders.RepositoryConfigPayload{
-			URL:            repoUrl,
-			ReferenceName:  repoReference,
-			Authentication: repoAuthentication,
-			Username:       repoUsername,
-			Password:       repoPassword,
-			TLSSkipVerify:  repoSkipSSLVerify,
+			URL:                repoUrl,
+			ReferenceName:      repoReference,
+			Authentication:     repoAuthentication,
+			Username:           repoUsername,
+			Password:           repoPassword,
+			TLSSkipVerify:      repoSkipSSLVerify,
+			CredentialsPayload: repoCredentials,
+			PinningPayload:     repoPinning,
 		},
 		Namespace:       namespace,
 		ComposeFormat:   composeFormat,
@@ -100,15 +109,26 @@ func (payload *kubernetesStringDeploymentPayload) Validate(r *http.Request) erro
 }
 
 func (payload *kubernetesGitDeploymentPayload) Validate(r *http.Request) error {
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
-		return errors.New("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if payload.RepositoryAuthentication {
+		if err := git.ValidateRepoAuthentication(payload.Authentication(true, payload.RepositoryUsername, payload.RepositoryPassword)); err != nil {
+			return err
+		}
+	}
+	if err := payload.PinningPayload.Validate(); err != nil {
+		return err
 	}
 	if govalidator.IsNull(payload.ManifestFile) {
 		return errors.New("Invalid manifest file in repository")
 	}
+	if err := stackutils.ValidateKubernetesBuild(payload.KubernetesBuild, payload.ComposeFormat); err != nil {
+		return err
+	}
+	if payload.KubernetesBuild != nil && len(payload.AdditionalFiles) > 0 {
+		return errors.New("Additional files can't be used with kustomize or Helm")
+	}
 	if err := update.ValidateAutoUpdateSettings(payload.AutoUpdate); err != nil {
 		return err
 	}
@@ -242,6 +262,8 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 		payload.RepositoryUsername,
 		payload.RepositoryPassword,
 		payload.RepositoryAuthentication,
+		payload.CredentialsPayload,
+		payload.PinningPayload,
 		payload.ComposeFormat,
 		payload.Namespace,
 		payload.ManifestFile,
@@ -249,6 +271,7 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 		payload.AutoUpdate,
 		payload.TLSSkipVerify,
 	)
+	stackPayload.KubernetesBuild = payload.KubernetesBuild
 
 	k8sStackBuilder := stackbuilders.CreateKubernetesStackGitBuilder(handler.DataStore,
 		handler.FileService,
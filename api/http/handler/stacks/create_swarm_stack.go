@@ -10,6 +10,7 @@ import (
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/stackbuilders"
@@ -124,6 +125,10 @@ type swarmStackFromGitRepositoryPayload struct {
 	RepositoryUsername string `example:"myGitUsername"`
 	// Password used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryPassword string `example:"myGitPassword"`
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+	// Tag constraint and trusted keys of the signatures
+	git.PinningPayload
 	// Whether the stack is from a app template
 	FromAppTemplate bool `example:"false"`
 	// Path to the Stack file inside the Git repository
@@ -143,11 +148,16 @@ func (payload *swarmStackFromGitRepositoryPayload) Validate(r *http.Request) err
 	if govalidator.IsNull(payload.SwarmID) {
 		return errors.New("Invalid Swarm ID")
 	}
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
-		return errors.New("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if payload.RepositoryAuthentication {
+		if err := git.ValidateRepoAuthentication(payload.Authentication(true, payload.RepositoryUsername, payload.RepositoryPassword)); err != nil {
+			return err
+		}
+	}
+	if err := payload.PinningPayload.Validate(); err != nil {
+		return err
 	}
 	if err := update.ValidateAutoUpdateSettings(payload.AutoUpdate); err != nil {
 		return err
@@ -155,17 +165,19 @@ func (payload *swarmStackFromGitRepositoryPayload) Validate(r *http.Request) err
 	return nil
 }
 
-func createStackPayloadFromSwarmGitPayload(name, swarmID, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication bool, composeFile string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, env []portainer.Pair, fromAppTemplate bool, repoSkipSSLVerify bool) stackbuilders.StackPayload {
+func createStackPayloadFromSwarmGitPayload(name, swarmID, repoUrl, repoReference, repoUsername, repoPassword string, repoAuthentication bool, repoCredentials git.CredentialsPayload, repoPinning git.PinningPayload, composeFile string, additionalFiles []string, autoUpdate *portainer.AutoUpdateSettings, env []portainer.Pair, fromAppTemplate bool, repoSkipSSLVerify bool) stackbuilders.StackPayload {
 	return stackbuilders.StackPayload{
 		Name:    name,
 		SwarmID: swarmID,
 		RepositoryConfigPayload: stackbuilders.RepositoryConfigPayload{
-			URL:            repoUrl,
-			ReferenceName:  repoReference,
-			Authentication: repoAuthentication,
-			Username:       repoUsername,
-			Password:       repoPassword,
-			TLSSkipVerify:  repoSkipSSLVerify,
+			URL:                repoUrl,
+			ReferenceName:      repoReference,
+			Authentication:     repoAuthentication,
+			Username:           repoUsername,
+			Password:           repoPassword,
+			TLSSkipVerify:      repoSkipSSLVerify,
+			CredentialsPayload: repoCredentials,
+			PinningPayload:     repoPinning,
 		},
 		ComposeFile:     composeFile,
 		AdditionalFiles: additionalFiles,
@@ -230,6 +242,8 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 		payload.RepositoryUsername,
 		payload.RepositoryPassword,
 		payload.RepositoryAuthentication,
+		payload.CredentialsPayload,
+		payload.PinningPayload,
 		payload.ComposeFile,
 		payload.AdditionalFiles,
 		payload.AutoUpdate,
@@ -28,6 +28,8 @@ type Handler struct {
 	stackCreationMutex *sync.Mutex
 	stackDeletionMutex *sync.Mutex
 	requestBouncer     *security.RequestBouncer
+	// git credentials of the pending deployments, kept in memory only
+	pendingDeploymentCredentials *pendingDeploymentCredentials
 	*mux.Router
 	DataStore               dataservices.DataStore
 	DockerClientFactory     *docker.ClientFactory
@@ -39,6 +41,7 @@ type Handler struct {
 	KubernetesClientFactory *cli.ClientFactory
 	Scheduler               *scheduler.Scheduler
 	StackDeployer           deployments.StackDeployer
+	StackDriftService       portainer.StackDriftService
 }
 
 func stackExistsError(name string) *httperror.HandlerError {
@@ -54,12 +57,22 @@ func NewHandler(bouncer *security.RequestBouncer) *Handler {
 		stackCreationMutex: &sync.Mutex{},
 		stackDeletionMutex: &sync.Mutex{},
 		requestBouncer:     bouncer,
+
+		pendingDeploymentCredentials: newPendingDeploymentCredentials(),
 	}
 
 	h.Handle("/stacks/create/{type}/{method}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackCreate))).Methods(http.MethodPost)
 	h.Handle("/stacks",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackList))).Methods(http.MethodGet)
+	h.Handle("/stacks/pending_deployments",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.pendingDeploymentList))).Methods(http.MethodGet)
+	h.Handle("/stacks/pending_deployments/{id}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.pendingDeploymentInspect))).Methods(http.MethodGet)
+	h.Handle("/stacks/pending_deployments/{id}/approve",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.pendingDeploymentApprove))).Methods(http.MethodPost)
+	h.Handle("/stacks/pending_deployments/{id}/reject",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.pendingDeploymentReject))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackInspect))).Methods(http.MethodGet)
 	h.Handle("/stacks/{id}",
@@ -72,6 +85,20 @@ func NewHandler(bouncer *security.RequestBouncer) *Handler {
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackUpdateGit))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/git/redeploy",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackGitRedeploy))).Methods(http.MethodPut)
+	h.Handle("/stacks/{id}/versions",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackVersionList))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/versions/retention",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackHistoryRetentionUpdate))).Methods(http.MethodPut)
+	h.Handle("/stacks/{id}/rollback/{version}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackRollback))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/drift",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackDriftInspect))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/drift",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackDriftCheck))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/drift/reconcile",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackDriftReconcile))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/preview",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackPreview))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/file",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackFile))).Methods(http.MethodGet)
 	h.Handle("/stacks/{id}/migrate",
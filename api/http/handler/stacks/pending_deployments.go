@@ -0,0 +1,306 @@
+package stacks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/rs/zerolog/log"
+)
+
+type pendingDeploymentDecisionPayload struct {
+	// Comment recorded with the decision
+	Comment string `example:"reviewed the image upgrade"`
+}
+
+func (payload *pendingDeploymentDecisionPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id PendingDeploymentList
+// @summary List the pending deployments
+// @description List the stack deployments waiting for an approval, and the decided ones. The users only see their
+// @description own deployments and the deployments they can approve.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.PendingDeployment "Success"
+// @failure 500 "Server error"
+// @router /stacks/pending_deployments [get]
+func (handler *Handler) pendingDeploymentList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	pendingDeployments, err := handler.DataStore.PendingDeployment().PendingDeployments()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the pending deployments from the database", err)
+	}
+
+	visible := make([]*portainer.PendingDeployment, 0, len(pendingDeployments))
+	for i := range pendingDeployments {
+		pending := &pendingDeployments[i]
+
+		canApprove, err := handler.canApprovePendingDeployment(securityContext, pending)
+		if err != nil {
+			return httperror.InternalServerError("Unable to verify user authorizations to approve the deployment", err)
+		}
+
+		if !canApprove && pending.CreatedBy != securityContext.UserID {
+			continue
+		}
+
+		err = handler.expirePendingDeployment(pending)
+		if err != nil {
+			return httperror.InternalServerError("Unable to persist the pending deployment changes inside the database", err)
+		}
+
+		visible = append(visible, sanitizePendingDeployment(pending))
+	}
+
+	return response.JSON(w, visible)
+}
+
+// @id PendingDeploymentInspect
+// @summary Inspect a pending deployment
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Pending deployment identifier"
+// @success 200 {object} portainer.PendingDeployment "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Pending deployment not found"
+// @failure 500 "Server error"
+// @router /stacks/pending_deployments/{id} [get]
+func (handler *Handler) pendingDeploymentInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	pending, securityContext, httpErr := handler.retrievePendingDeployment(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	canApprove, err := handler.canApprovePendingDeployment(securityContext, pending)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to approve the deployment", err)
+	}
+
+	if !canApprove && pending.CreatedBy != securityContext.UserID {
+		return httperror.Forbidden("Permission denied to access the pending deployment", errors.New("the user can't approve the deployment"))
+	}
+
+	return response.JSON(w, sanitizePendingDeployment(pending))
+}
+
+// @id PendingDeploymentApprove
+// @summary Approve a pending deployment
+// @description Record the approval of the user, the deployment is run on behalf of its author once it has received
+// @description the approvals required by the environment group. The authors can't approve their own deployments.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Pending deployment identifier"
+// @param body body pendingDeploymentDecisionPayload false "Approval comment"
+// @success 200 {object} portainer.PendingDeployment "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Pending deployment not found"
+// @failure 409 "The deployment is not pending or was already approved by the user"
+// @failure 500 "Server error"
+// @router /stacks/pending_deployments/{id}/approve [post]
+func (handler *Handler) pendingDeploymentApprove(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.decidePendingDeployment(w, r, true)
+}
+
+// @id PendingDeploymentReject
+// @summary Reject a pending deployment
+// @description Reject a deployment waiting for an approval. The authors can reject their own deployments to cancel them.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Pending deployment identifier"
+// @param body body pendingDeploymentDecisionPayload false "Rejection comment"
+// @success 200 {object} portainer.PendingDeployment "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Pending deployment not found"
+// @failure 409 "The deployment is not pending"
+// @failure 500 "Server error"
+// @router /stacks/pending_deployments/{id}/reject [post]
+func (handler *Handler) pendingDeploymentReject(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.decidePendingDeployment(w, r, false)
+}
+
+var (
+	errPendingDeploymentDecided = errors.New("the deployment is not pending")
+	errDuplicateApproval        = errors.New("duplicate approval")
+)
+
+func (handler *Handler) decidePendingDeployment(w http.ResponseWriter, r *http.Request, approved bool) *httperror.HandlerError {
+	pending, securityContext, httpErr := handler.retrievePendingDeployment(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload pendingDeploymentDecisionPayload
+	if r.ContentLength != 0 {
+		err := request.DecodeAndValidateJSONPayload(r, &payload)
+		if err != nil {
+			return httperror.BadRequest("Invalid request payload", err)
+		}
+	}
+
+	canApprove, err := handler.canApprovePendingDeployment(securityContext, pending)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to approve the deployment", err)
+	}
+
+	isAuthor := pending.CreatedBy == securityContext.UserID
+	if !canApprove && !(isAuthor && !approved) {
+		return httperror.Forbidden("Permission denied to decide on the pending deployment", errors.New("the user can't approve the deployment"))
+	}
+
+	user, err := handler.DataStore.User().User(securityContext.UserID)
+	if err != nil {
+		return httperror.BadRequest("Cannot find context user", errors.Wrap(err, "failed to fetch the user"))
+	}
+
+	stackChanged := false
+	if approved {
+		stackChanged, err = handler.pendingStackChanged(pending)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the stack of the deployment from the database", err)
+		}
+	}
+
+	decision := portainer.PendingDeploymentDecision{
+		UserID:   user.ID,
+		Username: user.Username,
+		Approved: approved,
+		Comment:  payload.Comment,
+		Date:     time.Now().Unix(),
+	}
+
+	// the decisions of concurrent requests are recorded one after the other, only the request which approves the
+	// deployment runs it
+	var deploymentRequest *portainer.PendingDeploymentRequest
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		pending, err = tx.PendingDeployment().PendingDeployment(pending.ID)
+		if err != nil {
+			return err
+		}
+
+		if pending.Status != portainer.PendingDeploymentPending || decision.Date >= pending.ExpiresAt {
+			return errPendingDeploymentDecided
+		}
+
+		for _, previous := range pending.Decisions {
+			if previous.UserID == decision.UserID {
+				return errDuplicateApproval
+			}
+		}
+
+		pending.Decisions = append(pending.Decisions, decision)
+
+		switch {
+		case stackChanged:
+			pending.Status = portainer.PendingDeploymentFailed
+			pending.Error = "the stack was updated since the deployment was requested"
+			pending.Request = nil
+		case !approved:
+			pending.Status = portainer.PendingDeploymentRejected
+			pending.Request = nil
+		case approvals(pending) >= pending.RequiredApprovals:
+			pending.Status = portainer.PendingDeploymentApproved
+			deploymentRequest = pending.Request
+			pending.Request = nil
+		}
+
+		return tx.PendingDeployment().UpdatePendingDeployment(pending.ID, pending)
+	})
+	if errors.Is(err, errPendingDeploymentDecided) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The deployment is not pending", Err: err}
+	} else if errors.Is(err, errDuplicateApproval) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The deployment was already approved by the user", Err: err}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to persist the pending deployment changes inside the database", err)
+	}
+
+	if pending.Status != portainer.PendingDeploymentPending && deploymentRequest == nil {
+		handler.pendingDeploymentCredentials.take(pending.ID)
+	}
+
+	if stackChanged {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The stack was updated since the deployment was requested", Err: errors.New(pending.Error)}
+	}
+
+	if deploymentRequest != nil {
+		err = handler.runPendingDeployment(pending, deploymentRequest)
+		if err != nil {
+			log.Warn().Err(err).Int("pending_deployment_id", int(pending.ID)).Msg("the approved deployment failed")
+
+			pending.Status = portainer.PendingDeploymentFailed
+			pending.Error = err.Error()
+		}
+
+		err = handler.DataStore.PendingDeployment().UpdatePendingDeployment(pending.ID, pending)
+		if err != nil {
+			return httperror.InternalServerError("Unable to persist the pending deployment changes inside the database", err)
+		}
+	}
+
+	return response.JSON(w, sanitizePendingDeployment(pending))
+}
+
+func (handler *Handler) retrievePendingDeployment(r *http.Request) (*portainer.PendingDeployment, *security.RestrictedRequestContext, *httperror.HandlerError) {
+	pendingDeploymentID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, nil, httperror.BadRequest("Invalid pending deployment identifier route variable", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	pending, err := handler.DataStore.PendingDeployment().PendingDeployment(portainer.PendingDeploymentID(pendingDeploymentID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find a pending deployment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find a pending deployment with the specified identifier inside the database", err)
+	}
+
+	err = handler.expirePendingDeployment(pending)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to persist the pending deployment changes inside the database", err)
+	}
+
+	return pending, securityContext, nil
+}
+
+func approvals(pending *portainer.PendingDeployment) int {
+	count := 0
+	for _, decision := range pending.Decisions {
+		if decision.Approved {
+			count++
+		}
+	}
+
+	return count
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 )
@@ -119,10 +120,12 @@ func (handler *Handler) stackAssociate(w http.ResponseWriter, r *http.Request) *
 
 	stack.ResourceControl = resourceControl
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		// sanitize the password and the private keys in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.HideSecrets()
 	}
 
+	update.HideWebhookSecret(stack.AutoUpdate)
+
 	return response.JSON(w, stack)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/stacks/stackutils"
@@ -60,6 +61,10 @@ func (handler *Handler) stackCreate(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
 	}
 
+	if deferred, httpErr := handler.deferDeployment(w, r, portainer.PendingDeploymentCreate, endpoint, nil); httpErr != nil || deferred {
+		return httpErr
+	}
+
 	switch stackType {
 	case "swarm":
 		return handler.createSwarmStack(w, r, method, endpoint, tokenData.ID)
@@ -132,10 +137,12 @@ func (handler *Handler) decorateStackResponse(w http.ResponseWriter, stack *port
 
 	stack.ResourceControl = resourceControl
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		// sanitize the password and the private keys in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.HideSecrets()
 	}
 
+	update.HideWebhookSecret(stack.AutoUpdate)
+
 	return response.JSON(w, stack)
 }
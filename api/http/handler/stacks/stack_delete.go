@@ -128,6 +128,16 @@ func (handler *Handler) stackDelete(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.InternalServerError("Unable to remove the stack from the database", err)
 	}
 
+	err = deployments.DeleteStackVersions(handler.DataStore, stack.ID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to remove the stack history from the database")
+	}
+
+	err = handler.DataStore.StackDrift().DeleteStackDriftReport(stack.ID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to remove the stack drift report from the database")
+	}
+
 	if resourceControl != nil {
 		err = handler.DataStore.ResourceControl().DeleteResourceControl(resourceControl.ID)
 		if err != nil {
@@ -196,6 +206,30 @@ func (handler *Handler) deleteStack(userID portainer.UserID, stack *portainer.St
 	if stack.Type == portainer.KubernetesStack {
 		var manifestFiles []string
 
+		//if it is a kustomize or Helm stack, render the manifests into a temp dir
+		//then process the remove operation
+		if stack.KubernetesBuild != nil {
+			tmpDir, err := os.MkdirTemp("", "kube_delete")
+			if err != nil {
+				return errors.Wrap(err, "failed to create temp directory for deleting kub stack")
+			}
+			defer os.RemoveAll(tmpDir)
+
+			manifestContent, err := stackutils.RenderKubernetesManifest(stack, handler.KubernetesDeployer)
+			if err != nil {
+				return errors.Wrap(err, "failed to render the kube manifests")
+			}
+
+			manifestFilePath := filesystem.JoinPaths(tmpDir, "manifest.yaml")
+			err = filesystem.WriteToFile(manifestFilePath, manifestContent)
+			if err != nil {
+				return errors.Wrap(err, "failed to create temp manifest file")
+			}
+
+			out, err := handler.KubernetesDeployer.Remove(userID, endpoint, []string{manifestFilePath}, stack.Namespace)
+			return errors.WithMessagef(err, "failed to remove kubernetes resources: %q", out)
+		}
+
 		//if it is a compose format kub stack, create a temp dir and convert the manifest files into it
 		//then process the remove operation
 		if stack.IsComposeFormat {
@@ -0,0 +1,145 @@
+package stacks
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git/update"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+// @id StackDriftInspect
+// @summary Inspect the drift of a stack
+// @description Get the last drift report of a stack, which lists the differences between the stack files
+// @description and the running services detected by the periodic drift detection.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {object} portainer.StackDriftReport "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack or drift report not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/drift [get]
+func (handler *Handler) stackDriftInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, _, httpErr := handler.retrieveManageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	report, err := handler.DataStore.StackDrift().StackDriftReport(stack.ID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("The drift of the stack has not been checked yet", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the stack drift report from the database", err)
+	}
+
+	return response.JSON(w, report)
+}
+
+// @id StackDriftCheck
+// @summary Check the drift of a stack
+// @description Compare the stack files with the running services now and replace the drift report of the stack.
+// @description The services of the Docker environments are read from their last snapshot.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {object} portainer.StackDriftReport "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/drift [post]
+func (handler *Handler) stackDriftCheck(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, _, httpErr := handler.retrieveManageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if stack.Status != portainer.StackStatusActive {
+		return httperror.BadRequest("The drift of an inactive stack cannot be checked", errors.New("stack is inactive"))
+	}
+
+	report, err := handler.StackDriftService.CheckStack(stack)
+	if err != nil {
+		return httperror.InternalServerError("Unable to check the drift of the stack", err)
+	}
+
+	return response.JSON(w, report)
+}
+
+// @id StackDriftReconcile
+// @summary Reconcile a stack
+// @description Redeploy a stack with its current files and environment variables to revert the changes made
+// @description to its running services outside of Portainer. The drift report of the stack is removed.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/drift/reconcile [post]
+func (handler *Handler) stackDriftReconcile(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, endpoint, httpErr := handler.retrieveManageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if stack.Status != portainer.StackStatusActive {
+		return httperror.BadRequest("An inactive stack cannot be reconciled", errors.New("stack is inactive"))
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.DataStore.User().User(securityContext.UserID)
+	if err != nil {
+		return httperror.BadRequest("Cannot find context user", errors.Wrap(err, "failed to fetch the user"))
+	}
+
+	stack.UpdatedBy = user.Username
+
+	httpErr = handler.deployStack(r, stack, false, endpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	stack.UpdateDate = time.Now().Unix()
+
+	err = handler.DataStore.Stack().UpdateStack(stack.ID, stack)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	// the report is outdated until the next snapshot of the environment
+	err = handler.DataStore.StackDrift().DeleteStackDriftReport(stack.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the stack drift report from the database", err)
+	}
+
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		// sanitize the password and the private keys in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.HideSecrets()
+	}
+
+	update.HideWebhookSecret(stack.AutoUpdate)
+
+	return response.JSON(w, stack)
+}
@@ -3,6 +3,7 @@ package stacks
 import (
 	"net/http"
 
+	"github.com/portainer/portainer/api/git/update"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 
 	httperror "github.com/portainer/libhttp/error"
@@ -83,10 +84,12 @@ func (handler *Handler) stackList(w http.ResponseWriter, r *http.Request) *httpe
 	}
 
 	for _, stack := range stacks {
-		if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-			// sanitize password in the http response to minimise possible security leaks
-			stack.GitConfig.Authentication.Password = ""
+		if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+			// sanitize the password and the private keys in the http response to minimise possible security leaks
+			stack.GitConfig.Authentication.HideSecrets()
 		}
+
+		update.HideWebhookSecret(stack.AutoUpdate)
 	}
 
 	return response.JSON(w, stacks)
@@ -1,14 +1,18 @@
 package stacks
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git/update"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/stacks/deployments"
@@ -54,6 +58,13 @@ func (handler *Handler) stackMigrate(w http.ResponseWriter, r *http.Request) *ht
 		return httperror.BadRequest("Invalid stack identifier route variable", err)
 	}
 
+	// the payload is read again when the migration is deferred
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return httperror.BadRequest("Unable to read the request payload", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
 	var payload stackMigratePayload
 	err = request.DecodeAndValidateJSONPayload(r, &payload)
 	if err != nil {
@@ -128,6 +139,21 @@ func (handler *Handler) stackMigrate(w http.ResponseWriter, r *http.Request) *ht
 		return httperror.InternalServerError("Unable to find an endpoint with the specified identifier inside the database", err)
 	}
 
+	// the migration is approved in the target environment, or in the current one when the target doesn't require it
+	approvalEndpoint := targetEndpoint
+	policy, err := handler.deploymentApprovalPolicy(targetEndpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the deployment approval policy", err)
+	}
+	if policy == nil {
+		approvalEndpoint = endpoint
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if deferred, httpErr := handler.deferDeployment(w, r, portainer.PendingDeploymentMigrate, approvalEndpoint, stack); httpErr != nil || deferred {
+		return httpErr
+	}
+
 	stack.EndpointID = portainer.EndpointID(payload.EndpointID)
 	if payload.SwarmID != "" {
 		stack.SwarmID = payload.SwarmID
@@ -161,6 +187,7 @@ func (handler *Handler) stackMigrate(w http.ResponseWriter, r *http.Request) *ht
 	}
 
 	stack.Name = newName
+	stack.UpdateDate = time.Now().Unix()
 	err = handler.DataStore.Stack().UpdateStack(stack.ID, stack)
 	if err != nil {
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
@@ -174,11 +201,13 @@ func (handler *Handler) stackMigrate(w http.ResponseWriter, r *http.Request) *ht
 		}
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		// sanitize the password and the private keys in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.HideSecrets()
 	}
 
+	update.HideWebhookSecret(stack.AutoUpdate)
+
 	return response.JSON(w, stack)
 }
 
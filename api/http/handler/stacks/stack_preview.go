@@ -0,0 +1,243 @@
+package stacks
+
+import (
+	"net/http"
+	"os"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/git/update"
+	"github.com/portainer/portainer/api/http/security"
+	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/preview"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/pkg/errors"
+)
+
+type stackPreviewPayload struct {
+	// New content of the Stack file, required by the stacks which are not deployed from a git repository
+	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx"`
+	// A list of environment(endpoint) variables used during stack deployment, the variables of the stack are kept when it is not set
+	Env []portainer.Pair
+	// Reference name of the git repository, the reference of the stack is kept when it is not set
+	RepositoryReferenceName string `example:"refs/heads/master"`
+	// Use other credentials than the saved ones to pull the git repository
+	RepositoryAuthentication bool
+	RepositoryUsername       string
+	RepositoryPassword       string
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+}
+
+func (payload *stackPreviewPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id StackPreview
+// @summary Preview the update of a stack
+// @description Compare the deployed stack with the new content of its file, or with the files of its git repository,
+// @description without deploying it. The compose files are compared with the deployed ones service by service, and the
+// @description Kubernetes objects are compared with the result of a server-side dry-run of their manifests.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack identifier"
+// @param body body stackPreviewPayload true "New content of the stack"
+// @success 200 {object} portainer.StackPreview "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/preview [post]
+func (handler *Handler) stackPreview(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, endpoint, httpErr := handler.retrieveManageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload stackPreviewPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	stackPreview, httpErr := handler.previewStack(r, stack, endpoint, &payload)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, stackPreview)
+}
+
+// previewStack returns the changes that the update of the payload would make to the deployed stack
+func (handler *Handler) previewStack(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, payload *stackPreviewPayload) (*portainer.StackPreview, *httperror.HandlerError) {
+	if stack.GitConfig == nil && payload.StackFileContent == "" {
+		return nil, httperror.BadRequest("Invalid request payload", errors.New("Invalid stack file content"))
+	}
+
+	tmpDir, err := os.MkdirTemp("", "stack_preview")
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to create a temporary directory", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the proposed files are written to a temporary project, the stack is not modified
+	proposed := *stack
+	proposed.ProjectPath = tmpDir
+	if payload.Env != nil {
+		proposed.Env = payload.Env
+	}
+
+	configHash := ""
+	if stack.GitConfig != nil {
+		var httpErr *httperror.HandlerError
+		configHash, httpErr = handler.clonePreviewRepository(stack, payload, tmpDir)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+	} else {
+		err = filesystem.WriteToFile(filesystem.JoinPaths(tmpDir, stack.EntryPoint), []byte(payload.StackFileContent))
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to persist the stack file in a temporary directory", err)
+		}
+	}
+
+	var changes []portainer.StackPreviewChange
+
+	switch stack.Type {
+	case portainer.DockerSwarmStack, portainer.DockerComposeStack:
+		current, err := handler.stackFileContents(stack)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to read the deployed stack files", err)
+		}
+
+		proposedContents, err := handler.stackFileContents(&proposed)
+		if err != nil {
+			return nil, httperror.BadRequest("Unable to read the proposed stack files", err)
+		}
+
+		changes, err = preview.ComposeChanges(current, proposedContents, stack.Env, proposed.Env, stack.Type == portainer.DockerSwarmStack)
+		if err != nil {
+			return nil, httperror.BadRequest("Unable to compare the stack files", err)
+		}
+	case portainer.KubernetesStack:
+		tokenData, err := security.RetrieveTokenData(r)
+		if err != nil {
+			return nil, httperror.BadRequest("Failed to retrieve user token data", err)
+		}
+
+		user := &portainer.User{ID: tokenData.ID, Username: tokenData.Username}
+
+		appLabels := k.KubeAppLabels{
+			StackID:   int(stack.ID),
+			StackName: stack.Name,
+			Owner:     user.Username,
+			Kind:      "content",
+		}
+		if stack.GitConfig != nil {
+			appLabels.Kind = "git"
+		}
+
+		k8sDeploymentConfig, err := deployments.CreateKubernetesStackDeploymentConfig(&proposed, handler.KubernetesDeployer, appLabels, user, endpoint)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to create the kubernetes deployment config", err)
+		}
+
+		live, applied, err := k8sDeploymentConfig.DryRun()
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to dry-run the kubernetes stack", err)
+		}
+
+		// the objects of the current manifests which are not in the new ones are no longer defined by the stack
+		currentDeploymentConfig, err := deployments.CreateKubernetesStackDeploymentConfig(stack, handler.KubernetesDeployer, appLabels, user, endpoint)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to create the kubernetes deployment config", err)
+		}
+
+		deployed, _, err := currentDeploymentConfig.DryRun()
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the objects of the kubernetes stack", err)
+		}
+
+		changes, err = preview.KubernetesChanges(deployed, live, applied)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to compare the kubernetes objects", err)
+		}
+	default:
+		return nil, httperror.InternalServerError("Unsupported stack", errors.Errorf("unsupported stack type: %v", stack.Type))
+	}
+
+	return &portainer.StackPreview{
+		StackID:    stack.ID,
+		ConfigHash: configHash,
+		Changes:    changes,
+	}, nil
+}
+
+// clonePreviewRepository clones the git repository of the stack at the previewed reference, as it would be
+// redeployed, and returns the hash of the cloned commit
+func (handler *Handler) clonePreviewRepository(stack *portainer.Stack, payload *stackPreviewPayload, dir string) (string, *httperror.HandlerError) {
+	gitConfig := *stack.GitConfig
+	if payload.RepositoryReferenceName != "" {
+		gitConfig.ReferenceName = payload.RepositoryReferenceName
+	}
+
+	if payload.RepositoryAuthentication {
+		auth := payload.CredentialsPayload.Authentication(true, payload.RepositoryUsername, payload.RepositoryPassword)
+		git.KeepSavedSecrets(auth, stack.GitConfig.Authentication)
+		gitConfig.Authentication = auth
+	}
+
+	err := update.ResolveTagConstraint(handler.GitService, &gitConfig)
+	if err != nil {
+		return "", httperror.InternalServerError("Unable to resolve the tag of the git repository", err)
+	}
+
+	verifiedHash, err := update.VerifySignature(handler.GitService, &gitConfig)
+	if err != nil {
+		return "", httperror.BadRequest("Unable to verify the signature of the git repository", err)
+	}
+
+	// the previewed files are the ones whose signature was verified
+	err = handler.GitService.CloneCommit(dir, gitConfig.URL, gitConfig.ReferenceName, verifiedHash, gitConfig.Authentication, gitConfig.TLSSkipVerify)
+	if errors.Is(err, gittypes.ErrUnexpectedCommit) {
+		return "", httperror.InternalServerError("The git repository was updated during the verification of its signature", err)
+	} else if err != nil {
+		return "", httperror.InternalServerError("Unable to clone git repository directory", err)
+	}
+
+	if verifiedHash != "" {
+		return verifiedHash, nil
+	}
+
+	hash, err := handler.GitService.LatestCommitID(gitConfig.URL, gitConfig.ReferenceName, gitConfig.Authentication, gitConfig.TLSSkipVerify)
+	if err != nil {
+		return "", httperror.InternalServerError("Unable get latest commit id", err)
+	}
+
+	return hash, nil
+}
+
+func (handler *Handler) stackFileContents(stack *portainer.Stack) ([][]byte, error) {
+	contents := [][]byte{}
+	for _, file := range stackutils.GetStackFilePaths(stack, false) {
+		content, err := handler.FileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to read the stack file %s", file)
+		}
+
+		contents = append(contents, content)
+	}
+
+	return contents, nil
+}
@@ -0,0 +1,183 @@
+package stacks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupHandler creates a stacks handler with an administrator authenticated by the returned API key
+func setupHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	t.Cleanup(teardown)
+
+	jwtService, err := jwt.NewService("1h", store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	err = store.User().Create(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	rawAPIKey, _, err := apiKeyService.GenerateApiKey(*user, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(security.NewRequestBouncer(store, jwtService, apiKeyService))
+	handler.DataStore = store
+
+	handler.FileService, err = filesystem.NewService(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handler, rawAPIKey
+}
+
+// createStack creates a stack and its environment(endpoint), with its file in the file store
+func createStack(t *testing.T, handler *Handler, stack *portainer.Stack, fileContent string) {
+	t.Helper()
+
+	endpointType := portainer.DockerEnvironment
+	if stack.Type == portainer.KubernetesStack {
+		endpointType = portainer.KubernetesLocalEnvironment
+	}
+
+	err := handler.DataStore.Endpoint().Create(&portainer.Endpoint{ID: stack.EndpointID, Name: "endpoint", Type: endpointType})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stack.ProjectPath, err = handler.FileService.StoreStackFileFromBytes(fmt.Sprint(stack.ID), stack.EntryPoint, []byte(fileContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = handler.DataStore.Stack().Create(stack)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func serveJSON(handler *Handler, rawAPIKey, method, url string, payload interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+
+	req := httptest.NewRequest(method, url, bytes.NewReader(body))
+	req.Header.Add("x-api-key", rawAPIKey)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+// previewDeployer returns the objects of a ConfigMap per manifest, as they are deployed and as they would be applied
+type previewDeployer struct {
+	portainer.KubernetesDeployer
+}
+
+func (deployer *previewDeployer) DryRun(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) ([]byte, []byte, error) {
+	var live, applied []string
+
+	for _, manifestFile := range manifestFiles {
+		manifest, err := os.ReadFile(manifestFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, name := range []string{"settings", "legacy"} {
+			if !strings.Contains(string(manifest), "name: "+name) {
+				continue
+			}
+
+			mode := "production"
+			if strings.Contains(string(manifest), "mode: debug") {
+				mode = "debug"
+			}
+
+			live = append(live, fmt.Sprintf(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": %q, "namespace": "default", "resourceVersion": "1"}, "data": {"mode": "production"}}`, name))
+			applied = append(applied, fmt.Sprintf(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": %q, "namespace": "default"}, "data": {"mode": %q}}`, name, mode))
+		}
+	}
+
+	return []byte(strings.Join(live, "\n")), []byte(strings.Join(applied, "\n")), nil
+}
+
+func Test_stackPreview_Compose(t *testing.T) {
+	is := assert.New(t)
+
+	handler, rawAPIKey := setupHandler(t)
+
+	stack := &portainer.Stack{ID: 1, Name: "web", Type: portainer.DockerComposeStack, EndpointID: 1, EntryPoint: "docker-compose.yml"}
+	createStack(t, handler, stack, "version: '3'\nservices:\n  web:\n    image: nginx:1.23\n  worker:\n    image: busybox\n")
+
+	rr := serveJSON(handler, rawAPIKey, http.MethodPost, "/stacks/1/preview", stackPreviewPayload{
+		StackFileContent: "version: '3'\nservices:\n  web:\n    image: nginx:1.24\n  cache:\n    image: redis\n",
+	})
+	is.Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var stackPreview portainer.StackPreview
+	is.NoError(json.NewDecoder(rr.Body).Decode(&stackPreview))
+	is.Equal(portainer.StackID(1), stackPreview.StackID)
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "cache", Action: portainer.StackPreviewCreate},
+		{Resource: "web", Action: portainer.StackPreviewRecreate, Fields: []portainer.StackPreviewFieldChange{
+			{Field: "image", Current: "nginx:1.23", Proposed: "nginx:1.24"},
+		}},
+		{Resource: "worker", Action: portainer.StackPreviewRemove},
+	}, stackPreview.Changes)
+
+	content, err := os.ReadFile(stack.ProjectPath + "/docker-compose.yml")
+	is.NoError(err)
+	is.Contains(string(content), "nginx:1.23", "the stack file should not be modified")
+
+	rr = serveJSON(handler, rawAPIKey, http.MethodPost, "/stacks/1/preview", stackPreviewPayload{})
+	is.Equal(http.StatusBadRequest, rr.Code, "the content is required by the stacks which are not deployed from git")
+
+	rr = serveJSON(handler, rawAPIKey, http.MethodPost, "/stacks/2/preview", stackPreviewPayload{StackFileContent: "version: '3'"})
+	is.Equal(http.StatusNotFound, rr.Code)
+}
+
+func Test_stackPreview_Kubernetes(t *testing.T) {
+	is := assert.New(t)
+
+	handler, rawAPIKey := setupHandler(t)
+	handler.KubernetesDeployer = &previewDeployer{}
+
+	stack := &portainer.Stack{ID: 1, Name: "web", Type: portainer.KubernetesStack, EndpointID: 1, EntryPoint: "manifest.yaml", Namespace: "default"}
+	createStack(t, handler, stack, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: legacy\n")
+
+	rr := serveJSON(handler, rawAPIKey, http.MethodPost, "/stacks/1/preview", stackPreviewPayload{
+		StackFileContent: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\ndata:\n  mode: debug\n",
+	})
+	is.Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var stackPreview portainer.StackPreview
+	is.NoError(json.NewDecoder(rr.Body).Decode(&stackPreview))
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "ConfigMap/default/legacy", Action: portainer.StackPreviewRemove},
+		{Resource: "ConfigMap/default/settings", Action: portainer.StackPreviewUpdate, Fields: []portainer.StackPreviewFieldChange{
+			{Field: "data.mode", Current: "production", Proposed: "debug"},
+		}},
+	}, stackPreview.Changes)
+}
@@ -0,0 +1,138 @@
+package stacks
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/rs/zerolog/log"
+)
+
+// @id StackRollback
+// @summary Rollback a stack
+// @description Redeploy a stack with the files and the environment variables of a version of its deployment history.
+// @description The rollback is recorded as a new version of the stack. The git stacks can't be rolled back, they are redeployed from their repository.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param version path int true "Version number to rollback to"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request, or the stack is deployed from a git repository"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack or version not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/rollback/{version} [post]
+func (handler *Handler) stackRollback(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	versionNumber, err := request.RetrieveNumericRouteVariableValue(r, "version")
+	if err != nil {
+		return httperror.BadRequest("Invalid version route variable", err)
+	}
+
+	stack, endpoint, httpErr := handler.retrieveManageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	// the files of a git stack are the ones of its repository, restoring the stored files would leave the repository
+	// folder out of sync with its commit
+	if stack.GitConfig != nil {
+		return httperror.BadRequest("A stack deployed from a git repository can't be rolled back", errors.New("the stack is deployed from a git repository"))
+	}
+
+	versions, err := handler.DataStore.StackVersion().StackVersionsByStackID(stack.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the stack versions from the database", err)
+	}
+
+	var version *portainer.StackVersion
+	for i := range versions {
+		if versions[i].Version == versionNumber {
+			version = &versions[i]
+			break
+		}
+	}
+
+	if version == nil {
+		return httperror.NotFound("Unable to find the stack version inside the database", errors.Errorf("version %d of the stack %d not found", versionNumber, stack.ID))
+	}
+
+	if deferred, httpErr := handler.deferDeployment(w, r, portainer.PendingDeploymentRollback, endpoint, stack); httpErr != nil || deferred {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.DataStore.User().User(securityContext.UserID)
+	if err != nil {
+		return httperror.BadRequest("Cannot find context user", errors.Wrap(err, "failed to fetch the user"))
+	}
+
+	stackFolder := strconv.Itoa(int(stack.ID))
+	files := append([]string{version.EntryPoint}, version.AdditionalFiles...)
+
+	rollbackFiles := func() {
+		for _, file := range files {
+			if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, file); rollbackErr != nil {
+				log.Warn().Err(rollbackErr).Msg("rollback stack file error")
+			}
+		}
+	}
+
+	for _, file := range files {
+		exists, err := handler.FileService.FileExists(filesystem.JoinPaths(stack.ProjectPath, file))
+		if err != nil {
+			rollbackFiles()
+			return httperror.InternalServerError("Unable to verify the stack file on disk", err)
+		}
+
+		if exists {
+			_, err = handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, file, []byte(version.FileContents[file]))
+		} else {
+			_, err = handler.FileService.StoreStackFileFromBytes(stackFolder, file, []byte(version.FileContents[file]))
+		}
+
+		if err != nil {
+			rollbackFiles()
+			return httperror.InternalServerError("Unable to persist the stack file on disk", err)
+		}
+	}
+
+	stack.EntryPoint = version.EntryPoint
+	stack.AdditionalFiles = version.AdditionalFiles
+	stack.Env = version.Env
+	stack.UpdatedBy = user.Username
+
+	httpErr = handler.deployStack(r, stack, false, endpoint)
+	if httpErr != nil {
+		rollbackFiles()
+		return httpErr
+	}
+
+	for _, file := range files {
+		handler.FileService.RemoveStackFileBackup(stackFolder, file)
+	}
+
+	stack.UpdateDate = time.Now().Unix()
+	stack.Status = portainer.StackStatusActive
+
+	err = handler.DataStore.Stack().UpdateStack(stack.ID, stack)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	return response.JSON(w, stack)
+}
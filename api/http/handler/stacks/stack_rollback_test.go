@@ -0,0 +1,40 @@
+package stacks
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_stackRollback_GitStack(t *testing.T) {
+	is := assert.New(t)
+
+	handler, rawAPIKey := setupHandler(t)
+
+	stack := &portainer.Stack{ID: 1, Name: "web", Type: portainer.DockerComposeStack, EndpointID: 1, EntryPoint: "docker-compose.yml",
+		GitConfig: &gittypes.RepoConfig{URL: "https://github.com/portainer/portainer.git", ReferenceName: "refs/heads/main", ConfigHash: "bc4c183d756879ea4d173315338110b31004b8e0"}}
+	createStack(t, handler, stack, "version: '3'\nservices:\n  web:\n    image: nginx:1.24\n")
+
+	is.NoError(handler.DataStore.StackVersion().Create(&portainer.StackVersion{
+		StackID:      stack.ID,
+		Version:      1,
+		EntryPoint:   "docker-compose.yml",
+		FileContents: map[string]string{"docker-compose.yml": "version: '3'\nservices:\n  web:\n    image: nginx:1.23\n"},
+		ConfigHash:   "9f0d2d8c4b4bb2c0a5d7f6e4e3c2b1a0f9e8d7c6",
+	}))
+
+	rr := serveJSON(handler, rawAPIKey, http.MethodPost, "/stacks/1/rollback/1", nil)
+	is.Equal(http.StatusBadRequest, rr.Code, "a git stack can't be rolled back to the stored files of a previous commit")
+
+	content, err := os.ReadFile(stack.ProjectPath + "/docker-compose.yml")
+	is.NoError(err)
+	is.Contains(string(content), "nginx:1.24", "the files of the repository should not be modified")
+
+	stack, err = handler.DataStore.Stack().Stack(stack.ID)
+	is.NoError(err)
+	is.Equal("bc4c183d756879ea4d173315338110b31004b8e0", stack.GitConfig.ConfigHash)
+}
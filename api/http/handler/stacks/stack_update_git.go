@@ -1,7 +1,10 @@
 package stacks
 
 import (
+	"bytes"
+	"io"
 	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/pkg/errors"
@@ -9,6 +12,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/git/update"
 	httperrors "github.com/portainer/portainer/api/http/errors"
@@ -25,14 +29,18 @@ type stackGitUpdatePayload struct {
 	RepositoryAuthentication bool
 	RepositoryUsername       string
 	RepositoryPassword       string
-	TLSSkipVerify            bool
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+	// Tag constraint and trusted keys of the signatures, the tag is resolved on the next redeployment
+	git.PinningPayload
+	TLSSkipVerify bool
 }
 
 func (payload *stackGitUpdatePayload) Validate(r *http.Request) error {
 	if err := update.ValidateAutoUpdateSettings(payload.AutoUpdate); err != nil {
 		return err
 	}
-	return nil
+	return payload.PinningPayload.Validate()
 }
 
 // @id StackUpdateGit
@@ -59,6 +67,13 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		return httperror.BadRequest("Invalid stack identifier route variable", err)
 	}
 
+	// the payload is read again when the update is deferred
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return httperror.BadRequest("Unable to read the request payload", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
 	var payload stackGitUpdatePayload
 	err = request.DecodeAndValidateJSONPayload(r, &payload)
 	if err != nil {
@@ -132,6 +147,14 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		return httperror.Forbidden(errMsg, errors.New(errMsg))
 	}
 
+	// the auto updates deploy the changes of the repository without any review, enabling them requires an approval
+	if autoUpdateChanged(stack, &payload) {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if deferred, httpErr := handler.deferDeployment(w, r, portainer.PendingDeploymentUpdateGit, endpoint, stack); httpErr != nil || deferred {
+			return httpErr
+		}
+	}
+
 	//stop the autoupdate job if there is any
 	if stack.AutoUpdate != nil {
 		deployments.StopAutoupdate(stack.ID, stack.AutoUpdate.JobID, handler.Scheduler)
@@ -140,6 +163,12 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 	//update retrieved stack data based on the payload
 	stack.GitConfig.ReferenceName = payload.RepositoryReferenceName
 	stack.GitConfig.TLSSkipVerify = payload.TLSSkipVerify
+	payload.PinningPayload.Apply(stack.GitConfig)
+	// the last push is kept to show which commit was deployed by the webhook
+	if payload.AutoUpdate != nil && stack.AutoUpdate != nil {
+		payload.AutoUpdate.LastWebhookPush = stack.AutoUpdate.LastWebhookPush
+	}
+	update.KeepSavedWebhookSecret(payload.AutoUpdate, stack.AutoUpdate)
 	stack.AutoUpdate = payload.AutoUpdate
 	stack.Env = payload.Env
 	stack.UpdatedBy = user.Username
@@ -152,18 +181,21 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 	}
 
 	if payload.RepositoryAuthentication {
-		password := payload.RepositoryPassword
+		auth := payload.CredentialsPayload.Authentication(true, payload.RepositoryUsername, payload.RepositoryPassword)
 
-		// When the existing stack is using the custom username/password and the password is not updated,
-		// the stack should keep using the saved username/password
-		if password == "" && stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
-			password = stack.GitConfig.Authentication.Password
-		}
-		stack.GitConfig.Authentication = &gittypes.GitAuthentication{
-			Username: payload.RepositoryUsername,
-			Password: password,
+		// When the existing stack is using custom credentials and the secrets are not updated,
+		// the stack should keep using the saved secrets
+		git.KeepSavedSecrets(auth, stack.GitConfig.Authentication)
+
+		// the basic authentication can be used without password
+		if auth.AuthorizationType != gittypes.GitCredentialAuthTypeBasic {
+			if err := git.ValidateRepoAuthentication(auth); err != nil {
+				return httperror.BadRequest("Invalid git credentials", err)
+			}
 		}
-		_, err = handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication.Username, stack.GitConfig.Authentication.Password, stack.GitConfig.TLSSkipVerify)
+
+		stack.GitConfig.Authentication = auth
+		_, err = handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication, stack.GitConfig.TLSSkipVerify)
 		if err != nil {
 			return httperror.InternalServerError("Unable to fetch git repository", err)
 		}
@@ -186,10 +218,36 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		// sanitize the password and the private keys in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.HideSecrets()
 	}
 
+	update.HideWebhookSecret(stack.AutoUpdate)
+
 	return response.JSON(w, stack)
 }
+
+// autoUpdateEnabled returns true when the stack is redeployed periodically or by webhook
+func autoUpdateEnabled(autoUpdate *portainer.AutoUpdateSettings) bool {
+	return autoUpdate != nil && (autoUpdate.Interval != "" || autoUpdate.Webhook != "")
+}
+
+// autoUpdateChanged returns true when the update enables the auto update of the stack, or changes what the enabled
+// auto update deploys
+func autoUpdateChanged(stack *portainer.Stack, payload *stackGitUpdatePayload) bool {
+	if !autoUpdateEnabled(payload.AutoUpdate) {
+		return false
+	}
+
+	if !autoUpdateEnabled(stack.AutoUpdate) {
+		return true
+	}
+
+	return payload.AutoUpdate.Interval != stack.AutoUpdate.Interval ||
+		payload.AutoUpdate.Webhook != stack.AutoUpdate.Webhook ||
+		payload.AutoUpdate.ForceUpdate != stack.AutoUpdate.ForceUpdate ||
+		payload.RepositoryReferenceName != stack.GitConfig.ReferenceName ||
+		payload.RepositoryTagConstraint != stack.GitConfig.TagConstraint ||
+		!reflect.DeepEqual(payload.RepositorySignatureVerification, stack.GitConfig.SignatureVerification)
+}
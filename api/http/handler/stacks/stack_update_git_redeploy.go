@@ -10,9 +10,10 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/git"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/git/update"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
-	k "github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 )
@@ -22,8 +23,10 @@ type stackGitRedployPayload struct {
 	RepositoryAuthentication bool
 	RepositoryUsername       string
 	RepositoryPassword       string
-	Env                      []portainer.Pair
-	Prune                    bool
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+	Env   []portainer.Pair
+	Prune bool
 	// Force a pulling to current image with the original tag though the image is already the latest
 	PullImage bool `example:"false"`
 }
@@ -121,6 +124,10 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		return httperror.Forbidden(errMsg, errors.New(errMsg))
 	}
 
+	if deferred, httpErr := handler.deferDeployment(w, r, portainer.PendingDeploymentRedeploy, endpoint, stack); httpErr != nil || deferred {
+		return httpErr
+	}
+
 	var payload stackGitRedployPayload
 	err = request.DecodeAndValidateJSONPayload(r, &payload)
 	if err != nil {
@@ -135,43 +142,65 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	repositoryUsername := ""
-	repositoryPassword := ""
-	if payload.RepositoryAuthentication {
-		repositoryPassword = payload.RepositoryPassword
+	auth := payload.CredentialsPayload.Authentication(payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)
+
+	// When the existing stack is using custom credentials and the secrets are not updated,
+	// the stack should keep using the saved secrets
+	git.KeepSavedSecrets(auth, stack.GitConfig.Authentication)
+
+	// the authentication of the payload is only used for this redeployment
+	gitConfig := *stack.GitConfig
+	gitConfig.Authentication = auth
 
-		// When the existing stack is using the custom username/password and the password is not updated,
-		// the stack should keep using the saved username/password
-		if repositoryPassword == "" && stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
-			repositoryPassword = stack.GitConfig.Authentication.Password
+	err = update.ResolveTagConstraint(handler.GitService, &gitConfig)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the tag of the git repository", err)
+	}
+	stack.GitConfig.ReferenceName = gitConfig.ReferenceName
+
+	verifiedHash, err := update.VerifySignature(handler.GitService, &gitConfig)
+	if err != nil {
+		return httperror.BadRequest("Unable to verify the signature of the git repository", err)
+	}
+
+	commitHash := verifiedHash
+	unexpectedCommitMessage := "The git repository was updated during the verification of its signature"
+
+	// the approved redeployments deploy the commit which was reviewed
+	if reviewedHash := approvedConfigHash(r); reviewedHash != "" {
+		if verifiedHash != "" && verifiedHash != reviewedHash {
+			return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The git repository was updated since the deployment was reviewed", Err: gittypes.ErrUnexpectedCommit}
 		}
-		repositoryUsername = payload.RepositoryUsername
+
+		commitHash = reviewedHash
+		unexpectedCommitMessage = "The git repository was updated since the deployment was reviewed"
 	}
 
 	cloneOptions := git.CloneOptions{
-		ProjectPath:   stack.ProjectPath,
-		URL:           stack.GitConfig.URL,
-		ReferenceName: stack.GitConfig.ReferenceName,
-		Username:      repositoryUsername,
-		Password:      repositoryPassword,
-		TLSSkipVerify: stack.GitConfig.TLSSkipVerify,
+		ProjectPath:    stack.ProjectPath,
+		URL:            stack.GitConfig.URL,
+		ReferenceName:  stack.GitConfig.ReferenceName,
+		Authentication: auth,
+		TLSSkipVerify:  stack.GitConfig.TLSSkipVerify,
+		// the files are only deployed when they are the ones whose signature was verified
+		CommitHash: commitHash,
 	}
 
 	clean, err := git.CloneWithBackup(handler.GitService, handler.FileService, cloneOptions)
-	if err != nil {
+	if errors.Is(err, gittypes.ErrUnexpectedCommit) {
+		return httperror.InternalServerError(unexpectedCommitMessage, err)
+	} else if err != nil {
 		return httperror.InternalServerError("Unable to clone git repository directory", err)
 	}
 
 	defer clean()
 
-	httpErr := handler.deployStack(r, stack, payload.PullImage, endpoint)
-	if httpErr != nil {
-		return httpErr
-	}
-
-	newHash, err := handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, repositoryUsername, repositoryPassword, stack.GitConfig.TLSSkipVerify)
-	if err != nil {
-		return httperror.InternalServerError("Unable get latest commit id", errors.WithMessagef(err, "failed to fetch latest commit id of the stack %v", stack.ID))
+	newHash := commitHash
+	if newHash == "" {
+		newHash, err = handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, auth, stack.GitConfig.TLSSkipVerify)
+		if err != nil {
+			return httperror.InternalServerError("Unable get latest commit id", errors.WithMessagef(err, "failed to fetch latest commit id of the stack %v", stack.ID))
+		}
 	}
 	stack.GitConfig.ConfigHash = newHash
 
@@ -180,6 +209,12 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		return httperror.BadRequest("Cannot find context user", errors.Wrap(err, "failed to fetch the user"))
 	}
 	stack.UpdatedBy = user.Username
+
+	httpErr := handler.deployStack(r, stack, payload.PullImage, endpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+
 	stack.UpdateDate = time.Now().Unix()
 	stack.Status = portainer.StackStatusActive
 
@@ -188,11 +223,13 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", errors.Wrap(err, "failed to update the stack"))
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		// sanitize the password and the private keys in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.HideSecrets()
 	}
 
+	update.HideWebhookSecret(stack.AutoUpdate)
+
 	return response.JSON(w, stack)
 }
 
@@ -245,17 +282,13 @@ func (handler *Handler) deployStack(r *http.Request, stack *portainer.Stack, pul
 			Username: tokenData.Username,
 		}
 
-		appLabel := k.KubeAppLabels{
-			StackID:   int(stack.ID),
-			StackName: stack.Name,
-			Owner:     tokenData.Username,
-			Kind:      "git",
-		}
-
-		deploymentConfiger, err = deployments.CreateKubernetesStackDeploymentConfig(stack, handler.KubernetesDeployer, appLabel, user, endpoint)
+		// deployed through the stack deployer to record the version in the stack history
+		err = handler.StackDeployer.DeployKubernetesStack(stack, endpoint, user)
 		if err != nil {
 			return httperror.InternalServerError(err.Error(), err)
 		}
+
+		return nil
 	default:
 		return httperror.InternalServerError("Unsupported stack", errors.Errorf("unsupported stack type: %v", stack.Type))
 	}
@@ -0,0 +1,175 @@
+package stacks
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git/update"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+)
+
+type stackHistoryRetentionPayload struct {
+	// Number of deployed versions kept in the stack history, 0 uses the default retention
+	HistoryRetention int `example:"10"`
+}
+
+func (payload *stackHistoryRetentionPayload) Validate(r *http.Request) error {
+	if payload.HistoryRetention < 0 {
+		return errors.New("Invalid history retention. Must be a positive number")
+	}
+
+	return nil
+}
+
+// @id StackVersionList
+// @summary List the versions of a stack
+// @description List the versions recorded in the deployment history of a stack, ordered by version number.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {array} portainer.StackVersion "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions [get]
+func (handler *Handler) stackVersionList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, _, httpErr := handler.retrieveManageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	versions, err := handler.DataStore.StackVersion().StackVersionsByStackID(stack.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the stack versions from the database", err)
+	}
+
+	return response.JSON(w, versions)
+}
+
+// @id StackHistoryRetentionUpdate
+// @summary Update the history retention of a stack
+// @description Update the number of deployed versions kept in the history of a stack.
+// @description The oldest versions beyond the new retention are removed.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack identifier"
+// @param body body stackHistoryRetentionPayload true "History retention"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions/retention [put]
+func (handler *Handler) stackHistoryRetentionUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload stackHistoryRetentionPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	stack, _, httpErr := handler.retrieveManageableStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	stack.HistoryRetention = payload.HistoryRetention
+
+	err = handler.DataStore.Stack().UpdateStack(stack.ID, stack)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	versions, err := handler.DataStore.StackVersion().StackVersionsByStackID(stack.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the stack versions from the database", err)
+	}
+
+	err = deployments.PruneStackVersions(handler.DataStore, versions, stack.HistoryRetention)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the stack versions beyond the retention", err)
+	}
+
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		// sanitize the password and the private keys in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.HideSecrets()
+	}
+
+	update.HideWebhookSecret(stack.AutoUpdate)
+
+	return response.JSON(w, stack)
+}
+
+// retrieveManageableStack retrieves the stack targeted by the request along with its environment(endpoint)
+// and verifies that the user is allowed to manage it
+func (handler *Handler) retrieveManageableStack(r *http.Request) (*portainer.Stack, *portainer.Endpoint, *httperror.HandlerError) {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, nil, httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Stack(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find the environment associated to the stack inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find the environment associated to the stack inside the database", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return nil, nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	//only check resource control when it is a DockerSwarmStack or a DockerComposeStack
+	if stack.Type == portainer.DockerSwarmStack || stack.Type == portainer.DockerComposeStack {
+		resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+		if err != nil {
+			return nil, nil, httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
+		}
+
+		access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
+		if err != nil {
+			return nil, nil, httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+		}
+		if !access {
+			return nil, nil, httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+		}
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to verify user authorizations to validate stack management", err)
+	}
+	if !canManage {
+		errMsg := "Stack management is disabled for non-admin users"
+		return nil, nil, httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	return stack, endpoint, nil
+}
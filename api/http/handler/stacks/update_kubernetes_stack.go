@@ -10,6 +10,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
@@ -30,8 +31,12 @@ type kubernetesGitStackUpdatePayload struct {
 	RepositoryAuthentication bool
 	RepositoryUsername       string
 	RepositoryPassword       string
-	AutoUpdate               *portainer.AutoUpdateSettings
-	TLSSkipVerify            bool
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+	// Tag constraint and trusted keys of the signatures, the tag is resolved on the next redeployment
+	git.PinningPayload
+	AutoUpdate    *portainer.AutoUpdateSettings
+	TLSSkipVerify bool
 }
 
 func (payload *kubernetesFileStackUpdatePayload) Validate(r *http.Request) error {
@@ -45,7 +50,7 @@ func (payload *kubernetesGitStackUpdatePayload) Validate(r *http.Request) error
 	if err := update.ValidateAutoUpdateSettings(payload.AutoUpdate); err != nil {
 		return err
 	}
-	return nil
+	return payload.PinningPayload.Validate()
 }
 
 func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint) *httperror.HandlerError {
@@ -64,18 +69,26 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 
 		stack.GitConfig.ReferenceName = payload.RepositoryReferenceName
 		stack.GitConfig.TLSSkipVerify = payload.TLSSkipVerify
+		payload.PinningPayload.Apply(stack.GitConfig)
+		// the last push is kept to show which commit was deployed by the webhook
+		if payload.AutoUpdate != nil && stack.AutoUpdate != nil {
+			payload.AutoUpdate.LastWebhookPush = stack.AutoUpdate.LastWebhookPush
+		}
 		stack.AutoUpdate = payload.AutoUpdate
 
 		if payload.RepositoryAuthentication {
-			password := payload.RepositoryPassword
-			if password == "" && stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
-				password = stack.GitConfig.Authentication.Password
-			}
-			stack.GitConfig.Authentication = &gittypes.GitAuthentication{
-				Username: payload.RepositoryUsername,
-				Password: password,
+			auth := payload.CredentialsPayload.Authentication(true, payload.RepositoryUsername, payload.RepositoryPassword)
+			git.KeepSavedSecrets(auth, stack.GitConfig.Authentication)
+
+			// the basic authentication can be used without password
+			if auth.AuthorizationType != gittypes.GitCredentialAuthTypeBasic {
+				if err := git.ValidateRepoAuthentication(auth); err != nil {
+					return httperror.BadRequest("Invalid git credentials", err)
+				}
 			}
-			_, err := handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication.Username, stack.GitConfig.Authentication.Password, stack.GitConfig.TLSSkipVerify)
+
+			stack.GitConfig.Authentication = auth
+			_, err := handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication, stack.GitConfig.TLSSkipVerify)
 			if err != nil {
 				return httperror.InternalServerError("Unable to fetch git repository", err)
 			}
@@ -146,5 +159,9 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 
 	handler.FileService.RemoveStackFileBackup(stackFolder, stack.EntryPoint)
 
+	if _, err := deployments.RecordStackVersion(handler.DataStore, handler.FileService, stack, tokenData.Username); err != nil {
+		log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to record the stack version")
+	}
+
 	return nil
 }
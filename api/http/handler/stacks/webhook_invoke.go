@@ -3,22 +3,32 @@ package stacks
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	gitwebhook "github.com/portainer/portainer/api/git/webhook"
 	"github.com/portainer/portainer/api/stacks/deployments"
 
 	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 // @id WebhookInvoke
 // @summary Webhook for triggering stack updates from git
+// @description The push webhooks of GitHub, GitLab, Gitea and Bitbucket only redeploy the stack when the pushed reference
+// @description and the changed files match the git configuration of the stack. The calls must be signed with the webhook secret
+// @description of the stack when it is set.
+// @description In the environments requiring an approval of the deployments, the webhooks of the non-administrators are
+// @description only enabled once their update is approved.
 // @description **Access policy**: public
 // @tags stacks
 // @param webhookID path string true "Stack identifier"
 // @success 200 "Success"
 // @failure 400 "Invalid request"
+// @failure 401 "Invalid webhook signature"
 // @failure 409 "Conflict"
 // @failure 500 "Server error"
 // @router /stacks/webhooks/{webhookID} [post]
@@ -38,6 +48,36 @@ func (handler *Handler) webhookInvoke(w http.ResponseWriter, r *http.Request) *h
 		return &httperror.HandlerError{StatusCode: statusCode, Message: "Unable to find the stack by webhook ID", Err: err}
 	}
 
+	secret := ""
+	if stack.AutoUpdate != nil {
+		secret = stack.AutoUpdate.WebhookSecret
+	}
+
+	push, err := gitwebhook.ReadPush(r, secret)
+	if errors.Is(err, gitwebhook.ErrNotPush) {
+		return response.Empty(w)
+	} else if gitwebhook.IsSignatureError(err) {
+		return &httperror.HandlerError{StatusCode: http.StatusUnauthorized, Message: "Invalid webhook signature", Err: err}
+	} else if err != nil {
+		return httperror.BadRequest("Invalid webhook payload", err)
+	}
+
+	if push != nil && stack.KubernetesBuild != nil {
+		// the kustomize bases and the chart dependencies can be anywhere in the repository
+		push.FilesKnown = false
+	}
+
+	if push != nil && !push.Affects(stack.GitConfig, append([]string{stack.EntryPoint}, stack.AdditionalFiles...)) {
+		log.Debug().Int("stack_id", int(stack.ID)).Str("ref", push.Ref).Str("commit", push.Commit).Msg("the push does not affect the stack, skipping the redeployment")
+
+		return response.Empty(w)
+	}
+
+	previousHash := ""
+	if stack.GitConfig != nil {
+		previousHash = stack.GitConfig.ConfigHash
+	}
+
 	if err = deployments.RedeployWhenChanged(stack.ID, handler.StackDeployer, handler.DataStore, handler.GitService); err != nil {
 		var StackAuthorMissingErr *deployments.StackAuthorMissingErr
 		if errors.As(err, &StackAuthorMissingErr) {
@@ -47,9 +87,32 @@ func (handler *Handler) webhookInvoke(w http.ResponseWriter, r *http.Request) *h
 		return httperror.InternalServerError("Failed to update the stack", err)
 	}
 
+	if push != nil {
+		err = handler.recordWebhookPush(stack.ID, previousHash, push)
+		if err != nil {
+			return httperror.InternalServerError("Unable to persist the webhook push inside the database", err)
+		}
+	}
+
 	return response.Empty(w)
 }
 
+// recordWebhookPush saves the push on the stack when it was redeployed
+func (handler *Handler) recordWebhookPush(stackID portainer.StackID, previousHash string, push *gitwebhook.PushEvent) error {
+	stack, err := handler.DataStore.Stack().Stack(stackID)
+	if err != nil {
+		return err
+	}
+
+	if stack.AutoUpdate == nil || stack.GitConfig == nil || stack.GitConfig.ConfigHash == previousHash {
+		return nil
+	}
+
+	stack.AutoUpdate.LastWebhookPush = push.Record(time.Now())
+
+	return handler.DataStore.Stack().UpdateStack(stack.ID, stack)
+}
+
 func retrieveUUIDRouteVariableValue(r *http.Request, name string) (uuid.UUID, error) {
 	webhookID, err := request.RetrieveRouteVariableValue(r, name)
 	if err != nil {
@@ -26,6 +26,7 @@ var (
 
 func hideFields(user *portainer.User) {
 	user.Password = ""
+	user.TOTP = portainer.UserTOTP{Enabled: user.TOTP.Enabled}
 }
 
 // Handler is the HTTP handler used to handle user operations.
@@ -74,6 +75,9 @@ func NewHandler(bouncer *security.RequestBouncer, rateLimiter *security.RateLimi
 	restrictedRouter.Handle("/users/{id}/tokens", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userCreateAccessToken))).Methods(http.MethodPost)
 	restrictedRouter.Handle("/users/{id}/tokens/{keyID}", httperror.LoggerHandler(h.userRemoveAccessToken)).Methods(http.MethodDelete)
 	restrictedRouter.Handle("/users/{id}/memberships", httperror.LoggerHandler(h.userMemberships)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/users/{id}/totp", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userTOTPEnrol))).Methods(http.MethodPost)
+	authenticatedRouter.Handle("/users/{id}/totp/activate", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userTOTPActivate))).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/totp", httperror.LoggerHandler(h.userTOTPReset)).Methods(http.MethodDelete)
 	authenticatedRouter.Handle("/users/{id}/passwd", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userUpdatePassword))).Methods(http.MethodPut)
 	publicRouter.Handle("/users/admin/check", httperror.LoggerHandler(h.adminCheck)).Methods(http.MethodGet)
 	publicRouter.Handle("/users/admin/init", httperror.LoggerHandler(h.adminInit)).Methods(http.MethodPost)
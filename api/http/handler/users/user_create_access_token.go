@@ -3,6 +3,7 @@ package users
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	httperror "github.com/portainer/libhttp/error"
@@ -15,6 +16,8 @@ import (
 
 type userAccessTokenCreatePayload struct {
 	Description string `validate:"required" example:"github-api-key" json:"description"`
+	// Restrictions of the API key, the key grants the full rights of the user when omitted
+	Scope portainer.APIKeyScope `json:"scope"`
 }
 
 func (payload *userAccessTokenCreatePayload) Validate(r *http.Request) error {
@@ -27,6 +30,9 @@ func (payload *userAccessTokenCreatePayload) Validate(r *http.Request) error {
 	if govalidator.MinStringLength(payload.Description, "128") {
 		return errors.New("invalid description. cannot be longer than 128 characters")
 	}
+	if payload.Scope.ExpiresAt < 0 || (payload.Scope.ExpiresAt > 0 && payload.Scope.ExpiresAt <= time.Now().Unix()) {
+		return errors.New("invalid expiry date. must be in the future")
+	}
 	return nil
 }
 
@@ -39,6 +45,7 @@ type accessTokenResponse struct {
 // @summary Generate an API key for a user
 // @description Generates an API key for a user.
 // @description Only the calling user can generate a token for themselves.
+// @description The key can be restricted with an expiry date, a list of environments, a read-only flag and a subset of the authorizations.
 // @description **Access policy**: restricted
 // @tags users
 // @security jwt
@@ -84,7 +91,14 @@ func (handler *Handler) userCreateAccessToken(w http.ResponseWriter, r *http.Req
 		return httperror.BadRequest("Unable to find a user", err)
 	}
 
-	rawAPIKey, apiKey, err := handler.apiKeyService.GenerateApiKey(*user, payload.Description)
+	for _, endpointID := range payload.Scope.EndpointIDs {
+		_, err := handler.DataStore.Endpoint().Endpoint(endpointID)
+		if err != nil {
+			return httperror.BadRequest("Unable to find an environment with the specified identifier inside the database", err)
+		}
+	}
+
+	rawAPIKey, apiKey, err := handler.apiKeyService.GenerateScopedApiKey(*user, payload.Description, payload.Scope)
 	if err != nil {
 		return httperror.InternalServerError("Internal Server Error", err)
 	}
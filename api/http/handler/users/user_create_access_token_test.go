@@ -144,6 +144,14 @@ this string is longer than 128 characters and hence this will fail.
 `},
 			shouldFail: true,
 		},
+		{
+			payload:    userAccessTokenCreatePayload{Description: "test-token", Scope: portainer.APIKeyScope{ExpiresAt: time.Now().Add(time.Hour).Unix()}},
+			shouldFail: false,
+		},
+		{
+			payload:    userAccessTokenCreatePayload{Description: "test-token", Scope: portainer.APIKeyScope{ExpiresAt: time.Now().Add(-time.Hour).Unix()}},
+			shouldFail: true,
+		},
 	}
 
 	for _, test := range tests {
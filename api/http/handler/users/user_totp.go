@@ -0,0 +1,178 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/totp"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type userTOTPActivatePayload struct {
+	// Code generated by the authenticator application
+	Code string `example:"123456" validate:"required"`
+}
+
+func (payload *userTOTPActivatePayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Code) {
+		return errors.New("Invalid TOTP code")
+	}
+
+	return nil
+}
+
+// @id UserTOTPEnrol
+// @summary Enrol a user in TOTP
+// @description Generate a new TOTP secret and new recovery codes for the calling user.
+// @description The TOTP is enabled once a code generated with the secret is sent to /users/{id}/totp/activate. The recovery codes are only returned once.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} totp.Enrolment "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 409 "TOTP already enabled"
+// @failure 500 "Server error"
+// @router /users/{id}/totp [post]
+func (handler *Handler) userTOTPEnrol(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.retrieveCallingUser(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	enrolment, err := totp.Enrol(user, handler.CryptoService)
+	if errors.Is(err, totp.ErrTOTPAlreadyEnabled) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "TOTP is already enabled for this user", Err: err}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to enrol the user", err)
+	}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return response.JSON(w, enrolment)
+}
+
+// @id UserTOTPActivate
+// @summary Enable the TOTP of a user
+// @description Enable the TOTP of the calling user with a first code generated with the secret of the enrolment.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param id path int true "User identifier"
+// @param body body userTOTPActivatePayload true "details"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/totp/activate [post]
+func (handler *Handler) userTOTPActivate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload userTOTPActivatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, httpErr := handler.retrieveCallingUser(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err = totp.Activate(user, payload.Code, time.Now())
+	if err != nil {
+		return httperror.BadRequest("Unable to enable the TOTP", err)
+	}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id UserTOTPReset
+// @summary Reset the TOTP of a user
+// @description Disable the TOTP of a user and remove its secret and recovery codes, for instance when the user lost its authenticator application.
+// @description The user enrols again during the next login when the TOTP is enforced.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/totp [delete]
+func (handler *Handler) userTOTPReset(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	user, err := handler.DataStore.User().User(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	user.TOTP = portainer.UserTOTP{}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+// retrieveCallingUser returns the user of the route when it is the calling user, the TOTP of a user
+// can only be configured by the user itself
+func (handler *Handler) retrieveCallingUser(r *http.Request) (*portainer.User, *httperror.HandlerError) {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	if handler.demoService.IsDemoUser(portainer.UserID(userID)) {
+		return nil, httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.ID != portainer.UserID(userID) {
+		return nil, httperror.Forbidden("Permission denied to configure the TOTP of another user", httperrors.ErrUnauthorized)
+	}
+
+	user, err := handler.DataStore.User().User(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,355 @@
+package docker
+
+import (
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+// authorizedAPIKeyScope verifies that the request is allowed by the scope of the API key used to authenticate it, if any
+func authorizedAPIKeyScope(request *http.Request, requestPath string) bool {
+	tokenData, err := security.RetrieveTokenData(request)
+	if err != nil || tokenData.APIKeyScope == nil {
+		return true
+	}
+
+	if !security.AuthorizedAPIKeyMethod(tokenData.APIKeyScope, request) {
+		return false
+	}
+
+	return security.AuthorizedAPIKeyOperation(tokenData.APIKeyScope, dockerOperationAuthorization(request.Method, requestPath))
+}
+
+// dockerOperationAuthorization returns the authorization required by a Docker API request,
+// the path of the request must be stripped of the API version
+func dockerOperationAuthorization(method, requestPath string) portainer.Authorization {
+	parts := strings.Split(strings.Trim(requestPath, "/"), "/")
+	if len(parts) == 0 {
+		return portainer.OperationDockerUndefined
+	}
+
+	resource, action := parts[0], ""
+	if len(parts) > 1 {
+		action = parts[len(parts)-1]
+	}
+
+	switch resource {
+	case "containers":
+		return containerOperationAuthorization(method, parts, action)
+	case "images":
+		return imageOperationAuthorization(method, parts, action)
+	case "networks":
+		return resourceOperationAuthorization(method, parts, action, map[string]portainer.Authorization{
+			"list":       portainer.OperationDockerNetworkList,
+			"inspect":    portainer.OperationDockerNetworkInspect,
+			"create":     portainer.OperationDockerNetworkCreate,
+			"prune":      portainer.OperationDockerNetworkPrune,
+			"delete":     portainer.OperationDockerNetworkDelete,
+			"connect":    portainer.OperationDockerNetworkConnect,
+			"disconnect": portainer.OperationDockerNetworkDisconnect,
+		})
+	case "volumes":
+		return resourceOperationAuthorization(method, parts, action, map[string]portainer.Authorization{
+			"list":    portainer.OperationDockerVolumeList,
+			"inspect": portainer.OperationDockerVolumeInspect,
+			"create":  portainer.OperationDockerVolumeCreate,
+			"prune":   portainer.OperationDockerVolumePrune,
+			"delete":  portainer.OperationDockerVolumeDelete,
+		})
+	case "services":
+		return resourceOperationAuthorization(method, parts, action, map[string]portainer.Authorization{
+			"list":    portainer.OperationDockerServiceList,
+			"inspect": portainer.OperationDockerServiceInspect,
+			"create":  portainer.OperationDockerServiceCreate,
+			"update":  portainer.OperationDockerServiceUpdate,
+			"delete":  portainer.OperationDockerServiceDelete,
+			"logs":    portainer.OperationDockerServiceLogs,
+		})
+	case "secrets":
+		return resourceOperationAuthorization(method, parts, action, map[string]portainer.Authorization{
+			"list":    portainer.OperationDockerSecretList,
+			"inspect": portainer.OperationDockerSecretInspect,
+			"create":  portainer.OperationDockerSecretCreate,
+			"update":  portainer.OperationDockerSecretUpdate,
+			"delete":  portainer.OperationDockerSecretDelete,
+		})
+	case "configs":
+		return resourceOperationAuthorization(method, parts, action, map[string]portainer.Authorization{
+			"list":    portainer.OperationDockerConfigList,
+			"inspect": portainer.OperationDockerConfigInspect,
+			"create":  portainer.OperationDockerConfigCreate,
+			"update":  portainer.OperationDockerConfigUpdate,
+			"delete":  portainer.OperationDockerConfigDelete,
+		})
+	case "nodes":
+		return resourceOperationAuthorization(method, parts, action, map[string]portainer.Authorization{
+			"list":    portainer.OperationDockerNodeList,
+			"inspect": portainer.OperationDockerNodeInspect,
+			"update":  portainer.OperationDockerNodeUpdate,
+			"delete":  portainer.OperationDockerNodeDelete,
+		})
+	case "tasks":
+		return resourceOperationAuthorization(method, parts, action, map[string]portainer.Authorization{
+			"list":    portainer.OperationDockerTaskList,
+			"inspect": portainer.OperationDockerTaskInspect,
+			"logs":    portainer.OperationDockerTaskLogs,
+		})
+	case "plugins":
+		return pluginOperationAuthorization(method, parts, action)
+	case "exec":
+		switch action {
+		case "start":
+			return portainer.OperationDockerExecStart
+		case "resize":
+			return portainer.OperationDockerExecResize
+		case "json":
+			return portainer.OperationDockerExecInspect
+		}
+	case "swarm":
+		switch {
+		case len(parts) == 1 && method == http.MethodGet:
+			return portainer.OperationDockerSwarmInspect
+		case action == "unlockkey":
+			return portainer.OperationDockerSwarmUnlockKey
+		case action == "init":
+			return portainer.OperationDockerSwarmInit
+		case action == "join":
+			return portainer.OperationDockerSwarmJoin
+		case action == "leave":
+			return portainer.OperationDockerSwarmLeave
+		case action == "update":
+			return portainer.OperationDockerSwarmUpdate
+		case action == "unlock":
+			return portainer.OperationDockerSwarmUnlock
+		}
+	case "build":
+		switch action {
+		case "":
+			return portainer.OperationDockerImageBuild
+		case "prune":
+			return portainer.OperationDockerBuildPrune
+		case "cancel":
+			return portainer.OperationDockerBuildCancel
+		}
+	case "commit":
+		return portainer.OperationDockerImageCommit
+	case "session":
+		return portainer.OperationDockerSessionStart
+	case "distribution":
+		return portainer.OperationDockerDistributionInspect
+	case "_ping":
+		return portainer.OperationDockerPing
+	case "info":
+		return portainer.OperationDockerInfo
+	case "events":
+		return portainer.OperationDockerEvents
+	case "system":
+		return portainer.OperationDockerSystem
+	case "version":
+		return portainer.OperationDockerVersion
+	case "v2":
+		return agentOperationAuthorization(method, parts)
+	}
+
+	return portainer.OperationDockerUndefined
+}
+
+// resourceOperationAuthorization maps the requests following the common layout of the Docker API,
+// /<resource>, /<resource>/create, /<resource>/prune and /<resource>/{id}[/<action>], to the authorizations
+func resourceOperationAuthorization(method string, parts []string, action string, authorizations map[string]portainer.Authorization) portainer.Authorization {
+	operation := ""
+
+	switch {
+	case len(parts) == 1 && method == http.MethodGet:
+		operation = "list"
+	case len(parts) == 2 && (action == "create" || action == "prune") && method == http.MethodPost:
+		operation = action
+	case len(parts) == 2 && method == http.MethodGet:
+		operation = "inspect"
+	case len(parts) == 2 && method == http.MethodDelete:
+		operation = "delete"
+	case len(parts) == 3:
+		operation = action
+	}
+
+	authorization, ok := authorizations[operation]
+	if !ok {
+		return portainer.OperationDockerUndefined
+	}
+
+	return authorization
+}
+
+func containerOperationAuthorization(method string, parts []string, action string) portainer.Authorization {
+	switch {
+	case len(parts) == 2 && action == "json" && method == http.MethodGet:
+		return portainer.OperationDockerContainerList
+	case len(parts) == 2 && action == "create":
+		return portainer.OperationDockerContainerCreate
+	case len(parts) == 2 && action == "prune":
+		return portainer.OperationDockerContainerPrune
+	case len(parts) == 2 && method == http.MethodDelete:
+		return portainer.OperationDockerContainerDelete
+	case len(parts) == 4 && parts[2] == "attach" && action == "ws":
+		return portainer.OperationDockerContainerAttachWebsocket
+	case len(parts) != 3:
+		return portainer.OperationDockerUndefined
+	}
+
+	switch action {
+	case "json":
+		return portainer.OperationDockerContainerInspect
+	case "top":
+		return portainer.OperationDockerContainerTop
+	case "logs":
+		return portainer.OperationDockerContainerLogs
+	case "changes":
+		return portainer.OperationDockerContainerChanges
+	case "export":
+		return portainer.OperationDockerContainerExport
+	case "stats":
+		return portainer.OperationDockerContainerStats
+	case "resize":
+		return portainer.OperationDockerContainerResize
+	case "start":
+		return portainer.OperationDockerContainerStart
+	case "stop":
+		return portainer.OperationDockerContainerStop
+	case "restart":
+		return portainer.OperationDockerContainerRestart
+	case "kill":
+		return portainer.OperationDockerContainerKill
+	case "update":
+		return portainer.OperationDockerContainerUpdate
+	case "rename":
+		return portainer.OperationDockerContainerRename
+	case "pause":
+		return portainer.OperationDockerContainerPause
+	case "unpause":
+		return portainer.OperationDockerContainerUnpause
+	case "attach":
+		return portainer.OperationDockerContainerAttach
+	case "wait":
+		return portainer.OperationDockerContainerWait
+	case "exec":
+		return portainer.OperationDockerContainerExec
+	case "archive":
+		switch method {
+		case http.MethodHead:
+			return portainer.OperationDockerContainerArchiveInfo
+		case http.MethodPut:
+			return portainer.OperationDockerContainerPutContainerArchive
+		default:
+			return portainer.OperationDockerContainerArchive
+		}
+	}
+
+	return portainer.OperationDockerUndefined
+}
+
+func imageOperationAuthorization(method string, parts []string, action string) portainer.Authorization {
+	if len(parts) == 2 {
+		switch {
+		case action == "json" && method == http.MethodGet:
+			return portainer.OperationDockerImageList
+		case action == "create":
+			return portainer.OperationDockerImageCreate
+		case action == "search":
+			return portainer.OperationDockerImageSearch
+		case action == "get":
+			return portainer.OperationDockerImageGetAll
+		case action == "load":
+			return portainer.OperationDockerImageLoad
+		case action == "prune":
+			return portainer.OperationDockerImagePrune
+		case method == http.MethodDelete:
+			return portainer.OperationDockerImageDelete
+		}
+
+		return portainer.OperationDockerUndefined
+	}
+
+	// image names can contain slashes, the action is the last part of the path
+	switch {
+	case method == http.MethodDelete:
+		return portainer.OperationDockerImageDelete
+	case action == "json":
+		return portainer.OperationDockerImageInspect
+	case action == "history":
+		return portainer.OperationDockerImageHistory
+	case action == "push":
+		return portainer.OperationDockerImagePush
+	case action == "tag":
+		return portainer.OperationDockerImageTag
+	case action == "get":
+		return portainer.OperationDockerImageGet
+	}
+
+	return portainer.OperationDockerUndefined
+}
+
+func pluginOperationAuthorization(method string, parts []string, action string) portainer.Authorization {
+	switch {
+	case len(parts) == 1:
+		return portainer.OperationDockerPluginList
+	case len(parts) == 2 && action == "privileges":
+		return portainer.OperationDockerPluginPrivileges
+	case len(parts) == 2 && action == "pull":
+		return portainer.OperationDockerPluginPull
+	case len(parts) == 2 && action == "create":
+		return portainer.OperationDockerPluginCreate
+	case method == http.MethodDelete:
+		return portainer.OperationDockerPluginDelete
+	}
+
+	switch action {
+	case "json":
+		return portainer.OperationDockerPluginInspect
+	case "enable":
+		return portainer.OperationDockerPluginEnable
+	case "disable":
+		return portainer.OperationDockerPluginDisable
+	case "push":
+		return portainer.OperationDockerPluginPush
+	case "upgrade":
+		return portainer.OperationDockerPluginUpgrade
+	case "set":
+		return portainer.OperationDockerPluginSet
+	}
+
+	return portainer.OperationDockerUndefined
+}
+
+func agentOperationAuthorization(method string, parts []string) portainer.Authorization {
+	if len(parts) < 2 {
+		return portainer.OperationDockerAgentUndefined
+	}
+
+	switch parts[1] {
+	case "ping":
+		return portainer.OperationDockerAgentPing
+	case "agents":
+		return portainer.OperationDockerAgentList
+	case "host":
+		return portainer.OperationDockerAgentHostInfo
+	case "browse":
+		action := parts[len(parts)-1]
+
+		switch {
+		case action == "ls":
+			return portainer.OperationDockerAgentBrowseList
+		case action == "get":
+			return portainer.OperationDockerAgentBrowseGet
+		case action == "rename":
+			return portainer.OperationDockerAgentBrowseRename
+		case action == "put" || method == http.MethodPost:
+			return portainer.OperationDockerAgentBrowsePut
+		case action == "delete" || method == http.MethodDelete:
+			return portainer.OperationDockerAgentBrowseDelete
+		}
+	}
+
+	return portainer.OperationDockerAgentUndefined
+}
@@ -0,0 +1,64 @@
+package docker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dockerOperationAuthorization(t *testing.T) {
+	is := assert.New(t)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected portainer.Authorization
+	}{
+		{http.MethodGet, "/containers/json", portainer.OperationDockerContainerList},
+		{http.MethodPost, "/containers/create", portainer.OperationDockerContainerCreate},
+		{http.MethodGet, "/containers/abc/logs", portainer.OperationDockerContainerLogs},
+		{http.MethodPost, "/containers/abc/start", portainer.OperationDockerContainerStart},
+		{http.MethodHead, "/containers/abc/archive", portainer.OperationDockerContainerArchiveInfo},
+		{http.MethodPut, "/containers/abc/archive", portainer.OperationDockerContainerPutContainerArchive},
+		{http.MethodDelete, "/containers/abc", portainer.OperationDockerContainerDelete},
+		{http.MethodGet, "/images/json", portainer.OperationDockerImageList},
+		{http.MethodGet, "/images/registry.example.com/org/app/json", portainer.OperationDockerImageInspect},
+		{http.MethodDelete, "/images/registry.example.com/org/app", portainer.OperationDockerImageDelete},
+		{http.MethodGet, "/networks", portainer.OperationDockerNetworkList},
+		{http.MethodPost, "/networks/abc/connect", portainer.OperationDockerNetworkConnect},
+		{http.MethodGet, "/volumes/data", portainer.OperationDockerVolumeInspect},
+		{http.MethodPost, "/volumes/prune", portainer.OperationDockerVolumePrune},
+		{http.MethodPost, "/services/abc/update", portainer.OperationDockerServiceUpdate},
+		{http.MethodGet, "/swarm", portainer.OperationDockerSwarmInspect},
+		{http.MethodPost, "/build", portainer.OperationDockerImageBuild},
+		{http.MethodGet, "/info", portainer.OperationDockerInfo},
+		{http.MethodGet, "/v2/browse/ls", portainer.OperationDockerAgentBrowseList},
+		{http.MethodGet, "/unknown", portainer.OperationDockerUndefined},
+	}
+
+	for _, test := range tests {
+		is.Equal(test.expected, dockerOperationAuthorization(test.method, test.path), "%s %s", test.method, test.path)
+	}
+}
+
+func Test_authorizedAPIKeyScope(t *testing.T) {
+	is := assert.New(t)
+
+	newRequest := func(method, path string, scope *portainer.APIKeyScope) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		return req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, APIKeyScope: scope}))
+	}
+
+	scope := &portainer.APIKeyScope{Authorizations: portainer.Authorizations{portainer.OperationDockerContainerList: true}}
+
+	is.True(authorizedAPIKeyScope(httptest.NewRequest(http.MethodPost, "/containers/create", nil), "/containers/create"))
+	is.True(authorizedAPIKeyScope(newRequest(http.MethodPost, "/containers/create", nil), "/containers/create"))
+	is.True(authorizedAPIKeyScope(newRequest(http.MethodGet, "/containers/json", scope), "/containers/json"))
+	is.False(authorizedAPIKeyScope(newRequest(http.MethodPost, "/containers/create", scope), "/containers/create"))
+	is.False(authorizedAPIKeyScope(newRequest(http.MethodPost, "/containers/abc/stop", &portainer.APIKeyScope{ReadOnly: true}), "/containers/abc/stop"))
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/docker/docker/client"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/admission"
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
@@ -184,17 +185,29 @@ func (transport *Transport) decorateContainerCreationOperation(request *http.Req
 		return nil, err
 	}
 
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+	// the admission policies apply to all the users
+	workload, err := admission.ContainerWorkload(request.URL.Query().Get("name"), body)
+	if err != nil {
+		return nil, err
+	}
+
+	forbiddenByPolicy, err := transport.admitWorkload(workload)
+	if forbiddenByPolicy != nil || err != nil {
+		return forbiddenByPolicy, err
+	}
+
 	if !isAdminOrEndpointAdmin {
 		securitySettings, err := transport.fetchEndpointSecuritySettings()
 		if err != nil {
 			return nil, err
 		}
 
-		body, err := io.ReadAll(request.Body)
-		if err != nil {
-			return nil, err
-		}
-
 		partialContainer := &PartialContainer{}
 		err = json.Unmarshal(body, partialContainer)
 		if err != nil {
@@ -228,8 +241,6 @@ func (transport *Transport) decorateContainerCreationOperation(request *http.Req
 				}
 			}
 		}
-
-		request.Body = io.NopCloser(bytes.NewBuffer(body))
 	}
 
 	response, err := transport.executeDockerRequest(request)
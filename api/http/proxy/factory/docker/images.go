@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/http/proxy/factory/utils"
+)
+
+const imageObjectIdentifier = "Id"
+
+// imageListOperation extracts the response as a JSON array and decorates each image
+// with the summary of its vulnerabilities when it was scanned.
+func (transport *Transport) imageListOperation(response *http.Response, executor *operationExecutor) error {
+	// ImageList response is a JSON array
+	// https://docs.docker.com/engine/api/v1.28/#operation/ImageList
+	responseArray, err := utils.GetResponseAsJSONArray(response)
+	if err != nil {
+		return err
+	}
+
+	scans, err := transport.dataStore.ImageScan().ImageScans()
+	if err != nil {
+		return err
+	}
+
+	if len(scans) == 0 {
+		return utils.RewriteResponse(response, responseArray, http.StatusOK)
+	}
+
+	summaries := make(map[string]interface{}, len(scans))
+	for _, scan := range scans {
+		summaries[scan.Digest] = scan.Summary
+	}
+
+	for _, item := range responseArray {
+		image, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		imageID, _ := image[imageObjectIdentifier].(string)
+		if summary, ok := summaries[imageID]; ok {
+			decorateImageObject(image, "VulnerabilitySummary", summary)
+		}
+	}
+
+	return utils.RewriteResponse(response, responseArray, http.StatusOK)
+}
+
+// imageInspectOperation extracts the response as a JSON object and decorates the image
+// with the result of its last vulnerability scan.
+func (transport *Transport) imageInspectOperation(response *http.Response, executor *operationExecutor) error {
+	// ImageInspect response is a JSON object
+	// https://docs.docker.com/engine/api/v1.28/#operation/ImageInspect
+	responseObject, err := utils.GetResponseAsJSONObject(response)
+	if err != nil {
+		return err
+	}
+
+	imageID, _ := responseObject[imageObjectIdentifier].(string)
+	if imageID != "" {
+		scan, err := transport.dataStore.ImageScan().ImageScan(imageID)
+		if err == nil {
+			decorateImageObject(responseObject, "VulnerabilityScan", scan)
+		} else if !transport.dataStore.IsErrObjectNotFound(err) {
+			return err
+		}
+	}
+
+	return utils.RewriteResponse(response, responseObject, http.StatusOK)
+}
+
+func decorateImageObject(object map[string]interface{}, key string, value interface{}) {
+	portainerMetadata, ok := object["Portainer"].(map[string]interface{})
+	if !ok {
+		portainerMetadata = make(map[string]interface{})
+		object["Portainer"] = portainerMetadata
+	}
+
+	portainerMetadata[key] = value
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/docker/docker/client"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/admission"
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/internal/authorization"
 )
@@ -110,17 +111,24 @@ func (transport *Transport) decorateServiceCreationOperation(request *http.Reque
 		return nil, err
 	}
 
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+	// the admission policies apply to all the users
+	response, err := transport.admitService(body)
+	if response != nil || err != nil {
+		return response, err
+	}
+
 	if !isAdminOrEndpointAdmin {
 		securitySettings, err := transport.fetchEndpointSecuritySettings()
 		if err != nil {
 			return nil, err
 		}
 
-		body, err := io.ReadAll(request.Body)
-		if err != nil {
-			return nil, err
-		}
-
 		partialService := &PartialService{}
 		err = json.Unmarshal(body, partialService)
 		if err != nil {
@@ -135,8 +143,58 @@ func (transport *Transport) decorateServiceCreationOperation(request *http.Reque
 			}
 		}
 
-		request.Body = io.NopCloser(bytes.NewBuffer(body))
 	}
 
 	return transport.replaceRegistryAuthenticationHeader(request)
 }
+
+// decorateServiceUpdateOperation evaluates the updated specification of the service against the admission policies
+// of the environment(endpoint) before the update
+func (transport *Transport) decorateServiceUpdateOperation(request *http.Request, serviceID string) (*http.Response, error) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+	response, err := transport.admitService(body)
+	if response != nil || err != nil {
+		return response, err
+	}
+
+	transport.decorateRegistryAuthenticationHeader(request)
+
+	return transport.restrictedResourceOperation(request, serviceID, serviceID, portainer.ServiceResourceControl, false)
+}
+
+// admitService evaluates the specification of a service against the admission policies of the environment(endpoint),
+// a forbidden response is returned when the policies are not respected
+func (transport *Transport) admitService(body []byte) (*http.Response, error) {
+	workload, err := admission.ServiceWorkload(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return transport.admitWorkload(workload)
+}
+
+// admitWorkload evaluates the workload against the admission policies of the environment(endpoint),
+// a forbidden response is returned when the policies are not respected
+func (transport *Transport) admitWorkload(workload admission.Workload) (*http.Response, error) {
+	endpoint, err := transport.dataStore.Endpoint().Endpoint(transport.endpoint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = admission.Admit(transport.dataStore, endpoint, []admission.Workload{workload})
+	if err != nil {
+		var violationErr *admission.ViolationError
+		if errors.As(err, &violationErr) {
+			return utils.WriteForbiddenResponse(violationErr.Error())
+		}
+
+		return nil, err
+	}
+
+	return nil, nil
+}
@@ -88,6 +88,10 @@ func (transport *Transport) ProxyDockerRequest(request *http.Request) (*http.Res
 	requestPath := apiVersionRe.ReplaceAllString(request.URL.Path, "")
 	request.URL.Path = requestPath
 
+	if !authorizedAPIKeyScope(request, requestPath) {
+		return utils.WriteAccessDeniedResponse()
+	}
+
 	if transport.endpoint.Type == portainer.AgentOnDockerEnvironment || transport.endpoint.Type == portainer.EdgeAgentOnDockerEnvironment {
 		signature, err := transport.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
 		if err != nil {
@@ -274,6 +278,10 @@ func (transport *Transport) proxyServiceRequest(request *http.Request) (*http.Re
 		if match, _ := path.Match("/services/*/*", requestPath); match {
 			// Handle /services/{id}/{action} requests
 			serviceID := path.Base(path.Dir(requestPath))
+			if path.Base(requestPath) == "update" && request.Method == http.MethodPost {
+				return transport.decorateServiceUpdateOperation(request, serviceID)
+			}
+
 			transport.decorateRegistryAuthenticationHeader(request)
 			return transport.restrictedResourceOperation(request, serviceID, serviceID, portainer.ServiceResourceControl, false)
 		} else if match, _ := path.Match("/services/*", requestPath); match {
@@ -394,7 +402,7 @@ func (transport *Transport) updateDefaultGitBranch(request *http.Request) error
 	remote := request.URL.Query().Get("remote")
 	if strings.HasSuffix(remote, ".git") {
 		repositoryURL := remote[:len(remote)-4]
-		latestCommitID, err := transport.gitService.LatestCommitID(repositoryURL, "", "", "", false)
+		latestCommitID, err := transport.gitService.LatestCommitID(repositoryURL, "", nil, false)
 		if err != nil {
 			return err
 		}
@@ -412,10 +420,17 @@ func (transport *Transport) proxyImageRequest(request *http.Request) (*http.Resp
 	switch requestPath := request.URL.Path; requestPath {
 	case "/images/create":
 		return transport.replaceRegistryAuthenticationHeader(request)
+	case "/images/json":
+		return transport.rewriteOperation(request, transport.imageListOperation)
 	default:
 		if path.Base(requestPath) == "push" && request.Method == http.MethodPost {
 			return transport.replaceRegistryAuthenticationHeader(request)
 		}
+
+		// Handle /images/{name}/json requests, the name of the image can contain slashes
+		if path.Base(requestPath) == "json" && request.Method == http.MethodGet {
+			return transport.rewriteOperation(request, transport.imageInspectOperation)
+		}
 		return transport.executeDockerRequest(request)
 	}
 }
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +70,32 @@ func TestTransport_updateDefaultGitBranch(t *testing.T) {
 		})
 	}
 }
+
+func TestTransport_proxyServiceRequest_AdmissionPolicies(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	endpoint := &portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}
+	is.NoError(store.Endpoint().Create(endpoint))
+	is.NoError(store.AdmissionPolicy().Create(&portainer.AdmissionPolicy{
+		Name:        "production",
+		Enabled:     true,
+		EndpointIDs: []portainer.EndpointID{endpoint.ID},
+		Rules:       portainer.AdmissionPolicyRules{DenyHostNetwork: true},
+	}))
+
+	transport := &Transport{dataStore: store, endpoint: endpoint}
+
+	body := `{"Name":"web","TaskTemplate":{"ContainerSpec":{"Image":"nginx"},"Networks":[{"Target":"host"}]}}`
+
+	for _, requestPath := range []string{"/services/create", "/services/abc/update"} {
+		request := httptest.NewRequest(http.MethodPost, requestPath, strings.NewReader(body))
+		request = request.WithContext(security.StoreTokenData(request, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+
+		response, err := transport.proxyServiceRequest(request)
+		is.NoError(err, requestPath)
+		is.Equal(http.StatusForbidden, response.StatusCode, "%s should be denied by the admission policies", requestPath)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
+	"github.com/portainer/portainer/api/metrics"
 
 	"github.com/portainer/portainer/api/kubernetes/cli"
 
@@ -42,6 +43,15 @@ func NewProxyFactory(dataStore dataservices.DataStore, signatureService portaine
 
 // NewEndpointProxy returns a new reverse proxy (filesystem based or HTTP) to an environment(endpoint) API server
 func (factory *ProxyFactory) NewEndpointProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
+	proxy, err := factory.newEndpointProxy(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return withRequestCounter(endpoint.ID, proxy), nil
+}
+
+func (factory *ProxyFactory) newEndpointProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
 	switch endpoint.Type {
 	case portainer.AzureEnvironment:
 		return newAzureProxy(endpoint, factory.dataStore)
@@ -52,6 +62,14 @@ func (factory *ProxyFactory) NewEndpointProxy(endpoint *portainer.Endpoint) (htt
 	return factory.newDockerProxy(endpoint)
 }
 
+// withRequestCounter counts the requests proxied to an environment(endpoint)
+func withRequestCounter(endpointID portainer.EndpointID, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.IncProxyRequests(endpointID)
+		next.ServeHTTP(w, r)
+	})
+}
+
 // NewGitlabProxy returns a new HTTP proxy to a Gitlab API server
 func (factory *ProxyFactory) NewGitlabProxy(gitlabAPIUri string) (http.Handler, error) {
 	return newGitlabProxy(gitlabAPIUri)
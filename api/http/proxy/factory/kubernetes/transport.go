@@ -13,6 +13,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/kubernetes/cli"
 
@@ -49,6 +50,10 @@ func (transport *baseTransport) proxyKubernetesRequest(request *http.Request) (*
 	apiVersionRe := regexp.MustCompile(`^(/kubernetes)?/(api|apis/apps)/v[0-9](\.[0-9])?`)
 	requestPath := apiVersionRe.ReplaceAllString(request.URL.Path, "")
 
+	if !authorizedAPIKeyScope(request) {
+		return utils.WriteAccessDeniedResponse()
+	}
+
 	switch {
 	case strings.EqualFold(requestPath, "/namespaces"):
 		return transport.executeKubernetesRequest(request)
@@ -100,6 +105,19 @@ func (transport *baseTransport) executeKubernetesRequest(request *http.Request)
 	return resp, err
 }
 
+// authorizedAPIKeyScope verifies that the request is allowed by the scope of the API key used to authenticate it, if any.
+// The Kubernetes resources are not mapped to individual authorizations, a key restricted to a subset of
+// the authorizations requires the EndpointResourcesAccess authorization.
+func authorizedAPIKeyScope(request *http.Request) bool {
+	tokenData, err := security.RetrieveTokenData(request)
+	if err != nil || tokenData.APIKeyScope == nil {
+		return true
+	}
+
+	return security.AuthorizedAPIKeyMethod(tokenData.APIKeyScope, request) &&
+		security.AuthorizedAPIKeyOperation(tokenData.APIKeyScope, portainer.EndpointResourcesAccess)
+}
+
 // #endregion
 
 // #region ROUND TRIP
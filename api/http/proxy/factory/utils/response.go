@@ -69,6 +69,14 @@ func WriteAccessDeniedResponse() (*http.Response, error) {
 	return response, err
 }
 
+// WriteForbiddenResponse will create a new forbidden response with the specified message
+func WriteForbiddenResponse(message string) (*http.Response, error) {
+	response := &http.Response{Header: http.Header{"Content-Type": []string{"application/json"}}}
+	err := RewriteResponse(response, errorResponse{Message: message}, http.StatusForbidden)
+
+	return response, err
+}
+
 // RewriteAccessDeniedResponse will overwrite the existing response with an access denied response
 func RewriteAccessDeniedResponse(response *http.Response) error {
 	return RewriteResponse(response, errorResponse{Message: "access denied to resource"}, http.StatusForbidden)
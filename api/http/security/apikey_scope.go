@@ -0,0 +1,273 @@
+package security
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// AuthorizedAPIKeyEndpointAccess verifies that the scope of the API key used to authenticate the request, if any,
+// is allowed to access the environment(endpoint).
+func AuthorizedAPIKeyEndpointAccess(scope *portainer.APIKeyScope, endpointID portainer.EndpointID) bool {
+	if scope == nil || len(scope.EndpointIDs) == 0 {
+		return true
+	}
+
+	for _, id := range scope.EndpointIDs {
+		if id == endpointID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorizedAPIKeyRequestEndpoint verifies that the environment(endpoint) identified in the route of the request, if
+// any, is in the scope of the API key. The mutating requests to the environment groups which don't target a single
+// environment are rejected for the keys limited to some environments, as they change the other environments of the
+// groups
+func authorizedAPIKeyRequestEndpoint(scope *portainer.APIKeyScope, r *http.Request) bool {
+	if scope == nil || len(scope.EndpointIDs) == 0 {
+		return true
+	}
+
+	resource := strings.Split(strings.Trim(strings.TrimPrefix(originalRequestPath(r), "/api"), "/"), "/")[0]
+	vars := mux.Vars(r)
+
+	endpointID := vars["endpointId"]
+	if resource == "endpoints" {
+		endpointID = vars["id"]
+	}
+
+	if endpointID == "" {
+		return resource != "endpoint_groups" || r.Method == http.MethodGet || r.Method == http.MethodHead
+	}
+
+	id, err := strconv.Atoi(endpointID)
+	if err != nil {
+		// the handler rejects the invalid identifiers
+		return true
+	}
+
+	return AuthorizedAPIKeyEndpointAccess(scope, portainer.EndpointID(id))
+}
+
+// AuthorizedAPIKeyOperation verifies that the scope of the API key used to authenticate the request, if any,
+// grants the authorization. All the authorizations of the user are granted when the scope does not list any.
+func AuthorizedAPIKeyOperation(scope *portainer.APIKeyScope, authorization portainer.Authorization) bool {
+	if scope == nil || len(scope.Authorizations) == 0 {
+		return true
+	}
+
+	return scope.Authorizations[authorization]
+}
+
+// AuthorizedAPIKeyMethod verifies that the scope of the API key used to authenticate the request, if any,
+// allows the method of the request. Read-only keys are limited to safe methods and cannot open websockets.
+func AuthorizedAPIKeyMethod(scope *portainer.APIKeyScope, r *http.Request) bool {
+	if scope == nil || !scope.ReadOnly {
+		return true
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// portainerOperationAuthorization returns the authorization required by a request to the Portainer API. The second
+// value is false for the requests proxied to the Docker API of an environment, their authorization is verified by
+// the proxy. The requests which are not mapped require an authorization that can't be granted to an API key.
+func portainerOperationAuthorization(method, requestPath string) (portainer.Authorization, bool) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(requestPath, "/api"), "/"), "/")
+
+	switch parts[0] {
+	case "custom_templates", "templates":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":    portainer.OperationPortainerTemplateList,
+			"inspect": portainer.OperationPortainerTemplateInspect,
+			"create":  portainer.OperationPortainerTemplateCreate,
+			"update":  portainer.OperationPortainerTemplateUpdate,
+			"delete":  portainer.OperationPortainerTemplateDelete,
+			"file":    portainer.OperationPortainerTemplateInspect,
+		}), true
+	case "endpoint_groups":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":      portainer.OperationPortainerEndpointGroupList,
+			"inspect":   portainer.OperationPortainerEndpointGroupInspect,
+			"create":    portainer.OperationPortainerEndpointGroupCreate,
+			"update":    portainer.OperationPortainerEndpointGroupUpdate,
+			"delete":    portainer.OperationPortainerEndpointGroupDelete,
+			"endpoints": portainer.OperationPortainerEndpointGroupUpdate,
+		}), true
+	case "endpoints":
+		return endpointOperationAuthorization(method, parts)
+	case "motd":
+		return portainer.OperationPortainerMOTD, true
+	case "registries":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":      portainer.OperationPortainerRegistryList,
+			"inspect":   portainer.OperationPortainerRegistryInspect,
+			"create":    portainer.OperationPortainerRegistryCreate,
+			"update":    portainer.OperationPortainerRegistryUpdate,
+			"delete":    portainer.OperationPortainerRegistryDelete,
+			"configure": portainer.OperationPortainerRegistryConfigure,
+		}), true
+	case "resource_controls":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"create": portainer.OperationPortainerResourceControlCreate,
+			"update": portainer.OperationPortainerResourceControlUpdate,
+			"delete": portainer.OperationPortainerResourceControlDelete,
+		}), true
+	case "roles":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list": portainer.OperationPortainerRoleList,
+		}), true
+	case "settings":
+		if method == http.MethodGet {
+			return portainer.OperationPortainerSettingsInspect, true
+		}
+
+		return portainer.OperationPortainerSettingsUpdate, true
+	case "stacks":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":    portainer.OperationPortainerStackList,
+			"inspect": portainer.OperationPortainerStackInspect,
+			"create":  portainer.OperationPortainerStackCreate,
+			"update":  portainer.OperationPortainerStackUpdate,
+			"delete":  portainer.OperationPortainerStackDelete,
+			"file":    portainer.OperationPortainerStackFile,
+			"migrate": portainer.OperationPortainerStackMigrate,
+		}), true
+	case "tags":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":   portainer.OperationPortainerTagList,
+			"create": portainer.OperationPortainerTagCreate,
+			"delete": portainer.OperationPortainerTagDelete,
+		}), true
+	case "team_memberships":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":   portainer.OperationPortainerTeamMembershipList,
+			"create": portainer.OperationPortainerTeamMembershipCreate,
+			"update": portainer.OperationPortainerTeamMembershipUpdate,
+			"delete": portainer.OperationPortainerTeamMembershipDelete,
+		}), true
+	case "teams":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":        portainer.OperationPortainerTeamList,
+			"inspect":     portainer.OperationPortainerTeamInspect,
+			"create":      portainer.OperationPortainerTeamCreate,
+			"update":      portainer.OperationPortainerTeamUpdate,
+			"delete":      portainer.OperationPortainerTeamDelete,
+			"memberships": portainer.OperationPortainerTeamMemberships,
+		}), true
+	case "upload":
+		return portainer.OperationPortainerUploadTLS, true
+	case "users":
+		return userOperationAuthorization(method, parts), true
+	case "webhooks":
+		return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+			"list":   portainer.OperationPortainerWebhookList,
+			"create": portainer.OperationPortainerWebhookCreate,
+			"delete": portainer.OperationPortainerWebhookDelete,
+		}), true
+	case "websocket":
+		return portainer.OperationPortainerWebsocketExec, true
+	case "docker", "kubernetes":
+		return portainer.EndpointResourcesAccess, true
+	}
+
+	return portainer.OperationPortainerUndefined, true
+}
+
+// portainerResourceAuthorization maps the requests following the common layout of the Portainer API,
+// /<resource>, /<resource>/create[/...] and /<resource>/{id}[/<action>[/...]], to the authorizations.
+// The actions without a dedicated authorization require the inspect or the update one
+func portainerResourceAuthorization(method string, parts []string, authorizations map[string]portainer.Authorization) portainer.Authorization {
+	operation := ""
+
+	switch {
+	case len(parts) == 1 && method == http.MethodGet:
+		operation = "list"
+	case len(parts) == 1 && method == http.MethodPost, len(parts) > 1 && parts[1] == "create":
+		operation = "create"
+	case len(parts) > 2 && authorizations[parts[2]] != "":
+		operation = parts[2]
+	case len(parts) == 2 && method == http.MethodDelete:
+		operation = "delete"
+	case method == http.MethodGet || method == http.MethodHead:
+		operation = "inspect"
+	default:
+		operation = "update"
+	}
+
+	authorization, ok := authorizations[operation]
+	if !ok {
+		return portainer.OperationPortainerUndefined
+	}
+
+	return authorization
+}
+
+func endpointOperationAuthorization(method string, parts []string) (portainer.Authorization, bool) {
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "docker":
+			return "", false
+		case "agent":
+			if len(parts) > 3 && parts[3] == "docker" {
+				return "", false
+			}
+
+			return portainer.EndpointResourcesAccess, true
+		case "kubernetes", "azure":
+			return portainer.EndpointResourcesAccess, true
+		case "snapshot":
+			return portainer.OperationPortainerEndpointSnapshot, true
+		}
+	}
+
+	if len(parts) == 2 && parts[1] == "snapshot" {
+		return portainer.OperationPortainerEndpointSnapshots, true
+	}
+
+	return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+		"list":    portainer.OperationPortainerEndpointList,
+		"inspect": portainer.OperationPortainerEndpointInspect,
+		"create":  portainer.OperationPortainerEndpointCreate,
+		"update":  portainer.OperationPortainerEndpointUpdate,
+		"delete":  portainer.OperationPortainerEndpointDelete,
+	}), true
+}
+
+func userOperationAuthorization(method string, parts []string) portainer.Authorization {
+	if len(parts) > 2 && parts[2] == "tokens" {
+		switch method {
+		case http.MethodGet:
+			return portainer.OperationPortainerUserListToken
+		case http.MethodPost:
+			return portainer.OperationPortainerUserCreateToken
+		case http.MethodDelete:
+			return portainer.OperationPortainerUserRevokeToken
+		}
+	}
+
+	return portainerResourceAuthorization(method, parts, map[string]portainer.Authorization{
+		"list":        portainer.OperationPortainerUserList,
+		"inspect":     portainer.OperationPortainerUserInspect,
+		"create":      portainer.OperationPortainerUserCreate,
+		"update":      portainer.OperationPortainerUserUpdate,
+		"delete":      portainer.OperationPortainerUserDelete,
+		"memberships": portainer.OperationPortainerUserMemberships,
+		"passwd":      portainer.OperationPortainerUserUpdatePassword,
+	})
+}
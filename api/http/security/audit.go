@@ -0,0 +1,322 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// the beginning of the body of the requests is read to be summarized in the audit logs
+	auditRequestBodyMaxSize = 64 * 1024
+	// size of the request summary kept in the audit logs, in bytes
+	auditRequestSummaryMaxSize = 1024
+	auditRedactedValue         = "[REDACTED]"
+)
+
+// the values of the request fields whose name contains one of these words are redacted from the audit logs
+var auditSensitiveFields = []string{"password", "passphrase", "secret", "token", "key", "credential", "certificate"}
+
+// auditResponseWriter keeps track of the status code sent to the client
+type auditResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *auditResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *auditResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusSwitchingProtocols
+	}
+
+	return hijacker.Hijack()
+}
+
+// isAuditedMethod returns true when the method of a request is expected to mutate a resource
+func isAuditedMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// mwAuditLog records an audit log for every mutating request performed by an authenticated user.
+// It expects the token data to be already stored in the request context.
+func (bouncer *RequestBouncer) mwAuditLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAuditedMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tokenData, err := RetrieveTokenData(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body := peekRequestBody(r)
+
+		rw := &auditResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		auditLog := bouncer.newAuditLog(r, tokenData, rw.statusCode, body)
+
+		err = bouncer.dataStore.AuditLog().Create(auditLog)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("method", auditLog.Method).
+				Str("path", auditLog.Path).
+				Msg("unable to persist audit log")
+		}
+	})
+}
+
+func (bouncer *RequestBouncer) newAuditLog(r *http.Request, tokenData *portainer.TokenData, statusCode int, body []byte) *portainer.AuditLog {
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	// the request path is stripped by the sub handlers, the original one is kept in the request URI
+	path := r.URL.Path
+	if requestURL, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		path = requestURL.Path
+	}
+
+	endpointID, resourceType, resourceID := parseAuditResource(path)
+	if endpointID == 0 {
+		if id, err := strconv.Atoi(r.URL.Query().Get("endpointId")); err == nil {
+			endpointID = portainer.EndpointID(id)
+		}
+	}
+
+	auditLog := &portainer.AuditLog{
+		Timestamp:      time.Now().UTC().Unix(),
+		UserID:         tokenData.ID,
+		Username:       tokenData.Username,
+		EndpointID:     endpointID,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Method:         r.Method,
+		Path:           path,
+		StatusCode:     statusCode,
+		Success:        statusCode < http.StatusBadRequest,
+		RequestSummary: summarizeRequestBody(r.Header.Get("Content-Type"), body, r.ContentLength),
+	}
+
+	if bouncer.JWTAuthLookup(r) == nil {
+		if rawAPIKey, ok := extractAPIKey(r); ok && len(rawAPIKey) >= 7 {
+			auditLog.APIKeyPrefix = rawAPIKey[:7]
+		}
+	}
+
+	return auditLog
+}
+
+// peekRequestBody reads the beginning of the body of a request, the body is left untouched for the handlers
+func peekRequestBody(r *http.Request) []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, auditRequestBodyMaxSize))
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// summarizeRequestBody returns the JSON body of a request without its secrets and file contents, or the type and
+// size of the other bodies
+func summarizeRequestBody(contentType string, body []byte, contentLength int64) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var value interface{}
+	if (mediaType == "" || mediaType == "application/json") && json.Unmarshal(body, &value) == nil {
+		summary, err := json.Marshal(redactRequestValue(value))
+		if err == nil {
+			return truncateRequestSummary(string(summary))
+		}
+	}
+
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	if contentLength < 0 {
+		return fmt.Sprintf("%s body", mediaType)
+	}
+
+	return fmt.Sprintf("%s body of %d bytes", mediaType, contentLength)
+}
+
+func redactRequestValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			name := strings.ToLower(key)
+
+			switch {
+			case name == "env":
+				value[key] = redactRequestEnv(field)
+			case isSensitiveRequestField(name):
+				value[key] = auditRedactedValue
+			case strings.Contains(name, "content"):
+				if content, ok := field.(string); ok {
+					value[key] = fmt.Sprintf("[%d bytes]", len(content))
+				}
+			default:
+				value[key] = redactRequestValue(field)
+			}
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = redactRequestValue(value[i])
+		}
+	}
+
+	return value
+}
+
+// redactRequestEnv keeps the names of the environment variables and redacts their values
+func redactRequestEnv(env interface{}) interface{} {
+	variables, ok := env.([]interface{})
+	if !ok {
+		return auditRedactedValue
+	}
+
+	for i := range variables {
+		variable, ok := variables[i].(map[string]interface{})
+		if !ok {
+			variables[i] = auditRedactedValue
+			continue
+		}
+
+		for key := range variable {
+			if !strings.EqualFold(key, "name") {
+				variable[key] = auditRedactedValue
+			}
+		}
+	}
+
+	return variables
+}
+
+func isSensitiveRequestField(name string) bool {
+	for _, word := range auditSensitiveFields {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func truncateRequestSummary(summary string) string {
+	if len(summary) <= auditRequestSummaryMaxSize {
+		return summary
+	}
+
+	summary = summary[:auditRequestSummaryMaxSize]
+
+	// drops the partial character left at the end
+	for len(summary) > 0 && !utf8.ValidString(summary) {
+		summary = summary[:len(summary)-1]
+	}
+
+	return summary + "..."
+}
+
+// parseAuditResource extracts the environment(endpoint) identifier, the resource type and
+// the resource identifier from an API path such as /api/stacks/1 or
+// /api/endpoints/1/docker/containers/{id}/start
+func parseAuditResource(path string) (portainer.EndpointID, string, string) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api"), "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return 0, "", ""
+	}
+
+	resourceType := segments[0]
+	resourceID := ""
+	if len(segments) > 1 {
+		if _, err := strconv.Atoi(segments[1]); err == nil {
+			resourceID = segments[1]
+		}
+	}
+
+	if resourceType != "endpoints" || resourceID == "" {
+		return 0, resourceType, resourceID
+	}
+
+	id, _ := strconv.Atoi(resourceID)
+	endpointID := portainer.EndpointID(id)
+
+	// requests proxied to the environment, e.g. docker/containers/{id}
+	if len(segments) > 3 {
+		switch segments[2] {
+		case "docker", "kubernetes", "agent", "azure":
+			resourceType = segments[2] + "/" + segments[3]
+			resourceID = ""
+			if len(segments) > 4 && segments[4] != "create" && segments[4] != "prune" {
+				resourceID = segments[4]
+			}
+		}
+	}
+
+	return endpointID, resourceType, resourceID
+}
@@ -0,0 +1,199 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/dataservices/auditlog"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseAuditResource(t *testing.T) {
+	tests := []struct {
+		path             string
+		wantEndpointID   portainer.EndpointID
+		wantResourceType string
+		wantResourceID   string
+	}{
+		{path: "/api/stacks/create/standalone/string", wantResourceType: "stacks"},
+		{path: "/api/stacks/12", wantResourceType: "stacks", wantResourceID: "12"},
+		{path: "/api/users/3/tokens", wantResourceType: "users", wantResourceID: "3"},
+		{path: "/api/endpoints/4", wantEndpointID: 4, wantResourceType: "endpoints", wantResourceID: "4"},
+		{path: "/api/endpoints/4/docker/containers/abc/start", wantEndpointID: 4, wantResourceType: "docker/containers", wantResourceID: "abc"},
+		{path: "/api/endpoints/4/docker/containers/create", wantEndpointID: 4, wantResourceType: "docker/containers"},
+		{path: "/api/settings", wantResourceType: "settings"},
+		{path: "/", wantResourceType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			endpointID, resourceType, resourceID := parseAuditResource(tt.path)
+			assert.Equal(t, tt.wantEndpointID, endpointID)
+			assert.Equal(t, tt.wantResourceType, resourceType)
+			assert.Equal(t, tt.wantResourceID, resourceID)
+		})
+	}
+}
+
+func Test_summarizeRequestBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		contentLength int64
+		want          string
+	}{
+		{name: "empty body", contentType: "application/json", want: ""},
+		{
+			name:        "secrets are redacted",
+			contentType: "application/json",
+			body:        `{"Name":"web","RepositoryPassword":"secret","Auth":{"PrivateKey":"pem","AppID":7}}`,
+			want:        `{"Auth":{"AppID":7,"PrivateKey":"[REDACTED]"},"Name":"web","RepositoryPassword":"[REDACTED]"}`,
+		},
+		{
+			name:        "only the names of the environment variables are kept",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"Env":[{"name":"DB_PASSWORD","value":"secret"}]}`,
+			want:        `{"Env":[{"name":"DB_PASSWORD","value":"[REDACTED]"}]}`,
+		},
+		{
+			name:        "file contents are replaced by their size",
+			contentType: "application/json",
+			body:        `{"StackFileContent":"version: '3'"}`,
+			want:        `{"StackFileContent":"[12 bytes]"}`,
+		},
+		{
+			name:          "other bodies are described",
+			contentType:   "multipart/form-data; boundary=abc",
+			body:          "--abc",
+			contentLength: 2048,
+			want:          "multipart/form-data body of 2048 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, summarizeRequestBody(tt.contentType, []byte(tt.body), tt.contentLength))
+		})
+	}
+
+	summary := summarizeRequestBody("application/json", []byte(`"`+strings.Repeat("é", auditRequestSummaryMaxSize)+`"`), -1)
+	assert.LessOrEqual(t, len(summary), auditRequestSummaryMaxSize+3)
+	assert.True(t, strings.HasSuffix(summary, "..."))
+}
+
+func Test_mwAuditLog(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "failed to create a copy of service")
+
+	bouncer := NewRequestBouncer(store, jwtService, apikey.NewAPIKeyService(nil, nil))
+
+	user := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	token, err := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+	is.NoError(err, "error generating token")
+
+	forbidden := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	requests := []struct {
+		method  string
+		target  string
+		handler http.Handler
+	}{
+		{method: http.MethodGet, target: "/api/stacks/1", handler: testHandler200},
+		{method: http.MethodPut, target: "/api/stacks/1?endpointId=2", handler: testHandler200},
+		{method: http.MethodDelete, target: "/api/endpoints/3", handler: forbidden},
+	}
+
+	for _, req := range requests {
+		r := httptest.NewRequest(req.method, req.target, strings.NewReader(`{"Name":"web","Password":"secret"}`))
+		r.Header.Add("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+
+		bouncer.mwAuthenticatedUser(req.handler).ServeHTTP(rr, r)
+	}
+
+	auditLogs, err := store.AuditLog().AuditLogs()
+	is.NoError(err)
+	is.Len(auditLogs, 2, "read-only requests should not be audited")
+
+	is.Equal(user.ID, auditLogs[0].UserID)
+	is.Equal("admin", auditLogs[0].Username)
+	is.Equal(http.MethodPut, auditLogs[0].Method)
+	is.Equal("/api/stacks/1", auditLogs[0].Path)
+	is.Equal("stacks", auditLogs[0].ResourceType)
+	is.Equal("1", auditLogs[0].ResourceID)
+	is.Equal(portainer.EndpointID(2), auditLogs[0].EndpointID)
+	is.True(auditLogs[0].Success)
+
+	is.Equal(portainer.EndpointID(3), auditLogs[1].EndpointID)
+	is.Equal(http.StatusForbidden, auditLogs[1].StatusCode)
+	is.False(auditLogs[1].Success)
+
+	is.Equal(`{"Name":"web","Password":"[REDACTED]"}`, auditLogs[0].RequestSummary)
+
+	_, ok := auditlog.VerifyChain(auditLogs)
+	is.True(ok, "audit log chain should be valid")
+
+	verified, tamperedID, err := store.AuditLog().VerifyAuditLogs()
+	is.NoError(err)
+	is.Equal(2, verified)
+	is.Zero(tamperedID)
+
+	_, ok = auditlog.VerifyChain(auditLogs[1:])
+	is.True(ok, "the chain should be verified from the first audit log kept by the retention")
+
+	auditLogs[0].Username = "someone-else"
+	tamperedID, ok = auditlog.VerifyChain(auditLogs)
+	is.False(ok, "tampered audit log chain should be detected")
+	is.Equal(auditLogs[0].ID, tamperedID)
+}
+
+func Test_mwAuditLog_APIKeyScope(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "failed to create a copy of service")
+
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	bouncer := NewRequestBouncer(store, jwtService, apiKeyService)
+
+	user := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	rawAPIKey, _, err := apiKeyService.GenerateScopedApiKey(*user, "test", portainer.APIKeyScope{ReadOnly: true})
+	is.NoError(err)
+
+	r := httptest.NewRequest(http.MethodDelete, "/api/stacks/1", nil)
+	r.Header.Add("X-API-KEY", rawAPIKey)
+	rr := httptest.NewRecorder()
+
+	bouncer.mwAuthenticatedUser(testHandler200).ServeHTTP(rr, r)
+	is.Equal(http.StatusForbidden, rr.Code)
+
+	auditLogs, err := store.AuditLog().AuditLogs()
+	is.NoError(err)
+	is.Len(auditLogs, 1, "the requests rejected by the scope of the API key should be audited")
+	is.Equal(http.StatusForbidden, auditLogs[0].StatusCode)
+	is.False(auditLogs[0].Success)
+}
@@ -2,6 +2,7 @@ package security
 
 import (
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -28,6 +29,8 @@ type (
 		IsTeamLeader    bool
 		UserID          portainer.UserID
 		UserMemberships []portainer.TeamMembership
+		// Scope of the API key used to authenticate the request, nil for the other authentication methods
+		APIKeyScope *portainer.APIKeyScope
 	}
 
 	// tokenLookup looks up a token in the request
@@ -110,6 +113,10 @@ func (bouncer *RequestBouncer) AuthorizedEndpointOperation(r *http.Request, endp
 		return err
 	}
 
+	if !AuthorizedAPIKeyEndpointAccess(tokenData.APIKeyScope, endpoint.ID) {
+		return httperrors.ErrEndpointAccessDenied
+	}
+
 	if tokenData.Role == portainer.AdministratorRole {
 		return nil
 	}
@@ -171,7 +178,11 @@ func (bouncer *RequestBouncer) TrustedEdgeEnvironmentAccess(endpoint *portainer.
 // mwAuthenticatedUser authenticates a request by
 // - adding a secure handlers to the response
 // - authenticating the request with a valid token
+// - rejecting the requests outside of the scope of the API key
+// - recording an audit log for mutating requests
 func (bouncer *RequestBouncer) mwAuthenticatedUser(h http.Handler) http.Handler {
+	h = mwAPIKeyScope(h)
+	h = bouncer.mwAuditLog(h)
 	h = bouncer.mwAuthenticateFirst([]tokenLookup{
 		bouncer.JWTAuthLookup,
 		bouncer.apiKeyLookup,
@@ -230,6 +241,7 @@ func (bouncer *RequestBouncer) mwUpgradeToRestrictedRequest(next http.Handler) h
 			httperror.WriteError(w, http.StatusInternalServerError, "Unable to create restricted request context ", err)
 			return
 		}
+		requestContext.APIKeyScope = tokenData.APIKeyScope
 
 		ctx := StoreRestrictedRequestContext(r, requestContext)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -254,6 +266,49 @@ func (bouncer *RequestBouncer) mwIsTeamLeader(next http.Handler) http.Handler {
 	})
 }
 
+// mwAPIKeyScope rejects the mutating requests authenticated with a read-only API key, the requests
+// which require an authorization that is not granted to the API key and the requests to the environments
+// (endpoints) outside of its scope
+func mwAPIKeyScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenData, err := RetrieveTokenData(r)
+		if err != nil {
+			httperror.WriteError(w, http.StatusForbidden, "Access denied", httperrors.ErrUnauthorized)
+			return
+		}
+
+		if !AuthorizedAPIKeyMethod(tokenData.APIKeyScope, r) {
+			httperror.WriteError(w, http.StatusForbidden, "Access denied", ErrReadOnlyAPIKey)
+			return
+		}
+
+		authorization, ok := portainerOperationAuthorization(r.Method, originalRequestPath(r))
+		if ok && !AuthorizedAPIKeyOperation(tokenData.APIKeyScope, authorization) {
+			httperror.WriteError(w, http.StatusForbidden, "Access denied", ErrAPIKeyScope)
+			return
+		}
+
+		if !authorizedAPIKeyRequestEndpoint(tokenData.APIKeyScope, r) {
+			httperror.WriteError(w, http.StatusForbidden, "Access denied", ErrAPIKeyScope)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originalRequestPath returns the path of the request before the API prefixes were stripped by the handlers
+func originalRequestPath(r *http.Request) string {
+	if r.RequestURI != "" {
+		requestURL, err := url.ParseRequestURI(r.RequestURI)
+		if err == nil {
+			return requestURL.Path
+		}
+	}
+
+	return r.URL.Path
+}
+
 // mwAuthenticateFirst authenticates a request an auth token.
 // A result of a first succeded token lookup would be used for the authentication.
 func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, next http.Handler) http.Handler {
@@ -273,7 +328,7 @@ func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, n
 			return
 		}
 
-		_, err := bouncer.dataStore.User().User(token.ID)
+		user, err := bouncer.dataStore.User().User(token.ID)
 		if err != nil && bouncer.dataStore.IsErrObjectNotFound(err) {
 			httperror.WriteError(w, http.StatusUnauthorized, "Unauthorized", httperrors.ErrUnauthorized)
 			return
@@ -282,6 +337,11 @@ func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, n
 			return
 		}
 
+		if user.Disabled {
+			httperror.WriteError(w, http.StatusUnauthorized, "Unauthorized", httperrors.ErrUnauthorized)
+			return
+		}
+
 		ctx := StoreTokenData(r, token)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -307,6 +367,7 @@ func (bouncer *RequestBouncer) JWTAuthLookup(r *http.Request) *portainer.TokenDa
 // - computing the digest of the raw api-key
 // - verifying it exists in cache/database
 // - matching the key to a user (ID, Role)
+// - rejecting expired keys and attaching the scope of the key
 // If the key is valid/verified, the last updated time of the key is updated.
 // Successful verification of the key will return a TokenData object - since the downstream handlers
 // utilise the token injected in the request context.
@@ -332,9 +393,16 @@ func (bouncer *RequestBouncer) apiKeyLookup(r *http.Request) *portainer.TokenDat
 		return nil
 	}
 
-	// update the last used time of the key
+	// update the last used time of the key, the key could have been revoked since the lookup
 	apiKey.LastUsed = time.Now().UTC().Unix()
-	bouncer.apiKeyService.UpdateAPIKey(&apiKey)
+	if err := bouncer.apiKeyService.UpdateAPIKey(&apiKey); err != nil {
+		return nil
+	}
+
+	// keys without restrictions keep the full rights of the user
+	if scope := apiKey.Scope; scope.ReadOnly || len(scope.EndpointIDs) > 0 || len(scope.Authorizations) > 0 {
+		tokenData.APIKeyScope = &scope
+	}
 
 	return tokenData
 }
@@ -385,6 +453,12 @@ func mwSecureHeaders(next http.Handler) http.Handler {
 	})
 }
 
+// RestrictedRequestContext returns the restricted request context of a user, it is used to run a request on behalf
+// of the user outside of its own HTTP request
+func (bouncer *RequestBouncer) RestrictedRequestContext(user *portainer.User) (*RestrictedRequestContext, error) {
+	return bouncer.newRestrictedContextRequest(user.ID, user.Role)
+}
+
 func (bouncer *RequestBouncer) newRestrictedContextRequest(userID portainer.UserID, userRole portainer.UserRole) (*RestrictedRequestContext, error) {
 	if userRole == portainer.AdministratorRole {
 		return &RestrictedRequestContext{
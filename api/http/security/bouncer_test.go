@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
 	"github.com/portainer/portainer/api/dataservices"
@@ -333,4 +335,164 @@ func Test_apiKeyLookup(t *testing.T) {
 
 		is.True(apiKeyUpdated.LastUsed > apiKey.LastUsed)
 	})
+	t.Run("expired api-key fails api-key lookup", func(t *testing.T) {
+		rawAPIKey, apiKey, err := apiKeyService.GenerateScopedApiKey(*user, "test", portainer.APIKeyScope{ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+		is.NoError(err)
+		defer apiKeyService.DeleteAPIKey(apiKey.ID)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add("x-api-key", rawAPIKey)
+
+		is.Nil(bouncer.apiKeyLookup(req))
+	})
+
+	t.Run("revoked api-key fails api-key lookup", func(t *testing.T) {
+		rawAPIKey, apiKey, err := apiKeyService.GenerateApiKey(*user, "test")
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add("x-api-key", rawAPIKey)
+		is.NotNil(bouncer.apiKeyLookup(req))
+
+		is.NoError(apiKeyService.DeleteAPIKey(apiKey.ID))
+		is.Nil(bouncer.apiKeyLookup(req))
+	})
+
+	t.Run("scoped api-key lookup attaches the scope to the token", func(t *testing.T) {
+		scope := portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{1}, ReadOnly: true}
+		rawAPIKey, apiKey, err := apiKeyService.GenerateScopedApiKey(*user, "test", scope)
+		is.NoError(err)
+		defer apiKeyService.DeleteAPIKey(apiKey.ID)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add("x-api-key", rawAPIKey)
+
+		token := bouncer.apiKeyLookup(req)
+		is.NotNil(token)
+		is.Equal(&scope, token.APIKeyScope)
+	})
+}
+
+func Test_mwAPIKeyScope(t *testing.T) {
+	is := assert.New(t)
+
+	stackScope := &portainer.APIKeyScope{Authorizations: portainer.Authorizations{
+		portainer.OperationPortainerStackList:   true,
+		portainer.OperationPortainerStackUpdate: true,
+	}}
+
+	endpointScope := &portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{1}}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		route          string
+		scope          *portainer.APIKeyScope
+		expectedStatus int
+	}{
+		{name: "unscoped token can post", method: http.MethodPost, expectedStatus: http.StatusOK},
+		{name: "read-only key can get", method: http.MethodGet, scope: &portainer.APIKeyScope{ReadOnly: true}, expectedStatus: http.StatusOK},
+		{name: "read-only key cannot post", method: http.MethodPost, scope: &portainer.APIKeyScope{ReadOnly: true}, expectedStatus: http.StatusForbidden},
+		{name: "read-only key cannot delete", method: http.MethodDelete, scope: &portainer.APIKeyScope{ReadOnly: true}, expectedStatus: http.StatusForbidden},
+		{name: "restricted key can post", method: http.MethodPost, scope: &portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{1}}, expectedStatus: http.StatusOK},
+		{name: "scoped key can list the stacks", method: http.MethodGet, path: "/api/stacks", scope: stackScope, expectedStatus: http.StatusOK},
+		{name: "scoped key can redeploy a stack", method: http.MethodPut, path: "/api/stacks/1/git/redeploy", scope: stackScope, expectedStatus: http.StatusOK},
+		{name: "scoped key cannot delete a stack", method: http.MethodDelete, path: "/api/stacks/1", scope: stackScope, expectedStatus: http.StatusForbidden},
+		{name: "scoped key cannot list the users", method: http.MethodGet, path: "/api/users", scope: stackScope, expectedStatus: http.StatusForbidden},
+		{name: "scoped key cannot update the settings", method: http.MethodPut, path: "/api/settings", scope: stackScope, expectedStatus: http.StatusForbidden},
+		{name: "scoped key cannot create an environment", method: http.MethodPost, path: "/api/endpoints", scope: stackScope, expectedStatus: http.StatusForbidden},
+		{name: "scoped key cannot use an unmapped route", method: http.MethodGet, path: "/api/audit_logs", scope: stackScope, expectedStatus: http.StatusForbidden},
+		{name: "scoped key is checked by the Docker proxy", method: http.MethodGet, path: "/api/endpoints/1/docker/containers/json", scope: stackScope, expectedStatus: http.StatusOK},
+		{name: "restricted key can update its environment", method: http.MethodPut, path: "/api/endpoints/1", route: "/endpoints/{id}", scope: endpointScope, expectedStatus: http.StatusOK},
+		{name: "restricted key cannot update another environment", method: http.MethodPut, path: "/api/endpoints/2", route: "/endpoints/{id}", scope: endpointScope, expectedStatus: http.StatusForbidden},
+		{name: "restricted key cannot delete another environment", method: http.MethodDelete, path: "/api/endpoints/2", route: "/endpoints/{id}", scope: endpointScope, expectedStatus: http.StatusForbidden},
+		{name: "restricted key can add its environment to a group", method: http.MethodPut, path: "/api/endpoint_groups/2/endpoints/1", route: "/endpoint_groups/{id}/endpoints/{endpointId}", scope: endpointScope, expectedStatus: http.StatusOK},
+		{name: "restricted key cannot add another environment to a group", method: http.MethodPut, path: "/api/endpoint_groups/2/endpoints/2", route: "/endpoint_groups/{id}/endpoints/{endpointId}", scope: endpointScope, expectedStatus: http.StatusForbidden},
+		{name: "restricted key can inspect a group", method: http.MethodGet, path: "/api/endpoint_groups/2", route: "/endpoint_groups/{id}", scope: endpointScope, expectedStatus: http.StatusOK},
+		{name: "restricted key cannot update a group", method: http.MethodPut, path: "/api/endpoint_groups/2", route: "/endpoint_groups/{id}", scope: endpointScope, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, handler := "/", mwAPIKeyScope(testHandler200)
+			if test.route != "" {
+				router := mux.NewRouter()
+				router.Handle(test.route, handler)
+				handler = router
+			}
+
+			if test.path != "" {
+				// the handlers only see the path stripped of the API prefix
+				path, handler = test.path, http.StripPrefix("/api", handler)
+			}
+
+			req := httptest.NewRequest(test.method, path, nil)
+			req = req.WithContext(StoreTokenData(req, &portainer.TokenData{ID: 1, APIKeyScope: test.scope}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			is.Equal(test.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func Test_portainerOperationAuthorization(t *testing.T) {
+	is := assert.New(t)
+
+	tests := []struct {
+		method        string
+		path          string
+		authorization portainer.Authorization
+	}{
+		{method: http.MethodGet, path: "/api/endpoints", authorization: portainer.OperationPortainerEndpointList},
+		{method: http.MethodGet, path: "/api/endpoints/1", authorization: portainer.OperationPortainerEndpointInspect},
+		{method: http.MethodDelete, path: "/api/endpoints/1", authorization: portainer.OperationPortainerEndpointDelete},
+		{method: http.MethodPost, path: "/api/endpoints/1/snapshot", authorization: portainer.OperationPortainerEndpointSnapshot},
+		{method: http.MethodPost, path: "/api/endpoints/snapshot", authorization: portainer.OperationPortainerEndpointSnapshots},
+		{method: http.MethodGet, path: "/api/endpoints/1/kubernetes/helm", authorization: portainer.EndpointResourcesAccess},
+		{method: http.MethodPost, path: "/api/stacks/create/standalone/string", authorization: portainer.OperationPortainerStackCreate},
+		{method: http.MethodGet, path: "/api/stacks/1/file", authorization: portainer.OperationPortainerStackFile},
+		{method: http.MethodPost, path: "/api/stacks/1/migrate", authorization: portainer.OperationPortainerStackMigrate},
+		{method: http.MethodPost, path: "/api/custom_templates/create/string", authorization: portainer.OperationPortainerTemplateCreate},
+		{method: http.MethodPost, path: "/api/registries/1/configure", authorization: portainer.OperationPortainerRegistryConfigure},
+		{method: http.MethodGet, path: "/api/teams/1/memberships", authorization: portainer.OperationPortainerTeamMemberships},
+		{method: http.MethodGet, path: "/api/users/1/tokens", authorization: portainer.OperationPortainerUserListToken},
+		{method: http.MethodDelete, path: "/api/users/1/tokens/2", authorization: portainer.OperationPortainerUserRevokeToken},
+		{method: http.MethodPut, path: "/api/users/1/passwd", authorization: portainer.OperationPortainerUserUpdatePassword},
+		{method: http.MethodPut, path: "/api/settings", authorization: portainer.OperationPortainerSettingsUpdate},
+		{method: http.MethodDelete, path: "/api/tags/1", authorization: portainer.OperationPortainerTagDelete},
+		{method: http.MethodGet, path: "/api/backup/s3/status", authorization: portainer.OperationPortainerUndefined},
+	}
+
+	for _, test := range tests {
+		authorization, ok := portainerOperationAuthorization(test.method, test.path)
+		is.True(ok)
+		is.Equal(test.authorization, authorization, "%s %s", test.method, test.path)
+	}
+
+	_, ok := portainerOperationAuthorization(http.MethodGet, "/api/endpoints/1/docker/containers/json")
+	is.False(ok, "the Docker requests should be checked by the proxy")
+
+	_, ok = portainerOperationAuthorization(http.MethodGet, "/api/endpoints/1/agent/docker/browse/ls")
+	is.False(ok, "the Docker requests should be checked by the proxy")
+}
+
+func Test_AuthorizedEndpointOperation_APIKeyScope(t *testing.T) {
+	is := assert.New(t)
+
+	bouncer := NewRequestBouncer(nil, nil, nil)
+	endpoint := &portainer.Endpoint{ID: 2}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	tokenData := &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}
+
+	is.NoError(bouncer.AuthorizedEndpointOperation(req.WithContext(StoreTokenData(req, tokenData)), endpoint))
+
+	tokenData.APIKeyScope = &portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{2}}
+	is.NoError(bouncer.AuthorizedEndpointOperation(req.WithContext(StoreTokenData(req, tokenData)), endpoint))
+
+	tokenData.APIKeyScope = &portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{1}}
+	is.ErrorIs(bouncer.AuthorizedEndpointOperation(req.WithContext(StoreTokenData(req, tokenData)), endpoint), httperrors.ErrEndpointAccessDenied)
 }
@@ -4,4 +4,6 @@ import "errors"
 
 var (
 	ErrAuthorizationRequired = errors.New("Authorization required for this operation")
+	ErrReadOnlyAPIKey        = errors.New("The API key only allows read-only operations")
+	ErrAPIKeyScope           = errors.New("The operation is outside of the scope of the API key")
 )
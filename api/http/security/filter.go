@@ -90,16 +90,21 @@ func FilterRegistries(registries []portainer.Registry, user *portainer.User, tea
 
 // FilterEndpoints filters environments(endpoints) based on user role and team memberships.
 // Non administrator only have access to authorized environments(endpoints) (can be inherited via endpoint groups).
+// Requests authenticated with an API key are also limited to the environments(endpoints) of its scope.
 func FilterEndpoints(endpoints []portainer.Endpoint, groups []portainer.EndpointGroup, context *RestrictedRequestContext) []portainer.Endpoint {
-	if context.IsAdmin {
+	if context.IsAdmin && (context.APIKeyScope == nil || len(context.APIKeyScope.EndpointIDs) == 0) {
 		return endpoints
 	}
 
 	n := 0
 	for _, endpoint := range endpoints {
+		if !AuthorizedAPIKeyEndpointAccess(context.APIKeyScope, endpoint.ID) {
+			continue
+		}
+
 		endpointGroup := getAssociatedGroup(&endpoint, groups)
 
-		if AuthorizedEndpointAccess(&endpoint, endpointGroup, context.UserID, context.UserMemberships) {
+		if context.IsAdmin || AuthorizedEndpointAccess(&endpoint, endpointGroup, context.UserID, context.UserMemberships) {
 			endpoints[n] = endpoint
 			n++
 		}
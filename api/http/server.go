@@ -10,11 +10,14 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/adminmonitor"
 	"github.com/portainer/portainer/api/apikey"
+	operations "github.com/portainer/portainer/api/backup"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
 	"github.com/portainer/portainer/api/docker"
 	"github.com/portainer/portainer/api/http/handler"
+	"github.com/portainer/portainer/api/http/handler/admissionpolicies"
+	"github.com/portainer/portainer/api/http/handler/auditlogs"
 	"github.com/portainer/portainer/api/http/handler/auth"
 	"github.com/portainer/portainer/api/http/handler/backup"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
@@ -34,7 +37,9 @@ import (
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
 	kubehandler "github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
+	"github.com/portainer/portainer/api/http/handler/metrics"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/notificationchannels"
 	"github.com/portainer/portainer/api/http/handler/registries"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
@@ -51,6 +56,7 @@ import (
 	"github.com/portainer/portainer/api/http/handler/users"
 	"github.com/portainer/portainer/api/http/handler/webhooks"
 	"github.com/portainer/portainer/api/http/handler/websocket"
+	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/offlinegate"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
@@ -61,6 +67,7 @@ import (
 	"github.com/portainer/portainer/api/internal/upgrade"
 	k8s "github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	ldapsync "github.com/portainer/portainer/api/ldap"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/pkg/libhelm"
@@ -77,6 +84,7 @@ type Server struct {
 	AssetsPath                  string
 	Status                      *portainer.Status
 	ReverseTunnelService        portainer.ReverseTunnelService
+	NotificationService         portainer.NotificationService
 	ComposeStackManager         portainer.ComposeStackManager
 	CryptoService               portainer.CryptoService
 	EdgeStacksService           *edgestackservice.Service
@@ -103,7 +111,10 @@ type Server struct {
 	Scheduler                   *scheduler.Scheduler
 	ShutdownCtx                 context.Context
 	ShutdownTrigger             context.CancelFunc
+	MetricsToken                string
 	StackDeployer               deployments.StackDeployer
+	ImageScanService            portainer.ImageScanService
+	StackDriftService           portainer.StackDriftService
 	DemoService                 *demo.Service
 	UpgradeService              upgrade.Service
 	AdminCreationDone           chan struct{}
@@ -120,6 +131,9 @@ func (server *Server) Start() error {
 
 	passwordStrengthChecker := security.NewPasswordStrengthChecker(server.DataStore.Settings())
 
+	var auditLogHandler = auditlogs.NewHandler(requestBouncer)
+	auditLogHandler.DataStore = server.DataStore
+
 	var authHandler = auth.NewHandler(requestBouncer, rateLimiter, passwordStrengthChecker)
 	authHandler.DataStore = server.DataStore
 	authHandler.CryptoService = server.CryptoService
@@ -132,6 +146,18 @@ func (server *Server) Start() error {
 	adminMonitor := adminmonitor.New(5*time.Minute, server.DataStore, server.ShutdownCtx)
 	adminMonitor.Start()
 
+	scheduledBackupService := operations.NewScheduledService(server.DataStore, offlineGate, server.FileService.GetDatastorePath(), server.Scheduler, server.NotificationService, server.ShutdownCtx)
+	err := scheduledBackupService.Start()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to schedule the automatic backups")
+	}
+
+	ldapSyncService := ldapsync.NewSyncService(server.DataStore, server.LDAPService, server.Scheduler)
+	err = ldapSyncService.Start()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to schedule the LDAP group synchronisation")
+	}
+
 	var backupHandler = backup.NewHandler(
 		requestBouncer,
 		server.DataStore,
@@ -141,6 +167,7 @@ func (server *Server) Start() error {
 		adminMonitor,
 		server.DemoService,
 	)
+	backupHandler.ScheduledBackupService = scheduledBackupService
 
 	var roleHandler = roles.NewHandler(requestBouncer)
 	roleHandler.DataStore = server.DataStore
@@ -149,6 +176,7 @@ func (server *Server) Start() error {
 
 	var edgeGroupsHandler = edgegroups.NewHandler(requestBouncer)
 	edgeGroupsHandler.DataStore = server.DataStore
+	edgeGroupsHandler.FileService = server.FileService
 	edgeGroupsHandler.ReverseTunnelService = server.ReverseTunnelService
 
 	var edgeJobsHandler = edgejobs.NewHandler(requestBouncer)
@@ -160,6 +188,7 @@ func (server *Server) Start() error {
 	edgeStacksHandler.FileService = server.FileService
 	edgeStacksHandler.GitService = server.GitService
 	edgeStacksHandler.KubernetesDeployer = server.KubernetesDeployer
+	edgeStacksHandler.NotificationService = server.NotificationService
 
 	var edgeTemplatesHandler = edgetemplates.NewHandler(requestBouncer)
 	edgeTemplatesHandler.DataStore = server.DataStore
@@ -181,6 +210,7 @@ func (server *Server) Start() error {
 	var endpointGroupHandler = endpointgroups.NewHandler(requestBouncer)
 	endpointGroupHandler.AuthorizationService = server.AuthorizationService
 	endpointGroupHandler.DataStore = server.DataStore
+	endpointGroupHandler.FileService = server.FileService
 
 	var endpointProxyHandler = endpointproxy.NewHandler(requestBouncer)
 	endpointProxyHandler.DataStore = server.DataStore
@@ -203,9 +233,18 @@ func (server *Server) Start() error {
 	ldapHandler.DataStore = server.DataStore
 	ldapHandler.FileService = server.FileService
 	ldapHandler.LDAPService = server.LDAPService
+	ldapHandler.LDAPSyncService = ldapSyncService
+
+	var metricsHandler = metrics.NewHandler(requestBouncer, server.MetricsToken)
 
 	var motdHandler = motd.NewHandler(requestBouncer)
 
+	var admissionPolicyHandler = admissionpolicies.NewHandler(requestBouncer)
+	admissionPolicyHandler.DataStore = server.DataStore
+
+	var notificationChannelHandler = notificationchannels.NewHandler(requestBouncer)
+	notificationChannelHandler.DataStore = server.DataStore
+
 	var registryHandler = registries.NewHandler(requestBouncer)
 	registryHandler.DataStore = server.DataStore
 	registryHandler.FileService = server.FileService
@@ -220,7 +259,12 @@ func (server *Server) Start() error {
 	settingsHandler.FileService = server.FileService
 	settingsHandler.JWTService = server.JWTService
 	settingsHandler.LDAPService = server.LDAPService
+	settingsHandler.OAuthService = server.OAuthService
 	settingsHandler.SnapshotService = server.SnapshotService
+	settingsHandler.ScheduledBackupService = scheduledBackupService
+	settingsHandler.LDAPSyncService = ldapSyncService
+	settingsHandler.ImageScanService = server.ImageScanService
+	settingsHandler.StackDriftService = server.StackDriftService
 
 	var sslHandler = sslhandler.NewHandler(requestBouncer)
 	sslHandler.SSLService = server.SSLService
@@ -243,6 +287,7 @@ func (server *Server) Start() error {
 	stackHandler.SwarmStackManager = server.SwarmStackManager
 	stackHandler.ComposeStackManager = server.ComposeStackManager
 	stackHandler.StackDeployer = server.StackDeployer
+	stackHandler.StackDriftService = server.StackDriftService
 
 	var storybookHandler = storybook.NewHandler(server.AssetsPath)
 
@@ -285,46 +330,50 @@ func (server *Server) Start() error {
 	webhookHandler.DockerClientFactory = server.DockerClientFactory
 
 	server.Handler = &handler.Handler{
-		RoleHandler:            roleHandler,
-		AuthHandler:            authHandler,
-		BackupHandler:          backupHandler,
-		CustomTemplatesHandler: customTemplatesHandler,
-		DockerHandler:          dockerHandler,
-		EdgeGroupsHandler:      edgeGroupsHandler,
-		EdgeJobsHandler:        edgeJobsHandler,
-		EdgeStacksHandler:      edgeStacksHandler,
-		EdgeTemplatesHandler:   edgeTemplatesHandler,
-		EndpointGroupHandler:   endpointGroupHandler,
-		EndpointHandler:        endpointHandler,
-		EndpointHelmHandler:    endpointHelmHandler,
-		EndpointEdgeHandler:    endpointEdgeHandler,
-		EndpointProxyHandler:   endpointProxyHandler,
-		GitOperationHandler:    gitOperationHandler,
-		FileHandler:            fileHandler,
-		LDAPHandler:            ldapHandler,
-		HelmTemplatesHandler:   helmTemplatesHandler,
-		KubernetesHandler:      kubernetesHandler,
-		MOTDHandler:            motdHandler,
-		OpenAMTHandler:         openAMTHandler,
-		FDOHandler:             fdoHandler,
-		RegistryHandler:        registryHandler,
-		ResourceControlHandler: resourceControlHandler,
-		SettingsHandler:        settingsHandler,
-		SSLHandler:             sslHandler,
-		StackHandler:           stackHandler,
-		StorybookHandler:       storybookHandler,
-		SystemHandler:          systemHandler,
-		TagHandler:             tagHandler,
-		TeamHandler:            teamHandler,
-		TeamMembershipHandler:  teamMembershipHandler,
-		TemplatesHandler:       templatesHandler,
-		UploadHandler:          uploadHandler,
-		UserHandler:            userHandler,
-		WebSocketHandler:       websocketHandler,
-		WebhookHandler:         webhookHandler,
+		RoleHandler:                roleHandler,
+		AdmissionPolicyHandler:     admissionPolicyHandler,
+		AuditLogHandler:            auditLogHandler,
+		AuthHandler:                authHandler,
+		BackupHandler:              backupHandler,
+		CustomTemplatesHandler:     customTemplatesHandler,
+		DockerHandler:              dockerHandler,
+		EdgeGroupsHandler:          edgeGroupsHandler,
+		EdgeJobsHandler:            edgeJobsHandler,
+		EdgeStacksHandler:          edgeStacksHandler,
+		EdgeTemplatesHandler:       edgeTemplatesHandler,
+		EndpointGroupHandler:       endpointGroupHandler,
+		EndpointHandler:            endpointHandler,
+		EndpointHelmHandler:        endpointHelmHandler,
+		EndpointEdgeHandler:        endpointEdgeHandler,
+		EndpointProxyHandler:       endpointProxyHandler,
+		GitOperationHandler:        gitOperationHandler,
+		FileHandler:                fileHandler,
+		LDAPHandler:                ldapHandler,
+		HelmTemplatesHandler:       helmTemplatesHandler,
+		KubernetesHandler:          kubernetesHandler,
+		MetricsHandler:             metricsHandler,
+		MOTDHandler:                motdHandler,
+		NotificationChannelHandler: notificationChannelHandler,
+		OpenAMTHandler:             openAMTHandler,
+		FDOHandler:                 fdoHandler,
+		RegistryHandler:            registryHandler,
+		ResourceControlHandler:     resourceControlHandler,
+		SettingsHandler:            settingsHandler,
+		SSLHandler:                 sslHandler,
+		StackHandler:               stackHandler,
+		StorybookHandler:           storybookHandler,
+		SystemHandler:              systemHandler,
+		TagHandler:                 tagHandler,
+		TeamHandler:                teamHandler,
+		TeamMembershipHandler:      teamMembershipHandler,
+		TemplatesHandler:           templatesHandler,
+		UploadHandler:              uploadHandler,
+		UserHandler:                userHandler,
+		WebSocketHandler:           websocketHandler,
+		WebhookHandler:             webhookHandler,
 	}
 
-	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, server.Handler))
+	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, middlewares.WithMetrics(server.Handler)))
 	if server.HTTPEnabled {
 		go func() {
 			log.Info().Str("bind_address", server.BindAddress).Msg("starting HTTP server")
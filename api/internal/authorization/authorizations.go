@@ -532,6 +532,32 @@ func getUserEndpointAuthorizations(user *portainer.User, endpoints []portainer.E
 	return endpointAuthorizations
 }
 
+// UserEndpointRoleIDs returns the roles given to a user on an environment(endpoint) by the access policies of the
+// environment and of its group, directly or through the teams of the user
+func UserEndpointRoleIDs(userID portainer.UserID, endpoint *portainer.Endpoint, group *portainer.EndpointGroup, memberships []portainer.TeamMembership) []portainer.RoleID {
+	roleIDs := make([]portainer.RoleID, 0)
+
+	if policy, ok := endpoint.UserAccessPolicies[userID]; ok {
+		roleIDs = append(roleIDs, policy.RoleID)
+	}
+
+	if policy, ok := group.UserAccessPolicies[userID]; ok {
+		roleIDs = append(roleIDs, policy.RoleID)
+	}
+
+	for _, membership := range memberships {
+		if policy, ok := endpoint.TeamAccessPolicies[membership.TeamID]; ok {
+			roleIDs = append(roleIDs, policy.RoleID)
+		}
+
+		if policy, ok := group.TeamAccessPolicies[membership.TeamID]; ok {
+			roleIDs = append(roleIDs, policy.RoleID)
+		}
+	}
+
+	return roleIDs
+}
+
 func getAuthorizationsFromUserEndpointPolicy(user *portainer.User, endpoint *portainer.Endpoint, roles []portainer.Role) portainer.Authorizations {
 	policyRoles := make([]portainer.RoleID, 0)
 
@@ -0,0 +1,200 @@
+package edge
+
+import (
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// EdgeJobRunOutputMaxSize is the size of the output kept for each run, in bytes
+	EdgeJobRunOutputMaxSize = 64 * 1024
+	// EdgeJobRunPayloadMaxSize is the size of the largest run an agent can report, in bytes
+	EdgeJobRunPayloadMaxSize = 1024 * 1024
+	// EdgeJobMaxEndpointRuns is the number of runs kept for each environment(endpoint) of an Edge job, the oldest runs
+	// are deleted first
+	EdgeJobMaxEndpointRuns = 50
+	// EdgeJobMaxRetries is the highest number of retries of a failed run
+	EdgeJobMaxRetries = 10
+)
+
+// EdgeJobRunStatus represents the state of an Edge job on an environment(endpoint), given by its last run
+type EdgeJobRunStatus string
+
+const (
+	// EdgeJobRunPending is the status of the environments which didn't report any run yet
+	EdgeJobRunPending EdgeJobRunStatus = "pending"
+	// EdgeJobRunSucceeded is the status of the environments whose last run exited successfully
+	EdgeJobRunSucceeded EdgeJobRunStatus = "succeeded"
+	// EdgeJobRunRetrying is the status of the environments whose last run failed and will be retried
+	EdgeJobRunRetrying EdgeJobRunStatus = "retrying"
+	// EdgeJobRunFailed is the status of the environments whose last run failed without any retry left
+	EdgeJobRunFailed EdgeJobRunStatus = "failed"
+)
+
+// EdgeJobRunCounts counts the environments(endpoints) of an Edge job by status
+type EdgeJobRunCounts struct {
+	Total     int `json:"Total"`
+	Pending   int `json:"Pending"`
+	Succeeded int `json:"Succeeded"`
+	Retrying  int `json:"Retrying"`
+	Failed    int `json:"Failed"`
+}
+
+// EdgeJobEndpointRuns represents the runs of an Edge job on an environment(endpoint)
+type EdgeJobEndpointRuns struct {
+	EndpointID portainer.EndpointID `json:"EndpointId"`
+	Status     EdgeJobRunStatus     `json:"Status"`
+	// Number of runs of the current version of the Edge job
+	Runs    int                   `json:"Runs"`
+	LastRun *portainer.EdgeJobRun `json:"LastRun,omitempty"`
+}
+
+// EdgeJobEdgeGroupRuns counts the environments(endpoints) of an edge group targeted by an Edge job by status
+type EdgeJobEdgeGroupRuns struct {
+	EdgeGroupID portainer.EdgeGroupID `json:"EdgeGroupId"`
+	EdgeJobRunCounts
+}
+
+// EdgeJobRunSummary aggregates the runs of an Edge job on all the environments(endpoints) it targets
+type EdgeJobRunSummary struct {
+	EdgeJobRunCounts
+	Endpoints  []EdgeJobEndpointRuns  `json:"Endpoints"`
+	EdgeGroups []EdgeJobEdgeGroupRuns `json:"EdgeGroups"`
+}
+
+func (counts *EdgeJobRunCounts) add(status EdgeJobRunStatus) {
+	counts.Total++
+
+	switch status {
+	case EdgeJobRunPending:
+		counts.Pending++
+	case EdgeJobRunSucceeded:
+		counts.Succeeded++
+	case EdgeJobRunRetrying:
+		counts.Retrying++
+	case EdgeJobRunFailed:
+		counts.Failed++
+	}
+}
+
+// ValidateEdgeJobRetryPolicy verifies the retries and the timeout of an Edge job
+func ValidateEdgeJobRetryPolicy(maxRetries, retryInterval, timeout int) error {
+	if maxRetries < 0 || maxRetries > EdgeJobMaxRetries {
+		return errors.Errorf("the number of retries must be between 0 and %d", EdgeJobMaxRetries)
+	}
+
+	if retryInterval < 0 {
+		return errors.New("the retry interval can't be negative")
+	}
+
+	if timeout < 0 {
+		return errors.New("the timeout can't be negative")
+	}
+
+	return nil
+}
+
+// TruncateEdgeJobRunOutput keeps the end of the output of a run, where the errors usually are. The second value is
+// true when the output was truncated
+func TruncateEdgeJobRunOutput(output string) (string, bool) {
+	if len(output) <= EdgeJobRunOutputMaxSize {
+		return output, false
+	}
+
+	output = output[len(output)-EdgeJobRunOutputMaxSize:]
+
+	// drops the partial character left at the beginning
+	for len(output) > 0 && !utf8.RuneStart(output[0]) {
+		output = output[1:]
+	}
+
+	return output, true
+}
+
+// EdgeJobRunOutputTaskID returns the identifier under which the output of a run is stored with the logs of its
+// Edge job
+func EdgeJobRunOutputTaskID(runID portainer.EdgeJobRunID) string {
+	return "run_" + strconv.Itoa(int(runID))
+}
+
+// IsEdgeJobRunSuccessful returns true when the script of the run exited successfully
+func IsEdgeJobRunSuccessful(run *portainer.EdgeJobRun) bool {
+	return run.ExitCode == 0 && !run.TimedOut
+}
+
+func edgeJobRunStatus(edgeJob *portainer.EdgeJob, run *portainer.EdgeJobRun) EdgeJobRunStatus {
+	switch {
+	case run == nil:
+		return EdgeJobRunPending
+	case IsEdgeJobRunSuccessful(run):
+		return EdgeJobRunSucceeded
+	case run.Attempt <= edgeJob.MaxRetries:
+		return EdgeJobRunRetrying
+	}
+
+	return EdgeJobRunFailed
+}
+
+// SummarizeEdgeJobRuns gives the status of the Edge job on each of its environments(endpoints) from the last run of
+// its current version, and counts them in total and per edge group. The runs must be ordered by identifier
+func SummarizeEdgeJobRuns(edgeJob *portainer.EdgeJob, runs []portainer.EdgeJobRun, edgeGroupEndpoints map[portainer.EdgeGroupID][]portainer.EndpointID) *EdgeJobRunSummary {
+	endpointRuns := map[portainer.EndpointID]*EdgeJobEndpointRuns{}
+
+	target := func(endpointID portainer.EndpointID) {
+		if _, ok := endpointRuns[endpointID]; !ok {
+			endpointRuns[endpointID] = &EdgeJobEndpointRuns{EndpointID: endpointID}
+		}
+	}
+
+	for endpointID := range edgeJob.Endpoints {
+		target(endpointID)
+	}
+
+	for _, endpointIDs := range edgeGroupEndpoints {
+		for _, endpointID := range endpointIDs {
+			target(endpointID)
+		}
+	}
+
+	for i := range runs {
+		endpoint, ok := endpointRuns[runs[i].EndpointID]
+		if !ok || runs[i].Version != edgeJob.Version {
+			continue
+		}
+
+		endpoint.Runs++
+		endpoint.LastRun = &runs[i]
+	}
+
+	summary := &EdgeJobRunSummary{
+		Endpoints:  make([]EdgeJobEndpointRuns, 0, len(endpointRuns)),
+		EdgeGroups: make([]EdgeJobEdgeGroupRuns, 0, len(edgeJob.EdgeGroups)),
+	}
+
+	for _, endpoint := range endpointRuns {
+		endpoint.Status = edgeJobRunStatus(edgeJob, endpoint.LastRun)
+
+		summary.add(endpoint.Status)
+		summary.Endpoints = append(summary.Endpoints, *endpoint)
+	}
+
+	sort.Slice(summary.Endpoints, func(i, j int) bool {
+		return summary.Endpoints[i].EndpointID < summary.Endpoints[j].EndpointID
+	})
+
+	for _, edgeGroupID := range edgeJob.EdgeGroups {
+		group := EdgeJobEdgeGroupRuns{EdgeGroupID: edgeGroupID}
+
+		for _, endpointID := range edgeGroupEndpoints[edgeGroupID] {
+			group.add(endpointRuns[endpointID].Status)
+		}
+
+		summary.EdgeGroups = append(summary.EdgeGroups, group)
+	}
+
+	return summary
+}
@@ -0,0 +1,85 @@
+package edge
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TruncateEdgeJobRunOutput(t *testing.T) {
+	is := assert.New(t)
+
+	output, truncated := TruncateEdgeJobRunOutput("done")
+	is.False(truncated)
+	is.Equal("done", output)
+
+	output, truncated = TruncateEdgeJobRunOutput(strings.Repeat("é", EdgeJobRunOutputMaxSize) + "exit status 1")
+	is.True(truncated)
+	is.True(strings.HasSuffix(output, "exit status 1"), "the end of the output should be kept")
+	is.LessOrEqual(len(output), EdgeJobRunOutputMaxSize)
+	is.True(utf8.ValidString(output))
+}
+
+func Test_ValidateEdgeJobRetryPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateEdgeJobRetryPolicy(0, 0, 0))
+	is.NoError(ValidateEdgeJobRetryPolicy(3, 60, 300))
+
+	is.Error(ValidateEdgeJobRetryPolicy(-1, 0, 0))
+	is.Error(ValidateEdgeJobRetryPolicy(EdgeJobMaxRetries+1, 0, 0))
+	is.Error(ValidateEdgeJobRetryPolicy(1, -1, 0))
+	is.Error(ValidateEdgeJobRetryPolicy(1, 0, -1))
+}
+
+func Test_SummarizeEdgeJobRuns(t *testing.T) {
+	is := assert.New(t)
+
+	edgeJob := &portainer.EdgeJob{
+		ID:         1,
+		Version:    2,
+		MaxRetries: 1,
+		Endpoints:  map[portainer.EndpointID]portainer.EdgeJobEndpointMeta{1: {}},
+		EdgeGroups: []portainer.EdgeGroupID{1, 2},
+	}
+
+	edgeGroupEndpoints := map[portainer.EdgeGroupID][]portainer.EndpointID{
+		1: {2, 3},
+		2: {3, 4, 5},
+	}
+
+	runs := []portainer.EdgeJobRun{
+		{ID: 1, EndpointID: 1, Version: 1, Attempt: 1, ExitCode: 0},
+		{ID: 2, EndpointID: 2, Version: 2, Attempt: 1, ExitCode: 1},
+		{ID: 3, EndpointID: 3, Version: 2, Attempt: 1, ExitCode: 1},
+		{ID: 4, EndpointID: 3, Version: 2, Attempt: 2, TimedOut: true},
+		{ID: 5, EndpointID: 4, Version: 2, Attempt: 1, ExitCode: 1},
+		{ID: 6, EndpointID: 4, Version: 2, Attempt: 2, ExitCode: 0},
+		{ID: 7, EndpointID: 9, Version: 2, Attempt: 1, ExitCode: 0},
+	}
+
+	summary := SummarizeEdgeJobRuns(edgeJob, runs, edgeGroupEndpoints)
+
+	statuses := map[portainer.EndpointID]EdgeJobRunStatus{}
+	for _, endpoint := range summary.Endpoints {
+		statuses[endpoint.EndpointID] = endpoint.Status
+	}
+
+	is.Equal(map[portainer.EndpointID]EdgeJobRunStatus{
+		1: EdgeJobRunPending, // only ran the previous version
+		2: EdgeJobRunRetrying,
+		3: EdgeJobRunFailed,
+		4: EdgeJobRunSucceeded,
+		5: EdgeJobRunPending,
+	}, statuses, "the environments which are not targeted should be ignored")
+
+	is.Equal(EdgeJobRunCounts{Total: 5, Pending: 2, Succeeded: 1, Retrying: 1, Failed: 1}, summary.EdgeJobRunCounts)
+	is.Equal([]EdgeJobEdgeGroupRuns{
+		{EdgeGroupID: 1, EdgeJobRunCounts: EdgeJobRunCounts{Total: 2, Retrying: 1, Failed: 1}},
+		{EdgeGroupID: 2, EdgeJobRunCounts: EdgeJobRunCounts{Total: 3, Pending: 1, Succeeded: 1, Failed: 1}},
+	}, summary.EdgeGroups)
+}
@@ -0,0 +1,133 @@
+package edgestacks
+
+import (
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// AdvertisedVersions returns the versions of an edge stack that were last advertised to the agents
+func AdvertisedVersions(tx dataservices.DataStoreTx, stackID portainer.EdgeStackID) (map[int]bool, error) {
+	relations, err := tx.EndpointRelation().EndpointRelations()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to retrieve the environment relations")
+	}
+
+	versions := map[int]bool{}
+	for _, relation := range relations {
+		if version, ok := relation.EdgeStackVersions[stackID]; ok {
+			versions[version] = true
+		}
+	}
+
+	return versions, nil
+}
+
+// KeepHeldVersions keeps the previous version of an edge stack when the version is still advertised to agents, which
+// are held back by the deployment windows, and drops the versions no longer advertised. The previous version is only
+// kept when its files were stored.
+func KeepHeldVersions(stack *portainer.EdgeStack, advertisedVersions map[int]bool, previousVersion int, hasPreviousFiles bool) {
+	heldVersions := []int{}
+	for _, version := range stack.HeldVersions {
+		if advertisedVersions[version] && version != previousVersion {
+			heldVersions = append(heldVersions, version)
+		}
+	}
+
+	if advertisedVersions[previousVersion] && hasPreviousFiles {
+		heldVersions = append(heldVersions, previousVersion)
+	}
+
+	stack.HeldVersions = nil
+	if len(heldVersions) > 0 {
+		stack.HeldVersions = heldVersions
+	}
+}
+
+// StoredVersions returns the previous versions of an edge stack whose files are stored on disk, for the environments
+// (endpoints) held back by the deployment windows or waiting for their batch of a rollout
+func StoredVersions(stack *portainer.EdgeStack) []int {
+	versions := append([]int{}, stack.HeldVersions...)
+
+	if stack.Rollout != nil {
+		versions = append(versions, stack.Rollout.PreviousVersion)
+	}
+
+	return versions
+}
+
+// StoreVersionFiles stores the files of a version of an edge stack before they are replaced by a new version
+func StoreVersionFiles(fileService portainer.FileService, stack *portainer.EdgeStack, version int, files map[string]string) error {
+	stackFolder := strconv.Itoa(int(stack.ID))
+
+	for fileName, content := range files {
+		_, err := fileService.StoreEdgeStackVersionFileFromBytes(stackFolder, version, fileName, []byte(content))
+		if err != nil {
+			return errors.WithMessagef(err, "failed to store the files of the version %d", version)
+		}
+	}
+
+	return nil
+}
+
+// RemoveVersionFiles removes the stored files of the given versions when the edge stack no longer uses them
+func RemoveVersionFiles(fileService portainer.FileService, stack *portainer.EdgeStack, versions []int) {
+	used := map[int]bool{}
+	for _, version := range StoredVersions(stack) {
+		used[version] = true
+	}
+
+	stackFolder := strconv.Itoa(int(stack.ID))
+
+	for _, version := range versions {
+		if used[version] {
+			continue
+		}
+
+		err := fileService.RemoveDirectory(fileService.GetEdgeStackVersionPath(stackFolder, version))
+		if err != nil {
+			log.Warn().Err(err).Int("edge_stack_id", int(stack.ID)).Int("version", version).Msg("unable to remove the files of a previous version of the edge stack")
+		}
+	}
+}
+
+// isStoredVersion returns true when the files of a previous version of an edge stack are stored on disk
+func isStoredVersion(stack *portainer.EdgeStack, version int) bool {
+	for _, storedVersion := range StoredVersions(stack) {
+		if storedVersion == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasVersionFiles returns true when the files of a previous version of an edge stack are still available, so that the
+// version can be kept on the environments(endpoints) held back by the deployment windows
+func HasVersionFiles(fileService portainer.FileService, stack *portainer.EdgeStack, version int) bool {
+	if version == 0 || version == stack.Version || !isStoredVersion(stack, version) {
+		return false
+	}
+
+	exists, err := fileService.FileExists(fileService.GetEdgeStackVersionPath(strconv.Itoa(int(stack.ID)), version))
+
+	return err == nil && exists
+}
+
+// versionFile returns the content of a file of a previous version of an edge stack
+func versionFile(fileService portainer.FileService, stack *portainer.EdgeStack, version int, fileName string) (string, bool) {
+	if version == 0 || version == stack.Version || !isStoredVersion(stack, version) {
+		return "", false
+	}
+
+	content, err := fileService.GetFileContent(fileService.GetEdgeStackVersionPath(strconv.Itoa(int(stack.ID)), version), fileName)
+	if err != nil {
+		return "", false
+	}
+
+	return string(content), true
+}
@@ -0,0 +1,53 @@
+package edgestacks
+
+import (
+	"fmt"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeepHeldVersions(t *testing.T) {
+	is := assert.New(t)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	stack := &portainer.EdgeStack{
+		ID:           1,
+		Version:      4,
+		HeldVersions: []int{1, 2},
+	}
+
+	for version := 1; version <= 3; version++ {
+		files := map[string]string{"docker-compose.yml": fmt.Sprintf("version: '%d'", version)}
+		is.NoError(StoreVersionFiles(fileService, stack, version, files))
+	}
+
+	storedVersions := append(StoredVersions(stack), 3)
+
+	KeepHeldVersions(stack, map[int]bool{2: true, 3: true}, 3, true)
+	is.Equal([]int{2, 3}, stack.HeldVersions, "only the advertised versions should be kept")
+
+	RemoveVersionFiles(fileService, stack, storedVersions)
+
+	is.True(HasVersionFiles(fileService, stack, 2))
+	is.False(HasVersionFiles(fileService, stack, 1))
+
+	exists, err := fileService.FileExists(fileService.GetEdgeStackVersionPath("1", 1))
+	is.NoError(err)
+	is.False(exists, "the files of the versions no longer held should be removed")
+
+	content, ok := versionFile(fileService, stack, 3, "docker-compose.yml")
+	is.True(ok)
+	is.Equal("version: '3'", content)
+
+	_, ok = versionFile(fileService, stack, 4, "docker-compose.yml")
+	is.False(ok, "the current version is read from the edge stack folder")
+
+	KeepHeldVersions(stack, map[int]bool{4: true}, 4, false)
+	is.Nil(stack.HeldVersions)
+}
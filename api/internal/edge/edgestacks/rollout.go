@@ -0,0 +1,246 @@
+package edgestacks
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ValidateRolloutPolicy verifies the batch size and the failure settings of a rollout policy
+func ValidateRolloutPolicy(policy *portainer.EdgeStackRolloutPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.BatchSize < 0 {
+		return errors.New("the batch size can't be negative")
+	}
+
+	if policy.BatchPercentage < 0 || policy.BatchPercentage > 100 {
+		return errors.New("the batch percentage must be between 0 and 100")
+	}
+
+	if policy.BatchSize == 0 && policy.BatchPercentage == 0 {
+		return errors.New("the batch size or the batch percentage is required")
+	}
+
+	if policy.FailureThreshold < 0 || policy.FailureThreshold > 100 {
+		return errors.New("the failure threshold must be between 0 and 100")
+	}
+
+	switch policy.FailureAction {
+	case "", portainer.EdgeStackRolloutPause, portainer.EdgeStackRolloutRollback:
+	default:
+		return errors.Errorf("invalid failure action %q", policy.FailureAction)
+	}
+
+	return nil
+}
+
+// IsRolloutActive returns true when the rollout still has environments(endpoints) waiting for the new version
+func IsRolloutActive(rollout *portainer.EdgeStackRollout) bool {
+	return rollout != nil && (rollout.Status == portainer.EdgeStackRolloutInProgress || rollout.Status == portainer.EdgeStackRolloutPaused)
+}
+
+// IsEndpointReleased returns true when the environment(endpoint) is deployed with the version of the rollout. The
+// environments which joined the edge stack during the rollout never ran the previous version, they are deployed with
+// the version of the rollout right away.
+func IsEndpointReleased(rollout *portainer.EdgeStackRollout, endpointID portainer.EndpointID) bool {
+	if !IsRolloutActive(rollout) {
+		return true
+	}
+
+	for i, batch := range rollout.Batches {
+		for _, id := range batch {
+			if id == endpointID {
+				return i < rollout.ReleasedBatches
+			}
+		}
+	}
+
+	return true
+}
+
+// RolloutFile returns the content of a file of the previous version when the environment(endpoint) waits for its
+// batch of the rollout of the edge stack
+func RolloutFile(fileService portainer.FileService, stack *portainer.EdgeStack, endpointID portainer.EndpointID, fileName string) (string, bool) {
+	if IsEndpointReleased(stack.Rollout, endpointID) {
+		return "", false
+	}
+
+	return versionFile(fileService, stack, stack.Rollout.PreviousVersion, fileName)
+}
+
+// StartRollout starts the staged deployment of the current version of the edge stack when it has a rollout policy,
+// the environments(endpoints) of the first batch are released immediately. When a rollout is already active, the new
+// rollout starts again from its previous version, which is still deployed on the environments of its pending batches.
+// The files of the previous version are stored on disk by the caller.
+func StartRollout(stack *portainer.EdgeStack, previousVersion int, previousConfigHash string, endpointIDs []portainer.EndpointID) {
+	if stack.RolloutPolicy == nil {
+		stack.Rollout = nil
+		return
+	}
+
+	if IsRolloutActive(stack.Rollout) {
+		previousVersion = stack.Rollout.PreviousVersion
+		previousConfigHash = stack.Rollout.PreviousConfigHash
+	}
+
+	now := time.Now().Unix()
+	stack.Rollout = &portainer.EdgeStackRollout{
+		Status:             portainer.EdgeStackRolloutInProgress,
+		Version:            stack.Version,
+		PreviousVersion:    previousVersion,
+		PreviousConfigHash: previousConfigHash,
+		Batches:            rolloutBatches(stack.RolloutPolicy, endpointIDs),
+		StartedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	releaseNextBatch(stack)
+}
+
+// rolloutBatches splits the environments(endpoints) in batches, sorted by identifier to keep the same order across
+// the rollouts
+func rolloutBatches(policy *portainer.EdgeStackRolloutPolicy, endpointIDs []portainer.EndpointID) [][]portainer.EndpointID {
+	sorted := make([]portainer.EndpointID, len(endpointIDs))
+	copy(sorted, endpointIDs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	size := policy.BatchSize
+	if size == 0 {
+		size = (len(sorted)*policy.BatchPercentage + 99) / 100
+	}
+
+	if size < 1 {
+		size = 1
+	}
+
+	batches := [][]portainer.EndpointID{}
+	for start := 0; start < len(sorted); start += size {
+		end := start + size
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+
+		batches = append(batches, sorted[start:end])
+	}
+
+	return batches
+}
+
+// releaseNextBatch releases the next batch of environments(endpoints) of the rollout. Their statuses were reported
+// for the previous version, they are cleared so that the batch is only healthy once it deployed the new version.
+func releaseNextBatch(stack *portainer.EdgeStack) {
+	rollout := stack.Rollout
+
+	if rollout.ReleasedBatches < len(rollout.Batches) {
+		for _, endpointID := range rollout.Batches[rollout.ReleasedBatches] {
+			delete(stack.Status, endpointID)
+		}
+	}
+
+	rollout.ReleasedBatches++
+	rollout.UpdatedAt = time.Now().Unix()
+
+	if rollout.ReleasedBatches >= len(rollout.Batches) {
+		rollout.ReleasedBatches = len(rollout.Batches)
+		rollout.Status = portainer.EdgeStackRolloutCompleted
+	}
+}
+
+// rolloutHealth counts the released environments(endpoints), and the ones which reported a successful or a failed
+// deployment
+func rolloutHealth(stack *portainer.EdgeStack) (released, ok, failed int) {
+	rollout := stack.Rollout
+
+	for i := 0; i < rollout.ReleasedBatches && i < len(rollout.Batches); i++ {
+		for _, endpointID := range rollout.Batches[i] {
+			released++
+
+			details := stack.Status[endpointID].Details
+			if details.Error {
+				failed++
+			} else if details.Ok {
+				ok++
+			}
+		}
+	}
+
+	return released, ok, failed
+}
+
+// EvaluateRollout releases the next batch of the rollout once every released environment(endpoint) reported its
+// deployment, and stops the rollout when the failures exceed the threshold of the policy. It returns true when the
+// rollout was rolled back, the files of the previous version must then be restored by the caller.
+func EvaluateRollout(stack *portainer.EdgeStack) bool {
+	rollout := stack.Rollout
+	if rollout == nil || rollout.Status != portainer.EdgeStackRolloutInProgress || stack.RolloutPolicy == nil {
+		return false
+	}
+
+	released, ok, failed := rolloutHealth(stack)
+	if released == 0 {
+		return false
+	}
+
+	if (failed-rollout.AcceptedFailures)*100 > stack.RolloutPolicy.FailureThreshold*released {
+		reason := fmt.Sprintf("%d of the %d deployed environments failed", failed, released)
+
+		if stack.RolloutPolicy.FailureAction == portainer.EdgeStackRolloutRollback {
+			RollbackRollout(stack, reason)
+			return true
+		}
+
+		rollout.Status = portainer.EdgeStackRolloutPaused
+		rollout.Reason = reason
+		rollout.UpdatedAt = time.Now().Unix()
+
+		return false
+	}
+
+	if ok+failed == released {
+		releaseNextBatch(stack)
+	}
+
+	return false
+}
+
+// PromoteRollout releases the next batch of an active rollout without waiting for the released environments(endpoints),
+// a paused rollout is resumed and its current failures are accepted
+func PromoteRollout(stack *portainer.EdgeStack) error {
+	rollout := stack.Rollout
+	if !IsRolloutActive(rollout) {
+		return errors.New("the edge stack has no active rollout")
+	}
+
+	if rollout.Status == portainer.EdgeStackRolloutPaused {
+		_, _, rollout.AcceptedFailures = rolloutHealth(stack)
+	}
+
+	rollout.Status = portainer.EdgeStackRolloutInProgress
+	rollout.Reason = ""
+	releaseNextBatch(stack)
+
+	return nil
+}
+
+// RollbackRollout stops the rollout and deploys its previous version to every environment(endpoint) under a new
+// version number, the files of the previous version must be restored by the caller
+func RollbackRollout(stack *portainer.EdgeStack, reason string) {
+	rollout := stack.Rollout
+
+	stack.Version++
+	stack.Status = make(map[portainer.EndpointID]portainer.EdgeStackStatus)
+
+	if stack.GitConfig != nil && rollout.PreviousConfigHash != "" {
+		stack.GitConfig.ConfigHash = rollout.PreviousConfigHash
+	}
+
+	rollout.Status = portainer.EdgeStackRolloutRolledBack
+	rollout.Reason = reason
+	rollout.UpdatedAt = time.Now().Unix()
+}
@@ -0,0 +1,173 @@
+package edgestacks
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRolloutStack(policy *portainer.EdgeStackRolloutPolicy) *portainer.EdgeStack {
+	stack := &portainer.EdgeStack{
+		ID:            1,
+		Version:       2,
+		Status:        map[portainer.EndpointID]portainer.EdgeStackStatus{},
+		RolloutPolicy: policy,
+	}
+
+	StartRollout(stack, 1, "", []portainer.EndpointID{5, 1, 4, 2, 3})
+
+	return stack
+}
+
+func reportStatus(stack *portainer.EdgeStack, endpointID portainer.EndpointID, ok bool) bool {
+	stack.Status[endpointID] = portainer.EdgeStackStatus{
+		EndpointID: endpointID,
+		Details:    portainer.EdgeStackStatusDetails{Ok: ok, Error: !ok},
+	}
+
+	return EvaluateRollout(stack)
+}
+
+func Test_ValidateRolloutPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateRolloutPolicy(nil))
+	is.NoError(ValidateRolloutPolicy(&portainer.EdgeStackRolloutPolicy{BatchSize: 2}))
+	is.NoError(ValidateRolloutPolicy(&portainer.EdgeStackRolloutPolicy{BatchPercentage: 10, FailureThreshold: 20, FailureAction: portainer.EdgeStackRolloutRollback}))
+
+	is.Error(ValidateRolloutPolicy(&portainer.EdgeStackRolloutPolicy{}), "the batch size is required")
+	is.Error(ValidateRolloutPolicy(&portainer.EdgeStackRolloutPolicy{BatchPercentage: 120}))
+	is.Error(ValidateRolloutPolicy(&portainer.EdgeStackRolloutPolicy{BatchSize: 1, FailureThreshold: -1}))
+	is.Error(ValidateRolloutPolicy(&portainer.EdgeStackRolloutPolicy{BatchSize: 1, FailureAction: "retry"}))
+}
+
+func Test_rolloutBatches(t *testing.T) {
+	is := assert.New(t)
+
+	endpointIDs := []portainer.EndpointID{3, 1, 2, 5, 4}
+
+	is.Equal([][]portainer.EndpointID{{1, 2}, {3, 4}, {5}}, rolloutBatches(&portainer.EdgeStackRolloutPolicy{BatchSize: 2}, endpointIDs))
+	is.Equal([][]portainer.EndpointID{{1, 2}, {3, 4}, {5}}, rolloutBatches(&portainer.EdgeStackRolloutPolicy{BatchPercentage: 30}, endpointIDs))
+	is.Equal([][]portainer.EndpointID{{1}, {2}, {3}, {4}, {5}}, rolloutBatches(&portainer.EdgeStackRolloutPolicy{BatchPercentage: 1}, endpointIDs))
+	is.Equal([]portainer.EndpointID{3, 1, 2, 5, 4}, endpointIDs, "the environments should not be sorted in place")
+}
+
+func Test_EvaluateRollout_releasesHealthyBatches(t *testing.T) {
+	is := assert.New(t)
+
+	stack := newRolloutStack(&portainer.EdgeStackRolloutPolicy{BatchSize: 2})
+	is.Equal(portainer.EdgeStackRolloutInProgress, stack.Rollout.Status)
+	is.Equal(1, stack.Rollout.ReleasedBatches)
+
+	is.True(IsEndpointReleased(stack.Rollout, 1))
+	is.False(IsEndpointReleased(stack.Rollout, 3))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+	is.NoError(StoreVersionFiles(fileService, stack, 1, map[string]string{"docker-compose.yml": "version: '3'"}))
+
+	content, ok := RolloutFile(fileService, stack, 3, "docker-compose.yml")
+	is.True(ok)
+	is.Equal("version: '3'", content)
+
+	_, ok = RolloutFile(fileService, stack, 1, "docker-compose.yml")
+	is.False(ok, "the released environments should get the current files")
+
+	is.False(reportStatus(stack, 1, true))
+	is.Equal(1, stack.Rollout.ReleasedBatches, "the next batch should wait for the whole batch")
+
+	is.False(reportStatus(stack, 2, true))
+	is.Equal(2, stack.Rollout.ReleasedBatches)
+
+	reportStatus(stack, 3, true)
+	reportStatus(stack, 4, true)
+	is.Equal(portainer.EdgeStackRolloutCompleted, stack.Rollout.Status)
+	is.True(IsEndpointReleased(stack.Rollout, 5))
+}
+
+func Test_EvaluateRollout_pausesOnFailures(t *testing.T) {
+	is := assert.New(t)
+
+	stack := newRolloutStack(&portainer.EdgeStackRolloutPolicy{BatchSize: 2, FailureThreshold: 25})
+
+	is.False(reportStatus(stack, 1, false))
+	is.Equal(portainer.EdgeStackRolloutPaused, stack.Rollout.Status)
+	is.NotEmpty(stack.Rollout.Reason)
+	is.False(IsEndpointReleased(stack.Rollout, 3), "a paused rollout should keep the previous version")
+
+	is.NoError(PromoteRollout(stack))
+	is.Equal(portainer.EdgeStackRolloutInProgress, stack.Rollout.Status)
+	is.Equal(2, stack.Rollout.ReleasedBatches)
+
+	// the failure accepted by the promotion doesn't pause the rollout again
+	is.False(reportStatus(stack, 2, true))
+	is.Equal(portainer.EdgeStackRolloutInProgress, stack.Rollout.Status)
+}
+
+func Test_EvaluateRollout_rollsBack(t *testing.T) {
+	is := assert.New(t)
+
+	stack := newRolloutStack(&portainer.EdgeStackRolloutPolicy{BatchSize: 2, FailureAction: portainer.EdgeStackRolloutRollback})
+
+	is.True(reportStatus(stack, 2, false))
+	is.Equal(portainer.EdgeStackRolloutRolledBack, stack.Rollout.Status)
+	is.Equal(3, stack.Version, "the previous version should be deployed under a new version")
+	is.Empty(stack.Status)
+	is.True(IsEndpointReleased(stack.Rollout, 5))
+
+	is.Error(PromoteRollout(stack))
+}
+
+func Test_StartRollout_keepsStableVersion(t *testing.T) {
+	is := assert.New(t)
+
+	stack := newRolloutStack(&portainer.EdgeStackRolloutPolicy{BatchSize: 2})
+
+	stack.Version = 3
+	StartRollout(stack, 2, "", []portainer.EndpointID{1, 2, 3, 4, 5})
+
+	is.Equal(3, stack.Rollout.Version)
+	is.Equal(1, stack.Rollout.PreviousVersion, "the pending batches still run the version deployed before the active rollout")
+	is.Equal([]int{1}, StoredVersions(stack))
+
+	stack.RolloutPolicy = nil
+	StartRollout(stack, 3, "", nil)
+	is.Nil(stack.Rollout)
+}
+
+func Test_EvaluateRollout_clearsReleasedStatuses(t *testing.T) {
+	is := assert.New(t)
+
+	stack := newRolloutStack(&portainer.EdgeStackRolloutPolicy{BatchSize: 2})
+
+	// the environments of the pending batches report the deployment of the previous version
+	stack.Status[3] = portainer.EdgeStackStatus{EndpointID: 3, Details: portainer.EdgeStackStatusDetails{Ok: true}}
+	stack.Status[4] = portainer.EdgeStackStatus{EndpointID: 4, Details: portainer.EdgeStackStatusDetails{Ok: true}}
+
+	reportStatus(stack, 1, true)
+	reportStatus(stack, 2, true)
+	is.Equal(2, stack.Rollout.ReleasedBatches)
+	is.NotContains(stack.Status, portainer.EndpointID(3), "the statuses of the previous version should be cleared")
+	is.NotContains(stack.Status, portainer.EndpointID(4))
+
+	is.False(EvaluateRollout(stack))
+	is.Equal(2, stack.Rollout.ReleasedBatches, "the batch should wait for the deployment of the new version")
+}
+
+func Test_IsEndpointReleased_newEndpoints(t *testing.T) {
+	is := assert.New(t)
+
+	stack := newRolloutStack(&portainer.EdgeStackRolloutPolicy{BatchSize: 2})
+
+	is.True(IsEndpointReleased(stack.Rollout, 6), "the environments which joined during the rollout should get the new version")
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+	is.NoError(StoreVersionFiles(fileService, stack, 1, map[string]string{"docker-compose.yml": "version: '3'"}))
+
+	_, ok := RolloutFile(fileService, stack, 6, "docker-compose.yml")
+	is.False(ok)
+}
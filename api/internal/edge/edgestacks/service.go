@@ -166,5 +166,10 @@ func (service *Service) DeleteEdgeStack(tx dataservices.DataStoreTx, edgeStackID
 		return errors.WithMessage(err, "Unable to remove the edge stack from the database")
 	}
 
+	err = tx.EdgeStackStatusEvent().DeleteEdgeStackStatusEvents(edgeStackID)
+	if err != nil {
+		return errors.WithMessage(err, "Unable to remove the status events of the edge stack from the database")
+	}
+
 	return nil
 }
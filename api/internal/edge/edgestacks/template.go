@@ -0,0 +1,249 @@
+package edgestacks
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+)
+
+var edgeVariableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TemplateData holds the values of an environment(endpoint) available to the templates of the edge stacks,
+// e.g. {{ .EndpointName }} or {{ .Variables.region }}
+type TemplateData struct {
+	EndpointID    portainer.EndpointID
+	EndpointName  string
+	EdgeID        string
+	EndpointGroup string
+	// Names of the tags of the environment, sorted by name
+	Tags []string
+	// Custom values of the environment
+	Variables map[string]string
+}
+
+// HasTag returns true when the environment(endpoint) has the tag, e.g. {{ if .HasTag "gpu" }}
+func (data TemplateData) HasTag(name string) bool {
+	for _, tag := range data.Tags {
+		if tag == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateEdgeVariables verifies that the names of the custom values of an environment(endpoint) can be referenced
+// by the templates
+func ValidateEdgeVariables(variables map[string]string) error {
+	for name := range variables {
+		if !edgeVariableNameRegex.MatchString(name) {
+			return errors.Errorf("invalid variable name %q, the names must only contain letters, digits and underscores", name)
+		}
+	}
+
+	return nil
+}
+
+// FetchTemplateData returns the values of the environment(endpoint) available to the templates
+func FetchTemplateData(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) (*TemplateData, error) {
+	data := &TemplateData{
+		EndpointID:   endpoint.ID,
+		EndpointName: endpoint.Name,
+		EdgeID:       endpoint.EdgeID,
+		Tags:         []string{},
+		Variables:    map[string]string{},
+	}
+
+	group, err := tx.EndpointGroup().EndpointGroup(endpoint.GroupID)
+	if err != nil && !tx.IsErrObjectNotFound(err) {
+		return nil, errors.WithMessage(err, "failed to retrieve the environment group")
+	} else if err == nil {
+		data.EndpointGroup = group.Name
+	}
+
+	for _, tagID := range endpoint.TagIDs {
+		tag, err := tx.Tag().Tag(tagID)
+		if tx.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.WithMessage(err, "failed to retrieve the environment tags")
+		}
+
+		data.Tags = append(data.Tags, tag.Name)
+	}
+	sort.Strings(data.Tags)
+
+	for name, value := range endpoint.EdgeVariables {
+		data.Variables[name] = value
+	}
+
+	return data, nil
+}
+
+func parseTemplate(content string) (*template.Template, error) {
+	tmpl, err := template.New("edge-stack").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid template")
+	}
+
+	return tmpl, nil
+}
+
+// RenderTemplate renders the file of an edge stack with the values of an environment(endpoint)
+func RenderTemplate(content string, data *TemplateData) (string, error) {
+	tmpl, err := parseTemplate(content)
+	if err != nil {
+		return "", err
+	}
+
+	return executeTemplate(tmpl, data)
+}
+
+func executeTemplate(tmpl *template.Template, data *TemplateData) (string, error) {
+	var rendered bytes.Buffer
+
+	err := tmpl.Execute(&rendered, data)
+	if err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
+
+// ValidateTemplate renders the file of an edge stack with the values of every environment(endpoint), to verify that
+// all the referenced values are set for each of them
+func ValidateTemplate(tx dataservices.DataStoreTx, content string, endpointIDs []portainer.EndpointID) error {
+	tmpl, err := parseTemplate(content)
+	if err != nil {
+		return err
+	}
+
+	failures := []string{}
+	for _, endpointID := range endpointIDs {
+		endpoint, err := tx.Endpoint().Endpoint(endpointID)
+		if err != nil {
+			return errors.WithMessage(err, "failed to retrieve the environment")
+		}
+
+		data, err := FetchTemplateData(tx, endpoint)
+		if err != nil {
+			return err
+		}
+
+		_, err = executeTemplate(tmpl, data)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", endpoint.Name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("the template can't be rendered for the environments %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// EndpointFileName returns the name of the file of an edge stack deployed on an environment(endpoint), it is empty
+// when the edge stack does not support the type of the environment
+func EndpointFileName(stack *portainer.EdgeStack, endpoint *portainer.Endpoint) string {
+	if endpointutils.IsKubernetesEndpoint(endpoint) {
+		return stack.ManifestPath
+	}
+
+	return stack.EntryPoint
+}
+
+// EndpointFile returns the content of a file of the version of an edge stack advertised to an environment(endpoint),
+// the environments held back by the deployment windows or waiting for their batch of a rollout keep the file of a
+// previous version. The version is 0 when it is not known.
+func EndpointFile(fileService portainer.FileService, stack *portainer.EdgeStack, endpoint *portainer.Endpoint, fileName string, version int) (string, error) {
+	if content, ok := versionFile(fileService, stack, version, fileName); ok {
+		return content, nil
+	}
+
+	if content, ok := RolloutFile(fileService, stack, endpoint.ID, fileName); ok {
+		return content, nil
+	}
+
+	content, err := fileService.GetFileContent(stack.ProjectPath, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// ValidateEndpointTemplates renders the templated edge stacks related to an environment(endpoint) with its values,
+// to verify that its changes or the changes of its groups don't break them. The environment group is fetched when
+// it is not given.
+func ValidateEndpointTemplates(tx dataservices.DataStoreTx, fileService portainer.FileService, endpoint *portainer.Endpoint, endpointGroup *portainer.EndpointGroup) error {
+	if !endpointutils.IsEdgeEndpoint(endpoint) {
+		return nil
+	}
+
+	if endpointGroup == nil {
+		var err error
+		endpointGroup, err = tx.EndpointGroup().EndpointGroup(endpoint.GroupID)
+		if err != nil {
+			return errors.WithMessage(err, "failed to retrieve the environment group")
+		}
+	}
+
+	edgeGroups, err := tx.EdgeGroup().EdgeGroups()
+	if err != nil {
+		return errors.WithMessage(err, "failed to retrieve the edge groups")
+	}
+
+	edgeStacks, err := tx.EdgeStack().EdgeStacks()
+	if err != nil {
+		return errors.WithMessage(err, "failed to retrieve the edge stacks")
+	}
+
+	relatedStacks := map[portainer.EdgeStackID]bool{}
+	for _, edgeStackID := range edge.EndpointRelatedEdgeStacks(endpoint, endpointGroup, edgeGroups, edgeStacks) {
+		relatedStacks[edgeStackID] = true
+	}
+
+	var data *TemplateData
+	failures := []string{}
+	for i := range edgeStacks {
+		stack := &edgeStacks[i]
+		fileName := EndpointFileName(stack, endpoint)
+		if !stack.UseTemplating || !relatedStacks[stack.ID] || fileName == "" {
+			continue
+		}
+
+		if data == nil {
+			data, err = FetchTemplateData(tx, endpoint)
+			if err != nil {
+				return err
+			}
+			data.EndpointGroup = endpointGroup.Name
+		}
+
+		content, err := EndpointFile(fileService, stack, endpoint, fileName, 0)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to retrieve the file of the edge stack %s", stack.Name)
+		}
+
+		_, err = RenderTemplate(content, data)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", stack.Name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("the edge stacks can't be rendered for the environment %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package edgestacks
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderTemplate(t *testing.T) {
+	is := assert.New(t)
+
+	data := &TemplateData{
+		EndpointID:    3,
+		EndpointName:  "store-42",
+		EdgeID:        "edge-id",
+		EndpointGroup: "stores",
+		Tags:          []string{"gpu", "paris"},
+		Variables:     map[string]string{"region": "eu-west"},
+	}
+
+	rendered, err := RenderTemplate(`image: app:{{ .Variables.region }}
+hostname: {{ .EndpointName }}-{{ .EndpointID }}
+group: {{ .EndpointGroup }}{{ if .HasTag "gpu" }}
+runtime: nvidia{{ end }}`, data)
+	is.NoError(err)
+	is.Equal(`image: app:eu-west
+hostname: store-42-3
+group: stores
+runtime: nvidia`, rendered)
+
+	_, err = RenderTemplate("image: app:{{ .Variables.tier }}", data)
+	is.Error(err, "a missing variable should not be rendered as an empty value")
+
+	_, err = RenderTemplate("image: app:{{ .Variables.region", data)
+	is.Error(err)
+}
+
+func Test_ValidateEdgeVariables(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateEdgeVariables(map[string]string{"region": "eu", "_RACK_2": "b"}))
+	is.Error(ValidateEdgeVariables(map[string]string{"rack-id": "b"}))
+	is.Error(ValidateEdgeVariables(map[string]string{"2nd": "b"}))
+}
+
+func Test_ValidateTemplate(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	is.NoError(store.EndpointGroup().Create(&portainer.EndpointGroup{ID: 2, Name: "stores"}))
+	is.NoError(store.Tag().Create(&portainer.Tag{ID: 1, Name: "gpu"}))
+
+	endpoints := []portainer.Endpoint{
+		{ID: 1, Name: "paris", GroupID: 2, TagIDs: []portainer.TagID{1}, EdgeVariables: map[string]string{"region": "eu"}},
+		{ID: 2, Name: "austin", GroupID: 2, EdgeVariables: map[string]string{"region": "us"}},
+		{ID: 3, Name: "lab", GroupID: 2},
+	}
+	for i := range endpoints {
+		is.NoError(store.Endpoint().Create(&endpoints[i]))
+	}
+
+	data, err := FetchTemplateData(store, &endpoints[0])
+	is.NoError(err)
+	is.Equal("stores", data.EndpointGroup)
+	is.Equal([]string{"gpu"}, data.Tags)
+
+	content := "image: app:{{ .Variables.region }}"
+	is.NoError(ValidateTemplate(store, content, []portainer.EndpointID{1, 2}))
+
+	err = ValidateTemplate(store, content, []portainer.EndpointID{1, 2, 3})
+	is.ErrorContains(err, "lab", "the environments without the variable should be reported")
+	is.NotContains(err.Error(), "paris")
+}
+
+func Test_ValidateEndpointTemplates(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	projectPath, err := fileService.StoreEdgeStackFileFromBytes("1", "docker-compose.yml", []byte("image: app:{{ .Variables.region }}"))
+	is.NoError(err)
+
+	is.NoError(store.EndpointGroup().Create(&portainer.EndpointGroup{ID: 1, Name: "Unassigned"}))
+	is.NoError(store.EdgeGroup().Create(&portainer.EdgeGroup{ID: 1, Name: "stores", Endpoints: []portainer.EndpointID{1}}))
+	is.NoError(store.EdgeStack().Create(1, &portainer.EdgeStack{
+		ID:            1,
+		Name:          "app",
+		EdgeGroups:    []portainer.EdgeGroupID{1},
+		ProjectPath:   projectPath,
+		EntryPoint:    "docker-compose.yml",
+		UseTemplating: true,
+	}))
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "paris", GroupID: 1, Type: portainer.EdgeAgentOnDockerEnvironment, EdgeVariables: map[string]string{"region": "eu"}}
+	is.NoError(ValidateEndpointTemplates(store, fileService, endpoint, nil))
+
+	endpoint.EdgeVariables = nil
+	err = ValidateEndpointTemplates(store, fileService, endpoint, nil)
+	is.ErrorContains(err, "app", "removing a referenced variable should be rejected")
+
+	other := &portainer.Endpoint{ID: 2, Name: "lab", GroupID: 1, Type: portainer.EdgeAgentOnDockerEnvironment}
+	is.NoError(ValidateEndpointTemplates(store, fileService, other, nil), "the environments outside of the edge groups should be ignored")
+}
@@ -0,0 +1,126 @@
+package edge
+
+import (
+	"time"
+	// the time zones of the deployment windows are resolved without the system database
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/robfig/cron/v3"
+)
+
+type deploymentWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+	location *time.Location
+}
+
+func parseDeploymentWindow(window *portainer.EdgeDeploymentWindow) (*deploymentWindow, error) {
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid deployment window schedule")
+	}
+
+	duration, err := time.ParseDuration(window.Duration)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid deployment window duration")
+	}
+
+	if duration <= 0 {
+		return nil, errors.New("the deployment window duration must be positive")
+	}
+
+	location := time.UTC
+	if window.Timezone != "" {
+		location, err = time.LoadLocation(window.Timezone)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid deployment window time zone")
+		}
+	}
+
+	return &deploymentWindow{schedule: schedule, duration: duration, location: location}, nil
+}
+
+// ValidateDeploymentWindow verifies the schedule, the duration and the time zone of a deployment window
+func ValidateDeploymentWindow(window *portainer.EdgeDeploymentWindow) error {
+	if window == nil {
+		return nil
+	}
+
+	_, err := parseDeploymentWindow(window)
+
+	return err
+}
+
+// InDeploymentWindow returns true when the time is between one of the beginnings of the window and the end of its duration
+func InDeploymentWindow(window *portainer.EdgeDeploymentWindow, now time.Time) (bool, error) {
+	w, err := parseDeploymentWindow(window)
+	if err != nil {
+		return false, err
+	}
+
+	// the window is open when it began less than a duration ago
+	start := w.schedule.Next(now.Add(-w.duration).In(w.location))
+
+	return !start.After(now), nil
+}
+
+// EdgeStackDeploymentWindows returns the deployment windows applying to an edge stack on an environment(endpoint):
+// the window of the edge stack, or the windows of its edge groups that include the environment
+func EdgeStackDeploymentWindows(tx dataservices.DataStoreTx, edgeStack *portainer.EdgeStack, endpoint *portainer.Endpoint) ([]*portainer.EdgeDeploymentWindow, error) {
+	if edgeStack.DeploymentWindow != nil {
+		return []*portainer.EdgeDeploymentWindow{edgeStack.DeploymentWindow}, nil
+	}
+
+	endpointGroup, err := tx.EndpointGroup().EndpointGroup(endpoint.GroupID)
+	if tx.IsErrObjectNotFound(err) {
+		endpointGroup = &portainer.EndpointGroup{}
+	} else if err != nil {
+		return nil, errors.WithMessage(err, "failed to retrieve the environment group")
+	}
+
+	windows := []*portainer.EdgeDeploymentWindow{}
+	for _, edgeGroupID := range edgeStack.EdgeGroups {
+		edgeGroup, err := tx.EdgeGroup().EdgeGroup(edgeGroupID)
+		if tx.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.WithMessage(err, "failed to retrieve the edge group")
+		}
+
+		if edgeGroup.DeploymentWindow != nil && edgeGroupRelatedToEndpoint(edgeGroup, endpoint, endpointGroup) {
+			windows = append(windows, edgeGroup.DeploymentWindow)
+		}
+	}
+
+	return windows, nil
+}
+
+// CanDeployEdgeStack returns true when a new version of the edge stack can be deployed to the environment(endpoint)
+// at this time, i.e. it has no deployment window or one of its windows is open
+func CanDeployEdgeStack(tx dataservices.DataStoreTx, edgeStack *portainer.EdgeStack, endpoint *portainer.Endpoint, now time.Time) (bool, error) {
+	windows, err := EdgeStackDeploymentWindows(tx, edgeStack, endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	if len(windows) == 0 {
+		return true, nil
+	}
+
+	for _, window := range windows {
+		open, err := InDeploymentWindow(window, now)
+		if err != nil {
+			return false, err
+		}
+
+		if open {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
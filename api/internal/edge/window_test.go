@@ -0,0 +1,85 @@
+package edge
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateDeploymentWindow(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateDeploymentWindow(nil))
+	is.NoError(ValidateDeploymentWindow(&portainer.EdgeDeploymentWindow{Schedule: "0 2 * * *", Duration: "3h"}))
+	is.NoError(ValidateDeploymentWindow(&portainer.EdgeDeploymentWindow{Schedule: "@daily", Duration: "30m", Timezone: "Europe/Paris"}))
+
+	is.Error(ValidateDeploymentWindow(&portainer.EdgeDeploymentWindow{Schedule: "0 2 * *", Duration: "3h"}))
+	is.Error(ValidateDeploymentWindow(&portainer.EdgeDeploymentWindow{Schedule: "0 2 * * *", Duration: "3"}))
+	is.Error(ValidateDeploymentWindow(&portainer.EdgeDeploymentWindow{Schedule: "0 2 * * *", Duration: "-1h"}))
+	is.Error(ValidateDeploymentWindow(&portainer.EdgeDeploymentWindow{Schedule: "0 2 * * *", Duration: "3h", Timezone: "Mars/Olympus"}))
+}
+
+func Test_InDeploymentWindow(t *testing.T) {
+	is := assert.New(t)
+
+	// every night from 2am to 5am in Paris, UTC+2 in the summer
+	window := &portainer.EdgeDeploymentWindow{Schedule: "0 2 * * *", Duration: "3h", Timezone: "Europe/Paris"}
+
+	for now, expected := range map[string]bool{
+		"2023-07-01T23:59:00Z": false,
+		"2023-07-02T00:00:00Z": true,
+		"2023-07-02T02:59:00Z": true,
+		"2023-07-02T03:00:00Z": false,
+		"2023-07-02T12:00:00Z": false,
+	} {
+		at, err := time.Parse(time.RFC3339, now)
+		is.NoError(err)
+
+		open, err := InDeploymentWindow(window, at)
+		is.NoError(err)
+		is.Equal(expected, open, now)
+	}
+}
+
+func Test_CanDeployEdgeStack(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	night := &portainer.EdgeDeploymentWindow{Schedule: "0 2 * * *", Duration: "3h"}
+	noon := &portainer.EdgeDeploymentWindow{Schedule: "0 12 * * *", Duration: "1h"}
+
+	is.NoError(store.EndpointGroup().Create(&portainer.EndpointGroup{ID: 1, Name: "stores"}))
+	is.NoError(store.EdgeGroup().Create(&portainer.EdgeGroup{ID: 1, Name: "stores", Endpoints: []portainer.EndpointID{1}, DeploymentWindow: night}))
+	is.NoError(store.EdgeGroup().Create(&portainer.EdgeGroup{ID: 2, Name: "warehouses", Endpoints: []portainer.EndpointID{2}}))
+
+	store1 := &portainer.Endpoint{ID: 1, GroupID: 1}
+	warehouse := &portainer.Endpoint{ID: 2, GroupID: 1}
+
+	stack := &portainer.EdgeStack{ID: 1, EdgeGroups: []portainer.EdgeGroupID{1, 2}}
+
+	atNoon := time.Date(2023, 7, 2, 12, 30, 0, 0, time.UTC)
+
+	canDeploy, err := CanDeployEdgeStack(store, stack, store1, atNoon)
+	is.NoError(err)
+	is.False(canDeploy, "the window of the edge group should apply to its environments")
+
+	canDeploy, err = CanDeployEdgeStack(store, stack, warehouse, atNoon)
+	is.NoError(err)
+	is.True(canDeploy, "the window of the edge group should not apply to the other environments")
+
+	stack.DeploymentWindow = noon
+
+	canDeploy, err = CanDeployEdgeStack(store, stack, store1, atNoon)
+	is.NoError(err)
+	is.True(canDeploy, "the window of the edge stack should override the windows of the edge groups")
+
+	canDeploy, err = CanDeployEdgeStack(store, stack, warehouse, atNoon.Add(time.Hour))
+	is.NoError(err)
+	is.False(canDeploy)
+}
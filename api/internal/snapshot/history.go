@@ -0,0 +1,165 @@
+package snapshot
+
+import (
+	"sort"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// RawHistoryRetention is the period during which the snapshots are kept as is
+	RawHistoryRetention = 24 * time.Hour
+	// HourlyHistoryRetention is the period during which the hourly averages of the snapshots are kept
+	HourlyHistoryRetention = 30 * 24 * time.Hour
+)
+
+// NewHistoryPoint returns the metrics of the snapshot
+func NewHistoryPoint(snapshot portainer.Snapshot, now time.Time) portainer.SnapshotHistoryPoint {
+	point := portainer.SnapshotHistoryPoint{
+		Time:    now.Unix(),
+		Samples: 1,
+	}
+
+	if snapshot.Docker != nil {
+		docker := snapshot.Docker
+
+		point.RunningContainerCount = float64(docker.RunningContainerCount)
+		point.StoppedContainerCount = float64(docker.StoppedContainerCount)
+		point.HealthyContainerCount = float64(docker.HealthyContainerCount)
+		point.UnhealthyContainerCount = float64(docker.UnhealthyContainerCount)
+		point.ImageCount = float64(docker.ImageCount)
+		point.VolumeCount = float64(docker.VolumeCount)
+		point.ServiceCount = float64(docker.ServiceCount)
+		point.StackCount = float64(docker.StackCount)
+		point.NodeCount = float64(docker.NodeCount)
+		point.TotalCPU = float64(docker.TotalCPU)
+		point.TotalMemory = float64(docker.TotalMemory)
+	}
+
+	if snapshot.Kubernetes != nil {
+		kubernetes := snapshot.Kubernetes
+
+		point.NodeCount = float64(kubernetes.NodeCount)
+		point.TotalCPU = float64(kubernetes.TotalCPU)
+		point.TotalMemory = float64(kubernetes.TotalMemory)
+	}
+
+	return point
+}
+
+// AppendHistory adds the point to the history. The points older than RawHistoryRetention are downsampled
+// to hourly averages and the averages older than HourlyHistoryRetention are removed.
+func AppendHistory(history *portainer.SnapshotHistory, point portainer.SnapshotHistoryPoint, now time.Time) {
+	history.Raw = append(history.Raw, point)
+	sortHistoryPoints(history.Raw)
+
+	rawCutoff := now.Add(-RawHistoryRetention).Unix()
+
+	expired := 0
+	for expired < len(history.Raw) && history.Raw[expired].Time < rawCutoff {
+		expired++
+	}
+
+	if expired > 0 {
+		history.Hourly = AggregateHistoryPoints(append(history.Hourly, history.Raw[:expired]...), time.Hour)
+		history.Raw = append([]portainer.SnapshotHistoryPoint{}, history.Raw[expired:]...)
+	}
+
+	hourlyCutoff := now.Add(-HourlyHistoryRetention).Unix()
+
+	expired = 0
+	for expired < len(history.Hourly) && history.Hourly[expired].Time < hourlyCutoff {
+		expired++
+	}
+
+	history.Hourly = append([]portainer.SnapshotHistoryPoint{}, history.Hourly[expired:]...)
+}
+
+// HistoryPoints returns the points of the history between from and to, aggregated by periods of the resolution
+// when it is not zero. The points older than RawHistoryRetention are only available as hourly averages.
+func HistoryPoints(history *portainer.SnapshotHistory, from, to time.Time, resolution time.Duration) []portainer.SnapshotHistoryPoint {
+	points := []portainer.SnapshotHistoryPoint{}
+
+	for _, series := range [][]portainer.SnapshotHistoryPoint{history.Hourly, history.Raw} {
+		for _, point := range series {
+			if point.Time >= from.Unix() && point.Time <= to.Unix() {
+				points = append(points, point)
+			}
+		}
+	}
+
+	sortHistoryPoints(points)
+
+	if resolution <= 0 {
+		return points
+	}
+
+	return AggregateHistoryPoints(points, resolution)
+}
+
+// AggregateHistoryPoints averages the points by periods of the resolution, the time of an aggregated point
+// is the start of its period. The points must be sorted by time.
+func AggregateHistoryPoints(points []portainer.SnapshotHistoryPoint, resolution time.Duration) []portainer.SnapshotHistoryPoint {
+	period := int64(resolution.Seconds())
+	aggregated := []portainer.SnapshotHistoryPoint{}
+
+	for _, point := range points {
+		start := point.Time - point.Time%period
+
+		if len(aggregated) > 0 && aggregated[len(aggregated)-1].Time == start {
+			mergeHistoryPoints(&aggregated[len(aggregated)-1], point)
+			continue
+		}
+
+		point.Time = start
+		aggregated = append(aggregated, point)
+	}
+
+	return aggregated
+}
+
+// mergeHistoryPoints adds the point to the average of the aggregated point
+func mergeHistoryPoints(aggregated *portainer.SnapshotHistoryPoint, point portainer.SnapshotHistoryPoint) {
+	total := float64(aggregated.Samples + point.Samples)
+	average := func(a, b float64) float64 {
+		return (a*float64(aggregated.Samples) + b*float64(point.Samples)) / total
+	}
+
+	aggregated.RunningContainerCount = average(aggregated.RunningContainerCount, point.RunningContainerCount)
+	aggregated.StoppedContainerCount = average(aggregated.StoppedContainerCount, point.StoppedContainerCount)
+	aggregated.HealthyContainerCount = average(aggregated.HealthyContainerCount, point.HealthyContainerCount)
+	aggregated.UnhealthyContainerCount = average(aggregated.UnhealthyContainerCount, point.UnhealthyContainerCount)
+	aggregated.ImageCount = average(aggregated.ImageCount, point.ImageCount)
+	aggregated.VolumeCount = average(aggregated.VolumeCount, point.VolumeCount)
+	aggregated.ServiceCount = average(aggregated.ServiceCount, point.ServiceCount)
+	aggregated.StackCount = average(aggregated.StackCount, point.StackCount)
+	aggregated.NodeCount = average(aggregated.NodeCount, point.NodeCount)
+	aggregated.TotalCPU = average(aggregated.TotalCPU, point.TotalCPU)
+	aggregated.TotalMemory = average(aggregated.TotalMemory, point.TotalMemory)
+	aggregated.Samples += point.Samples
+}
+
+func sortHistoryPoints(points []portainer.SnapshotHistoryPoint) {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time < points[j].Time
+	})
+}
+
+// recordHistory adds the snapshot to the history of its environment(endpoint)
+func (service *Service) recordHistory(snapshot portainer.Snapshot) error {
+	service.historyMu.Lock()
+	defer service.historyMu.Unlock()
+
+	history, err := service.dataStore.SnapshotHistory().SnapshotHistory(snapshot.EndpointID)
+	if service.dataStore.IsErrObjectNotFound(err) {
+		history = &portainer.SnapshotHistory{EndpointID: snapshot.EndpointID}
+	} else if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	AppendHistory(history, NewHistoryPoint(snapshot, now), now)
+
+	return service.dataStore.SnapshotHistory().UpdateSnapshotHistory(history)
+}
@@ -0,0 +1,80 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AppendHistory(t *testing.T) {
+	is := assert.New(t)
+
+	start := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	history := &portainer.SnapshotHistory{EndpointID: 1}
+
+	// a snapshot every 30 minutes during 40 days, the running containers alternate between 2 and 4
+	var now time.Time
+	for i := 0; i < 40*48; i++ {
+		now = start.Add(time.Duration(i) * 30 * time.Minute)
+
+		running := 2
+		if i%2 == 1 {
+			running = 4
+		}
+
+		snapshot := portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{RunningContainerCount: running, TotalMemory: 1024}}
+		AppendHistory(history, NewHistoryPoint(snapshot, now), now)
+	}
+
+	is.Len(history.Raw, 49, "the snapshots of the last 24 hours should be kept as is")
+	is.Equal(now.Add(-RawHistoryRetention).Unix(), history.Raw[0].Time)
+
+	is.Len(history.Hourly, 29*24, "the hourly averages of the 29 days preceding the raw snapshots should be kept")
+
+	// the last hour is split between the averages and the raw snapshots
+	is.Equal(1, history.Hourly[len(history.Hourly)-1].Samples)
+
+	for _, point := range history.Hourly[:len(history.Hourly)-1] {
+		is.Equal(2, point.Samples)
+		is.Equal(3.0, point.RunningContainerCount)
+		is.Equal(1024.0, point.TotalMemory)
+		is.Zero(point.Time % 3600)
+	}
+
+	is.GreaterOrEqual(history.Hourly[0].Time, now.Add(-HourlyHistoryRetention).Unix())
+	is.Less(history.Hourly[len(history.Hourly)-1].Time, history.Raw[0].Time)
+}
+
+func Test_HistoryPoints(t *testing.T) {
+	is := assert.New(t)
+
+	history := &portainer.SnapshotHistory{
+		Hourly: []portainer.SnapshotHistoryPoint{
+			{Time: 0, Samples: 2, ImageCount: 1},
+			{Time: 3600, Samples: 2, ImageCount: 3},
+		},
+		Raw: []portainer.SnapshotHistoryPoint{
+			{Time: 7200, Samples: 1, ImageCount: 4},
+			{Time: 9000, Samples: 1, ImageCount: 6},
+		},
+	}
+
+	points := HistoryPoints(history, time.Unix(3600, 0), time.Unix(9000, 0), 0)
+	is.Len(points, 3)
+	is.Equal(int64(3600), points[0].Time)
+	is.Equal(int64(9000), points[2].Time)
+
+	points = HistoryPoints(history, time.Unix(3600, 0), time.Unix(9000, 0), time.Hour)
+	is.Len(points, 2)
+	is.Equal(int64(7200), points[1].Time)
+	is.Equal(5.0, points[1].ImageCount)
+	is.Equal(2, points[1].Samples)
+
+	points = HistoryPoints(history, time.Unix(0, 0), time.Unix(9000, 0), 24*time.Hour)
+	is.Len(points, 1)
+	is.Equal(6, points[0].Samples)
+	is.InDelta(3.0, points[0].ImageCount, 0.001)
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"strconv"
+	"sync"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/agent"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/metrics"
 
 	"github.com/rs/zerolog/log"
 )
@@ -23,11 +26,13 @@ type Service struct {
 	snapshotIntervalInSeconds float64
 	dockerSnapshotter         portainer.DockerSnapshotter
 	kubernetesSnapshotter     portainer.KubernetesSnapshotter
+	notificationService       portainer.NotificationService
 	shutdownCtx               context.Context
+	historyMu                 sync.Mutex
 }
 
 // NewService creates a new instance of a service
-func NewService(snapshotIntervalFromFlag string, dataStore dataservices.DataStore, dockerSnapshotter portainer.DockerSnapshotter, kubernetesSnapshotter portainer.KubernetesSnapshotter, shutdownCtx context.Context) (*Service, error) {
+func NewService(snapshotIntervalFromFlag string, dataStore dataservices.DataStore, dockerSnapshotter portainer.DockerSnapshotter, kubernetesSnapshotter portainer.KubernetesSnapshotter, notificationService portainer.NotificationService, shutdownCtx context.Context) (*Service, error) {
 	interval, err := parseSnapshotFrequency(snapshotIntervalFromFlag, dataStore)
 	if err != nil {
 		return nil, err
@@ -39,6 +44,7 @@ func NewService(snapshotIntervalFromFlag string, dataStore dataservices.DataStor
 		snapshotIntervalInSeconds: interval,
 		dockerSnapshotter:         dockerSnapshotter,
 		kubernetesSnapshotter:     kubernetesSnapshotter,
+		notificationService:       notificationService,
 		shutdownCtx:               shutdownCtx,
 	}, nil
 }
@@ -120,7 +126,22 @@ func (service *Service) SnapshotEndpoint(endpoint *portainer.Endpoint) error {
 }
 
 func (service *Service) Create(snapshot portainer.Snapshot) error {
-	return service.dataStore.Snapshot().Create(&snapshot)
+	return service.storeSnapshot(&snapshot)
+}
+
+// storeSnapshot replaces the latest snapshot of the environment(endpoint) and adds it to the history
+func (service *Service) storeSnapshot(snapshot *portainer.Snapshot) error {
+	err := service.dataStore.Snapshot().Create(snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = service.recordHistory(*snapshot)
+	if err != nil {
+		log.Warn().Err(err).Int("endpoint_id", int(snapshot.EndpointID)).Msg("unable to add the snapshot to the history")
+	}
+
+	return nil
 }
 
 func (service *Service) FillSnapshotData(endpoint *portainer.Endpoint) error {
@@ -152,7 +173,9 @@ func FillSnapshotData(dataStore dataservices.DataStore, endpoint *portainer.Endp
 }
 
 func (service *Service) snapshotKubernetesEndpoint(endpoint *portainer.Endpoint) error {
+	start := time.Now()
 	kubernetesSnapshot, err := service.kubernetesSnapshotter.CreateSnapshot(endpoint)
+	metrics.ObserveSnapshot("kubernetes", start, err)
 	if err != nil {
 		return err
 	}
@@ -160,14 +183,16 @@ func (service *Service) snapshotKubernetesEndpoint(endpoint *portainer.Endpoint)
 	if kubernetesSnapshot != nil {
 		snapshot := &portainer.Snapshot{EndpointID: endpoint.ID, Kubernetes: kubernetesSnapshot}
 
-		return service.dataStore.Snapshot().Create(snapshot)
+		return service.storeSnapshot(snapshot)
 	}
 
 	return nil
 }
 
 func (service *Service) snapshotDockerEndpoint(endpoint *portainer.Endpoint) error {
+	start := time.Now()
 	dockerSnapshot, err := service.dockerSnapshotter.CreateSnapshot(endpoint)
+	metrics.ObserveSnapshot("docker", start, err)
 	if err != nil {
 		return err
 	}
@@ -175,7 +200,7 @@ func (service *Service) snapshotDockerEndpoint(endpoint *portainer.Endpoint) err
 	if dockerSnapshot != nil {
 		snapshot := &portainer.Snapshot{EndpointID: endpoint.ID, Docker: dockerSnapshot}
 
-		return service.dataStore.Snapshot().Create(snapshot)
+		return service.storeSnapshot(snapshot)
 	}
 
 	return nil
@@ -233,6 +258,8 @@ func (service *Service) snapshotEndpoints() error {
 			continue
 		}
 
+		previousStatus := latestEndpointReference.Status
+
 		latestEndpointReference.Status = portainer.EndpointStatusUp
 		if snapshotError != nil {
 			log.Debug().
@@ -254,11 +281,38 @@ func (service *Service) snapshotEndpoints() error {
 
 			continue
 		}
+
+		if previousStatus != latestEndpointReference.Status {
+			service.notifyStatusChange(latestEndpointReference, snapshotError)
+		}
 	}
 
 	return nil
 }
 
+// notifyStatusChange sends the new status of an environment(endpoint) to the notification channels
+func (service *Service) notifyStatusChange(endpoint *portainer.Endpoint, snapshotError error) {
+	if service.notificationService == nil {
+		return
+	}
+
+	event := portainer.NotificationEvent{
+		Type:         portainer.NotificationEventEnvironmentUp,
+		EndpointID:   endpoint.ID,
+		ResourceID:   strconv.Itoa(int(endpoint.ID)),
+		ResourceName: endpoint.Name,
+	}
+
+	if endpoint.Status == portainer.EndpointStatusDown {
+		event.Type = portainer.NotificationEventEnvironmentDown
+		if snapshotError != nil {
+			event.Message = snapshotError.Error()
+		}
+	}
+
+	service.notificationService.Notify(event)
+}
+
 // FetchDockerID fetches info.Swarm.Cluster.ID if environment(endpoint) is swarm and info.ID otherwise
 func FetchDockerID(snapshot portainer.DockerSnapshot) (string, error) {
 	info := snapshot.SnapshotRaw.Info
@@ -10,15 +10,23 @@ import (
 )
 
 type testDatastore struct {
+	admissionPolicy         dataservices.AdmissionPolicyService
+	auditLog                dataservices.AuditLogService
 	customTemplate          dataservices.CustomTemplateService
 	edgeGroup               dataservices.EdgeGroupService
 	edgeJob                 dataservices.EdgeJobService
+	edgeJobRun              dataservices.EdgeJobRunService
 	edgeStack               dataservices.EdgeStackService
+	edgeStackStatusEvent    dataservices.EdgeStackStatusEventService
 	endpoint                dataservices.EndpointService
 	endpointGroup           dataservices.EndpointGroupService
 	endpointRelation        dataservices.EndpointRelationService
 	fdoProfile              dataservices.FDOProfileService
 	helmUserRepository      dataservices.HelmUserRepositoryService
+	imageScan               dataservices.ImageScanService
+	notificationChannel     dataservices.NotificationChannelService
+	notificationDelivery    dataservices.NotificationDeliveryService
+	pendingDeployment       dataservices.PendingDeploymentService
 	registry                dataservices.RegistryService
 	resourceControl         dataservices.ResourceControlService
 	apiKeyRepositoryService dataservices.APIKeyRepository
@@ -26,7 +34,10 @@ type testDatastore struct {
 	sslSettings             dataservices.SSLSettingsService
 	settings                dataservices.SettingsService
 	snapshot                dataservices.SnapshotService
+	snapshotHistory         dataservices.SnapshotHistoryService
 	stack                   dataservices.StackService
+	stackDrift              dataservices.StackDriftService
+	stackVersion            dataservices.StackVersionService
 	tag                     dataservices.TagService
 	teamMembership          dataservices.TeamMembershipService
 	team                    dataservices.TeamService
@@ -46,12 +57,17 @@ func (d *testDatastore) ViewTx(func(dataservices.DataStoreTx) error) error   { r
 func (d *testDatastore) CheckCurrentEdition() error                         { return nil }
 func (d *testDatastore) MigrateData() error                                 { return nil }
 func (d *testDatastore) Rollback(force bool) error                          { return nil }
+func (d *testDatastore) AuditLog() dataservices.AuditLogService             { return d.auditLog }
 func (d *testDatastore) CustomTemplate() dataservices.CustomTemplateService { return d.customTemplate }
 func (d *testDatastore) EdgeGroup() dataservices.EdgeGroupService           { return d.edgeGroup }
 func (d *testDatastore) EdgeJob() dataservices.EdgeJobService               { return d.edgeJob }
+func (d *testDatastore) EdgeJobRun() dataservices.EdgeJobRunService         { return d.edgeJobRun }
 func (d *testDatastore) EdgeStack() dataservices.EdgeStackService           { return d.edgeStack }
-func (d *testDatastore) Endpoint() dataservices.EndpointService             { return d.endpoint }
-func (d *testDatastore) EndpointGroup() dataservices.EndpointGroupService   { return d.endpointGroup }
+func (d *testDatastore) EdgeStackStatusEvent() dataservices.EdgeStackStatusEventService {
+	return d.edgeStackStatusEvent
+}
+func (d *testDatastore) Endpoint() dataservices.EndpointService           { return d.endpoint }
+func (d *testDatastore) EndpointGroup() dataservices.EndpointGroupService { return d.endpointGroup }
 
 func (d *testDatastore) FDOProfile() dataservices.FDOProfileService {
 	return d.fdoProfile
@@ -62,6 +78,24 @@ func (d *testDatastore) EndpointRelation() dataservices.EndpointRelationService
 func (d *testDatastore) HelmUserRepository() dataservices.HelmUserRepositoryService {
 	return d.helmUserRepository
 }
+func (d *testDatastore) ImageScan() dataservices.ImageScanService {
+	return d.imageScan
+}
+func (d *testDatastore) StackDrift() dataservices.StackDriftService {
+	return d.stackDrift
+}
+func (d *testDatastore) AdmissionPolicy() dataservices.AdmissionPolicyService {
+	return d.admissionPolicy
+}
+func (d *testDatastore) NotificationChannel() dataservices.NotificationChannelService {
+	return d.notificationChannel
+}
+func (d *testDatastore) NotificationDelivery() dataservices.NotificationDeliveryService {
+	return d.notificationDelivery
+}
+func (d *testDatastore) PendingDeployment() dataservices.PendingDeploymentService {
+	return d.pendingDeployment
+}
 func (d *testDatastore) Registry() dataservices.RegistryService { return d.registry }
 func (d *testDatastore) ResourceControl() dataservices.ResourceControlService {
 	return d.resourceControl
@@ -82,6 +116,14 @@ func (d *testDatastore) User() dataservices.UserService                     { re
 func (d *testDatastore) Version() dataservices.VersionService               { return d.version }
 func (d *testDatastore) Webhook() dataservices.WebhookService               { return d.webhook }
 
+func (d *testDatastore) StackVersion() dataservices.StackVersionService {
+	return d.stackVersion
+}
+
+func (d *testDatastore) SnapshotHistory() dataservices.SnapshotHistoryService {
+	return d.snapshotHistory
+}
+
 func (d *testDatastore) IsErrObjectNotFound(e error) bool {
 	return false
 }
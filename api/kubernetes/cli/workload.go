@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetWorkload returns the specification of a Deployment, a StatefulSet or a DaemonSet of a namespace.
+// It returns nil when the workload does not exist.
+func (kcl *KubeClient) GetWorkload(namespace, kind, name string) (*portainer.K8sWorkload, error) {
+	workload := &portainer.K8sWorkload{
+		Kind:      kind,
+		Name:      name,
+		Namespace: namespace,
+	}
+
+	var (
+		template v1.PodTemplateSpec
+		err      error
+	)
+
+	switch kind {
+	case "Deployment":
+		deployment, getErr := kcl.cli.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			workload.Replicas = deployment.Spec.Replicas
+			template = deployment.Spec.Template
+		}
+	case "StatefulSet":
+		statefulSet, getErr := kcl.cli.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			workload.Replicas = statefulSet.Spec.Replicas
+			template = statefulSet.Spec.Template
+		}
+	case "DaemonSet":
+		daemonSet, getErr := kcl.cli.AppsV1().DaemonSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			template = daemonSet.Spec.Template
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %s", kind)
+	}
+
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	workload.Labels = template.Labels
+	if workload.Labels == nil {
+		workload.Labels = map[string]string{}
+	}
+
+	for _, container := range template.Spec.Containers {
+		workloadContainer := portainer.K8sWorkloadContainer{
+			Name:  container.Name,
+			Image: container.Image,
+			Env:   map[string]string{},
+			Ports: []string{},
+		}
+
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				workloadContainer.Env[env.Name] = env.Value
+			}
+		}
+
+		for _, port := range container.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = v1.ProtocolTCP
+			}
+
+			workloadContainer.Ports = append(workloadContainer.Ports, fmt.Sprintf("%d/%s", port.ContainerPort, protocol))
+		}
+
+		workload.Containers = append(workload.Containers, workloadContainer)
+	}
+
+	return workload, nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetWorkloadImages returns the images of the containers running in all the namespaces, identified by the digest
+// reported by the container runtime. The images whose digest is not known yet are skipped.
+func (kcl *KubeClient) GetWorkloadImages() ([]portainer.K8sWorkloadImage, error) {
+	pods, err := kcl.cli.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	images := []portainer.K8sWorkloadImage{}
+	seen := map[string]bool{}
+
+	for _, pod := range pods.Items {
+		statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+
+		for _, status := range statuses {
+			digest := imageDigest(status.ImageID)
+			if digest == "" || seen[digest] {
+				continue
+			}
+
+			seen[digest] = true
+			images = append(images, portainer.K8sWorkloadImage{
+				Image:  status.Image,
+				Digest: digest,
+			})
+		}
+	}
+
+	return images, nil
+}
+
+// imageDigest extracts the digest of the image ID reported by the container runtime,
+// e.g. docker-pullable://nginx@sha256:... or sha256:...
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		imageID = imageID[i+1:]
+	}
+
+	if i := strings.Index(imageID, "sha256:"); i >= 0 {
+		return imageID[i:]
+	}
+
+	return ""
+}
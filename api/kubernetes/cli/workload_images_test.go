@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_GetWorkloadImages(t *testing.T) {
+	is := assert.New(t)
+
+	pod := func(name, namespace string, statuses ...v1.ContainerStatus) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status:     v1.PodStatus{ContainerStatuses: statuses},
+		}
+	}
+
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(
+			pod("web-1", "default", v1.ContainerStatus{Image: "nginx:1.23", ImageID: "docker-pullable://nginx@sha256:aaa"}),
+			pod("web-2", "prod", v1.ContainerStatus{Image: "nginx:1.23", ImageID: "docker.io/library/nginx@sha256:aaa"}),
+			pod("db", "prod",
+				v1.ContainerStatus{Image: "postgres:15", ImageID: "sha256:bbb"},
+				v1.ContainerStatus{Image: "pending:latest", ImageID: ""},
+			),
+		),
+		instanceID: "test",
+	}
+
+	images, err := k.GetWorkloadImages()
+	is.NoError(err)
+	is.ElementsMatch([]portainer.K8sWorkloadImage{
+		{Image: "nginx:1.23", Digest: "sha256:aaa"},
+		{Image: "postgres:15", Digest: "sha256:bbb"},
+	}, images)
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_GetWorkload(t *testing.T) {
+	is := assert.New(t)
+
+	replicas := int32(3)
+
+	k := &KubeClient{
+		cli: kfake.NewSimpleClientset(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{
+							Name:  "nginx",
+							Image: "nginx:1.23",
+							Env: []v1.EnvVar{
+								{Name: "MODE", Value: "production"},
+								{Name: "PASSWORD", ValueFrom: &v1.EnvVarSource{}},
+							},
+							Ports: []v1.ContainerPort{{ContainerPort: 80}, {ContainerPort: 53, Protocol: v1.ProtocolUDP}},
+						}},
+					},
+				},
+			},
+		}),
+		instanceID: "test",
+	}
+
+	workload, err := k.GetWorkload("prod", "Deployment", "web")
+	is.NoError(err)
+	is.Equal(&portainer.K8sWorkload{
+		Kind:      "Deployment",
+		Name:      "web",
+		Namespace: "prod",
+		Replicas:  &replicas,
+		Labels:    map[string]string{"app": "web"},
+		Containers: []portainer.K8sWorkloadContainer{{
+			Name:  "nginx",
+			Image: "nginx:1.23",
+			Env:   map[string]string{"MODE": "production"},
+			Ports: []string{"80/TCP", "53/UDP"},
+		}},
+	}, workload)
+
+	workload, err = k.GetWorkload("default", "Deployment", "web")
+	is.NoError(err)
+	is.Nil(workload)
+
+	_, err = k.GetWorkload("prod", "Service", "web")
+	is.Error(err)
+}
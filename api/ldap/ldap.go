@@ -220,6 +220,115 @@ func (*Service) SearchGroups(settings *portainer.LDAPSettings) ([]portainer.LDAP
 	return users, nil
 }
 
+// SearchUsersWithGroups searches for users with the specified settings along with the groups they belong to.
+// The members of the groups are matched against the distinguished names and the usernames of the users.
+func (*Service) SearchUsersWithGroups(settings *portainer.LDAPSettings) ([]portainer.LDAPUser, error) {
+	connection, err := createConnection(settings)
+	if err != nil {
+		return nil, err
+	}
+	defer connection.Close()
+
+	if !settings.AnonymousMode {
+		err = connection.Bind(settings.ReaderDN, settings.Password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	users := map[string]*portainer.LDAPUser{}
+	usersByDN := map[string]*portainer.LDAPUser{}
+
+	for _, searchSettings := range settings.SearchSettings {
+		searchRequest := ldap.NewSearchRequest(
+			searchSettings.BaseDN,
+			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			searchSettings.Filter,
+			[]string{"dn", searchSettings.UserNameAttribute},
+			nil,
+		)
+
+		sr, err := connection.Search(searchRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range sr.Entries {
+			username := entry.GetAttributeValue(searchSettings.UserNameAttribute)
+			if username == "" {
+				continue
+			}
+
+			user, ok := users[strings.ToLower(username)]
+			if !ok {
+				user = &portainer.LDAPUser{Name: username, Groups: []string{}}
+				users[strings.ToLower(username)] = user
+			}
+
+			usersByDN[strings.ToLower(entry.DN)] = user
+		}
+	}
+
+	for _, searchSettings := range settings.GroupSearchSettings {
+		if searchSettings.GroupBaseDN == "" {
+			continue
+		}
+
+		filter := searchSettings.GroupFilter
+		if filter == "" {
+			filter = "(objectClass=*)"
+		}
+
+		searchRequest := ldap.NewSearchRequest(
+			searchSettings.GroupBaseDN,
+			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			filter,
+			[]string{"cn", searchSettings.GroupAttribute},
+			nil,
+		)
+
+		sr, err := connection.Search(searchRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range sr.Entries {
+			group := entry.GetAttributeValue("cn")
+			if group == "" {
+				continue
+			}
+
+			for _, member := range entry.GetAttributeValues(searchSettings.GroupAttribute) {
+				user, ok := usersByDN[strings.ToLower(member)]
+				if !ok {
+					user, ok = users[strings.ToLower(member)]
+				}
+
+				if ok && !containsFold(user.Groups, group) {
+					user.Groups = append(user.Groups, group)
+				}
+			}
+		}
+	}
+
+	usersList := make([]portainer.LDAPUser, 0, len(users))
+	for _, user := range users {
+		usersList = append(usersList, *user)
+	}
+
+	return usersList, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func searchUser(username string, conn *ldap.Conn, settings []portainer.LDAPSearchSettings) (string, error) {
 	var userDN string
 	found := false
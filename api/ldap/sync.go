@@ -0,0 +1,326 @@
+package ldap
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const initialAdminID = portainer.UserID(1)
+
+var errLDAPAuthenticationDisabled = errors.New("LDAP authentication is not enabled")
+
+// SyncService periodically synchronises the LDAP groups with the teams. The teams matching the groups by name
+// are created, the memberships of the LDAP users are reconciled with their groups and the users missing from the
+// directory can be disabled. The teams which do not match any group are left untouched.
+type SyncService struct {
+	dataStore   dataservices.DataStore
+	ldapService portainer.LDAPService
+	scheduler   *scheduler.Scheduler
+
+	mu     sync.Mutex
+	jobID  string
+	syncMu sync.Mutex
+}
+
+// NewSyncService returns a new instance of SyncService
+func NewSyncService(dataStore dataservices.DataStore, ldapService portainer.LDAPService, scheduler *scheduler.Scheduler) *SyncService {
+	return &SyncService{
+		dataStore:   dataStore,
+		ldapService: ldapService,
+		scheduler:   scheduler,
+	}
+}
+
+// ValidateGroupSyncSettings verifies that the synchronisation can be scheduled with the settings
+func ValidateGroupSyncSettings(settings portainer.LDAPGroupSyncSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(settings.Interval)
+	if err != nil {
+		return errors.Wrap(err, "Invalid LDAP synchronisation interval")
+	}
+
+	if interval < time.Minute {
+		return errors.New("Invalid LDAP synchronisation interval, the minimum is 1m")
+	}
+
+	return nil
+}
+
+// Start schedules the synchronisation with the saved settings
+func (service *SyncService) Start() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return service.SetSchedule(settings.LDAPSettings.GroupSync)
+}
+
+// SetSchedule replaces the schedule of the synchronisation
+func (service *SyncService) SetSchedule(settings portainer.LDAPGroupSyncSettings) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.jobID != "" {
+		err := service.scheduler.StopJob(service.jobID)
+		if err != nil {
+			return err
+		}
+
+		service.jobID = ""
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(settings.Interval)
+	if err != nil {
+		return errors.Wrap(err, "Invalid LDAP synchronisation interval")
+	}
+
+	service.jobID = service.scheduler.StartJobEvery(interval, func() error {
+		report, err := service.Sync(false)
+		if err != nil {
+			log.Error().Err(err).Msg("LDAP group synchronisation failed")
+
+			// a failed synchronisation is retried on the next run
+			return nil
+		}
+
+		log.Info().
+			Int("created_teams", len(report.CreatedTeams)).
+			Int("added_memberships", len(report.AddedMemberships)).
+			Int("removed_memberships", len(report.RemovedMemberships)).
+			Int("disabled_users", len(report.DisabledUsers)).
+			Int("enabled_users", len(report.EnabledUsers)).
+			Msg("LDAP group synchronisation completed")
+
+		return nil
+	})
+
+	return nil
+}
+
+// Sync reconciles the teams and the users with the directory, the changes are only computed in dry-run mode
+func (service *SyncService) Sync(dryRun bool) (*portainer.LDAPSyncReport, error) {
+	service.syncMu.Lock()
+	defer service.syncMu.Unlock()
+
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the settings")
+	}
+
+	if settings.AuthenticationMethod != portainer.AuthenticationLDAP {
+		return nil, errLDAPAuthenticationDisabled
+	}
+
+	directoryUsers, err := service.ldapService.SearchUsersWithGroups(&settings.LDAPSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to search the LDAP directory")
+	}
+
+	report := &portainer.LDAPSyncReport{
+		DryRun:             dryRun,
+		Timestamp:          time.Now().UTC().Unix(),
+		CreatedTeams:       []string{},
+		AddedMemberships:   []portainer.LDAPSyncMembership{},
+		RemovedMemberships: []portainer.LDAPSyncMembership{},
+		DisabledUsers:      []string{},
+		EnabledUsers:       []string{},
+	}
+
+	teams, err := service.syncTeams(directoryUsers, dryRun, report)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.syncUsers(directoryUsers, teams, settings.LDAPSettings.GroupSync.DisableMissingUsers, dryRun, report)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// syncTeams creates the teams missing for the groups of the directory, it returns the teams indexed by lowercase name
+func (service *SyncService) syncTeams(directoryUsers []portainer.LDAPUser, dryRun bool, report *portainer.LDAPSyncReport) (map[string]portainer.Team, error) {
+	existingTeams, err := service.dataStore.Team().Teams()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the teams")
+	}
+
+	teams := make(map[string]portainer.Team, len(existingTeams))
+	for _, team := range existingTeams {
+		teams[strings.ToLower(team.Name)] = team
+	}
+
+	for _, group := range directoryGroups(directoryUsers) {
+		if _, ok := teams[strings.ToLower(group)]; ok {
+			continue
+		}
+
+		team := portainer.Team{Name: group}
+		if !dryRun {
+			err := service.dataStore.Team().Create(&team)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to create the team %s", group)
+			}
+		}
+
+		teams[strings.ToLower(group)] = team
+		report.CreatedTeams = append(report.CreatedTeams, group)
+	}
+
+	return teams, nil
+}
+
+// syncUsers reconciles the memberships of the users with their groups and disables the users missing from the directory
+func (service *SyncService) syncUsers(directoryUsers []portainer.LDAPUser, teams map[string]portainer.Team, disableMissingUsers, dryRun bool, report *portainer.LDAPSyncReport) error {
+	users, err := service.dataStore.User().Users()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the users")
+	}
+
+	directory := make(map[string][]string, len(directoryUsers))
+	for _, user := range directoryUsers {
+		directory[strings.ToLower(user.Name)] = user.Groups
+	}
+
+	groupTeams := map[portainer.TeamID]bool{}
+	for _, group := range directoryGroups(directoryUsers) {
+		groupTeams[teams[strings.ToLower(group)].ID] = true
+	}
+
+	teamNames := make(map[portainer.TeamID]string, len(teams))
+	for _, team := range teams {
+		teamNames[team.ID] = team.Name
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+
+	for i := range users {
+		user := &users[i]
+
+		// the initial administrator always authenticates against the internal database
+		if user.ID == initialAdminID {
+			continue
+		}
+
+		groups, inDirectory := directory[strings.ToLower(user.Username)]
+
+		if disableMissingUsers && user.Disabled == inDirectory {
+			// an empty directory is most likely a misconfiguration of the search settings, the users are kept
+			if !inDirectory && len(directoryUsers) == 0 {
+				continue
+			}
+
+			user.Disabled = !inDirectory
+			if !dryRun {
+				err := service.dataStore.User().UpdateUser(user.ID, user)
+				if err != nil {
+					return errors.Wrapf(err, "failed to update the user %s", user.Username)
+				}
+			}
+
+			if user.Disabled {
+				report.DisabledUsers = append(report.DisabledUsers, user.Username)
+			} else {
+				report.EnabledUsers = append(report.EnabledUsers, user.Username)
+			}
+		}
+
+		if !inDirectory {
+			continue
+		}
+
+		memberships, err := service.dataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to retrieve the memberships of the user %s", user.Username)
+		}
+
+		userTeams := map[portainer.TeamID]bool{}
+		for _, group := range groups {
+			team := teams[strings.ToLower(group)]
+			userTeams[team.ID] = true
+
+			if team.ID != 0 && teamMembershipExists(team.ID, memberships) {
+				continue
+			}
+
+			if !dryRun {
+				err := service.dataStore.TeamMembership().Create(&portainer.TeamMembership{
+					UserID: user.ID,
+					TeamID: team.ID,
+					Role:   portainer.TeamMember,
+				})
+				if err != nil {
+					return errors.Wrapf(err, "failed to add the user %s into the team %s", user.Username, team.Name)
+				}
+			}
+
+			report.AddedMemberships = append(report.AddedMemberships, portainer.LDAPSyncMembership{Username: user.Username, Team: team.Name})
+		}
+
+		for _, membership := range memberships {
+			if !groupTeams[membership.TeamID] || userTeams[membership.TeamID] {
+				continue
+			}
+
+			if !dryRun {
+				err := service.dataStore.TeamMembership().DeleteTeamMembership(membership.ID)
+				if err != nil {
+					return errors.Wrapf(err, "failed to remove the user %s from the team %s", user.Username, teamNames[membership.TeamID])
+				}
+			}
+
+			report.RemovedMemberships = append(report.RemovedMemberships, portainer.LDAPSyncMembership{Username: user.Username, Team: teamNames[membership.TeamID]})
+		}
+	}
+
+	return nil
+}
+
+// directoryGroups returns the sorted groups of the directory users, the duplicates differing only by case are removed
+func directoryGroups(directoryUsers []portainer.LDAPUser) []string {
+	seen := map[string]bool{}
+	groups := []string{}
+
+	for _, user := range directoryUsers {
+		for _, group := range user.Groups {
+			if seen[strings.ToLower(group)] {
+				continue
+			}
+
+			seen[strings.ToLower(group)] = true
+			groups = append(groups, group)
+		}
+	}
+
+	sort.Strings(groups)
+
+	return groups
+}
+
+func teamMembershipExists(teamID portainer.TeamID, memberships []portainer.TeamMembership) bool {
+	for _, membership := range memberships {
+		if membership.TeamID == teamID {
+			return true
+		}
+	}
+
+	return false
+}
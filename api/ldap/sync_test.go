@@ -0,0 +1,173 @@
+package ldap
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type directoryLDAPService struct {
+	portainer.LDAPService
+	users []portainer.LDAPUser
+}
+
+func (service *directoryLDAPService) SearchUsersWithGroups(settings *portainer.LDAPSettings) ([]portainer.LDAPUser, error) {
+	return service.users, nil
+}
+
+func setupSyncStore(t *testing.T) (*datastore.Store, func()) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+
+	settings.AuthenticationMethod = portainer.AuthenticationLDAP
+	settings.LDAPSettings.GroupSync.DisableMissingUsers = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	for _, username := range []string{"admin", "alice", "bob", "carol"} {
+		is.NoError(store.User().Create(&portainer.User{Username: username, Role: portainer.StandardUserRole}))
+	}
+
+	developers := &portainer.Team{Name: "developers"}
+	is.NoError(store.Team().Create(developers))
+
+	local := &portainer.Team{Name: "local"}
+	is.NoError(store.Team().Create(local))
+
+	// bob left the developers group and carol left the directory
+	for _, membership := range []portainer.TeamMembership{
+		{UserID: 3, TeamID: developers.ID, Role: portainer.TeamMember},
+		{UserID: 3, TeamID: local.ID, Role: portainer.TeamMember},
+		{UserID: 4, TeamID: developers.ID, Role: portainer.TeamMember},
+	} {
+		membership := membership
+		is.NoError(store.TeamMembership().Create(&membership))
+	}
+
+	return store, teardown
+}
+
+func Test_SyncService_Sync(t *testing.T) {
+	directory := &directoryLDAPService{users: []portainer.LDAPUser{
+		{Name: "alice", Groups: []string{"Developers", "ops"}},
+		{Name: "bob", Groups: []string{"ops"}},
+	}}
+
+	expected := &portainer.LDAPSyncReport{
+		CreatedTeams: []string{"ops"},
+		AddedMemberships: []portainer.LDAPSyncMembership{
+			{Username: "alice", Team: "developers"},
+			{Username: "alice", Team: "ops"},
+			{Username: "bob", Team: "ops"},
+		},
+		RemovedMemberships: []portainer.LDAPSyncMembership{
+			{Username: "bob", Team: "developers"},
+		},
+		DisabledUsers: []string{"carol"},
+		EnabledUsers:  []string{},
+	}
+
+	t.Run("dry-run reports the changes without applying them", func(t *testing.T) {
+		is := assert.New(t)
+
+		store, teardown := setupSyncStore(t)
+		defer teardown()
+
+		report, err := NewSyncService(store, directory, nil).Sync(true)
+		is.NoError(err)
+
+		is.True(report.DryRun)
+		is.Equal(expected.CreatedTeams, report.CreatedTeams)
+		is.Equal(expected.AddedMemberships, report.AddedMemberships)
+		is.Equal(expected.RemovedMemberships, report.RemovedMemberships)
+		is.Equal(expected.DisabledUsers, report.DisabledUsers)
+
+		_, err = store.Team().TeamByName("ops")
+		is.True(store.IsErrObjectNotFound(err))
+
+		memberships, err := store.TeamMembership().TeamMemberships()
+		is.NoError(err)
+		is.Len(memberships, 3)
+
+		carol, err := store.User().User(4)
+		is.NoError(err)
+		is.False(carol.Disabled)
+	})
+
+	t.Run("sync applies the changes and is idempotent", func(t *testing.T) {
+		is := assert.New(t)
+
+		store, teardown := setupSyncStore(t)
+		defer teardown()
+
+		service := NewSyncService(store, directory, nil)
+
+		report, err := service.Sync(false)
+		is.NoError(err)
+
+		is.False(report.DryRun)
+		is.Equal(expected.CreatedTeams, report.CreatedTeams)
+		is.Equal(expected.AddedMemberships, report.AddedMemberships)
+		is.Equal(expected.RemovedMemberships, report.RemovedMemberships)
+		is.Equal(expected.DisabledUsers, report.DisabledUsers)
+
+		ops, err := store.Team().TeamByName("ops")
+		is.NoError(err)
+
+		bobMemberships, err := store.TeamMembership().TeamMembershipsByUserID(3)
+		is.NoError(err)
+
+		bobTeams := []portainer.TeamID{}
+		for _, membership := range bobMemberships {
+			bobTeams = append(bobTeams, membership.TeamID)
+		}
+		is.ElementsMatch([]portainer.TeamID{2, ops.ID}, bobTeams, "the membership of the team not matching a group should be kept")
+
+		carol, err := store.User().User(4)
+		is.NoError(err)
+		is.True(carol.Disabled)
+
+		report, err = service.Sync(false)
+		is.NoError(err)
+		is.Empty(report.CreatedTeams)
+		is.Empty(report.AddedMemberships)
+		is.Empty(report.RemovedMemberships)
+		is.Empty(report.DisabledUsers)
+	})
+
+	t.Run("an empty directory does not disable the users", func(t *testing.T) {
+		is := assert.New(t)
+
+		store, teardown := setupSyncStore(t)
+		defer teardown()
+
+		report, err := NewSyncService(store, &directoryLDAPService{}, nil).Sync(false)
+		is.NoError(err)
+		is.Empty(report.DisabledUsers)
+	})
+
+	t.Run("sync requires the LDAP authentication", func(t *testing.T) {
+		is := assert.New(t)
+
+		_, store, teardown := datastore.MustNewTestStore(t, true, true)
+		defer teardown()
+
+		_, err := NewSyncService(store, directory, nil).Sync(true)
+		is.ErrorIs(err, errLDAPAuthenticationDisabled)
+	})
+}
+
+func Test_ValidateGroupSyncSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateGroupSyncSettings(portainer.LDAPGroupSyncSettings{}))
+	is.NoError(ValidateGroupSyncSettings(portainer.LDAPGroupSyncSettings{Enabled: true, Interval: "1h"}))
+	is.Error(ValidateGroupSyncSettings(portainer.LDAPGroupSyncSettings{Enabled: true, Interval: "hourly"}))
+	is.Error(ValidateGroupSyncSettings(portainer.LDAPGroupSyncSettings{Enabled: true, Interval: "10s"}))
+}
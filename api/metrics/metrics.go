@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "portainer"
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the HTTP requests handled by the API, per route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	proxyRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "proxy",
+		Name:      "requests_total",
+		Help:      "Number of requests proxied to each environment(endpoint).",
+	}, []string{"endpoint_id"})
+
+	snapshotDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "snapshot",
+		Name:      "duration_seconds",
+		Help:      "Duration of the environment(endpoint) snapshots.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"platform"})
+
+	snapshotFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "snapshot",
+		Name:      "failures_total",
+		Help:      "Number of environment(endpoint) snapshots that failed.",
+	}, []string{"platform"})
+
+	activeTunnels = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "tunnel",
+		Name:      "active",
+		Help:      "Number of active reverse tunnels opened by Edge agents.",
+	})
+
+	dbTransactionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "database",
+		Name:      "transaction_duration_seconds",
+		Help:      "Duration of the database transactions.",
+		Buckets:   []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+	}, []string{"type"})
+
+	gitAutoUpdatePolls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "git",
+		Name:      "auto_update_polls_total",
+		Help:      "Number of git auto-update polls of the stacks, per result.",
+	}, []string{"result"})
+)
+
+// Result labels of the git auto-update polls
+const (
+	GitPollUpdated   = "updated"
+	GitPollUnchanged = "unchanged"
+	GitPollError     = "error"
+)
+
+// ObserveHTTPRequest records the duration of a request handled by the API
+func ObserveHTTPRequest(method, route string, statusCode int, start time.Time) {
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(statusCode)).Observe(time.Since(start).Seconds())
+}
+
+// IncProxyRequests counts a request proxied to an environment(endpoint)
+func IncProxyRequests(endpointID portainer.EndpointID) {
+	proxyRequests.WithLabelValues(strconv.Itoa(int(endpointID))).Inc()
+}
+
+// ObserveSnapshot records the duration and the outcome of an environment(endpoint) snapshot
+func ObserveSnapshot(platform string, start time.Time, err error) {
+	snapshotDuration.WithLabelValues(platform).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		snapshotFailures.WithLabelValues(platform).Inc()
+	}
+}
+
+// IncActiveTunnels is called when a reverse tunnel becomes active
+func IncActiveTunnels() {
+	activeTunnels.Inc()
+}
+
+// DecActiveTunnels is called when an active reverse tunnel is closed
+func DecActiveTunnels() {
+	activeTunnels.Dec()
+}
+
+// ObserveDBTransaction records the duration of a database transaction
+func ObserveDBTransaction(txType string, start time.Time) {
+	dbTransactionDuration.WithLabelValues(txType).Observe(time.Since(start).Seconds())
+}
+
+// IncGitAutoUpdatePolls counts a git auto-update poll with the given result
+func IncGitAutoUpdatePolls(result string) {
+	gitAutoUpdatePolls.WithLabelValues(result).Inc()
+}
+
+// RouteFromPath returns a low cardinality route for a request path by replacing the
+// identifiers with placeholders. Requests proxied to an environment(endpoint) are
+// grouped by the first segment of the proxied API, e.g. /api/endpoints/{id}/docker/containers
+func RouteFromPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 0 || segments[0] != "api" {
+		return "other"
+	}
+
+	for i := 1; i < len(segments); i++ {
+		if i == 4 && segments[1] == "endpoints" {
+			switch segments[3] {
+			case "docker", "kubernetes", "agent", "azure":
+				return "/" + strings.Join(segments[:5], "/")
+			}
+		}
+
+		if isIdentifier(segments[i]) {
+			segments[i] = "{id}"
+		}
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+func isIdentifier(segment string) bool {
+	if _, err := strconv.Atoi(segment); err == nil {
+		return true
+	}
+
+	// docker identifiers, uuids and tokens
+	return len(segment) >= 24 && !strings.ContainsAny(segment, "_.")
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RouteFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/stacks", want: "/api/stacks"},
+		{path: "/api/stacks/12", want: "/api/stacks/{id}"},
+		{path: "/api/stacks/12/git/redeploy", want: "/api/stacks/{id}/git/redeploy"},
+		{path: "/api/endpoints/3/docker/containers/4d1a6a4f0b2c7a9e8f3d5b6c7a8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e/json", want: "/api/endpoints/{id}/docker/containers"},
+		{path: "/api/endpoints/3/kubernetes/api/v1/namespaces", want: "/api/endpoints/{id}/kubernetes/api"},
+		{path: "/api/webhooks/2a1f4b3c-9d8e-4f7a-8b6c-5d4e3f2a1b0c", want: "/api/webhooks/{id}"},
+		{path: "/api/endpoints/3/edge/status", want: "/api/endpoints/{id}/edge/status"},
+		{path: "/main.js", want: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, RouteFromPath(tt.path))
+		})
+	}
+}
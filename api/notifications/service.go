@@ -0,0 +1,212 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// EventHeader is the header containing the type of the delivered event
+	EventHeader = "X-Portainer-Event"
+	// SignatureHeader is the header containing the HMAC-SHA256 signature of the timestamp and the payload
+	SignatureHeader = "X-Portainer-Signature"
+	// TimestampHeader is the header containing the signed unix time of the delivery attempt, the receivers can
+	// reject the old deliveries to prevent them from being replayed
+	TimestampHeader = "X-Portainer-Timestamp"
+
+	defaultMaxAttempts = 5
+	defaultBackoff     = 2 * time.Second
+	requestTimeout     = 10 * time.Second
+)
+
+// Service sends lifecycle events to the notification channels
+type Service struct {
+	dataStore   dataservices.DataStore
+	httpClient  *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	shutdownCtx context.Context
+}
+
+// NewService returns a new instance of Service
+func NewService(dataStore dataservices.DataStore, shutdownCtx context.Context) *Service {
+	return &Service{
+		dataStore:   dataStore,
+		httpClient:  &http.Client{Timeout: requestTimeout},
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		shutdownCtx: shutdownCtx,
+	}
+}
+
+// Notify sends an event to every enabled notification channel subscribed to its type.
+// The deliveries are made in the background.
+func (service *Service) Notify(event portainer.NotificationEvent) {
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UTC().Unix()
+	}
+
+	channels, err := service.dataStore.NotificationChannel().NotificationChannels()
+	if err != nil {
+		log.Warn().Err(err).Str("event", string(event.Type)).Msg("unable to retrieve the notification channels")
+		return
+	}
+
+	for _, channel := range channels {
+		if !channel.Enabled || !isSubscribed(&channel, event.Type) {
+			continue
+		}
+
+		go service.Deliver(channel, event)
+	}
+}
+
+// Deliver sends an event to a notification channel, retrying with an exponential backoff
+// until it is accepted or the maximum number of attempts is reached. The outcome is saved
+// in the delivery history.
+func (service *Service) Deliver(channel portainer.NotificationChannel, event portainer.NotificationEvent) *portainer.NotificationDelivery {
+	delivery := &portainer.NotificationDelivery{
+		ChannelID: channel.ID,
+		Event:     event,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		delivery.Error = err.Error()
+		service.saveDelivery(delivery)
+
+		return delivery
+	}
+
+	backoff := service.backoff
+	for attempt := 1; attempt <= service.maxAttempts; attempt++ {
+		delivery.Attempts = attempt
+		delivery.Timestamp = time.Now().UTC().Unix()
+
+		delivery.StatusCode, err = service.send(channel, event.Type, payload)
+		if err == nil {
+			delivery.Success = true
+			delivery.Error = ""
+			break
+		}
+
+		delivery.Error = err.Error()
+
+		if attempt == service.maxAttempts || !service.wait(backoff) {
+			break
+		}
+
+		backoff *= 2
+	}
+
+	if !delivery.Success {
+		log.Warn().
+			Int("channel_id", int(channel.ID)).
+			Str("event", string(event.Type)).
+			Int("attempts", delivery.Attempts).
+			Str("error", delivery.Error).
+			Msg("unable to deliver the notification")
+	}
+
+	service.saveDelivery(delivery)
+
+	return delivery
+}
+
+func (service *Service) send(channel portainer.NotificationChannel, eventType portainer.NotificationEventType, payload []byte) (int, error) {
+	ctx := context.Background()
+	if service.shutdownCtx != nil {
+		ctx = service.shutdownCtx
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().UTC().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(eventType))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	if channel.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(channel.Secret, timestamp, payload))
+	}
+
+	resp, err := service.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status code: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// wait blocks for the given duration, it returns false if the service is shutting down
+func (service *Service) wait(duration time.Duration) bool {
+	if service.shutdownCtx == nil {
+		time.Sleep(duration)
+		return true
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-service.shutdownCtx.Done():
+		return false
+	}
+}
+
+func (service *Service) saveDelivery(delivery *portainer.NotificationDelivery) {
+	err := service.dataStore.NotificationDelivery().Create(delivery)
+	if err != nil {
+		log.Warn().Err(err).Int("channel_id", int(delivery.ChannelID)).Msg("unable to save the notification delivery")
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of a payload sent at the unix time timestamp, the signed
+// message is the timestamp and the payload separated by a dot
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func isSubscribed(channel *portainer.NotificationChannel, eventType portainer.NotificationEventType) bool {
+	if len(channel.Events) == 0 {
+		return true
+	}
+
+	for _, subscribed := range channel.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices/notificationdelivery"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Deliver(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	defer teardown()
+
+	requests := 0
+	var signature, eventType, timestamp string
+	var body []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		signature = r.Header.Get(SignatureHeader)
+		timestamp = r.Header.Get(TimestampHeader)
+		eventType = r.Header.Get(EventHeader)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	service := NewService(store, context.Background())
+	service.backoff = time.Millisecond
+
+	channel := portainer.NotificationChannel{ID: 1, URL: srv.URL, Secret: "secret", Enabled: true}
+	event := portainer.NotificationEvent{Type: portainer.NotificationEventStackDeploySuccess, EndpointID: 1, ResourceID: "2"}
+
+	delivery := service.Deliver(channel, event)
+
+	is.True(delivery.Success)
+	is.Equal(3, delivery.Attempts)
+	is.Equal(http.StatusOK, delivery.StatusCode)
+	is.Equal(string(portainer.NotificationEventStackDeploySuccess), eventType)
+
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	is.NoError(err)
+	is.InDelta(time.Now().Unix(), sentAt, 5)
+	is.Equal("sha256="+Sign("secret", sentAt, body), signature)
+	is.NotEqual("sha256="+Sign("secret", sentAt+1, body), signature, "the timestamp should be signed")
+
+	deliveries, err := store.NotificationDelivery().NotificationDeliveriesByChannelID(channel.ID)
+	is.NoError(err)
+	is.Len(deliveries, 1)
+}
+
+func Test_Deliver_History(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	defer teardown()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	service := NewService(store, context.Background())
+
+	for i := 0; i < notificationdelivery.MaxChannelDeliveries+2; i++ {
+		service.Deliver(portainer.NotificationChannel{ID: 1, URL: srv.URL}, portainer.NotificationEvent{Type: portainer.NotificationEventEnvironmentUp})
+	}
+	service.Deliver(portainer.NotificationChannel{ID: 2, URL: srv.URL}, portainer.NotificationEvent{Type: portainer.NotificationEventEnvironmentUp})
+
+	deliveries, err := store.NotificationDelivery().NotificationDeliveriesByChannelID(1)
+	is.NoError(err)
+	is.Len(deliveries, notificationdelivery.MaxChannelDeliveries, "the oldest deliveries of the channel should be deleted")
+	is.Equal(portainer.NotificationDeliveryID(3), deliveries[0].ID)
+
+	deliveries, err = store.NotificationDelivery().NotificationDeliveriesByChannelID(2)
+	is.NoError(err)
+	is.Len(deliveries, 1, "the deliveries of the other channels should be kept")
+}
+
+func Test_Deliver_GivesUpAfterMaxAttempts(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	defer teardown()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	service := NewService(store, context.Background())
+	service.backoff = time.Millisecond
+	service.maxAttempts = 2
+
+	delivery := service.Deliver(portainer.NotificationChannel{ID: 1, URL: srv.URL}, portainer.NotificationEvent{Type: portainer.NotificationEventEnvironmentDown})
+
+	is.False(delivery.Success)
+	is.Equal(2, delivery.Attempts)
+	is.Equal(http.StatusInternalServerError, delivery.StatusCode)
+	is.NotEmpty(delivery.Error)
+}
+
+func Test_isSubscribed(t *testing.T) {
+	is := assert.New(t)
+
+	channel := &portainer.NotificationChannel{}
+	is.True(isSubscribed(channel, portainer.NotificationEventTunnelConnected), "a channel without events should receive all the events")
+
+	channel.Events = []portainer.NotificationEventType{portainer.NotificationEventTunnelDisconnected}
+	is.False(isSubscribed(channel, portainer.NotificationEventTunnelConnected))
+	is.True(isSubscribed(channel, portainer.NotificationEventTunnelDisconnected))
+}
@@ -26,42 +26,78 @@ func NewService() *Service {
 }
 
 // Authenticate takes an access code and exchanges it for an access token from portainer OAuthSettings token environment(endpoint).
-// On success, it will then return the username and token expiry time associated to authenticated user by fetching this information
-// from the resource server and matching it with the user identifier setting.
-func (*Service) Authenticate(code string, configuration *portainer.OAuthSettings) (string, error) {
-	token, err := getOAuthToken(code, configuration)
+// The code verifier is sent along with the code when PKCE is enabled.
+// On success, it will then return the username and the groups associated to authenticated user by fetching this information
+// from the resource server and matching it with the user identifier and groups claim settings.
+// When OpenID Connect is enabled, the signature of the ID token is validated with the keys of the provider, its nonce
+// must match the nonce of the authorization request and its claims take precedence over the ones of the resource server.
+func (*Service) Authenticate(code, codeVerifier, nonce string, configuration *portainer.OAuthSettings) (*portainer.OAuthInfo, error) {
+	if configuration.PKCE && codeVerifier == "" {
+		return nil, errors.New("missing PKCE code verifier")
+	}
+
+	token, err := getOAuthToken(code, codeVerifier, configuration)
 	if err != nil {
 		log.Debug().Err(err).Msg("failed retrieving oauth token")
 
-		return "", err
+		return nil, err
 	}
 
-	idToken, err := getIdToken(token)
-	if err != nil {
-		log.Debug().Err(err).Msg("failed parsing id_token")
+	var idToken map[string]interface{}
+	if configuration.OpenIDConnect.Enabled {
+		rawIdToken, ok := token.Extra("id_token").(string)
+		if !ok || rawIdToken == "" {
+			return nil, errors.New("missing id_token in the OpenID Connect token response")
+		}
+
+		idToken, err = validateIdToken(rawIdToken, nonce, configuration)
+		if err != nil {
+			log.Debug().Err(err).Msg("failed validating id_token")
+
+			return nil, err
+		}
+	} else {
+		idToken, err = getIdToken(token)
+		if err != nil {
+			log.Debug().Err(err).Msg("failed parsing id_token")
+		}
 	}
 
-	resource, err := getResource(token.AccessToken, configuration)
-	if err != nil {
-		log.Debug().Err(err).Msg("failed retrieving resource")
+	resource := map[string]interface{}{}
+	if !configuration.OpenIDConnect.Enabled || configuration.ResourceURI != "" {
+		resource, err = getResource(token.AccessToken, configuration)
+		if err != nil {
+			log.Debug().Err(err).Msg("failed retrieving resource")
 
-		return "", err
+			return nil, err
+		}
 	}
 
-	resource = mergeSecondIntoFirst(idToken, resource)
+	if configuration.OpenIDConnect.Enabled {
+		resource = mergeSecondIntoFirst(resource, idToken)
+	} else {
+		resource = mergeSecondIntoFirst(idToken, resource)
+	}
 
 	username, err := getUsername(resource, configuration)
 	if err != nil {
 		log.Debug().Err(err).Msg("failed retrieving username")
 
-		return "", err
+		return nil, err
 	}
 
-	return username, nil
+	return &portainer.OAuthInfo{
+		Username: username,
+		Groups:   getGroups(resource, configuration),
+	}, nil
 }
 
 // mergeSecondIntoFirst merges the overlap map into the base overwriting any existing values.
 func mergeSecondIntoFirst(base map[string]interface{}, overlap map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{}, len(overlap))
+	}
+
 	for k, v := range overlap {
 		base[k] = v
 	}
@@ -69,14 +105,19 @@ func mergeSecondIntoFirst(base map[string]interface{}, overlap map[string]interf
 	return base
 }
 
-func getOAuthToken(code string, configuration *portainer.OAuthSettings) (*oauth2.Token, error) {
+func getOAuthToken(code, codeVerifier string, configuration *portainer.OAuthSettings) (*oauth2.Token, error) {
 	unescapedCode, err := url.QueryUnescape(code)
 	if err != nil {
 		return nil, err
 	}
 
+	var options []oauth2.AuthCodeOption
+	if codeVerifier != "" {
+		options = append(options, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	}
+
 	config := buildConfig(configuration)
-	token, err := config.Exchange(context.Background(), unescapedCode)
+	token, err := config.Exchange(context.Background(), unescapedCode, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package oauth
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	portainer "github.com/portainer/portainer/api"
 )
@@ -22,3 +23,31 @@ func getUsername(datamap map[string]interface{}, configuration *portainer.OAuthS
 
 	return "", errors.New("failed to extract username from oauth resource")
 }
+
+// getGroups returns the values of the groups claim, the claim can either be a list or a single value
+// holding comma separated groups
+func getGroups(datamap map[string]interface{}, configuration *portainer.OAuthSettings) []string {
+	claimName := configuration.TeamMemberships.OAuthClaimName
+	if claimName == "" {
+		return nil
+	}
+
+	var groups []string
+
+	switch claim := datamap[claimName].(type) {
+	case string:
+		for _, group := range strings.Split(claim, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+	case []interface{}:
+		for _, value := range claim {
+			if group, ok := value.(string); ok && group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return groups
+}
@@ -78,3 +78,32 @@ func Test_getUsername(t *testing.T) {
 		}
 	})
 }
+
+func Test_getGroups(t *testing.T) {
+	oauthSettings := &portaineree.OAuthSettings{TeamMemberships: portaineree.OAuthTeamMemberships{OAuthClaimName: "groups"}}
+
+	tests := []struct {
+		name     string
+		datamap  map[string]interface{}
+		expected []string
+	}{
+		{name: "missing claim", datamap: map[string]interface{}{}, expected: nil},
+		{name: "list claim", datamap: map[string]interface{}{"groups": []interface{}{"first", 1, "second"}}, expected: []string{"first", "second"}},
+		{name: "comma separated claim", datamap: map[string]interface{}{"groups": "first, second"}, expected: []string{"first", "second"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			groups := getGroups(test.datamap, oauthSettings)
+			if len(groups) != len(test.expected) {
+				t.Fatalf("getGroups returned %v, want %v", groups, test.expected)
+			}
+
+			for i := range groups {
+				if groups[i] != test.expected[i] {
+					t.Errorf("getGroups returned %v, want %v", groups, test.expected)
+				}
+			}
+		})
+	}
+}
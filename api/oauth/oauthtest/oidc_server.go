@@ -0,0 +1,205 @@
+package oauthtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// OIDCClientID is the client identifier expected by the OpenID Connect server
+	OIDCClientID = "test-client"
+	// OIDCKeyID is the identifier of the key signing the ID tokens
+	OIDCKeyID = "test-key"
+)
+
+// OIDCServer is a barebones OpenID Connect provider which can be used to test the discovery,
+// the validation of the ID tokens, PKCE, the nonce and the groups claim
+type OIDCServer struct {
+	*httptest.Server
+
+	// Claims added to the ID tokens
+	Claims map[string]interface{}
+	// Claims returned by the userinfo endpoint along with the subject of the ID tokens
+	UserInfo map[string]interface{}
+	// Key signing the ID tokens, it can be replaced to issue tokens with an invalid signature
+	SigningKey *rsa.PrivateKey
+
+	code       string
+	publicKey  *rsa.PublicKey
+	mu         sync.Mutex
+	challenges map[string]string
+	nonces     map[string]string
+}
+
+// RunOIDCServer starts an OpenID Connect provider accepting the code, the issuer URL is the URL of the server
+func RunOIDCServer(code string, claims map[string]interface{}) *OIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	srv := &OIDCServer{
+		Claims:     claims,
+		SigningKey: key,
+		code:       code,
+		publicKey:  &key.PublicKey,
+		challenges: map[string]string{},
+		nonces:     map[string]string{},
+	}
+
+	srv.Server = httptest.NewServer(srv.routes())
+
+	return srv
+}
+
+// Authorize registers the PKCE code challenge and the nonce of an authorization request for the code, as the
+// authorization endpoint would do during the redirection of the browser. The nonce is added to the ID tokens
+func (srv *OIDCServer) Authorize(codeChallenge, nonce string) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if codeChallenge != "" {
+		srv.challenges[srv.code] = codeChallenge
+	} else {
+		delete(srv.challenges, srv.code)
+	}
+
+	srv.nonces[srv.code] = nonce
+}
+
+func (srv *OIDCServer) routes() http.Handler {
+	router := mux.NewRouter()
+
+	router.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"userinfo_endpoint":      srv.URL + "/userinfo",
+			"jwks_uri":               srv.URL + "/jwks",
+			"end_session_endpoint":   srv.URL + "/logout",
+		})
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/jwks", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": OIDCKeyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(srv.publicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(srv.publicKey.E)).Bytes()),
+			}},
+		})
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if req.FormValue("code") != srv.code {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		srv.mu.Lock()
+		challenge, ok := srv.challenges[srv.code]
+		srv.mu.Unlock()
+
+		if ok {
+			hash := sha256.Sum256([]byte(req.FormValue("code_verifier")))
+			if base64.RawURLEncoding.EncodeToString(hash[:]) != challenge {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+		}
+
+		idToken, err := srv.idToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"access_token": AccessToken,
+			"id_token":     idToken,
+		})
+	}).Methods(http.MethodPost)
+
+	router.HandleFunc("/userinfo", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer "+AccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		userInfo := map[string]interface{}{"sub": srv.Claims["sub"]}
+		for k, v := range srv.UserInfo {
+			userInfo[k] = v
+		}
+
+		writeJSON(w, http.StatusOK, userInfo)
+	}).Methods(http.MethodGet)
+
+	return router
+}
+
+func (srv *OIDCServer) idToken() (string, error) {
+	claims := jwt.MapClaims{
+		"iss": srv.URL,
+		"aud": OIDCClientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	for k, v := range srv.Claims {
+		claims[k] = v
+	}
+
+	srv.mu.Lock()
+	if nonce := srv.nonces[srv.code]; nonce != "" {
+		claims["nonce"] = nonce
+	}
+	srv.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = OIDCKeyID
+
+	return token.SignedString(srv.SigningKey)
+}
+
+// OAuthSettings returns the settings of an OpenID Connect client of the server
+func (srv *OIDCServer) OAuthSettings() *portainer.OAuthSettings {
+	return &portainer.OAuthSettings{
+		ClientID:       OIDCClientID,
+		RedirectURI:    srv.URL + "/callback",
+		UserIdentifier: "preferred_username",
+		OpenIDConnect: portainer.OpenIDConnectSettings{
+			Enabled:   true,
+			IssuerURL: srv.URL,
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
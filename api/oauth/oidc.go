@@ -0,0 +1,233 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	openIDConfigurationPath = "/.well-known/openid-configuration"
+	openIDScope             = "openid"
+	oidcRequestTimeout      = 10 * time.Second
+)
+
+var idTokenSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// openIDConfiguration represents the fields of the OpenID Connect discovery document used by Portainer
+type openIDConfiguration struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// jsonWebKey represents a public key of a JSON Web Key Set (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// DiscoverOpenIDConfiguration retrieves the discovery document of the issuer and fills the
+// endpoints of the provider along with the openid scope. The logout URI is only filled when it is not already defined.
+func (*Service) DiscoverOpenIDConfiguration(configuration *portainer.OAuthSettings) error {
+	issuerURL := strings.TrimSuffix(configuration.OpenIDConnect.IssuerURL, "/")
+	if issuerURL == "" {
+		return errors.New("missing OpenID Connect issuer URL")
+	}
+
+	var discovery openIDConfiguration
+	err := getJSON(issuerURL+openIDConfigurationPath, &discovery)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the OpenID Connect discovery document")
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuerURL {
+		return fmt.Errorf("the issuer of the discovery document %q does not match %q", discovery.Issuer, configuration.OpenIDConnect.IssuerURL)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return errors.New("the OpenID Connect discovery document is missing required endpoints")
+	}
+
+	configuration.AuthorizationURI = discovery.AuthorizationEndpoint
+	configuration.AccessTokenURI = discovery.TokenEndpoint
+	configuration.ResourceURI = discovery.UserinfoEndpoint
+	configuration.OpenIDConnect.JWKSURI = discovery.JWKSURI
+
+	if configuration.LogoutURI == "" {
+		configuration.LogoutURI = discovery.EndSessionEndpoint
+	}
+
+	configuration.Scopes = withOpenIDScope(configuration.Scopes)
+
+	return nil
+}
+
+// withOpenIDScope adds the openid scope required by OpenID Connect to the comma separated scopes
+func withOpenIDScope(scopes string) string {
+	if scopes == "" {
+		return openIDScope
+	}
+
+	for _, scope := range strings.Split(scopes, ",") {
+		if strings.TrimSpace(scope) == openIDScope {
+			return scopes
+		}
+	}
+
+	return scopes + "," + openIDScope
+}
+
+// validateIdToken verifies the signature of the ID token with the keys of the provider along with
+// its issuer, audience, expiry and nonce, and returns its claims
+func validateIdToken(rawIdToken, nonce string, configuration *portainer.OAuthSettings) (map[string]interface{}, error) {
+	keys, err := getJSONWebKeys(configuration.OpenIDConnect.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(idTokenSigningMethods))
+
+	_, err = parser.ParseWithClaims(rawIdToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		for _, key := range keys {
+			if (kid == "" || key.Kid == kid) && (key.Use == "" || key.Use == "sig") {
+				return key.publicKey()
+			}
+		}
+
+		return nil, fmt.Errorf("no signing key found for kid %q", kid)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid id_token")
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if issuer == "" || strings.TrimSuffix(issuer, "/") != strings.TrimSuffix(configuration.OpenIDConnect.IssuerURL, "/") {
+		return nil, errors.New("invalid id_token issuer")
+	}
+
+	if !claims.VerifyAudience(configuration.ClientID, true) {
+		return nil, errors.New("invalid id_token audience")
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("missing id_token expiry")
+	}
+
+	tokenNonce, _ := claims["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid id_token nonce")
+	}
+
+	return claims, nil
+}
+
+func getJSONWebKeys(jwksURI string) ([]jsonWebKey, error) {
+	if jwksURI == "" {
+		return nil, errors.New("missing JSON Web Key Set URI")
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	err := getJSON(jwksURI, &jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the JSON Web Key Set")
+	}
+
+	return jwks.Keys, nil
+}
+
+func (key jsonWebKey) publicKey() (interface{}, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(key.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported elliptic curve %q", key.Crv)
+		}
+
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", key.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid JSON Web Key")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+func getJSON(url string, target interface{}) error {
+	client := &http.Client{Timeout: oidcRequestTimeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
@@ -0,0 +1,120 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/portainer/portainer/api/oauth/oauthtest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiscoverOpenIDConfiguration(t *testing.T) {
+	is := assert.New(t)
+
+	srv := oauthtest.RunOIDCServer("code", nil)
+	defer srv.Close()
+
+	config := srv.OAuthSettings()
+	config.Scopes = "profile,groups"
+
+	is.NoError(NewService().DiscoverOpenIDConfiguration(config))
+	is.Equal(srv.URL+"/authorize", config.AuthorizationURI)
+	is.Equal(srv.URL+"/token", config.AccessTokenURI)
+	is.Equal(srv.URL+"/userinfo", config.ResourceURI)
+	is.Equal(srv.URL+"/jwks", config.OpenIDConnect.JWKSURI)
+	is.Equal(srv.URL+"/logout", config.LogoutURI)
+	is.Equal("profile,groups,openid", config.Scopes)
+
+	config.OpenIDConnect.IssuerURL = srv.URL + "/other"
+	is.Error(NewService().DiscoverOpenIDConfiguration(config), "the discovery document should not be found")
+}
+
+func Test_AuthenticateOpenIDConnect(t *testing.T) {
+	is := assert.New(t)
+	code := "valid-code"
+
+	srv := oauthtest.RunOIDCServer(code, map[string]interface{}{
+		"sub":                "1234",
+		"preferred_username": "oidc-user",
+		"groups":             []interface{}{"developers", "admins"},
+	})
+	defer srv.Close()
+
+	nonce := "n-0S6_WzA2Mj"
+	srv.Authorize("", nonce)
+
+	config := srv.OAuthSettings()
+	config.TeamMemberships.OAuthClaimName = "groups"
+	is.NoError(NewService().DiscoverOpenIDConfiguration(config))
+
+	t.Run("should return the username and the groups of a valid id_token", func(t *testing.T) {
+		info, err := NewService().Authenticate(code, "", nonce, config)
+		is.NoError(err)
+		is.Equal("oidc-user", info.Username)
+		is.Equal([]string{"developers", "admins"}, info.Groups)
+	})
+
+	t.Run("should prefer the claims of the id_token over the ones of the userinfo endpoint", func(t *testing.T) {
+		srv.UserInfo = map[string]interface{}{
+			"preferred_username": "other-user",
+			"groups":             []interface{}{"admins"},
+		}
+		defer func() { srv.UserInfo = nil }()
+
+		info, err := NewService().Authenticate(code, "", nonce, config)
+		is.NoError(err)
+		is.Equal("oidc-user", info.Username)
+		is.Equal([]string{"developers", "admins"}, info.Groups)
+	})
+
+	t.Run("should validate the nonce of the id_token", func(t *testing.T) {
+		_, err := NewService().Authenticate(code, "", "", config)
+		is.Error(err, "the nonce should be required")
+
+		_, err = NewService().Authenticate(code, "", "other-nonce", config)
+		is.Error(err)
+	})
+
+	t.Run("should fail with an id_token signed with an unknown key", func(t *testing.T) {
+		signingKey := srv.SigningKey
+		defer func() { srv.SigningKey = signingKey }()
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		is.NoError(err)
+		srv.SigningKey = key
+
+		_, err = NewService().Authenticate(code, "", nonce, config)
+		is.Error(err)
+	})
+
+	t.Run("should fail with an id_token issued for another client", func(t *testing.T) {
+		otherConfig := *config
+		otherConfig.ClientID = "other-client"
+
+		_, err := NewService().Authenticate(code, "", nonce, &otherConfig)
+		is.Error(err)
+	})
+
+	t.Run("should validate the PKCE code verifier", func(t *testing.T) {
+		pkceConfig := *config
+		pkceConfig.PKCE = true
+
+		verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		hash := sha256.Sum256([]byte(verifier))
+		srv.Authorize(base64.RawURLEncoding.EncodeToString(hash[:]), nonce)
+
+		_, err := NewService().Authenticate(code, "", nonce, &pkceConfig)
+		is.Error(err, "the code verifier should be required")
+
+		_, err = NewService().Authenticate(code, "invalid-verifier", nonce, &pkceConfig)
+		is.Error(err)
+
+		info, err := NewService().Authenticate(code, verifier, nonce, &pkceConfig)
+		is.NoError(err)
+		is.Equal("oidc-user", info.Username)
+	})
+}
@@ -118,3 +118,20 @@ func Test_StartJobEvery_Concurrently(t *testing.T) {
 
 	<-ctx.Done()
 }
+
+func Test_StartJobWithCron_InvalidRule(t *testing.T) {
+	s := NewScheduler(context.Background())
+	defer s.Shutdown()
+
+	_, err := s.StartJobWithCron("not a cron rule", func() error { return nil })
+	assert.Error(t, err)
+}
+
+func Test_StartJobWithCron_CanBeStopped(t *testing.T) {
+	s := NewScheduler(context.Background())
+	defer s.Shutdown()
+
+	jobID, err := s.StartJobWithCron("@every 1s", func() error { return nil })
+	assert.NoError(t, err)
+	assert.NoError(t, s.StopJob(jobID))
+}
@@ -0,0 +1,61 @@
+package deployments
+
+import (
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	pendingDeploymentSweepInterval = time.Hour
+	// pendingDeploymentRetention is the duration during which the decided and expired deployments are kept after
+	// their expiry
+	pendingDeploymentRetention = 30 * 24 * time.Hour
+)
+
+// StartPendingDeploymentSweep periodically expires the pending deployments and removes the old ones
+func StartPendingDeploymentSweep(scheduler *scheduler.Scheduler, datastore dataservices.DataStore) {
+	scheduler.StartJobEvery(pendingDeploymentSweepInterval, func() error {
+		if err := SweepPendingDeployments(datastore, time.Now()); err != nil {
+			log.Warn().Err(err).Msg("unable to sweep the pending deployments")
+		}
+
+		// the sweep is retried on the next run
+		return nil
+	})
+}
+
+// SweepPendingDeployments marks the deployments which were not approved in time as expired and drops their
+// requests, and removes the deployments whose expiry is older than the retention
+func SweepPendingDeployments(datastore dataservices.DataStore, now time.Time) error {
+	return datastore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		pendingDeployments, err := tx.PendingDeployment().PendingDeployments()
+		if err != nil {
+			return err
+		}
+
+		for i := range pendingDeployments {
+			pending := &pendingDeployments[i]
+
+			switch {
+			case now.Unix() >= pending.ExpiresAt+int64(pendingDeploymentRetention.Seconds()):
+				err = tx.PendingDeployment().DeletePendingDeployment(pending.ID)
+			case pending.Status == portainer.PendingDeploymentPending && now.Unix() >= pending.ExpiresAt:
+				pending.Status = portainer.PendingDeploymentExpired
+				pending.Request = nil
+
+				err = tx.PendingDeployment().UpdatePendingDeployment(pending.ID, pending)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
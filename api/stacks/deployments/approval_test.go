@@ -0,0 +1,47 @@
+package deployments
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SweepPendingDeployments(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	defer teardown()
+
+	now := time.Now()
+	request := &portainer.PendingDeploymentRequest{Method: "PUT", URL: "/stacks/1"}
+
+	deployments := []*portainer.PendingDeployment{
+		{Status: portainer.PendingDeploymentPending, ExpiresAt: now.Add(time.Hour).Unix(), Request: request},
+		{Status: portainer.PendingDeploymentPending, ExpiresAt: now.Add(-time.Hour).Unix(), Request: request},
+		{Status: portainer.PendingDeploymentApproved, ExpiresAt: now.Add(-time.Hour).Unix()},
+		{Status: portainer.PendingDeploymentRejected, ExpiresAt: now.Add(-pendingDeploymentRetention - time.Hour).Unix()},
+		{Status: portainer.PendingDeploymentPending, ExpiresAt: now.Add(-pendingDeploymentRetention - time.Hour).Unix(), Request: request},
+	}
+
+	for _, pending := range deployments {
+		is.NoError(store.PendingDeployment().Create(pending))
+	}
+
+	is.NoError(SweepPendingDeployments(store, now))
+
+	remaining, err := store.PendingDeployment().PendingDeployments()
+	is.NoError(err)
+	is.Len(remaining, 3, "the deployments expired for longer than the retention should be removed")
+
+	is.Equal(portainer.PendingDeploymentPending, remaining[0].Status)
+	is.NotNil(remaining[0].Request)
+
+	is.Equal(portainer.PendingDeploymentExpired, remaining[1].Status)
+	is.Nil(remaining[1].Request, "the request of the expired deployments should be dropped")
+
+	is.Equal(portainer.PendingDeploymentApproved, remaining[2].Status)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/metrics"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -62,14 +63,18 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 	if !stack.FromAppTemplate {
 		updated, newHash, err := update.UpdateGitObject(gitService, fmt.Sprintf("stack:%d", stackID), stack.GitConfig, false, stack.ProjectPath)
 		if err != nil {
+			metrics.IncGitAutoUpdatePolls(metrics.GitPollError)
 			return err
 		}
 
+		pollResult := metrics.GitPollUnchanged
 		if updated {
+			pollResult = metrics.GitPollUpdated
 			stack.GitConfig.ConfigHash = newHash
 			stack.UpdateDate = time.Now().Unix()
 			gitCommitChangedOrForceUpdate = updated
 		}
+		metrics.IncGitAutoUpdatePolls(pollResult)
 	}
 
 	if !gitCommitChangedOrForceUpdate {
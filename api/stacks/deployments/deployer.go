@@ -2,12 +2,19 @@ package deployments
 
 import (
 	"context"
+	"strconv"
 	"sync"
 
 	"github.com/pkg/errors"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/admission"
+	"github.com/portainer/portainer/api/dataservices"
 	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/vulnerability"
+
+	"github.com/rs/zerolog/log"
 )
 
 type StackDeployer interface {
@@ -21,21 +28,43 @@ type stackDeployer struct {
 	swarmStackManager   portainer.SwarmStackManager
 	composeStackManager portainer.ComposeStackManager
 	kubernetesDeployer  portainer.KubernetesDeployer
+	notificationService portainer.NotificationService
+	dataStore           dataservices.DataStore
+	fileService         portainer.FileService
+	imageScanService    portainer.ImageScanService
 }
 
-// NewStackDeployer inits a stackDeployer struct with a SwarmStackManager, a ComposeStackManager and a KubernetesDeployer
-func NewStackDeployer(swarmStackManager portainer.SwarmStackManager, composeStackManager portainer.ComposeStackManager, kubernetesDeployer portainer.KubernetesDeployer) *stackDeployer {
+// NewStackDeployer inits a stackDeployer struct with a SwarmStackManager, a ComposeStackManager, a KubernetesDeployer
+// and a NotificationService used to report the outcome of the deployments.
+// The DataStore and the FileService are used to record the deployment history of the stacks.
+// The ImageScanService, when defined, can block the deployment of the stacks whose images have vulnerabilities.
+func NewStackDeployer(swarmStackManager portainer.SwarmStackManager, composeStackManager portainer.ComposeStackManager, kubernetesDeployer portainer.KubernetesDeployer, notificationService portainer.NotificationService, dataStore dataservices.DataStore, fileService portainer.FileService, imageScanService portainer.ImageScanService) *stackDeployer {
 	return &stackDeployer{
 		lock:                &sync.Mutex{},
 		swarmStackManager:   swarmStackManager,
 		composeStackManager: composeStackManager,
 		kubernetesDeployer:  kubernetesDeployer,
+		notificationService: notificationService,
+		dataStore:           dataStore,
+		fileService:         fileService,
+		imageScanService:    imageScanService,
 	}
 }
 
-func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, prune bool, pullImage bool) error {
+func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, prune bool, pullImage bool) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
+	defer func() { d.afterDeploy(stack, stackAuthor(stack), err) }()
+
+	err = d.admitStack(stack, endpoint)
+	if err != nil {
+		return err
+	}
+
+	err = d.checkImages(stack, registries)
+	if err != nil {
+		return err
+	}
 
 	d.swarmStackManager.Login(registries, endpoint)
 	defer d.swarmStackManager.Logout(endpoint)
@@ -43,9 +72,20 @@ func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *porta
 	return d.swarmStackManager.Deploy(stack, prune, pullImage, endpoint)
 }
 
-func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, forcePullImage bool, forceRereate bool) error {
+func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, forcePullImage bool, forceRereate bool) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
+	defer func() { d.afterDeploy(stack, stackAuthor(stack), err) }()
+
+	err = d.admitStack(stack, endpoint)
+	if err != nil {
+		return err
+	}
+
+	err = d.checkImages(stack, registries)
+	if err != nil {
+		return err
+	}
 
 	d.swarmStackManager.Login(registries, endpoint)
 	defer d.swarmStackManager.Logout(endpoint)
@@ -58,16 +98,27 @@ func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *por
 		}
 	}
 
-	err := d.composeStackManager.Up(context.TODO(), stack, endpoint, forceRereate)
+	err = d.composeStackManager.Up(context.TODO(), stack, endpoint, forceRereate)
 	if err != nil {
 		d.composeStackManager.Down(context.TODO(), stack, endpoint)
 	}
 	return err
 }
 
-func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) error {
+func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
+	defer func() { d.afterDeploy(stack, user.Username, err) }()
+
+	registries, err := d.endpointRegistries(endpoint)
+	if err != nil {
+		return err
+	}
+
+	err = d.checkImages(stack, registries)
+	if err != nil {
+		return err
+	}
 
 	appLabels := k.KubeAppLabels{
 		StackID:   int(stack.ID),
@@ -93,3 +144,101 @@ func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *
 
 	return nil
 }
+
+// admitStack evaluates the compose files of the stack against the admission policies of the environment(endpoint)
+func (d *stackDeployer) admitStack(stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+	if d.dataStore == nil || d.fileService == nil {
+		return nil
+	}
+
+	return admission.AdmitStack(d.dataStore, d.fileService, stack, endpoint)
+}
+
+// endpointRegistries returns the registries the environment(endpoint) has access to
+func (d *stackDeployer) endpointRegistries(endpoint *portainer.Endpoint) ([]portainer.Registry, error) {
+	if d.imageScanService == nil || d.dataStore == nil {
+		return nil, nil
+	}
+
+	registries, err := d.dataStore.Registry().Registries()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the registries")
+	}
+
+	endpointRegistries := []portainer.Registry{}
+	for _, registry := range registries {
+		if _, ok := registry.RegistryAccesses[endpoint.ID]; ok {
+			endpointRegistries = append(endpointRegistries, registry)
+		}
+	}
+
+	return endpointRegistries, nil
+}
+
+// checkImages verifies the vulnerabilities of the images referenced by the files of the stack, the images are pulled
+// with the credentials of the registries
+func (d *stackDeployer) checkImages(stack *portainer.Stack, registries []portainer.Registry) error {
+	if d.imageScanService == nil || d.fileService == nil {
+		return nil
+	}
+
+	images := []string{}
+	for _, file := range stackutils.GetStackFilePaths(stack, false) {
+		content, err := d.fileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the stack file %s", file)
+		}
+
+		fileImages, err := vulnerability.ExtractImages(content, stack.Env)
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract the images of the stack file %s", file)
+		}
+
+		images = append(images, fileImages...)
+	}
+
+	return d.imageScanService.CheckImages(context.TODO(), images, registries)
+}
+
+// afterDeploy records the deployed version of the stack when the deployment succeeded
+// and reports the outcome of the deployment
+func (d *stackDeployer) afterDeploy(stack *portainer.Stack, deployedBy string, deployErr error) {
+	if deployErr == nil && d.dataStore != nil && d.fileService != nil {
+		_, err := RecordStackVersion(d.dataStore, d.fileService, stack, deployedBy)
+		if err != nil {
+			log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to record the stack version")
+		}
+	}
+
+	d.notify(stack, deployErr)
+}
+
+// stackAuthor returns the username of the last user who updated the stack
+func stackAuthor(stack *portainer.Stack) string {
+	if stack.UpdatedBy != "" {
+		return stack.UpdatedBy
+	}
+
+	return stack.CreatedBy
+}
+
+// notify sends the outcome of a stack deployment to the notification channels
+func (d *stackDeployer) notify(stack *portainer.Stack, deployErr error) {
+	if d.notificationService == nil {
+		return
+	}
+
+	event := portainer.NotificationEvent{
+		Type:         portainer.NotificationEventStackDeploySuccess,
+		EndpointID:   stack.EndpointID,
+		ResourceID:   strconv.Itoa(int(stack.ID)),
+		ResourceName: stack.Name,
+	}
+
+	if deployErr != nil {
+		event.Type = portainer.NotificationEventStackDeployFailure
+		event.Message = deployErr.Error()
+	}
+
+	d.notificationService.Notify(event)
+}
@@ -36,10 +36,6 @@ func (config *KubernetesStackDeploymentConfig) GetUsername() string {
 }
 
 func (config *KubernetesStackDeploymentConfig) Deploy() error {
-	fileNames := stackutils.GetStackFilePaths(config.stack, false)
-
-	manifestFilePaths := make([]string, len(fileNames))
-
 	tmpDir, err := os.MkdirTemp("", "kub_deployment")
 	if err != nil {
 		return errors.Wrap(err, "failed to create temp kub deployment directory")
@@ -47,40 +43,107 @@ func (config *KubernetesStackDeploymentConfig) Deploy() error {
 
 	defer os.RemoveAll(tmpDir)
 
+	manifestFilePaths, err := config.prepareManifests(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	output, err := config.kuberneteDeployer.Deploy(config.user.ID, config.endpoint, manifestFilePaths, config.stack.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to deploy kubernete stack: %w", err)
+	}
+
+	config.output = output
+	return nil
+}
+
+// DryRun returns the deployed objects of the stack and the objects as they would be applied, without deploying them
+func (config *KubernetesStackDeploymentConfig) DryRun() ([]byte, []byte, error) {
+	tmpDir, err := os.MkdirTemp("", "kub_dry_run")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create temp kub dry-run directory")
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	manifestFilePaths, err := config.prepareManifests(tmpDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	live, applied, err := config.kuberneteDeployer.DryRun(config.user.ID, config.endpoint, manifestFilePaths, config.stack.Namespace)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to dry-run kubernetes stack")
+	}
+
+	return live, applied, nil
+}
+
+// prepareManifests writes the manifests of the stack in the directory, converted from the compose files or
+// rendered when it is required, with the application labels
+func (config *KubernetesStackDeploymentConfig) prepareManifests(dir string) ([]string, error) {
+	if config.stack.KubernetesBuild != nil {
+		manifestFilePath, err := config.renderManifest(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{manifestFilePath}, nil
+	}
+
+	fileNames := stackutils.GetStackFilePaths(config.stack, false)
+	manifestFilePaths := make([]string, 0, len(fileNames))
+
 	for _, fileName := range fileNames {
-		manifestFilePath := filesystem.JoinPaths(tmpDir, fileName)
+		manifestFilePath := filesystem.JoinPaths(dir, fileName)
 		manifestContent, err := os.ReadFile(filesystem.JoinPaths(config.stack.ProjectPath, fileName))
 		if err != nil {
-			return errors.Wrap(err, "failed to read manifest file")
+			return nil, errors.Wrap(err, "failed to read manifest file")
 		}
 
 		if config.stack.IsComposeFormat {
 			manifestContent, err = config.kuberneteDeployer.ConvertCompose(manifestContent)
 			if err != nil {
-				return errors.Wrap(err, "failed to convert docker compose file to a kube manifest")
+				return nil, errors.Wrap(err, "failed to convert docker compose file to a kube manifest")
 			}
 		}
 
 		manifestContent, err = k.AddAppLabels(manifestContent, config.appLabels.ToMap())
 		if err != nil {
-			return errors.Wrap(err, "failed to add application labels")
+			return nil, errors.Wrap(err, "failed to add application labels")
 		}
 
 		err = filesystem.WriteToFile(manifestFilePath, []byte(manifestContent))
 		if err != nil {
-			return errors.Wrap(err, "failed to create temp manifest file")
+			return nil, errors.Wrap(err, "failed to create temp manifest file")
 		}
 
 		manifestFilePaths = append(manifestFilePaths, manifestFilePath)
 	}
 
-	output, err := config.kuberneteDeployer.Deploy(config.user.ID, config.endpoint, manifestFilePaths, config.stack.Namespace)
+	return manifestFilePaths, nil
+}
+
+// renderManifest renders the manifests of the stack in a single file of the directory
+func (config *KubernetesStackDeploymentConfig) renderManifest(dir string) (string, error) {
+	manifestContent, err := stackutils.RenderKubernetesManifest(config.stack, config.kuberneteDeployer)
 	if err != nil {
-		return fmt.Errorf("failed to deploy kubernete stack: %w", err)
+		return "", errors.Wrap(err, "failed to render the kube manifests")
 	}
 
-	config.output = output
-	return nil
+	manifestContent, err = k.AddAppLabels(manifestContent, config.appLabels.ToMap())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to add application labels")
+	}
+
+	manifestFilePath := filesystem.JoinPaths(dir, "manifest.yaml")
+
+	err = filesystem.WriteToFile(manifestFilePath, manifestContent)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temp manifest file")
+	}
+
+	return manifestFilePath, nil
 }
 
 func (config *KubernetesStackDeploymentConfig) GetResponse() string {
@@ -0,0 +1,120 @@
+package deployments
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/exec"
+	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/stretchr/testify/assert"
+)
+
+const helmManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.25
+`
+
+// writeHelmStub writes a helm script which records its arguments, one per line, and prints a rendered manifest
+func writeHelmStub(t *testing.T) (string, string) {
+	t.Helper()
+
+	binaryPath := t.TempDir()
+	argsFile := filepath.Join(t.TempDir(), "helm.args")
+
+	script := "#!/bin/sh\n" +
+		"printf '%s\\n' \"$@\" > " + argsFile + " || exit 1\n" +
+		"cat <<'EOF'\n" + helmManifest + "EOF\n"
+
+	err := os.WriteFile(filepath.Join(binaryPath, "helm"), []byte(script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return binaryPath, argsFile
+}
+
+func Test_prepareManifests_RendersTheHelmChart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stub binaries are shell scripts")
+	}
+
+	is := assert.New(t)
+
+	binaryPath, argsFile := writeHelmStub(t)
+
+	stack := &portainer.Stack{
+		ID:          1,
+		Name:        "web",
+		Namespace:   "production",
+		ProjectPath: "/data/compose/1",
+		EntryPoint:  "charts/web/Chart.yaml",
+		KubernetesBuild: &portainer.KubernetesBuildConfig{
+			Mode:        portainer.KubernetesBuildHelm,
+			ValuesFiles: []string{"charts/web/values-production.yaml"},
+		},
+	}
+
+	config, err := CreateKubernetesStackDeploymentConfig(stack, exec.NewKubernetesDeployer(nil, nil, nil, nil, nil, nil, binaryPath),
+		k.KubeAppLabels{StackID: 1, StackName: "web", Owner: "admin", Kind: "git"}, &portainer.User{ID: 1, Username: "admin"}, &portainer.Endpoint{ID: 1})
+	is.NoError(err)
+
+	dir := t.TempDir()
+	manifestFilePaths, err := config.prepareManifests(dir)
+	is.NoError(err)
+	is.Equal([]string{filepath.Join(dir, "manifest.yaml")}, manifestFilePaths)
+
+	args, err := os.ReadFile(argsFile)
+	is.NoError(err)
+	is.Equal([]string{
+		"template", "web", "/data/compose/1/charts/web", "--include-crds",
+		"--namespace", "production",
+		"--values", "/data/compose/1/charts/web/values-production.yaml",
+	}, strings.Split(strings.TrimSuffix(string(args), "\n"), "\n"), "the release should be named after the stack")
+
+	manifest, err := os.ReadFile(manifestFilePaths[0])
+	is.NoError(err)
+	is.Contains(string(manifest), "image: nginx:1.25")
+	is.Contains(string(manifest), "io.portainer.kubernetes.application.stack: web")
+	is.Contains(string(manifest), `io.portainer.kubernetes.application.stackid: "1"`)
+	is.Contains(string(manifest), "io.portainer.kubernetes.application.owner: admin")
+}
+
+func Test_prepareManifests_KeepsTheManifestFiles(t *testing.T) {
+	is := assert.New(t)
+
+	projectPath := t.TempDir()
+	err := os.WriteFile(filepath.Join(projectPath, "deployment.yaml"), []byte(helmManifest), 0644)
+	is.NoError(err)
+
+	stack := &portainer.Stack{
+		ID:          2,
+		Name:        "web",
+		ProjectPath: projectPath,
+		EntryPoint:  "deployment.yaml",
+	}
+
+	config, err := CreateKubernetesStackDeploymentConfig(stack, exec.NewKubernetesDeployer(nil, nil, nil, nil, nil, nil, t.TempDir()),
+		k.KubeAppLabels{StackID: 2, StackName: "web", Owner: "admin", Kind: "content"}, &portainer.User{ID: 1, Username: "admin"}, &portainer.Endpoint{ID: 1})
+	is.NoError(err)
+
+	dir := t.TempDir()
+	manifestFilePaths, err := config.prepareManifests(dir)
+	is.NoError(err)
+	is.Equal([]string{filepath.Join(dir, "deployment.yaml")}, manifestFilePaths)
+
+	manifest, err := os.ReadFile(manifestFilePaths[0])
+	is.NoError(err)
+	is.Contains(string(manifest), "image: nginx:1.25")
+	is.Contains(string(manifest), "io.portainer.kubernetes.application.kind: content")
+}
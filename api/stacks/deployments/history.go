@@ -0,0 +1,91 @@
+package deployments
+
+import (
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/pkg/errors"
+)
+
+// RecordStackVersion saves a snapshot of the deployed files, environment variables and git commit
+// of a stack in its deployment history. The oldest versions beyond the retention of the stack are removed.
+func RecordStackVersion(dataStore dataservices.DataStore, fileService portainer.FileService, stack *portainer.Stack, deployedBy string) (*portainer.StackVersion, error) {
+	version := &portainer.StackVersion{
+		StackID:         stack.ID,
+		EntryPoint:      stack.EntryPoint,
+		AdditionalFiles: append([]string{}, stack.AdditionalFiles...),
+		FileContents:    make(map[string]string),
+		Env:             append([]portainer.Pair{}, stack.Env...),
+		DeployedBy:      deployedBy,
+		Timestamp:       time.Now().Unix(),
+	}
+
+	if stack.GitConfig != nil {
+		version.ConfigHash = stack.GitConfig.ConfigHash
+	}
+
+	for _, file := range stackutils.GetStackFilePaths(stack, false) {
+		content, err := fileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to read the stack file %s", file)
+		}
+
+		version.FileContents[file] = string(content)
+	}
+
+	versions, err := dataStore.StackVersion().StackVersionsByStackID(stack.ID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to retrieve the versions of the stack %v", stack.ID)
+	}
+
+	version.Version = 1
+	if len(versions) > 0 {
+		version.Version = versions[len(versions)-1].Version + 1
+	}
+
+	err = dataStore.StackVersion().Create(version)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to save the version of the stack %v", stack.ID)
+	}
+
+	versions = append(versions, *version)
+
+	return version, PruneStackVersions(dataStore, versions, stack.HistoryRetention)
+}
+
+// PruneStackVersions removes the oldest versions of a stack history, ordered by version number,
+// so that only the given number of versions is kept. A retention of 0 uses the default retention.
+func PruneStackVersions(dataStore dataservices.DataStore, versions []portainer.StackVersion, retention int) error {
+	if retention <= 0 {
+		retention = portainer.DefaultStackHistoryRetention
+	}
+
+	for i := 0; i < len(versions)-retention; i++ {
+		err := dataStore.StackVersion().DeleteStackVersion(versions[i].ID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to remove the version %d of the stack %v", versions[i].Version, versions[i].StackID)
+		}
+	}
+
+	return nil
+}
+
+// DeleteStackVersions removes the whole deployment history of a stack
+func DeleteStackVersions(dataStore dataservices.DataStore, stackID portainer.StackID) error {
+	versions, err := dataStore.StackVersion().StackVersionsByStackID(stackID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to retrieve the versions of the stack %v", stackID)
+	}
+
+	for _, version := range versions {
+		err := dataStore.StackVersion().DeleteStackVersion(version.ID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to remove the version %d of the stack %v", version.Version, stackID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package deployments
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RecordStackVersion(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	defer teardown()
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	projectPath, err := fileService.StoreStackFileFromBytes("1", "docker-compose.yml", []byte("version: '3'"))
+	is.NoError(err)
+
+	stack := &portainer.Stack{
+		ID:               1,
+		EntryPoint:       "docker-compose.yml",
+		ProjectPath:      projectPath,
+		Env:              []portainer.Pair{{Name: "TAG", Value: "1.0"}},
+		HistoryRetention: 2,
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := RecordStackVersion(store, fileService, stack, "admin")
+		is.NoError(err)
+	}
+
+	versions, err := store.StackVersion().StackVersionsByStackID(stack.ID)
+	is.NoError(err)
+	is.Len(versions, 2, "the versions beyond the retention should be removed")
+	is.Equal(2, versions[0].Version)
+	is.Equal(3, versions[1].Version)
+	is.Equal("version: '3'", versions[1].FileContents["docker-compose.yml"])
+	is.Equal(stack.Env, versions[1].Env)
+	is.Equal("admin", versions[1].DeployedBy)
+}
+
+func Test_DeleteStackVersions(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, false)
+	defer teardown()
+
+	is.NoError(store.StackVersion().Create(&portainer.StackVersion{StackID: 1, Version: 1}))
+	is.NoError(store.StackVersion().Create(&portainer.StackVersion{StackID: 2, Version: 1}))
+
+	is.NoError(DeleteStackVersions(store, 1))
+
+	versions, err := store.StackVersion().StackVersionsByStackID(1)
+	is.NoError(err)
+	is.Empty(versions)
+
+	versions, err = store.StackVersion().StackVersionsByStackID(2)
+	is.NoError(err)
+	is.Len(versions, 1)
+}
@@ -0,0 +1,302 @@
+package drift
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+)
+
+const composeOneoffLabel = "com.docker.compose.oneoff"
+
+// ComposeStates returns the expected states of the services of the compose files of a stack, the later files
+// override the definitions of the earlier ones. The deploy labels are only applied to the Swarm services.
+func ComposeStates(contents [][]byte, env []portainer.Pair, swarmMode bool) ([]State, error) {
+	states := map[string]*State{}
+
+	for _, content := range contents {
+		services, err := stackutils.ParseComposeServices(content, env)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, service := range services {
+			state, ok := states[name]
+			if !ok {
+				replicas := 1
+				state = &State{
+					Name:     name,
+					Env:      map[string]string{},
+					Replicas: &replicas,
+					Ports:    []string{},
+					Labels:   map[string]string{},
+				}
+				states[name] = state
+			}
+
+			err := mergeComposeService(state, service, swarmMode)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "invalid service %s", name)
+			}
+		}
+	}
+
+	return sortedStates(states), nil
+}
+
+func mergeComposeService(state *State, service map[string]interface{}, swarmMode bool) error {
+	deploy, _ := service["deploy"].(map[string]interface{})
+
+	if image, ok := service["image"].(string); ok {
+		state.Image = image
+	}
+
+	for key, value := range composeEnvironment(service["environment"]) {
+		state.Env[key] = value
+	}
+
+	labels := []interface{}{service["labels"]}
+	if swarmMode {
+		labels = append(labels, deploy["labels"])
+	}
+
+	for _, mapping := range labels {
+		for key, value := range stackutils.ComposeMapping(mapping) {
+			state.Labels[key] = value
+		}
+	}
+
+	if ports, ok := service["ports"].([]interface{}); ok {
+		publishedPorts, err := composePorts(ports)
+		if err != nil {
+			return err
+		}
+
+		state.Ports = publishedPorts
+	}
+
+	if replicas, ok := composeReplicas(service["scale"]); ok && !swarmMode {
+		state.Replicas = &replicas
+	}
+
+	if replicas, ok := composeReplicas(deploy["replicas"]); ok {
+		state.Replicas = &replicas
+	}
+
+	if swarmMode && deploy["mode"] == "global" {
+		state.Replicas = nil
+	}
+
+	return nil
+}
+
+// composeEnvironment returns the environment variables of a service which are defined by value, the variables
+// taken from the environment of the host are skipped
+func composeEnvironment(value interface{}) map[string]string {
+	env := map[string]string{}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, v := range value {
+			if v != nil {
+				env[key] = fmt.Sprint(v)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			key, v, ok := strings.Cut(fmt.Sprint(item), "=")
+			if ok {
+				env[key] = v
+			}
+		}
+	}
+
+	return env
+}
+
+// composePorts returns the published ports of a service, formatted as published:target/protocol.
+// The ports published on a random or on a range of host ports are skipped.
+func composePorts(ports []interface{}) ([]string, error) {
+	published := []string{}
+
+	for _, port := range ports {
+		switch port := port.(type) {
+		case map[string]interface{}:
+			if port["published"] == nil || port["target"] == nil {
+				continue
+			}
+
+			protocol, _ := port["protocol"].(string)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+
+			published = append(published, formatPort(fmt.Sprint(port["published"]), fmt.Sprint(port["target"]), protocol))
+		default:
+			mappings, err := nat.ParsePortSpec(fmt.Sprint(port))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, mapping := range mappings {
+				if mapping.Binding.HostPort == "" || strings.Contains(mapping.Binding.HostPort, "-") {
+					continue
+				}
+
+				published = append(published, formatPort(mapping.Binding.HostPort, mapping.Port.Port(), mapping.Port.Proto()))
+			}
+		}
+	}
+
+	return published, nil
+}
+
+func composeReplicas(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case float64:
+		return int(value), true
+	case string:
+		var replicas int
+		_, err := fmt.Sscan(value, &replicas)
+		return replicas, err == nil
+	}
+
+	return 0, false
+}
+
+// ComposeLiveStates returns the live states of the services of a compose project from the containers
+// of a snapshot. The replicas are the number of running containers of each service.
+func ComposeLiveStates(projectName string, containers []portainer.DockerContainerSnapshot) []State {
+	states := map[string]*State{}
+
+	for _, container := range containers {
+		// the one-off containers are started by docker compose run
+		if container.Labels[docker.ComposeStackNameLabel] != projectName || container.Labels[composeOneoffLabel] == "True" {
+			continue
+		}
+
+		name := container.Labels[docker.ComposeServiceLabel]
+
+		state, ok := states[name]
+		if !ok {
+			replicas := 0
+			state = &State{Name: name, Replicas: &replicas, Ports: []string{}}
+			states[name] = state
+		}
+
+		running := container.State == "running"
+		if running {
+			*state.Replicas++
+		}
+
+		// the running containers describe the service better than the stopped ones
+		if state.Labels == nil || (running && *state.Replicas == 1) {
+			state.Image = container.Image
+			state.Labels = container.Labels
+			state.Env = nil
+
+			// the values of the variables are hashed in the snapshots
+			if container.Env != nil {
+				state.Env = composeEnvironment(toInterfaces(container.Env))
+			}
+		}
+
+		for _, port := range container.Ports {
+			if port.PublicPort != 0 {
+				state.Ports = append(state.Ports, formatPort(fmt.Sprint(port.PublicPort), fmt.Sprint(port.PrivatePort), port.Type))
+			}
+		}
+	}
+
+	return sortedStates(states)
+}
+
+// SwarmLiveStates returns the live states of the services of a Swarm stack
+func SwarmLiveStates(stackName string, services []swarm.Service) []State {
+	states := map[string]*State{}
+
+	for _, service := range services {
+		if service.Spec.Labels[docker.SwarmStackNameLabel] != stackName {
+			continue
+		}
+
+		name := strings.TrimPrefix(service.Spec.Name, stackName+"_")
+
+		state := &State{
+			Name:   name,
+			Ports:  []string{},
+			Env:    map[string]string{},
+			Labels: map[string]string{},
+		}
+
+		if service.Spec.Mode.Replicated != nil && service.Spec.Mode.Replicated.Replicas != nil {
+			replicas := int(*service.Spec.Mode.Replicated.Replicas)
+			state.Replicas = &replicas
+		}
+
+		for key, value := range service.Spec.Labels {
+			state.Labels[key] = value
+		}
+
+		if containerSpec := service.Spec.TaskTemplate.ContainerSpec; containerSpec != nil {
+			state.Image = containerSpec.Image
+			// the values of the variables are hashed in the snapshots
+			state.Env = composeEnvironment(toInterfaces(containerSpec.Env))
+
+			for key, value := range containerSpec.Labels {
+				state.Labels[key] = value
+			}
+		}
+
+		if service.Spec.EndpointSpec != nil {
+			for _, port := range service.Spec.EndpointSpec.Ports {
+				if port.PublishedPort != 0 {
+					state.Ports = append(state.Ports, formatPort(fmt.Sprint(port.PublishedPort), fmt.Sprint(port.TargetPort), string(port.Protocol)))
+				}
+			}
+		}
+
+		states[name] = state
+	}
+
+	return sortedStates(states)
+}
+
+func formatPort(published, target, protocol string) string {
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	return fmt.Sprintf("%s:%s/%s", published, target, strings.ToLower(protocol))
+}
+
+func toInterfaces(values []string) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, value := range values {
+		items[i] = value
+	}
+
+	return items
+}
+
+func sortedStates(states map[string]*State) []State {
+	sorted := make([]State, 0, len(states))
+	for _, state := range states {
+		sorted = append(sorted, *state)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
@@ -0,0 +1,309 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+)
+
+const composeFile = `
+version: "3.8"
+services:
+  web:
+    image: nginx:${NGINX_TAG:-1.23}
+    environment:
+      MODE: production
+      HOST_VAR:
+    labels:
+      - team=web
+    ports:
+      - "8080:80"
+      - "127.0.0.1:5353:53/udp"
+      - "9000"
+    deploy:
+      replicas: 2
+      labels:
+        tier: front
+  worker:
+    image: worker
+    environment:
+      - QUEUE=jobs
+      - HOST_VAR
+    deploy:
+      mode: global
+`
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func Test_ComposeStates(t *testing.T) {
+	is := assert.New(t)
+
+	override := []byte(`
+services:
+  web:
+    environment:
+      DEBUG: "true"
+    ports:
+      - target: 80
+        published: 8081
+`)
+
+	states, err := ComposeStates([][]byte{[]byte(composeFile), override}, []portainer.Pair{{Name: "NGINX_TAG", Value: "1.24"}}, false)
+	is.NoError(err)
+	is.Equal([]State{
+		{
+			Name:     "web",
+			Image:    "nginx:1.24",
+			Env:      map[string]string{"MODE": "production", "DEBUG": "true"},
+			Replicas: intPtr(2),
+			Ports:    []string{"8081:80/tcp"},
+			Labels:   map[string]string{"team": "web"},
+		},
+		{
+			Name:     "worker",
+			Image:    "worker",
+			Env:      map[string]string{"QUEUE": "jobs"},
+			Replicas: intPtr(1),
+			Ports:    []string{},
+			Labels:   map[string]string{},
+		},
+	}, states)
+
+	states, err = ComposeStates([][]byte{[]byte(composeFile)}, nil, true)
+	is.NoError(err)
+	is.Equal([]string{"8080:80/tcp", "5353:53/udp"}, states[0].Ports)
+	is.Equal(map[string]string{"team": "web", "tier": "front"}, states[0].Labels)
+	is.Nil(states[1].Replicas)
+
+	_, err = ComposeStates([][]byte{[]byte("services: [")}, nil, false)
+	is.Error(err)
+}
+
+func Test_Compare(t *testing.T) {
+	is := assert.New(t)
+
+	expected, err := ComposeStates([][]byte{[]byte(composeFile)}, nil, false)
+	is.NoError(err)
+
+	container := func(service, image, state string, env []string, labels map[string]string, ports ...types.Port) portainer.DockerContainerSnapshot {
+		labels["com.docker.compose.project"] = "app"
+		labels["com.docker.compose.service"] = service
+
+		return portainer.DockerContainerSnapshot{
+			Container: types.Container{Image: image, State: state, Labels: labels, Ports: ports},
+			Env:       docker.HashEnv(env),
+		}
+	}
+
+	live := ComposeLiveStates("app", []portainer.DockerContainerSnapshot{
+		container("web", "nginx:1.24", "running", []string{"MODE=debug", "PATH=/bin"}, map[string]string{"team": "web"},
+			types.Port{PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+			types.Port{PrivatePort: 80, PublicPort: 8080, Type: "tcp"},
+			types.Port{PrivatePort: 53, PublicPort: 5353, Type: "udp"},
+		),
+		container("web", "nginx:1.24", "exited", nil, map[string]string{"team": "web"}),
+		container("cache", "redis", "running", nil, map[string]string{}),
+		container("web", "nginx", "running", nil, map[string]string{"com.docker.compose.oneoff": "True"}),
+		{Container: types.Container{Image: "other", State: "running", Labels: map[string]string{"com.docker.compose.project": "other"}}},
+	})
+
+	is.Equal([]portainer.StackDriftDifference{
+		{Resource: "web", Field: "image", Expected: "nginx:1.23", Actual: "nginx:1.24"},
+		{Resource: "web", Field: "replicas", Expected: "2", Actual: "1"},
+		{Resource: "web", Field: "env.MODE", Expected: docker.HashEnvValue("production"), Actual: docker.HashEnvValue("debug")},
+		{Resource: "worker", Field: "status", Expected: "running", Actual: "missing"},
+		{Resource: "cache", Field: "status", Expected: "absent", Actual: "running"},
+	}, Compare(expected, live))
+}
+
+func Test_SwarmLiveStates(t *testing.T) {
+	is := assert.New(t)
+
+	expected, err := ComposeStates([][]byte{[]byte(composeFile)}, nil, true)
+	is.NoError(err)
+
+	replicas := uint64(2)
+
+	services := []swarm.Service{
+		{Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: "app_web", Labels: map[string]string{"com.docker.stack.namespace": "app", "tier": "back"}},
+			TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{
+				Image:  "nginx:1.23@sha256:2cf2c3bb4ba5e4a5c56b5b4e4d3fc5fb5b0b1c2b3e4d5f6a7b8c9d0e1f2a3b4c",
+				Env:    docker.HashEnv([]string{"MODE=production"}),
+				Labels: map[string]string{"team": "web"},
+			}},
+			Mode: swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+			EndpointSpec: &swarm.EndpointSpec{Ports: []swarm.PortConfig{
+				{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 80, PublishedPort: 8080},
+			}},
+		}},
+		{Spec: swarm.ServiceSpec{
+			Annotations:  swarm.Annotations{Name: "app_worker", Labels: map[string]string{"com.docker.stack.namespace": "app"}},
+			TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "worker:latest", Env: docker.HashEnv([]string{"QUEUE=jobs"})}},
+			Mode:         swarm.ServiceMode{Global: &swarm.GlobalService{}},
+		}},
+		{Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: "other_web", Labels: map[string]string{"com.docker.stack.namespace": "other"}},
+		}},
+	}
+
+	is.Equal([]portainer.StackDriftDifference{
+		{Resource: "web", Field: "ports", Expected: "5353:53/udp, 8080:80/tcp", Actual: "8080:80/tcp"},
+		{Resource: "web", Field: "labels.tier", Expected: "front", Actual: "back"},
+	}, Compare(expected, SwarmLiveStates("app", services)))
+}
+
+func Test_imagesMatch(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(imagesMatch("nginx", "docker.io/library/nginx:latest"))
+	is.True(imagesMatch("nginx:1.23", "nginx:1.23@sha256:2cf2c3bb4ba5e4a5c56b5b4e4d3fc5fb5b0b1c2b3e4d5f6a7b8c9d0e1f2a3b4c"))
+	is.False(imagesMatch("nginx:1.23", "nginx:1.24"))
+	is.False(imagesMatch("nginx", "bitnami/nginx"))
+	is.False(imagesMatch("nginx@sha256:2cf2c3bb4ba5e4a5c56b5b4e4d3fc5fb5b0b1c2b3e4d5f6a7b8c9d0e1f2a3b4c", "nginx:1.23"))
+	is.False(imagesMatch("nginx:1.23", "sha256:2cf2c3bb4ba5e4a5c56b5b4e4d3fc5fb5b0b1c2b3e4d5f6a7b8c9d0e1f2a3b4c"))
+}
+
+func Test_KubernetesWorkloads(t *testing.T) {
+	is := assert.New(t)
+
+	manifest := []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.23
+          env:
+            - name: MODE
+              value: production
+            - name: PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: web
+                  key: password
+          ports:
+            - containerPort: 80
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+  namespace: monitoring
+spec:
+  template:
+    spec:
+      containers:
+        - name: agent
+          image: agent
+`)
+
+	workloads, err := KubernetesWorkloads([][]byte{manifest}, "prod")
+	is.NoError(err)
+	is.Len(workloads, 2)
+	is.Equal("prod", workloads[0].Namespace)
+	is.Equal("monitoring", workloads[1].Namespace)
+
+	replicas := int32(2)
+
+	lookup := func(namespace, kind, name string) (*portainer.K8sWorkload, error) {
+		if kind != "Deployment" {
+			return nil, nil
+		}
+
+		return &portainer.K8sWorkload{
+			Kind:      kind,
+			Name:      name,
+			Namespace: namespace,
+			Replicas:  &replicas,
+			Labels:    map[string]string{"app": "web", "io.portainer.kubernetes.application.stack": "web"},
+			Containers: []portainer.K8sWorkloadContainer{
+				{Name: "nginx", Image: "nginx:1.23", Env: map[string]string{"MODE": "debug"}, Ports: []string{"80/TCP"}},
+				{Name: "sidecar", Image: "envoy", Env: map[string]string{}, Ports: []string{}},
+			},
+		}, nil
+	}
+
+	differences, err := CompareKubernetesWorkloads(workloads, lookup)
+	is.NoError(err)
+	is.Equal([]portainer.StackDriftDifference{
+		{Resource: "Deployment/web", Field: "replicas", Expected: "3", Actual: "2"},
+		{Resource: "Deployment/web container nginx", Field: "env.MODE", Expected: docker.HashEnvValue("production"), Actual: docker.HashEnvValue("debug")},
+		{Resource: "Deployment/web container sidecar", Field: "status", Expected: "absent", Actual: "running"},
+		{Resource: "DaemonSet/agent", Field: "status", Expected: "running", Actual: "missing"},
+	}, differences)
+}
+
+func Test_CheckStack(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	defer teardown()
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	projectPath := t.TempDir()
+	is.NoError(os.WriteFile(filepath.Join(projectPath, "docker-compose.yml"), []byte(composeFile), 0644))
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}))
+
+	stack := &portainer.Stack{ID: 1, Name: "app", Type: portainer.DockerComposeStack, EndpointID: 1, EntryPoint: "docker-compose.yml", ProjectPath: projectPath, Status: portainer.StackStatusActive}
+	is.NoError(store.Stack().Create(stack))
+
+	service := NewService(store, fileService, nil, nil)
+
+	report, err := service.CheckStack(stack)
+	is.NoError(err)
+	is.False(report.Drifted)
+	is.Equal("no Docker snapshot available for the environment", report.Error)
+
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{}}))
+
+	is.NoError(service.CheckStacks())
+
+	report, err = store.StackDrift().StackDriftReport(stack.ID)
+	is.NoError(err)
+	is.True(report.Drifted)
+	is.Empty(report.Error)
+	is.Len(report.Differences, 2)
+
+	is.NoError(store.Stack().DeleteStack(stack.ID))
+	is.NoError(service.CheckStacks())
+
+	_, err = store.StackDrift().StackDriftReport(stack.ID)
+	is.True(store.IsErrObjectNotFound(err))
+}
+
+func Test_ValidateInterval(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateInterval(""))
+	is.NoError(ValidateInterval("1h"))
+	is.Error(ValidateInterval("30s"))
+	is.Error(ValidateInterval("daily"))
+}
@@ -0,0 +1,191 @@
+package drift
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Workload is a Kubernetes workload defined by the manifests of a stack. Its first state describes the workload
+// and the following ones describe its containers.
+type Workload struct {
+	Kind      string
+	Name      string
+	Namespace string
+	States    []State
+}
+
+type manifestContainer struct {
+	Name  string `yaml:"name"`
+	Image string `yaml:"image"`
+	Env   []struct {
+		Name      string      `yaml:"name"`
+		Value     string      `yaml:"value"`
+		ValueFrom interface{} `yaml:"valueFrom"`
+	} `yaml:"env"`
+	Ports []struct {
+		ContainerPort int    `yaml:"containerPort"`
+		Protocol      string `yaml:"protocol"`
+	} `yaml:"ports"`
+}
+
+type manifestObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Replicas *int `yaml:"replicas"`
+		Template struct {
+			Metadata struct {
+				Labels map[string]string `yaml:"labels"`
+			} `yaml:"metadata"`
+			Spec struct {
+				Containers []manifestContainer `yaml:"containers"`
+			} `yaml:"spec"`
+		} `yaml:"template"`
+	} `yaml:"spec"`
+}
+
+// KubernetesWorkloads returns the Deployments, the StatefulSets and the DaemonSets defined by the manifests.
+// The workloads without namespace are deployed in the namespace of the stack. The replicas are only compared
+// when they are defined by the manifests, as they can be managed by an autoscaler otherwise.
+func KubernetesWorkloads(contents [][]byte, namespace string) ([]Workload, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	workloads := []Workload{}
+
+	for _, content := range contents {
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+		for {
+			var object manifestObject
+
+			err := decoder.Decode(&object)
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, errors.Wrap(err, "failed to parse the manifest")
+			}
+
+			if object.Kind != "Deployment" && object.Kind != "StatefulSet" && object.Kind != "DaemonSet" {
+				continue
+			}
+
+			workload := Workload{
+				Kind:      object.Kind,
+				Name:      object.Metadata.Name,
+				Namespace: object.Metadata.Namespace,
+			}
+
+			if workload.Namespace == "" {
+				workload.Namespace = namespace
+			}
+
+			state := State{
+				Name:   workloadResource(object.Kind, object.Metadata.Name),
+				Labels: object.Spec.Template.Metadata.Labels,
+			}
+
+			if object.Kind != "DaemonSet" {
+				state.Replicas = object.Spec.Replicas
+			}
+
+			workload.States = append(workload.States, state)
+
+			for _, container := range object.Spec.Template.Spec.Containers {
+				state := State{
+					Name:  containerResource(object.Kind, object.Metadata.Name, container.Name),
+					Image: container.Image,
+					Env:   map[string]string{},
+					Ports: []string{},
+				}
+
+				for _, env := range container.Env {
+					if env.ValueFrom == nil {
+						state.Env[env.Name] = env.Value
+					}
+				}
+
+				for _, port := range container.Ports {
+					protocol := port.Protocol
+					if protocol == "" {
+						protocol = "TCP"
+					}
+
+					state.Ports = append(state.Ports, fmt.Sprintf("%d/%s", port.ContainerPort, protocol))
+				}
+
+				workload.States = append(workload.States, state)
+			}
+
+			workloads = append(workloads, workload)
+		}
+	}
+
+	return workloads, nil
+}
+
+// WorkloadStates returns the live states of a Kubernetes workload, in the order of KubernetesWorkloads
+func WorkloadStates(workload *portainer.K8sWorkload) []State {
+	state := State{
+		Name:   workloadResource(workload.Kind, workload.Name),
+		Labels: workload.Labels,
+	}
+
+	if workload.Replicas != nil {
+		replicas := int(*workload.Replicas)
+		state.Replicas = &replicas
+	}
+
+	states := []State{state}
+
+	for _, container := range workload.Containers {
+		states = append(states, State{
+			Name:  containerResource(workload.Kind, workload.Name, container.Name),
+			Image: container.Image,
+			Env:   hashEnv(container.Env),
+			Ports: container.Ports,
+		})
+	}
+
+	return states
+}
+
+// CompareKubernetesWorkloads returns the differences between the workloads and the live ones, a missing
+// workload is reported once rather than once per container
+func CompareKubernetesWorkloads(workloads []Workload, getWorkload func(namespace, kind, name string) (*portainer.K8sWorkload, error)) ([]portainer.StackDriftDifference, error) {
+	differences := []portainer.StackDriftDifference{}
+
+	for _, workload := range workloads {
+		live, err := getWorkload(workload.Namespace, workload.Kind, workload.Name)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to retrieve the %s %s", workload.Kind, workload.Name)
+		}
+
+		if live == nil {
+			differences = append(differences, Compare(workload.States[:1], nil)...)
+			continue
+		}
+
+		differences = append(differences, Compare(workload.States, WorkloadStates(live))...)
+	}
+
+	return differences, nil
+}
+
+func workloadResource(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+func containerResource(kind, name, container string) string {
+	return fmt.Sprintf("%s/%s container %s", kind, name, container)
+}
@@ -0,0 +1,249 @@
+package drift
+
+import (
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Service periodically compares the files of the active stacks with their running services
+// and stores a drift report per stack.
+type Service struct {
+	dataStore               dataservices.DataStore
+	fileService             portainer.FileService
+	scheduler               *scheduler.Scheduler
+	kubernetesClientFactory *cli.ClientFactory
+
+	mu    sync.Mutex
+	jobID string
+}
+
+// NewService returns a new instance of Service
+func NewService(dataStore dataservices.DataStore, fileService portainer.FileService, scheduler *scheduler.Scheduler, kubernetesClientFactory *cli.ClientFactory) *Service {
+	return &Service{
+		dataStore:               dataStore,
+		fileService:             fileService,
+		scheduler:               scheduler,
+		kubernetesClientFactory: kubernetesClientFactory,
+	}
+}
+
+// ValidateInterval verifies the interval of the drift detection, an empty interval disables the detection
+func ValidateInterval(interval string) error {
+	if interval == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return errors.Wrap(err, "Invalid drift detection interval")
+	}
+
+	if duration < time.Minute {
+		return errors.New("Invalid drift detection interval, the minimum is 1m")
+	}
+
+	return nil
+}
+
+// Start schedules the drift detection with the saved settings
+func (service *Service) Start() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return service.SetSchedule(settings.DriftDetectionInterval)
+}
+
+// SetSchedule replaces the schedule of the drift detection
+func (service *Service) SetSchedule(interval string) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.jobID != "" {
+		err := service.scheduler.StopJob(service.jobID)
+		if err != nil {
+			return err
+		}
+
+		service.jobID = ""
+	}
+
+	if interval == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return errors.Wrap(err, "Invalid drift detection interval")
+	}
+
+	service.jobID = service.scheduler.StartJobEvery(duration, func() error {
+		err := service.CheckStacks()
+		if err != nil {
+			log.Error().Err(err).Msg("stack drift detection failed")
+		}
+
+		// a failed detection is retried on the next run
+		return nil
+	})
+
+	return nil
+}
+
+// CheckStacks checks the drift of the active stacks and removes the reports of the stacks which no longer exist
+func (service *Service) CheckStacks() error {
+	stacks, err := service.dataStore.Stack().Stacks()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the stacks")
+	}
+
+	stackIDs := make(map[portainer.StackID]bool, len(stacks))
+
+	for i := range stacks {
+		stack := &stacks[i]
+		stackIDs[stack.ID] = true
+
+		if stack.Status != portainer.StackStatusActive {
+			continue
+		}
+
+		report, err := service.CheckStack(stack)
+		if err != nil {
+			return err
+		}
+
+		if report.Drifted {
+			log.Info().Int("stack_id", int(stack.ID)).Str("stack", stack.Name).Int("differences", len(report.Differences)).Msg("stack drift detected")
+		}
+	}
+
+	reports, err := service.dataStore.StackDrift().StackDriftReports()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the stack drift reports")
+	}
+
+	for _, report := range reports {
+		if stackIDs[report.StackID] {
+			continue
+		}
+
+		err := service.dataStore.StackDrift().DeleteStackDriftReport(report.StackID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to remove the drift report of the stack %d", report.StackID)
+		}
+	}
+
+	return nil
+}
+
+// CheckStack compares the files of a stack with its running services and stores the report.
+// The reason why the comparison could not be completed is recorded in the report.
+func (service *Service) CheckStack(stack *portainer.Stack) (*portainer.StackDriftReport, error) {
+	report := &portainer.StackDriftReport{
+		StackID:     stack.ID,
+		EndpointID:  stack.EndpointID,
+		Differences: []portainer.StackDriftDifference{},
+		CheckedAt:   time.Now().Unix(),
+	}
+
+	differences, err := service.detect(stack)
+	if err != nil {
+		report.Error = err.Error()
+	} else {
+		report.Differences = differences
+		report.Drifted = len(differences) > 0
+	}
+
+	err = service.dataStore.StackDrift().UpdateStackDriftReport(report)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to save the drift report of the stack %d", stack.ID)
+	}
+
+	return report, nil
+}
+
+func (service *Service) detect(stack *portainer.Stack) ([]portainer.StackDriftDifference, error) {
+	endpoint, err := service.dataStore.Endpoint().Endpoint(stack.EndpointID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to retrieve the environment of the stack")
+	}
+
+	if stack.KubernetesBuild != nil {
+		return nil, errors.New("the drift of the kustomize and Helm stacks can't be detected")
+	}
+
+	contents := [][]byte{}
+	for _, file := range stackutils.GetStackFilePaths(stack, false) {
+		content, err := service.fileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to read the stack file %s", file)
+		}
+
+		contents = append(contents, content)
+	}
+
+	switch stack.Type {
+	case portainer.DockerComposeStack, portainer.DockerSwarmStack:
+		snapshot, err := service.dataStore.Snapshot().Snapshot(endpoint.ID)
+		if service.dataStore.IsErrObjectNotFound(err) || (err == nil && snapshot.Docker == nil) {
+			return nil, errors.New("no Docker snapshot available for the environment")
+		} else if err != nil {
+			return nil, errors.WithMessage(err, "failed to retrieve the snapshot of the environment")
+		}
+
+		swarmMode := stack.Type == portainer.DockerSwarmStack
+
+		expected, err := ComposeStates(contents, stack.Env, swarmMode)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to parse the stack files")
+		}
+
+		if !swarmMode {
+			return Compare(expected, ComposeLiveStates(stack.Name, snapshot.Docker.SnapshotRaw.Containers)), nil
+		}
+
+		if snapshot.Docker.SnapshotRaw.Services == nil {
+			return nil, errors.New("the services of the environment are not available in its snapshot")
+		}
+
+		return Compare(expected, SwarmLiveStates(stack.Name, snapshot.Docker.SnapshotRaw.Services)), nil
+
+	case portainer.KubernetesStack:
+		if stack.IsComposeFormat {
+			return nil, errors.New("the drift of the Kubernetes stacks created from a compose file is not detected")
+		}
+
+		if endpointutils.IsEdgeEndpoint(endpoint) {
+			return nil, errors.New("the drift of the stacks of the Edge environments is not detected")
+		}
+
+		if service.kubernetesClientFactory == nil {
+			return nil, errors.New("no Kubernetes client available")
+		}
+
+		workloads, err := KubernetesWorkloads(contents, stack.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		kubeClient, err := service.kubernetesClientFactory.GetKubeClient(endpoint)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to create the Kubernetes client")
+		}
+
+		return CompareKubernetesWorkloads(workloads, kubeClient.GetWorkload)
+	}
+
+	return nil, errors.Errorf("unsupported stack type %d", stack.Type)
+}
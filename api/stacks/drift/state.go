@@ -0,0 +1,196 @@
+package drift
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+
+	"github.com/docker/distribution/reference"
+)
+
+// unsetValue is reported when an environment variable or a label of the stack files is not set on the running service
+const unsetValue = "(unset)"
+
+// State is the expected or the live state of a service of a stack. The fields left empty in the expected state
+// are not compared, and only the environment variables and the labels defined by the stack files are compared.
+// The environment variables are not compared either when they are unknown in the live state. The values of the
+// environment variables of the live states are hashed with docker.HashEnvValue, so that they are neither stored
+// nor reported.
+type State struct {
+	Name     string
+	Image    string
+	Env      map[string]string
+	Replicas *int
+	Ports    []string
+	Labels   map[string]string
+}
+
+// Compare returns the differences between the expected states, defined by the stack files, and the live states
+// of the running services. The services missing on either side are reported with the status field.
+func Compare(expected, live []State) []portainer.StackDriftDifference {
+	differences := []portainer.StackDriftDifference{}
+
+	liveStates := make(map[string]State, len(live))
+	for _, state := range live {
+		liveStates[state.Name] = state
+	}
+
+	expectedNames := make(map[string]bool, len(expected))
+	for _, state := range expected {
+		expectedNames[state.Name] = true
+
+		liveState, ok := liveStates[state.Name]
+		if !ok {
+			differences = append(differences, portainer.StackDriftDifference{Resource: state.Name, Field: "status", Expected: "running", Actual: "missing"})
+			continue
+		}
+
+		differences = append(differences, compareState(state, liveState)...)
+	}
+
+	unexpected := []string{}
+	for _, state := range live {
+		if !expectedNames[state.Name] {
+			unexpected = append(unexpected, state.Name)
+		}
+	}
+	sort.Strings(unexpected)
+
+	for _, name := range unexpected {
+		differences = append(differences, portainer.StackDriftDifference{Resource: name, Field: "status", Expected: "absent", Actual: "running"})
+	}
+
+	return differences
+}
+
+func compareState(expected, live State) []portainer.StackDriftDifference {
+	differences := []portainer.StackDriftDifference{}
+
+	if expected.Image != "" && !imagesMatch(expected.Image, live.Image) {
+		differences = append(differences, portainer.StackDriftDifference{Resource: expected.Name, Field: "image", Expected: expected.Image, Actual: live.Image})
+	}
+
+	if expected.Replicas != nil && live.Replicas != nil && *expected.Replicas != *live.Replicas {
+		differences = append(differences, portainer.StackDriftDifference{
+			Resource: expected.Name,
+			Field:    "replicas",
+			Expected: strconv.Itoa(*expected.Replicas),
+			Actual:   strconv.Itoa(*live.Replicas),
+		})
+	}
+
+	if expected.Ports != nil {
+		expectedPorts, livePorts := uniqueSorted(expected.Ports), uniqueSorted(live.Ports)
+		if strings.Join(expectedPorts, ",") != strings.Join(livePorts, ",") {
+			differences = append(differences, portainer.StackDriftDifference{
+				Resource: expected.Name,
+				Field:    "ports",
+				Expected: strings.Join(expectedPorts, ", "),
+				Actual:   strings.Join(livePorts, ", "),
+			})
+		}
+	}
+
+	// the environment variables of the containers are not always recorded in the snapshots
+	if live.Env != nil {
+		differences = append(differences, compareMapping(expected.Name, "env", hashEnv(expected.Env), live.Env)...)
+	}
+
+	differences = append(differences, compareMapping(expected.Name, "labels", expected.Labels, live.Labels)...)
+
+	return differences
+}
+
+// compareMapping compares the entries of the expected mapping with the live ones, the additional live entries
+// such as the variables of the image or the labels added by the orchestrator are ignored
+func compareMapping(resource, field string, expected, live map[string]string) []portainer.StackDriftDifference {
+	differences := []portainer.StackDriftDifference{}
+
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, ok := live[key]
+		if !ok {
+			value = unsetValue
+		}
+
+		if value != expected[key] {
+			differences = append(differences, portainer.StackDriftDifference{Resource: resource, Field: field + "." + key, Expected: expected[key], Actual: value})
+		}
+	}
+
+	return differences
+}
+
+// hashEnv hashes the values of the environment variables
+func hashEnv(env map[string]string) map[string]string {
+	hashed := make(map[string]string, len(env))
+	for key, value := range env {
+		hashed[key] = docker.HashEnvValue(value)
+	}
+
+	return hashed
+}
+
+// imagesMatch returns true when the live image is the expected one. The digest added to the live image
+// by the orchestrator, e.g. when a Swarm service is pinned to the digest of its tag, is ignored unless
+// the expected image is referenced by digest.
+func imagesMatch(expected, live string) bool {
+	if expected == live {
+		return true
+	}
+
+	expectedRef, err := reference.ParseNormalizedNamed(expected)
+	if err != nil {
+		return false
+	}
+
+	liveRef, err := reference.ParseNormalizedNamed(live)
+	if err != nil {
+		return false
+	}
+
+	if expectedRef.Name() != liveRef.Name() {
+		return false
+	}
+
+	if expectedDigested, ok := expectedRef.(reference.Digested); ok {
+		liveDigested, ok := liveRef.(reference.Digested)
+		return ok && liveDigested.Digest() == expectedDigested.Digest()
+	}
+
+	expectedTagged, _ := reference.TagNameOnly(expectedRef).(reference.Tagged)
+
+	liveTagged, ok := liveRef.(reference.Tagged)
+	if !ok {
+		if _, digested := liveRef.(reference.Digested); digested {
+			return false
+		}
+
+		liveTagged, _ = reference.TagNameOnly(liveRef).(reference.Tagged)
+	}
+
+	return expectedTagged != nil && liveTagged != nil && expectedTagged.Tag() == liveTagged.Tag()
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := []string{}
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+
+	return unique
+}
@@ -0,0 +1,121 @@
+package preview
+
+import (
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/pkg/errors"
+)
+
+// the keys of the compose services which can be defined either as a mapping or as a list of key=value
+var composeMappingKeys = []string{"environment", "labels", "build.args"}
+
+// ComposeChanges returns the services created, changed or removed when the deployed compose files of a stack are
+// replaced by the proposed ones. The changed services are recreated by compose and updated by Swarm.
+func ComposeChanges(current, proposed [][]byte, currentEnv, proposedEnv []portainer.Pair, swarmMode bool) ([]portainer.StackPreviewChange, error) {
+	currentServices, err := composeServices(current, currentEnv)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the deployed stack files")
+	}
+
+	proposedServices, err := composeServices(proposed, proposedEnv)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the proposed stack files")
+	}
+
+	changedAction := portainer.StackPreviewRecreate
+	if swarmMode {
+		changedAction = portainer.StackPreviewUpdate
+	}
+
+	names := make([]string, 0, len(currentServices)+len(proposedServices))
+	for name := range currentServices {
+		names = append(names, name)
+	}
+	for name := range proposedServices {
+		if _, ok := currentServices[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []portainer.StackPreviewChange{}
+	for _, name := range names {
+		currentService, deployed := currentServices[name]
+		proposedService, defined := proposedServices[name]
+
+		switch {
+		case !deployed:
+			changes = append(changes, portainer.StackPreviewChange{Resource: name, Action: portainer.StackPreviewCreate})
+		case !defined:
+			changes = append(changes, portainer.StackPreviewChange{Resource: name, Action: portainer.StackPreviewRemove})
+		default:
+			fields := fieldChanges(currentService, proposedService)
+			if len(fields) > 0 {
+				changes = append(changes, portainer.StackPreviewChange{Resource: name, Action: changedAction, Fields: fields})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// composeServices returns the definitions of the services of the compose files, the later files override the
+// keys of the services defined by the earlier ones
+func composeServices(contents [][]byte, env []portainer.Pair) (map[string]map[string]interface{}, error) {
+	services := map[string]map[string]interface{}{}
+
+	for _, content := range contents {
+		fileServices, err := stackutils.ParseComposeServices(content, env)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, fileService := range fileServices {
+			service, ok := services[name]
+			if !ok {
+				service = map[string]interface{}{}
+				services[name] = service
+			}
+
+			for key, value := range fileService {
+				service[key] = value
+			}
+		}
+	}
+
+	for _, service := range services {
+		normalizeComposeMappings(service)
+	}
+
+	return services, nil
+}
+
+// normalizeComposeMappings converts the lists of key=value to mappings, so that both forms are compared by entry
+func normalizeComposeMappings(service map[string]interface{}) {
+	for _, key := range composeMappingKeys {
+		parent := service
+		if key == "build.args" {
+			build, ok := service["build"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			parent, key = build, "args"
+		}
+
+		value, ok := parent[key]
+		if !ok {
+			continue
+		}
+
+		mapping := map[string]interface{}{}
+		for k, v := range stackutils.ComposeMapping(value) {
+			mapping[k] = v
+		}
+
+		parent[key] = mapping
+	}
+}
@@ -0,0 +1,80 @@
+package preview
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// unsetValue is reported for a field which is only defined on one side of the change
+const unsetValue = "(unset)"
+
+// fieldChanges returns the changes between the leaves of two decoded YAML or JSON documents, the fields are
+// identified by their path, e.g. spec.template.spec.containers[0].image
+func fieldChanges(current, proposed interface{}) []portainer.StackPreviewFieldChange {
+	currentFields, proposedFields := map[string]string{}, map[string]string{}
+	flatten("", current, currentFields)
+	flatten("", proposed, proposedFields)
+
+	paths := make([]string, 0, len(currentFields)+len(proposedFields))
+	for path := range currentFields {
+		paths = append(paths, path)
+	}
+	for path := range proposedFields {
+		if _, ok := currentFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []portainer.StackPreviewFieldChange{}
+	for _, path := range paths {
+		currentValue, ok := currentFields[path]
+		if !ok {
+			currentValue = unsetValue
+		}
+
+		proposedValue, ok := proposedFields[path]
+		if !ok {
+			proposedValue = unsetValue
+		}
+
+		if currentValue != proposedValue {
+			changes = append(changes, portainer.StackPreviewFieldChange{Field: path, Current: currentValue, Proposed: proposedValue})
+		}
+	}
+
+	return changes
+}
+
+func flatten(path string, value interface{}, fields map[string]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, v := range value {
+			if path == "" {
+				flatten(key, v, fields)
+				continue
+			}
+
+			flatten(path+"."+key, v, fields)
+		}
+	case []interface{}:
+		for i, v := range value {
+			flatten(fmt.Sprintf("%s[%d]", path, i), v, fields)
+		}
+	case nil:
+		fields[path] = ""
+	case string:
+		fields[path] = value
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			fields[path] = fmt.Sprint(value)
+			return
+		}
+
+		fields[path] = string(encoded)
+	}
+}
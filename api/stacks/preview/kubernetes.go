@@ -0,0 +1,222 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+// the fields set by the API server which do not describe the objects
+var (
+	kubernetesServerFields         = []string{"status"}
+	kubernetesServerMetadataFields = []string{"creationTimestamp", "generation", "managedFields", "resourceVersion", "selfLink", "uid"}
+	kubernetesServerAnnotations    = []string{
+		"kubectl.kubernetes.io/last-applied-configuration",
+		"deployment.kubernetes.io/revision",
+		"deployment.kubernetes.io/desired-replicas",
+		"deployment.kubernetes.io/max-replicas",
+		"deprecated.daemonset.template.generation",
+		"endpoints.kubernetes.io/last-change-trigger-time",
+	}
+)
+
+// the fields defaulted by the API server, which are only compared when they are set on both sides
+var kubernetesDefaultedFields = map[string]bool{
+	"clusterIP":                     true,
+	"clusterIPs":                    true,
+	"dnsPolicy":                     true,
+	"imagePullPolicy":               true,
+	"internalTrafficPolicy":         true,
+	"ipFamilies":                    true,
+	"ipFamilyPolicy":                true,
+	"progressDeadlineSeconds":       true,
+	"protocol":                      true,
+	"restartPolicy":                 true,
+	"revisionHistoryLimit":          true,
+	"schedulerName":                 true,
+	"securityContext":               true,
+	"sessionAffinity":               true,
+	"strategy":                      true,
+	"targetPort":                    true,
+	"terminationGracePeriodSeconds": true,
+	"terminationMessagePath":        true,
+	"terminationMessagePolicy":      true,
+}
+
+// the values of the secrets are not reported
+const redactedValue = "(redacted)"
+
+var fieldIndexes = regexp.MustCompile(`\[\d+\]`)
+
+type kubernetesObject map[string]interface{}
+
+// KubernetesChanges returns the objects created, updated or removed by a stack, from the objects deployed by the
+// current manifests, the deployed objects of the new manifests and the objects returned by a server-side dry-run
+// of the new manifests, all as JSON objects or lists
+func KubernetesChanges(deployed, live, applied []byte) ([]portainer.StackPreviewChange, error) {
+	deployedObjects, err := kubernetesObjects(deployed)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the objects of the current manifests")
+	}
+
+	liveObjects, err := kubernetesObjects(live)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the deployed objects")
+	}
+
+	appliedObjects, err := kubernetesObjects(applied)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the dry-run objects")
+	}
+
+	resources := make([]string, 0, len(appliedObjects)+len(deployedObjects))
+	for resource := range appliedObjects {
+		resources = append(resources, resource)
+	}
+	for resource := range deployedObjects {
+		if _, ok := appliedObjects[resource]; !ok {
+			resources = append(resources, resource)
+		}
+	}
+	sort.Strings(resources)
+
+	changes := []portainer.StackPreviewChange{}
+	for _, resource := range resources {
+		appliedObject, defined := appliedObjects[resource]
+		if !defined {
+			changes = append(changes, portainer.StackPreviewChange{Resource: resource, Action: portainer.StackPreviewRemove})
+			continue
+		}
+
+		liveObject, ok := liveObjects[resource]
+		if !ok {
+			changes = append(changes, portainer.StackPreviewChange{Resource: resource, Action: portainer.StackPreviewCreate})
+			continue
+		}
+
+		fields := kubernetesFieldChanges(liveObject, appliedObject)
+		if len(fields) > 0 {
+			changes = append(changes, portainer.StackPreviewChange{Resource: resource, Action: portainer.StackPreviewUpdate, Fields: fields})
+		}
+	}
+
+	return changes, nil
+}
+
+// kubernetesFieldChanges returns the changed fields of an object, without the fields only defaulted on one side
+// and without the values of the secrets
+func kubernetesFieldChanges(live, applied kubernetesObject) []portainer.StackPreviewFieldChange {
+	isSecret := applied["kind"] == "Secret"
+
+	changes := []portainer.StackPreviewFieldChange{}
+	for _, change := range fieldChanges(map[string]interface{}(live), map[string]interface{}(applied)) {
+		if (change.Current == unsetValue || change.Proposed == unsetValue) && isDefaultedField(change.Field) {
+			continue
+		}
+
+		if isSecret && (strings.HasPrefix(change.Field, "data.") || strings.HasPrefix(change.Field, "stringData.")) {
+			change.Current = redact(change.Current)
+			change.Proposed = redact(change.Proposed)
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// isDefaultedField returns true when a field, or one of its parents, is defaulted by the API server
+func isDefaultedField(path string) bool {
+	for _, field := range strings.Split(fieldIndexes.ReplaceAllString(path, ""), ".") {
+		if kubernetesDefaultedFields[field] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redact(value string) string {
+	if value == unsetValue {
+		return value
+	}
+
+	return redactedValue
+}
+
+// kubernetesObjects returns the objects of a JSON object or list, indexed by kind, namespace and name,
+// without the fields set by the API server
+func kubernetesObjects(content []byte) (map[string]kubernetesObject, error) {
+	objects := map[string]kubernetesObject{}
+
+	// kubectl prints one document per object or list
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for decoder.More() {
+		var object kubernetesObject
+		if err := decoder.Decode(&object); err != nil {
+			return nil, err
+		}
+
+		items, isList := object["items"].([]interface{})
+		if !isList {
+			items = []interface{}{map[string]interface{}(object)}
+		}
+
+		for _, item := range items {
+			itemObject, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			cleanKubernetesObject(itemObject)
+			objects[kubernetesResource(itemObject)] = itemObject
+		}
+	}
+
+	return objects, nil
+}
+
+func kubernetesResource(object map[string]interface{}) string {
+	kind, _ := object["kind"].(string)
+	metadata, _ := object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	if namespace == "" {
+		return fmt.Sprintf("%s/%s", kind, name)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+func cleanKubernetesObject(object map[string]interface{}) {
+	for _, field := range kubernetesServerFields {
+		delete(object, field)
+	}
+
+	metadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for _, field := range kubernetesServerMetadataFields {
+		delete(metadata, field)
+	}
+
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		for _, annotation := range kubernetesServerAnnotations {
+			delete(annotations, annotation)
+		}
+
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
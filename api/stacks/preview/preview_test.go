@@ -0,0 +1,223 @@
+package preview
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ComposeChanges(t *testing.T) {
+	is := assert.New(t)
+
+	current := []byte(`version: "3"
+services:
+  web:
+    image: nginx:${TAG}
+    environment:
+      - MODE=production
+      - DEBUG=false
+    volumes:
+      - data:/usr/share/nginx/html
+  cache:
+    image: redis:7
+  worker:
+    image: worker:1
+`)
+
+	proposed := []byte(`version: "3"
+services:
+  web:
+    image: nginx:${TAG}
+    environment:
+      MODE: production
+      DEBUG: "true"
+    volumes:
+      - static:/usr/share/nginx/html
+  cache:
+    image: redis:7
+  queue:
+    image: rabbitmq:3
+`)
+
+	override := []byte(`version: "3"
+services:
+  worker:
+    image: worker:2
+`)
+
+	changes, err := ComposeChanges([][]byte{current}, [][]byte{proposed}, []portainer.Pair{{Name: "TAG", Value: "1.23"}}, []portainer.Pair{{Name: "TAG", Value: "1.24"}}, false)
+	is.NoError(err)
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "queue", Action: portainer.StackPreviewCreate},
+		{Resource: "web", Action: portainer.StackPreviewRecreate, Fields: []portainer.StackPreviewFieldChange{
+			{Field: "environment.DEBUG", Current: "false", Proposed: "true"},
+			{Field: "image", Current: "nginx:1.23", Proposed: "nginx:1.24"},
+			{Field: "volumes[0]", Current: "data:/usr/share/nginx/html", Proposed: "static:/usr/share/nginx/html"},
+		}},
+		{Resource: "worker", Action: portainer.StackPreviewRemove},
+	}, changes)
+
+	// the later files override the services of the earlier ones
+	changes, err = ComposeChanges([][]byte{current}, [][]byte{current, override}, []portainer.Pair{{Name: "TAG", Value: "1.23"}}, []portainer.Pair{{Name: "TAG", Value: "1.23"}}, true)
+	is.NoError(err)
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "worker", Action: portainer.StackPreviewUpdate, Fields: []portainer.StackPreviewFieldChange{
+			{Field: "image", Current: "worker:1", Proposed: "worker:2"},
+		}},
+	}, changes)
+
+	_, err = ComposeChanges([][]byte{current}, [][]byte{[]byte("services: [")}, nil, nil, false)
+	is.Error(err)
+}
+
+func Test_KubernetesChanges(t *testing.T) {
+	is := assert.New(t)
+
+	live := []byte(`{
+		"apiVersion": "v1",
+		"kind": "List",
+		"items": [
+			{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {
+					"name": "web",
+					"namespace": "default",
+					"resourceVersion": "1042",
+					"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+				},
+				"spec": {"replicas": 2, "template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.23"}]}}},
+				"status": {"readyReplicas": 2}
+			},
+			{
+				"apiVersion": "v1",
+				"kind": "ConfigMap",
+				"metadata": {"name": "settings", "namespace": "default", "uid": "1b4e28ba"},
+				"data": {"mode": "production"}
+			}
+		]
+	}`)
+
+	applied := []byte(`{
+		"apiVersion": "v1",
+		"kind": "List",
+		"items": [
+			{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "web", "namespace": "default", "resourceVersion": "1043"},
+				"spec": {"replicas": 3, "template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.24"}]}}},
+				"status": {"readyReplicas": 2}
+			},
+			{
+				"apiVersion": "v1",
+				"kind": "ConfigMap",
+				"metadata": {"name": "settings", "namespace": "default", "uid": "1b4e28ba"},
+				"data": {"mode": "production"}
+			},
+			{
+				"apiVersion": "v1",
+				"kind": "Namespace",
+				"metadata": {"name": "monitoring"}
+			}
+		]
+	}`)
+
+	changes, err := KubernetesChanges(nil, live, applied)
+	is.NoError(err)
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "Deployment/default/web", Action: portainer.StackPreviewUpdate, Fields: []portainer.StackPreviewFieldChange{
+			{Field: "spec.replicas", Current: "2", Proposed: "3"},
+			{Field: "spec.template.spec.containers[0].image", Current: "nginx:1.23", Proposed: "nginx:1.24"},
+		}},
+		{Resource: "Namespace/monitoring", Action: portainer.StackPreviewCreate},
+	}, changes)
+
+	// a single object is printed without a list, and nothing is printed when no object is deployed
+	changes, err = KubernetesChanges(nil, nil, []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "monitoring"}}`))
+	is.NoError(err)
+	is.Equal([]portainer.StackPreviewChange{{Resource: "Namespace/monitoring", Action: portainer.StackPreviewCreate}}, changes)
+}
+
+func Test_KubernetesChanges_ServerFields(t *testing.T) {
+	is := assert.New(t)
+
+	live := []byte(`{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {
+			"name": "web",
+			"namespace": "default",
+			"annotations": {"deployment.kubernetes.io/revision": "3"}
+		},
+		"spec": {
+			"revisionHistoryLimit": 10,
+			"strategy": {"type": "RollingUpdate", "rollingUpdate": {"maxSurge": "25%"}},
+			"template": {"spec": {"dnsPolicy": "ClusterFirst", "containers": [{"name": "web", "image": "nginx:1.23", "imagePullPolicy": "IfNotPresent"}]}}
+		}
+	}`)
+
+	applied := []byte(`{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {"name": "web", "namespace": "default"},
+		"spec": {"template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.23", "imagePullPolicy": "Always"}]}}}
+	}`)
+
+	changes, err := KubernetesChanges(nil, live, applied)
+	is.NoError(err)
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "Deployment/default/web", Action: portainer.StackPreviewUpdate, Fields: []portainer.StackPreviewFieldChange{
+			{Field: "spec.template.spec.containers[0].imagePullPolicy", Current: "IfNotPresent", Proposed: "Always"},
+		}},
+	}, changes, "the server annotations and the fields only defaulted on one side should be ignored")
+}
+
+func Test_KubernetesChanges_Secrets(t *testing.T) {
+	is := assert.New(t)
+
+	live := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Secret",
+		"metadata": {"name": "credentials", "namespace": "default"},
+		"data": {"password": "b2xk", "token": "dG9rZW4="}
+	}`)
+
+	applied := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Secret",
+		"metadata": {"name": "credentials", "namespace": "default"},
+		"data": {"password": "bmV3", "token": "dG9rZW4=", "username": "YWRtaW4="}
+	}`)
+
+	changes, err := KubernetesChanges(nil, live, applied)
+	is.NoError(err)
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "Secret/default/credentials", Action: portainer.StackPreviewUpdate, Fields: []portainer.StackPreviewFieldChange{
+			{Field: "data.password", Current: redactedValue, Proposed: redactedValue},
+			{Field: "data.username", Current: unsetValue, Proposed: redactedValue},
+		}},
+	}, changes)
+}
+
+func Test_KubernetesChanges_RemovedObjects(t *testing.T) {
+	is := assert.New(t)
+
+	deployed := []byte(`{
+		"apiVersion": "v1",
+		"kind": "List",
+		"items": [
+			{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings", "namespace": "default"}, "data": {"mode": "production"}},
+			{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "legacy", "namespace": "default"}, "data": {"mode": "production"}}
+		]
+	}`)
+
+	live := []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings", "namespace": "default"}, "data": {"mode": "production"}}`)
+
+	changes, err := KubernetesChanges(deployed, live, live)
+	is.NoError(err)
+	is.Equal([]portainer.StackPreviewChange{
+		{Resource: "ConfigMap/default/legacy", Action: portainer.StackPreviewRemove},
+	}, changes)
+}
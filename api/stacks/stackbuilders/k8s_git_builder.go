@@ -56,6 +56,7 @@ func (b *KubernetesStackGitBuilder) SetUniqueInfo(payload *StackPayload) GitMeth
 	b.stack.EntryPoint = payload.ManifestFile
 	b.stack.CreatedBy = b.user.Username
 	b.stack.IsComposeFormat = payload.ComposeFormat
+	b.stack.KubernetesBuild = payload.KubernetesBuild
 	return b
 }
 
@@ -41,6 +41,16 @@ func (b *StackBuilder) SaveStack() (*portainer.Stack, *httperror.HandlerError) {
 	}
 
 	b.doCleanUp = false
+
+	// Kubernetes stacks are deployed by the builders without going through the stack deployer,
+	// the initial version of their history is recorded once they are saved
+	if b.stack.Type == portainer.KubernetesStack {
+		_, err := deployments.RecordStackVersion(b.dataStore, b.fileService, b.stack, b.stack.CreatedBy)
+		if err != nil {
+			log.Warn().Err(err).Int("stack_id", int(b.stack.ID)).Msg("unable to record the stack version")
+		}
+	}
+
 	return b.stack, b.err
 }
 
@@ -9,6 +9,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
 	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackutils"
@@ -59,16 +60,12 @@ func (b *GitMethodStackBuilder) SetGitRepository(payload *StackPayload) GitMetho
 	}
 
 	var repoConfig gittypes.RepoConfig
-	if payload.Authentication {
-		repoConfig.Authentication = &gittypes.GitAuthentication{
-			Username: payload.RepositoryConfigPayload.Username,
-			Password: payload.RepositoryConfigPayload.Password,
-		}
-	}
+	repoConfig.Authentication = payload.CredentialsPayload.Authentication(payload.Authentication, payload.RepositoryConfigPayload.Username, payload.RepositoryConfigPayload.Password)
 
 	repoConfig.URL = payload.URL
 	repoConfig.ReferenceName = payload.ReferenceName
 	repoConfig.TLSSkipVerify = payload.TLSSkipVerify
+	payload.PinningPayload.Apply(&repoConfig)
 
 	repoConfig.ConfigFilePath = payload.ComposeFile
 	if payload.ComposeFile == "" {
@@ -88,6 +85,12 @@ func (b *GitMethodStackBuilder) SetGitRepository(payload *StackPayload) GitMetho
 		return b.fileService.GetStackProjectPath(stackFolder)
 	}
 
+	err := update.ResolveTagConstraint(b.gitService, &repoConfig)
+	if err != nil {
+		b.err = httperror.InternalServerError("Unable to resolve the tag of the git repository", err)
+		return b
+	}
+
 	commitHash, err := stackutils.DownloadGitRepository(repoConfig, b.gitService, getProjectPath)
 	if err != nil {
 		b.err = httperror.InternalServerError(err.Error(), err)
@@ -2,6 +2,7 @@ package stackbuilders
 
 import (
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
 )
 
 // StackPayload contains all the fields for creating a stack with all kinds of methods
@@ -29,6 +30,8 @@ type StackPayload struct {
 	Namespace string
 	// Path to the k8s Stack file. Used by k8s git repository method
 	ManifestFile string
+	// Renders the directory of the k8s Stack file with kustomize or Helm. Used by k8s git repository method
+	KubernetesBuild *portainer.KubernetesBuildConfig
 	// URL to the k8s Stack file. Used by k8s git repository method
 	ManifestURL string
 	// Path to the Stack file inside the Git repository
@@ -54,4 +57,8 @@ type RepositoryConfigPayload struct {
 	Password string `example:"myGitPassword"`
 	// TLSSkipVerify skips SSL verification when cloning the Git repository
 	TLSSkipVerify bool `example:"false"`
+	// SSH and GitHub App credentials
+	git.CredentialsPayload
+	// Tag constraint and trusted keys of the signatures
+	git.PinningPayload
 }
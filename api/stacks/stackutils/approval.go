@@ -0,0 +1,46 @@
+package stackutils
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+const defaultDeploymentApprovalExpiry = 24 * time.Hour
+
+// DeploymentApprovalExpiry returns the duration after which the deployments pending for the approval policy expire
+func DeploymentApprovalExpiry(policy *portainer.DeploymentApprovalPolicy) (time.Duration, error) {
+	if policy.Expiry == "" {
+		return defaultDeploymentApprovalExpiry, nil
+	}
+
+	expiry, err := time.ParseDuration(policy.Expiry)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid expiry")
+	}
+
+	if expiry <= 0 {
+		return 0, errors.New("the expiry must be positive")
+	}
+
+	return expiry, nil
+}
+
+// ValidateDeploymentApprovalPolicy verifies the expiry and the number of approvals of an approval policy
+func ValidateDeploymentApprovalPolicy(policy *portainer.DeploymentApprovalPolicy) error {
+	if policy == nil || !policy.Enabled {
+		return nil
+	}
+
+	if policy.ApproverRoleID == 0 {
+		return errors.New("the approver role is required")
+	}
+
+	if policy.RequiredApprovals < 0 {
+		return errors.New("the number of required approvals can't be negative")
+	}
+
+	_, err := DeploymentApprovalExpiry(policy)
+	return err
+}
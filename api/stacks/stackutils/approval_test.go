@@ -0,0 +1,36 @@
+package stackutils
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeploymentApprovalExpiry(t *testing.T) {
+	is := assert.New(t)
+
+	expiry, err := DeploymentApprovalExpiry(&portainer.DeploymentApprovalPolicy{})
+	is.NoError(err)
+	is.Equal(24*time.Hour, expiry)
+
+	expiry, err = DeploymentApprovalExpiry(&portainer.DeploymentApprovalPolicy{Expiry: "90m"})
+	is.NoError(err)
+	is.Equal(90*time.Minute, expiry)
+
+	_, err = DeploymentApprovalExpiry(&portainer.DeploymentApprovalPolicy{Expiry: "-1h"})
+	is.Error(err)
+}
+
+func Test_ValidateDeploymentApprovalPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateDeploymentApprovalPolicy(nil))
+	is.NoError(ValidateDeploymentApprovalPolicy(&portainer.DeploymentApprovalPolicy{Enabled: false, Expiry: "invalid"}))
+	is.NoError(ValidateDeploymentApprovalPolicy(&portainer.DeploymentApprovalPolicy{Enabled: true, ApproverRoleID: 1, RequiredApprovals: 2, Expiry: "48h"}))
+
+	is.Error(ValidateDeploymentApprovalPolicy(&portainer.DeploymentApprovalPolicy{Enabled: true}), "the approver role is required")
+	is.Error(ValidateDeploymentApprovalPolicy(&portainer.DeploymentApprovalPolicy{Enabled: true, ApproverRoleID: 1, RequiredApprovals: -1}))
+	is.Error(ValidateDeploymentApprovalPolicy(&portainer.DeploymentApprovalPolicy{Enabled: true, ApproverRoleID: 1, Expiry: "tomorrow"}))
+}
@@ -0,0 +1,74 @@
+package stackutils
+
+import (
+	"fmt"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/cli/cli/compose/interpolation"
+	"github.com/docker/cli/cli/compose/loader"
+)
+
+// ParseComposeServices returns the raw definitions of the services of a compose file, indexed by service name.
+// The variables are interpolated with the environment variables of the stack. Both the version 2 and
+// the version 3 of the compose file format are supported.
+func ParseComposeServices(content []byte, env []portainer.Pair) (map[string]map[string]interface{}, error) {
+	composeConfigYAML, err := loader.ParseYAML(content)
+	if err != nil {
+		return nil, err
+	}
+
+	composeConfigYAML, err = interpolation.Interpolate(composeConfigYAML, interpolation.Options{
+		LookupValue: func(key string) (string, bool) {
+			for _, pair := range env {
+				if pair.Name == key {
+					return pair.Value, true
+				}
+			}
+
+			return "", false
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rawServices, _ := composeConfigYAML["services"].(map[string]interface{})
+
+	services := make(map[string]map[string]interface{}, len(rawServices))
+	for name, rawService := range rawServices {
+		service, _ := rawService.(map[string]interface{})
+		if service == nil {
+			service = map[string]interface{}{}
+		}
+
+		services[name] = service
+	}
+
+	return services, nil
+}
+
+// ComposeMapping returns the entries of a compose mapping, defined either as a map or as a list of key=value
+func ComposeMapping(value interface{}) map[string]string {
+	mapping := map[string]string{}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, v := range value {
+			if v == nil {
+				mapping[key] = ""
+				continue
+			}
+
+			mapping[key] = fmt.Sprint(v)
+		}
+	case []interface{}:
+		for _, item := range value {
+			key, v, _ := strings.Cut(fmt.Sprint(item), "=")
+			mapping[key] = v
+		}
+	}
+
+	return mapping
+}
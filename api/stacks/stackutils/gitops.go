@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	portainer "github.com/portainer/portainer/api"
 	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/git/update"
 )
 
 var (
@@ -16,16 +17,16 @@ var (
 
 // DownloadGitRepository downloads the target git repository on the disk
 // The first return value represents the commit hash of the downloaded git repository
+// The repository is only downloaded when its reference is signed by a trusted key if the config requires signatures
 func DownloadGitRepository(config gittypes.RepoConfig, gitService portainer.GitService, getProjectPath func() string) (string, error) {
-	username := ""
-	password := ""
-	if config.Authentication != nil {
-		username = config.Authentication.Username
-		password = config.Authentication.Password
+	verifiedHash, err := update.VerifySignature(gitService, &config)
+	if err != nil {
+		return "", fmt.Errorf("unable to verify the signature of the git repository: %w", err)
 	}
 
+	// the signature is only valid for the cloned files when the reference was not updated in the meantime
 	projectPath := getProjectPath()
-	err := gitService.CloneRepository(projectPath, config.URL, config.ReferenceName, username, password, config.TLSSkipVerify)
+	err = gitService.CloneCommit(projectPath, config.URL, config.ReferenceName, verifiedHash, config.Authentication, config.TLSSkipVerify)
 	if err != nil {
 		if errors.Is(err, gittypes.ErrAuthenticationFailure) {
 			newErr := ErrInvalidGitCredential
@@ -36,10 +37,15 @@ func DownloadGitRepository(config gittypes.RepoConfig, gitService portainer.GitS
 		return "", newErr
 	}
 
-	commitID, err := gitService.LatestCommitID(config.URL, config.ReferenceName, username, password, config.TLSSkipVerify)
+	if verifiedHash != "" {
+		return verifiedHash, nil
+	}
+
+	commitID, err := gitService.LatestCommitID(config.URL, config.ReferenceName, config.Authentication, config.TLSSkipVerify)
 	if err != nil {
 		newErr := fmt.Errorf("unable to fetch git repository id: %w", err)
 		return "", newErr
 	}
+
 	return commitID, nil
 }
@@ -0,0 +1,48 @@
+package stackutils
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// the Helm release names are DNS-1123 labels limited to 53 characters
+var helmReleaseNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,51}[a-z0-9])?$`)
+
+// ValidateKubernetesBuild verifies the build configuration of a Kubernetes git stack
+func ValidateKubernetesBuild(build *portainer.KubernetesBuildConfig, composeFormat bool) error {
+	if build == nil {
+		return nil
+	}
+
+	if composeFormat {
+		return errors.New("Docker Compose files can't be rendered with kustomize or Helm")
+	}
+
+	switch build.Mode {
+	case portainer.KubernetesBuildKustomize:
+		if len(build.ValuesFiles) > 0 || build.ReleaseName != "" {
+			return errors.New("the values files and the release name are only used by the Helm charts")
+		}
+	case portainer.KubernetesBuildHelm:
+		if build.ReleaseName != "" && !helmReleaseNameRegex.MatchString(build.ReleaseName) {
+			return errors.New("invalid Helm release name")
+		}
+	default:
+		return errors.Errorf("invalid build mode %q", build.Mode)
+	}
+
+	return nil
+}
+
+// RenderKubernetesManifest renders the manifests of a Kubernetes git stack with its build configuration,
+// the Helm release is named after the stack unless its name is set
+func RenderKubernetesManifest(stack *portainer.Stack, deployer portainer.KubernetesDeployer) ([]byte, error) {
+	build := *stack.KubernetesBuild
+	if build.ReleaseName == "" {
+		build.ReleaseName = stack.Name
+	}
+
+	return deployer.Render(stack.ProjectPath, stack.EntryPoint, build, stack.Namespace)
+}
@@ -0,0 +1,25 @@
+package stackutils
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateKubernetesBuild(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateKubernetesBuild(nil, true))
+	is.NoError(ValidateKubernetesBuild(&portainer.KubernetesBuildConfig{Mode: portainer.KubernetesBuildKustomize}, false))
+	is.NoError(ValidateKubernetesBuild(&portainer.KubernetesBuildConfig{
+		Mode:        portainer.KubernetesBuildHelm,
+		ValuesFiles: []string{"charts/app/values-production.yaml"},
+		ReleaseName: "app-production",
+	}, false))
+
+	is.Error(ValidateKubernetesBuild(&portainer.KubernetesBuildConfig{Mode: "jsonnet"}, false))
+	is.Error(ValidateKubernetesBuild(&portainer.KubernetesBuildConfig{Mode: portainer.KubernetesBuildKustomize}, true), "the compose files can't be rendered")
+	is.Error(ValidateKubernetesBuild(&portainer.KubernetesBuildConfig{Mode: portainer.KubernetesBuildKustomize, ValuesFiles: []string{"values.yaml"}}, false))
+	is.Error(ValidateKubernetesBuild(&portainer.KubernetesBuildConfig{Mode: portainer.KubernetesBuildHelm, ReleaseName: "App_Production"}, false))
+}
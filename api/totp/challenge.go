@@ -0,0 +1,115 @@
+package totp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	challengeTTL         = 5 * time.Minute
+	maxChallengeAttempts = 5
+)
+
+// Challenge represents the second step of the authentication of a user whose password was verified
+type Challenge struct {
+	UserID              portainer.UserID
+	ForceChangePassword bool
+	expiresAt           time.Time
+	attempts            int
+}
+
+// ChallengeStore keeps the pending second authentication steps in memory, a challenge is removed
+// once it succeeds, expires or after too many failed attempts
+type ChallengeStore struct {
+	mu         sync.Mutex
+	challenges map[string]*Challenge
+}
+
+// NewChallengeStore returns a new instance of ChallengeStore
+func NewChallengeStore() *ChallengeStore {
+	return &ChallengeStore{
+		challenges: map[string]*Challenge{},
+	}
+}
+
+// Create registers a challenge for the user and returns the opaque token identifying it
+func (store *ChallengeStore) Create(userID portainer.UserID, forceChangePassword bool) (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to generate the TOTP challenge")
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	for t, challenge := range store.challenges {
+		if now.After(challenge.expiresAt) {
+			delete(store.challenges, t)
+		}
+	}
+
+	store.challenges[token] = &Challenge{
+		UserID:              userID,
+		ForceChangePassword: forceChangePassword,
+		expiresAt:           now.Add(challengeTTL),
+	}
+
+	return token, nil
+}
+
+// Get returns the challenge identified by the token, if it is still valid
+func (store *ChallengeStore) Get(token string) (Challenge, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	challenge, ok := store.challenges[token]
+	if !ok {
+		return Challenge{}, false
+	}
+
+	if time.Now().After(challenge.expiresAt) {
+		delete(store.challenges, token)
+		return Challenge{}, false
+	}
+
+	return *challenge, true
+}
+
+// Fail records a failed attempt, the challenge is removed after too many attempts
+func (store *ChallengeStore) Fail(token string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	challenge, ok := store.challenges[token]
+	if !ok {
+		return
+	}
+
+	challenge.attempts++
+	if challenge.attempts >= maxChallengeAttempts {
+		delete(store.challenges, token)
+	}
+}
+
+// Remove removes the challenge identified by the token, it returns false when the challenge was already removed
+// so that a challenge completed concurrently is only completed once
+func (store *ChallengeStore) Remove(token string) bool {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	_, ok := store.challenges[token]
+	delete(store.challenges, token)
+
+	return ok
+}
@@ -0,0 +1,240 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Issuer is the issuer displayed by the authenticator applications
+	Issuer = "Portainer"
+
+	period            = 30
+	digits            = 6
+	secretSize        = 20
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 10
+	// number of time steps accepted before and after the current one to tolerate clock drifts
+	skew = 1
+)
+
+var (
+	// ErrTOTPAlreadyEnabled is returned when enrolling a user whose TOTP is already enabled
+	ErrTOTPAlreadyEnabled = errors.New("TOTP is already enabled for this user")
+	// ErrNoPendingEnrolment is returned when activating the TOTP of a user who did not enrol
+	ErrNoPendingEnrolment = errors.New("No pending TOTP enrolment for this user")
+
+	encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// Enrolment represents the details required to configure an authenticator application,
+// the recovery codes are only returned once
+type Enrolment struct {
+	// Base32 encoded secret
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	// otpauth URI, usually rendered as a QR code
+	ProvisioningURI string `json:"provisioningURI" example:"otpauth://totp/Portainer:admin?secret=JBSWY3DPEHPK3PXP&issuer=Portainer"`
+	// Single use codes allowing to authenticate without the authenticator application
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// Enrol generates a new secret and new recovery codes for the user, the TOTP of the user is enabled once
+// a first code generated with the secret is validated
+func Enrol(user *portainer.User, cryptoService portainer.CryptoService) (*Enrolment, error) {
+	if user.TOTP.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		hash, err := cryptoService.Hash(code)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to hash the recovery codes")
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	user.TOTP = portainer.UserTOTP{
+		PendingSecret: secret,
+		RecoveryCodes: hashes,
+	}
+
+	return &Enrolment{
+		Secret:          secret,
+		ProvisioningURI: ProvisioningURI(Issuer, user.Username, secret),
+		RecoveryCodes:   recoveryCodes,
+	}, nil
+}
+
+// Activate enables the TOTP of the user once the code is validated against the secret of the pending enrolment
+func Activate(user *portainer.User, code string, t time.Time) error {
+	if user.TOTP.Enabled || user.TOTP.PendingSecret == "" {
+		return ErrNoPendingEnrolment
+	}
+
+	counter, ok := ValidateCode(user.TOTP.PendingSecret, code, t, 0)
+	if !ok {
+		return errors.New("Invalid TOTP code")
+	}
+
+	user.TOTP.Enabled = true
+	user.TOTP.Secret = user.TOTP.PendingSecret
+	user.TOTP.PendingSecret = ""
+	user.TOTP.LastCounter = counter
+
+	return nil
+}
+
+// Verify validates the code of a user whose TOTP is enabled, the code can either be generated by the
+// authenticator application or one of the recovery codes, which is then consumed. The user must be
+// persisted after a successful verification so the codes cannot be replayed.
+func Verify(user *portainer.User, code string, cryptoService portainer.CryptoService, t time.Time) bool {
+	if !user.TOTP.Enabled {
+		return false
+	}
+
+	code = strings.TrimSpace(code)
+
+	if len(code) == digits {
+		counter, ok := ValidateCode(user.TOTP.Secret, code, t, user.TOTP.LastCounter)
+		if ok {
+			user.TOTP.LastCounter = counter
+		}
+
+		return ok
+	}
+
+	for i, hash := range user.TOTP.RecoveryCodes {
+		if cryptoService.CompareHashAndData(hash, normalizeRecoveryCode(code)) == nil {
+			user.TOTP.RecoveryCodes = append(user.TOTP.RecoveryCodes[:i:i], user.TOTP.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// GenerateSecret returns a new random base32 encoded secret
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to generate the TOTP secret")
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI returns the otpauth URI used by the authenticator applications to configure the generator
+func ProvisioningURI(issuer, accountName, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(period))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(accountName)
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// GenerateCode returns the code of the secret for the time (RFC 6238)
+func GenerateCode(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid TOTP secret")
+	}
+
+	return generateCode(key, t.Unix()/period), nil
+}
+
+// ValidateCode verifies the code against the secret for the time, tolerating a clock drift of one time step.
+// The codes of the time steps up to the last accepted one are rejected. It returns the time step of the code.
+func ValidateCode(secret, code string, t time.Time, lastCounter int64) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+
+	current := t.Unix() / period
+
+	for counter := current - skew; counter <= current+skew; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(generateCode(key, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+func generateCode(key []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation (RFC 4226)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
+
+// GenerateRecoveryCodes returns new random recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeSize)
+
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to generate the recovery codes")
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(b))[:recoveryCodeSize]
+		codes = append(codes, code[:recoveryCodeSize/2]+"-"+code[recoveryCodeSize/2:])
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	if len(code) != recoveryCodeSize {
+		return code
+	}
+
+	return code[:recoveryCodeSize/2] + "-" + code[recoveryCodeSize/2:]
+}
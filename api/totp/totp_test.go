@@ -0,0 +1,106 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 test vectors truncated to 6 digits
+func Test_GenerateCode(t *testing.T) {
+	is := assert.New(t)
+
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for timestamp, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := GenerateCode(secret, time.Unix(timestamp, 0))
+		is.NoError(err)
+		is.Equal(expected, code, "code at %d", timestamp)
+	}
+}
+
+func Test_ValidateCode(t *testing.T) {
+	is := assert.New(t)
+
+	secret, err := GenerateSecret()
+	is.NoError(err)
+
+	now := time.Now()
+	code, err := GenerateCode(secret, now.Add(-period*time.Second))
+	is.NoError(err)
+
+	counter, ok := ValidateCode(secret, code, now, 0)
+	is.True(ok, "the code of the previous time step should be accepted")
+
+	_, ok = ValidateCode(secret, code, now, counter)
+	is.False(ok, "a code cannot be used twice")
+
+	_, ok = ValidateCode(secret, code, now.Add(3*period*time.Second), 0)
+	is.False(ok, "an expired code should be rejected")
+
+	_, ok = ValidateCode(secret, "12345", now, 0)
+	is.False(ok)
+}
+
+func Test_ProvisioningURI(t *testing.T) {
+	is := assert.New(t)
+
+	uri, err := url.Parse(ProvisioningURI(Issuer, "john doe", "JBSWY3DPEHPK3PXP"))
+	is.NoError(err)
+
+	is.Equal("otpauth", uri.Scheme)
+	is.Equal("totp", uri.Host)
+	is.Equal("/Portainer:john doe", uri.Path)
+	is.Equal("JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	is.Equal(Issuer, uri.Query().Get("issuer"))
+}
+
+func Test_EnrolActivateVerify(t *testing.T) {
+	is := assert.New(t)
+
+	cryptoService := &crypto.Service{}
+	user := &portainer.User{Username: "john"}
+
+	enrolment, err := Enrol(user, cryptoService)
+	is.NoError(err)
+	is.Len(enrolment.RecoveryCodes, recoveryCodeCount)
+	is.Len(user.TOTP.RecoveryCodes, recoveryCodeCount)
+	is.False(user.TOTP.Enabled)
+
+	now := time.Now()
+	is.False(Verify(user, enrolment.RecoveryCodes[0], cryptoService, now), "the codes are rejected until the TOTP is enabled")
+
+	is.Error(Activate(user, "000000", now.Add(-time.Hour)))
+
+	code, err := GenerateCode(enrolment.Secret, now)
+	is.NoError(err)
+	is.NoError(Activate(user, code, now))
+	is.True(user.TOTP.Enabled)
+	is.Empty(user.TOTP.PendingSecret)
+
+	is.False(Verify(user, code, cryptoService, now), "the code used for the activation cannot be replayed")
+
+	next, err := GenerateCode(enrolment.Secret, now.Add(period*time.Second))
+	is.NoError(err)
+	is.True(Verify(user, next, cryptoService, now))
+
+	recoveryCode := strings.ToUpper(strings.ReplaceAll(enrolment.RecoveryCodes[1], "-", ""))
+	is.True(Verify(user, recoveryCode, cryptoService, now))
+	is.Len(user.TOTP.RecoveryCodes, recoveryCodeCount-1)
+	is.False(Verify(user, recoveryCode, cryptoService, now), "a recovery code can only be used once")
+
+	_, err = Enrol(user, cryptoService)
+	is.ErrorIs(err, ErrTOTPAlreadyEnabled)
+}
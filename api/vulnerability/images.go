@@ -0,0 +1,132 @@
+package vulnerability
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var variableRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:?-([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ExtractImages returns the images referenced by the "image" keys of a compose file or of Kubernetes manifests,
+// the compose variables are expanded with the environment variables of the stack
+func ExtractImages(content []byte, env []portainer.Pair) ([]string, error) {
+	images := []string{}
+	seen := map[string]bool{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	for {
+		var document yaml.Node
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the stack file")
+		}
+
+		collectImages(&document, func(image string) {
+			image = strings.TrimSpace(expandVariables(image, env))
+			if image != "" && !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		})
+	}
+
+	return images, nil
+}
+
+func collectImages(node *yaml.Node, collect func(string)) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if key.Value == "image" && value.Kind == yaml.ScalarNode {
+				collect(value.Value)
+				continue
+			}
+
+			collectImages(value, collect)
+		}
+
+		return
+	}
+
+	for _, child := range node.Content {
+		collectImages(child, collect)
+	}
+}
+
+// expandVariables replaces the ${VAR}, ${VAR:-default}, ${VAR-default} and $VAR variables
+func expandVariables(value string, env []portainer.Pair) string {
+	lookup := func(name string) (string, bool) {
+		for _, pair := range env {
+			if pair.Name == name {
+				return pair.Value, true
+			}
+		}
+
+		return "", false
+	}
+
+	return variableRe.ReplaceAllStringFunc(value, func(match string) string {
+		groups := variableRe.FindStringSubmatch(match)
+
+		if groups[4] != "" {
+			v, _ := lookup(groups[4])
+			return v
+		}
+
+		v, ok := lookup(groups[1])
+		if groups[2] == "" {
+			return v
+		}
+
+		// ${VAR:-default} applies the default when the variable is unset or empty, ${VAR-default} only when it is unset
+		if !ok || (strings.HasPrefix(groups[2], ":") && v == "") {
+			return groups[3]
+		}
+
+		return v
+	})
+}
+
+// FindImageRegistry returns the registry the image is pulled from, the registry with the most specific URL is
+// returned when several of them match the image
+func FindImageRegistry(image string, registries []portainer.Registry) *portainer.Registry {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil
+	}
+
+	name := named.Name()
+
+	var match *portainer.Registry
+	matchLength := 0
+
+	for i := range registries {
+		registryURL := strings.ToLower(strings.TrimSpace(registries[i].URL))
+		registryURL = strings.TrimPrefix(strings.TrimPrefix(registryURL, "https://"), "http://")
+		registryURL = strings.TrimSuffix(registryURL, "/")
+
+		if registryURL == "" || (name != registryURL && !strings.HasPrefix(name, registryURL+"/")) {
+			continue
+		}
+
+		if len(registryURL) > matchLength {
+			match = &registries[i]
+			matchLength = len(registryURL)
+		}
+	}
+
+	return match
+}
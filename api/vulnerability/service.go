@@ -0,0 +1,347 @@
+package vulnerability
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrImageBlocked is returned when an image has findings of the blocking severity
+var ErrImageBlocked = errors.New("image blocked by the vulnerability policy")
+
+// Service periodically scans the images of the environments(endpoints) and stores the results by image digest.
+// It also scans the images of the stacks before their deployment when the deployments can be blocked.
+type Service struct {
+	dataStore               dataservices.DataStore
+	scheduler               *scheduler.Scheduler
+	kubernetesClientFactory *cli.ClientFactory
+	newScanner              func(settings portainer.VulnerabilityScanSettings) portainer.ImageScanner
+
+	mu    sync.Mutex
+	jobID string
+
+	// the scans of the same image are serialized, the different images are scanned concurrently
+	imageLocksMu sync.Mutex
+	imageLocks   map[string]*imageLock
+}
+
+type imageLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewService returns a new instance of Service scanning the images with the Trivy compatible CLI found at scannerPath
+func NewService(dataStore dataservices.DataStore, scheduler *scheduler.Scheduler, kubernetesClientFactory *cli.ClientFactory, scannerPath string) *Service {
+	return &Service{
+		dataStore:               dataStore,
+		scheduler:               scheduler,
+		kubernetesClientFactory: kubernetesClientFactory,
+		newScanner: func(settings portainer.VulnerabilityScanSettings) portainer.ImageScanner {
+			return NewTrivyScanner(scannerPath, settings)
+		},
+		imageLocks: map[string]*imageLock{},
+	}
+}
+
+// ValidateSettings verifies that the scanning can be scheduled with the settings
+func ValidateSettings(settings portainer.VulnerabilityScanSettings) error {
+	if settings.BlockSeverity != "" && !IsValidSeverity(settings.BlockSeverity) {
+		return errors.New("Invalid blocking severity, must be one of UNKNOWN, LOW, MEDIUM, HIGH or CRITICAL")
+	}
+
+	if settings.ServerURL != "" {
+		u, err := url.ParseRequestURI(settings.ServerURL)
+		if err != nil || u.Host == "" {
+			return errors.New("Invalid scanner server URL")
+		}
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(settings.Interval)
+	if err != nil {
+		return errors.Wrap(err, "Invalid vulnerability scan interval")
+	}
+
+	if interval < time.Minute {
+		return errors.New("Invalid vulnerability scan interval, the minimum is 1m")
+	}
+
+	return nil
+}
+
+// Start schedules the scanning with the saved settings
+func (service *Service) Start() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return service.SetSchedule(settings.VulnerabilityScan)
+}
+
+// SetSchedule replaces the schedule of the scanning
+func (service *Service) SetSchedule(settings portainer.VulnerabilityScanSettings) error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.jobID != "" {
+		err := service.scheduler.StopJob(service.jobID)
+		if err != nil {
+			return err
+		}
+
+		service.jobID = ""
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(settings.Interval)
+	if err != nil {
+		return errors.Wrap(err, "Invalid vulnerability scan interval")
+	}
+
+	service.jobID = service.scheduler.StartJobEvery(interval, func() error {
+		err := service.ScanEndpoints(context.Background())
+		if err != nil {
+			log.Error().Err(err).Msg("image vulnerability scan failed")
+		}
+
+		// a failed scan is retried on the next run
+		return nil
+	})
+
+	return nil
+}
+
+// ScanEndpoints scans the images recorded in the snapshots of the Docker environments(endpoints) and the images
+// running in the Kubernetes environments(endpoints). The images scanned during the last interval are skipped.
+func (service *Service) ScanEndpoints(ctx context.Context) error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the settings")
+	}
+
+	interval, _ := time.ParseDuration(settings.VulnerabilityScan.Interval)
+	freshness := time.Now().Add(-interval).Unix()
+
+	scans, err := service.dataStore.ImageScan().ImageScans()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the image scans")
+	}
+
+	scannedAt := map[string]int64{}
+	for _, scan := range scans {
+		scannedAt[scan.Digest] = scan.ScannedAt
+	}
+
+	images, err := service.endpointImages()
+	if err != nil {
+		return err
+	}
+
+	registries, err := service.dataStore.Registry().Registries()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the registries")
+	}
+
+	for digest, image := range images {
+		if scannedAt[digest] > freshness {
+			continue
+		}
+
+		_, err := service.ScanImage(ctx, image, digest, registries)
+		if err != nil {
+			log.Warn().Err(err).Str("image", image).Str("digest", digest).Msg("unable to scan the image")
+		}
+	}
+
+	return nil
+}
+
+// endpointImages returns the images of the environments(endpoints) to scan, by digest
+func (service *Service) endpointImages() (map[string]string, error) {
+	images := map[string]string{}
+
+	snapshots, err := service.dataStore.Snapshot().Snapshots()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the snapshots")
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.Docker == nil {
+			continue
+		}
+
+		for _, image := range snapshot.Docker.SnapshotRaw.Images {
+			reference := imageReference(image.RepoTags, image.RepoDigests)
+			if image.ID == "" || reference == "" {
+				continue
+			}
+
+			images[image.ID] = reference
+		}
+	}
+
+	if service.kubernetesClientFactory == nil {
+		return images, nil
+	}
+
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the environments")
+	}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if !endpointutils.IsKubernetesEndpoint(endpoint) || endpointutils.IsEdgeEndpoint(endpoint) {
+			continue
+		}
+
+		kubeClient, err := service.kubernetesClientFactory.GetKubeClient(endpoint)
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to create the Kubernetes client")
+			continue
+		}
+
+		workloadImages, err := kubeClient.GetWorkloadImages()
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to list the Kubernetes workload images")
+			continue
+		}
+
+		for _, image := range workloadImages {
+			images[image.Digest] = image.Image
+		}
+	}
+
+	return images, nil
+}
+
+// imageReference returns the reference used to pull an image, the untagged images are referenced by digest
+func imageReference(repoTags, repoDigests []string) string {
+	for _, tag := range repoTags {
+		if tag != "" && tag != "<none>:<none>" {
+			return tag
+		}
+	}
+
+	for _, digest := range repoDigests {
+		if digest != "" && !strings.HasPrefix(digest, "<none>@") {
+			return digest
+		}
+	}
+
+	return ""
+}
+
+// ScanImage scans the image and stores the result by digest. The digest reported by the scanner is used
+// when the digest is empty. The image is pulled with the credentials of the matching registry, if any.
+func (service *Service) ScanImage(ctx context.Context, image, digest string, registries []portainer.Registry) (*portainer.ImageScan, error) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the settings")
+	}
+
+	registry := FindImageRegistry(image, registries)
+	if registry != nil {
+		err = registryutils.EnsureRegTokenValid(service.dataStore, registry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to refresh the token of the registry %s", registry.URL)
+		}
+	}
+
+	unlock := service.lockImage(image)
+	defer unlock()
+
+	scan, err := service.newScanner(settings.VulnerabilityScan).Scan(ctx, image, registry)
+	if err != nil {
+		return nil, err
+	}
+
+	if digest != "" {
+		scan.Digest = digest
+	}
+
+	if scan.Digest == "" {
+		return scan, nil
+	}
+
+	err = service.dataStore.ImageScan().UpdateImageScan(scan)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save the image scan")
+	}
+
+	return scan, nil
+}
+
+// lockImage serializes the scans of the image and returns the function releasing the lock
+func (service *Service) lockImage(image string) func() {
+	service.imageLocksMu.Lock()
+	lock, ok := service.imageLocks[image]
+	if !ok {
+		lock = &imageLock{}
+		service.imageLocks[image] = lock
+	}
+	lock.refs++
+	service.imageLocksMu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		service.imageLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(service.imageLocks, image)
+		}
+		service.imageLocksMu.Unlock()
+	}
+}
+
+// CheckImages scans the images before a deployment and returns an error wrapping ErrImageBlocked when one of them
+// has findings of the blocking severity or higher. An image which cannot be scanned blocks the deployment.
+// The images are pulled with the credentials of the registries the deployment has access to.
+func (service *Service) CheckImages(ctx context.Context, images []string, registries []portainer.Registry) error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the settings")
+	}
+
+	threshold := settings.VulnerabilityScan.BlockSeverity
+	if threshold == "" {
+		return nil
+	}
+
+	for _, image := range images {
+		scan, err := service.ScanImage(ctx, image, "", registries)
+		if err != nil {
+			return errors.Wrapf(err, "unable to verify the vulnerabilities of the image %s", image)
+		}
+
+		count := CountAtOrAbove(scan.Summary, threshold)
+		if count > 0 {
+			return errors.Wrap(ErrImageBlocked, fmt.Sprintf("the image %s has %d vulnerabilities of severity %s or higher", image, count, threshold))
+		}
+	}
+
+	return nil
+}
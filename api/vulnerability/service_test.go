@@ -0,0 +1,258 @@
+package vulnerability
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubScanner is a local stand-in for the Trivy CLI, the report depends on the scanned image
+const stubScanner = `#!/bin/sh
+for image; do :; done
+case "$image" in
+  vulnerable:*)
+    cat <<REPORT
+{"ArtifactName":"$image","Metadata":{"ImageID":"sha256:vulnerable"},"Results":[
+  {"Target":"debian","Vulnerabilities":[
+    {"VulnerabilityID":"CVE-1","PkgName":"openssl","InstalledVersion":"1.0","FixedVersion":"1.1","Severity":"CRITICAL","Title":"critical"},
+    {"VulnerabilityID":"CVE-2","PkgName":"zlib","InstalledVersion":"1.2","Severity":"medium","Title":"medium"}
+  ]}
+]}
+REPORT
+    ;;
+  registry.example.com/private:*)
+    if [ "$TRIVY_USERNAME" != "user" ] || [ "$TRIVY_PASSWORD" != "secret" ]; then
+      echo "unauthorized" >&2
+      exit 1
+    fi
+    echo "{\"ArtifactName\":\"$image\",\"Metadata\":{\"ImageID\":\"sha256:private\"},\"Results\":[]}"
+    ;;
+  clean:*)
+    echo "{\"ArtifactName\":\"$image\",\"Metadata\":{\"ImageID\":\"sha256:clean\"},\"Results\":[{\"Target\":\"alpine\"}]}"
+    ;;
+  *)
+    echo "unknown image $image" >&2
+    exit 1
+    ;;
+esac
+`
+
+func setupService(t *testing.T, settings portainer.VulnerabilityScanSettings) (*Service, *datastore.Store) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(t, true, true)
+	t.Cleanup(teardown)
+
+	scannerPath := filepath.Join(t.TempDir(), "trivy")
+	is.NoError(os.WriteFile(scannerPath, []byte(stubScanner), 0755))
+
+	s, err := store.Settings().Settings()
+	is.NoError(err)
+
+	s.VulnerabilityScan = settings
+	is.NoError(store.Settings().UpdateSettings(s))
+
+	return NewService(store, nil, nil, scannerPath), store
+}
+
+func Test_ScanImage(t *testing.T) {
+	is := assert.New(t)
+
+	service, store := setupService(t, portainer.VulnerabilityScanSettings{})
+
+	scan, err := service.ScanImage(context.Background(), "vulnerable:1.0", "", nil)
+	is.NoError(err)
+	is.Equal("sha256:vulnerable", scan.Digest)
+	is.Equal(portainer.VulnerabilitySummary{Critical: 1, Medium: 1}, scan.Summary)
+	is.Len(scan.Vulnerabilities, 2)
+	is.Equal(portainer.VulnerabilitySeverityMedium, scan.Vulnerabilities[1].Severity)
+
+	stored, err := store.ImageScan().ImageScan("sha256:vulnerable")
+	is.NoError(err)
+	is.Equal("vulnerable:1.0", stored.Image)
+
+	_, err = service.ScanImage(context.Background(), "missing:1.0", "", nil)
+	is.Error(err)
+}
+
+func Test_ScanImage_RegistryCredentials(t *testing.T) {
+	is := assert.New(t)
+
+	service, _ := setupService(t, portainer.VulnerabilityScanSettings{})
+
+	registries := []portainer.Registry{
+		{ID: 1, Type: portainer.CustomRegistry, URL: "registry.example.com", Authentication: true, Username: "user", Password: "secret"},
+	}
+
+	_, err := service.ScanImage(context.Background(), "registry.example.com/private:1.0", "", nil)
+	is.Error(err, "the private image should not be pulled without credentials")
+
+	scan, err := service.ScanImage(context.Background(), "registry.example.com/private:1.0", "", registries)
+	is.NoError(err)
+	is.Equal("sha256:private", scan.Digest)
+}
+
+func Test_FindImageRegistry(t *testing.T) {
+	is := assert.New(t)
+
+	registries := []portainer.Registry{
+		{ID: 1, URL: "docker.io"},
+		{ID: 2, URL: "registry.example.com"},
+		{ID: 3, URL: "https://registry.example.com/team/"},
+	}
+
+	is.Equal(portainer.RegistryID(1), FindImageRegistry("nginx:latest", registries).ID)
+	is.Equal(portainer.RegistryID(2), FindImageRegistry("registry.example.com/app:1.0", registries).ID)
+	is.Equal(portainer.RegistryID(3), FindImageRegistry("registry.example.com/team/app:1.0", registries).ID)
+	is.Nil(FindImageRegistry("quay.io/app:1.0", registries))
+}
+
+func Test_lockImage(t *testing.T) {
+	is := assert.New(t)
+
+	service := NewService(nil, nil, nil, "")
+
+	unlockFirst := service.lockImage("nginx:latest")
+
+	// another image is not held by the scan of the first one
+	unlockOther := service.lockImage("redis:7")
+	unlockOther()
+
+	locked := make(chan struct{})
+	go func() {
+		unlock := service.lockImage("nginx:latest")
+		close(locked)
+		unlock()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("the scans of the same image should be serialized")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlockFirst()
+	<-locked
+
+	is.Eventually(func() bool {
+		service.imageLocksMu.Lock()
+		defer service.imageLocksMu.Unlock()
+
+		return len(service.imageLocks) == 0
+	}, time.Second, 10*time.Millisecond, "the locks should be released")
+}
+
+func Test_ScanEndpoints(t *testing.T) {
+	is := assert.New(t)
+
+	service, store := setupService(t, portainer.VulnerabilityScanSettings{Enabled: true, Interval: "1h"})
+
+	snapshot := &portainer.Snapshot{
+		EndpointID: 1,
+		Docker: &portainer.DockerSnapshot{
+			SnapshotRaw: portainer.DockerSnapshotRaw{
+				Images: []types.ImageSummary{
+					{ID: "sha256:local-clean", RepoTags: []string{"clean:latest"}},
+					{ID: "sha256:local-vulnerable", RepoTags: []string{"<none>:<none>"}, RepoDigests: []string{"vulnerable:1.0@sha256:abc"}},
+					{ID: "sha256:dangling", RepoTags: []string{"<none>:<none>"}},
+				},
+			},
+		},
+	}
+	is.NoError(store.Snapshot().Create(snapshot))
+
+	is.NoError(service.ScanEndpoints(context.Background()))
+
+	scans, err := store.ImageScan().ImageScans()
+	is.NoError(err)
+	is.Len(scans, 2)
+
+	scan, err := store.ImageScan().ImageScan("sha256:local-vulnerable")
+	is.NoError(err)
+	is.Equal(1, scan.Summary.Critical)
+
+	_, err = store.ImageScan().ImageScan("sha256:dangling")
+	is.True(store.IsErrObjectNotFound(err))
+}
+
+func Test_CheckImages(t *testing.T) {
+	is := assert.New(t)
+
+	t.Run("does not block without severity", func(t *testing.T) {
+		service, _ := setupService(t, portainer.VulnerabilityScanSettings{})
+		is.NoError(service.CheckImages(context.Background(), []string{"vulnerable:1.0", "missing:1.0"}, nil))
+	})
+
+	t.Run("blocks findings of the severity or higher", func(t *testing.T) {
+		service, _ := setupService(t, portainer.VulnerabilityScanSettings{BlockSeverity: portainer.VulnerabilitySeverityHigh})
+
+		is.NoError(service.CheckImages(context.Background(), []string{"clean:1.0"}, nil))
+
+		err := service.CheckImages(context.Background(), []string{"clean:1.0", "vulnerable:1.0"}, nil)
+		is.True(errors.Is(err, ErrImageBlocked))
+	})
+
+	t.Run("blocks images which cannot be scanned", func(t *testing.T) {
+		service, _ := setupService(t, portainer.VulnerabilityScanSettings{BlockSeverity: portainer.VulnerabilitySeverityCritical})
+
+		err := service.CheckImages(context.Background(), []string{"missing:1.0"}, nil)
+		is.Error(err)
+		is.False(errors.Is(err, ErrImageBlocked))
+	})
+}
+
+func Test_ExtractImages(t *testing.T) {
+	is := assert.New(t)
+
+	content := []byte(`
+version: "3"
+services:
+  web:
+    image: nginx:${NGINX_TAG:-1.23}
+  db:
+    image: ${DB_IMAGE}
+  worker:
+    build: .
+  cache:
+    image: redis:7
+---
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.23
+      initContainers:
+        - name: init
+          image: busybox
+`)
+
+	images, err := ExtractImages(content, []portainer.Pair{{Name: "DB_IMAGE", Value: "postgres:15"}})
+	is.NoError(err)
+	is.Equal([]string{"nginx:1.23", "postgres:15", "redis:7", "busybox"}, images)
+
+	_, err = ExtractImages([]byte("services: ["), nil)
+	is.Error(err)
+}
+
+func Test_CountAtOrAbove(t *testing.T) {
+	is := assert.New(t)
+
+	summary := portainer.VulnerabilitySummary{Critical: 1, High: 2, Medium: 3, Low: 4, Unknown: 5}
+
+	is.Equal(1, CountAtOrAbove(summary, portainer.VulnerabilitySeverityCritical))
+	is.Equal(3, CountAtOrAbove(summary, portainer.VulnerabilitySeverityHigh))
+	is.Equal(10, CountAtOrAbove(summary, portainer.VulnerabilitySeverityLow))
+	is.Equal(15, CountAtOrAbove(summary, portainer.VulnerabilitySeverityUnknown))
+}
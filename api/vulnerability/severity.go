@@ -0,0 +1,75 @@
+package vulnerability
+
+import (
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+var severityRanks = map[portainer.VulnerabilitySeverity]int{
+	portainer.VulnerabilitySeverityUnknown:  0,
+	portainer.VulnerabilitySeverityLow:      1,
+	portainer.VulnerabilitySeverityMedium:   2,
+	portainer.VulnerabilitySeverityHigh:     3,
+	portainer.VulnerabilitySeverityCritical: 4,
+}
+
+// IsValidSeverity returns true when the severity is one of the known severities
+func IsValidSeverity(severity portainer.VulnerabilitySeverity) bool {
+	_, ok := severityRanks[severity]
+	return ok
+}
+
+// NormalizeSeverity returns the known severity matching the value, or UNKNOWN
+func NormalizeSeverity(value string) portainer.VulnerabilitySeverity {
+	severity := portainer.VulnerabilitySeverity(strings.ToUpper(strings.TrimSpace(value)))
+	if !IsValidSeverity(severity) {
+		return portainer.VulnerabilitySeverityUnknown
+	}
+
+	return severity
+}
+
+// Summarize counts the vulnerabilities by severity
+func Summarize(vulnerabilities []portainer.Vulnerability) portainer.VulnerabilitySummary {
+	summary := portainer.VulnerabilitySummary{}
+
+	for _, v := range vulnerabilities {
+		switch NormalizeSeverity(string(v.Severity)) {
+		case portainer.VulnerabilitySeverityCritical:
+			summary.Critical++
+		case portainer.VulnerabilitySeverityHigh:
+			summary.High++
+		case portainer.VulnerabilitySeverityMedium:
+			summary.Medium++
+		case portainer.VulnerabilitySeverityLow:
+			summary.Low++
+		default:
+			summary.Unknown++
+		}
+	}
+
+	return summary
+}
+
+// CountAtOrAbove returns the number of vulnerabilities of the summary whose severity is at least the threshold
+func CountAtOrAbove(summary portainer.VulnerabilitySummary, threshold portainer.VulnerabilitySeverity) int {
+	counts := map[portainer.VulnerabilitySeverity]int{
+		portainer.VulnerabilitySeverityUnknown:  summary.Unknown,
+		portainer.VulnerabilitySeverityLow:      summary.Low,
+		portainer.VulnerabilitySeverityMedium:   summary.Medium,
+		portainer.VulnerabilitySeverityHigh:     summary.High,
+		portainer.VulnerabilitySeverityCritical: summary.Critical,
+	}
+
+	rank := severityRanks[NormalizeSeverity(string(threshold))]
+
+	count := 0
+	for severity, n := range counts {
+		if severityRanks[severity] >= rank {
+			count += n
+		}
+	}
+
+	return count
+}
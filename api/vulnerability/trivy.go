@@ -0,0 +1,111 @@
+package vulnerability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/registryutils"
+
+	"github.com/pkg/errors"
+)
+
+// TrivyScanner scans the images with a Trivy compatible CLI, either locally or as a client of a Trivy server
+type TrivyScanner struct {
+	path      string
+	serverURL string
+}
+
+type trivyReport struct {
+	ArtifactName string `json:"ArtifactName"`
+	Metadata     struct {
+		ImageID     string   `json:"ImageID"`
+		RepoDigests []string `json:"RepoDigests"`
+	} `json:"Metadata"`
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// NewTrivyScanner returns a new instance of TrivyScanner running the CLI found at the path and configured with the settings
+func NewTrivyScanner(path string, settings portainer.VulnerabilityScanSettings) *TrivyScanner {
+	return &TrivyScanner{
+		path:      path,
+		serverURL: settings.ServerURL,
+	}
+}
+
+// Scan runs the scanner against the image and parses its JSON report. The image is pulled with the credentials
+// of the registry when it is defined and requires an authentication
+func (scanner *TrivyScanner) Scan(ctx context.Context, image string, registry *portainer.Registry) (*portainer.ImageScan, error) {
+	args := []string{"image", "--format", "json", "--quiet"}
+	if scanner.serverURL != "" {
+		args = append(args, "--server", scanner.serverURL)
+	}
+	args = append(args, image)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, scanner.path, args...)
+	cmd.Stderr = &stderr
+
+	if registry != nil && registry.Authentication {
+		username, password, err := registryutils.GetRegEffectiveCredential(registry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve the credentials of the registry %s", registry.URL)
+		}
+
+		cmd.Env = append(os.Environ(), "TRIVY_USERNAME="+username, "TRIVY_PASSWORD="+password)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scan the image %s: %q", image, stderr.String())
+	}
+
+	return parseTrivyReport(image, output)
+}
+
+func parseTrivyReport(image string, output []byte) (*portainer.ImageScan, error) {
+	var report trivyReport
+
+	err := json.Unmarshal(output, &report)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the scanner report")
+	}
+
+	scan := &portainer.ImageScan{
+		Digest:          report.Metadata.ImageID,
+		Image:           image,
+		ScannedAt:       time.Now().Unix(),
+		Vulnerabilities: []portainer.Vulnerability{},
+	}
+
+	for _, result := range report.Results {
+		for _, v := range result.Vulnerabilities {
+			scan.Vulnerabilities = append(scan.Vulnerabilities, portainer.Vulnerability{
+				ID:               v.VulnerabilityID,
+				PkgName:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Severity:         NormalizeSeverity(v.Severity),
+				Title:            v.Title,
+			})
+		}
+	}
+
+	scan.Summary = Summarize(scan.Vulnerabilities)
+
+	return scan, nil
+}
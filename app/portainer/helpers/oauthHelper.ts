@@ -0,0 +1,26 @@
+import { Sha256 } from '@aws-crypto/sha256-js';
+
+function base64URLEncode(bytes: Uint8Array) {
+  return btoa(String.fromCharCode(...bytes))
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=+$/, '');
+}
+
+/**
+ * Generates a random PKCE code verifier (RFC 7636)
+ */
+export function generateCodeVerifier() {
+  const bytes = new Uint8Array(32);
+  window.crypto.getRandomValues(bytes);
+  return base64URLEncode(bytes);
+}
+
+/**
+ * Computes the S256 PKCE code challenge of a code verifier
+ */
+export async function generateCodeChallenge(codeVerifier: string) {
+  const hash = new Sha256();
+  hash.update(codeVerifier);
+  return base64URLEncode(await hash.digest());
+}
@@ -9,6 +9,8 @@ angular.module('portainer.app').factory('Auth', [
       {
         login: { method: 'POST', ignoreLoadingBar: true },
         logout: { method: 'POST', params: { action: 'logout' }, ignoreLoadingBar: true },
+        totp: { method: 'POST', params: { action: 'totp' }, ignoreLoadingBar: true },
+        totpEnrol: { method: 'POST', url: API_ENDPOINT_AUTH + '/totp/enrol', ignoreLoadingBar: true },
       }
     );
   },
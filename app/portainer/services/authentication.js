@@ -22,6 +22,8 @@ angular.module('portainer.app').factory('Authentication', [
     service.init = init;
     service.OAuthLogin = OAuthLogin;
     service.login = login;
+    service.TOTPLogin = TOTPLogin;
+    service.TOTPEnrol = TOTPEnrol;
     service.logout = logout;
     service.isAuthenticated = isAuthenticated;
     service.getUserDetails = getUserDetails;
@@ -50,6 +52,8 @@ angular.module('portainer.app').factory('Authentication', [
       EndpointProvider.clean();
       LocalStorage.cleanAuthData();
       LocalStorage.storeLoginStateUUID('');
+      LocalStorage.storeLoginNonce('');
+      LocalStorage.storeLoginCodeVerifier('');
       tryAutoLoginExtension();
     }
 
@@ -61,8 +65,8 @@ angular.module('portainer.app').factory('Authentication', [
       return $async(initAsync);
     }
 
-    async function OAuthLoginAsync(code) {
-      const response = await OAuth.validate({ code: code }).$promise;
+    async function OAuthLoginAsync(code, codeVerifier, nonce) {
+      const response = await OAuth.validate({ code: code, codeVerifier: codeVerifier, nonce: nonce }).$promise;
       const jwt = setJWTFromResponse(response);
       await setUser(jwt);
     }
@@ -74,20 +78,44 @@ angular.module('portainer.app').factory('Authentication', [
       return response.jwt;
     }
 
-    function OAuthLogin(code) {
-      return $async(OAuthLoginAsync, code);
+    function OAuthLogin(code, codeVerifier, nonce) {
+      return $async(OAuthLoginAsync, code, codeVerifier, nonce);
     }
 
+    // returns the TOTP challenge when a TOTP code is required to complete the login
     async function loginAsync(username, password) {
       const response = await Auth.login({ username: username, password: password }).$promise;
+      if (response.totpToken) {
+        return {
+          token: response.totpToken,
+          required: !!response.totpRequired,
+          enrolmentRequired: !!response.totpEnrolmentRequired,
+        };
+      }
+
       const jwt = setJWTFromResponse(response);
       await setUser(jwt);
+      return null;
     }
 
     function login(username, password) {
       return $async(loginAsync, username, password);
     }
 
+    async function TOTPLoginAsync(token, code) {
+      const response = await Auth.totp({ Token: token, Code: code }).$promise;
+      const jwt = setJWTFromResponse(response);
+      await setUser(jwt);
+    }
+
+    function TOTPLogin(token, code) {
+      return $async(TOTPLoginAsync, token, code);
+    }
+
+    function TOTPEnrol(token) {
+      return Auth.totpEnrol({ Token: token }).$promise;
+    }
+
     function isAuthenticated() {
       var jwt = LocalStorage.getJWT();
       return !!jwt && !jwtHelper.isTokenExpired(jwt);
@@ -8,6 +8,18 @@ angular.module('portainer.app').factory('LocalStorage', [
       getLoginStateUUID: function () {
         return localStorageService.get('LOGIN_STATE_UUID');
       },
+      storeLoginNonce: function (nonce) {
+        localStorageService.set('LOGIN_NONCE', nonce);
+      },
+      getLoginNonce: function () {
+        return localStorageService.get('LOGIN_NONCE');
+      },
+      storeLoginCodeVerifier: function (codeVerifier) {
+        localStorageService.set('LOGIN_CODE_VERIFIER', codeVerifier);
+      },
+      getLoginCodeVerifier: function () {
+        return localStorageService.get('LOGIN_CODE_VERIFIER');
+      },
       storeEndpointState: function (state) {
         localStorageService.set('ENDPOINT_STATE', state);
       },
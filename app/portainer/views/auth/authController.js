@@ -1,5 +1,6 @@
 import angular from 'angular';
 import uuidv4 from 'uuid/v4';
+import { generateCodeChallenge, generateCodeVerifier } from '@/portainer/helpers/oauthHelper';
 import { getEnvironments } from '@/react/portainer/environments/environment.service';
 
 class AuthenticationController {
@@ -39,6 +40,7 @@ class AuthenticationController {
     this.formValues = {
       Username: '',
       Password: '',
+      TOTPCode: '',
     };
     this.state = {
       passwordInputType: 'password',
@@ -47,6 +49,8 @@ class AuthenticationController {
       AuthenticationError: '',
       loginInProgress: true,
       OAuthProvider: '',
+      OAuthPKCE: false,
+      totp: null,
     };
 
     this.checkForEndpointsAsync = this.checkForEndpointsAsync.bind(this);
@@ -56,7 +60,9 @@ class AuthenticationController {
     this.internalLoginAsync = this.internalLoginAsync.bind(this);
 
     this.authenticateUserAsync = this.authenticateUserAsync.bind(this);
+    this.authenticateTOTPAsync = this.authenticateTOTPAsync.bind(this);
 
+    this.generateOAuthLoginURI = this.generateOAuthLoginURI.bind(this);
     this.manageOauthCodeReturn = this.manageOauthCodeReturn.bind(this);
     this.authEnabledFlowAsync = this.authEnabledFlowAsync.bind(this);
     this.onInit = this.onInit.bind(this);
@@ -79,7 +85,7 @@ class AuthenticationController {
   logout(error) {
     this.Authentication.logout();
     this.state.loginInProgress = false;
-    this.generateOAuthLoginURI();
+    this.$async(this.generateOAuthLoginURI);
     this.LocalStorage.storeLogoutReason(error);
     this.$window.location.reload();
   }
@@ -107,11 +113,26 @@ class AuthenticationController {
   generateState() {
     const uuid = uuidv4();
     this.LocalStorage.storeLoginStateUUID(uuid);
-    return '&state=' + uuid;
+    const nonce = uuidv4();
+    this.LocalStorage.storeLoginNonce(nonce);
+    return '&state=' + uuid + '&nonce=' + nonce;
   }
 
-  generateOAuthLoginURI() {
-    this.OAuthLoginURI = this.state.OAuthLoginURI + this.generateState();
+  async generateCodeChallenge() {
+    if (!this.state.OAuthPKCE) {
+      this.LocalStorage.storeLoginCodeVerifier('');
+      return '';
+    }
+
+    const codeVerifier = generateCodeVerifier();
+    this.LocalStorage.storeLoginCodeVerifier(codeVerifier);
+    const codeChallenge = await generateCodeChallenge(codeVerifier);
+    return '&code_challenge=' + codeChallenge + '&code_challenge_method=S256';
+  }
+
+  async generateOAuthLoginURI() {
+    const codeChallenge = await this.generateCodeChallenge();
+    this.OAuthLoginURI = this.state.OAuthLoginURI + this.generateState() + codeChallenge;
   }
 
   hasValidState(state) {
@@ -164,7 +185,7 @@ class AuthenticationController {
 
   async oAuthLoginAsync(code) {
     try {
-      await this.Authentication.OAuthLogin(code);
+      await this.Authentication.OAuthLogin(code, this.LocalStorage.getLoginCodeVerifier(), this.LocalStorage.getLoginNonce());
       this.URLHelper.cleanParameters();
     } catch (err) {
       this.error(err, 'Unable to login via OAuth');
@@ -172,10 +193,27 @@ class AuthenticationController {
   }
 
   async internalLoginAsync(username, password) {
-    await this.Authentication.login(username, password);
+    const challenge = await this.Authentication.login(username, password);
+    if (challenge) {
+      await this.startTOTPStep(challenge);
+      return;
+    }
+
     await this.postLoginSteps();
   }
 
+  // the password was verified, the user must send a TOTP code, after enrolling when the TOTP is enforced
+  async startTOTPStep(challenge) {
+    this.state.totp = { token: challenge.token, enrolment: null };
+    this.formValues.TOTPCode = '';
+
+    if (challenge.enrolmentRequired) {
+      this.state.totp.enrolment = await this.Authentication.TOTPEnrol(challenge.token);
+    }
+
+    this.state.loginInProgress = false;
+  }
+
   /**
    * END LOGIN METHODS SECTION
    */
@@ -200,6 +238,28 @@ class AuthenticationController {
     return this.$async(this.authenticateUserAsync);
   }
 
+  async authenticateTOTPAsync() {
+    try {
+      this.state.loginInProgress = true;
+      await this.Authentication.TOTPLogin(this.state.totp.token, this.formValues.TOTPCode);
+      this.state.totp = null;
+      await this.postLoginSteps();
+    } catch (err) {
+      this.error(err, 'Invalid TOTP code');
+    }
+  }
+
+  authenticateTOTP() {
+    return this.$async(this.authenticateTOTPAsync);
+  }
+
+  cancelTOTP() {
+    this.state.totp = null;
+    this.formValues.Password = '';
+    this.formValues.TOTPCode = '';
+    this.state.AuthenticationError = '';
+  }
+
   /**
    * END AUTHENTICATE USER SECTION
    */
@@ -236,20 +296,21 @@ class AuthenticationController {
       this.state.showOAuthLogin = settings.AuthenticationMethod === 3;
       this.state.showStandardLogin = !this.state.showOAuthLogin;
       this.state.OAuthLoginURI = settings.OAuthLoginURI;
+      this.state.OAuthPKCE = settings.OAuthPKCE;
       this.state.OAuthProvider = this.determineOauthProvider(settings.OAuthLoginURI);
 
       const code = this.URLHelper.getParameter('code');
       const state = this.URLHelper.getParameter('state');
       if (code && state) {
         await this.manageOauthCodeReturn(code, state);
-        this.generateOAuthLoginURI();
+        await this.generateOAuthLoginURI();
         return;
       }
       if (!this.logo) {
         await this.StateManager.initialize();
         this.logo = this.StateManager.getState().application.logo;
       }
-      this.generateOAuthLoginURI();
+      await this.generateOAuthLoginURI();
 
       if (this.$stateParams.logout || this.$stateParams.error) {
         this.logout(this.$stateParams.error);
@@ -168,6 +168,8 @@ export interface PublicSettingsResponse {
   OAuthLoginURI: string;
   /** The URL used for oauth logout */
   OAuthLogoutURI: string;
+  /** Whether the oauth login is protected with PKCE */
+  OAuthPKCE: boolean;
   /** Whether portainer internal auth view will be hidden (only on BE) */
   OAuthHideInternalAuth: boolean;
   /** Whether telemetry is enabled */